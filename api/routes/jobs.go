@@ -0,0 +1,59 @@
+package routes
+
+import (
+	"log/slog"
+	"warehouse_system/internal/cache"
+	"warehouse_system/internal/config"
+	"warehouse_system/internal/database/db"
+	"warehouse_system/internal/handlers"
+	"warehouse_system/internal/handlers/pos"
+	"warehouse_system/internal/handlers/transactions"
+	"warehouse_system/internal/jobs"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// SetupJobs registers all background job handlers and their schedules
+func SetupJobs(registry *jobs.Registry, scheduler *jobs.Scheduler, db *pgxpool.Pool, q *db.Queries, logger *slog.Logger, cache cache.Cache, cfg *config.Config) {
+	h := handlers.NewHandler(q, cache, logger, db, cfg)
+	// purchase orders handler
+	posHandler := pos.NewPOSHandler(h)
+	// stock transactions handler
+	transactionsHandler := transactions.NewTransactionHandler(h)
+
+	// Replenishment suggestions from reorder points
+	registry.RegisterFunc(pos.ReplenishmentJobType, posHandler.ReplenishmentJob)
+	if cfg.Inventory.ReplenishmentInterval > 0 {
+		scheduler.Register(&jobs.CronJob{
+			ID:       "replenishment",
+			Schedule: jobs.Every(cfg.Inventory.ReplenishmentInterval),
+			JobType:  pos.ReplenishmentJobType,
+			Config:   &jobs.JobConfig{MaxRetries: 1, RetryBackoff: jobs.ExponentialBackoff},
+			Enabled:  true,
+		})
+	}
+
+	// Expiry flags and holds on expired batches
+	registry.RegisterFunc(transactions.ExpiryJobType, transactionsHandler.ExpiryJob)
+	if cfg.Inventory.ExpiryCheckInterval > 0 {
+		scheduler.Register(&jobs.CronJob{
+			ID:       "batch-expiry",
+			Schedule: jobs.Every(cfg.Inventory.ExpiryCheckInterval),
+			JobType:  transactions.ExpiryJobType,
+			Config:   &jobs.JobConfig{MaxRetries: 1, RetryBackoff: jobs.ExponentialBackoff},
+			Enabled:  true,
+		})
+	}
+
+	// Idempotency keys past their 24 hour replay window
+	registry.RegisterFunc(transactions.IdempotencyCleanupJobType, transactionsHandler.IdempotencyCleanupJob)
+	if cfg.Inventory.IdempotencyCleanupInterval > 0 {
+		scheduler.Register(&jobs.CronJob{
+			ID:       "idempotency-cleanup",
+			Schedule: jobs.Every(cfg.Inventory.IdempotencyCleanupInterval),
+			JobType:  transactions.IdempotencyCleanupJobType,
+			Config:   &jobs.JobConfig{MaxRetries: 1, RetryBackoff: jobs.ExponentialBackoff},
+			Enabled:  true,
+		})
+	}
+}
@@ -13,12 +13,14 @@ import (
 	"warehouse_system/internal/handlers/materials"
 	"warehouse_system/internal/handlers/pos"
 	"warehouse_system/internal/handlers/quality"
+	"warehouse_system/internal/handlers/reports"
 	"warehouse_system/internal/handlers/sales"
 	"warehouse_system/internal/handlers/suppliers"
 	"warehouse_system/internal/handlers/transactions"
 	"warehouse_system/internal/handlers/units"
 	"warehouse_system/internal/handlers/users"
 	"warehouse_system/internal/handlers/warehouses"
+	"warehouse_system/internal/middlewares"
 	"warehouse_system/internal/router"
 	"warehouse_system/web/views"
 
@@ -70,6 +72,10 @@ func ApiRoutes(r *router.RouterImpl, db *pgxpool.Pool, q *db.Queries, logger *sl
 	qualityHandler := quality.NewQualityHandler(h)
 	// laboratory handler
 	labHandler := laboratory.NewLaboratoryHandler(h)
+	// reports handler
+	reportsHandler := reports.NewReportHandler(h)
+	// stock-outs lock batches, a deadlock or serialization failure reruns the request
+	txRetry := middlewares.TxRetry(&middlewares.TxRetryConfig{Logger: logger})
 
 	// Authentication routes
 	r.Register(&router.Route{
@@ -681,19 +687,22 @@ func ApiRoutes(r *router.RouterImpl, db *pgxpool.Pool, q *db.Queries, logger *sl
 		Input: &router.RouteInput{
 			RequiredAuth: true,
 			Body: map[string]string{
-				"name":            "string (required) - Material name",
-				"description":     "string (optional) - Material description",
-				"type":            "string (required) - Type: raw, intermediate, finished, consumable, service",
-				"code":            "string (required) - Unique material code",
-				"sku":             "string (required) - Unique SKU",
-				"barcode":         "string (optional) - Barcode",
-				"saleable":        "boolean (optional, default: true) - Is material saleable",
-				"unit_price":      "float64 (optional) - Unit price",
-				"sale_price":      "float64 (optional) - Sale price",
-				"category":        "int32 (optional) - Category ID",
-				"measure_unit_id": "int32 (optional) - Measurement unit ID",
-				"weight":          "float64 (optional) - Weight",
-				"is_active":       "boolean (optional, default: true) - Is material active",
+				"name":                "string (required) - Material name",
+				"description":         "string (optional) - Material description",
+				"type":                "string (required) - Type: raw, intermediate, finished, consumable, service",
+				"code":                "string (required) - Unique material code",
+				"sku":                 "string (required) - Unique SKU",
+				"barcode":             "string (optional) - Barcode",
+				"saleable":            "boolean (optional, default: true) - Is material saleable",
+				"unit_price":          "float64 (optional) - Unit price",
+				"sale_price":          "float64 (optional) - Sale price",
+				"category":            "int32 (optional) - Category ID",
+				"measure_unit_id":     "int32 (optional) - Measurement unit ID",
+				"weight":              "float64 (optional) - Weight",
+				"is_active":           "boolean (optional, default: true) - Is material active",
+				"is_serialized":       "boolean (optional, default: false) - Track each unit by serial number",
+				"valuation":           "string (optional) - Valuation method: FIFO, LIFO, Weighted Average (default: the warehouse's)",
+				"allocation_strategy": "string (optional) - Order batches are drawn from: FIFO, LIFO, FEFO (default: the warehouse's)",
 			},
 		},
 		Response: map[string]any{
@@ -702,7 +711,7 @@ func ApiRoutes(r *router.RouterImpl, db *pgxpool.Pool, q *db.Queries, logger *sl
 				"body":   "Material object with all fields",
 			},
 			"error": map[string]any{
-				"400": map[string]string{"error": "Name is required | Code is required | SKU is required | Type is required"},
+				"400": map[string]string{"error": "Name is required | Code is required | SKU is required | Type is required | Invalid valuation method | Invalid allocation strategy"},
 				"401": map[string]string{"error": "Unauthorized - Authentication required"},
 				"409": map[string]string{"error": "Material code already exists | Material SKU already exists"},
 				"500": map[string]string{"error": "Internal server error"},
@@ -777,7 +786,7 @@ func ApiRoutes(r *router.RouterImpl, db *pgxpool.Pool, q *db.Queries, logger *sl
 				"id": "int32 (required) - Material ID to update",
 			},
 			Body: map[string]string{
-				"description": "All fields are optional - send only fields to update",
+				"description": "All fields are optional - send only fields to update. valuation is FIFO, LIFO or Weighted Average, allocation_strategy FIFO, LIFO or FEFO, an empty allocation_strategy drops the override so the material follows its warehouse. is_serialized cannot change while the material has stock or stock in transit",
 			},
 		},
 		Response: map[string]any{
@@ -789,7 +798,7 @@ func ApiRoutes(r *router.RouterImpl, db *pgxpool.Pool, q *db.Queries, logger *sl
 				"400": map[string]string{"error": "Invalid material ID | Invalid request payload"},
 				"401": map[string]string{"error": "Unauthorized - Authentication required"},
 				"404": map[string]string{"error": "Material not found"},
-				"409": map[string]string{"error": "Material code already exists | Material SKU already exists"},
+				"409": map[string]string{"error": "Material code already exists | Material SKU already exists | Serialization cannot change while the material has stock"},
 				"500": map[string]string{"error": "Internal server error"},
 			},
 		},
@@ -879,35 +888,37 @@ func ApiRoutes(r *router.RouterImpl, db *pgxpool.Pool, q *db.Queries, logger *sl
 		Input: &router.RouteInput{
 			RequiredAuth: true,
 			Body: map[string]string{
-				"name":             "string (required) - Warehouse name",
-				"code":             "string (required) - Unique warehouse code",
-				"location":         "string (optional) - Warehouse location address",
-				"description":      "string (optional) - Warehouse description",
-				"valuation":        "string (optional) - Valuation method: FIFO, LIFO, Weighted Average",
-				"parent_warehouse": "int32 (optional) - Parent warehouse ID for hierarchical structure",
-				"capacity":         "float64 (optional) - Warehouse capacity",
-				"meta":             "json (optional) - Additional metadata",
+				"name":                "string (required) - Warehouse name",
+				"code":                "string (required) - Unique warehouse code",
+				"location":            "string (optional) - Warehouse location address",
+				"description":         "string (optional) - Warehouse description",
+				"valuation":           "string (optional) - Valuation method: FIFO, LIFO, Weighted Average",
+				"allocation_strategy": "string (optional) - Order batches are drawn from: FIFO, LIFO, FEFO (default: FIFO)",
+				"parent_warehouse":    "int32 (optional) - Parent warehouse ID for hierarchical structure",
+				"capacity":            "float64 (optional) - Warehouse capacity",
+				"meta":                "json (optional) - Additional metadata",
 			},
 		},
 		Response: map[string]any{
 			"success": map[string]any{
 				"status": 201,
 				"body": map[string]any{
-					"id":               "int32",
-					"name":             "string",
-					"code":             "string",
-					"location":         "string",
-					"description":      "string",
-					"valuation":        "string",
-					"parent_warehouse": "int32",
-					"capacity":         "decimal",
-					"meta":             "json",
-					"created_at":       "timestamp",
-					"updated_at":       "timestamp",
+					"id":                  "int32",
+					"name":                "string",
+					"code":                "string",
+					"location":            "string",
+					"description":         "string",
+					"valuation":           "string",
+					"allocation_strategy": "string",
+					"parent_warehouse":    "int32",
+					"capacity":            "decimal",
+					"meta":                "json",
+					"created_at":          "timestamp",
+					"updated_at":          "timestamp",
 				},
 			},
 			"error": map[string]any{
-				"400": map[string]string{"error": "Invalid request payload | Missing required fields"},
+				"400": map[string]string{"error": "Invalid request payload | Missing required fields | Invalid valuation method | Invalid allocation strategy"},
 				"401": map[string]string{"error": "Unauthorized - Authentication required"},
 				"409": map[string]string{"error": "Warehouse code already exists | Warehouse name already exists"},
 				"500": map[string]string{"error": "Internal server error"},
@@ -931,17 +942,18 @@ func ApiRoutes(r *router.RouterImpl, db *pgxpool.Pool, q *db.Queries, logger *sl
 			"success": map[string]any{
 				"status": 200,
 				"body": map[string]any{
-					"id":               "int32",
-					"name":             "string",
-					"code":             "string",
-					"location":         "string",
-					"description":      "string",
-					"valuation":        "string",
-					"parent_warehouse": "int32",
-					"capacity":         "decimal",
-					"meta":             "json",
-					"created_at":       "timestamp",
-					"updated_at":       "timestamp",
+					"id":                  "int32",
+					"name":                "string",
+					"code":                "string",
+					"location":            "string",
+					"description":         "string",
+					"valuation":           "string",
+					"allocation_strategy": "string",
+					"parent_warehouse":    "int32",
+					"capacity":            "decimal",
+					"meta":                "json",
+					"created_at":          "timestamp",
+					"updated_at":          "timestamp",
 				},
 			},
 			"error": map[string]any{
@@ -964,31 +976,33 @@ func ApiRoutes(r *router.RouterImpl, db *pgxpool.Pool, q *db.Queries, logger *sl
 				"id": "int32 (required) - Warehouse ID",
 			},
 			Body: map[string]string{
-				"name":             "string (optional) - Warehouse name",
-				"code":             "string (optional) - Unique warehouse code",
-				"location":         "string (optional) - Warehouse location address",
-				"description":      "string (optional) - Warehouse description",
-				"valuation":        "string (optional) - Valuation method: FIFO, LIFO, Weighted Average",
-				"parent_warehouse": "int32 (optional) - Parent warehouse ID",
-				"capacity":         "float64 (optional) - Warehouse capacity",
-				"meta":             "json (optional) - Additional metadata",
+				"name":                "string (optional) - Warehouse name",
+				"code":                "string (optional) - Unique warehouse code",
+				"location":            "string (optional) - Warehouse location address",
+				"description":         "string (optional) - Warehouse description",
+				"valuation":           "string (optional) - Valuation method: FIFO, LIFO, Weighted Average",
+				"allocation_strategy": "string (optional) - Order batches are drawn from: FIFO, LIFO, FEFO",
+				"parent_warehouse":    "int32 (optional) - Parent warehouse ID",
+				"capacity":            "float64 (optional) - Warehouse capacity",
+				"meta":                "json (optional) - Additional metadata",
 			},
 		},
 		Response: map[string]any{
 			"success": map[string]any{
 				"status": 200,
 				"body": map[string]any{
-					"id":               "int32",
-					"name":             "string",
-					"code":             "string",
-					"location":         "string",
-					"description":      "string",
-					"valuation":        "string",
-					"parent_warehouse": "int32",
-					"capacity":         "decimal",
-					"meta":             "json",
-					"created_at":       "timestamp",
-					"updated_at":       "timestamp",
+					"id":                  "int32",
+					"name":                "string",
+					"code":                "string",
+					"location":            "string",
+					"description":         "string",
+					"valuation":           "string",
+					"allocation_strategy": "string",
+					"parent_warehouse":    "int32",
+					"capacity":            "decimal",
+					"meta":                "json",
+					"created_at":          "timestamp",
+					"updated_at":          "timestamp",
 				},
 			},
 			"error": map[string]any{
@@ -1061,6 +1075,194 @@ func ApiRoutes(r *router.RouterImpl, db *pgxpool.Pool, q *db.Queries, logger *sl
 		},
 	})
 
+	// Create Warehouse Bin
+	r.Register(&router.Route{
+		Method:      "POST",
+		Path:        "/warehouses/{id}/bins",
+		HandlerFunc: warehousesHandler.CreateBin,
+		Category:    "warehouses",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			PathParameters: map[string]string{
+				"id": "int32 (required) - Warehouse ID",
+			},
+			Body: map[string]string{
+				"code":     "string (required) - Bin code, unique inside the warehouse (e.g. A-01-03)",
+				"name":     "string (optional) - Bin name",
+				"bin_type": "string (optional, default: Bin) - Shelf, Bin, Pallet, Floor",
+				"zone":     "string (optional) - Aisle or area the bin belongs to",
+				"capacity": "float64 (optional) - Maximum quantity the bin holds, empty = unlimited",
+				"notes":    "string (optional) - Additional notes",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 201,
+				"body":   "Created bin object",
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "Invalid request payload | Missing required fields | Invalid bin type"},
+				"401": map[string]string{"error": "Unauthorized - Authentication required"},
+				"404": map[string]string{"error": "Warehouse not found"},
+				"409": map[string]string{"error": "Bin code already exists in this warehouse"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// List Warehouse Bins
+	r.Register(&router.Route{
+		Method:      "GET",
+		Path:        "/warehouses/{id}/bins",
+		HandlerFunc: warehousesHandler.ListBins,
+		Category:    "warehouses",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			PathParameters: map[string]string{
+				"id": "int32 (required) - Warehouse ID",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body":   "Array of bins ordered by zone and code, with occupied_quantity and material_count",
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "Invalid warehouse ID format"},
+				"401": map[string]string{"error": "Unauthorized - Authentication required"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Suggest Putaway Bins
+	r.Register(&router.Route{
+		Method:      "GET",
+		Path:        "/warehouses/{id}/bins/putaway",
+		HandlerFunc: warehousesHandler.SuggestPutaway,
+		Category:    "warehouses",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			PathParameters: map[string]string{
+				"id": "int32 (required) - Warehouse ID",
+			},
+			QueryParameters: map[string]string{
+				"material_id": "int32 (required) - Material to put away",
+				"quantity":    "float64 (required) - Quantity to put away",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body": map[string]any{
+					"warehouse_id":      "int32",
+					"material_id":       "int32",
+					"quantity":          "float64",
+					"suggestions":       "Array of {bin_id, code, zone, free_capacity, suggested_quantity, reason}: bins already holding the material first, then empty bins, then bins with other materials",
+					"unplaced_quantity": "float64 - Quantity no active bin has room for",
+				},
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "Invalid warehouse ID format | Invalid material_id | Invalid quantity"},
+				"401": map[string]string{"error": "Unauthorized - Authentication required"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Get Warehouse Bin
+	r.Register(&router.Route{
+		Method:      "GET",
+		Path:        "/warehouses/{id}/bins/{bin_id}",
+		HandlerFunc: warehousesHandler.GetBin,
+		Category:    "warehouses",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			PathParameters: map[string]string{
+				"id":     "int32 (required) - Warehouse ID",
+				"bin_id": "int32 (required) - Bin ID",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body":   "Bin object",
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "Invalid warehouse ID format | Invalid bin ID format"},
+				"401": map[string]string{"error": "Unauthorized - Authentication required"},
+				"404": map[string]string{"error": "Bin not found"},
+			},
+		},
+	})
+
+	// Update Warehouse Bin
+	r.Register(&router.Route{
+		Method:      "PUT",
+		Path:        "/warehouses/{id}/bins/{bin_id}",
+		HandlerFunc: warehousesHandler.UpdateBin,
+		Category:    "warehouses",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			PathParameters: map[string]string{
+				"id":     "int32 (required) - Warehouse ID",
+				"bin_id": "int32 (required) - Bin ID",
+			},
+			Body: map[string]string{
+				"code":      "string (optional) - Bin code",
+				"name":      "string (optional) - Bin name",
+				"bin_type":  "string (optional) - Shelf, Bin, Pallet, Floor",
+				"zone":      "string (optional) - Aisle or area",
+				"capacity":  "float64 (optional) - Maximum quantity the bin holds",
+				"is_active": "bool (optional) - Inactive bins keep their stock but take no new stock",
+				"notes":     "string (optional) - Additional notes",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body":   "Updated bin object",
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "Invalid request payload | Invalid bin type | Invalid capacity"},
+				"401": map[string]string{"error": "Unauthorized - Authentication required"},
+				"404": map[string]string{"error": "Bin not found"},
+				"409": map[string]string{"error": "Bin code already exists in this warehouse"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Delete Warehouse Bin
+	r.Register(&router.Route{
+		Method:      "DELETE",
+		Path:        "/warehouses/{id}/bins/{bin_id}",
+		HandlerFunc: warehousesHandler.DeleteBin,
+		Category:    "warehouses",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			PathParameters: map[string]string{
+				"id":     "int32 (required) - Warehouse ID",
+				"bin_id": "int32 (required) - Bin ID",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body": map[string]string{
+					"message": "Bin deleted successfully",
+				},
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "Invalid warehouse ID format | Invalid bin ID format"},
+				"401": map[string]string{"error": "Unauthorized - Authentication required"},
+				"404": map[string]string{"error": "Bin not found"},
+				"409": map[string]string{"error": "Bin has stored batches, deactivate it instead"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
 	// ============================================================================
 	// SUPPLIER ROUTES
 	// ============================================================================
@@ -1429,7 +1631,7 @@ func ApiRoutes(r *router.RouterImpl, db *pgxpool.Pool, q *db.Queries, logger *sl
 				"supplier_id":            "int32 (optional) - Supplier ID",
 				"order_date":             "timestamp (optional) - Order date (defaults to now)",
 				"expected_delivery_date": "timestamp (optional) - Expected delivery date",
-				"status":                 "string (optional) - Order status (Pending, Approved, Received, Cancelled, Partial)",
+				"status":                 "string (optional) - Order status (Pending, Approved, PartiallyReceived, Received, Cancelled, Partial). PartiallyReceived and Received are set automatically by purchase receipts",
 				"items":                  "array (required) - Array of order items with material_id, quantity, unit_price, received_quantity",
 				"meta":                   "object (optional) - Additional metadata as JSON",
 			},
@@ -1605,6 +1807,36 @@ func ApiRoutes(r *router.RouterImpl, db *pgxpool.Pool, q *db.Queries, logger *sl
 		},
 	})
 
+	// Get Purchase Order Receipts
+	r.Register(&router.Route{
+		Method:      "GET",
+		Path:        "/purchase-orders/{id}/receipts",
+		HandlerFunc: posHandler.GetPurchaseOrderReceipts,
+		Category:    "purchase_orders",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			PathParameters: map[string]string{
+				"id": "int32 (required) - Purchase order ID",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body": map[string]any{
+					"purchase_order": "purchase order object",
+					"lines":          "array of purchase order items with remaining_quantity",
+					"receipts":       "array of purchase receipts (movement_id, purchase_order_item_id, material, warehouse, batch, quantity, unit_cost, movement_date, performed_by_username), newest first",
+				},
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "Missing purchase order ID | Invalid purchase order ID format"},
+				"401": map[string]string{"error": "Unauthorized - Authentication required"},
+				"404": map[string]string{"error": "Purchase order not found"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
 	// Add Item to Purchase Order
 	r.Register(&router.Route{
 		Method:      "POST",
@@ -1699,198 +1931,399 @@ func ApiRoutes(r *router.RouterImpl, db *pgxpool.Pool, q *db.Queries, logger *sl
 		},
 	})
 
-	// ______________________________Sales Orders_______________________________________________
-	// Create Sales Order
+	// ______________________________replenishment_______________________________________________
+	// Set Planning Parameters
 	r.Register(&router.Route{
-		Method:      "POST",
-		Path:        "/sales-orders",
-		HandlerFunc: salesHandler.CreateSalesOrder,
-		Category:    "sales_orders",
+		Method:      "PUT",
+		Path:        "/planning-parameters",
+		HandlerFunc: posHandler.SetPlanningParameters,
+		Category:    "purchase_orders",
 		Input: &router.RouteInput{
 			RequiredAuth: true,
 			Body: map[string]string{
-				"order_number":           "string (required) - Unique order number",
-				"customer_id":            "int32 (required) - Customer ID",
-				"order_date":             "string (optional) - Order date (ISO format: 2026-01-27 or 2026-01-27T10:30:00)",
-				"expected_delivery_date": "string (optional) - Expected delivery date",
-				"status":                 "string (optional) - Status: Pending, Approved, Shipped, Cancelled, Partial (default: Pending)",
-				"items":                  "array (required) - Array of items with material_id, quantity, unit_price, shipped_quantity",
-				"meta":                   "object (optional) - Additional metadata",
+				"material_id":           "int32 (required) - Material ID",
+				"warehouse_id":          "int32 (required) - Warehouse ID",
+				"min_quantity":          "float (optional) - Minimum stock, an order always tops up to it (default: 0)",
+				"max_quantity":          "float (optional) - Maximum stock, ordered up to when reorder_quantity is 0",
+				"reorder_point":         "float (optional) - Order when on hand plus on order falls to this quantity, 0 = use min_quantity",
+				"reorder_quantity":      "float (optional) - Quantity to order, 0 = order up to max_quantity. One of reorder_quantity and max_quantity is required",
+				"preferred_supplier_id": "int32 (optional) - Supplier the suggestions are ordered from",
+				"is_active":             "bool (optional) - Include in replenishment runs (default: true)",
 			},
 		},
 		Response: map[string]any{
 			"success": map[string]any{
-				"status": 201,
-				"body": map[string]any{
-					"sales_order": "Sales order object",
-					"items":       "Array of created items",
-				},
+				"status": 200,
+				"body":   "Planning parameters object. Parameters of the same material and warehouse are replaced",
 			},
 			"error": map[string]any{
-				"400": map[string]string{"error": "Invalid request payload | Missing required fields | Invalid status | Invalid item data | Invalid dates"},
+				"400": map[string]string{"error": "Invalid request payload | Missing required fields | Invalid planning parameters"},
 				"401": map[string]string{"error": "Unauthorized - Authentication required"},
-				"409": map[string]string{"error": "Sales order number already exists"},
 				"500": map[string]string{"error": "Internal server error"},
 			},
 		},
 	})
 
-	// Get Sales Order by ID
+	// List Planning Parameters
 	r.Register(&router.Route{
 		Method:      "GET",
-		Path:        "/sales-orders/{id}",
-		HandlerFunc: salesHandler.GetSalesOrder,
-		Category:    "sales_orders",
+		Path:        "/planning-parameters",
+		HandlerFunc: posHandler.ListPlanningParameters,
+		Category:    "purchase_orders",
 		Input: &router.RouteInput{
 			RequiredAuth: true,
-			PathParameters: map[string]string{
-				"id": "int32 (required) - Sales order ID",
+			QueryParameters: map[string]string{
+				"material_id":  "int32 (optional) - Only this material",
+				"warehouse_id": "int32 (optional) - Only this warehouse",
 			},
 		},
 		Response: map[string]any{
 			"success": map[string]any{
 				"status": 200,
 				"body": map[string]any{
-					"sales_order": "Sales order object",
-					"items":       "Array of order items",
+					"planning_parameters": "array of planning parameters with material, warehouse and preferred supplier names",
 				},
 			},
 			"error": map[string]any{
-				"400": map[string]string{"error": "Missing sales order ID | Invalid sales order ID format"},
+				"400": map[string]string{"error": "Invalid material_id | Invalid warehouse_id"},
 				"401": map[string]string{"error": "Unauthorized - Authentication required"},
-				"404": map[string]string{"error": "Sales order not found"},
 				"500": map[string]string{"error": "Internal server error"},
 			},
 		},
 	})
 
-	// Update Sales Order
+	// Delete Planning Parameters
 	r.Register(&router.Route{
-		Method:      "PUT",
-		Path:        "/sales-orders/{id}",
-		HandlerFunc: salesHandler.UpdateSalesOrder,
-		Category:    "sales_orders",
+		Method:      "DELETE",
+		Path:        "/planning-parameters/{id}",
+		HandlerFunc: posHandler.DeletePlanningParameters,
+		Category:    "purchase_orders",
 		Input: &router.RouteInput{
 			RequiredAuth: true,
 			PathParameters: map[string]string{
-				"id": "int32 (required) - Sales order ID",
-			},
-			Body: map[string]string{
-				"order_number":           "string (optional) - New order number",
-				"customer_id":            "int32 (optional) - New customer ID",
-				"order_date":             "string (optional) - New order date",
-				"expected_delivery_date": "string (optional) - New expected delivery date",
-				"status":                 "string (optional) - New status",
-				"approved_by":            "int32 (optional) - Approver user ID",
-				"meta":                   "object (optional) - Additional metadata",
+				"id": "int32 (required) - Planning parameters ID",
 			},
 		},
 		Response: map[string]any{
 			"success": map[string]any{
 				"status": 200,
-				"body":   "Updated sales order object",
+				"body": map[string]string{
+					"message": "Planning parameters deleted successfully",
+				},
 			},
 			"error": map[string]any{
-				"400": map[string]string{"error": "Invalid request payload | Invalid status | Invalid dates"},
+				"400": map[string]string{"error": "Invalid planning parameters ID format"},
 				"401": map[string]string{"error": "Unauthorized - Authentication required"},
-				"404": map[string]string{"error": "Sales order not found"},
-				"409": map[string]string{"error": "Sales order number already exists"},
+				"404": map[string]string{"error": "Planning parameters not found"},
 				"500": map[string]string{"error": "Internal server error"},
 			},
 		},
 	})
 
-	// Delete Sales Order
+	// Run Replenishment
 	r.Register(&router.Route{
-		Method:      "DELETE",
-		Path:        "/sales-orders/{id}",
-		HandlerFunc: salesHandler.DeleteSalesOrder,
-		Category:    "sales_orders",
+		Method:      "POST",
+		Path:        "/replenishment/run",
+		HandlerFunc: posHandler.RunReplenishment,
+		Category:    "purchase_orders",
 		Input: &router.RouteInput{
 			RequiredAuth: true,
-			PathParameters: map[string]string{
-				"id": "int32 (required) - Sales order ID",
-			},
 		},
 		Response: map[string]any{
 			"success": map[string]any{
 				"status": 200,
-				"body": map[string]string{
-					"message": "Sales order deleted successfully",
+				"body": map[string]any{
+					"evaluated": "int - Active planning parameters compared with stock levels and open purchase orders",
+					"suggested": "int - Open suggestions after the run",
+					"removed":   "int - Open suggestions no longer needed",
 				},
+				"description": "Runs the replenishment job now, it also runs every REPLENISHMENT_INTERVAL_MINUTES",
 			},
 			"error": map[string]any{
-				"400": map[string]string{"error": "Missing sales order ID | Invalid sales order ID format"},
 				"401": map[string]string{"error": "Unauthorized - Authentication required"},
-				"404": map[string]string{"error": "Sales order not found"},
-				"500": map[string]string{"error": "Internal server error"},
+				"500": map[string]string{"error": "Failed to generate replenishment suggestions"},
 			},
 		},
 	})
 
-	// List Sales Orders
+	// List Replenishment Suggestions
 	r.Register(&router.Route{
 		Method:      "GET",
-		Path:        "/sales-orders",
-		HandlerFunc: salesHandler.ListSalesOrders,
-		Category:    "sales_orders",
+		Path:        "/replenishment/suggestions",
+		HandlerFunc: posHandler.ListReplenishmentSuggestions,
+		Category:    "purchase_orders",
 		Input: &router.RouteInput{
 			RequiredAuth: true,
 			QueryParameters: map[string]string{
-				"page":  "int (optional) - Page number for pagination (default: 1)",
-				"limit": "int (optional) - Items per page (default: 10)",
-				"q":     "string (optional) - Search query (order number, status)",
+				"status":       "string (optional) - Open, Converted, Dismissed or all (default: Open)",
+				"warehouse_id": "int32 (optional) - Only this warehouse",
+				"supplier_id":  "int32 (optional) - Only this supplier",
 			},
 		},
 		Response: map[string]any{
 			"success": map[string]any{
 				"status": 200,
 				"body": map[string]any{
-					"sales_orders": "array of sales order objects",
-					"pagination": map[string]any{
-						"page":        "int",
-						"limit":       "int",
-						"total":       "int",
-						"total_pages": "int",
-					},
+					"suggestions": "array of suggestions (material, warehouse, supplier, on_hand_quantity, on_order_quantity, reorder_point, suggested_quantity, unit_price, status, purchase_order_id, order_number)",
 				},
 			},
 			"error": map[string]any{
+				"400": map[string]string{"error": "Invalid status | Invalid warehouse_id | Invalid supplier_id"},
 				"401": map[string]string{"error": "Unauthorized - Authentication required"},
 				"500": map[string]string{"error": "Internal server error"},
 			},
 		},
 	})
 
-	// Get Sales Order Items
+	// Convert Replenishment Suggestions to Purchase Orders
 	r.Register(&router.Route{
-		Method:      "GET",
-		Path:        "/sales-orders/{id}/items",
-		HandlerFunc: salesHandler.GetSalesOrderItems,
-		Category:    "sales_orders",
+		Method:      "POST",
+		Path:        "/replenishment/suggestions/convert",
+		HandlerFunc: posHandler.ConvertReplenishmentSuggestions,
+		Category:    "purchase_orders",
 		Input: &router.RouteInput{
 			RequiredAuth: true,
-			PathParameters: map[string]string{
-				"id": "int32 (required) - Sales order ID",
+			Body: map[string]string{
+				"suggestion_ids":         "[]int32 (required) - Open suggestions to order",
+				"expected_delivery_date": "timestamp (optional) - Expected delivery date of the orders",
 			},
 		},
 		Response: map[string]any{
 			"success": map[string]any{
-				"status": 200,
+				"status": 201,
 				"body": map[string]any{
-					"items": "array of sales order items",
+					"purchase_orders": "array of {purchase_order, items}, one Pending order per supplier and warehouse. The warehouse and suggestion IDs are kept in the order meta",
 				},
 			},
 			"error": map[string]any{
-				"400": map[string]string{"error": "Missing sales order ID | Invalid sales order ID format"},
+				"400": map[string]string{"error": "Invalid request payload | Missing required fields | Missing supplier | Missing price"},
 				"401": map[string]string{"error": "Unauthorized - Authentication required"},
-				"404": map[string]string{"error": "Sales order not found"},
+				"404": map[string]string{"error": "Suggestion 12 not found"},
+				"409": map[string]string{"error": "Suggestion 12 is Converted | Purchase order number already exists"},
 				"500": map[string]string{"error": "Internal server error"},
 			},
 		},
 	})
 
-	// Add Item to Sales Order
+	// Dismiss Replenishment Suggestion
+	r.Register(&router.Route{
+		Method:      "POST",
+		Path:        "/replenishment/suggestions/{id}/dismiss",
+		HandlerFunc: posHandler.DismissReplenishmentSuggestion,
+		Category:    "purchase_orders",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			PathParameters: map[string]string{
+				"id": "int32 (required) - Suggestion ID",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status":      200,
+				"body":        "Dismissed suggestion object",
+				"description": "The next run suggests the order again if it is still needed",
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "Invalid suggestion ID format"},
+				"401": map[string]string{"error": "Unauthorized - Authentication required"},
+				"404": map[string]string{"error": "Open suggestion not found"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// ______________________________Sales Orders_______________________________________________
+	// Create Sales Order
+	r.Register(&router.Route{
+		Method:      "POST",
+		Path:        "/sales-orders",
+		HandlerFunc: salesHandler.CreateSalesOrder,
+		Category:    "sales_orders",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			Body: map[string]string{
+				"order_number":           "string (required) - Unique order number",
+				"customer_id":            "int32 (required) - Customer ID",
+				"order_date":             "string (optional) - Order date (ISO format: 2026-01-27 or 2026-01-27T10:30:00)",
+				"expected_delivery_date": "string (optional) - Expected delivery date",
+				"status":                 "string (optional) - Status: Pending, Approved, PartiallyShipped, Shipped, Cancelled, Partial (default: Pending). PartiallyShipped and Shipped are set automatically by sales shipments",
+				"items":                  "array (required) - Array of items with material_id, quantity, unit_price, shipped_quantity",
+				"meta":                   "object (optional) - Additional metadata",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 201,
+				"body": map[string]any{
+					"sales_order": "Sales order object",
+					"items":       "Array of created items",
+				},
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "Invalid request payload | Missing required fields | Invalid status | Invalid item data | Invalid dates"},
+				"401": map[string]string{"error": "Unauthorized - Authentication required"},
+				"409": map[string]string{"error": "Sales order number already exists"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Get Sales Order by ID
+	r.Register(&router.Route{
+		Method:      "GET",
+		Path:        "/sales-orders/{id}",
+		HandlerFunc: salesHandler.GetSalesOrder,
+		Category:    "sales_orders",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			PathParameters: map[string]string{
+				"id": "int32 (required) - Sales order ID",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body": map[string]any{
+					"sales_order": "Sales order object",
+					"items":       "Array of order items",
+				},
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "Missing sales order ID | Invalid sales order ID format"},
+				"401": map[string]string{"error": "Unauthorized - Authentication required"},
+				"404": map[string]string{"error": "Sales order not found"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Update Sales Order
+	r.Register(&router.Route{
+		Method:      "PUT",
+		Path:        "/sales-orders/{id}",
+		HandlerFunc: salesHandler.UpdateSalesOrder,
+		Category:    "sales_orders",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			PathParameters: map[string]string{
+				"id": "int32 (required) - Sales order ID",
+			},
+			Body: map[string]string{
+				"order_number":           "string (optional) - New order number",
+				"customer_id":            "int32 (optional) - New customer ID",
+				"order_date":             "string (optional) - New order date",
+				"expected_delivery_date": "string (optional) - New expected delivery date",
+				"status":                 "string (optional) - New status. Cancelled and Shipped release the stock reservations of the order",
+				"approved_by":            "int32 (optional) - Approver user ID",
+				"meta":                   "object (optional) - Additional metadata",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body":   "Updated sales order object",
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "Invalid request payload | Invalid status | Invalid dates"},
+				"401": map[string]string{"error": "Unauthorized - Authentication required"},
+				"404": map[string]string{"error": "Sales order not found"},
+				"409": map[string]string{"error": "Sales order number already exists"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Delete Sales Order
+	r.Register(&router.Route{
+		Method:      "DELETE",
+		Path:        "/sales-orders/{id}",
+		HandlerFunc: salesHandler.DeleteSalesOrder,
+		Category:    "sales_orders",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			PathParameters: map[string]string{
+				"id": "int32 (required) - Sales order ID",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body": map[string]string{
+					"message": "Sales order deleted successfully",
+				},
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "Missing sales order ID | Invalid sales order ID format"},
+				"401": map[string]string{"error": "Unauthorized - Authentication required"},
+				"404": map[string]string{"error": "Sales order not found"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// List Sales Orders
+	r.Register(&router.Route{
+		Method:      "GET",
+		Path:        "/sales-orders",
+		HandlerFunc: salesHandler.ListSalesOrders,
+		Category:    "sales_orders",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			QueryParameters: map[string]string{
+				"page":  "int (optional) - Page number for pagination (default: 1)",
+				"limit": "int (optional) - Items per page (default: 10)",
+				"q":     "string (optional) - Search query (order number, status)",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body": map[string]any{
+					"sales_orders": "array of sales order objects",
+					"pagination": map[string]any{
+						"page":        "int",
+						"limit":       "int",
+						"total":       "int",
+						"total_pages": "int",
+					},
+				},
+			},
+			"error": map[string]any{
+				"401": map[string]string{"error": "Unauthorized - Authentication required"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Get Sales Order Items
+	r.Register(&router.Route{
+		Method:      "GET",
+		Path:        "/sales-orders/{id}/items",
+		HandlerFunc: salesHandler.GetSalesOrderItems,
+		Category:    "sales_orders",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			PathParameters: map[string]string{
+				"id": "int32 (required) - Sales order ID",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body": map[string]any{
+					"items": "array of sales order items",
+				},
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "Missing sales order ID | Invalid sales order ID format"},
+				"401": map[string]string{"error": "Unauthorized - Authentication required"},
+				"404": map[string]string{"error": "Sales order not found"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Add Item to Sales Order
 	r.Register(&router.Route{
 		Method:      "POST",
 		Path:        "/sales-orders/{id}/items",
@@ -2564,8 +2997,12 @@ func ApiRoutes(r *router.RouterImpl, db *pgxpool.Pool, q *db.Queries, logger *sl
 		Path:        "/transactions/opening-stock/import",
 		HandlerFunc: transactionsHandler.ImportOpeningStockFromExcel,
 		Category:    "transactions",
+		Middlewares: []router.MiddlewaresType{transactionsHandler.Idempotency},
 		Input: &router.RouteInput{
 			RequiredAuth: true,
+			Headers: map[string]string{
+				"Idempotency-Key": "string (optional) - Client chosen key, a retry with the same key and payload replays the stored response",
+			},
 			FormData: map[string]string{
 				"file": "multipart/form-data - Excel file (.xlsx) with opening stock data",
 			},
@@ -2594,103 +3031,218 @@ func ApiRoutes(r *router.RouterImpl, db *pgxpool.Pool, q *db.Queries, logger *sl
 					},
 				},
 				"401": map[string]string{"error": "Unauthorized"},
+				"409": map[string]string{"error": "A request with this Idempotency-Key is still in progress | The request with this Idempotency-Key was posted but its response was lost, check the stock before posting it again"},
+				"422": map[string]string{"error": "Idempotency-Key was already used with a different request"},
 				"500": map[string]string{"error": "Internal server error"},
 			},
 		},
 	})
 
-	// Opening Stock
+	// Download Bulk Import Template
 	r.Register(&router.Route{
-		Method:      "POST",
-		Path:        "/transactions/opening-stock",
-		HandlerFunc: transactionsHandler.OpeningStock,
+		Method:      "GET",
+		Path:        "/transactions/import/{type}/template",
+		HandlerFunc: transactionsHandler.DownloadImportTemplate,
 		Category:    "transactions",
 		Input: &router.RouteInput{
 			RequiredAuth: true,
-			Body: map[string]string{
-				"material_id":      "int32 (required) - Material ID",
-				"warehouse_id":     "int32 (required) - Warehouse ID",
-				"quantity":         "float64 (required) - Quantity",
-				"unit_price":       "float64 (required) - Unit price",
-				"manufacture_date": "string (optional) - Format: YYYY-MM-DD",
-				"expiry_date":      "string (optional) - Format: YYYY-MM-DD",
-				"notes":            "string (optional) - Additional notes",
-				"meta":             "object (optional) - Additional metadata",
+			PathParameters: map[string]string{
+				"type": "string (required) - purchase-receipt, transfer, scrap or adjustment",
+			},
+			QueryParameters: map[string]string{
+				"format": "string (optional) - xlsx (default) or csv",
 			},
 		},
 		Response: map[string]any{
 			"success": map[string]any{
-				"status": 201,
-				"body": map[string]any{
-					"success":     true,
-					"message":     "Opening stock recorded successfully",
-					"movement_id": 1,
-					"batch_ids":   []int32{1},
-				},
+				"status": 200,
+				"body":   "Excel or CSV file download (<type>_template.xlsx) with the columns of the type and an example row",
 			},
 			"error": map[string]any{
-				"400": map[string]string{"error": "Quantity must be positive | Invalid request body"},
 				"401": map[string]string{"error": "Unauthorized"},
-				"409": map[string]string{"error": "Opening stock already exists for this material in current year"},
+				"404": map[string]string{"error": "Unknown import type"},
 				"500": map[string]string{"error": "Internal server error"},
 			},
 		},
 	})
 
-	// Purchase Receipt
+	// Bulk Import Transactions
 	r.Register(&router.Route{
 		Method:      "POST",
-		Path:        "/transactions/purchase-receipt",
-		HandlerFunc: transactionsHandler.PurchaseReceipt,
+		Path:        "/transactions/import/{type}",
+		HandlerFunc: transactionsHandler.ImportTransactions,
 		Category:    "transactions",
+		Middlewares: []router.MiddlewaresType{transactionsHandler.Idempotency, txRetry},
 		Input: &router.RouteInput{
 			RequiredAuth: true,
-			Body: map[string]string{
-				"material_id":       "int32 (required) - Material ID",
-				"warehouse_id":      "int32 (required) - Warehouse ID",
-				"supplier_id":       "int32 (optional) - Supplier ID",
-				"quantity":          "float64 (required) - Quantity",
-				"unit_price":        "float64 (required) - Unit price",
-				"purchase_order_id": "int32 (optional) - Purchase order ID",
-				"manufacture_date":  "string (optional) - Format: YYYY-MM-DD",
-				"expiry_date":       "string (optional) - Format: YYYY-MM-DD",
-				"notes":             "string (optional) - Additional notes",
-				"meta":              "object (optional) - Additional metadata",
+			Headers: map[string]string{
+				"Idempotency-Key": "string (optional) - Client chosen key, a retry with the same key and payload replays the stored response",
+			},
+			PathParameters: map[string]string{
+				"type": "string (required) - purchase-receipt, transfer, scrap or adjustment",
+			},
+			FormData: map[string]string{
+				"file": "multipart/form-data - Excel (.xlsx) or CSV (.csv) file laid out like the template of the type. Materials, warehouses and bins are given by code, units by abbreviation, suppliers by name, serial numbers separated by commas. Each row is validated and posted like the single transaction endpoint of its type",
+				"mode": "string (optional) - file (default): the file posts in one database transaction, any failed row rolls back every row. row: every valid row is posted, the failed rows are reported",
 			},
 		},
 		Response: map[string]any{
 			"success": map[string]any{
 				"status": 201,
 				"body": map[string]any{
-					"success":     true,
-					"message":     "Purchase receipt recorded successfully",
-					"movement_id": 2,
-					"batch_ids":   []int32{2},
+					"type":          "string - Import type",
+					"mode":          "string - file or row",
+					"success_count": "int - Number of rows posted",
+					"failed_count":  "int - Number of failed rows, only with mode row",
+					"total_rows":    "int - Data rows in the file, empty rows are skipped",
+					"movement_ids":  "array - Movement posted for each posted row",
+					"failed":        "array - Failed rows, as for 400",
 				},
 			},
 			"error": map[string]any{
-				"400": map[string]string{"error": "Invalid request body"},
-				"401": map[string]string{"error": "Unauthorized"},
-				"500": map[string]string{"error": "Internal server error"},
-			},
-		},
-	})
-
-	// Sale
-	r.Register(&router.Route{
+				"400": map[string]any{
+					"type":          "string",
+					"mode":          "string",
+					"success_count": 0,
+					"failed_count":  "int",
+					"total_rows":    "int",
+					"failed": []map[string]any{
+						{
+							"row":            "int - Row number with error",
+							"material_code":  "string",
+							"warehouse_code": "string - Source warehouse for transfers",
+							"reason":         "string - Error description",
+						},
+					},
+				},
+				"401": map[string]string{"error": "Unauthorized"},
+				"404": map[string]string{"error": "Unknown import type"},
+				"409": map[string]string{"error": "A request with this Idempotency-Key is still in progress | The request with this Idempotency-Key was posted but its response was lost, check the stock before posting it again"},
+				"422": map[string]string{"error": "Idempotency-Key was already used with a different request"},
+				"500": map[string]string{"error": "Internal server error"},
+				"503": map[string]string{"error": "Row <n> conflicted with a concurrent transaction, no rows were posted, please retry"},
+			},
+		},
+	})
+
+	// Opening Stock
+	r.Register(&router.Route{
+		Method:      "POST",
+		Path:        "/transactions/opening-stock",
+		HandlerFunc: transactionsHandler.OpeningStock,
+		Category:    "transactions",
+		Middlewares: []router.MiddlewaresType{transactionsHandler.Idempotency},
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			Headers: map[string]string{
+				"Idempotency-Key": "string (optional) - Client chosen key, a retry with the same key and payload replays the stored response",
+			},
+			Body: map[string]string{
+				"material_id":      "int32 (required) - Material ID",
+				"warehouse_id":     "int32 (required) - Warehouse ID",
+				"bin_id":           "int32 (optional) - Bin of the warehouse to store the batch in",
+				"quantity":         "float64 (required) - Quantity",
+				"unit_id":          "int32 (optional) - Unit the quantity and unit_price are entered in, must convert to the material's unit (default: the material's unit)",
+				"unit_price":       "float64 (required) - Unit price",
+				"manufacture_date": "string (optional) - Format: YYYY-MM-DD",
+				"expiry_date":      "string (optional) - Format: YYYY-MM-DD",
+				"notes":            "string (optional) - Additional notes",
+				"meta":             "object (optional) - Additional metadata",
+				"serial_numbers":   "array (required for serialized materials) - One serial number per unit, the quantity must be whole",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 201,
+				"body": map[string]any{
+					"success":     true,
+					"message":     "Opening stock recorded successfully",
+					"movement_id": 1,
+					"batch_ids":   []int32{1},
+				},
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "Quantity must be positive | Invalid request body | Bin does not belong to warehouse | Bin is not active | Bin has not enough free capacity | Unit cannot be converted to the material's unit | Serial numbers do not match the quantity"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"409": map[string]string{"error": "Opening stock already exists for this material in current year | Serial number is already in stock or in transit | A request with this Idempotency-Key is still in progress | The request with this Idempotency-Key was posted but its response was lost, check the stock before posting it again"},
+				"422": map[string]string{"error": "Idempotency-Key was already used with a different request"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Purchase Receipt
+	r.Register(&router.Route{
+		Method:      "POST",
+		Path:        "/transactions/purchase-receipt",
+		HandlerFunc: transactionsHandler.PurchaseReceipt,
+		Category:    "transactions",
+		Middlewares: []router.MiddlewaresType{transactionsHandler.Idempotency},
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			Headers: map[string]string{
+				"Idempotency-Key": "string (optional) - Client chosen key, a retry with the same key and payload replays the stored response",
+			},
+			Body: map[string]string{
+				"material_id":            "int32 (required) - Material ID",
+				"warehouse_id":           "int32 (required) - Warehouse ID",
+				"supplier_id":            "int32 (optional) - Supplier ID, defaults to the purchase order supplier",
+				"bin_id":                 "int32 (optional) - Bin of the warehouse to store the batch in, see /warehouses/{id}/bins/putaway",
+				"quantity":               "float64 (required) - Quantity",
+				"unit_id":                "int32 (optional) - Unit the quantity and unit_price are entered in, must convert to the material's unit (default: the material's unit)",
+				"unit_price":             "float64 (optional with purchase_order_id) - Unit price, defaults to the order line price",
+				"purchase_order_id":      "int32 (optional) - Purchase order ID",
+				"purchase_order_item_id": "int32 (optional) - Purchase order line to receive against, defaults to the first open line of the material",
+				"manufacture_date":       "string (optional) - Format: YYYY-MM-DD",
+				"expiry_date":            "string (optional) - Format: YYYY-MM-DD",
+				"notes":                  "string (optional) - Additional notes",
+				"meta":                   "object (optional) - Additional metadata",
+				"serial_numbers":         "array (required for serialized materials) - One serial number per unit received, the quantity must be whole",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 201,
+				"body": map[string]any{
+					"success":     true,
+					"message":     "Purchase receipt recorded successfully, purchase order is PartiallyReceived, batch B-2026-0002 is quarantined for incoming inspection QI-2026-0001. Materials with required quality specs get a pending incoming inspection of the batch and a quarantine hold until the inspection is decided",
+					"movement_id": 2,
+					"batch_ids":   []int32{2},
+				},
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "Invalid request body | Cannot receive against a cancelled purchase order | Quantity exceeds what may still be received on order line (over-receipt tolerance: PO_OVER_RECEIPT_TOLERANCE_PERCENT) | Bin is not active | Bin has not enough free capacity | Unit cannot be converted to the material's unit | Serial numbers do not match the quantity"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"404": map[string]string{"error": "Purchase order not found"},
+				"409": map[string]string{"error": "Serial number is already in stock or in transit | A request with this Idempotency-Key is still in progress | The request with this Idempotency-Key was posted but its response was lost, check the stock before posting it again"},
+				"422": map[string]string{"error": "Idempotency-Key was already used with a different request"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Sale
+	r.Register(&router.Route{
 		Method:      "POST",
 		Path:        "/transactions/sale",
 		HandlerFunc: transactionsHandler.Sale,
 		Category:    "transactions",
+		Middlewares: []router.MiddlewaresType{transactionsHandler.Idempotency, txRetry},
 		Input: &router.RouteInput{
 			RequiredAuth: true,
+			Headers: map[string]string{
+				"Idempotency-Key": "string (optional) - Client chosen key, a retry with the same key and payload replays the stored response",
+			},
 			Body: map[string]string{
-				"sales_order_id": "int32 (required) - Sales order ID",
-				"warehouse_id":   "int32 (required) - Warehouse ID",
-				"material_id":    "int32 (required) - Material ID",
-				"quantity":       "float64 (required) - Quantity",
-				"use_manual":     "bool (optional, default: false) - Manual batch selection",
-				"batches":        "array (optional) - Array of {batch_id, quantity} for manual selection",
+				"sales_order_id":      "int32 (required) - Sales order ID",
+				"sales_order_item_id": "int32 (optional) - Order line to ship, defaults to the first open line of the material",
+				"warehouse_id":        "int32 (required) - Warehouse ID",
+				"material_id":         "int32 (required) - Material ID, must be on the sales order",
+				"quantity":            "float64 (required) - Quantity, cannot exceed the open quantity of the order line",
+				"unit_id":             "int32 (optional) - Unit the quantities are entered in, must convert to the material's unit (default: the material's unit)",
+				"use_manual":          "bool (optional, default: false) - Manual batch selection",
+				"batches":             "array (optional) - Array of {batch_id, quantity} for manual selection",
+				"serial_numbers":      "array (required for serialized materials) - Serial numbers of the units shipped, in stock in the warehouse. They decide the batches, use_manual and batches are ignored",
 			},
 		},
 		Response: map[string]any{
@@ -2698,363 +3250,1457 @@ func ApiRoutes(r *router.RouterImpl, db *pgxpool.Pool, q *db.Queries, logger *sl
 				"status": 201,
 				"body": map[string]any{
 					"success":     true,
-					"message":     "Sale recorded successfully",
+					"message":     "Sale recorded successfully, sales order is PartiallyShipped",
 					"movement_id": 3,
 					"batch_ids":   []int32{1, 2},
 				},
 			},
 			"error": map[string]any{
-				"400": map[string]string{"error": "Insufficient stock | Invalid batch allocations"},
+				"400": map[string]string{"error": "Insufficient stock | Invalid batch allocations | Batch is on quality hold | Material is not on this sales order | Quantity exceeds the open quantity of order line | Insufficient available stock (reserved for other sales orders) | Unit cannot be converted to the material's unit | Serial numbers do not match the quantity | Serial number is not in stock in the warehouse"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"404": map[string]string{"error": "Sales order not found"},
+				"409": map[string]string{"error": "Batch does not hold the quantity anymore (concurrent stock-out) | A request with this Idempotency-Key is still in progress | The request with this Idempotency-Key was posted but its response was lost, check the stock before posting it again"},
+				"422": map[string]string{"error": "Idempotency-Key was already used with a different request"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Customer Return
+	r.Register(&router.Route{
+		Method:      "POST",
+		Path:        "/transactions/customer-return",
+		HandlerFunc: transactionsHandler.CustomerReturn,
+		Category:    "transactions",
+		Middlewares: []router.MiddlewaresType{transactionsHandler.Idempotency},
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			Headers: map[string]string{
+				"Idempotency-Key": "string (optional) - Client chosen key, a retry with the same key and payload replays the stored response",
+			},
+			Body: map[string]string{
+				"sales_order_id": "int32 (required) - Sales order ID",
+				"material_id":    "int32 (required) - Material ID",
+				"quantity":       "float64 (required) - Quantity, at most what was shipped on the order and not returned yet",
+				"unit_id":        "int32 (optional) - Unit the quantities are entered in, must convert to the material's unit (default: the material's unit)",
+				"notes":          "string (optional) - Return notes",
+				"serial_numbers": "array (required for serialized materials) - Serial numbers of the units returned, sold with this sales order",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 201,
+				"body": map[string]any{
+					"success":     true,
+					"message":     "Customer return recorded successfully, batch BATCH-RET-001 is quarantined for return inspection QI-2026-0004",
+					"movement_id": 4,
+					"batch_ids":   []int32{5},
+				},
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "Invalid request body | Unit cannot be converted to the material's unit | Quantity exceeds the returnable quantity of sales order 12 (returnable: 3.00) | Serial numbers do not match the quantity | Serial number was not sold with this sales order"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"404": map[string]string{"error": "Sales order not found | Original sale not found"},
+				"409": map[string]string{"error": "A request with this Idempotency-Key is still in progress | The request with this Idempotency-Key was posted but its response was lost, check the stock before posting it again"},
+				"422": map[string]string{"error": "Idempotency-Key was already used with a different request"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Decide Customer Return Inspection
+	r.Register(&router.Route{
+		Method:      "POST",
+		Path:        "/transactions/customer-return/inspections/{id}/decision",
+		HandlerFunc: transactionsHandler.DecideCustomerReturn,
+		Category:    "transactions",
+		Middlewares: []router.MiddlewaresType{transactionsHandler.Idempotency, txRetry},
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			Headers: map[string]string{
+				"Idempotency-Key": "string (optional) - Client chosen key, a retry with the same key and payload replays the stored response",
+			},
+			PathParameters: map[string]string{
+				"id": "int32 (required) - customer_return quality inspection opened by the return, its stock must still be held",
+			},
+			Body: map[string]string{
+				"decision": "string (required) - restock: the quarantine hold is released and the batch becomes available stock. scrap: what the batch holds is scrapped as a SCRAP movement referencing the inspection. rework: a rework work order for the same material is opened and the batch is issued to it as a PRODUCTION_ISSUE movement, completing the order receives the reworked stock",
+				"notes":    "string (optional) - Notes, added to the inspection",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body": map[string]any{
+					"success":     true,
+					"message":     "Batch BATCH-RET-001 issued to rework order RW-2026-000012, inspection QI-2026-0004 is failed",
+					"movement_id": 15,
+					"batch_ids":   []int32{5},
+				},
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "Invalid inspection ID | Decision must be restock, scrap or rework | Inspection is not the inspection of a customer return | The stock of the inspection is not held anymore | Batch has no stock left"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"404": map[string]string{"error": "Inspection not found"},
+				"409": map[string]string{"error": "Batch does not hold the quantity anymore | A request with this Idempotency-Key is still in progress | The request with this Idempotency-Key was posted but its response was lost, check the stock before posting it again"},
+				"422": map[string]string{"error": "Idempotency-Key was already used with a different request"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Transfer
+	r.Register(&router.Route{
+		Method:      "POST",
+		Path:        "/transactions/transfer",
+		HandlerFunc: transactionsHandler.Transfer,
+		Category:    "transactions",
+		Middlewares: []router.MiddlewaresType{transactionsHandler.Idempotency, txRetry},
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			Headers: map[string]string{
+				"Idempotency-Key": "string (optional) - Client chosen key, a retry with the same key and payload replays the stored response",
+			},
+			Body: map[string]string{
+				"material_id":       "int32 (required) - Material ID",
+				"from_warehouse_id": "int32 (required) - Source warehouse ID",
+				"to_warehouse_id":   "int32 (required) - Destination warehouse ID, the source warehouse for a move between bins, which keeps the batch numbers",
+				"from_bin_id":       "int32 (optional) - Only take batches stored in this bin of the source warehouse",
+				"to_bin_id":         "int32 (optional) - Store the transferred batches in this bin of the destination warehouse",
+				"quantity":          "float64 (required) - Quantity",
+				"unit_id":           "int32 (optional) - Unit the quantities are entered in, must convert to the material's unit (default: the material's unit)",
+				"use_manual":        "bool (optional, default: false) - Manual batch selection",
+				"batches":           "array (optional) - Array of {batch_id, quantity} for manual selection",
+				"notes":             "string (optional) - Transfer notes",
+				"serial_numbers":    "array (required for serialized materials) - Serial numbers of the units transferred, in stock in the source warehouse. They decide the batches, use_manual and batches are ignored",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 201,
+				"body": map[string]any{
+					"success":     true,
+					"message":     "Transfer completed successfully",
+					"movement_id": 5,
+					"batch_ids":   []int32{6, 7},
+				},
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "Source and destination must be different warehouses or bins | Batch is not stored in the source bin | Bin has not enough free capacity | Insufficient stock | Batch is on quality hold | Insufficient available stock (reserved for sales orders) | Unit cannot be converted to the material's unit | Serial numbers do not match the quantity | Serial number is not in stock in the warehouse"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"409": map[string]string{"error": "Batch does not hold the quantity anymore (concurrent stock-out) | A request with this Idempotency-Key is still in progress | The request with this Idempotency-Key was posted but its response was lost, check the stock before posting it again"},
+				"422": map[string]string{"error": "Idempotency-Key was already used with a different request"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Ship Transfer
+	r.Register(&router.Route{
+		Method:      "POST",
+		Path:        "/transactions/transfer-shipments",
+		HandlerFunc: transactionsHandler.ShipTransfer,
+		Category:    "transactions",
+		Middlewares: []router.MiddlewaresType{transactionsHandler.Idempotency, txRetry},
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			Headers: map[string]string{
+				"Idempotency-Key": "string (optional) - Client chosen key, a retry with the same key and payload replays the stored response",
+			},
+			Body: map[string]string{
+				"material_id":       "int32 (required) - Material ID",
+				"from_warehouse_id": "int32 (required) - Source warehouse ID",
+				"to_warehouse_id":   "int32 (required) - Destination warehouse ID, must differ from the source",
+				"from_bin_id":       "int32 (optional) - Only take batches stored in this bin of the source warehouse",
+				"to_bin_id":         "int32 (optional) - Bin of the destination warehouse to put the stock away in on receipt",
+				"quantity":          "float64 (required) - Quantity",
+				"unit_id":           "int32 (optional) - Unit the quantities are entered in, must convert to the material's unit (default: the material's unit)",
+				"use_manual":        "bool (optional, default: false) - Manual batch selection",
+				"batches":           "array (optional) - Array of {batch_id, quantity} for manual selection",
+				"expected_date":     "string (optional) - Expected arrival date (YYYY-MM-DD)",
+				"notes":             "string (optional) - Shipment notes",
+				"serial_numbers":    "array (required for serialized materials) - Serial numbers of the units shipped, in stock in the source warehouse. They decide the batches, use_manual and batches are ignored",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 201,
+				"body":   "Transfer shipment (status In Transit) with the shipped source batches and the TRANSFER_OUT movement. The stock leaves the source now and shows as in_transit_quantity of the destination until it is received",
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "Source and destination must be different warehouses | Batch is not stored in the source bin | Insufficient stock | Batch is on quality hold | Insufficient available stock (reserved for sales orders) | Unit cannot be converted to the material's unit | Serial numbers do not match the quantity | Serial number is not in stock in the warehouse"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"409": map[string]string{"error": "Batch does not hold the quantity anymore (concurrent stock-out) | A request with this Idempotency-Key is still in progress | The request with this Idempotency-Key was posted but its response was lost, check the stock before posting it again"},
+				"422": map[string]string{"error": "Idempotency-Key was already used with a different request"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// List Transfer Shipments
+	r.Register(&router.Route{
+		Method:      "GET",
+		Path:        "/transactions/transfer-shipments",
+		HandlerFunc: transactionsHandler.ListTransferShipments,
+		Category:    "transactions",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			QueryParameters: map[string]string{
+				"status":            "string (optional) - In Transit or Received",
+				"from_warehouse_id": "int32 (optional) - Source warehouse ID",
+				"to_warehouse_id":   "int32 (optional) - Destination warehouse ID",
+				"material_id":       "int32 (optional) - Material ID",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body":   "Array of transfer shipments with material, warehouses and days_in_transit, oldest first",
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "Invalid from_warehouse_id | Invalid to_warehouse_id | Invalid material_id"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Get Transfer Shipment
+	r.Register(&router.Route{
+		Method:      "GET",
+		Path:        "/transactions/transfer-shipments/{id}",
+		HandlerFunc: transactionsHandler.GetTransferShipment,
+		Category:    "transactions",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			PathParameters: map[string]string{
+				"id": "int32 (required) - Transfer shipment ID",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body":   "Transfer shipment with the shipped source batches and its TRANSFER_OUT and TRANSFER_IN movements",
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "Invalid transfer shipment ID"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"404": map[string]string{"error": "Transfer shipment not found"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Receive Transfer
+	r.Register(&router.Route{
+		Method:      "POST",
+		Path:        "/transactions/transfer-shipments/{id}/receive",
+		HandlerFunc: transactionsHandler.ReceiveTransfer,
+		Category:    "transactions",
+		Middlewares: []router.MiddlewaresType{transactionsHandler.Idempotency, txRetry},
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			Headers: map[string]string{
+				"Idempotency-Key": "string (optional) - Client chosen key, a retry with the same key and payload replays the stored response",
+			},
+			PathParameters: map[string]string{
+				"id": "int32 (required) - Transfer shipment ID",
+			},
+			Body: map[string]string{
+				"received_quantity":  "float64 (optional) - Total quantity that arrived, a shortage is taken from the last shipped batches (default: everything shipped)",
+				"batches":            "array (optional) - Array of {batch_id, quantity} received per shipped source batch, batches left out did not arrive",
+				"unit_id":            "int32 (optional) - Unit the quantities are entered in, must convert to the material's unit (default: the material's unit)",
+				"to_bin_id":          "int32 (optional) - Bin to put the stock away in (default: the bin chosen when shipping)",
+				"discrepancy_reason": "string (optional) - Why less arrived than was shipped, required when it did",
+				"notes":              "string (optional) - Receipt notes",
+				"serial_numbers":     "array (optional) - Serial numbers of the units that arrived of a serialized shipment, in place of received_quantity and batches. Units left out are marked Lost (default: every shipped unit)",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body":   "Received transfer shipment with received_quantity and discrepancy_reason. What arrived is posted as a TRANSFER_IN movement with a new destination batch per source batch, what did not as a TRANSFER_LOSS movement, both at the cost it was shipped with",
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "Invalid transfer shipment ID | transfer shipment is Received | batch was not shipped with this transfer | received more than was shipped | discrepancy_reason is required | Bin has not enough free capacity | Unit cannot be converted to the material's unit | serial number was not shipped with this transfer | serial_numbers are required to receive part of a serialized shipment"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"404": map[string]string{"error": "Transfer shipment not found"},
+				"409": map[string]string{"error": "A request with this Idempotency-Key is still in progress | The request with this Idempotency-Key was posted but its response was lost, check the stock before posting it again"},
+				"422": map[string]string{"error": "Idempotency-Key was already used with a different request"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// List Serial Numbers
+	r.Register(&router.Route{
+		Method:      "GET",
+		Path:        "/transactions/serial-numbers",
+		HandlerFunc: transactionsHandler.ListSerialNumbers,
+		Category:    "transactions",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			QueryParameters: map[string]string{
+				"material_id":  "int32 (optional) - Material ID",
+				"warehouse_id": "int32 (optional) - Warehouse holding the units",
+				"batch_id":     "int32 (optional) - Batch holding the units",
+				"status":       "string (optional) - In Stock, In Transit, Sold or Lost",
+				"search":       "string (optional) - Part of the serial number",
+				"limit":        "int (optional, default: 100) - Maximum number of serial numbers",
+				"offset":       "int (optional, default: 0) - Serial numbers to skip",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body":   "Array of serial numbers with material, batch, warehouse and status, by material code and serial number",
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "Invalid material_id | Invalid warehouse_id | Invalid batch_id | Invalid limit | Invalid offset"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Lookup Serial Number
+	r.Register(&router.Route{
+		Method:      "GET",
+		Path:        "/transactions/serial-numbers/lookup",
+		HandlerFunc: transactionsHandler.LookupSerialNumber,
+		Category:    "transactions",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			QueryParameters: map[string]string{
+				"serial_number": "string (required) - Serial number",
+				"material_id":   "int32 (optional) - Material ID, when the serial number is used by more than one material",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body":   "Array of the matching serial numbers, one per material, with the batch and warehouse holding the unit, its status and history: every movement of the unit with type, warehouses, batch, reference, user and date, oldest first",
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "serial_number is required | Invalid material_id"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"404": map[string]string{"error": "Serial number not found"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Supplier Return
+	r.Register(&router.Route{
+		Method:      "POST",
+		Path:        "/transactions/supplier-return",
+		HandlerFunc: transactionsHandler.SupplierReturn,
+		Category:    "transactions",
+		Middlewares: []router.MiddlewaresType{transactionsHandler.Idempotency, txRetry},
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			Headers: map[string]string{
+				"Idempotency-Key": "string (optional) - Client chosen key, a retry with the same key and payload replays the stored response",
+			},
+			Body: map[string]string{
+				"batch_id":       "int32 (required) - Batch sent back, must have been received against a purchase order. The material, warehouse and order line are the batch's",
+				"quantity":       "float64 (required) - Quantity sent back, comes off the received quantity of the order line",
+				"unit_id":        "int32 (optional) - Unit the quantity is entered in, must convert to the material's unit (default: the material's unit)",
+				"ncr_id":         "int32 (optional) - Non-conformance report with the return_to_supplier disposition settled by the return. It must be open and about the batch's material, batch and purchase order. It is closed once the returns against it add up to its affected quantity, otherwise it is set in_progress",
+				"notes":          "string (optional) - Notes",
+				"serial_numbers": "array (required for serialized materials) - Serial numbers of the units sent back, all in the batch, one per unit. They are marked Returned",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 201,
+				"body": map[string]any{
+					"success":     true,
+					"message":     "Supplier return recorded successfully, purchase order is PartiallyReceived, non-conformance report NCR-2026-0001 is closed",
+					"movement_id": 14,
+					"batch_ids":   []int32{3},
+				},
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "Quantity must be positive | Batch was not received against a purchase order | Cannot return against a cancelled purchase order | Quantity exceeds what was received on order line | Non-conformance report is closed or does not match the batch | Insufficient quantity | Quantity is reserved for sales orders | Serial numbers must be in the batch | Unit cannot be converted to the material's unit"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"404": map[string]string{"error": "Batch not found"},
+				"409": map[string]string{"error": "Batch does not hold the quantity anymore (concurrent stock-out) | A request with this Idempotency-Key is still in progress | The request with this Idempotency-Key was posted but its response was lost, check the stock before posting it again"},
+				"422": map[string]string{"error": "Idempotency-Key was already used with a different request"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Scrap
+	r.Register(&router.Route{
+		Method:      "POST",
+		Path:        "/transactions/scrap",
+		HandlerFunc: transactionsHandler.Scrap,
+		Category:    "transactions",
+		Middlewares: []router.MiddlewaresType{transactionsHandler.Idempotency, txRetry},
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			Headers: map[string]string{
+				"Idempotency-Key": "string (optional) - Client chosen key, a retry with the same key and payload replays the stored response",
+			},
+			Body: map[string]string{
+				"material_id":    "int32 (required) - Material ID",
+				"warehouse_id":   "int32 (required) - Warehouse ID",
+				"quantity":       "float64 (required) - Quantity",
+				"unit_id":        "int32 (optional) - Unit the quantities are entered in, must convert to the material's unit (default: the material's unit)",
+				"reason":         "string (required) - Reason for scrap",
+				"use_manual":     "bool (optional, default: false) - Manual batch selection",
+				"batches":        "array (optional) - Array of {batch_id, quantity} for manual selection",
+				"serial_numbers": "array (required for serialized materials) - Serial numbers of the units scrapped, in stock in the warehouse. They decide the batches, use_manual and batches are ignored. The units are marked Scrapped",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 201,
+				"body": map[string]any{
+					"success":     true,
+					"message":     "Scrap recorded successfully",
+					"movement_id": 6,
+					"batch_ids":   []int32{1},
+				},
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "Reason is required for scrap | Insufficient stock | Batch is on quality hold | Unit cannot be converted to the material's unit"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"409": map[string]string{"error": "Batch does not hold the quantity anymore (concurrent stock-out) | A request with this Idempotency-Key is still in progress | The request with this Idempotency-Key was posted but its response was lost, check the stock before posting it again"},
+				"422": map[string]string{"error": "Idempotency-Key was already used with a different request"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Adjustment
+	r.Register(&router.Route{
+		Method:      "POST",
+		Path:        "/transactions/adjustment",
+		HandlerFunc: transactionsHandler.Adjustment,
+		Category:    "transactions",
+		Middlewares: []router.MiddlewaresType{transactionsHandler.Idempotency, txRetry},
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			Headers: map[string]string{
+				"Idempotency-Key": "string (optional) - Client chosen key, a retry with the same key and payload replays the stored response",
+			},
+			Body: map[string]string{
+				"material_id":    "int32 (required) - Material ID",
+				"warehouse_id":   "int32 (required) - Warehouse ID",
+				"quantity":       "float64 (required) - Quantity",
+				"unit_id":        "int32 (optional) - Unit the quantity and unit_price are entered in, must convert to the material's unit (default: the material's unit)",
+				"direction":      "string (required) - 'IN' or 'OUT'",
+				"reason":         "string (required) - Reason for adjustment",
+				"unit_price":     "float64 (optional) - Unit price for adjustment IN",
+				"use_manual":     "bool (optional, default: false) - Manual batch selection for OUT",
+				"batches":        "array (optional) - Array of {batch_id, quantity} for manual selection",
+				"serial_numbers": "array (required for serialized materials) - One serial number per unit. IN: the units found, registered with the new batch. OUT: the units missing, in stock in the warehouse, they decide the batches and are marked Lost",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 201,
+				"body": map[string]any{
+					"success":     true,
+					"message":     "Adjustment IN recorded successfully",
+					"movement_id": 7,
+					"batch_ids":   []int32{8},
+				},
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "Reason is required | Direction must be 'IN' or 'OUT' | Unit cannot be converted to the material's unit"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"409": map[string]string{"error": "Batch does not hold the quantity anymore (concurrent stock-out) | A request with this Idempotency-Key is still in progress | The request with this Idempotency-Key was posted but its response was lost, check the stock before posting it again"},
+				"422": map[string]string{"error": "Idempotency-Key was already used with a different request"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Get Stock Level
+	r.Register(&router.Route{
+		Method:      "GET",
+		Path:        "/transactions/stock-level",
+		HandlerFunc: transactionsHandler.GetStockLevel,
+		Category:    "transactions",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			QueryParameters: map[string]string{
+				"material_id":  "int32 (required) - Material ID",
+				"warehouse_id": "int32 (required) - Warehouse ID",
+				"as_of":        "string (optional) - YYYY-MM-DD (end of that day in UTC) or RFC3339, rebuilds the stock from movement history at that moment, not before stock history by batch starts",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body": map[string]any{
+					"total_quantity":      100.50,
+					"batch_count":         5,
+					"held_quantity":       10.00,
+					"reserved_quantity":   40.00,
+					"available_quantity":  50.50,
+					"in_transit_quantity": 20.00,
+				},
+			},
+			"as_of": map[string]any{
+				"status": 200,
+				"body":   "as_of, total_quantity, total_batch_value, total_ledger_value, in_transit_quantity, in_transit_value, levels (quantity, batch_value, ledger_value and average_unit_cost per material and warehouse), batches (quantity and value per batch) and in_transit (quantity and value shipped to the warehouse and not received yet at as_of)",
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "material_id and warehouse_id are required | Invalid as_of | Stock history starts at 2024-03-01T08:00:00Z, as_of must not be earlier"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Get Stock Levels by Warehouse
+	r.Register(&router.Route{
+		Method:      "GET",
+		Path:        "/transactions/stock-levels/warehouse",
+		HandlerFunc: transactionsHandler.GetStockLevelsByWarehouse,
+		Category:    "transactions",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			QueryParameters: map[string]string{
+				"as_of":        "string (optional) - YYYY-MM-DD (end of that day in UTC) or RFC3339, rebuilds the stock from movement history at that moment, not before stock history by batch starts",
+				"warehouse_id": "int32 (optional) - Limit the as_of stock to one warehouse",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body":   "Array of stock levels grouped by warehouse, with in_transit_quantity shipped to the warehouse and not received yet",
+			},
+			"as_of": map[string]any{
+				"status": 200,
+				"body":   "as_of, totals, levels per material and warehouse, batches and in_transit per material and destination warehouse, valued at batch cost and from the cost ledger",
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "Invalid as_of | Invalid warehouse_id | Stock history starts at 2024-03-01T08:00:00Z, as_of must not be earlier"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Get Stock Levels by Material
+	r.Register(&router.Route{
+		Method:      "GET",
+		Path:        "/transactions/stock-levels/material",
+		HandlerFunc: transactionsHandler.GetStockLevelsByMaterial,
+		Category:    "transactions",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			QueryParameters: map[string]string{
+				"material_id": "int32 (required) - Material ID",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body":   "Array of stock levels across all warehouses for the material, with in_transit_quantity shipped to each warehouse and not received yet",
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "material_id is required"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Get Comprehensive Material Stock (with batches)
+	r.Register(&router.Route{
+		Method:      "GET",
+		Path:        "/transactions/stock/material",
+		HandlerFunc: transactionsHandler.GetMaterialStock,
+		Category:    "transactions",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			QueryParameters: map[string]string{
+				"material_id": "int32 (required) - Material ID",
+				"as_of":       "string (optional) - YYYY-MM-DD (end of that day in UTC) or RFC3339, rebuilds the stock from movement history at that moment, not before stock history by batch starts",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body":   "Comprehensive stock info with all batches per warehouse, with on-hand (total_quantity), held, reserved, available and in_transit quantities per warehouse and total_reserved/total_available/total_in_transit for the material",
+			},
+			"as_of": map[string]any{
+				"status": 200,
+				"body":   "as_of, totals, levels per warehouse, batches and in_transit per destination warehouse of the material, valued at batch cost and from the cost ledger",
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "material_id is required | Invalid as_of | Stock history starts at 2024-03-01T08:00:00Z, as_of must not be earlier"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"404": map[string]string{"error": "Material not found or has no stock"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Get Bin Stock Levels
+	r.Register(&router.Route{
+		Method:      "GET",
+		Path:        "/transactions/stock-levels/bins",
+		HandlerFunc: transactionsHandler.GetBinStockLevels,
+		Category:    "transactions",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			QueryParameters: map[string]string{
+				"warehouse_id": "int32 (required) - Warehouse ID",
+				"bin_id":       "int32 (optional) - Only this bin",
+				"material_id":  "int32 (optional) - Only this material",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body":   "Array of stock levels per bin and material (bin_id, bin_code, zone, material, total_quantity, batch_count). Stock without a bin is listed with an empty bin",
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "warehouse_id is required"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Get Available Batches
+	r.Register(&router.Route{
+		Method:      "GET",
+		Path:        "/transactions/batches/available",
+		HandlerFunc: transactionsHandler.GetAvailableBatches,
+		Category:    "transactions",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			QueryParameters: map[string]string{
+				"material_id":  "int32 (required) - Material ID",
+				"warehouse_id": "int32 (required) - Warehouse ID",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body":   "Array of available batches with details. held_quantity is the part under an active quality hold (with hold_number), reserved_quantity the part pinned to sales order reservations, available_quantity what can be allocated",
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "material_id and warehouse_id are required"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// List Expiring Batches
+	r.Register(&router.Route{
+		Method:      "GET",
+		Path:        "/transactions/batches/expiring",
+		HandlerFunc: transactionsHandler.ListExpiringBatches,
+		Category:    "transactions",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			QueryParameters: map[string]string{
+				"days":         "int (optional) - Horizon in days, default EXPIRY_WARNING_DAYS",
+				"warehouse_id": "int32 (optional) - Filter by warehouse",
+				"material_id":  "int32 (optional) - Filter by material",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body":   "{horizon_days, expired, expiring, batches: [{id, batch_number, material_id, material_code, material_name, warehouse_id, warehouse_name, bin_code, current_quantity, unit, unit_price, expiry_date, days_to_expiry, expired, flagged_at, hold_id, hold_number}]} - batches with stock expiring within the horizon or already expired, soonest first",
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "Invalid days"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"500": map[string]string{"error": "Failed to get expiring batches"},
+			},
+		},
+	})
+
+	// Run Batch Expiry Check
+	r.Register(&router.Route{
+		Method:      "POST",
+		Path:        "/transactions/batches/expiry-check",
+		HandlerFunc: transactionsHandler.RunExpiryCheck,
+		Category:    "transactions",
+		Middlewares: []router.MiddlewaresType{transactionsHandler.Idempotency},
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			Headers: map[string]string{
+				"Idempotency-Key": "string (optional) - Client chosen key, a retry with the same key and payload replays the stored response",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body":   "{horizon_days, flagged, held, hold_numbers} - flags batches expiring within the horizon and places a blocked quality hold on expired batches so they can no longer be allocated. Also runs every EXPIRY_CHECK_INTERVAL_MINUTES",
+			},
+			"error": map[string]any{
+				"401": map[string]string{"error": "Unauthorized"},
+				"409": map[string]string{"error": "A request with this Idempotency-Key is still in progress | The request with this Idempotency-Key was posted but its response was lost, check the stock before posting it again"},
+				"422": map[string]string{"error": "Idempotency-Key was already used with a different request"},
+				"500": map[string]string{"error": "Failed to check batch expiry"},
+			},
+		},
+	})
+
+	// Get Movement History
+	r.Register(&router.Route{
+		Method:      "GET",
+		Path:        "/transactions/movements/history",
+		HandlerFunc: transactionsHandler.GetMovementHistory,
+		Category:    "transactions",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			QueryParameters: map[string]string{
+				"material_id": "int32 (required) - Material ID",
+				"limit":       "int (optional, default: 50, max: 100) - Items per page",
+				"offset":      "int (optional, default: 0) - Offset for pagination",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body":   "Array of stock movements for the material",
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "material_id is required"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Get Warehouse Movements
+	r.Register(&router.Route{
+		Method:      "GET",
+		Path:        "/transactions/movements/warehouse",
+		HandlerFunc: transactionsHandler.GetWarehouseMovements,
+		Category:    "transactions",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			QueryParameters: map[string]string{
+				"warehouse_id": "int32 (required) - Warehouse ID",
+				"limit":        "int (optional, default: 50, max: 100) - Items per page",
+				"offset":       "int (optional, default: 0) - Offset for pagination",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body":   "Array of stock movements for the warehouse",
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "warehouse_id is required"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Get Movement by ID
+	r.Register(&router.Route{
+		Method:      "GET",
+		Path:        "/transactions/movements/{id}",
+		HandlerFunc: transactionsHandler.GetMovementByID,
+		Category:    "transactions",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			QueryParameters: map[string]string{
+				"id": "int32 (required) - Movement ID",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body":   "Stock movement details with batches: the batch lines (batch_id, batch_number, quantity, unit_cost) consumed by an OUT movement or created by an IN movement. reverses_movement_id / reversed_by_movement_id link a movement and its reversal, paired_movement_id the two legs of a one-step transfer",
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "id is required"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"404": map[string]string{"error": "Movement not found"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Reverse Movement
+	r.Register(&router.Route{
+		Method:      "POST",
+		Path:        "/transactions/movements/{id}/reverse",
+		HandlerFunc: transactionsHandler.ReverseMovement,
+		Category:    "transactions",
+		Middlewares: []router.MiddlewaresType{transactionsHandler.Idempotency, txRetry},
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			Headers: map[string]string{
+				"Idempotency-Key": "string (optional) - Client chosen key, a retry with the same key and payload replays the stored response",
+			},
+			PathParameters: map[string]string{
+				"id": "int32 (required) - Movement ID to reverse. For a one-step transfer both legs, linked by paired_movement_id, are reversed",
+			},
+			Body: map[string]string{
+				"reason": "string (optional) - Why the movement is reversed, stored in the reversal notes",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 201,
+				"body": map[string]any{
+					"success":     true,
+					"message":     "Movement 12 reversed successfully",
+					"movement_id": 13,
+					"batch_ids":   []int32{4, 5},
+				},
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "Invalid movement ID | Movement is a reversal and cannot be reversed | Movement is already reversed | Movements of serialized materials cannot be reversed | Supplier returns of a non-conformance report cannot be reversed | Transfer movement is not linked to its other leg | Batch has since been consumed downstream"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"404": map[string]string{"error": "Movement not found"},
+				"409": map[string]string{"error": "A request with this Idempotency-Key is still in progress | The request with this Idempotency-Key was posted but its response was lost, check the stock before posting it again"},
+				"422": map[string]string{"error": "Idempotency-Key was already used with a different request"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Get Material Average Costs
+	r.Register(&router.Route{
+		Method:      "GET",
+		Path:        "/transactions/costing/average",
+		HandlerFunc: transactionsHandler.GetMaterialAverageCosts,
+		Category:    "transactions",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			QueryParameters: map[string]string{
+				"material_id": "int32 (required) - Material ID",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body":   "Array of running weighted-average costs per warehouse (on_hand_quantity, total_value, average_unit_cost)",
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "material_id is required"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Get Cost Ledger
+	r.Register(&router.Route{
+		Method:      "GET",
+		Path:        "/transactions/costing/ledger",
+		HandlerFunc: transactionsHandler.GetCostLedger,
+		Category:    "transactions",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			QueryParameters: map[string]string{
+				"material_id":  "int32 (required) - Material ID",
+				"warehouse_id": "int32 (required) - Warehouse ID",
+				"limit":        "int (optional, default: 50, max: 100) - Items per page",
+				"offset":       "int (optional, default: 0) - Offset for pagination",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body":   "Array of cost ledger entries, newest first. Each entry holds the movement (type, reference, date), quantity_change, unit_cost, value_change and the running balance after it. OUT entries give the COGS of the movement",
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "material_id and warehouse_id are required"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Create Stock Reservation
+	r.Register(&router.Route{
+		Method:      "POST",
+		Path:        "/transactions/reservations",
+		HandlerFunc: transactionsHandler.CreateReservation,
+		Category:    "transactions",
+		Middlewares: []router.MiddlewaresType{transactionsHandler.Idempotency, txRetry},
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			Headers: map[string]string{
+				"Idempotency-Key": "string (optional) - Client chosen key, a retry with the same key and payload replays the stored response",
+			},
+			Body: map[string]string{
+				"sales_order_id":      "int32 (required) - Sales order ID",
+				"sales_order_item_id": "int32 (required) - Sales order line to reserve for",
+				"warehouse_id":        "int32 (required) - Warehouse ID",
+				"quantity":            "float64 (required) - Quantity to reserve",
+				"batch_id":            "int32 (optional) - Pin the reservation to a batch",
+				"notes":               "string (optional) - Additional notes",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 201,
+				"body":   "Created reservation object (status Active)",
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "Invalid request body | Cannot reserve stock for a Cancelled sales order | Quantity exceeds the unreserved open quantity of order line | Insufficient available stock | Batch is on quality hold"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"404": map[string]string{"error": "Sales order not found"},
+				"409": map[string]string{"error": "A request with this Idempotency-Key is still in progress | The request with this Idempotency-Key was posted but its response was lost, check the stock before posting it again"},
+				"422": map[string]string{"error": "Idempotency-Key was already used with a different request"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Get Stock Reservations
+	r.Register(&router.Route{
+		Method:      "GET",
+		Path:        "/transactions/reservations",
+		HandlerFunc: transactionsHandler.GetReservations,
+		Category:    "transactions",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			QueryParameters: map[string]string{
+				"sales_order_id": "int32 (required) - Sales order ID",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body":   "Array of reservations of the sales order (Active, Fulfilled or Released) with material, warehouse and batch, newest first",
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "sales_order_id is required"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Release Stock Reservation
+	r.Register(&router.Route{
+		Method:      "POST",
+		Path:        "/transactions/reservations/{id}/release",
+		HandlerFunc: transactionsHandler.ReleaseReservation,
+		Category:    "transactions",
+		Middlewares: []router.MiddlewaresType{transactionsHandler.Idempotency},
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			Headers: map[string]string{
+				"Idempotency-Key": "string (optional) - Client chosen key, a retry with the same key and payload replays the stored response",
+			},
+			PathParameters: map[string]string{
+				"id": "int32 (required) - Reservation ID",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body":   "Released reservation object",
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "Invalid reservation ID | Reservation is already Released"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"404": map[string]string{"error": "Reservation not found"},
+				"409": map[string]string{"error": "A request with this Idempotency-Key is still in progress | The request with this Idempotency-Key was posted but its response was lost, check the stock before posting it again"},
+				"422": map[string]string{"error": "Idempotency-Key was already used with a different request"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Create Count Session
+	r.Register(&router.Route{
+		Method:      "POST",
+		Path:        "/transactions/counts",
+		HandlerFunc: transactionsHandler.CreateCountSession,
+		Category:    "transactions",
+		Middlewares: []router.MiddlewaresType{transactionsHandler.Idempotency},
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			Headers: map[string]string{
+				"Idempotency-Key": "string (optional) - Client chosen key, a retry with the same key and payload replays the stored response",
+			},
+			Body: map[string]string{
+				"warehouse_id": "int32 (required) - Warehouse to count",
+				"category_id":  "int32 (optional) - Only count materials of this category",
+				"abc_class":    "string (optional) - Only count materials of this ABC class: A, B or C",
+				"notes":        "string (optional) - Additional notes",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 201,
+				"body": map[string]any{
+					"session":    "Created count session object (status Open)",
+					"line_count": "int - Number of batches frozen for counting",
+				},
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "warehouse_id is required | abc_class must be 'A', 'B' or 'C' | No stock to count in this warehouse and scope"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"409": map[string]string{"error": "A request with this Idempotency-Key is still in progress | The request with this Idempotency-Key was posted but its response was lost, check the stock before posting it again"},
+				"422": map[string]string{"error": "Idempotency-Key was already used with a different request"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// List Count Sessions
+	r.Register(&router.Route{
+		Method:      "GET",
+		Path:        "/transactions/counts",
+		HandlerFunc: transactionsHandler.ListCountSessions,
+		Category:    "transactions",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			QueryParameters: map[string]string{
+				"warehouse_id": "int32 (required) - Warehouse ID",
+				"limit":        "int (optional, default: 50, max: 100) - Number of results",
+				"offset":       "int (optional, default: 0) - Pagination offset",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body":   "Array of count sessions with warehouse and category names, line_count and counted_line_count, newest first",
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "warehouse_id is required"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"500": map[string]string{"error": "Internal server error"},
+			},
+		},
+	})
+
+	// Get Count Session
+	r.Register(&router.Route{
+		Method:      "GET",
+		Path:        "/transactions/counts/{id}",
+		HandlerFunc: transactionsHandler.GetCountSession,
+		Category:    "transactions",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			PathParameters: map[string]string{
+				"id": "int32 (required) - Count session ID",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body":   "Count session with lines (snapshot_quantity, counted_quantity, expected_quantity, variance_quantity, adjustment_movement_id per batch), counted_line_count, variance_line_count and variance_value. expected_quantity is the system quantity of the batch when it was counted, variance_quantity is counted_quantity less expected_quantity",
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "Invalid count session ID"},
 				"401": map[string]string{"error": "Unauthorized"},
+				"404": map[string]string{"error": "Count session not found"},
 				"500": map[string]string{"error": "Internal server error"},
 			},
 		},
 	})
 
-	// Customer Return
+	// Download Count Sheet
 	r.Register(&router.Route{
-		Method:      "POST",
-		Path:        "/transactions/customer-return",
-		HandlerFunc: transactionsHandler.CustomerReturn,
+		Method:      "GET",
+		Path:        "/transactions/counts/{id}/sheet",
+		HandlerFunc: transactionsHandler.DownloadCountSheet,
 		Category:    "transactions",
 		Input: &router.RouteInput{
 			RequiredAuth: true,
-			Body: map[string]string{
-				"sales_order_id": "int32 (required) - Sales order ID",
-				"material_id":    "int32 (required) - Material ID",
-				"quantity":       "float64 (required) - Quantity",
-				"notes":          "string (optional) - Return notes",
+			PathParameters: map[string]string{
+				"id": "int32 (required) - Count session ID",
 			},
 		},
 		Response: map[string]any{
 			"success": map[string]any{
-				"status": 201,
-				"body": map[string]any{
-					"success":     true,
-					"message":     "Customer return recorded successfully",
-					"movement_id": 4,
-					"batch_ids":   []int32{5},
-				},
+				"status": 200,
+				"body":   "Excel file download (count_sheet_<session_number>.xlsx). The sheet lists the batches to count without their system quantity",
 			},
 			"error": map[string]any{
-				"400": map[string]string{"error": "Invalid request body"},
+				"400": map[string]string{"error": "Invalid count session ID"},
 				"401": map[string]string{"error": "Unauthorized"},
-				"404": map[string]string{"error": "Original sale not found"},
+				"404": map[string]string{"error": "Count session not found"},
 				"500": map[string]string{"error": "Internal server error"},
 			},
 		},
 	})
 
-	// Transfer
+	// Import Count Sheet
 	r.Register(&router.Route{
 		Method:      "POST",
-		Path:        "/transactions/transfer",
-		HandlerFunc: transactionsHandler.Transfer,
+		Path:        "/transactions/counts/{id}/sheet",
+		HandlerFunc: transactionsHandler.ImportCountSheet,
 		Category:    "transactions",
+		Middlewares: []router.MiddlewaresType{transactionsHandler.Idempotency},
 		Input: &router.RouteInput{
 			RequiredAuth: true,
-			Body: map[string]string{
-				"material_id":       "int32 (required) - Material ID",
-				"from_warehouse_id": "int32 (required) - Source warehouse ID",
-				"to_warehouse_id":   "int32 (required) - Destination warehouse ID",
-				"quantity":          "float64 (required) - Quantity",
-				"use_manual":        "bool (optional, default: false) - Manual batch selection",
-				"batches":           "array (optional) - Array of {batch_id, quantity} for manual selection",
-				"notes":             "string (optional) - Transfer notes",
+			Headers: map[string]string{
+				"Idempotency-Key": "string (optional) - Client chosen key, a retry with the same key and payload replays the stored response",
+			},
+			PathParameters: map[string]string{
+				"id": "int32 (required) - Count session ID",
+			},
+			FormData: map[string]string{
+				"file": "multipart/form-data - Filled in count sheet (.xlsx). Rows without a counted quantity are skipped",
 			},
 		},
 		Response: map[string]any{
 			"success": map[string]any{
-				"status": 201,
+				"status": 200,
 				"body": map[string]any{
-					"success":     true,
-					"message":     "Transfer completed successfully",
-					"movement_id": 5,
-					"batch_ids":   []int32{6, 7},
+					"recorded_count": "int - Number of lines recorded",
+					"failed_count":   "int - Number of failed rows",
+					"failed":         "Array of {row, line_id, reason}",
 				},
 			},
 			"error": map[string]any{
-				"400": map[string]string{"error": "Source and destination warehouses must be different | Insufficient stock"},
+				"400": map[string]string{"error": "Invalid count session ID | Invalid Excel file | Missing required column | count session is Approved"},
 				"401": map[string]string{"error": "Unauthorized"},
+				"404": map[string]string{"error": "count session not found"},
+				"409": map[string]string{"error": "A request with this Idempotency-Key is still in progress | The request with this Idempotency-Key was posted but its response was lost, check the stock before posting it again"},
+				"422": map[string]string{"error": "Idempotency-Key was already used with a different request"},
 				"500": map[string]string{"error": "Internal server error"},
 			},
 		},
 	})
 
-	// Scrap
+	// Record Counted Quantities
 	r.Register(&router.Route{
-		Method:      "POST",
-		Path:        "/transactions/scrap",
-		HandlerFunc: transactionsHandler.Scrap,
+		Method:      "PUT",
+		Path:        "/transactions/counts/{id}/lines",
+		HandlerFunc: transactionsHandler.RecordCounts,
 		Category:    "transactions",
+		Middlewares: []router.MiddlewaresType{transactionsHandler.Idempotency},
 		Input: &router.RouteInput{
 			RequiredAuth: true,
+			Headers: map[string]string{
+				"Idempotency-Key": "string (optional) - Client chosen key, a retry with the same key and payload replays the stored response",
+			},
+			PathParameters: map[string]string{
+				"id": "int32 (required) - Count session ID",
+			},
 			Body: map[string]string{
-				"material_id":  "int32 (required) - Material ID",
-				"warehouse_id": "int32 (required) - Warehouse ID",
-				"quantity":     "float64 (required) - Quantity",
-				"reason":       "string (required) - Reason for scrap",
-				"use_manual":   "bool (optional, default: false) - Manual batch selection",
-				"batches":      "array (optional) - Array of {batch_id, quantity} for manual selection",
+				"lines": "array (required) - Array of counts with line_id, counted_quantity (0 if nothing was found) and optional notes",
 			},
 		},
 		Response: map[string]any{
 			"success": map[string]any{
-				"status": 201,
+				"status": 200,
 				"body": map[string]any{
-					"success":     true,
-					"message":     "Scrap recorded successfully",
-					"movement_id": 6,
-					"batch_ids":   []int32{1},
+					"success":        true,
+					"recorded_count": "int - Number of lines recorded",
 				},
 			},
 			"error": map[string]any{
-				"400": map[string]string{"error": "Reason is required for scrap | Insufficient stock"},
+				"400": map[string]string{"error": "Invalid request body | No counts provided | count session is Approved | line is not part of this count session"},
 				"401": map[string]string{"error": "Unauthorized"},
+				"404": map[string]string{"error": "count session not found"},
+				"409": map[string]string{"error": "A request with this Idempotency-Key is still in progress | The request with this Idempotency-Key was posted but its response was lost, check the stock before posting it again"},
+				"422": map[string]string{"error": "Idempotency-Key was already used with a different request"},
 				"500": map[string]string{"error": "Internal server error"},
 			},
 		},
 	})
 
-	// Adjustment
+	// Approve Count Session
 	r.Register(&router.Route{
 		Method:      "POST",
-		Path:        "/transactions/adjustment",
-		HandlerFunc: transactionsHandler.Adjustment,
+		Path:        "/transactions/counts/{id}/approve",
+		HandlerFunc: transactionsHandler.ApproveCountSession,
 		Category:    "transactions",
+		Middlewares: []router.MiddlewaresType{transactionsHandler.Idempotency, txRetry},
 		Input: &router.RouteInput{
 			RequiredAuth: true,
-			Body: map[string]string{
-				"material_id":  "int32 (required) - Material ID",
-				"warehouse_id": "int32 (required) - Warehouse ID",
-				"quantity":     "float64 (required) - Quantity",
-				"direction":    "string (required) - 'IN' or 'OUT'",
-				"reason":       "string (required) - Reason for adjustment",
-				"unit_price":   "float64 (optional) - Unit price for adjustment IN",
-				"use_manual":   "bool (optional, default: false) - Manual batch selection for OUT",
-				"batches":      "array (optional) - Array of {batch_id, quantity} for manual selection",
+			Headers: map[string]string{
+				"Idempotency-Key": "string (optional) - Client chosen key, a retry with the same key and payload replays the stored response",
+			},
+			PathParameters: map[string]string{
+				"id": "int32 (required) - Count session ID",
 			},
 		},
 		Response: map[string]any{
 			"success": map[string]any{
-				"status": 201,
+				"status": 200,
 				"body": map[string]any{
-					"success":     true,
-					"message":     "Adjustment IN recorded successfully",
-					"movement_id": 7,
-					"batch_ids":   []int32{8},
+					"session":      "Approved count session object",
+					"movement_ids": "Array of ADJUSTMENT_IN / ADJUSTMENT_OUT movement IDs posted for the variances, all in one transaction. Each variance is taken against the batch quantity when the line was counted and applied to the batch as it is now, postings are not blocked while a session is open",
 				},
 			},
 			"error": map[string]any{
-				"400": map[string]string{"error": "Reason is required | Direction must be 'IN' or 'OUT'"},
+				"400": map[string]string{"error": "Invalid count session ID | count session is Approved | lines have not been counted yet | batch has moved since it was counted"},
 				"401": map[string]string{"error": "Unauthorized"},
+				"404": map[string]string{"error": "count session not found"},
+				"409": map[string]string{"error": "A request with this Idempotency-Key is still in progress | The request with this Idempotency-Key was posted but its response was lost, check the stock before posting it again"},
+				"422": map[string]string{"error": "Idempotency-Key was already used with a different request"},
 				"500": map[string]string{"error": "Internal server error"},
 			},
 		},
 	})
 
-	// Get Stock Level
+	// Cancel Count Session
 	r.Register(&router.Route{
-		Method:      "GET",
-		Path:        "/transactions/stock-level",
-		HandlerFunc: transactionsHandler.GetStockLevel,
+		Method:      "POST",
+		Path:        "/transactions/counts/{id}/cancel",
+		HandlerFunc: transactionsHandler.CancelCountSession,
 		Category:    "transactions",
+		Middlewares: []router.MiddlewaresType{transactionsHandler.Idempotency},
 		Input: &router.RouteInput{
 			RequiredAuth: true,
-			QueryParameters: map[string]string{
-				"material_id":  "int32 (required) - Material ID",
-				"warehouse_id": "int32 (required) - Warehouse ID",
+			Headers: map[string]string{
+				"Idempotency-Key": "string (optional) - Client chosen key, a retry with the same key and payload replays the stored response",
+			},
+			PathParameters: map[string]string{
+				"id": "int32 (required) - Count session ID",
 			},
 		},
 		Response: map[string]any{
 			"success": map[string]any{
 				"status": 200,
-				"body": map[string]any{
-					"total_quantity": 100.50,
-					"batch_count":    5,
-				},
+				"body":   "Cancelled count session object, nothing is posted",
 			},
 			"error": map[string]any{
-				"400": map[string]string{"error": "material_id and warehouse_id are required"},
+				"400": map[string]string{"error": "Invalid count session ID | count session is Approved"},
 				"401": map[string]string{"error": "Unauthorized"},
+				"404": map[string]string{"error": "count session not found"},
+				"409": map[string]string{"error": "A request with this Idempotency-Key is still in progress | The request with this Idempotency-Key was posted but its response was lost, check the stock before posting it again"},
+				"422": map[string]string{"error": "Idempotency-Key was already used with a different request"},
 				"500": map[string]string{"error": "Internal server error"},
 			},
 		},
 	})
 
-	// Get Stock Levels by Warehouse
+	// Create Work Order
 	r.Register(&router.Route{
-		Method:      "GET",
-		Path:        "/transactions/stock-levels/warehouse",
-		HandlerFunc: transactionsHandler.GetStockLevelsByWarehouse,
+		Method:      "POST",
+		Path:        "/transactions/work-orders",
+		HandlerFunc: transactionsHandler.CreateWorkOrder,
 		Category:    "transactions",
+		Middlewares: []router.MiddlewaresType{transactionsHandler.Idempotency},
 		Input: &router.RouteInput{
 			RequiredAuth: true,
+			Headers: map[string]string{
+				"Idempotency-Key": "string (optional) - Client chosen key, a retry with the same key and payload replays the stored response",
+			},
+			Body: map[string]string{
+				"finished_material_id": "int32 (required) - Material to produce",
+				"warehouse_id":         "int32 (required) - Warehouse the components are issued from and the goods received into",
+				"quantity":             "float64 (required) - Quantity to produce",
+				"unit_id":              "int32 (optional) - Unit the quantity is entered in, default the material's unit",
+				"bom_version":          "string (optional) - BOM version to produce from, default the version in effect",
+				"due_date":             "string (optional) - Due date (YYYY-MM-DD)",
+				"notes":                "string (optional) - Additional notes",
+			},
 		},
 		Response: map[string]any{
 			"success": map[string]any{
-				"status": 200,
-				"body":   "Array of stock levels grouped by warehouse",
+				"status": 201,
+				"body":   "Work order (status Planned) with components copied from the BOM lines in effect: required_quantity adds scrap_percentage and is not multiplied by the order quantity for fixed_quantity lines",
 			},
 			"error": map[string]any{
+				"400": map[string]string{"error": "finished_material_id and warehouse_id are required | Quantity must be positive | Warehouse not found | Material has no BOM in effect | BOM version has no components in effect"},
 				"401": map[string]string{"error": "Unauthorized"},
+				"409": map[string]string{"error": "A request with this Idempotency-Key is still in progress | The request with this Idempotency-Key was posted but its response was lost, check the stock before posting it again"},
+				"422": map[string]string{"error": "Idempotency-Key was already used with a different request"},
 				"500": map[string]string{"error": "Internal server error"},
 			},
 		},
 	})
 
-	// Get Stock Levels by Material
+	// List Work Orders
 	r.Register(&router.Route{
 		Method:      "GET",
-		Path:        "/transactions/stock-levels/material",
-		HandlerFunc: transactionsHandler.GetStockLevelsByMaterial,
+		Path:        "/transactions/work-orders",
+		HandlerFunc: transactionsHandler.ListWorkOrders,
 		Category:    "transactions",
 		Input: &router.RouteInput{
 			RequiredAuth: true,
 			QueryParameters: map[string]string{
-				"material_id": "int32 (required) - Material ID",
+				"status":       "string (optional) - Planned, In Progress, Completed or Cancelled",
+				"warehouse_id": "int32 (optional) - Warehouse ID",
+				"material_id":  "int32 (optional) - Finished material ID",
 			},
 		},
 		Response: map[string]any{
 			"success": map[string]any{
 				"status": 200,
-				"body":   "Array of stock levels across all warehouses for the material",
+				"body":   "Array of work orders with material, warehouse and finished goods batch, newest first",
 			},
 			"error": map[string]any{
-				"400": map[string]string{"error": "material_id is required"},
+				"400": map[string]string{"error": "Invalid warehouse_id | Invalid material_id"},
 				"401": map[string]string{"error": "Unauthorized"},
 				"500": map[string]string{"error": "Internal server error"},
 			},
 		},
 	})
 
-	// Get Comprehensive Material Stock (with batches)
+	// Get Work Order
 	r.Register(&router.Route{
 		Method:      "GET",
-		Path:        "/transactions/stock/material",
-		HandlerFunc: transactionsHandler.GetMaterialStock,
+		Path:        "/transactions/work-orders/{id}",
+		HandlerFunc: transactionsHandler.GetWorkOrder,
 		Category:    "transactions",
 		Input: &router.RouteInput{
 			RequiredAuth: true,
-			QueryParameters: map[string]string{
-				"material_id": "int32 (required) - Material ID",
+			PathParameters: map[string]string{
+				"id": "int32 (required) - Work order ID",
 			},
 		},
 		Response: map[string]any{
 			"success": map[string]any{
 				"status": 200,
-				"body":   "Comprehensive stock info with all batches per warehouse",
+				"body":   "Work order with components (required, issued and alternate issued quantities, issued_cost) and its production movements",
 			},
 			"error": map[string]any{
-				"400": map[string]string{"error": "material_id is required"},
+				"400": map[string]string{"error": "Invalid work order ID"},
 				"401": map[string]string{"error": "Unauthorized"},
-				"404": map[string]string{"error": "Material not found or has no stock"},
+				"404": map[string]string{"error": "Work order not found"},
 				"500": map[string]string{"error": "Internal server error"},
 			},
 		},
 	})
 
-	// Get Available Batches
+	// Issue Work Order Components
 	r.Register(&router.Route{
-		Method:      "GET",
-		Path:        "/transactions/batches/available",
-		HandlerFunc: transactionsHandler.GetAvailableBatches,
+		Method:      "POST",
+		Path:        "/transactions/work-orders/{id}/issue",
+		HandlerFunc: transactionsHandler.IssueWorkOrder,
 		Category:    "transactions",
+		Middlewares: []router.MiddlewaresType{transactionsHandler.Idempotency, txRetry},
 		Input: &router.RouteInput{
 			RequiredAuth: true,
-			QueryParameters: map[string]string{
-				"material_id":  "int32 (required) - Material ID",
-				"warehouse_id": "int32 (required) - Warehouse ID",
+			Headers: map[string]string{
+				"Idempotency-Key": "string (optional) - Client chosen key, a retry with the same key and payload replays the stored response",
+			},
+			PathParameters: map[string]string{
+				"id": "int32 (required) - Work order ID",
 			},
 		},
 		Response: map[string]any{
 			"success": map[string]any{
 				"status": 200,
-				"body":   "Array of available batches with details",
+				"body": map[string]any{
+					"work_order": "Work order (status In Progress) with components and movements. The open quantity of every component is issued as PRODUCTION_ISSUE movements, a shortfall is covered by the alternate component if it has enough available stock, and all components are issued or none",
+					"skipped":    "array - Optional components left out for lack of stock, with component_id, material_id, code, quantity and reason",
+				},
 			},
 			"error": map[string]any{
-				"400": map[string]string{"error": "material_id and warehouse_id are required"},
+				"400": map[string]string{"error": "Invalid work order ID | work order is Completed | insufficient stock of a component"},
 				"401": map[string]string{"error": "Unauthorized"},
+				"404": map[string]string{"error": "work order not found"},
+				"409": map[string]string{"error": "A request with this Idempotency-Key is still in progress | The request with this Idempotency-Key was posted but its response was lost, check the stock before posting it again"},
+				"422": map[string]string{"error": "Idempotency-Key was already used with a different request"},
 				"500": map[string]string{"error": "Internal server error"},
 			},
 		},
 	})
 
-	// Get Movement History
+	// Complete Work Order
 	r.Register(&router.Route{
-		Method:      "GET",
-		Path:        "/transactions/movements/history",
-		HandlerFunc: transactionsHandler.GetMovementHistory,
+		Method:      "POST",
+		Path:        "/transactions/work-orders/{id}/complete",
+		HandlerFunc: transactionsHandler.CompleteWorkOrder,
 		Category:    "transactions",
+		Middlewares: []router.MiddlewaresType{transactionsHandler.Idempotency, txRetry},
 		Input: &router.RouteInput{
 			RequiredAuth: true,
-			QueryParameters: map[string]string{
-				"material_id": "int32 (required) - Material ID",
-				"limit":       "int (optional, default: 50, max: 100) - Items per page",
-				"offset":      "int (optional, default: 0) - Offset for pagination",
+			Headers: map[string]string{
+				"Idempotency-Key": "string (optional) - Client chosen key, a retry with the same key and payload replays the stored response",
+			},
+			PathParameters: map[string]string{
+				"id": "int32 (required) - Work order ID",
+			},
+			Body: map[string]string{
+				"quantity":         "float64 (optional) - Quantity produced, default the planned quantity",
+				"unit_id":          "int32 (optional) - Unit the quantity is entered in, default the material's unit",
+				"bin_id":           "int32 (optional) - Bin to put the finished goods away in",
+				"manufacture_date": "string (optional) - Manufacture date (YYYY-MM-DD), default today",
+				"expiry_date":      "string (optional) - Expiry date (YYYY-MM-DD)",
+				"notes":            "string (optional) - Additional notes",
+				"serial_numbers":   "array (required for serialized materials) - One serial number per unit produced, the quantity must be whole",
 			},
 		},
 		Response: map[string]any{
 			"success": map[string]any{
 				"status": 200,
-				"body":   "Array of stock movements for the material",
+				"body": map[string]any{
+					"work_order":   "Completed work order with components and movements",
+					"batch_id":     "int32 - Finished goods batch received as a PRODUCTION_RECEIPT movement, the consumed batches become its parents in the batch genealogy",
+					"batch_number": "string - Finished goods batch number",
+					"unit_cost":    "float64 - Cost of the issued components per unit produced",
+				},
 			},
 			"error": map[string]any{
-				"400": map[string]string{"error": "material_id is required"},
+				"400": map[string]string{"error": "Invalid work order ID | issue the components of the work order first | work order is Completed | Quantity must be positive | bin cannot take the quantity | serial numbers are required"},
 				"401": map[string]string{"error": "Unauthorized"},
+				"404": map[string]string{"error": "work order not found"},
+				"409": map[string]string{"error": "serial number is already in stock or in transit | A request with this Idempotency-Key is still in progress | The request with this Idempotency-Key was posted but its response was lost, check the stock before posting it again"},
+				"422": map[string]string{"error": "Idempotency-Key was already used with a different request"},
 				"500": map[string]string{"error": "Internal server error"},
 			},
 		},
 	})
 
-	// Get Warehouse Movements
+	// Cancel Work Order
 	r.Register(&router.Route{
-		Method:      "GET",
-		Path:        "/transactions/movements/warehouse",
-		HandlerFunc: transactionsHandler.GetWarehouseMovements,
+		Method:      "POST",
+		Path:        "/transactions/work-orders/{id}/cancel",
+		HandlerFunc: transactionsHandler.CancelWorkOrder,
 		Category:    "transactions",
+		Middlewares: []router.MiddlewaresType{transactionsHandler.Idempotency},
 		Input: &router.RouteInput{
 			RequiredAuth: true,
-			QueryParameters: map[string]string{
-				"warehouse_id": "int32 (required) - Warehouse ID",
-				"limit":        "int (optional, default: 50, max: 100) - Items per page",
-				"offset":       "int (optional, default: 0) - Offset for pagination",
+			Headers: map[string]string{
+				"Idempotency-Key": "string (optional) - Client chosen key, a retry with the same key and payload replays the stored response",
+			},
+			PathParameters: map[string]string{
+				"id": "int32 (required) - Work order ID",
 			},
 		},
 		Response: map[string]any{
 			"success": map[string]any{
 				"status": 200,
-				"body":   "Array of stock movements for the warehouse",
+				"body":   "Cancelled work order object, only Planned work orders can be cancelled",
 			},
 			"error": map[string]any{
-				"400": map[string]string{"error": "warehouse_id is required"},
+				"400": map[string]string{"error": "Invalid work order ID | work order is In Progress"},
 				"401": map[string]string{"error": "Unauthorized"},
+				"404": map[string]string{"error": "work order not found"},
+				"409": map[string]string{"error": "A request with this Idempotency-Key is still in progress | The request with this Idempotency-Key was posted but its response was lost, check the stock before posting it again"},
+				"422": map[string]string{"error": "Idempotency-Key was already used with a different request"},
 				"500": map[string]string{"error": "Internal server error"},
 			},
 		},
 	})
 
-	// Get Movement by ID
+	// Get Material ABC Classes
 	r.Register(&router.Route{
 		Method:      "GET",
-		Path:        "/transactions/movements/{id}",
-		HandlerFunc: transactionsHandler.GetMovementByID,
+		Path:        "/transactions/abc-classes",
+		HandlerFunc: transactionsHandler.GetMaterialABCClasses,
 		Category:    "transactions",
 		Input: &router.RouteInput{
 			RequiredAuth: true,
 			QueryParameters: map[string]string{
-				"id": "int32 (required) - Movement ID",
+				"warehouse_id": "int32 (required) - Warehouse ID",
 			},
 		},
 		Response: map[string]any{
 			"success": map[string]any{
 				"status": 200,
-				"body":   "Stock movement details",
+				"body":   "Array of stocked materials with annual_usage_value (12 months of consumption value) and abc_class: A = first 80% of the value, B = next 15%, C = the rest",
 			},
 			"error": map[string]any{
-				"400": map[string]string{"error": "id is required"},
+				"400": map[string]string{"error": "warehouse_id is required"},
 				"401": map[string]string{"error": "Unauthorized"},
-				"404": map[string]string{"error": "Movement not found"},
 				"500": map[string]string{"error": "Internal server error"},
 			},
 		},
@@ -3272,6 +4918,18 @@ func ApiRoutes(r *router.RouterImpl, db *pgxpool.Pool, q *db.Queries, logger *sl
 				"decision_date":     "timestamp (optional)",
 			},
 		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body":   "Updated inspection. When the inspection becomes passed (status passed or final_decision unrestricted) the quality holds it placed are released. When it becomes failed (status failed or final_decision blocked/rejected) its holds stay, set to the decision (default rejected), and an open NCR about the held batch is raised unless the inspection has one",
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "Invalid inspection ID | Invalid request payload"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"404": map[string]string{"error": "Quality inspection not found"},
+				"500": map[string]string{"error": "Failed to update quality inspection | Failed to apply inspection decision"},
+			},
+		},
 	})
 
 	r.Register(&router.Route{
@@ -4242,4 +5900,157 @@ func ApiRoutes(r *router.RouterImpl, db *pgxpool.Pool, q *db.Queries, logger *sl
 		Response: map[string]any{"success": map[string]any{"body": "Updated OOS investigation"}},
 	})
 
+	// ============================================================================
+	// REPORTS
+	// ============================================================================
+
+	// Inventory Valuation
+	r.Register(&router.Route{
+		Method:      "GET",
+		Path:        "/reports/inventory-valuation",
+		HandlerFunc: reportsHandler.GetInventoryValuation,
+		Category:    "reports",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			QueryParameters: map[string]string{
+				"as_of":        "date|datetime (optional) - Value the stock at this moment instead of now. A date means the end of that day in UTC, give an RFC3339 moment with offset for another timezone",
+				"warehouse_id": "int32 (optional) - Only this warehouse",
+				"category_id":  "int32 (optional) - Only materials of this category",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body": map[string]any{
+					"as_of":            "timestamp",
+					"on_hand_value":    "float64",
+					"in_transit_value": "float64",
+					"total_value":      "float64 - on_hand_value plus in_transit_value",
+					"by_category":      "[]{id, name, lines, value} - Materials without a category are totalled as Uncategorized (id 0)",
+					"by_warehouse":     "[]{id, code, name, lines, value} - Stock in transit counts at its destination warehouse",
+					"lines":            "[]{material_id, material_code, material_name, category_id, category_name, warehouse_id, warehouse_code, warehouse_name, valuation_method, unit, quantity, batch_count, batch_value, average_unit_cost, unit_cost, value} - FIFO and LIFO stock is valued at batch cost, Weighted Average stock at the average unit cost",
+					"in_transit":       "[]{material_id, material_code, material_name, category_id, category_name, warehouse_id, warehouse_code, warehouse_name, unit, shipments, quantity, value} - Stock shipped to the warehouse and not received yet, at the cost it was shipped with",
+				},
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "invalid as_of, use YYYY-MM-DD or RFC3339 | Stock history starts at 2024-03-01T08:00:00Z, as_of must not be earlier"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"500": map[string]string{"error": "failed to get inventory valuation | failed to get in-transit valuation"},
+			},
+		},
+	})
+
+	// Export Inventory Valuation to Excel
+	r.Register(&router.Route{
+		Method:      "GET",
+		Path:        "/reports/inventory-valuation/export",
+		HandlerFunc: reportsHandler.ExportInventoryValuation,
+		Category:    "reports",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			QueryParameters: map[string]string{
+				"as_of":        "date|datetime (optional) - Value the stock at this moment instead of now. A date means the end of that day in UTC, give an RFC3339 moment with offset for another timezone",
+				"warehouse_id": "int32 (optional) - Only this warehouse",
+				"category_id":  "int32 (optional) - Only materials of this category",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status":       200,
+				"content_type": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+				"description":  "Excel file download (inventory_valuation_<date>.xlsx) with the Valuation, In Transit, By Category and By Warehouse sheets",
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "invalid as_of, use YYYY-MM-DD or RFC3339 | Stock history starts at 2024-03-01T08:00:00Z, as_of must not be earlier"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"500": map[string]string{"error": "failed to get inventory valuation | failed to get in-transit valuation"},
+			},
+		},
+	})
+
+	// Batch Traceability
+	r.Register(&router.Route{
+		Method:      "GET",
+		Path:        "/reports/batch-trace",
+		HandlerFunc: reportsHandler.GetBatchTrace,
+		Category:    "reports",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			QueryParameters: map[string]string{
+				"batch_number": "string (required) - Batch number to trace, every batch with this number is traced",
+				"material_id":  "int32 (optional) - Only batches of this material",
+				"direction":    "string (optional) - both (default), upstream or downstream",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body":   "{batch_number, direction, batches: [{id, batch_number, relation (origin|upstream|downstream), depth, material, warehouse, supplier, quantities, dates, hold_number}], movements: [{movement_id, batch_id, movement_type, direction, quantity, date, purchase_order, supplier, sales_order, customer, reversed}], receipts: [{party_id, party_name, order_id, order_number, batch, material, quantity, date}], shipments: [...same for customers], nodes: [{id, type (batch|supplier|purchase_order|warehouse|sales_order|customer), ref_id, label, relation}], edges: [{from, to, type (supplied|received|stored_in|TRANSFER|RETURN|PRODUCTION|shipped|sold_to), movement_id, quantity}]} - upstream follows batch genealogy to the supplier receipts, downstream to the sales orders and customers reached",
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "batch_number is required"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"404": map[string]string{"error": "batch not found"},
+				"500": map[string]string{"error": "failed to trace batches"},
+			},
+		},
+	})
+
+	// Export Batch Traceability
+	r.Register(&router.Route{
+		Method:      "GET",
+		Path:        "/reports/batch-trace/export",
+		HandlerFunc: reportsHandler.ExportBatchTrace,
+		Category:    "reports",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			QueryParameters: map[string]string{
+				"batch_number": "string (required) - Batch number to trace, every batch with this number is traced",
+				"material_id":  "int32 (optional) - Only batches of this material",
+				"direction":    "string (optional) - both (default), upstream or downstream",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status":       200,
+				"content_type": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+				"description":  "Excel file download (batch_trace_<batch number>.xlsx) with the Batches, Genealogy, Movements, Suppliers and Customers sheets",
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "batch_number is required"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"404": map[string]string{"error": "batch not found"},
+				"500": map[string]string{"error": "failed to trace batches"},
+			},
+		},
+	})
+
+	// Overdue Transfers
+	r.Register(&router.Route{
+		Method:      "GET",
+		Path:        "/reports/overdue-transfers",
+		HandlerFunc: reportsHandler.GetOverdueTransfers,
+		Category:    "reports",
+		Input: &router.RouteInput{
+			RequiredAuth: true,
+			QueryParameters: map[string]string{
+				"days":              "int (optional, default: TRANSFER_OVERDUE_DAYS) - Transfers in transit longer than this many days are overdue",
+				"from_warehouse_id": "int32 (optional) - Source warehouse ID",
+				"to_warehouse_id":   "int32 (optional) - Destination warehouse ID",
+				"material_id":       "int32 (optional) - Material ID",
+			},
+		},
+		Response: map[string]any{
+			"success": map[string]any{
+				"status": 200,
+				"body":   "days, shipped_before, total_value and transfers: shipments still In Transit that were shipped before shipped_before, with quantity, value at shipping cost and days_in_transit, longest in transit first",
+			},
+			"error": map[string]any{
+				"400": map[string]string{"error": "Invalid days | invalid from_warehouse_id | invalid to_warehouse_id | invalid material_id"},
+				"401": map[string]string{"error": "Unauthorized"},
+				"500": map[string]string{"error": "Failed to get overdue transfers"},
+			},
+		},
+	})
+
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"log/slog"
 	"net/http"
@@ -11,9 +12,12 @@ import (
 	"warehouse_system/internal/cache"
 	"warehouse_system/internal/config"
 	dbq "warehouse_system/internal/database/db"
+	"warehouse_system/internal/jobs"
 	"warehouse_system/internal/middlewares"
 	"warehouse_system/internal/observability"
 	"warehouse_system/internal/router"
+
+	"github.com/redis/go-redis/v9"
 )
 
 func main() {
@@ -38,6 +42,8 @@ func main() {
 		ConnectTimeout:    5 * time.Second,
 		MaxRetries:        3,
 		RetryDelay:        time.Second,
+		// Flags deadlocks and serialization failures for middlewares.TxRetry
+		Tracer: middlewares.TxConflictTracer{},
 	}
 	db, err := config.NewPool(&dbConfig)
 	if err != nil {
@@ -120,7 +126,7 @@ func main() {
 		AllowOrigins:     cfg.CORS.AllowedOrigins,
 		AllowMethods:     cfg.CORS.AllowedMethods,
 		AllowHeaders:     cfg.CORS.AllowedHeaders,
-		ExposeHeaders:    []string{"X-Request-ID", "X-Total-Count"},
+		ExposeHeaders:    []string{"X-Request-ID", "X-Total-Count", "Idempotent-Replayed"},
 		AllowCredentials: true,
 		MaxAge:           3600,
 	}
@@ -188,6 +194,45 @@ func main() {
 	// Register application routes
 	routes.SetupRoutes(r, db, queries, logger, cacheSystem, cfg)
 
+	// Background jobs (Redis queue, worker pool and scheduler)
+	jobsCtx, cancelJobs := context.WithCancel(context.Background())
+	defer cancelJobs()
+
+	jobQueue, err := jobs.NewRedisQueue(&jobs.RedisQueueConfig{
+		Client: redis.NewClient(&redis.Options{
+			Addr:     cfg.Redis.Addr,
+			Password: cfg.Redis.Password,
+			DB:       cfg.Redis.DB,
+		}),
+		Prefix:            "app:jobs:",
+		Logger:            logger,
+		VisibilityTimeout: 5 * time.Minute,
+		PollInterval:      time.Second,
+	})
+	if err != nil {
+		log.Fatalf("Failed to initialize job queue: %v", err)
+	}
+	defer jobQueue.Close()
+
+	jobRegistry := jobs.NewRegistry()
+	jobClient := jobs.NewClient(&jobs.ClientConfig{
+		Queue:    jobQueue,
+		Registry: jobRegistry,
+		WorkerPoolConfig: &jobs.WorkerPoolConfig{
+			NumWorkers:   2,
+			WorkerConfig: &jobs.WorkerConfig{JobTimeout: 5 * time.Minute, PollInterval: time.Second, Logger: logger},
+			Logger:       logger,
+		},
+		Logger: logger,
+	})
+	scheduler := jobs.NewScheduler(jobClient, logger)
+	routes.SetupJobs(jobRegistry, scheduler, db, queries, logger, cacheSystem, cfg)
+
+	jobClient.Start(jobsCtx)
+	defer jobClient.Stop()
+	scheduler.Start(jobsCtx)
+	defer scheduler.Stop()
+
 	logger.Info("Starting server", "port", cfg.Server.Port)
 
 	// Start server (this includes graceful shutdown handling)
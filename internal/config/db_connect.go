@@ -7,6 +7,7 @@ import (
 	"math"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -52,6 +53,9 @@ type DBConfig struct {
 	// Uses exponential backoff
 	// Default: 1 second
 	RetryDelay time.Duration
+
+	// Tracer is called for every query of the pool (optional)
+	Tracer pgx.QueryTracer
 }
 
 // DefaultDBConfig returns a default database configuration
@@ -146,6 +150,10 @@ func NewPool(config *DBConfig) (*pgxpool.Pool, error) {
 		dbConfig.ConnConfig.ConnectTimeout = config.ConnectTimeout
 	}
 
+	if config.Tracer != nil {
+		dbConfig.ConnConfig.Tracer = config.Tracer
+	}
+
 	// Retry logic with exponential backoff
 	var pool *pgxpool.Pool
 	var lastErr error
@@ -25,6 +25,7 @@ type Config struct {
 	Rendering  RenderingConfig
 	OpenAI     OpenAIConfig
 	Redis      RedisConfig
+	Inventory  InventoryConfig
 }
 
 // AppConfig holds application-level settings
@@ -112,6 +113,17 @@ type RedisConfig struct {
 	DB       int
 }
 
+// InventoryConfig holds stock transaction settings
+type InventoryConfig struct {
+	OverReceiptTolerancePercent float64       // how far a PO line may be over-received
+	ReplenishmentInterval       time.Duration // how often replenishment suggestions are generated, 0 = never
+	ExpiryWarningDays           int           // batches expiring within this many days are flagged
+	ExpiryCheckInterval         time.Duration // how often batch expiry is checked, 0 = never
+	TransferOverdueDays         int           // transfers in transit longer than this are overdue
+	IdempotencyCleanupInterval  time.Duration // how often expired idempotency keys are deleted, 0 = never
+	IdempotencyLease            time.Duration // how long a running request holds its idempotency key
+}
+
 // LoadConfig loads configuration from environment variables
 // Returns Config struct and error instead of mutating global state
 func LoadConfig(logger *slog.Logger) (*Config, error) {
@@ -151,6 +163,7 @@ func LoadConfig(logger *slog.Logger) (*Config, error) {
 	loadRenderingConfig(&config.Rendering, logger)
 	loadOpenAIConfig(&config.OpenAI, logger)
 	loadRedisConfig(&config.Redis, logger)
+	loadInventoryConfig(&config.Inventory, logger)
 	logger.Info("configuration loaded successfully",
 		"environment", config.App.Environment,
 		"version", config.App.Version,
@@ -297,7 +310,7 @@ func loadCORSConfig(cfg *CORSConfig, logger *slog.Logger) {
 	if headers := os.Getenv("CORS_ALLOWED_HEADERS"); headers != "" {
 		cfg.AllowedHeaders = splitAndTrim(headers, ",")
 	} else {
-		cfg.AllowedHeaders = []string{"Content-Type", "Authorization", "X-Requested-With"}
+		cfg.AllowedHeaders = []string{"Content-Type", "Authorization", "X-Requested-With", "Idempotency-Key"}
 	}
 
 	// Exposed Headers
@@ -375,6 +388,63 @@ func loadRedisConfig(cfg *RedisConfig, logger *slog.Logger) {
 	}
 }
 
+func loadInventoryConfig(cfg *InventoryConfig, logger *slog.Logger) {
+	cfg.OverReceiptTolerancePercent = getEnvAsFloat("PO_OVER_RECEIPT_TOLERANCE_PERCENT", 0)
+	if cfg.OverReceiptTolerancePercent < 0 {
+		logger.Warn("PO_OVER_RECEIPT_TOLERANCE_PERCENT is negative, using 0")
+		cfg.OverReceiptTolerancePercent = 0
+	}
+
+	minutes := getEnvAsInt("REPLENISHMENT_INTERVAL_MINUTES", 60)
+	if minutes < 0 {
+		logger.Warn("REPLENISHMENT_INTERVAL_MINUTES is negative, replenishment job disabled")
+		minutes = 0
+	}
+	cfg.ReplenishmentInterval = time.Duration(minutes) * time.Minute
+
+	cfg.ExpiryWarningDays = getEnvAsInt("EXPIRY_WARNING_DAYS", 30)
+	if cfg.ExpiryWarningDays < 0 {
+		logger.Warn("EXPIRY_WARNING_DAYS is negative, using 0")
+		cfg.ExpiryWarningDays = 0
+	}
+	minutes = getEnvAsInt("EXPIRY_CHECK_INTERVAL_MINUTES", 60)
+	if minutes < 0 {
+		logger.Warn("EXPIRY_CHECK_INTERVAL_MINUTES is negative, expiry job disabled")
+		minutes = 0
+	}
+	cfg.ExpiryCheckInterval = time.Duration(minutes) * time.Minute
+
+	cfg.TransferOverdueDays = getEnvAsInt("TRANSFER_OVERDUE_DAYS", 7)
+	if cfg.TransferOverdueDays < 0 {
+		logger.Warn("TRANSFER_OVERDUE_DAYS is negative, using 0")
+		cfg.TransferOverdueDays = 0
+	}
+
+	minutes = getEnvAsInt("IDEMPOTENCY_CLEANUP_INTERVAL_MINUTES", 60)
+	if minutes < 0 {
+		logger.Warn("IDEMPOTENCY_CLEANUP_INTERVAL_MINUTES is negative, idempotency cleanup job disabled")
+		minutes = 0
+	}
+	cfg.IdempotencyCleanupInterval = time.Duration(minutes) * time.Minute
+
+	minutes = getEnvAsInt("IDEMPOTENCY_LEASE_MINUTES", 10)
+	if minutes <= 0 {
+		logger.Warn("IDEMPOTENCY_LEASE_MINUTES must be positive, using 10")
+		minutes = 10
+	}
+	cfg.IdempotencyLease = time.Duration(minutes) * time.Minute
+
+	logger.Debug("inventory config loaded",
+		"over_receipt_tolerance_percent", cfg.OverReceiptTolerancePercent,
+		"replenishment_interval", cfg.ReplenishmentInterval.String(),
+		"expiry_warning_days", cfg.ExpiryWarningDays,
+		"expiry_check_interval", cfg.ExpiryCheckInterval.String(),
+		"transfer_overdue_days", cfg.TransferOverdueDays,
+		"idempotency_cleanup_interval", cfg.IdempotencyCleanupInterval.String(),
+		"idempotency_lease", cfg.IdempotencyLease.String(),
+	)
+}
+
 // Helper functions
 
 func getEnvAsInt(key string, defaultVal int) int {
@@ -395,6 +465,15 @@ func getEnvAsInt32(key string, defaultVal int32) int32 {
 	return defaultVal
 }
 
+func getEnvAsFloat(key string, defaultVal float64) float64 {
+	if val := os.Getenv(key); val != "" {
+		if parsed, err := strconv.ParseFloat(val, 64); err == nil {
+			return parsed
+		}
+	}
+	return defaultVal
+}
+
 func getEnvAsBool(key string, defaultVal bool) bool {
 	if val := os.Getenv(key); val != "" {
 		return val == "true" || val == "1" || val == "yes"
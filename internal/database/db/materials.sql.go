@@ -134,7 +134,7 @@ INSERT INTO materials (
     is_toxic, is_flammable, is_fragile,
     image_url, document_url,
     tax_rate, discount_rate,
-    is_active, meta
+    is_active, meta, is_serialized, allocation_strategy
 ) VALUES (
     $1, $2, $3, $4, $5,
     $6, $7, $8, $9, $10, $11,
@@ -142,7 +142,7 @@ INSERT INTO materials (
     $16, $17, $18,
     $19, $20,
     $21, $22,
-    $23, $24
+    $23, $24, $25, $26
 )
 RETURNING id, name, description, valuation, type, saleable,
     unit_price, sale_price, category, code, sku, barcode,
@@ -150,34 +150,36 @@ RETURNING id, name, description, valuation, type, saleable,
     is_toxic, is_flammable, is_fragile,
     image_url, document_url,
     tax_rate, discount_rate,
-    is_active, archived, meta, created_at, updated_at
+    is_active, archived, meta, created_at, updated_at, is_serialized, allocation_strategy
 `
 
 type CreateMaterialParams struct {
-	Name          string              `json:"name"`
-	Description   pgtype.Text         `json:"description"`
-	Valuation     NullValuationMethod `json:"valuation"`
-	Type          MaterialType        `json:"type"`
-	Saleable      pgtype.Bool         `json:"saleable"`
-	UnitPrice     pgtype.Numeric      `json:"unit_price"`
-	SalePrice     pgtype.Numeric      `json:"sale_price"`
-	Category      pgtype.Int4         `json:"category"`
-	Code          string              `json:"code"`
-	Sku           string              `json:"sku"`
-	Barcode       pgtype.Text         `json:"barcode"`
-	MeasureUnitID pgtype.Int4         `json:"measure_unit_id"`
-	Weight        pgtype.Numeric      `json:"weight"`
-	Volume        pgtype.Numeric      `json:"volume"`
-	Density       pgtype.Numeric      `json:"density"`
-	IsToxic       pgtype.Bool         `json:"is_toxic"`
-	IsFlammable   pgtype.Bool         `json:"is_flammable"`
-	IsFragile     pgtype.Bool         `json:"is_fragile"`
-	ImageUrl      pgtype.Text         `json:"image_url"`
-	DocumentUrl   pgtype.Text         `json:"document_url"`
-	TaxRate       pgtype.Numeric      `json:"tax_rate"`
-	DiscountRate  pgtype.Numeric      `json:"discount_rate"`
-	IsActive      pgtype.Bool         `json:"is_active"`
-	Meta          []byte              `json:"meta"`
+	Name               string                 `json:"name"`
+	Description        pgtype.Text            `json:"description"`
+	Valuation          NullValuationMethod    `json:"valuation"`
+	Type               MaterialType           `json:"type"`
+	Saleable           pgtype.Bool            `json:"saleable"`
+	UnitPrice          pgtype.Numeric         `json:"unit_price"`
+	SalePrice          pgtype.Numeric         `json:"sale_price"`
+	Category           pgtype.Int4            `json:"category"`
+	Code               string                 `json:"code"`
+	Sku                string                 `json:"sku"`
+	Barcode            pgtype.Text            `json:"barcode"`
+	MeasureUnitID      pgtype.Int4            `json:"measure_unit_id"`
+	Weight             pgtype.Numeric         `json:"weight"`
+	Volume             pgtype.Numeric         `json:"volume"`
+	Density            pgtype.Numeric         `json:"density"`
+	IsToxic            pgtype.Bool            `json:"is_toxic"`
+	IsFlammable        pgtype.Bool            `json:"is_flammable"`
+	IsFragile          pgtype.Bool            `json:"is_fragile"`
+	ImageUrl           pgtype.Text            `json:"image_url"`
+	DocumentUrl        pgtype.Text            `json:"document_url"`
+	TaxRate            pgtype.Numeric         `json:"tax_rate"`
+	DiscountRate       pgtype.Numeric         `json:"discount_rate"`
+	IsActive           pgtype.Bool            `json:"is_active"`
+	Meta               []byte                 `json:"meta"`
+	IsSerialized       bool                   `json:"is_serialized"`
+	AllocationStrategy NullAllocationStrategy `json:"allocation_strategy"`
 }
 
 func (q *Queries) CreateMaterial(ctx context.Context, arg CreateMaterialParams) (Material, error) {
@@ -206,6 +208,8 @@ func (q *Queries) CreateMaterial(ctx context.Context, arg CreateMaterialParams)
 		arg.DiscountRate,
 		arg.IsActive,
 		arg.Meta,
+		arg.IsSerialized,
+		arg.AllocationStrategy,
 	)
 	var i Material
 	err := row.Scan(
@@ -237,6 +241,8 @@ func (q *Queries) CreateMaterial(ctx context.Context, arg CreateMaterialParams)
 		&i.Meta,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.IsSerialized,
+		&i.AllocationStrategy,
 	)
 	return i, err
 }
@@ -421,7 +427,7 @@ SELECT
     m.image_url, m.document_url,
     m.tax_rate, m.discount_rate,
     m.is_active, m.archived, m.meta,
-    m.created_at, m.updated_at
+    m.created_at, m.updated_at, m.is_serialized, m.allocation_strategy
 FROM materials m
 LEFT JOIN material_categories mc ON m.category = mc.id
 LEFT JOIN measure_units mu ON m.measure_unit_id = mu.id
@@ -429,37 +435,39 @@ WHERE m.id = $1 AND m.archived = FALSE
 `
 
 type GetMaterialByIDRow struct {
-	ID               int32               `json:"id"`
-	Name             string              `json:"name"`
-	Description      pgtype.Text         `json:"description"`
-	Valuation        NullValuationMethod `json:"valuation"`
-	Type             MaterialType        `json:"type"`
-	Saleable         pgtype.Bool         `json:"saleable"`
-	UnitPrice        pgtype.Numeric      `json:"unit_price"`
-	SalePrice        pgtype.Numeric      `json:"sale_price"`
-	Category         pgtype.Int4         `json:"category"`
-	CategoryName     pgtype.Text         `json:"category_name"`
-	Code             string              `json:"code"`
-	Sku              string              `json:"sku"`
-	Barcode          pgtype.Text         `json:"barcode"`
-	MeasureUnitID    pgtype.Int4         `json:"measure_unit_id"`
-	UnitName         pgtype.Text         `json:"unit_name"`
-	UnitAbbreviation pgtype.Text         `json:"unit_abbreviation"`
-	Weight           pgtype.Numeric      `json:"weight"`
-	Volume           pgtype.Numeric      `json:"volume"`
-	Density          pgtype.Numeric      `json:"density"`
-	IsToxic          pgtype.Bool         `json:"is_toxic"`
-	IsFlammable      pgtype.Bool         `json:"is_flammable"`
-	IsFragile        pgtype.Bool         `json:"is_fragile"`
-	ImageUrl         pgtype.Text         `json:"image_url"`
-	DocumentUrl      pgtype.Text         `json:"document_url"`
-	TaxRate          pgtype.Numeric      `json:"tax_rate"`
-	DiscountRate     pgtype.Numeric      `json:"discount_rate"`
-	IsActive         pgtype.Bool         `json:"is_active"`
-	Archived         pgtype.Bool         `json:"archived"`
-	Meta             []byte              `json:"meta"`
-	CreatedAt        pgtype.Timestamptz  `json:"created_at"`
-	UpdatedAt        pgtype.Timestamptz  `json:"updated_at"`
+	ID                 int32                  `json:"id"`
+	Name               string                 `json:"name"`
+	Description        pgtype.Text            `json:"description"`
+	Valuation          NullValuationMethod    `json:"valuation"`
+	Type               MaterialType           `json:"type"`
+	Saleable           pgtype.Bool            `json:"saleable"`
+	UnitPrice          pgtype.Numeric         `json:"unit_price"`
+	SalePrice          pgtype.Numeric         `json:"sale_price"`
+	Category           pgtype.Int4            `json:"category"`
+	CategoryName       pgtype.Text            `json:"category_name"`
+	Code               string                 `json:"code"`
+	Sku                string                 `json:"sku"`
+	Barcode            pgtype.Text            `json:"barcode"`
+	MeasureUnitID      pgtype.Int4            `json:"measure_unit_id"`
+	UnitName           pgtype.Text            `json:"unit_name"`
+	UnitAbbreviation   pgtype.Text            `json:"unit_abbreviation"`
+	Weight             pgtype.Numeric         `json:"weight"`
+	Volume             pgtype.Numeric         `json:"volume"`
+	Density            pgtype.Numeric         `json:"density"`
+	IsToxic            pgtype.Bool            `json:"is_toxic"`
+	IsFlammable        pgtype.Bool            `json:"is_flammable"`
+	IsFragile          pgtype.Bool            `json:"is_fragile"`
+	ImageUrl           pgtype.Text            `json:"image_url"`
+	DocumentUrl        pgtype.Text            `json:"document_url"`
+	TaxRate            pgtype.Numeric         `json:"tax_rate"`
+	DiscountRate       pgtype.Numeric         `json:"discount_rate"`
+	IsActive           pgtype.Bool            `json:"is_active"`
+	Archived           pgtype.Bool            `json:"archived"`
+	Meta               []byte                 `json:"meta"`
+	CreatedAt          pgtype.Timestamptz     `json:"created_at"`
+	UpdatedAt          pgtype.Timestamptz     `json:"updated_at"`
+	IsSerialized       bool                   `json:"is_serialized"`
+	AllocationStrategy NullAllocationStrategy `json:"allocation_strategy"`
 }
 
 func (q *Queries) GetMaterialByID(ctx context.Context, id int32) (GetMaterialByIDRow, error) {
@@ -497,6 +505,8 @@ func (q *Queries) GetMaterialByID(ctx context.Context, id int32) (GetMaterialByI
 		&i.Meta,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.IsSerialized,
+		&i.AllocationStrategy,
 	)
 	return i, err
 }
@@ -789,6 +799,8 @@ SET
     discount_rate = COALESCE($23, discount_rate),
     is_active = COALESCE($24, is_active),
     meta = COALESCE($25, meta),
+    is_serialized = COALESCE($26, is_serialized),
+    allocation_strategy = CASE WHEN $28::boolean THEN NULL ELSE COALESCE($27, allocation_strategy) END,
     updated_at = CURRENT_TIMESTAMP
 WHERE id = $1 AND archived = FALSE
 RETURNING id, name, description, valuation, type, saleable,
@@ -797,35 +809,38 @@ RETURNING id, name, description, valuation, type, saleable,
     is_toxic, is_flammable, is_fragile,
     image_url, document_url,
     tax_rate, discount_rate,
-    is_active, archived, meta, created_at, updated_at
+    is_active, archived, meta, created_at, updated_at, is_serialized, allocation_strategy
 `
 
 type UpdateMaterialParams struct {
-	ID            int32               `json:"id"`
-	Column2       interface{}         `json:"column_2"`
-	Description   pgtype.Text         `json:"description"`
-	Valuation     NullValuationMethod `json:"valuation"`
-	Type          MaterialType        `json:"type"`
-	Saleable      pgtype.Bool         `json:"saleable"`
-	UnitPrice     pgtype.Numeric      `json:"unit_price"`
-	SalePrice     pgtype.Numeric      `json:"sale_price"`
-	Category      pgtype.Int4         `json:"category"`
-	Column10      interface{}         `json:"column_10"`
-	Column11      interface{}         `json:"column_11"`
-	Barcode       pgtype.Text         `json:"barcode"`
-	MeasureUnitID pgtype.Int4         `json:"measure_unit_id"`
-	Weight        pgtype.Numeric      `json:"weight"`
-	Volume        pgtype.Numeric      `json:"volume"`
-	Density       pgtype.Numeric      `json:"density"`
-	IsToxic       pgtype.Bool         `json:"is_toxic"`
-	IsFlammable   pgtype.Bool         `json:"is_flammable"`
-	IsFragile     pgtype.Bool         `json:"is_fragile"`
-	ImageUrl      pgtype.Text         `json:"image_url"`
-	DocumentUrl   pgtype.Text         `json:"document_url"`
-	TaxRate       pgtype.Numeric      `json:"tax_rate"`
-	DiscountRate  pgtype.Numeric      `json:"discount_rate"`
-	IsActive      pgtype.Bool         `json:"is_active"`
-	Meta          []byte              `json:"meta"`
+	ID                 int32                  `json:"id"`
+	Column2            interface{}            `json:"column_2"`
+	Description        pgtype.Text            `json:"description"`
+	Valuation          NullValuationMethod    `json:"valuation"`
+	Type               MaterialType           `json:"type"`
+	Saleable           pgtype.Bool            `json:"saleable"`
+	UnitPrice          pgtype.Numeric         `json:"unit_price"`
+	SalePrice          pgtype.Numeric         `json:"sale_price"`
+	Category           pgtype.Int4            `json:"category"`
+	Column10           interface{}            `json:"column_10"`
+	Column11           interface{}            `json:"column_11"`
+	Barcode            pgtype.Text            `json:"barcode"`
+	MeasureUnitID      pgtype.Int4            `json:"measure_unit_id"`
+	Weight             pgtype.Numeric         `json:"weight"`
+	Volume             pgtype.Numeric         `json:"volume"`
+	Density            pgtype.Numeric         `json:"density"`
+	IsToxic            pgtype.Bool            `json:"is_toxic"`
+	IsFlammable        pgtype.Bool            `json:"is_flammable"`
+	IsFragile          pgtype.Bool            `json:"is_fragile"`
+	ImageUrl           pgtype.Text            `json:"image_url"`
+	DocumentUrl        pgtype.Text            `json:"document_url"`
+	TaxRate            pgtype.Numeric         `json:"tax_rate"`
+	DiscountRate       pgtype.Numeric         `json:"discount_rate"`
+	IsActive           pgtype.Bool            `json:"is_active"`
+	Meta               []byte                 `json:"meta"`
+	IsSerialized       pgtype.Bool            `json:"is_serialized"`
+	AllocationStrategy NullAllocationStrategy `json:"allocation_strategy"`
+	Column28           bool                   `json:"column_28"`
 }
 
 // ============================================================================
@@ -858,6 +873,9 @@ func (q *Queries) UpdateMaterial(ctx context.Context, arg UpdateMaterialParams)
 		arg.DiscountRate,
 		arg.IsActive,
 		arg.Meta,
+		arg.IsSerialized,
+		arg.AllocationStrategy,
+		arg.Column28,
 	)
 	var i Material
 	err := row.Scan(
@@ -889,6 +907,8 @@ func (q *Queries) UpdateMaterial(ctx context.Context, arg UpdateMaterialParams)
 		&i.Meta,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.IsSerialized,
+		&i.AllocationStrategy,
 	)
 	return i, err
 }
@@ -11,6 +11,49 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type AllocationStrategy string
+
+const (
+	AllocationStrategyFIFO AllocationStrategy = "FIFO"
+	AllocationStrategyLIFO AllocationStrategy = "LIFO"
+	AllocationStrategyFEFO AllocationStrategy = "FEFO"
+)
+
+func (e *AllocationStrategy) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = AllocationStrategy(s)
+	case string:
+		*e = AllocationStrategy(s)
+	default:
+		return fmt.Errorf("unsupported scan type for AllocationStrategy: %T", src)
+	}
+	return nil
+}
+
+type NullAllocationStrategy struct {
+	AllocationStrategy AllocationStrategy `json:"allocation_strategy"`
+	Valid              bool               `json:"valid"` // Valid is true if AllocationStrategy is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullAllocationStrategy) Scan(value interface{}) error {
+	if value == nil {
+		ns.AllocationStrategy, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.AllocationStrategy.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullAllocationStrategy) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.AllocationStrategy), nil
+}
+
 type CalibrationStatus string
 
 const (
@@ -560,15 +603,20 @@ func (ns NullStockDirection) Value() (driver.Value, error) {
 type StockMovementType string
 
 const (
-	StockMovementTypeOPENING         StockMovementType = "OPENING"
-	StockMovementTypePURCHASERECEIPT StockMovementType = "PURCHASE_RECEIPT"
-	StockMovementTypeSALE            StockMovementType = "SALE"
-	StockMovementTypeCUSTOMERRETURN  StockMovementType = "CUSTOMER_RETURN"
-	StockMovementTypeTRANSFERIN      StockMovementType = "TRANSFER_IN"
-	StockMovementTypeTRANSFEROUT     StockMovementType = "TRANSFER_OUT"
-	StockMovementTypeSCRAP           StockMovementType = "SCRAP"
-	StockMovementTypeADJUSTMENTIN    StockMovementType = "ADJUSTMENT_IN"
-	StockMovementTypeADJUSTMENTOUT   StockMovementType = "ADJUSTMENT_OUT"
+	StockMovementTypeOPENING           StockMovementType = "OPENING"
+	StockMovementTypePURCHASERECEIPT   StockMovementType = "PURCHASE_RECEIPT"
+	StockMovementTypeSALE              StockMovementType = "SALE"
+	StockMovementTypeCUSTOMERRETURN    StockMovementType = "CUSTOMER_RETURN"
+	StockMovementTypeTRANSFERIN        StockMovementType = "TRANSFER_IN"
+	StockMovementTypeTRANSFEROUT       StockMovementType = "TRANSFER_OUT"
+	StockMovementTypeSCRAP             StockMovementType = "SCRAP"
+	StockMovementTypeADJUSTMENTIN      StockMovementType = "ADJUSTMENT_IN"
+	StockMovementTypeADJUSTMENTOUT     StockMovementType = "ADJUSTMENT_OUT"
+	StockMovementTypeREVERSAL          StockMovementType = "REVERSAL"
+	StockMovementTypePRODUCTIONISSUE   StockMovementType = "PRODUCTION_ISSUE"
+	StockMovementTypePRODUCTIONRECEIPT StockMovementType = "PRODUCTION_RECEIPT"
+	StockMovementTypeSUPPLIERRETURN    StockMovementType = "SUPPLIER_RETURN"
+	StockMovementTypeTRANSFERLOSS      StockMovementType = "TRANSFER_LOSS"
 )
 
 func (e *StockMovementType) Scan(src interface{}) error {
@@ -832,6 +880,24 @@ type Batch struct {
 	Meta            []byte             `json:"meta"`
 	CreatedAt       pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+	BinID           pgtype.Int4        `json:"bin_id"`
+}
+
+type BatchExpiryFlag struct {
+	BatchID   int32              `json:"batch_id"`
+	FlaggedAt pgtype.Timestamptz `json:"flagged_at"`
+	HoldID    pgtype.Int4        `json:"hold_id"`
+	HeldAt    pgtype.Timestamptz `json:"held_at"`
+}
+
+type BatchGenealogy struct {
+	ID            int32              `json:"id"`
+	ParentBatchID int32              `json:"parent_batch_id"`
+	ChildBatchID  int32              `json:"child_batch_id"`
+	LinkType      string             `json:"link_type"`
+	MovementID    pgtype.Int4        `json:"movement_id"`
+	Quantity      pgtype.Numeric     `json:"quantity"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
 }
 
 type BillsOfMaterial struct {
@@ -898,6 +964,36 @@ type CertificatesOfAnalysis struct {
 	UpdatedAt           pgtype.Timestamptz `json:"updated_at"`
 }
 
+type CountSession struct {
+	ID            int32              `json:"id"`
+	SessionNumber string             `json:"session_number"`
+	WarehouseID   int32              `json:"warehouse_id"`
+	CategoryID    pgtype.Int4        `json:"category_id"`
+	AbcClass      pgtype.Text        `json:"abc_class"`
+	Status        string             `json:"status"`
+	CreatedBy     pgtype.Int4        `json:"created_by"`
+	ApprovedBy    pgtype.Int4        `json:"approved_by"`
+	ApprovedAt    pgtype.Timestamptz `json:"approved_at"`
+	Notes         pgtype.Text        `json:"notes"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
+}
+
+type CountSessionLine struct {
+	ID                   int32              `json:"id"`
+	SessionID            int32              `json:"session_id"`
+	MaterialID           int32              `json:"material_id"`
+	BatchID              int32              `json:"batch_id"`
+	SnapshotQuantity     pgtype.Numeric     `json:"snapshot_quantity"`
+	CountedQuantity      pgtype.Numeric     `json:"counted_quantity"`
+	CountedBy            pgtype.Int4        `json:"counted_by"`
+	CountedAt            pgtype.Timestamptz `json:"counted_at"`
+	AdjustmentMovementID pgtype.Int4        `json:"adjustment_movement_id"`
+	Notes                pgtype.Text        `json:"notes"`
+	CreatedAt            pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt            pgtype.Timestamptz `json:"updated_at"`
+}
+
 type Customer struct {
 	ID           int32              `json:"id"`
 	Name         string             `json:"name"`
@@ -911,6 +1007,20 @@ type Customer struct {
 }
 
 // Laboratory instruments and equipment with calibration tracking
+type IdempotencyKey struct {
+	ID             int32              `json:"id"`
+	UserID         int32              `json:"user_id"`
+	IdempotencyKey string             `json:"idempotency_key"`
+	Method         string             `json:"method"`
+	Path           string             `json:"path"`
+	RequestHash    string             `json:"request_hash"`
+	StatusCode     pgtype.Int4        `json:"status_code"`
+	ResponseBody   []byte             `json:"response_body"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	CompletedAt    pgtype.Timestamptz `json:"completed_at"`
+	LeaseExpiresAt pgtype.Timestamptz `json:"lease_expires_at"`
+}
+
 type LabEquipment struct {
 	ID                       int32                 `json:"id"`
 	EquipmentCode            string                `json:"equipment_code"`
@@ -1075,34 +1185,46 @@ type LabTestResult struct {
 }
 
 type Material struct {
-	ID            int32               `json:"id"`
-	Name          string              `json:"name"`
-	Description   pgtype.Text         `json:"description"`
-	Valuation     NullValuationMethod `json:"valuation"`
-	Type          MaterialType        `json:"type"`
-	Saleable      pgtype.Bool         `json:"saleable"`
-	UnitPrice     pgtype.Numeric      `json:"unit_price"`
-	SalePrice     pgtype.Numeric      `json:"sale_price"`
-	Category      pgtype.Int4         `json:"category"`
-	Code          string              `json:"code"`
-	Sku           string              `json:"sku"`
-	Barcode       pgtype.Text         `json:"barcode"`
-	MeasureUnitID pgtype.Int4         `json:"measure_unit_id"`
-	Weight        pgtype.Numeric      `json:"weight"`
-	Volume        pgtype.Numeric      `json:"volume"`
-	Density       pgtype.Numeric      `json:"density"`
-	IsToxic       pgtype.Bool         `json:"is_toxic"`
-	IsFlammable   pgtype.Bool         `json:"is_flammable"`
-	IsFragile     pgtype.Bool         `json:"is_fragile"`
-	ImageUrl      pgtype.Text         `json:"image_url"`
-	DocumentUrl   pgtype.Text         `json:"document_url"`
-	TaxRate       pgtype.Numeric      `json:"tax_rate"`
-	DiscountRate  pgtype.Numeric      `json:"discount_rate"`
-	IsActive      pgtype.Bool         `json:"is_active"`
-	Archived      pgtype.Bool         `json:"archived"`
-	Meta          []byte              `json:"meta"`
-	CreatedAt     pgtype.Timestamptz  `json:"created_at"`
-	UpdatedAt     pgtype.Timestamptz  `json:"updated_at"`
+	ID                 int32                  `json:"id"`
+	Name               string                 `json:"name"`
+	Description        pgtype.Text            `json:"description"`
+	Valuation          NullValuationMethod    `json:"valuation"`
+	Type               MaterialType           `json:"type"`
+	Saleable           pgtype.Bool            `json:"saleable"`
+	UnitPrice          pgtype.Numeric         `json:"unit_price"`
+	SalePrice          pgtype.Numeric         `json:"sale_price"`
+	Category           pgtype.Int4            `json:"category"`
+	Code               string                 `json:"code"`
+	Sku                string                 `json:"sku"`
+	Barcode            pgtype.Text            `json:"barcode"`
+	MeasureUnitID      pgtype.Int4            `json:"measure_unit_id"`
+	Weight             pgtype.Numeric         `json:"weight"`
+	Volume             pgtype.Numeric         `json:"volume"`
+	Density            pgtype.Numeric         `json:"density"`
+	IsToxic            pgtype.Bool            `json:"is_toxic"`
+	IsFlammable        pgtype.Bool            `json:"is_flammable"`
+	IsFragile          pgtype.Bool            `json:"is_fragile"`
+	ImageUrl           pgtype.Text            `json:"image_url"`
+	DocumentUrl        pgtype.Text            `json:"document_url"`
+	TaxRate            pgtype.Numeric         `json:"tax_rate"`
+	DiscountRate       pgtype.Numeric         `json:"discount_rate"`
+	IsActive           pgtype.Bool            `json:"is_active"`
+	Archived           pgtype.Bool            `json:"archived"`
+	Meta               []byte                 `json:"meta"`
+	CreatedAt          pgtype.Timestamptz     `json:"created_at"`
+	UpdatedAt          pgtype.Timestamptz     `json:"updated_at"`
+	IsSerialized       bool                   `json:"is_serialized"`
+	AllocationStrategy NullAllocationStrategy `json:"allocation_strategy"`
+}
+
+type MaterialAverageCost struct {
+	MaterialID      int32              `json:"material_id"`
+	WarehouseID     int32              `json:"warehouse_id"`
+	OnHandQuantity  pgtype.Numeric     `json:"on_hand_quantity"`
+	TotalValue      pgtype.Numeric     `json:"total_value"`
+	AverageUnitCost pgtype.Numeric     `json:"average_unit_cost"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
 }
 
 type MaterialCategory struct {
@@ -1115,6 +1237,34 @@ type MaterialCategory struct {
 }
 
 // Link materials to their required quality criteria
+type MaterialCostLedger struct {
+	ID              int32              `json:"id"`
+	MaterialID      int32              `json:"material_id"`
+	WarehouseID     int32              `json:"warehouse_id"`
+	MovementID      pgtype.Int4        `json:"movement_id"`
+	QuantityChange  pgtype.Numeric     `json:"quantity_change"`
+	UnitCost        pgtype.Numeric     `json:"unit_cost"`
+	ValueChange     pgtype.Numeric     `json:"value_change"`
+	OnHandQuantity  pgtype.Numeric     `json:"on_hand_quantity"`
+	TotalValue      pgtype.Numeric     `json:"total_value"`
+	AverageUnitCost pgtype.Numeric     `json:"average_unit_cost"`
+	CreatedAt       pgtype.Timestamptz `json:"created_at"`
+}
+
+type MaterialPlanningParameter struct {
+	ID                  int32              `json:"id"`
+	MaterialID          int32              `json:"material_id"`
+	WarehouseID         int32              `json:"warehouse_id"`
+	MinQuantity         pgtype.Numeric     `json:"min_quantity"`
+	MaxQuantity         pgtype.Numeric     `json:"max_quantity"`
+	ReorderPoint        pgtype.Numeric     `json:"reorder_point"`
+	ReorderQuantity     pgtype.Numeric     `json:"reorder_quantity"`
+	PreferredSupplierID pgtype.Int4        `json:"preferred_supplier_id"`
+	IsActive            bool               `json:"is_active"`
+	CreatedAt           pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt           pgtype.Timestamptz `json:"updated_at"`
+}
+
 type MaterialQualitySpec struct {
 	ID                 int32              `json:"id"`
 	MaterialID         int32              `json:"material_id"`
@@ -1327,6 +1477,24 @@ type QualityInspectionResult struct {
 	CreatedAt     pgtype.Timestamptz `json:"created_at"`
 }
 
+type ReplenishmentSuggestion struct {
+	ID                int32              `json:"id"`
+	MaterialID        int32              `json:"material_id"`
+	WarehouseID       int32              `json:"warehouse_id"`
+	SupplierID        pgtype.Int4        `json:"supplier_id"`
+	OnHandQuantity    pgtype.Numeric     `json:"on_hand_quantity"`
+	OnOrderQuantity   pgtype.Numeric     `json:"on_order_quantity"`
+	ReorderPoint      pgtype.Numeric     `json:"reorder_point"`
+	SuggestedQuantity pgtype.Numeric     `json:"suggested_quantity"`
+	UnitPrice         pgtype.Numeric     `json:"unit_price"`
+	Status            string             `json:"status"`
+	PurchaseOrderID   pgtype.Int4        `json:"purchase_order_id"`
+	ConvertedBy       pgtype.Int4        `json:"converted_by"`
+	ConvertedAt       pgtype.Timestamptz `json:"converted_at"`
+	CreatedAt         pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt         pgtype.Timestamptz `json:"updated_at"`
+}
+
 type SalesOrder struct {
 	ID                   int32              `json:"id"`
 	OrderNumber          string             `json:"order_number"`
@@ -1355,6 +1523,17 @@ type SalesOrderItem struct {
 }
 
 // Individual time-point samples within stability studies
+type SerialNumber struct {
+	ID           int32              `json:"id"`
+	MaterialID   int32              `json:"material_id"`
+	SerialNumber string             `json:"serial_number"`
+	BatchID      pgtype.Int4        `json:"batch_id"`
+	WarehouseID  pgtype.Int4        `json:"warehouse_id"`
+	Status       string             `json:"status"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt    pgtype.Timestamptz `json:"updated_at"`
+}
+
 type StabilitySample struct {
 	ID                   int32              `json:"id"`
 	StabilityStudyID     int32              `json:"stability_study_id"`
@@ -1402,19 +1581,66 @@ type StabilityStudy struct {
 }
 
 type StockMovement struct {
-	ID              int32              `json:"id"`
-	MaterialID      pgtype.Int4        `json:"material_id"`
-	FromWarehouseID pgtype.Int4        `json:"from_warehouse_id"`
-	ToWarehouseID   pgtype.Int4        `json:"to_warehouse_id"`
-	Quantity        pgtype.Numeric     `json:"quantity"`
-	StockDirection  StockDirection     `json:"stock_direction"`
-	MovementType    StockMovementType  `json:"movement_type"`
-	Reference       pgtype.Text        `json:"reference"`
-	PerformedBy     pgtype.Int4        `json:"performed_by"`
-	MovementDate    pgtype.Timestamptz `json:"movement_date"`
-	Notes           pgtype.Text        `json:"notes"`
-	CreatedAt       pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+	ID                   int32              `json:"id"`
+	MaterialID           pgtype.Int4        `json:"material_id"`
+	FromWarehouseID      pgtype.Int4        `json:"from_warehouse_id"`
+	ToWarehouseID        pgtype.Int4        `json:"to_warehouse_id"`
+	Quantity             pgtype.Numeric     `json:"quantity"`
+	StockDirection       StockDirection     `json:"stock_direction"`
+	MovementType         StockMovementType  `json:"movement_type"`
+	Reference            pgtype.Text        `json:"reference"`
+	PerformedBy          pgtype.Int4        `json:"performed_by"`
+	MovementDate         pgtype.Timestamptz `json:"movement_date"`
+	Notes                pgtype.Text        `json:"notes"`
+	CreatedAt            pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt            pgtype.Timestamptz `json:"updated_at"`
+	UnitCost             pgtype.Numeric     `json:"unit_cost"`
+	TotalCost            pgtype.Numeric     `json:"total_cost"`
+	SalesOrderItemID     pgtype.Int4        `json:"sales_order_item_id"`
+	PurchaseOrderItemID  pgtype.Int4        `json:"purchase_order_item_id"`
+	ReversesMovementID   pgtype.Int4        `json:"reverses_movement_id"`
+	ReversedByMovementID pgtype.Int4        `json:"reversed_by_movement_id"`
+	FromBinID            pgtype.Int4        `json:"from_bin_id"`
+	ToBinID              pgtype.Int4        `json:"to_bin_id"`
+	EnteredQuantity      pgtype.Numeric     `json:"entered_quantity"`
+	EnteredUnitID        pgtype.Int4        `json:"entered_unit_id"`
+	WorkOrderID          pgtype.Int4        `json:"work_order_id"`
+	TransferShipmentID   pgtype.Int4        `json:"transfer_shipment_id"`
+	NcrID                pgtype.Int4        `json:"ncr_id"`
+	PairedMovementID     pgtype.Int4        `json:"paired_movement_id"`
+}
+
+type StockMovementBatch struct {
+	ID         int32              `json:"id"`
+	MovementID int32              `json:"movement_id"`
+	BatchID    int32              `json:"batch_id"`
+	Quantity   pgtype.Numeric     `json:"quantity"`
+	UnitCost   pgtype.Numeric     `json:"unit_cost"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+}
+
+type StockMovementSerial struct {
+	ID             int32              `json:"id"`
+	MovementID     int32              `json:"movement_id"`
+	SerialNumberID int32              `json:"serial_number_id"`
+	BatchID        pgtype.Int4        `json:"batch_id"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+}
+
+type StockReservation struct {
+	ID                int32              `json:"id"`
+	SalesOrderItemID  int32              `json:"sales_order_item_id"`
+	MaterialID        int32              `json:"material_id"`
+	WarehouseID       int32              `json:"warehouse_id"`
+	BatchID           pgtype.Int4        `json:"batch_id"`
+	Quantity          pgtype.Numeric     `json:"quantity"`
+	FulfilledQuantity pgtype.Numeric     `json:"fulfilled_quantity"`
+	Status            string             `json:"status"`
+	ReservedBy        pgtype.Int4        `json:"reserved_by"`
+	ReleasedAt        pgtype.Timestamptz `json:"released_at"`
+	Notes             pgtype.Text        `json:"notes"`
+	CreatedAt         pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt         pgtype.Timestamptz `json:"updated_at"`
 }
 
 type Supplier struct {
@@ -1456,6 +1682,29 @@ type SupplierQualityRating struct {
 	UpdatedAt             pgtype.Timestamptz `json:"updated_at"`
 }
 
+type TransferShipment struct {
+	ID                int32              `json:"id"`
+	ShipmentNumber    string             `json:"shipment_number"`
+	MaterialID        int32              `json:"material_id"`
+	FromWarehouseID   int32              `json:"from_warehouse_id"`
+	ToWarehouseID     int32              `json:"to_warehouse_id"`
+	FromBinID         pgtype.Int4        `json:"from_bin_id"`
+	ToBinID           pgtype.Int4        `json:"to_bin_id"`
+	Quantity          pgtype.Numeric     `json:"quantity"`
+	ReceivedQuantity  pgtype.Numeric     `json:"received_quantity"`
+	UnitCost          pgtype.Numeric     `json:"unit_cost"`
+	Status            string             `json:"status"`
+	ExpectedDate      pgtype.Date        `json:"expected_date"`
+	DiscrepancyReason pgtype.Text        `json:"discrepancy_reason"`
+	Notes             pgtype.Text        `json:"notes"`
+	ShippedBy         pgtype.Int4        `json:"shipped_by"`
+	ReceivedBy        pgtype.Int4        `json:"received_by"`
+	ShippedAt         pgtype.Timestamptz `json:"shipped_at"`
+	ReceivedAt        pgtype.Timestamptz `json:"received_at"`
+	CreatedAt         pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt         pgtype.Timestamptz `json:"updated_at"`
+}
+
 type User struct {
 	ID           int32              `json:"id"`
 	Username     string             `json:"username"`
@@ -1495,6 +1744,26 @@ type VAnalystWorkload struct {
 	EarliestDueDate  interface{} `json:"earliest_due_date"`
 }
 
+type VBatchesOnHold struct {
+	BatchID       int32          `json:"batch_id"`
+	MaterialID    pgtype.Int4    `json:"material_id"`
+	WarehouseID   pgtype.Int4    `json:"warehouse_id"`
+	BatchNumber   string         `json:"batch_number"`
+	HeldQuantity  pgtype.Numeric `json:"held_quantity"`
+	HoldID        int32          `json:"hold_id"`
+	HoldNumber    string         `json:"hold_number"`
+	QualityStatus QualityStatus  `json:"quality_status"`
+	HoldReason    string         `json:"hold_reason"`
+}
+
+type VBinStock struct {
+	BinID       int32          `json:"bin_id"`
+	WarehouseID int32          `json:"warehouse_id"`
+	MaterialID  pgtype.Int4    `json:"material_id"`
+	Quantity    pgtype.Numeric `json:"quantity"`
+	BatchCount  int64          `json:"batch_count"`
+}
+
 type VBomCostAnalysis struct {
 	FinishedMaterialID    pgtype.Int4    `json:"finished_material_id"`
 	FinishedMaterialName  pgtype.Text    `json:"finished_material_name"`
@@ -1563,6 +1832,13 @@ type VEquipmentCalibrationDue struct {
 	IsOperational       pgtype.Bool           `json:"is_operational"`
 }
 
+type VMaterialAbcClass struct {
+	MaterialID       pgtype.Int4    `json:"material_id"`
+	WarehouseID      pgtype.Int4    `json:"warehouse_id"`
+	AnnualUsageValue pgtype.Numeric `json:"annual_usage_value"`
+	AbcClass         string         `json:"abc_class"`
+}
+
 type VMaterialQualitySummary struct {
 	MaterialID        int32          `json:"material_id"`
 	MaterialName      string         `json:"material_name"`
@@ -1591,6 +1867,17 @@ type VPendingLabWork struct {
 	IsOverdue    bool                 `json:"is_overdue"`
 }
 
+type VReservedBatch struct {
+	BatchID          pgtype.Int4    `json:"batch_id"`
+	ReservedQuantity pgtype.Numeric `json:"reserved_quantity"`
+}
+
+type VReservedStock struct {
+	MaterialID       int32          `json:"material_id"`
+	WarehouseID      int32          `json:"warehouse_id"`
+	ReservedQuantity pgtype.Numeric `json:"reserved_quantity"`
+}
+
 type VSampleRetentionStatus struct {
 	SampleNumber        string              `json:"sample_number"`
 	SampleType          LabSampleType       `json:"sample_type"`
@@ -1618,15 +1905,69 @@ type VSupplierQualitySummary struct {
 }
 
 type Warehouse struct {
-	ID              int32              `json:"id"`
-	Name            string             `json:"name"`
-	Code            string             `json:"code"`
-	Location        pgtype.Text        `json:"location"`
-	Description     pgtype.Text        `json:"description"`
-	Valuation       ValuationMethod    `json:"valuation"`
-	ParentWarehouse pgtype.Int4        `json:"parent_warehouse"`
-	Capacity        pgtype.Numeric     `json:"capacity"`
-	Meta            []byte             `json:"meta"`
-	CreatedAt       pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
+	ID                 int32              `json:"id"`
+	Name               string             `json:"name"`
+	Code               string             `json:"code"`
+	Location           pgtype.Text        `json:"location"`
+	Description        pgtype.Text        `json:"description"`
+	Valuation          ValuationMethod    `json:"valuation"`
+	ParentWarehouse    pgtype.Int4        `json:"parent_warehouse"`
+	Capacity           pgtype.Numeric     `json:"capacity"`
+	Meta               []byte             `json:"meta"`
+	CreatedAt          pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt          pgtype.Timestamptz `json:"updated_at"`
+	AllocationStrategy AllocationStrategy `json:"allocation_strategy"`
+}
+
+type WarehouseBin struct {
+	ID          int32              `json:"id"`
+	WarehouseID int32              `json:"warehouse_id"`
+	Code        string             `json:"code"`
+	Name        pgtype.Text        `json:"name"`
+	BinType     string             `json:"bin_type"`
+	Zone        pgtype.Text        `json:"zone"`
+	Capacity    pgtype.Numeric     `json:"capacity"`
+	IsActive    bool               `json:"is_active"`
+	Notes       pgtype.Text        `json:"notes"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
+type WorkOrder struct {
+	ID                 int32              `json:"id"`
+	WorkOrderNumber    string             `json:"work_order_number"`
+	FinishedMaterialID int32              `json:"finished_material_id"`
+	BomVersion         string             `json:"bom_version"`
+	WarehouseID        int32              `json:"warehouse_id"`
+	PlannedQuantity    pgtype.Numeric     `json:"planned_quantity"`
+	CompletedQuantity  pgtype.Numeric     `json:"completed_quantity"`
+	Status             string             `json:"status"`
+	IssuedCost         pgtype.Numeric     `json:"issued_cost"`
+	BatchID            pgtype.Int4        `json:"batch_id"`
+	DueDate            pgtype.Date        `json:"due_date"`
+	Notes              pgtype.Text        `json:"notes"`
+	CreatedBy          pgtype.Int4        `json:"created_by"`
+	CompletedBy        pgtype.Int4        `json:"completed_by"`
+	CompletedAt        pgtype.Timestamptz `json:"completed_at"`
+	CreatedAt          pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt          pgtype.Timestamptz `json:"updated_at"`
+}
+
+type WorkOrderComponent struct {
+	ID                      int32              `json:"id"`
+	WorkOrderID             int32              `json:"work_order_id"`
+	BomID                   pgtype.Int4        `json:"bom_id"`
+	ComponentMaterialID     int32              `json:"component_material_id"`
+	AlternateComponentID    pgtype.Int4        `json:"alternate_component_id"`
+	BaseQuantity            pgtype.Numeric     `json:"base_quantity"`
+	ScrapPercentage         pgtype.Numeric     `json:"scrap_percentage"`
+	FixedQuantity           bool               `json:"fixed_quantity"`
+	IsOptional              bool               `json:"is_optional"`
+	RequiredQuantity        pgtype.Numeric     `json:"required_quantity"`
+	IssuedQuantity          pgtype.Numeric     `json:"issued_quantity"`
+	AlternateIssuedQuantity pgtype.Numeric     `json:"alternate_issued_quantity"`
+	IssuedCost              pgtype.Numeric     `json:"issued_cost"`
+	Sequence                int32              `json:"sequence"`
+	CreatedAt               pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt               pgtype.Timestamptz `json:"updated_at"`
 }
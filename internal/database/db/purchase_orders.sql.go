@@ -172,6 +172,33 @@ func (q *Queries) GetPurchaseOrderByID(ctx context.Context, id int32) (PurchaseO
 	return i, err
 }
 
+const getPurchaseOrderByIDForUpdate = `-- name: GetPurchaseOrderByIDForUpdate :one
+SELECT id, order_number, supplier_id, order_date, expected_delivery_date, status, total_amount, created_by, approved_by, meta, created_at, updated_at
+FROM purchase_orders
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetPurchaseOrderByIDForUpdate(ctx context.Context, id int32) (PurchaseOrder, error) {
+	row := q.db.QueryRow(ctx, getPurchaseOrderByIDForUpdate, id)
+	var i PurchaseOrder
+	err := row.Scan(
+		&i.ID,
+		&i.OrderNumber,
+		&i.SupplierID,
+		&i.OrderDate,
+		&i.ExpectedDeliveryDate,
+		&i.Status,
+		&i.TotalAmount,
+		&i.CreatedBy,
+		&i.ApprovedBy,
+		&i.Meta,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const getPurchaseOrderByOrderNumber = `-- name: GetPurchaseOrderByOrderNumber :one
 SELECT id, order_number, supplier_id, order_date, expected_delivery_date, status, total_amount, created_by, approved_by, meta, created_at, updated_at
 FROM purchase_orders
@@ -258,6 +285,88 @@ func (q *Queries) ListPurchaseOrderItems(ctx context.Context, purchaseOrderID pg
 	return items, nil
 }
 
+const listPurchaseOrderReceipts = `-- name: ListPurchaseOrderReceipts :many
+SELECT
+    sm.id as movement_id,
+    sm.purchase_order_item_id,
+    sm.material_id,
+    sm.to_warehouse_id,
+    sm.quantity,
+    sm.unit_cost,
+    sm.total_cost,
+    sm.reference,
+    sm.movement_date,
+    sm.notes,
+    m.name as material_name,
+    w.name as warehouse_name,
+    b.id as batch_id,
+    b.batch_number,
+    u.username as performed_by_username
+FROM stock_movements sm
+JOIN purchase_order_items poi ON sm.purchase_order_item_id = poi.id
+LEFT JOIN materials m ON sm.material_id = m.id
+LEFT JOIN warehouses w ON sm.to_warehouse_id = w.id
+LEFT JOIN batches b ON b.movement_id = sm.id
+LEFT JOIN users u ON sm.performed_by = u.id
+WHERE poi.purchase_order_id = $1
+  AND sm.movement_type = 'PURCHASE_RECEIPT'
+ORDER BY sm.movement_date DESC, sm.id DESC
+`
+
+type ListPurchaseOrderReceiptsRow struct {
+	MovementID          int32              `json:"movement_id"`
+	PurchaseOrderItemID pgtype.Int4        `json:"purchase_order_item_id"`
+	MaterialID          pgtype.Int4        `json:"material_id"`
+	ToWarehouseID       pgtype.Int4        `json:"to_warehouse_id"`
+	Quantity            pgtype.Numeric     `json:"quantity"`
+	UnitCost            pgtype.Numeric     `json:"unit_cost"`
+	TotalCost           pgtype.Numeric     `json:"total_cost"`
+	Reference           pgtype.Text        `json:"reference"`
+	MovementDate        pgtype.Timestamptz `json:"movement_date"`
+	Notes               pgtype.Text        `json:"notes"`
+	MaterialName        pgtype.Text        `json:"material_name"`
+	WarehouseName       pgtype.Text        `json:"warehouse_name"`
+	BatchID             pgtype.Int4        `json:"batch_id"`
+	BatchNumber         pgtype.Text        `json:"batch_number"`
+	PerformedByUsername pgtype.Text        `json:"performed_by_username"`
+}
+
+func (q *Queries) ListPurchaseOrderReceipts(ctx context.Context, purchaseOrderID pgtype.Int4) ([]ListPurchaseOrderReceiptsRow, error) {
+	rows, err := q.db.Query(ctx, listPurchaseOrderReceipts, purchaseOrderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListPurchaseOrderReceiptsRow{}
+	for rows.Next() {
+		var i ListPurchaseOrderReceiptsRow
+		if err := rows.Scan(
+			&i.MovementID,
+			&i.PurchaseOrderItemID,
+			&i.MaterialID,
+			&i.ToWarehouseID,
+			&i.Quantity,
+			&i.UnitCost,
+			&i.TotalCost,
+			&i.Reference,
+			&i.MovementDate,
+			&i.Notes,
+			&i.MaterialName,
+			&i.WarehouseName,
+			&i.BatchID,
+			&i.BatchNumber,
+			&i.PerformedByUsername,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listPurchaseOrders = `-- name: ListPurchaseOrders :many
 SELECT id, order_number, supplier_id, order_date, expected_delivery_date, status, total_amount, created_by, approved_by, meta, created_at, updated_at
 FROM purchase_orders
@@ -581,3 +690,37 @@ func (q *Queries) UpdatePurchaseOrderItemReceivedQuantity(ctx context.Context, a
 	)
 	return i, err
 }
+
+const updatePurchaseOrderStatus = `-- name: UpdatePurchaseOrderStatus :one
+UPDATE purchase_orders
+SET
+    status = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, order_number, supplier_id, order_date, expected_delivery_date, status, total_amount, created_by, approved_by, meta, created_at, updated_at
+`
+
+type UpdatePurchaseOrderStatusParams struct {
+	ID     int32  `json:"id"`
+	Status string `json:"status"`
+}
+
+func (q *Queries) UpdatePurchaseOrderStatus(ctx context.Context, arg UpdatePurchaseOrderStatusParams) (PurchaseOrder, error) {
+	row := q.db.QueryRow(ctx, updatePurchaseOrderStatus, arg.ID, arg.Status)
+	var i PurchaseOrder
+	err := row.Scan(
+		&i.ID,
+		&i.OrderNumber,
+		&i.SupplierID,
+		&i.OrderDate,
+		&i.ExpectedDeliveryDate,
+		&i.Status,
+		&i.TotalAmount,
+		&i.CreatedBy,
+		&i.ApprovedBy,
+		&i.Meta,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
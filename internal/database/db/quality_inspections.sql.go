@@ -47,6 +47,23 @@ func (q *Queries) CountQualityInspectionsByStatus(ctx context.Context, inspectio
 	return count, err
 }
 
+const countRequiredMaterialQualitySpecs = `-- name: CountRequiredMaterialQualitySpecs :one
+
+SELECT COUNT(*) FROM material_quality_specs mqs
+JOIN quality_inspection_criteria qic ON mqs.criteria_id = qic.id
+WHERE mqs.material_id = $1
+  AND COALESCE(mqs.is_required, TRUE)
+  AND COALESCE(qic.is_active, TRUE)
+`
+
+// Materials with required criteria of active templates are inspected on receipt
+func (q *Queries) CountRequiredMaterialQualitySpecs(ctx context.Context, materialID int32) (int64, error) {
+	row := q.db.QueryRow(ctx, countRequiredMaterialQualitySpecs, materialID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createMaterialQualitySpec = `-- name: CreateMaterialQualitySpec :one
 
 INSERT INTO material_quality_specs (
@@ -641,6 +658,29 @@ func (q *Queries) DeleteSupplierQualityRating(ctx context.Context, id int32) err
 	return err
 }
 
+const failInspectionQualityHolds = `-- name: FailInspectionQualityHolds :exec
+
+UPDATE quality_holds
+SET
+    quality_status = $2,
+    ncr_id = COALESCE($3, ncr_id),
+    updated_at = CURRENT_TIMESTAMP
+WHERE inspection_id = $1 AND is_released = FALSE
+`
+
+type FailInspectionQualityHoldsParams struct {
+	InspectionID  pgtype.Int4   `json:"inspection_id"`
+	QualityStatus QualityStatus `json:"quality_status"`
+	NcrID         pgtype.Int4   `json:"ncr_id"`
+}
+
+// The holds an inspection placed stay in force after it failed, with the
+// decision and the report raised about it
+func (q *Queries) FailInspectionQualityHolds(ctx context.Context, arg FailInspectionQualityHoldsParams) error {
+	_, err := q.db.Exec(ctx, failInspectionQualityHolds, arg.InspectionID, arg.QualityStatus, arg.NcrID)
+	return err
+}
+
 const getInspectionStatsByMaterial = `-- name: GetInspectionStatsByMaterial :one
 SELECT 
     COUNT(*) as total_inspections,
@@ -735,6 +775,23 @@ func (q *Queries) GetMaterialQualitySpecByID(ctx context.Context, id int32) (Mat
 	return i, err
 }
 
+const getNcrReturnedQuantity = `-- name: GetNcrReturnedQuantity :one
+
+SELECT COALESCE(SUM(quantity), 0)::DECIMAL(15, 4) as returned_quantity
+FROM stock_movements
+WHERE ncr_id = $1
+  AND movement_type = 'SUPPLIER_RETURN'
+  AND reversed_by_movement_id IS NULL
+`
+
+// Quantity sent back to the supplier to settle a non-conformance report
+func (q *Queries) GetNcrReturnedQuantity(ctx context.Context, ncrID pgtype.Int4) (pgtype.Numeric, error) {
+	row := q.db.QueryRow(ctx, getNcrReturnedQuantity, ncrID)
+	var returned_quantity pgtype.Numeric
+	err := row.Scan(&returned_quantity)
+	return returned_quantity, err
+}
+
 const getNonConformanceReportByID = `-- name: GetNonConformanceReportByID :one
 SELECT 
     ncr.id, ncr.ncr_number, ncr.title, ncr.description, ncr.ncr_type, ncr.severity, ncr.status, ncr.material_id, ncr.batch_number, ncr.quantity_affected, ncr.unit_id, ncr.inspection_id, ncr.supplier_id, ncr.customer_id, ncr.purchase_order_id, ncr.sales_order_id, ncr.root_cause, ncr.root_cause_analysis_by, ncr.root_cause_date, ncr.corrective_action, ncr.preventive_action, ncr.action_assigned_to, ncr.action_due_date, ncr.action_completed_date, ncr.disposition, ncr.cost_impact, ncr.reported_by, ncr.reported_date, ncr.closed_by, ncr.closed_date, ncr.attachments, ncr.notes, ncr.created_at, ncr.updated_at,
@@ -891,6 +948,54 @@ func (q *Queries) GetNonConformanceReportByNumber(ctx context.Context, ncrNumber
 	return i, err
 }
 
+const getNonConformanceReportForUpdate = `-- name: GetNonConformanceReportForUpdate :one
+SELECT id, ncr_number, title, description, ncr_type, severity, status, material_id, batch_number, quantity_affected, unit_id, inspection_id, supplier_id, customer_id, purchase_order_id, sales_order_id, root_cause, root_cause_analysis_by, root_cause_date, corrective_action, preventive_action, action_assigned_to, action_due_date, action_completed_date, disposition, cost_impact, reported_by, reported_date, closed_by, closed_date, attachments, notes, created_at, updated_at FROM non_conformance_reports
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetNonConformanceReportForUpdate(ctx context.Context, id int32) (NonConformanceReport, error) {
+	row := q.db.QueryRow(ctx, getNonConformanceReportForUpdate, id)
+	var i NonConformanceReport
+	err := row.Scan(
+		&i.ID,
+		&i.NcrNumber,
+		&i.Title,
+		&i.Description,
+		&i.NcrType,
+		&i.Severity,
+		&i.Status,
+		&i.MaterialID,
+		&i.BatchNumber,
+		&i.QuantityAffected,
+		&i.UnitID,
+		&i.InspectionID,
+		&i.SupplierID,
+		&i.CustomerID,
+		&i.PurchaseOrderID,
+		&i.SalesOrderID,
+		&i.RootCause,
+		&i.RootCauseAnalysisBy,
+		&i.RootCauseDate,
+		&i.CorrectiveAction,
+		&i.PreventiveAction,
+		&i.ActionAssignedTo,
+		&i.ActionDueDate,
+		&i.ActionCompletedDate,
+		&i.Disposition,
+		&i.CostImpact,
+		&i.ReportedBy,
+		&i.ReportedDate,
+		&i.ClosedBy,
+		&i.ClosedDate,
+		&i.Attachments,
+		&i.Notes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const getQualityDashboardStats = `-- name: GetQualityDashboardStats :one
 
 SELECT 
@@ -1196,6 +1301,45 @@ func (q *Queries) GetQualityInspectionCriteriaByID(ctx context.Context, id int32
 	return i, err
 }
 
+const getQualityInspectionForUpdate = `-- name: GetQualityInspectionForUpdate :one
+SELECT id, inspection_number, inspection_type, inspection_status, material_id, batch_number, lot_number, quantity, unit_id, purchase_order_id, sales_order_id, stock_movement_id, supplier_id, inspection_date, inspector_id, approved_by_id, quantity_passed, quantity_failed, quantity_on_hold, final_decision, decision_date, notes, attachments, created_at, updated_at FROM quality_inspections
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetQualityInspectionForUpdate(ctx context.Context, id int32) (QualityInspection, error) {
+	row := q.db.QueryRow(ctx, getQualityInspectionForUpdate, id)
+	var i QualityInspection
+	err := row.Scan(
+		&i.ID,
+		&i.InspectionNumber,
+		&i.InspectionType,
+		&i.InspectionStatus,
+		&i.MaterialID,
+		&i.BatchNumber,
+		&i.LotNumber,
+		&i.Quantity,
+		&i.UnitID,
+		&i.PurchaseOrderID,
+		&i.SalesOrderID,
+		&i.StockMovementID,
+		&i.SupplierID,
+		&i.InspectionDate,
+		&i.InspectorID,
+		&i.ApprovedByID,
+		&i.QuantityPassed,
+		&i.QuantityFailed,
+		&i.QuantityOnHold,
+		&i.FinalDecision,
+		&i.DecisionDate,
+		&i.Notes,
+		&i.Attachments,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const getQualityInspectionResultByID = `-- name: GetQualityInspectionResultByID :one
 SELECT id, inspection_id, criteria_id, criteria_name, measured_value, text_value, is_passed, deviation, sample_number, notes, photo_urls, created_at FROM quality_inspection_results
 WHERE id = $1
@@ -1467,6 +1611,54 @@ func (q *Queries) ListActiveQualityHolds(ctx context.Context, arg ListActiveQual
 	return items, nil
 }
 
+const listActiveQualityHoldsByInspection = `-- name: ListActiveQualityHoldsByInspection :many
+SELECT id, hold_number, material_id, warehouse_id, batch_number, lot_number, quantity, unit_id, quality_status, hold_reason, inspection_id, ncr_id, placed_by, placed_date, expected_release_date, is_released, released_by, released_date, release_notes, created_at, updated_at FROM quality_holds
+WHERE inspection_id = $1 AND is_released = FALSE
+ORDER BY id
+`
+
+func (q *Queries) ListActiveQualityHoldsByInspection(ctx context.Context, inspectionID pgtype.Int4) ([]QualityHold, error) {
+	rows, err := q.db.Query(ctx, listActiveQualityHoldsByInspection, inspectionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []QualityHold{}
+	for rows.Next() {
+		var i QualityHold
+		if err := rows.Scan(
+			&i.ID,
+			&i.HoldNumber,
+			&i.MaterialID,
+			&i.WarehouseID,
+			&i.BatchNumber,
+			&i.LotNumber,
+			&i.Quantity,
+			&i.UnitID,
+			&i.QualityStatus,
+			&i.HoldReason,
+			&i.InspectionID,
+			&i.NcrID,
+			&i.PlacedBy,
+			&i.PlacedDate,
+			&i.ExpectedReleaseDate,
+			&i.IsReleased,
+			&i.ReleasedBy,
+			&i.ReleasedDate,
+			&i.ReleaseNotes,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listAllQualityInspectionCriteria = `-- name: ListAllQualityInspectionCriteria :many
 SELECT id, name, description, criteria_type, specification, unit_id, tolerance_min, tolerance_max, is_critical, is_active, created_at, updated_at FROM quality_inspection_criteria
 ORDER BY created_at DESC
@@ -1684,6 +1876,67 @@ func (q *Queries) ListNonConformanceReports(ctx context.Context, arg ListNonConf
 	return items, nil
 }
 
+const listNonConformanceReportsByInspection = `-- name: ListNonConformanceReportsByInspection :many
+SELECT id, ncr_number, title, description, ncr_type, severity, status, material_id, batch_number, quantity_affected, unit_id, inspection_id, supplier_id, customer_id, purchase_order_id, sales_order_id, root_cause, root_cause_analysis_by, root_cause_date, corrective_action, preventive_action, action_assigned_to, action_due_date, action_completed_date, disposition, cost_impact, reported_by, reported_date, closed_by, closed_date, attachments, notes, created_at, updated_at FROM non_conformance_reports
+WHERE inspection_id = $1
+ORDER BY reported_date
+`
+
+func (q *Queries) ListNonConformanceReportsByInspection(ctx context.Context, inspectionID pgtype.Int4) ([]NonConformanceReport, error) {
+	rows, err := q.db.Query(ctx, listNonConformanceReportsByInspection, inspectionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []NonConformanceReport{}
+	for rows.Next() {
+		var i NonConformanceReport
+		if err := rows.Scan(
+			&i.ID,
+			&i.NcrNumber,
+			&i.Title,
+			&i.Description,
+			&i.NcrType,
+			&i.Severity,
+			&i.Status,
+			&i.MaterialID,
+			&i.BatchNumber,
+			&i.QuantityAffected,
+			&i.UnitID,
+			&i.InspectionID,
+			&i.SupplierID,
+			&i.CustomerID,
+			&i.PurchaseOrderID,
+			&i.SalesOrderID,
+			&i.RootCause,
+			&i.RootCauseAnalysisBy,
+			&i.RootCauseDate,
+			&i.CorrectiveAction,
+			&i.PreventiveAction,
+			&i.ActionAssignedTo,
+			&i.ActionDueDate,
+			&i.ActionCompletedDate,
+			&i.Disposition,
+			&i.CostImpact,
+			&i.ReportedBy,
+			&i.ReportedDate,
+			&i.ClosedBy,
+			&i.ClosedDate,
+			&i.Attachments,
+			&i.Notes,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listNonConformanceReportsByMaterial = `-- name: ListNonConformanceReportsByMaterial :many
 SELECT id, ncr_number, title, description, ncr_type, severity, status, material_id, batch_number, quantity_affected, unit_id, inspection_id, supplier_id, customer_id, purchase_order_id, sales_order_id, root_cause, root_cause_analysis_by, root_cause_date, corrective_action, preventive_action, action_assigned_to, action_due_date, action_completed_date, disposition, cost_impact, reported_by, reported_date, closed_by, closed_date, attachments, notes, created_at, updated_at FROM non_conformance_reports
 WHERE material_id = $1
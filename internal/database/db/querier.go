@@ -11,7 +11,38 @@ import (
 )
 
 type Querier interface {
+	// =====================================================
+	// ============================================================================
+	// ANALYST QUALIFICATIONS
+	// BATCH ALLOCATION QUERIES (for manual selection)
+	// BATCH QUERIES
+	// CERTIFICATES OF ANALYSIS
+	// GET MATERIAL BY SKU
+	// LAB EQUIPMENT
+	// LAB SAMPLES
+	// LAB TEST ASSIGNMENTS
+	// LAB TEST METHODS
+	// LAB TEST RESULTS
+	// MATERIAL QUALITY SPECS
+	// NON-CONFORMANCE REPORTS (NCR)
+	// OOS INVESTIGATIONS
+	// QUALITY HOLDS
+	// QUALITY INSPECTION CRITERIA
+	// QUALITY INSPECTION RESULTS
+	// QUALITY INSPECTIONS
+	// SEARCH MATERIALS (with filters and pagination)
+	// STABILITY SAMPLES
+	// STABILITY STUDIES
+	// STATISTICS & REPORTS
+	// STOCK LEVEL QUERIES
+	// STOCK MOVEMENT QUERIES
+	// SUPPLIER QUALITY RATINGS
+	// TRANSACTION-SPECIFIC QUERIES
+	// UPDATE MATERIAL
+	// VALUATION METHOD QUERIES
 	ActivateUser(ctx context.Context, id int32) error
+	AddWorkOrderComponentIssue(ctx context.Context, arg AddWorkOrderComponentIssueParams) error
+	AddWorkOrderIssuedCost(ctx context.Context, arg AddWorkOrderIssuedCostParams) (WorkOrder, error)
 	ArchiveBOM(ctx context.Context, arg ArchiveBOMParams) (ArchiveBOMRow, error)
 	ArchiveMaterial(ctx context.Context, id int32) error
 	BatchCreateMaterials(ctx context.Context, arg []BatchCreateMaterialsParams) (int64, error)
@@ -21,18 +52,24 @@ type Querier interface {
 	CheckBOMExists(ctx context.Context, arg CheckBOMExistsParams) (bool, error)
 	CheckDuplicateCode(ctx context.Context, arg CheckDuplicateCodeParams) (bool, error)
 	CheckDuplicateSKU(ctx context.Context, arg CheckDuplicateSKUParams) (bool, error)
+	CheckMaterialHasStock(ctx context.Context, materialID pgtype.Int4) (bool, error)
 	CheckMaterialSaleable(ctx context.Context, id int32) (pgtype.Bool, error)
 	CheckOpeningStockExists(ctx context.Context, materialID pgtype.Int4) (bool, error)
 	CheckUnitReferences(ctx context.Context, convertTo pgtype.Int4) (int64, error)
 	CheckUnitUsedByMaterials(ctx context.Context, measureUnitID pgtype.Int4) (int64, error)
+	ClaimIdempotencyKey(ctx context.Context, arg ClaimIdempotencyKeyParams) (int32, error)
 	CloneBOMVersion(ctx context.Context, arg CloneBOMVersionParams) error
+	CompleteIdempotencyKey(ctx context.Context, arg CompleteIdempotencyKeyParams) error
+	CompleteWorkOrder(ctx context.Context, arg CompleteWorkOrderParams) (WorkOrder, error)
 	CountBillsOfMaterials(ctx context.Context) (int64, error)
+	CountBinBatches(ctx context.Context, binID pgtype.Int4) (int64, error)
 	CountCategories(ctx context.Context) (int64, error)
 	CountCustomers(ctx context.Context) (int64, error)
 	CountMaterials(ctx context.Context, arg CountMaterialsParams) (int64, error)
 	CountNonConformanceReportsByStatus(ctx context.Context, status NullNcrStatus) (int64, error)
 	CountPurchaseOrders(ctx context.Context) (int64, error)
 	CountQualityInspectionsByStatus(ctx context.Context, inspectionStatus NullQualityInspectionStatus) (int64, error)
+	CountRequiredMaterialQualitySpecs(ctx context.Context, materialID int32) (int64, error)
 	CountSalesOrders(ctx context.Context) (int64, error)
 	CountSearchBillsOfMaterials(ctx context.Context, query pgtype.Text) (int64, error)
 	CountSearchCustomers(ctx context.Context, query pgtype.Text) (int64, error)
@@ -42,91 +79,49 @@ type Querier interface {
 	CountSuppliers(ctx context.Context) (int64, error)
 	CountUnits(ctx context.Context) (int64, error)
 	CountUsers(ctx context.Context) (int64, error)
-	// ============================================================================
-	// ANALYST QUALIFICATIONS
-	// ============================================================================
 	CreateAnalystQualification(ctx context.Context, arg CreateAnalystQualificationParams) (AnalystQualification, error)
 	CreateBatch(ctx context.Context, arg CreateBatchParams) (Batch, error)
+	CreateBatchGenealogyLink(ctx context.Context, arg CreateBatchGenealogyLinkParams) error
 	CreateBillOfMaterial(ctx context.Context, arg CreateBillOfMaterialParams) (CreateBillOfMaterialRow, error)
 	CreateCategory(ctx context.Context, arg CreateCategoryParams) (MaterialCategory, error)
-	// ============================================================================
-	// CERTIFICATES OF ANALYSIS
-	// ============================================================================
 	CreateCertificateOfAnalysis(ctx context.Context, arg CreateCertificateOfAnalysisParams) (CertificatesOfAnalysis, error)
+	CreateCostLedgerEntry(ctx context.Context, arg CreateCostLedgerEntryParams) (MaterialCostLedger, error)
+	CreateCountSession(ctx context.Context, arg CreateCountSessionParams) (CountSession, error)
+	CreateCountSessionLines(ctx context.Context, arg CreateCountSessionLinesParams) (int64, error)
 	CreateCustomer(ctx context.Context, arg CreateCustomerParams) (Customer, error)
-	// ============================================================================
-	// LAB EQUIPMENT
-	// ============================================================================
 	CreateLabEquipment(ctx context.Context, arg CreateLabEquipmentParams) (LabEquipment, error)
-	// ============================================================================
-	// LAB SAMPLES
-	// ============================================================================
 	CreateLabSample(ctx context.Context, arg CreateLabSampleParams) (LabSample, error)
-	// ============================================================================
-	// LAB TEST ASSIGNMENTS
-	// ============================================================================
 	CreateLabTestAssignment(ctx context.Context, arg CreateLabTestAssignmentParams) (LabTestAssignment, error)
-	// ============================================================================
-	// LAB TEST METHODS
-	// ============================================================================
 	CreateLabTestMethod(ctx context.Context, arg CreateLabTestMethodParams) (LabTestMethod, error)
-	// ============================================================================
-	// LAB TEST RESULTS
-	// ============================================================================
 	CreateLabTestResult(ctx context.Context, arg CreateLabTestResultParams) (LabTestResult, error)
 	CreateMaterial(ctx context.Context, arg CreateMaterialParams) (Material, error)
-	// ============================================================================
-	// MATERIAL QUALITY SPECS
-	// ============================================================================
+	CreateMaterialAverageCostIfMissing(ctx context.Context, arg CreateMaterialAverageCostIfMissingParams) error
 	CreateMaterialQualitySpec(ctx context.Context, arg CreateMaterialQualitySpecParams) (MaterialQualitySpec, error)
-	// ============================================================================
-	// NON-CONFORMANCE REPORTS (NCR)
-	// ============================================================================
 	CreateNonConformanceReport(ctx context.Context, arg CreateNonConformanceReportParams) (NonConformanceReport, error)
-	// ============================================================================
-	// OOS INVESTIGATIONS
-	// ============================================================================
 	CreateOOSInvestigation(ctx context.Context, arg CreateOOSInvestigationParams) (OosInvestigation, error)
 	CreatePurchaseOrder(ctx context.Context, arg CreatePurchaseOrderParams) (PurchaseOrder, error)
 	CreatePurchaseOrderItem(ctx context.Context, arg CreatePurchaseOrderItemParams) (PurchaseOrderItem, error)
-	// ============================================================================
-	// QUALITY HOLDS
-	// ============================================================================
 	CreateQualityHold(ctx context.Context, arg CreateQualityHoldParams) (QualityHold, error)
-	// ============================================================================
-	// QUALITY INSPECTIONS
-	// ============================================================================
 	CreateQualityInspection(ctx context.Context, arg CreateQualityInspectionParams) (QualityInspection, error)
-	// ============================================================================
-	// QUALITY INSPECTION CRITERIA
-	// ============================================================================
 	CreateQualityInspectionCriteria(ctx context.Context, arg CreateQualityInspectionCriteriaParams) (QualityInspectionCriterium, error)
-	// ============================================================================
-	// QUALITY INSPECTION RESULTS
-	// ============================================================================
 	CreateQualityInspectionResult(ctx context.Context, arg CreateQualityInspectionResultParams) (QualityInspectionResult, error)
 	CreateSalesOrder(ctx context.Context, arg CreateSalesOrderParams) (SalesOrder, error)
 	CreateSalesOrderItem(ctx context.Context, arg CreateSalesOrderItemParams) (SalesOrderItem, error)
-	// ============================================================================
-	// STABILITY SAMPLES
-	// ============================================================================
 	CreateStabilitySample(ctx context.Context, arg CreateStabilitySampleParams) (StabilitySample, error)
-	// ============================================================================
-	// STABILITY STUDIES
-	// ============================================================================
 	CreateStabilityStudy(ctx context.Context, arg CreateStabilityStudyParams) (StabilityStudy, error)
-	// =====================================================
-	// STOCK MOVEMENT QUERIES
-	// =====================================================
 	CreateStockMovement(ctx context.Context, arg CreateStockMovementParams) (StockMovement, error)
+	CreateStockMovementBatch(ctx context.Context, arg CreateStockMovementBatchParams) (StockMovementBatch, error)
+	CreateStockMovementSerial(ctx context.Context, arg CreateStockMovementSerialParams) error
+	CreateStockReservation(ctx context.Context, arg CreateStockReservationParams) (StockReservation, error)
 	CreateSupplier(ctx context.Context, arg CreateSupplierParams) (Supplier, error)
-	// ============================================================================
-	// SUPPLIER QUALITY RATINGS
-	// ============================================================================
 	CreateSupplierQualityRating(ctx context.Context, arg CreateSupplierQualityRatingParams) (SupplierQualityRating, error)
+	CreateTransferShipment(ctx context.Context, arg CreateTransferShipmentParams) (TransferShipment, error)
 	CreateUnit(ctx context.Context, arg CreateUnitParams) (MeasureUnit, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (CreateUserRow, error)
 	CreateWarehouse(ctx context.Context, arg CreateWarehouseParams) (Warehouse, error)
+	CreateWarehouseBin(ctx context.Context, arg CreateWarehouseBinParams) (WarehouseBin, error)
+	CreateWorkOrder(ctx context.Context, arg CreateWorkOrderParams) (WorkOrder, error)
+	CreateWorkOrderComponent(ctx context.Context, arg CreateWorkOrderComponentParams) (WorkOrderComponent, error)
 	DeactivateUser(ctx context.Context, id int32) error
 	DeleteAnalystQualification(ctx context.Context, id int32) error
 	DeleteBillOfMaterial(ctx context.Context, id int32) error
@@ -135,6 +130,8 @@ type Querier interface {
 	DeleteCategory(ctx context.Context, id int32) error
 	DeleteCertificateOfAnalysis(ctx context.Context, id int32) error
 	DeleteCustomer(ctx context.Context, id int32) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error)
+	DeleteIdempotencyKey(ctx context.Context, id int32) error
 	DeleteLabEquipment(ctx context.Context, id int32) error
 	DeleteLabSample(ctx context.Context, id int32) error
 	DeleteLabTestAssignment(ctx context.Context, id int32) error
@@ -145,6 +142,7 @@ type Querier interface {
 	DeleteMaterialQualitySpecsByMaterial(ctx context.Context, materialID int32) error
 	DeleteNonConformanceReport(ctx context.Context, id int32) error
 	DeleteOOSInvestigation(ctx context.Context, id int32) error
+	DeletePlanningParameters(ctx context.Context, id int32) (int64, error)
 	DeletePurchaseOrder(ctx context.Context, id int32) error
 	DeletePurchaseOrderItem(ctx context.Context, id int32) error
 	DeleteQualityHold(ctx context.Context, id int32) error
@@ -155,47 +153,56 @@ type Querier interface {
 	DeleteSalesOrderItem(ctx context.Context, id int32) error
 	DeleteStabilitySample(ctx context.Context, id int32) error
 	DeleteStabilityStudy(ctx context.Context, id int32) error
+	DeleteStaleReplenishmentSuggestions(ctx context.Context, keepIds []int32) (int64, error)
 	DeleteSupplier(ctx context.Context, id int32) error
 	DeleteSupplierQualityRating(ctx context.Context, id int32) error
 	DeleteUnit(ctx context.Context, id int32) error
 	DeleteUser(ctx context.Context, id int32) error
 	DeleteWarehouse(ctx context.Context, id int32) error
+	DeleteWarehouseBin(ctx context.Context, id int32) error
+	DismissReplenishmentSuggestion(ctx context.Context, id int32) (ReplenishmentSuggestion, error)
 	ExportAllMaterials(ctx context.Context) ([]ExportAllMaterialsRow, error)
+	FailInspectionQualityHolds(ctx context.Context, arg FailInspectionQualityHoldsParams) error
+	FindBatchesByNumber(ctx context.Context, arg FindBatchesByNumberParams) ([]int32, error)
+	FlagExpiringBatches(ctx context.Context, horizonDays int32) (int64, error)
 	GetActiveBOMsByFinishedMaterial(ctx context.Context, finishedMaterialID pgtype.Int4) ([]GetActiveBOMsByFinishedMaterialRow, error)
 	GetAnalystProductivity(ctx context.Context, arg GetAnalystProductivityParams) ([]GetAnalystProductivityRow, error)
 	GetAnalystQualificationByID(ctx context.Context, id int32) (GetAnalystQualificationByIDRow, error)
-	// =====================================================
-	// BATCH ALLOCATION QUERIES (for manual selection)
-	// =====================================================
 	GetAvailableBatchesForMaterial(ctx context.Context, arg GetAvailableBatchesForMaterialParams) ([]GetAvailableBatchesForMaterialRow, error)
 	GetBOMCostBreakdown(ctx context.Context, finishedMaterialID pgtype.Int4) ([]GetBOMCostBreakdownRow, error)
+	GetBOMLinesForWorkOrder(ctx context.Context, arg GetBOMLinesForWorkOrderParams) ([]GetBOMLinesForWorkOrderRow, error)
 	GetBOMTotalCost(ctx context.Context, finishedMaterialID pgtype.Int4) (interface{}, error)
 	GetBOMVersions(ctx context.Context, finishedMaterialID pgtype.Int4) ([]GetBOMVersionsRow, error)
 	GetBOMsBySupplier(ctx context.Context, supplierID pgtype.Int4) ([]GetBOMsBySupplierRow, error)
 	GetBOMsByVersion(ctx context.Context, arg GetBOMsByVersionParams) ([]GetBOMsByVersionRow, error)
 	GetBatchByID(ctx context.Context, id int32) (Batch, error)
+	GetBatchHoldsByIDs(ctx context.Context, dollar_1 []int32) ([]GetBatchHoldsByIDsRow, error)
+	GetBatchStockAsOf(ctx context.Context, arg GetBatchStockAsOfParams) ([]GetBatchStockAsOfRow, error)
 	GetBatchesByIDs(ctx context.Context, dollar_1 []int32) ([]Batch, error)
 	GetBatchesByWarehouseAndMaterial(ctx context.Context, arg GetBatchesByWarehouseAndMaterialParams) ([]Batch, error)
+	GetBatchesByWarehouseAndMaterialFEFO(ctx context.Context, arg GetBatchesByWarehouseAndMaterialFEFOParams) ([]Batch, error)
 	GetBatchesByWarehouseAndMaterialLIFO(ctx context.Context, arg GetBatchesByWarehouseAndMaterialLIFOParams) ([]Batch, error)
 	GetBillOfMaterialByID(ctx context.Context, id int32) (GetBillOfMaterialByIDRow, error)
 	GetBillOfMaterialsByComponent(ctx context.Context, componentMaterialID pgtype.Int4) ([]GetBillOfMaterialsByComponentRow, error)
 	GetBillOfMaterialsByFinishedMaterial(ctx context.Context, finishedMaterialID pgtype.Int4) ([]GetBillOfMaterialsByFinishedMaterialRow, error)
+	GetBinStockLevels(ctx context.Context, arg GetBinStockLevelsParams) ([]GetBinStockLevelsRow, error)
 	GetCategoryByID(ctx context.Context, id int32) (MaterialCategory, error)
 	GetCategoryByName(ctx context.Context, name string) (MaterialCategory, error)
 	GetCertificateOfAnalysisByID(ctx context.Context, id int32) (GetCertificateOfAnalysisByIDRow, error)
 	GetCertificateOfAnalysisByNumber(ctx context.Context, coaNumber string) (CertificatesOfAnalysis, error)
-	// =====================================================
-	// STOCK LEVEL QUERIES
-	// =====================================================
+	GetCountSessionByID(ctx context.Context, id int32) (CountSession, error)
+	GetCountSessionByIDForUpdate(ctx context.Context, id int32) (CountSession, error)
 	GetCurrentStockLevel(ctx context.Context, arg GetCurrentStockLevelParams) (GetCurrentStockLevelRow, error)
 	GetCustomerByEmail(ctx context.Context, contactEmail pgtype.Text) (Customer, error)
 	GetCustomerByID(ctx context.Context, id int32) (Customer, error)
 	GetCustomerByName(ctx context.Context, name string) (Customer, error)
 	GetCustomerByPhone(ctx context.Context, contactPhone pgtype.Text) (Customer, error)
+	GetEffectiveBOMVersion(ctx context.Context, finishedMaterialID pgtype.Int4) (string, error)
+	GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error)
 	GetInspectionStatsByMaterial(ctx context.Context, materialID pgtype.Int4) (GetInspectionStatsByMaterialRow, error)
-	// ============================================================================
-	// STATISTICS & REPORTS
-	// ============================================================================
+	GetInTransitAsOf(ctx context.Context, arg GetInTransitAsOfParams) ([]GetInTransitAsOfRow, error)
+	GetInTransitValuation(ctx context.Context, arg GetInTransitValuationParams) ([]GetInTransitValuationRow, error)
+	GetInventoryValuation(ctx context.Context, arg GetInventoryValuationParams) ([]GetInventoryValuationRow, error)
 	GetLabDashboardStats(ctx context.Context) (GetLabDashboardStatsRow, error)
 	GetLabEquipmentByCode(ctx context.Context, equipmentCode string) (LabEquipment, error)
 	GetLabEquipmentByID(ctx context.Context, id int32) (GetLabEquipmentByIDRow, error)
@@ -205,63 +212,72 @@ type Querier interface {
 	GetLabTestMethodByCode(ctx context.Context, methodCode string) (LabTestMethod, error)
 	GetLabTestMethodByID(ctx context.Context, id int32) (GetLabTestMethodByIDRow, error)
 	GetLabTestResultByID(ctx context.Context, id int32) (GetLabTestResultByIDRow, error)
-	// =====================================================
-	// BATCH QUERIES
-	// =====================================================
 	GetLastBatchNumberForMaterial(ctx context.Context, materialID pgtype.Int4) (string, error)
 	GetLatestSupplierQualityRating(ctx context.Context, supplierID int32) (SupplierQualityRating, error)
+	GetLineReservedQuantity(ctx context.Context, salesOrderItemID int32) (pgtype.Numeric, error)
+	GetMaterialAllocationStrategy(ctx context.Context, arg GetMaterialAllocationStrategyParams) (AllocationStrategy, error)
+	GetMaterialAverageCost(ctx context.Context, arg GetMaterialAverageCostParams) (MaterialAverageCost, error)
+	GetMaterialAverageCostForUpdate(ctx context.Context, arg GetMaterialAverageCostForUpdateParams) (MaterialAverageCost, error)
 	GetMaterialByCode(ctx context.Context, code string) (GetMaterialByCodeRow, error)
 	GetMaterialByID(ctx context.Context, id int32) (GetMaterialByIDRow, error)
-	// ============================================================================
-	// GET MATERIAL BY SKU
-	// ============================================================================
 	GetMaterialBySKU(ctx context.Context, sku string) (GetMaterialBySKURow, error)
 	GetMaterialQualitySpecByID(ctx context.Context, id int32) (MaterialQualitySpec, error)
-	// =====================================================
-	// VALUATION METHOD QUERIES
-	// =====================================================
 	GetMaterialValuationMethod(ctx context.Context, arg GetMaterialValuationMethodParams) (ValuationMethod, error)
+	GetNcrReturnedQuantity(ctx context.Context, ncrID pgtype.Int4) (pgtype.Numeric, error)
 	GetNonConformanceReportByID(ctx context.Context, id int32) (GetNonConformanceReportByIDRow, error)
 	GetNonConformanceReportByNumber(ctx context.Context, ncrNumber string) (NonConformanceReport, error)
+	GetNonConformanceReportForUpdate(ctx context.Context, id int32) (NonConformanceReport, error)
 	GetOOSInvestigationByID(ctx context.Context, id int32) (GetOOSInvestigationByIDRow, error)
 	GetOOSInvestigationByNumber(ctx context.Context, oosNumber string) (OosInvestigation, error)
+	GetOpenPurchaseOrderQuantities(ctx context.Context) ([]GetOpenPurchaseOrderQuantitiesRow, error)
 	GetOptionalComponents(ctx context.Context, finishedMaterialID pgtype.Int4) ([]GetOptionalComponentsRow, error)
 	GetPurchaseOrderByID(ctx context.Context, id int32) (PurchaseOrder, error)
+	GetPurchaseOrderByIDForUpdate(ctx context.Context, id int32) (PurchaseOrder, error)
 	GetPurchaseOrderByOrderNumber(ctx context.Context, orderNumber string) (PurchaseOrder, error)
 	GetPurchaseOrderItemByID(ctx context.Context, id int32) (PurchaseOrderItem, error)
-	// ============================================================================
-	// STATISTICS & REPORTS
-	// ============================================================================
 	GetQualityDashboardStats(ctx context.Context) (GetQualityDashboardStatsRow, error)
 	GetQualityHoldByID(ctx context.Context, id int32) (GetQualityHoldByIDRow, error)
 	GetQualityHoldByNumber(ctx context.Context, holdNumber string) (QualityHold, error)
 	GetQualityInspectionByID(ctx context.Context, id int32) (GetQualityInspectionByIDRow, error)
 	GetQualityInspectionByNumber(ctx context.Context, inspectionNumber string) (QualityInspection, error)
 	GetQualityInspectionCriteriaByID(ctx context.Context, id int32) (QualityInspectionCriterium, error)
+	GetQualityInspectionForUpdate(ctx context.Context, id int32) (QualityInspection, error)
 	GetQualityInspectionResultByID(ctx context.Context, id int32) (QualityInspectionResult, error)
 	GetQualityInspectionTrends(ctx context.Context, arg GetQualityInspectionTrendsParams) ([]GetQualityInspectionTrendsRow, error)
-	// =====================================================
-	// TRANSACTION-SPECIFIC QUERIES
-	// =====================================================
+	GetReplenishmentSuggestionsForUpdate(ctx context.Context, ids []int32) ([]ReplenishmentSuggestion, error)
 	GetSaleOrderItemsWithBatches(ctx context.Context, salesOrderID pgtype.Int4) ([]GetSaleOrderItemsWithBatchesRow, error)
 	GetSalesOrderByID(ctx context.Context, id int32) (SalesOrder, error)
+	GetSalesOrderByIDForUpdate(ctx context.Context, id int32) (SalesOrder, error)
 	GetSalesOrderByOrderNumber(ctx context.Context, orderNumber string) (SalesOrder, error)
 	GetSalesOrderItemByID(ctx context.Context, id int32) (SalesOrderItem, error)
+	GetSerialNumberHistory(ctx context.Context, serialNumberID int32) ([]GetSerialNumberHistoryRow, error)
+	GetSerialNumbersByNumber(ctx context.Context, arg GetSerialNumbersByNumberParams) ([]GetSerialNumbersByNumberRow, error)
+	GetSerialNumbersForUpdate(ctx context.Context, arg GetSerialNumbersForUpdateParams) ([]SerialNumber, error)
 	GetStabilitySampleByID(ctx context.Context, id int32) (GetStabilitySampleByIDRow, error)
 	GetStabilityStudyByID(ctx context.Context, id int32) (GetStabilityStudyByIDRow, error)
 	GetStabilityStudyByNumber(ctx context.Context, studyNumber string) (StabilityStudy, error)
+	GetStockHistoryStart(ctx context.Context) (pgtype.Timestamptz, error)
+	GetStockLevelsAsOf(ctx context.Context, arg GetStockLevelsAsOfParams) ([]GetStockLevelsAsOfRow, error)
 	GetStockLevelsByMaterial(ctx context.Context, id int32) ([]GetStockLevelsByMaterialRow, error)
 	GetStockLevelsByWarehouse(ctx context.Context) ([]GetStockLevelsByWarehouseRow, error)
+	GetStockMovementBatches(ctx context.Context, movementID int32) ([]GetStockMovementBatchesRow, error)
 	GetStockMovementByID(ctx context.Context, id int32) (StockMovement, error)
+	GetStockMovementByIDForUpdate(ctx context.Context, id int32) (StockMovement, error)
 	GetStockMovementHistory(ctx context.Context, arg GetStockMovementHistoryParams) ([]GetStockMovementHistoryRow, error)
 	GetStockMovementsByReference(ctx context.Context, reference pgtype.Text) ([]StockMovement, error)
+	GetStockReservationByIDForUpdate(ctx context.Context, id int32) (StockReservation, error)
 	GetSupplierByEmail(ctx context.Context, contactEmail pgtype.Text) (Supplier, error)
 	GetSupplierByID(ctx context.Context, id int32) (Supplier, error)
 	GetSupplierByName(ctx context.Context, name string) (Supplier, error)
 	GetSupplierByPhone(ctx context.Context, contactPhone pgtype.Text) (Supplier, error)
 	GetSupplierQualityRatingByID(ctx context.Context, id int32) (GetSupplierQualityRatingByIDRow, error)
 	GetTopDefectiveMaterials(ctx context.Context, limit int32) ([]GetTopDefectiveMaterialsRow, error)
+	GetTraceBatchMovements(ctx context.Context, batchIds []int32) ([]GetTraceBatchMovementsRow, error)
+	GetTraceBatches(ctx context.Context, batchIds []int32) ([]GetTraceBatchesRow, error)
 	GetTransferOutMovementDetails(ctx context.Context, id int32) (StockMovement, error)
+	GetTransferShipmentBatches(ctx context.Context, transferShipmentID pgtype.Int4) ([]GetTransferShipmentBatchesRow, error)
+	GetTransferShipmentByID(ctx context.Context, id int32) (TransferShipment, error)
+	GetTransferShipmentByIDForUpdate(ctx context.Context, id int32) (TransferShipment, error)
 	GetUnitByAbbreviation(ctx context.Context, abbreviation string) (MeasureUnit, error)
 	GetUnitByID(ctx context.Context, id int32) (MeasureUnit, error)
 	GetUnitByName(ctx context.Context, name string) (MeasureUnit, error)
@@ -269,15 +285,27 @@ type Querier interface {
 	GetUserByEmail(ctx context.Context, email string) (GetUserByEmailRow, error)
 	GetUserByID(ctx context.Context, id int32) (GetUserByIDRow, error)
 	GetUserByUsername(ctx context.Context, username string) (GetUserByUsernameRow, error)
+	GetWarehouseBinByCode(ctx context.Context, arg GetWarehouseBinByCodeParams) (WarehouseBin, error)
+	GetWarehouseBinByID(ctx context.Context, id int32) (WarehouseBin, error)
 	GetWarehouseByCode(ctx context.Context, code string) (Warehouse, error)
 	GetWarehouseByID(ctx context.Context, id int32) (Warehouse, error)
 	GetWarehouseByName(ctx context.Context, name string) (Warehouse, error)
 	GetWarehouseStockMovements(ctx context.Context, arg GetWarehouseStockMovementsParams) ([]GetWarehouseStockMovementsRow, error)
+	GetWorkOrderByID(ctx context.Context, id int32) (WorkOrder, error)
+	GetWorkOrderByIDForUpdate(ctx context.Context, id int32) (WorkOrder, error)
+	HoldIdempotencyKey(ctx context.Context, id int32) error
+	LinkBatchToMovementBatches(ctx context.Context, arg LinkBatchToMovementBatchesParams) error
+	LinkBatchToWorkOrderIssues(ctx context.Context, arg LinkBatchToWorkOrderIssuesParams) error
+	ListActiveLineReservationsForUpdate(ctx context.Context, arg ListActiveLineReservationsForUpdateParams) ([]StockReservation, error)
 	ListActiveMaterials(ctx context.Context, arg ListActiveMaterialsParams) ([]ListActiveMaterialsRow, error)
+	ListActivePlanningParameters(ctx context.Context) ([]ListActivePlanningParametersRow, error)
 	ListActiveQualityHolds(ctx context.Context, arg ListActiveQualityHoldsParams) ([]QualityHold, error)
+	ListActiveQualityHoldsByInspection(ctx context.Context, inspectionID pgtype.Int4) ([]QualityHold, error)
 	ListActiveStabilityStudies(ctx context.Context) ([]StabilityStudy, error)
+	ListActiveStockReservations(ctx context.Context, arg ListActiveStockReservationsParams) ([]StockReservation, error)
 	ListAllQualityInspectionCriteria(ctx context.Context, arg ListAllQualityInspectionCriteriaParams) ([]QualityInspectionCriterium, error)
 	ListAnalystQualifications(ctx context.Context, analystID int32) ([]ListAnalystQualificationsRow, error)
+	ListBatchSerialNumbersForUpdate(ctx context.Context, batchID pgtype.Int4) ([]SerialNumber, error)
 	ListBillsOfMaterials(ctx context.Context, arg ListBillsOfMaterialsParams) ([]ListBillsOfMaterialsRow, error)
 	ListCategories(ctx context.Context, arg ListCategoriesParams) ([]MaterialCategory, error)
 	ListCertificatesOfAnalysis(ctx context.Context, arg ListCertificatesOfAnalysisParams) ([]ListCertificatesOfAnalysisRow, error)
@@ -285,7 +313,12 @@ type Querier interface {
 	ListCertificatesOfAnalysisByCustomer(ctx context.Context, arg ListCertificatesOfAnalysisByCustomerParams) ([]CertificatesOfAnalysis, error)
 	ListCertificatesOfAnalysisByMaterial(ctx context.Context, materialID int32) ([]CertificatesOfAnalysis, error)
 	ListCertificatesOfAnalysisByStatus(ctx context.Context, arg ListCertificatesOfAnalysisByStatusParams) ([]CertificatesOfAnalysis, error)
+	ListCostLedgerEntries(ctx context.Context, arg ListCostLedgerEntriesParams) ([]ListCostLedgerEntriesRow, error)
+	ListCountSessionLines(ctx context.Context, sessionID int32) ([]ListCountSessionLinesRow, error)
+	ListCountSessions(ctx context.Context, arg ListCountSessionsParams) ([]ListCountSessionsRow, error)
 	ListCustomers(ctx context.Context, arg ListCustomersParams) ([]Customer, error)
+	ListExpiredBatchesWithoutHold(ctx context.Context) ([]ListExpiredBatchesWithoutHoldRow, error)
+	ListExpiringBatches(ctx context.Context, arg ListExpiringBatchesParams) ([]ListExpiringBatchesRow, error)
 	ListExpiringQualifications(ctx context.Context, expiryDate pgtype.Date) ([]ListExpiringQualificationsRow, error)
 	ListFailedInspectionResults(ctx context.Context, inspectionID int32) ([]QualityInspectionResult, error)
 	ListLabEquipment(ctx context.Context, arg ListLabEquipmentParams) ([]ListLabEquipmentRow, error)
@@ -308,9 +341,13 @@ type Querier interface {
 	ListLabTestResults(ctx context.Context, testAssignmentID int32) ([]ListLabTestResultsRow, error)
 	ListLabTestResultsByAnalyst(ctx context.Context, arg ListLabTestResultsByAnalystParams) ([]LabTestResult, error)
 	ListLabTestResultsOutOfSpec(ctx context.Context, arg ListLabTestResultsOutOfSpecParams) ([]ListLabTestResultsOutOfSpecRow, error)
+	ListMaterialABCClasses(ctx context.Context, warehouseID pgtype.Int4) ([]ListMaterialABCClassesRow, error)
+	ListMaterialAverageCosts(ctx context.Context, materialID int32) ([]ListMaterialAverageCostsRow, error)
 	ListMaterialQualitySpecs(ctx context.Context, materialID int32) ([]ListMaterialQualitySpecsRow, error)
 	ListMonthAuditLogs(ctx context.Context, arg ListMonthAuditLogsParams) ([]AuditLog, error)
+	ListMovementSerials(ctx context.Context, movementIds []int32) ([]ListMovementSerialsRow, error)
 	ListNonConformanceReports(ctx context.Context, arg ListNonConformanceReportsParams) ([]ListNonConformanceReportsRow, error)
+	ListNonConformanceReportsByInspection(ctx context.Context, inspectionID pgtype.Int4) ([]NonConformanceReport, error)
 	ListNonConformanceReportsByMaterial(ctx context.Context, materialID pgtype.Int4) ([]NonConformanceReport, error)
 	ListNonConformanceReportsBySeverity(ctx context.Context, arg ListNonConformanceReportsBySeverityParams) ([]NonConformanceReport, error)
 	ListNonConformanceReportsByStatus(ctx context.Context, arg ListNonConformanceReportsByStatusParams) ([]NonConformanceReport, error)
@@ -325,10 +362,13 @@ type Querier interface {
 	ListOverdueNCRActions(ctx context.Context) ([]NonConformanceReport, error)
 	ListPendingInspections(ctx context.Context, arg ListPendingInspectionsParams) ([]QualityInspection, error)
 	ListPendingLabTestAssignments(ctx context.Context, arg ListPendingLabTestAssignmentsParams) ([]LabTestAssignment, error)
+	ListPlanningParameters(ctx context.Context, arg ListPlanningParametersParams) ([]ListPlanningParametersRow, error)
 	ListPurchaseOrderItems(ctx context.Context, purchaseOrderID pgtype.Int4) ([]PurchaseOrderItem, error)
+	ListPurchaseOrderReceipts(ctx context.Context, purchaseOrderID pgtype.Int4) ([]ListPurchaseOrderReceiptsRow, error)
 	ListPurchaseOrders(ctx context.Context, arg ListPurchaseOrdersParams) ([]PurchaseOrder, error)
 	ListPurchaseOrdersByStatus(ctx context.Context, arg ListPurchaseOrdersByStatusParams) ([]PurchaseOrder, error)
 	ListPurchaseOrdersBySupplier(ctx context.Context, arg ListPurchaseOrdersBySupplierParams) ([]PurchaseOrder, error)
+	ListPutawayCandidates(ctx context.Context, arg ListPutawayCandidatesParams) ([]ListPutawayCandidatesRow, error)
 	ListQualifiedAnalystsForMethod(ctx context.Context, testMethodID int32) ([]ListQualifiedAnalystsForMethodRow, error)
 	ListQualityHolds(ctx context.Context, arg ListQualityHoldsParams) ([]ListQualityHoldsRow, error)
 	ListQualityHoldsByBatch(ctx context.Context, batchNumber pgtype.Text) ([]QualityHold, error)
@@ -342,36 +382,56 @@ type Querier interface {
 	ListQualityInspectionsByStatus(ctx context.Context, arg ListQualityInspectionsByStatusParams) ([]QualityInspection, error)
 	ListQualityInspectionsBySupplier(ctx context.Context, arg ListQualityInspectionsBySupplierParams) ([]QualityInspection, error)
 	ListQualityInspectionsByType(ctx context.Context, arg ListQualityInspectionsByTypeParams) ([]QualityInspection, error)
+	ListReplenishmentSuggestions(ctx context.Context, arg ListReplenishmentSuggestionsParams) ([]ListReplenishmentSuggestionsRow, error)
 	ListSalesOrderItems(ctx context.Context, salesOrderID pgtype.Int4) ([]SalesOrderItem, error)
 	ListSalesOrders(ctx context.Context, arg ListSalesOrdersParams) ([]SalesOrder, error)
 	ListSalesOrdersByCustomer(ctx context.Context, arg ListSalesOrdersByCustomerParams) ([]SalesOrder, error)
 	ListSalesOrdersByStatus(ctx context.Context, arg ListSalesOrdersByStatusParams) ([]SalesOrder, error)
+	ListSerialNumbers(ctx context.Context, arg ListSerialNumbersParams) ([]ListSerialNumbersRow, error)
 	ListStabilitySamplesByStudy(ctx context.Context, stabilityStudyID int32) ([]ListStabilitySamplesByStudyRow, error)
 	ListStabilitySamplesDue(ctx context.Context, scheduledPullDate pgtype.Date) ([]ListStabilitySamplesDueRow, error)
 	ListStabilityStudies(ctx context.Context, arg ListStabilityStudiesParams) ([]ListStabilityStudiesRow, error)
 	ListStabilityStudiesByMaterial(ctx context.Context, materialID int32) ([]StabilityStudy, error)
 	ListStabilityStudiesByStatus(ctx context.Context, arg ListStabilityStudiesByStatusParams) ([]StabilityStudy, error)
+	ListStockReservationsBySalesOrder(ctx context.Context, salesOrderID pgtype.Int4) ([]ListStockReservationsBySalesOrderRow, error)
 	ListSupplierQualityRatings(ctx context.Context, arg ListSupplierQualityRatingsParams) ([]ListSupplierQualityRatingsRow, error)
 	ListSupplierQualityRatingsBySupplier(ctx context.Context, supplierID int32) ([]SupplierQualityRating, error)
 	ListSuppliers(ctx context.Context, arg ListSuppliersParams) ([]Supplier, error)
 	ListSuppliersByQualityRating(ctx context.Context) ([]ListSuppliersByQualityRatingRow, error)
+	ListTransferShipmentMovements(ctx context.Context, transferShipmentID pgtype.Int4) ([]ListTransferShipmentMovementsRow, error)
+	ListTransferShipments(ctx context.Context, arg ListTransferShipmentsParams) ([]ListTransferShipmentsRow, error)
 	ListUnits(ctx context.Context, arg ListUnitsParams) ([]ListUnitsRow, error)
 	ListUsers(ctx context.Context, arg ListUsersParams) ([]ListUsersRow, error)
+	ListWarehouseBins(ctx context.Context, warehouseID int32) ([]ListWarehouseBinsRow, error)
 	ListWarehouses(ctx context.Context, arg ListWarehousesParams) ([]Warehouse, error)
+	ListWorkOrderComponents(ctx context.Context, workOrderID int32) ([]ListWorkOrderComponentsRow, error)
+	ListWorkOrderMovements(ctx context.Context, workOrderID pgtype.Int4) ([]ListWorkOrderMovementsRow, error)
+	ListWorkOrders(ctx context.Context, arg ListWorkOrdersParams) ([]ListWorkOrdersRow, error)
+	LockMaterialBatches(ctx context.Context, arg LockMaterialBatchesParams) error
 	LogAudit(ctx context.Context, arg LogAuditParams) error
+	MarkReplenishmentSuggestionConverted(ctx context.Context, arg MarkReplenishmentSuggestionConvertedParams) error
+	MarkStockMovementReversed(ctx context.Context, arg MarkStockMovementReversedParams) error
+	NextReplenishmentOrderNumber(ctx context.Context) (string, error)
+	PairStockMovement(ctx context.Context, arg PairStockMovementParams) error
+	ReceiveTransferShipment(ctx context.Context, arg ReceiveTransferShipmentParams) (TransferShipment, error)
+	RegisterSerialNumber(ctx context.Context, arg RegisterSerialNumberParams) (SerialNumber, error)
 	ReleaseQualityHold(ctx context.Context, arg ReleaseQualityHoldParams) (QualityHold, error)
+	ReleaseSalesOrderItemReservations(ctx context.Context, salesOrderItemID int32) (int64, error)
+	ReleaseSalesOrderReservations(ctx context.Context, salesOrderID pgtype.Int4) (int64, error)
+	ReleaseStockReservation(ctx context.Context, id int32) (StockReservation, error)
 	RestoreMaterial(ctx context.Context, id int32) error
 	SearchBillsOfMaterials(ctx context.Context, arg SearchBillsOfMaterialsParams) ([]SearchBillsOfMaterialsRow, error)
 	SearchCustomers(ctx context.Context, arg SearchCustomersParams) ([]Customer, error)
 	SearchLabTestMethods(ctx context.Context, arg SearchLabTestMethodsParams) ([]LabTestMethod, error)
-	// ============================================================================
-	// SEARCH MATERIALS (with filters and pagination)
-	// ============================================================================
 	SearchMaterials(ctx context.Context, arg SearchMaterialsParams) ([]SearchMaterialsRow, error)
 	SearchPurchaseOrders(ctx context.Context, arg SearchPurchaseOrdersParams) ([]PurchaseOrder, error)
 	SearchQualityInspectionCriteria(ctx context.Context, arg SearchQualityInspectionCriteriaParams) ([]QualityInspectionCriterium, error)
 	SearchSalesOrders(ctx context.Context, arg SearchSalesOrdersParams) ([]SalesOrder, error)
 	SearchSuppliers(ctx context.Context, arg SearchSuppliersParams) ([]Supplier, error)
+	SetBatchExpiryHold(ctx context.Context, arg SetBatchExpiryHoldParams) error
+	SetCountSessionLineMovement(ctx context.Context, arg SetCountSessionLineMovementParams) error
+	TraceBatchesDownstream(ctx context.Context, batchIds []int32) ([]TraceBatchesDownstreamRow, error)
+	TraceBatchesUpstream(ctx context.Context, batchIds []int32) ([]TraceBatchesUpstreamRow, error)
 	UnarchiveBOM(ctx context.Context, id int32) (UnarchiveBOMRow, error)
 	UpdateAnalystQualification(ctx context.Context, arg UpdateAnalystQualificationParams) (AnalystQualification, error)
 	UpdateBOMActualCost(ctx context.Context, arg UpdateBOMActualCostParams) error
@@ -379,14 +439,13 @@ type Querier interface {
 	UpdateBillOfMaterial(ctx context.Context, arg UpdateBillOfMaterialParams) (UpdateBillOfMaterialRow, error)
 	UpdateCategory(ctx context.Context, arg UpdateCategoryParams) (MaterialCategory, error)
 	UpdateCertificateOfAnalysis(ctx context.Context, arg UpdateCertificateOfAnalysisParams) (CertificatesOfAnalysis, error)
+	UpdateCountSessionLineCount(ctx context.Context, arg UpdateCountSessionLineCountParams) (int64, error)
+	UpdateCountSessionStatus(ctx context.Context, arg UpdateCountSessionStatusParams) (CountSession, error)
 	UpdateCustomer(ctx context.Context, arg UpdateCustomerParams) (Customer, error)
 	UpdateLabEquipment(ctx context.Context, arg UpdateLabEquipmentParams) (LabEquipment, error)
 	UpdateLabSample(ctx context.Context, arg UpdateLabSampleParams) (LabSample, error)
 	UpdateLabTestAssignment(ctx context.Context, arg UpdateLabTestAssignmentParams) (LabTestAssignment, error)
 	UpdateLabTestMethod(ctx context.Context, arg UpdateLabTestMethodParams) (LabTestMethod, error)
-	// ============================================================================
-	// UPDATE MATERIAL
-	// ============================================================================
 	UpdateMaterial(ctx context.Context, arg UpdateMaterialParams) (Material, error)
 	UpdateMaterialQualitySpec(ctx context.Context, arg UpdateMaterialQualitySpecParams) (MaterialQualitySpec, error)
 	UpdateNonConformanceReport(ctx context.Context, arg UpdateNonConformanceReportParams) (NonConformanceReport, error)
@@ -394,20 +453,29 @@ type Querier interface {
 	UpdatePurchaseOrder(ctx context.Context, arg UpdatePurchaseOrderParams) (PurchaseOrder, error)
 	UpdatePurchaseOrderItem(ctx context.Context, arg UpdatePurchaseOrderItemParams) (PurchaseOrderItem, error)
 	UpdatePurchaseOrderItemReceivedQuantity(ctx context.Context, arg UpdatePurchaseOrderItemReceivedQuantityParams) (PurchaseOrderItem, error)
+	UpdatePurchaseOrderStatus(ctx context.Context, arg UpdatePurchaseOrderStatusParams) (PurchaseOrder, error)
 	UpdateQualityHold(ctx context.Context, arg UpdateQualityHoldParams) (QualityHold, error)
 	UpdateQualityInspection(ctx context.Context, arg UpdateQualityInspectionParams) (QualityInspection, error)
 	UpdateQualityInspectionCriteria(ctx context.Context, arg UpdateQualityInspectionCriteriaParams) (QualityInspectionCriterium, error)
 	UpdateSalesOrder(ctx context.Context, arg UpdateSalesOrderParams) (SalesOrder, error)
 	UpdateSalesOrderItem(ctx context.Context, arg UpdateSalesOrderItemParams) (SalesOrderItem, error)
 	UpdateSalesOrderItemShippedQuantity(ctx context.Context, arg UpdateSalesOrderItemShippedQuantityParams) (SalesOrderItem, error)
+	UpdateSalesOrderStatus(ctx context.Context, arg UpdateSalesOrderStatusParams) (SalesOrder, error)
+	UpdateSerialNumber(ctx context.Context, arg UpdateSerialNumberParams) (SerialNumber, error)
 	UpdateStabilitySample(ctx context.Context, arg UpdateStabilitySampleParams) (StabilitySample, error)
 	UpdateStabilityStudy(ctx context.Context, arg UpdateStabilityStudyParams) (StabilityStudy, error)
+	UpdateStockReservationFulfilment(ctx context.Context, arg UpdateStockReservationFulfilmentParams) (StockReservation, error)
 	UpdateSupplier(ctx context.Context, arg UpdateSupplierParams) (Supplier, error)
 	UpdateSupplierQualityRating(ctx context.Context, arg UpdateSupplierQualityRatingParams) (SupplierQualityRating, error)
 	UpdateUnit(ctx context.Context, arg UpdateUnitParams) (MeasureUnit, error)
 	UpdateUser(ctx context.Context, arg UpdateUserParams) (UpdateUserRow, error)
 	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) error
 	UpdateWarehouse(ctx context.Context, arg UpdateWarehouseParams) (Warehouse, error)
+	UpdateWarehouseBin(ctx context.Context, arg UpdateWarehouseBinParams) (WarehouseBin, error)
+	UpdateWorkOrderStatus(ctx context.Context, arg UpdateWorkOrderStatusParams) (WorkOrder, error)
+	UpsertMaterialAverageCost(ctx context.Context, arg UpsertMaterialAverageCostParams) (MaterialAverageCost, error)
+	UpsertOpenReplenishmentSuggestion(ctx context.Context, arg UpsertOpenReplenishmentSuggestionParams) (ReplenishmentSuggestion, error)
+	UpsertPlanningParameters(ctx context.Context, arg UpsertPlanningParametersParams) (MaterialPlanningParameter, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -172,6 +172,33 @@ func (q *Queries) GetSalesOrderByID(ctx context.Context, id int32) (SalesOrder,
 	return i, err
 }
 
+const getSalesOrderByIDForUpdate = `-- name: GetSalesOrderByIDForUpdate :one
+SELECT id, order_number, customer_id, order_date, expected_delivery_date, status, total_amount, created_by, approved_by, meta, created_at, updated_at
+FROM sales_orders
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetSalesOrderByIDForUpdate(ctx context.Context, id int32) (SalesOrder, error) {
+	row := q.db.QueryRow(ctx, getSalesOrderByIDForUpdate, id)
+	var i SalesOrder
+	err := row.Scan(
+		&i.ID,
+		&i.OrderNumber,
+		&i.CustomerID,
+		&i.OrderDate,
+		&i.ExpectedDeliveryDate,
+		&i.Status,
+		&i.TotalAmount,
+		&i.CreatedBy,
+		&i.ApprovedBy,
+		&i.Meta,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const getSalesOrderByOrderNumber = `-- name: GetSalesOrderByOrderNumber :one
 SELECT id, order_number, customer_id, order_date, expected_delivery_date, status, total_amount, created_by, approved_by, meta, created_at, updated_at
 FROM sales_orders
@@ -581,3 +608,37 @@ func (q *Queries) UpdateSalesOrderItemShippedQuantity(ctx context.Context, arg U
 	)
 	return i, err
 }
+
+const updateSalesOrderStatus = `-- name: UpdateSalesOrderStatus :one
+UPDATE sales_orders
+SET
+    status = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, order_number, customer_id, order_date, expected_delivery_date, status, total_amount, created_by, approved_by, meta, created_at, updated_at
+`
+
+type UpdateSalesOrderStatusParams struct {
+	ID     int32  `json:"id"`
+	Status string `json:"status"`
+}
+
+func (q *Queries) UpdateSalesOrderStatus(ctx context.Context, arg UpdateSalesOrderStatusParams) (SalesOrder, error) {
+	row := q.db.QueryRow(ctx, updateSalesOrderStatus, arg.ID, arg.Status)
+	var i SalesOrder
+	err := row.Scan(
+		&i.ID,
+		&i.OrderNumber,
+		&i.CustomerID,
+		&i.OrderDate,
+		&i.ExpectedDeliveryDate,
+		&i.Status,
+		&i.TotalAmount,
+		&i.CreatedBy,
+		&i.ApprovedBy,
+		&i.Meta,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -31,15 +31,15 @@ const createBatch = `-- name: CreateBatch :one
 INSERT INTO batches (
     material_id, supplier_id, warehouse_id, movement_id,
     unit_price, batch_number, manufacture_date, expiry_date,
-    start_quantity, current_quantity, notes, meta
+    start_quantity, current_quantity, notes, meta, bin_id
 ) VALUES (
     $1, $2, $3, $4,
     $5, $6, $7, $8,
-    $9, $10, $11, $12
+    $9, $10, $11, $12, $13
 )
 RETURNING id, material_id, supplier_id, warehouse_id, movement_id,
     unit_price, batch_number, manufacture_date, expiry_date,
-    start_quantity, current_quantity, notes, meta, created_at, updated_at
+    start_quantity, current_quantity, notes, meta, created_at, updated_at, bin_id
 `
 
 type CreateBatchParams struct {
@@ -55,6 +55,7 @@ type CreateBatchParams struct {
 	CurrentQuantity pgtype.Numeric `json:"current_quantity"`
 	Notes           pgtype.Text    `json:"notes"`
 	Meta            []byte         `json:"meta"`
+	BinID           pgtype.Int4    `json:"bin_id"`
 }
 
 func (q *Queries) CreateBatch(ctx context.Context, arg CreateBatchParams) (Batch, error) {
@@ -71,6 +72,7 @@ func (q *Queries) CreateBatch(ctx context.Context, arg CreateBatchParams) (Batch
 		arg.CurrentQuantity,
 		arg.Notes,
 		arg.Meta,
+		arg.BinID,
 	)
 	var i Batch
 	err := row.Scan(
@@ -89,6 +91,7 @@ func (q *Queries) CreateBatch(ctx context.Context, arg CreateBatchParams) (Batch
 		&i.Meta,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.BinID,
 	)
 	return i, err
 }
@@ -98,28 +101,45 @@ const createStockMovement = `-- name: CreateStockMovement :one
 INSERT INTO stock_movements (
     material_id, from_warehouse_id, to_warehouse_id,
     quantity, stock_direction, movement_type,
-    reference, performed_by, movement_date, notes
+    reference, performed_by, movement_date, notes,
+    unit_cost, total_cost, sales_order_item_id, purchase_order_item_id, reverses_movement_id, reversed_by_movement_id, from_bin_id, to_bin_id, entered_quantity, entered_unit_id, work_order_id, transfer_shipment_id, ncr_id, paired_movement_id
 ) VALUES (
     $1, $2, $3,
     $4, $5, $6,
-    $7, $8, $9, $10
+    $7, $8, $9, $10,
+    $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24
 )
 RETURNING id, material_id, from_warehouse_id, to_warehouse_id,
     quantity, stock_direction, movement_type,
-    reference, performed_by, movement_date, notes, created_at, updated_at
+    reference, performed_by, movement_date, notes, created_at, updated_at,
+    unit_cost, total_cost, sales_order_item_id, purchase_order_item_id, reverses_movement_id, reversed_by_movement_id, from_bin_id, to_bin_id, entered_quantity, entered_unit_id, work_order_id, transfer_shipment_id, ncr_id, paired_movement_id
 `
 
 type CreateStockMovementParams struct {
-	MaterialID      pgtype.Int4        `json:"material_id"`
-	FromWarehouseID pgtype.Int4        `json:"from_warehouse_id"`
-	ToWarehouseID   pgtype.Int4        `json:"to_warehouse_id"`
-	Quantity        pgtype.Numeric     `json:"quantity"`
-	StockDirection  StockDirection     `json:"stock_direction"`
-	MovementType    StockMovementType  `json:"movement_type"`
-	Reference       pgtype.Text        `json:"reference"`
-	PerformedBy     pgtype.Int4        `json:"performed_by"`
-	MovementDate    pgtype.Timestamptz `json:"movement_date"`
-	Notes           pgtype.Text        `json:"notes"`
+	MaterialID           pgtype.Int4        `json:"material_id"`
+	FromWarehouseID      pgtype.Int4        `json:"from_warehouse_id"`
+	ToWarehouseID        pgtype.Int4        `json:"to_warehouse_id"`
+	Quantity             pgtype.Numeric     `json:"quantity"`
+	StockDirection       StockDirection     `json:"stock_direction"`
+	MovementType         StockMovementType  `json:"movement_type"`
+	Reference            pgtype.Text        `json:"reference"`
+	PerformedBy          pgtype.Int4        `json:"performed_by"`
+	MovementDate         pgtype.Timestamptz `json:"movement_date"`
+	Notes                pgtype.Text        `json:"notes"`
+	UnitCost             pgtype.Numeric     `json:"unit_cost"`
+	TotalCost            pgtype.Numeric     `json:"total_cost"`
+	SalesOrderItemID     pgtype.Int4        `json:"sales_order_item_id"`
+	PurchaseOrderItemID  pgtype.Int4        `json:"purchase_order_item_id"`
+	ReversesMovementID   pgtype.Int4        `json:"reverses_movement_id"`
+	ReversedByMovementID pgtype.Int4        `json:"reversed_by_movement_id"`
+	FromBinID            pgtype.Int4        `json:"from_bin_id"`
+	ToBinID              pgtype.Int4        `json:"to_bin_id"`
+	EnteredQuantity      pgtype.Numeric     `json:"entered_quantity"`
+	EnteredUnitID        pgtype.Int4        `json:"entered_unit_id"`
+	WorkOrderID          pgtype.Int4        `json:"work_order_id"`
+	TransferShipmentID   pgtype.Int4        `json:"transfer_shipment_id"`
+	NcrID                pgtype.Int4        `json:"ncr_id"`
+	PairedMovementID     pgtype.Int4        `json:"paired_movement_id"`
 }
 
 // =====================================================
@@ -137,6 +157,20 @@ func (q *Queries) CreateStockMovement(ctx context.Context, arg CreateStockMoveme
 		arg.PerformedBy,
 		arg.MovementDate,
 		arg.Notes,
+		arg.UnitCost,
+		arg.TotalCost,
+		arg.SalesOrderItemID,
+		arg.PurchaseOrderItemID,
+		arg.ReversesMovementID,
+		arg.ReversedByMovementID,
+		arg.FromBinID,
+		arg.ToBinID,
+		arg.EnteredQuantity,
+		arg.EnteredUnitID,
+		arg.WorkOrderID,
+		arg.TransferShipmentID,
+		arg.NcrID,
+		arg.PairedMovementID,
 	)
 	var i StockMovement
 	err := row.Scan(
@@ -153,6 +187,54 @@ func (q *Queries) CreateStockMovement(ctx context.Context, arg CreateStockMoveme
 		&i.Notes,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.UnitCost,
+		&i.TotalCost,
+		&i.SalesOrderItemID,
+		&i.PurchaseOrderItemID,
+		&i.ReversesMovementID,
+		&i.ReversedByMovementID,
+		&i.FromBinID,
+		&i.ToBinID,
+		&i.EnteredQuantity,
+		&i.EnteredUnitID,
+		&i.WorkOrderID,
+		&i.TransferShipmentID,
+		&i.NcrID,
+		&i.PairedMovementID,
+	)
+	return i, err
+}
+
+const createStockMovementBatch = `-- name: CreateStockMovementBatch :one
+
+INSERT INTO stock_movement_batches (
+    movement_id, batch_id, quantity, unit_cost
+) VALUES (
+    $1, $2, $3, $4
+)
+RETURNING id, movement_id, batch_id, quantity, unit_cost, created_at
+`
+
+type CreateStockMovementBatchParams struct {
+	MovementID int32          `json:"movement_id"`
+	BatchID    int32          `json:"batch_id"`
+	Quantity   pgtype.Numeric `json:"quantity"`
+	UnitCost   pgtype.Numeric `json:"unit_cost"`
+}
+
+// =====================================================
+// MOVEMENT BATCH LINE QUERIES
+// =====================================================
+func (q *Queries) CreateStockMovementBatch(ctx context.Context, arg CreateStockMovementBatchParams) (StockMovementBatch, error) {
+	row := q.db.QueryRow(ctx, createStockMovementBatch, arg.MovementID, arg.BatchID, arg.Quantity, arg.UnitCost)
+	var i StockMovementBatch
+	err := row.Scan(
+		&i.ID,
+		&i.MovementID,
+		&i.BatchID,
+		&i.Quantity,
+		&i.UnitCost,
+		&i.CreatedAt,
 	)
 	return i, err
 }
@@ -167,10 +249,19 @@ SELECT
     b.manufacture_date,
     b.expiry_date,
     w.name as warehouse_name,
-    s.name as supplier_name
+    s.name as supplier_name,
+    COALESCE(h.held_quantity, 0)::DECIMAL(15, 4) as held_quantity,
+    COALESCE(rb.reserved_quantity, 0)::DECIMAL(15, 4) as reserved_quantity,
+    GREATEST(b.current_quantity - COALESCE(h.held_quantity, 0) - COALESCE(rb.reserved_quantity, 0), 0)::DECIMAL(15, 4) as available_quantity,
+    h.hold_number,
+    b.bin_id,
+    wb.code as bin_code
 FROM batches b
 LEFT JOIN warehouses w ON b.warehouse_id = w.id
+LEFT JOIN warehouse_bins wb ON b.bin_id = wb.id
 LEFT JOIN suppliers s ON b.supplier_id = s.id
+LEFT JOIN v_batches_on_hold h ON h.batch_id = b.id
+LEFT JOIN v_reserved_batches rb ON rb.batch_id = b.id
 WHERE b.material_id = $1
   AND b.warehouse_id = $2
   AND b.current_quantity > 0
@@ -183,14 +274,20 @@ type GetAvailableBatchesForMaterialParams struct {
 }
 
 type GetAvailableBatchesForMaterialRow struct {
-	ID              int32          `json:"id"`
-	BatchNumber     string         `json:"batch_number"`
-	CurrentQuantity pgtype.Numeric `json:"current_quantity"`
-	UnitPrice       pgtype.Numeric `json:"unit_price"`
-	ManufactureDate pgtype.Date    `json:"manufacture_date"`
-	ExpiryDate      pgtype.Date    `json:"expiry_date"`
-	WarehouseName   pgtype.Text    `json:"warehouse_name"`
-	SupplierName    pgtype.Text    `json:"supplier_name"`
+	ID                int32          `json:"id"`
+	BatchNumber       string         `json:"batch_number"`
+	CurrentQuantity   pgtype.Numeric `json:"current_quantity"`
+	UnitPrice         pgtype.Numeric `json:"unit_price"`
+	ManufactureDate   pgtype.Date    `json:"manufacture_date"`
+	ExpiryDate        pgtype.Date    `json:"expiry_date"`
+	WarehouseName     pgtype.Text    `json:"warehouse_name"`
+	SupplierName      pgtype.Text    `json:"supplier_name"`
+	HeldQuantity      pgtype.Numeric `json:"held_quantity"`
+	ReservedQuantity  pgtype.Numeric `json:"reserved_quantity"`
+	AvailableQuantity pgtype.Numeric `json:"available_quantity"`
+	HoldNumber        pgtype.Text    `json:"hold_number"`
+	BinID             pgtype.Int4    `json:"bin_id"`
+	BinCode           pgtype.Text    `json:"bin_code"`
 }
 
 // =====================================================
@@ -214,6 +311,12 @@ func (q *Queries) GetAvailableBatchesForMaterial(ctx context.Context, arg GetAva
 			&i.ExpiryDate,
 			&i.WarehouseName,
 			&i.SupplierName,
+			&i.HeldQuantity,
+			&i.ReservedQuantity,
+			&i.AvailableQuantity,
+			&i.HoldNumber,
+			&i.BinID,
+			&i.BinCode,
 		); err != nil {
 			return nil, err
 		}
@@ -228,7 +331,7 @@ func (q *Queries) GetAvailableBatchesForMaterial(ctx context.Context, arg GetAva
 const getBatchByID = `-- name: GetBatchByID :one
 SELECT id, material_id, supplier_id, warehouse_id, movement_id,
     unit_price, batch_number, manufacture_date, expiry_date,
-    start_quantity, current_quantity, notes, meta, created_at, updated_at
+    start_quantity, current_quantity, notes, meta, created_at, updated_at, bin_id
 FROM batches
 WHERE id = $1
 `
@@ -252,16 +355,59 @@ func (q *Queries) GetBatchByID(ctx context.Context, id int32) (Batch, error) {
 		&i.Meta,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.BinID,
 	)
 	return i, err
 }
 
+const getBatchHoldsByIDs = `-- name: GetBatchHoldsByIDs :many
+SELECT batch_id, batch_number, hold_number, quality_status, hold_reason
+FROM v_batches_on_hold
+WHERE batch_id = ANY($1::int[])
+`
+
+type GetBatchHoldsByIDsRow struct {
+	BatchID       int32         `json:"batch_id"`
+	BatchNumber   string        `json:"batch_number"`
+	HoldNumber    string        `json:"hold_number"`
+	QualityStatus QualityStatus `json:"quality_status"`
+	HoldReason    string        `json:"hold_reason"`
+}
+
+func (q *Queries) GetBatchHoldsByIDs(ctx context.Context, dollar_1 []int32) ([]GetBatchHoldsByIDsRow, error) {
+	rows, err := q.db.Query(ctx, getBatchHoldsByIDs, dollar_1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetBatchHoldsByIDsRow{}
+	for rows.Next() {
+		var i GetBatchHoldsByIDsRow
+		if err := rows.Scan(
+			&i.BatchID,
+			&i.BatchNumber,
+			&i.HoldNumber,
+			&i.QualityStatus,
+			&i.HoldReason,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getBatchesByIDs = `-- name: GetBatchesByIDs :many
 SELECT id, material_id, supplier_id, warehouse_id, movement_id,
     unit_price, batch_number, manufacture_date, expiry_date,
-    start_quantity, current_quantity, notes, meta, created_at, updated_at
+    start_quantity, current_quantity, notes, meta, created_at, updated_at, bin_id
 FROM batches
 WHERE id = ANY($1::int[])
+ORDER BY id
+FOR UPDATE
 `
 
 func (q *Queries) GetBatchesByIDs(ctx context.Context, dollar_1 []int32) ([]Batch, error) {
@@ -289,6 +435,7 @@ func (q *Queries) GetBatchesByIDs(ctx context.Context, dollar_1 []int32) ([]Batc
 			&i.Meta,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.BinID,
 		); err != nil {
 			return nil, err
 		}
@@ -303,12 +450,16 @@ func (q *Queries) GetBatchesByIDs(ctx context.Context, dollar_1 []int32) ([]Batc
 const getBatchesByWarehouseAndMaterial = `-- name: GetBatchesByWarehouseAndMaterial :many
 SELECT id, material_id, supplier_id, warehouse_id, movement_id,
     unit_price, batch_number, manufacture_date, expiry_date,
-    start_quantity, current_quantity, notes, meta, created_at, updated_at
+    start_quantity, current_quantity, notes, meta, created_at, updated_at, bin_id
 FROM batches
 WHERE warehouse_id = $1
   AND material_id = $2
   AND current_quantity > 0
+  AND NOT EXISTS (
+      SELECT 1 FROM v_batches_on_hold h WHERE h.batch_id = batches.id
+  )
 ORDER BY created_at ASC
+FOR UPDATE OF batches
 `
 
 type GetBatchesByWarehouseAndMaterialParams struct {
@@ -341,6 +492,65 @@ func (q *Queries) GetBatchesByWarehouseAndMaterial(ctx context.Context, arg GetB
 			&i.Meta,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.BinID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBatchesByWarehouseAndMaterialFEFO = `-- name: GetBatchesByWarehouseAndMaterialFEFO :many
+SELECT id, material_id, supplier_id, warehouse_id, movement_id,
+    unit_price, batch_number, manufacture_date, expiry_date,
+    start_quantity, current_quantity, notes, meta, created_at, updated_at, bin_id
+FROM batches
+WHERE warehouse_id = $1
+  AND material_id = $2
+  AND current_quantity > 0
+  AND (expiry_date IS NULL OR expiry_date >= CURRENT_DATE)
+  AND NOT EXISTS (
+      SELECT 1 FROM v_batches_on_hold h WHERE h.batch_id = batches.id
+  )
+ORDER BY expiry_date ASC NULLS LAST, created_at ASC
+FOR UPDATE OF batches
+`
+
+type GetBatchesByWarehouseAndMaterialFEFOParams struct {
+	WarehouseID pgtype.Int4 `json:"warehouse_id"`
+	MaterialID  pgtype.Int4 `json:"material_id"`
+}
+
+func (q *Queries) GetBatchesByWarehouseAndMaterialFEFO(ctx context.Context, arg GetBatchesByWarehouseAndMaterialFEFOParams) ([]Batch, error) {
+	rows, err := q.db.Query(ctx, getBatchesByWarehouseAndMaterialFEFO, arg.WarehouseID, arg.MaterialID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Batch{}
+	for rows.Next() {
+		var i Batch
+		if err := rows.Scan(
+			&i.ID,
+			&i.MaterialID,
+			&i.SupplierID,
+			&i.WarehouseID,
+			&i.MovementID,
+			&i.UnitPrice,
+			&i.BatchNumber,
+			&i.ManufactureDate,
+			&i.ExpiryDate,
+			&i.StartQuantity,
+			&i.CurrentQuantity,
+			&i.Notes,
+			&i.Meta,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.BinID,
 		); err != nil {
 			return nil, err
 		}
@@ -355,12 +565,16 @@ func (q *Queries) GetBatchesByWarehouseAndMaterial(ctx context.Context, arg GetB
 const getBatchesByWarehouseAndMaterialLIFO = `-- name: GetBatchesByWarehouseAndMaterialLIFO :many
 SELECT id, material_id, supplier_id, warehouse_id, movement_id,
     unit_price, batch_number, manufacture_date, expiry_date,
-    start_quantity, current_quantity, notes, meta, created_at, updated_at
+    start_quantity, current_quantity, notes, meta, created_at, updated_at, bin_id
 FROM batches
 WHERE warehouse_id = $1
   AND material_id = $2
   AND current_quantity > 0
+  AND NOT EXISTS (
+      SELECT 1 FROM v_batches_on_hold h WHERE h.batch_id = batches.id
+  )
 ORDER BY created_at DESC
+FOR UPDATE OF batches
 `
 
 type GetBatchesByWarehouseAndMaterialLIFOParams struct {
@@ -393,6 +607,7 @@ func (q *Queries) GetBatchesByWarehouseAndMaterialLIFO(ctx context.Context, arg
 			&i.Meta,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.BinID,
 		); err != nil {
 			return nil, err
 		}
@@ -408,8 +623,23 @@ const getCurrentStockLevel = `-- name: GetCurrentStockLevel :one
 
 SELECT 
     COALESCE(SUM(b.current_quantity), 0) as total_quantity,
-    COUNT(DISTINCT b.id) as batch_count
+    COUNT(DISTINCT b.id) as batch_count,
+    COALESCE(SUM(h.held_quantity), 0)::DECIMAL(15, 4) as held_quantity,
+    COALESCE(MAX(r.reserved_quantity), 0)::DECIMAL(15, 4) as reserved_quantity,
+    GREATEST(
+        COALESCE(SUM(b.current_quantity), 0)
+        - COALESCE(SUM(h.held_quantity), 0)
+        - COALESCE(MAX(r.reserved_quantity), 0),
+        0
+    )::DECIMAL(15, 4) as available_quantity,
+    (
+        SELECT COALESCE(SUM(t.in_transit_quantity), 0)
+        FROM v_in_transit_stock t
+        WHERE t.material_id = $1 AND t.warehouse_id = $2
+    )::DECIMAL(15, 4) as in_transit_quantity
 FROM batches b
+LEFT JOIN v_batches_on_hold h ON h.batch_id = b.id
+LEFT JOIN v_reserved_stock r ON r.material_id = b.material_id AND r.warehouse_id = b.warehouse_id
 WHERE b.material_id = $1
   AND b.warehouse_id = $2
   AND b.current_quantity > 0
@@ -421,8 +651,12 @@ type GetCurrentStockLevelParams struct {
 }
 
 type GetCurrentStockLevelRow struct {
-	TotalQuantity interface{} `json:"total_quantity"`
-	BatchCount    int64       `json:"batch_count"`
+	TotalQuantity     interface{}    `json:"total_quantity"`
+	BatchCount        int64          `json:"batch_count"`
+	HeldQuantity      pgtype.Numeric `json:"held_quantity"`
+	ReservedQuantity  pgtype.Numeric `json:"reserved_quantity"`
+	AvailableQuantity pgtype.Numeric `json:"available_quantity"`
+	InTransitQuantity pgtype.Numeric `json:"in_transit_quantity"`
 }
 
 // =====================================================
@@ -431,7 +665,14 @@ type GetCurrentStockLevelRow struct {
 func (q *Queries) GetCurrentStockLevel(ctx context.Context, arg GetCurrentStockLevelParams) (GetCurrentStockLevelRow, error) {
 	row := q.db.QueryRow(ctx, getCurrentStockLevel, arg.MaterialID, arg.WarehouseID)
 	var i GetCurrentStockLevelRow
-	err := row.Scan(&i.TotalQuantity, &i.BatchCount)
+	err := row.Scan(
+		&i.TotalQuantity,
+		&i.BatchCount,
+		&i.HeldQuantity,
+		&i.ReservedQuantity,
+		&i.AvailableQuantity,
+		&i.InTransitQuantity,
+	)
 	return i, err
 }
 
@@ -455,6 +696,28 @@ func (q *Queries) GetLastBatchNumberForMaterial(ctx context.Context, materialID
 	return batch_number, err
 }
 
+const getMaterialAllocationStrategy = `-- name: GetMaterialAllocationStrategy :one
+
+SELECT COALESCE(m.allocation_strategy, w.allocation_strategy) as allocation_strategy
+FROM materials m
+CROSS JOIN warehouses w
+WHERE m.id = $1 AND w.id = $2
+`
+
+type GetMaterialAllocationStrategyParams struct {
+	ID   int32 `json:"id"`
+	ID_2 int32 `json:"id_2"`
+}
+
+// The order batches of a material are drawn from in a warehouse, the
+// material's own strategy or else the warehouse's
+func (q *Queries) GetMaterialAllocationStrategy(ctx context.Context, arg GetMaterialAllocationStrategyParams) (AllocationStrategy, error) {
+	row := q.db.QueryRow(ctx, getMaterialAllocationStrategy, arg.ID, arg.ID_2)
+	var allocation_strategy AllocationStrategy
+	err := row.Scan(&allocation_strategy)
+	return allocation_strategy, err
+}
+
 const getMaterialValuationMethod = `-- name: GetMaterialValuationMethod :one
 
 SELECT COALESCE(m.valuation, w.valuation) as valuation_method
@@ -554,26 +817,35 @@ SELECT
     COUNT(DISTINCT b.id) as batch_count,
     MIN(b.unit_price) as min_unit_price,
     MAX(b.unit_price) as max_unit_price,
-    AVG(b.unit_price) as avg_unit_price
+    AVG(b.unit_price) as avg_unit_price,
+    COALESCE(MAX(t.in_transit_quantity), 0)::DECIMAL(15, 4) as in_transit_quantity
 FROM materials m
-LEFT JOIN batches b ON b.material_id = m.id AND b.current_quantity > 0
-LEFT JOIN warehouses w ON b.warehouse_id = w.id
+LEFT JOIN warehouses w ON EXISTS (
+        SELECT 1 FROM batches sb
+        WHERE sb.material_id = m.id AND sb.warehouse_id = w.id AND sb.current_quantity > 0
+    ) OR EXISTS (
+        SELECT 1 FROM v_in_transit_stock st
+        WHERE st.material_id = m.id AND st.warehouse_id = w.id
+    )
+LEFT JOIN batches b ON b.material_id = m.id AND b.warehouse_id = w.id AND b.current_quantity > 0
+LEFT JOIN v_in_transit_stock t ON t.material_id = m.id AND t.warehouse_id = w.id
 WHERE m.id = $1
 GROUP BY m.id, m.name, m.code, w.id, w.name
 ORDER BY w.name
 `
 
 type GetStockLevelsByMaterialRow struct {
-	MaterialID    int32       `json:"material_id"`
-	MaterialName  string      `json:"material_name"`
-	MaterialCode  string      `json:"material_code"`
-	WarehouseID   pgtype.Int4 `json:"warehouse_id"`
-	WarehouseName pgtype.Text `json:"warehouse_name"`
-	TotalQuantity interface{} `json:"total_quantity"`
-	BatchCount    int64       `json:"batch_count"`
-	MinUnitPrice  interface{} `json:"min_unit_price"`
-	MaxUnitPrice  interface{} `json:"max_unit_price"`
-	AvgUnitPrice  float64     `json:"avg_unit_price"`
+	MaterialID        int32          `json:"material_id"`
+	MaterialName      string         `json:"material_name"`
+	MaterialCode      string         `json:"material_code"`
+	WarehouseID       pgtype.Int4    `json:"warehouse_id"`
+	WarehouseName     pgtype.Text    `json:"warehouse_name"`
+	TotalQuantity     interface{}    `json:"total_quantity"`
+	BatchCount        int64          `json:"batch_count"`
+	MinUnitPrice      interface{}    `json:"min_unit_price"`
+	MaxUnitPrice      interface{}    `json:"max_unit_price"`
+	AvgUnitPrice      float64        `json:"avg_unit_price"`
+	InTransitQuantity pgtype.Numeric `json:"in_transit_quantity"`
 }
 
 func (q *Queries) GetStockLevelsByMaterial(ctx context.Context, id int32) ([]GetStockLevelsByMaterialRow, error) {
@@ -596,6 +868,7 @@ func (q *Queries) GetStockLevelsByMaterial(ctx context.Context, id int32) ([]Get
 			&i.MinUnitPrice,
 			&i.MaxUnitPrice,
 			&i.AvgUnitPrice,
+			&i.InTransitQuantity,
 		); err != nil {
 			return nil, err
 		}
@@ -615,24 +888,28 @@ SELECT
     m.name as material_name,
     m.code as material_code,
     COALESCE(SUM(b.current_quantity), 0) as total_quantity,
-    COUNT(DISTINCT b.id) as batch_count
+    COUNT(DISTINCT b.id) as batch_count,
+    COALESCE(MAX(t.in_transit_quantity), 0)::DECIMAL(15, 4) as in_transit_quantity
 FROM warehouses w
 CROSS JOIN materials m
 LEFT JOIN batches b ON b.warehouse_id = w.id AND b.material_id = m.id AND b.current_quantity > 0
+LEFT JOIN v_in_transit_stock t ON t.material_id = m.id AND t.warehouse_id = w.id
 WHERE m.is_active = TRUE
 GROUP BY w.id, w.name, m.id, m.name, m.code
 HAVING COALESCE(SUM(b.current_quantity), 0) > 0
+    OR COALESCE(MAX(t.in_transit_quantity), 0) > 0
 ORDER BY w.name, m.name
 `
 
 type GetStockLevelsByWarehouseRow struct {
-	WarehouseID   int32       `json:"warehouse_id"`
-	WarehouseName string      `json:"warehouse_name"`
-	MaterialID    int32       `json:"material_id"`
-	MaterialName  string      `json:"material_name"`
-	MaterialCode  string      `json:"material_code"`
-	TotalQuantity interface{} `json:"total_quantity"`
-	BatchCount    int64       `json:"batch_count"`
+	WarehouseID       int32          `json:"warehouse_id"`
+	WarehouseName     string         `json:"warehouse_name"`
+	MaterialID        int32          `json:"material_id"`
+	MaterialName      string         `json:"material_name"`
+	MaterialCode      string         `json:"material_code"`
+	TotalQuantity     interface{}    `json:"total_quantity"`
+	BatchCount        int64          `json:"batch_count"`
+	InTransitQuantity pgtype.Numeric `json:"in_transit_quantity"`
 }
 
 func (q *Queries) GetStockLevelsByWarehouse(ctx context.Context) ([]GetStockLevelsByWarehouseRow, error) {
@@ -652,6 +929,66 @@ func (q *Queries) GetStockLevelsByWarehouse(ctx context.Context) ([]GetStockLeve
 			&i.MaterialCode,
 			&i.TotalQuantity,
 			&i.BatchCount,
+			&i.InTransitQuantity,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getStockMovementBatches = `-- name: GetStockMovementBatches :many
+SELECT
+    smb.id,
+    smb.movement_id,
+    smb.batch_id,
+    smb.quantity,
+    smb.unit_cost,
+    smb.created_at,
+    b.batch_number,
+    b.warehouse_id,
+    b.expiry_date
+FROM stock_movement_batches smb
+JOIN batches b ON smb.batch_id = b.id
+WHERE smb.movement_id = $1
+ORDER BY smb.id
+`
+
+type GetStockMovementBatchesRow struct {
+	ID          int32              `json:"id"`
+	MovementID  int32              `json:"movement_id"`
+	BatchID     int32              `json:"batch_id"`
+	Quantity    pgtype.Numeric     `json:"quantity"`
+	UnitCost    pgtype.Numeric     `json:"unit_cost"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	BatchNumber string             `json:"batch_number"`
+	WarehouseID pgtype.Int4        `json:"warehouse_id"`
+	ExpiryDate  pgtype.Date        `json:"expiry_date"`
+}
+
+func (q *Queries) GetStockMovementBatches(ctx context.Context, movementID int32) ([]GetStockMovementBatchesRow, error) {
+	rows, err := q.db.Query(ctx, getStockMovementBatches, movementID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []GetStockMovementBatchesRow{}
+	for rows.Next() {
+		var i GetStockMovementBatchesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.MovementID,
+			&i.BatchID,
+			&i.Quantity,
+			&i.UnitCost,
+			&i.CreatedAt,
+			&i.BatchNumber,
+			&i.WarehouseID,
+			&i.ExpiryDate,
 		); err != nil {
 			return nil, err
 		}
@@ -666,7 +1003,8 @@ func (q *Queries) GetStockLevelsByWarehouse(ctx context.Context) ([]GetStockLeve
 const getStockMovementByID = `-- name: GetStockMovementByID :one
 SELECT id, material_id, from_warehouse_id, to_warehouse_id,
     quantity, stock_direction, movement_type,
-    reference, performed_by, movement_date, notes, created_at, updated_at
+    reference, performed_by, movement_date, notes, created_at, updated_at,
+    unit_cost, total_cost, sales_order_item_id, purchase_order_item_id, reverses_movement_id, reversed_by_movement_id, from_bin_id, to_bin_id, entered_quantity, entered_unit_id, work_order_id, transfer_shipment_id, ncr_id, paired_movement_id
 FROM stock_movements
 WHERE id = $1
 `
@@ -688,6 +1026,65 @@ func (q *Queries) GetStockMovementByID(ctx context.Context, id int32) (StockMove
 		&i.Notes,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.UnitCost,
+		&i.TotalCost,
+		&i.SalesOrderItemID,
+		&i.PurchaseOrderItemID,
+		&i.ReversesMovementID,
+		&i.ReversedByMovementID,
+		&i.FromBinID,
+		&i.ToBinID,
+		&i.EnteredQuantity,
+		&i.EnteredUnitID,
+		&i.WorkOrderID,
+		&i.TransferShipmentID,
+		&i.NcrID,
+		&i.PairedMovementID,
+	)
+	return i, err
+}
+
+const getStockMovementByIDForUpdate = `-- name: GetStockMovementByIDForUpdate :one
+SELECT id, material_id, from_warehouse_id, to_warehouse_id,
+    quantity, stock_direction, movement_type,
+    reference, performed_by, movement_date, notes, created_at, updated_at,
+    unit_cost, total_cost, sales_order_item_id, purchase_order_item_id, reverses_movement_id, reversed_by_movement_id, from_bin_id, to_bin_id, entered_quantity, entered_unit_id, work_order_id, transfer_shipment_id, ncr_id, paired_movement_id
+FROM stock_movements
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetStockMovementByIDForUpdate(ctx context.Context, id int32) (StockMovement, error) {
+	row := q.db.QueryRow(ctx, getStockMovementByIDForUpdate, id)
+	var i StockMovement
+	err := row.Scan(
+		&i.ID,
+		&i.MaterialID,
+		&i.FromWarehouseID,
+		&i.ToWarehouseID,
+		&i.Quantity,
+		&i.StockDirection,
+		&i.MovementType,
+		&i.Reference,
+		&i.PerformedBy,
+		&i.MovementDate,
+		&i.Notes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UnitCost,
+		&i.TotalCost,
+		&i.SalesOrderItemID,
+		&i.PurchaseOrderItemID,
+		&i.ReversesMovementID,
+		&i.ReversedByMovementID,
+		&i.FromBinID,
+		&i.ToBinID,
+		&i.EnteredQuantity,
+		&i.EnteredUnitID,
+		&i.WorkOrderID,
+		&i.TransferShipmentID,
+		&i.NcrID,
+		&i.PairedMovementID,
 	)
 	return i, err
 }
@@ -696,7 +1093,7 @@ const getStockMovementHistory = `-- name: GetStockMovementHistory :many
 SELECT sm.id, sm.material_id, sm.from_warehouse_id, sm.to_warehouse_id,
     sm.quantity, sm.stock_direction, sm.movement_type,
     sm.reference, sm.performed_by, sm.movement_date, sm.notes,
-    sm.created_at, sm.updated_at,
+    sm.created_at, sm.updated_at, sm.unit_cost, sm.total_cost, sm.sales_order_item_id, sm.purchase_order_item_id, sm.reverses_movement_id, sm.reversed_by_movement_id, sm.from_bin_id, sm.to_bin_id, sm.entered_quantity, sm.entered_unit_id, sm.work_order_id, sm.transfer_shipment_id, sm.ncr_id, sm.paired_movement_id,
     m.name as material_name,
     u.username as performed_by_username
 FROM stock_movements sm
@@ -714,21 +1111,35 @@ type GetStockMovementHistoryParams struct {
 }
 
 type GetStockMovementHistoryRow struct {
-	ID                  int32              `json:"id"`
-	MaterialID          pgtype.Int4        `json:"material_id"`
-	FromWarehouseID     pgtype.Int4        `json:"from_warehouse_id"`
-	ToWarehouseID       pgtype.Int4        `json:"to_warehouse_id"`
-	Quantity            pgtype.Numeric     `json:"quantity"`
-	StockDirection      StockDirection     `json:"stock_direction"`
-	MovementType        StockMovementType  `json:"movement_type"`
-	Reference           pgtype.Text        `json:"reference"`
-	PerformedBy         pgtype.Int4        `json:"performed_by"`
-	MovementDate        pgtype.Timestamptz `json:"movement_date"`
-	Notes               pgtype.Text        `json:"notes"`
-	CreatedAt           pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt           pgtype.Timestamptz `json:"updated_at"`
-	MaterialName        pgtype.Text        `json:"material_name"`
-	PerformedByUsername pgtype.Text        `json:"performed_by_username"`
+	ID                   int32              `json:"id"`
+	MaterialID           pgtype.Int4        `json:"material_id"`
+	FromWarehouseID      pgtype.Int4        `json:"from_warehouse_id"`
+	ToWarehouseID        pgtype.Int4        `json:"to_warehouse_id"`
+	Quantity             pgtype.Numeric     `json:"quantity"`
+	StockDirection       StockDirection     `json:"stock_direction"`
+	MovementType         StockMovementType  `json:"movement_type"`
+	Reference            pgtype.Text        `json:"reference"`
+	PerformedBy          pgtype.Int4        `json:"performed_by"`
+	MovementDate         pgtype.Timestamptz `json:"movement_date"`
+	Notes                pgtype.Text        `json:"notes"`
+	CreatedAt            pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt            pgtype.Timestamptz `json:"updated_at"`
+	UnitCost             pgtype.Numeric     `json:"unit_cost"`
+	TotalCost            pgtype.Numeric     `json:"total_cost"`
+	SalesOrderItemID     pgtype.Int4        `json:"sales_order_item_id"`
+	PurchaseOrderItemID  pgtype.Int4        `json:"purchase_order_item_id"`
+	ReversesMovementID   pgtype.Int4        `json:"reverses_movement_id"`
+	ReversedByMovementID pgtype.Int4        `json:"reversed_by_movement_id"`
+	FromBinID            pgtype.Int4        `json:"from_bin_id"`
+	ToBinID              pgtype.Int4        `json:"to_bin_id"`
+	EnteredQuantity      pgtype.Numeric     `json:"entered_quantity"`
+	EnteredUnitID        pgtype.Int4        `json:"entered_unit_id"`
+	WorkOrderID          pgtype.Int4        `json:"work_order_id"`
+	TransferShipmentID   pgtype.Int4        `json:"transfer_shipment_id"`
+	NcrID                pgtype.Int4        `json:"ncr_id"`
+	PairedMovementID     pgtype.Int4        `json:"paired_movement_id"`
+	MaterialName         pgtype.Text        `json:"material_name"`
+	PerformedByUsername  pgtype.Text        `json:"performed_by_username"`
 }
 
 func (q *Queries) GetStockMovementHistory(ctx context.Context, arg GetStockMovementHistoryParams) ([]GetStockMovementHistoryRow, error) {
@@ -754,6 +1165,20 @@ func (q *Queries) GetStockMovementHistory(ctx context.Context, arg GetStockMovem
 			&i.Notes,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.UnitCost,
+			&i.TotalCost,
+			&i.SalesOrderItemID,
+			&i.PurchaseOrderItemID,
+			&i.ReversesMovementID,
+			&i.ReversedByMovementID,
+			&i.FromBinID,
+			&i.ToBinID,
+			&i.EnteredQuantity,
+			&i.EnteredUnitID,
+			&i.WorkOrderID,
+			&i.TransferShipmentID,
+			&i.NcrID,
+			&i.PairedMovementID,
 			&i.MaterialName,
 			&i.PerformedByUsername,
 		); err != nil {
@@ -770,7 +1195,8 @@ func (q *Queries) GetStockMovementHistory(ctx context.Context, arg GetStockMovem
 const getStockMovementsByReference = `-- name: GetStockMovementsByReference :many
 SELECT id, material_id, from_warehouse_id, to_warehouse_id,
     quantity, stock_direction, movement_type,
-    reference, performed_by, movement_date, notes, created_at, updated_at
+    reference, performed_by, movement_date, notes, created_at, updated_at,
+    unit_cost, total_cost, sales_order_item_id, purchase_order_item_id, reverses_movement_id, reversed_by_movement_id, from_bin_id, to_bin_id, entered_quantity, entered_unit_id, work_order_id, transfer_shipment_id, ncr_id, paired_movement_id
 FROM stock_movements
 WHERE reference = $1
 ORDER BY movement_date DESC
@@ -799,6 +1225,20 @@ func (q *Queries) GetStockMovementsByReference(ctx context.Context, reference pg
 			&i.Notes,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.UnitCost,
+			&i.TotalCost,
+			&i.SalesOrderItemID,
+			&i.PurchaseOrderItemID,
+			&i.ReversesMovementID,
+			&i.ReversedByMovementID,
+			&i.FromBinID,
+			&i.ToBinID,
+			&i.EnteredQuantity,
+			&i.EnteredUnitID,
+			&i.WorkOrderID,
+			&i.TransferShipmentID,
+			&i.NcrID,
+			&i.PairedMovementID,
 		); err != nil {
 			return nil, err
 		}
@@ -814,7 +1254,7 @@ const getTransferOutMovementDetails = `-- name: GetTransferOutMovementDetails :o
 SELECT sm.id, sm.material_id, sm.from_warehouse_id, sm.to_warehouse_id,
     sm.quantity, sm.stock_direction, sm.movement_type,
     sm.reference, sm.performed_by, sm.movement_date, sm.notes,
-    sm.created_at, sm.updated_at
+    sm.created_at, sm.updated_at, sm.unit_cost, sm.total_cost, sm.sales_order_item_id, sm.purchase_order_item_id, sm.reverses_movement_id, sm.reversed_by_movement_id, sm.from_bin_id, sm.to_bin_id, sm.entered_quantity, sm.entered_unit_id, sm.work_order_id, sm.transfer_shipment_id, sm.ncr_id, sm.paired_movement_id
 FROM stock_movements sm
 WHERE sm.id = $1
   AND sm.movement_type = 'TRANSFER_OUT'
@@ -837,6 +1277,20 @@ func (q *Queries) GetTransferOutMovementDetails(ctx context.Context, id int32) (
 		&i.Notes,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.UnitCost,
+		&i.TotalCost,
+		&i.SalesOrderItemID,
+		&i.PurchaseOrderItemID,
+		&i.ReversesMovementID,
+		&i.ReversedByMovementID,
+		&i.FromBinID,
+		&i.ToBinID,
+		&i.EnteredQuantity,
+		&i.EnteredUnitID,
+		&i.WorkOrderID,
+		&i.TransferShipmentID,
+		&i.NcrID,
+		&i.PairedMovementID,
 	)
 	return i, err
 }
@@ -845,7 +1299,7 @@ const getWarehouseStockMovements = `-- name: GetWarehouseStockMovements :many
 SELECT sm.id, sm.material_id, sm.from_warehouse_id, sm.to_warehouse_id,
     sm.quantity, sm.stock_direction, sm.movement_type,
     sm.reference, sm.performed_by, sm.movement_date, sm.notes,
-    sm.created_at, sm.updated_at,
+    sm.created_at, sm.updated_at, sm.unit_cost, sm.total_cost, sm.sales_order_item_id, sm.purchase_order_item_id, sm.reverses_movement_id, sm.reversed_by_movement_id, sm.from_bin_id, sm.to_bin_id, sm.entered_quantity, sm.entered_unit_id, sm.work_order_id, sm.transfer_shipment_id, sm.ncr_id, sm.paired_movement_id,
     m.name as material_name,
     u.username as performed_by_username
 FROM stock_movements sm
@@ -863,21 +1317,35 @@ type GetWarehouseStockMovementsParams struct {
 }
 
 type GetWarehouseStockMovementsRow struct {
-	ID                  int32              `json:"id"`
-	MaterialID          pgtype.Int4        `json:"material_id"`
-	FromWarehouseID     pgtype.Int4        `json:"from_warehouse_id"`
-	ToWarehouseID       pgtype.Int4        `json:"to_warehouse_id"`
-	Quantity            pgtype.Numeric     `json:"quantity"`
-	StockDirection      StockDirection     `json:"stock_direction"`
-	MovementType        StockMovementType  `json:"movement_type"`
-	Reference           pgtype.Text        `json:"reference"`
-	PerformedBy         pgtype.Int4        `json:"performed_by"`
-	MovementDate        pgtype.Timestamptz `json:"movement_date"`
-	Notes               pgtype.Text        `json:"notes"`
-	CreatedAt           pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt           pgtype.Timestamptz `json:"updated_at"`
-	MaterialName        pgtype.Text        `json:"material_name"`
-	PerformedByUsername pgtype.Text        `json:"performed_by_username"`
+	ID                   int32              `json:"id"`
+	MaterialID           pgtype.Int4        `json:"material_id"`
+	FromWarehouseID      pgtype.Int4        `json:"from_warehouse_id"`
+	ToWarehouseID        pgtype.Int4        `json:"to_warehouse_id"`
+	Quantity             pgtype.Numeric     `json:"quantity"`
+	StockDirection       StockDirection     `json:"stock_direction"`
+	MovementType         StockMovementType  `json:"movement_type"`
+	Reference            pgtype.Text        `json:"reference"`
+	PerformedBy          pgtype.Int4        `json:"performed_by"`
+	MovementDate         pgtype.Timestamptz `json:"movement_date"`
+	Notes                pgtype.Text        `json:"notes"`
+	CreatedAt            pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt            pgtype.Timestamptz `json:"updated_at"`
+	UnitCost             pgtype.Numeric     `json:"unit_cost"`
+	TotalCost            pgtype.Numeric     `json:"total_cost"`
+	SalesOrderItemID     pgtype.Int4        `json:"sales_order_item_id"`
+	PurchaseOrderItemID  pgtype.Int4        `json:"purchase_order_item_id"`
+	ReversesMovementID   pgtype.Int4        `json:"reverses_movement_id"`
+	ReversedByMovementID pgtype.Int4        `json:"reversed_by_movement_id"`
+	FromBinID            pgtype.Int4        `json:"from_bin_id"`
+	ToBinID              pgtype.Int4        `json:"to_bin_id"`
+	EnteredQuantity      pgtype.Numeric     `json:"entered_quantity"`
+	EnteredUnitID        pgtype.Int4        `json:"entered_unit_id"`
+	WorkOrderID          pgtype.Int4        `json:"work_order_id"`
+	TransferShipmentID   pgtype.Int4        `json:"transfer_shipment_id"`
+	NcrID                pgtype.Int4        `json:"ncr_id"`
+	PairedMovementID     pgtype.Int4        `json:"paired_movement_id"`
+	MaterialName         pgtype.Text        `json:"material_name"`
+	PerformedByUsername  pgtype.Text        `json:"performed_by_username"`
 }
 
 func (q *Queries) GetWarehouseStockMovements(ctx context.Context, arg GetWarehouseStockMovementsParams) ([]GetWarehouseStockMovementsRow, error) {
@@ -903,6 +1371,20 @@ func (q *Queries) GetWarehouseStockMovements(ctx context.Context, arg GetWarehou
 			&i.Notes,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.UnitCost,
+			&i.TotalCost,
+			&i.SalesOrderItemID,
+			&i.PurchaseOrderItemID,
+			&i.ReversesMovementID,
+			&i.ReversedByMovementID,
+			&i.FromBinID,
+			&i.ToBinID,
+			&i.EnteredQuantity,
+			&i.EnteredUnitID,
+			&i.WorkOrderID,
+			&i.TransferShipmentID,
+			&i.NcrID,
+			&i.PairedMovementID,
 			&i.MaterialName,
 			&i.PerformedByUsername,
 		); err != nil {
@@ -916,6 +1398,42 @@ func (q *Queries) GetWarehouseStockMovements(ctx context.Context, arg GetWarehou
 	return items, nil
 }
 
+const markStockMovementReversed = `-- name: MarkStockMovementReversed :exec
+UPDATE stock_movements
+SET reversed_by_movement_id = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+
+type MarkStockMovementReversedParams struct {
+	ID                   int32       `json:"id"`
+	ReversedByMovementID pgtype.Int4 `json:"reversed_by_movement_id"`
+}
+
+func (q *Queries) MarkStockMovementReversed(ctx context.Context, arg MarkStockMovementReversedParams) error {
+	_, err := q.db.Exec(ctx, markStockMovementReversed, arg.ID, arg.ReversedByMovementID)
+	return err
+}
+
+const pairStockMovement = `-- name: PairStockMovement :exec
+
+UPDATE stock_movements
+SET paired_movement_id = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+
+type PairStockMovementParams struct {
+	ID               int32       `json:"id"`
+	PairedMovementID pgtype.Int4 `json:"paired_movement_id"`
+}
+
+// Links the two legs of a one-step transfer
+func (q *Queries) PairStockMovement(ctx context.Context, arg PairStockMovementParams) error {
+	_, err := q.db.Exec(ctx, pairStockMovement, arg.ID, arg.PairedMovementID)
+	return err
+}
+
 const updateBatchQuantity = `-- name: UpdateBatchQuantity :one
 UPDATE batches
 SET current_quantity = current_quantity + $2,
@@ -923,7 +1441,7 @@ SET current_quantity = current_quantity + $2,
 WHERE id = $1
 RETURNING id, material_id, supplier_id, warehouse_id, movement_id,
     unit_price, batch_number, manufacture_date, expiry_date,
-    start_quantity, current_quantity, notes, meta, created_at, updated_at
+    start_quantity, current_quantity, notes, meta, created_at, updated_at, bin_id
 `
 
 type UpdateBatchQuantityParams struct {
@@ -950,6 +1468,7 @@ func (q *Queries) UpdateBatchQuantity(ctx context.Context, arg UpdateBatchQuanti
 		&i.Meta,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.BinID,
 	)
 	return i, err
 }
@@ -12,20 +12,21 @@ import (
 )
 
 const createWarehouse = `-- name: CreateWarehouse :one
-INSERT INTO warehouses (name, code, location, description, valuation, parent_warehouse, capacity, meta)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-RETURNING id, name, code, location, description, valuation, parent_warehouse, capacity, meta, created_at, updated_at
+INSERT INTO warehouses (name, code, location, description, valuation, parent_warehouse, capacity, meta, allocation_strategy)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, name, code, location, description, valuation, parent_warehouse, capacity, meta, created_at, updated_at, allocation_strategy
 `
 
 type CreateWarehouseParams struct {
-	Name            string          `json:"name"`
-	Code            string          `json:"code"`
-	Location        pgtype.Text     `json:"location"`
-	Description     pgtype.Text     `json:"description"`
-	Valuation       ValuationMethod `json:"valuation"`
-	ParentWarehouse pgtype.Int4     `json:"parent_warehouse"`
-	Capacity        pgtype.Numeric  `json:"capacity"`
-	Meta            []byte          `json:"meta"`
+	Name               string             `json:"name"`
+	Code               string             `json:"code"`
+	Location           pgtype.Text        `json:"location"`
+	Description        pgtype.Text        `json:"description"`
+	Valuation          ValuationMethod    `json:"valuation"`
+	ParentWarehouse    pgtype.Int4        `json:"parent_warehouse"`
+	Capacity           pgtype.Numeric     `json:"capacity"`
+	Meta               []byte             `json:"meta"`
+	AllocationStrategy AllocationStrategy `json:"allocation_strategy"`
 }
 
 func (q *Queries) CreateWarehouse(ctx context.Context, arg CreateWarehouseParams) (Warehouse, error) {
@@ -38,6 +39,7 @@ func (q *Queries) CreateWarehouse(ctx context.Context, arg CreateWarehouseParams
 		arg.ParentWarehouse,
 		arg.Capacity,
 		arg.Meta,
+		arg.AllocationStrategy,
 	)
 	var i Warehouse
 	err := row.Scan(
@@ -52,6 +54,7 @@ func (q *Queries) CreateWarehouse(ctx context.Context, arg CreateWarehouseParams
 		&i.Meta,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.AllocationStrategy,
 	)
 	return i, err
 }
@@ -67,7 +70,7 @@ func (q *Queries) DeleteWarehouse(ctx context.Context, id int32) error {
 }
 
 const getWarehouseByCode = `-- name: GetWarehouseByCode :one
-SELECT id, name, code, location, description, valuation, parent_warehouse, capacity, meta, created_at, updated_at
+SELECT id, name, code, location, description, valuation, parent_warehouse, capacity, meta, created_at, updated_at, allocation_strategy
 FROM warehouses
 WHERE code = $1
 `
@@ -87,12 +90,13 @@ func (q *Queries) GetWarehouseByCode(ctx context.Context, code string) (Warehous
 		&i.Meta,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.AllocationStrategy,
 	)
 	return i, err
 }
 
 const getWarehouseByID = `-- name: GetWarehouseByID :one
-SELECT id, name, code, location, description, valuation, parent_warehouse, capacity, meta, created_at, updated_at
+SELECT id, name, code, location, description, valuation, parent_warehouse, capacity, meta, created_at, updated_at, allocation_strategy
 FROM warehouses
 WHERE id = $1
 `
@@ -112,12 +116,13 @@ func (q *Queries) GetWarehouseByID(ctx context.Context, id int32) (Warehouse, er
 		&i.Meta,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.AllocationStrategy,
 	)
 	return i, err
 }
 
 const getWarehouseByName = `-- name: GetWarehouseByName :one
-SELECT id, name, code, location, description, valuation, parent_warehouse, capacity, meta, created_at, updated_at
+SELECT id, name, code, location, description, valuation, parent_warehouse, capacity, meta, created_at, updated_at, allocation_strategy
 FROM warehouses
 WHERE name = $1
 `
@@ -137,12 +142,13 @@ func (q *Queries) GetWarehouseByName(ctx context.Context, name string) (Warehous
 		&i.Meta,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.AllocationStrategy,
 	)
 	return i, err
 }
 
 const listWarehouses = `-- name: ListWarehouses :many
-SELECT id, name, code, location, description, valuation, parent_warehouse, capacity, meta, created_at, updated_at
+SELECT id, name, code, location, description, valuation, parent_warehouse, capacity, meta, created_at, updated_at, allocation_strategy
 FROM warehouses
 ORDER BY created_at DESC
 LIMIT $1 OFFSET $2
@@ -174,6 +180,7 @@ func (q *Queries) ListWarehouses(ctx context.Context, arg ListWarehousesParams)
 			&i.Meta,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.AllocationStrategy,
 		); err != nil {
 			return nil, err
 		}
@@ -196,21 +203,23 @@ SET
     parent_warehouse = COALESCE($7, parent_warehouse),
     capacity = COALESCE($8, capacity),
     meta = COALESCE($9, meta),
+    allocation_strategy = COALESCE($10, allocation_strategy),
     updated_at = CURRENT_TIMESTAMP
 WHERE id = $1
-RETURNING id, name, code, location, description, valuation, parent_warehouse, capacity, meta, created_at, updated_at
+RETURNING id, name, code, location, description, valuation, parent_warehouse, capacity, meta, created_at, updated_at, allocation_strategy
 `
 
 type UpdateWarehouseParams struct {
-	ID              int32           `json:"id"`
-	Column2         interface{}     `json:"column_2"`
-	Column3         interface{}     `json:"column_3"`
-	Location        pgtype.Text     `json:"location"`
-	Description     pgtype.Text     `json:"description"`
-	Valuation       ValuationMethod `json:"valuation"`
-	ParentWarehouse pgtype.Int4     `json:"parent_warehouse"`
-	Capacity        pgtype.Numeric  `json:"capacity"`
-	Meta            []byte          `json:"meta"`
+	ID                 int32              `json:"id"`
+	Column2            interface{}        `json:"column_2"`
+	Column3            interface{}        `json:"column_3"`
+	Location           pgtype.Text        `json:"location"`
+	Description        pgtype.Text        `json:"description"`
+	Valuation          ValuationMethod    `json:"valuation"`
+	ParentWarehouse    pgtype.Int4        `json:"parent_warehouse"`
+	Capacity           pgtype.Numeric     `json:"capacity"`
+	Meta               []byte             `json:"meta"`
+	AllocationStrategy AllocationStrategy `json:"allocation_strategy"`
 }
 
 func (q *Queries) UpdateWarehouse(ctx context.Context, arg UpdateWarehouseParams) (Warehouse, error) {
@@ -224,6 +233,7 @@ func (q *Queries) UpdateWarehouse(ctx context.Context, arg UpdateWarehouseParams
 		arg.ParentWarehouse,
 		arg.Capacity,
 		arg.Meta,
+		arg.AllocationStrategy,
 	)
 	var i Warehouse
 	err := row.Scan(
@@ -238,6 +248,7 @@ func (q *Queries) UpdateWarehouse(ctx context.Context, arg UpdateWarehouseParams
 		&i.Meta,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.AllocationStrategy,
 	)
 	return i, err
 }
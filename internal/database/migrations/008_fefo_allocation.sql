@@ -0,0 +1,26 @@
+-- Migration 008: FEFO (first-expired-first-out) batch allocation
+-- The order outgoing stock is drawn from batches is an allocation strategy,
+-- kept apart from the valuation method that decides what the stock costs.
+-- Every warehouse has one and a material may override it, the same way as
+-- the valuation method. FEFO draws from the batches that expire first and
+-- skips expired batches. Existing settings keep allocating the way they
+-- did: LIFO from the newest batch, FIFO and Weighted Average from the oldest.
+
+CREATE TYPE ALLOCATION_STRATEGY AS ENUM ('FIFO', 'LIFO', 'FEFO');
+
+ALTER TABLE warehouses
+ADD COLUMN IF NOT EXISTS allocation_strategy ALLOCATION_STRATEGY NOT NULL DEFAULT 'FIFO';
+
+ALTER TABLE materials
+ADD COLUMN IF NOT EXISTS allocation_strategy ALLOCATION_STRATEGY;
+
+UPDATE warehouses SET allocation_strategy = 'LIFO' WHERE valuation = 'LIFO';
+UPDATE materials SET allocation_strategy = 'LIFO' WHERE valuation = 'LIFO';
+
+-- Speed up expiry ordered batch lookups
+CREATE INDEX IF NOT EXISTS idx_batches_fefo
+ON batches(warehouse_id, material_id, expiry_date)
+WHERE current_quantity > 0;
+
+COMMENT ON COLUMN warehouses.allocation_strategy IS 'Order batches are drawn from when stock leaves the warehouse: FIFO, LIFO or FEFO';
+COMMENT ON COLUMN materials.allocation_strategy IS 'Allocation strategy of the material, NULL follows the warehouse';
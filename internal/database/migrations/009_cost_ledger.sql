@@ -0,0 +1,70 @@
+-- Migration 009: Moving weighted-average costing ledger
+-- Keeps a running average unit cost per material/warehouse and stamps the
+-- cost of every movement so COGS can be read per stock_movements row.
+
+-- ============================================================================
+-- MOVEMENT COST STAMP
+-- ============================================================================
+
+ALTER TABLE stock_movements
+ADD COLUMN IF NOT EXISTS unit_cost DECIMAL(15, 4),
+ADD COLUMN IF NOT EXISTS total_cost DECIMAL(15, 4);
+
+-- ============================================================================
+-- RUNNING AVERAGE COST PER MATERIAL/WAREHOUSE
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS material_average_costs (
+    material_id INT NOT NULL REFERENCES materials(id) ON DELETE CASCADE,
+    warehouse_id INT NOT NULL REFERENCES warehouses(id) ON DELETE CASCADE,
+    on_hand_quantity DECIMAL(15, 4) NOT NULL DEFAULT 0,
+    total_value DECIMAL(15, 4) NOT NULL DEFAULT 0,
+    average_unit_cost DECIMAL(15, 4) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (material_id, warehouse_id)
+);
+
+CREATE TRIGGER trg_update_material_average_costs_updated_at
+BEFORE UPDATE ON material_average_costs
+FOR EACH ROW
+EXECUTE PROCEDURE update_updated_at_column();
+
+-- ============================================================================
+-- COST LEDGER (one entry per movement and warehouse)
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS material_cost_ledger (
+    id SERIAL PRIMARY KEY,
+    material_id INT NOT NULL REFERENCES materials(id) ON DELETE CASCADE,
+    warehouse_id INT NOT NULL REFERENCES warehouses(id) ON DELETE CASCADE,
+    movement_id INT REFERENCES stock_movements(id) ON DELETE SET NULL,
+    quantity_change DECIMAL(15, 4) NOT NULL,      -- positive for IN, negative for OUT
+    unit_cost DECIMAL(15, 4) NOT NULL,            -- cost per unit of this movement
+    value_change DECIMAL(15, 4) NOT NULL,         -- quantity_change * unit_cost
+    on_hand_quantity DECIMAL(15, 4) NOT NULL,     -- balance after the movement
+    total_value DECIMAL(15, 4) NOT NULL,          -- inventory value after the movement
+    average_unit_cost DECIMAL(15, 4) NOT NULL,    -- running average after the movement
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_cost_ledger_material_warehouse ON material_cost_ledger(material_id, warehouse_id);
+CREATE INDEX IF NOT EXISTS idx_cost_ledger_movement ON material_cost_ledger(movement_id);
+
+-- Seed running averages from the stock that exists before the ledger
+INSERT INTO material_average_costs (material_id, warehouse_id, on_hand_quantity, total_value, average_unit_cost)
+SELECT
+    b.material_id,
+    b.warehouse_id,
+    SUM(b.current_quantity),
+    SUM(b.current_quantity * COALESCE(b.unit_price, 0)),
+    SUM(b.current_quantity * COALESCE(b.unit_price, 0)) / SUM(b.current_quantity)
+FROM batches b
+WHERE b.current_quantity > 0
+  AND b.material_id IS NOT NULL
+  AND b.warehouse_id IS NOT NULL
+GROUP BY b.material_id, b.warehouse_id
+ON CONFLICT (material_id, warehouse_id) DO NOTHING;
+
+COMMENT ON TABLE material_average_costs IS 'Running weighted-average unit cost per material and warehouse';
+COMMENT ON TABLE material_cost_ledger IS 'Cost history per movement, basis for COGS and inventory valuation';
@@ -0,0 +1,28 @@
+-- Migration 010: Per-movement batch line items
+-- Records which batches every stock movement drew from (OUT) or created (IN),
+-- so movements can be audited and reversed exactly.
+
+CREATE TABLE IF NOT EXISTS stock_movement_batches (
+    id SERIAL PRIMARY KEY,
+    movement_id INT NOT NULL REFERENCES stock_movements(id) ON DELETE CASCADE,
+    batch_id INT NOT NULL REFERENCES batches(id) ON DELETE RESTRICT,
+    quantity DECIMAL(15, 4) NOT NULL CHECK (quantity > 0),
+    unit_cost DECIMAL(15, 4) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_movement_batches_movement_id ON stock_movement_batches(movement_id);
+CREATE INDEX IF NOT EXISTS idx_stock_movement_batches_batch_id ON stock_movement_batches(batch_id);
+
+-- Backfill lines for movements that created a batch
+INSERT INTO stock_movement_batches (movement_id, batch_id, quantity, unit_cost)
+SELECT b.movement_id, b.id, b.start_quantity, COALESCE(b.unit_price, 0)
+FROM batches b
+WHERE b.movement_id IS NOT NULL
+  AND b.start_quantity > 0
+  AND NOT EXISTS (
+      SELECT 1 FROM stock_movement_batches smb
+      WHERE smb.movement_id = b.movement_id AND smb.batch_id = b.id
+  );
+
+COMMENT ON TABLE stock_movement_batches IS 'Batch line items of a stock movement: batches consumed by OUT movements and created by IN movements';
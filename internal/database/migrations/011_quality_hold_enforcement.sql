@@ -0,0 +1,29 @@
+-- Migration 011: Enforce quality holds in stock-out operations
+-- Resolves active quality holds (material, warehouse, batch_number) to the
+-- batches they cover so allocation can skip them.
+-- A hold without warehouse covers every warehouse, a hold without
+-- batch_number covers every batch of the material.
+
+CREATE INDEX IF NOT EXISTS idx_quality_holds_active
+ON quality_holds(material_id, batch_number)
+WHERE is_released = FALSE;
+
+CREATE OR REPLACE VIEW v_batches_on_hold AS
+SELECT DISTINCT ON (b.id)
+    b.id AS batch_id,
+    b.material_id,
+    b.warehouse_id,
+    b.batch_number,
+    b.current_quantity AS held_quantity,
+    qh.id AS hold_id,
+    qh.hold_number,
+    qh.quality_status,
+    qh.hold_reason
+FROM batches b
+JOIN quality_holds qh ON qh.material_id = b.material_id
+    AND (qh.warehouse_id IS NULL OR qh.warehouse_id = b.warehouse_id)
+    AND (qh.batch_number IS NULL OR qh.batch_number = b.batch_number)
+WHERE qh.is_released = FALSE
+ORDER BY b.id, qh.placed_date ASC;
+
+COMMENT ON VIEW v_batches_on_hold IS 'Batches covered by an active quality hold, excluded from stock-out allocation';
@@ -0,0 +1,12 @@
+-- Migration 012: Sales order line fulfilment
+-- Links SALE movements to the sales order line they ship so shipped
+-- quantities and order status can be maintained.
+
+ALTER TABLE stock_movements
+ADD COLUMN IF NOT EXISTS sales_order_item_id INT REFERENCES sales_order_items(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_sales_order_item_id ON stock_movements(sales_order_item_id);
+
+UPDATE sales_order_items SET shipped_quantity = 0 WHERE shipped_quantity IS NULL;
+
+COMMENT ON COLUMN stock_movements.sales_order_item_id IS 'Sales order line shipped by a SALE movement';
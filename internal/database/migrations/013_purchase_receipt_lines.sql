@@ -0,0 +1,12 @@
+-- Migration 013: Purchase receipts against purchase order lines
+-- Links PURCHASE_RECEIPT movements to the purchase order line they receive
+-- so received quantities, PO status and receipt history can be maintained.
+
+ALTER TABLE stock_movements
+ADD COLUMN IF NOT EXISTS purchase_order_item_id INT REFERENCES purchase_order_items(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_purchase_order_item_id ON stock_movements(purchase_order_item_id);
+
+UPDATE purchase_order_items SET received_quantity = 0 WHERE received_quantity IS NULL;
+
+COMMENT ON COLUMN stock_movements.purchase_order_item_id IS 'Purchase order line received by a PURCHASE_RECEIPT movement';
@@ -0,0 +1,64 @@
+-- Migration 014: Stock reservations and available-to-promise
+-- Reserves stock of a warehouse for a sales order line, optionally pinned to
+-- a batch, so the same stock cannot be promised twice.
+-- available = on hand - on quality hold - reserved
+
+-- ============================================================================
+-- RESERVATIONS
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS stock_reservations (
+    id SERIAL PRIMARY KEY,
+    sales_order_item_id INT NOT NULL REFERENCES sales_order_items(id) ON DELETE CASCADE,
+    material_id INT NOT NULL REFERENCES materials(id) ON DELETE CASCADE,
+    warehouse_id INT NOT NULL REFERENCES warehouses(id) ON DELETE CASCADE,
+    batch_id INT REFERENCES batches(id) ON DELETE CASCADE,      -- NULL = any batch of the warehouse
+    quantity DECIMAL(15, 4) NOT NULL CHECK (quantity > 0),
+    fulfilled_quantity DECIMAL(15, 4) NOT NULL DEFAULT 0,       -- shipped against this reservation
+    status VARCHAR(20) NOT NULL DEFAULT 'Active'
+        CHECK (status IN ('Active', 'Fulfilled', 'Released')),
+    reserved_by INT REFERENCES users(id) ON DELETE SET NULL,
+    released_at TIMESTAMP WITH TIME ZONE,
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_sales_order_item_id ON stock_reservations(sales_order_item_id);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_active
+ON stock_reservations(material_id, warehouse_id)
+WHERE status = 'Active';
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_active_batch
+ON stock_reservations(batch_id)
+WHERE status = 'Active' AND batch_id IS NOT NULL;
+
+CREATE TRIGGER trg_update_stock_reservations_updated_at
+BEFORE UPDATE ON stock_reservations
+FOR EACH ROW
+EXECUTE PROCEDURE update_updated_at_column();
+
+-- ============================================================================
+-- OPEN RESERVED QUANTITIES
+-- ============================================================================
+
+CREATE OR REPLACE VIEW v_reserved_stock AS
+SELECT
+    material_id,
+    warehouse_id,
+    SUM(quantity - fulfilled_quantity)::DECIMAL(15, 4) AS reserved_quantity
+FROM stock_reservations
+WHERE status = 'Active'
+GROUP BY material_id, warehouse_id;
+
+CREATE OR REPLACE VIEW v_reserved_batches AS
+SELECT
+    batch_id,
+    SUM(quantity - fulfilled_quantity)::DECIMAL(15, 4) AS reserved_quantity
+FROM stock_reservations
+WHERE status = 'Active'
+  AND batch_id IS NOT NULL
+GROUP BY batch_id;
+
+COMMENT ON TABLE stock_reservations IS 'Stock promised to sales order lines, released on shipment or cancellation';
+COMMENT ON VIEW v_reserved_stock IS 'Open reserved quantity per material and warehouse';
+COMMENT ON VIEW v_reserved_batches IS 'Open reserved quantity pinned to a batch';
@@ -0,0 +1,18 @@
+-- Migration 015: Movement reversal
+-- A posted movement is corrected by a compensating REVERSAL movement of the
+-- opposite direction that restores the exact batch quantities. Both rows
+-- point at each other so the correction stays linked to the mistake.
+
+ALTER TYPE stock_movement_type ADD VALUE IF NOT EXISTS 'REVERSAL';
+
+ALTER TABLE stock_movements
+ADD COLUMN IF NOT EXISTS reverses_movement_id INT REFERENCES stock_movements(id) ON DELETE RESTRICT,
+ADD COLUMN IF NOT EXISTS reversed_by_movement_id INT REFERENCES stock_movements(id) ON DELETE RESTRICT;
+
+-- A movement can only be reversed once
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_movements_reverses_movement_id
+ON stock_movements(reverses_movement_id)
+WHERE reverses_movement_id IS NOT NULL;
+
+COMMENT ON COLUMN stock_movements.reverses_movement_id IS 'Movement compensated by this REVERSAL movement';
+COMMENT ON COLUMN stock_movements.reversed_by_movement_id IS 'REVERSAL movement that compensated this movement';
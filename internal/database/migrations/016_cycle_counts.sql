@@ -0,0 +1,113 @@
+-- Migration 016: Physical inventory / cycle count sessions
+-- A count session freezes the batch quantities of a warehouse (optionally one
+-- category or ABC class), collects counted quantities per batch and, once
+-- approved, posts the variances as ADJUSTMENT_IN / ADJUSTMENT_OUT movements.
+
+-- ============================================================================
+-- ABC CLASSIFICATION
+-- ============================================================================
+
+-- Materials ranked by the value they consumed over the last 12 months in a
+-- warehouse: A = first 80% of the value, B = next 15%, C = the rest and
+-- everything without consumption.
+CREATE OR REPLACE VIEW v_material_abc_class AS
+WITH stocked AS (
+    SELECT DISTINCT material_id, warehouse_id
+    FROM batches
+    WHERE material_id IS NOT NULL
+      AND warehouse_id IS NOT NULL
+      AND current_quantity > 0
+),
+consumption AS (
+    SELECT
+        material_id,
+        from_warehouse_id AS warehouse_id,
+        SUM(COALESCE(total_cost, 0)) AS usage_value
+    FROM stock_movements
+    WHERE stock_direction = 'OUT'
+      AND movement_type NOT IN ('TRANSFER_OUT', 'REVERSAL')
+      AND reversed_by_movement_id IS NULL
+      AND movement_date >= CURRENT_TIMESTAMP - INTERVAL '12 months'
+    GROUP BY material_id, from_warehouse_id
+),
+ranked AS (
+    SELECT
+        s.material_id,
+        s.warehouse_id,
+        COALESCE(c.usage_value, 0) AS usage_value,
+        SUM(COALESCE(c.usage_value, 0)) OVER (
+            PARTITION BY s.warehouse_id
+            ORDER BY COALESCE(c.usage_value, 0) DESC, s.material_id
+            ROWS UNBOUNDED PRECEDING
+        ) AS cumulative_value,
+        SUM(COALESCE(c.usage_value, 0)) OVER (PARTITION BY s.warehouse_id) AS warehouse_value
+    FROM stocked s
+    LEFT JOIN consumption c ON c.material_id = s.material_id AND c.warehouse_id = s.warehouse_id
+)
+SELECT
+    material_id,
+    warehouse_id,
+    usage_value::DECIMAL(15, 4) AS annual_usage_value,
+    (CASE
+        WHEN usage_value = 0 THEN 'C'
+        WHEN cumulative_value - usage_value < warehouse_value * 0.80 THEN 'A'
+        WHEN cumulative_value - usage_value < warehouse_value * 0.95 THEN 'B'
+        ELSE 'C'
+    END)::VARCHAR(1) AS abc_class
+FROM ranked;
+
+-- ============================================================================
+-- COUNT SESSIONS
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS count_sessions (
+    id SERIAL PRIMARY KEY,
+    session_number VARCHAR(100) NOT NULL UNIQUE,
+    warehouse_id INT NOT NULL REFERENCES warehouses(id) ON DELETE RESTRICT,
+    category_id INT REFERENCES material_categories(id) ON DELETE SET NULL,  -- scope, NULL = all categories
+    abc_class VARCHAR(1) CHECK (abc_class IN ('A', 'B', 'C')),             -- scope, NULL = all classes
+    status VARCHAR(20) NOT NULL DEFAULT 'Open'
+        CHECK (status IN ('Open', 'Approved', 'Cancelled')),
+    created_by INT REFERENCES users(id) ON DELETE SET NULL,
+    approved_by INT REFERENCES users(id) ON DELETE SET NULL,
+    approved_at TIMESTAMP WITH TIME ZONE,
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_count_sessions_warehouse_id ON count_sessions(warehouse_id);
+CREATE INDEX IF NOT EXISTS idx_count_sessions_status ON count_sessions(status);
+
+CREATE TRIGGER trg_update_count_sessions_updated_at
+BEFORE UPDATE ON count_sessions
+FOR EACH ROW
+EXECUTE PROCEDURE update_updated_at_column();
+
+-- One line per batch frozen at session start
+CREATE TABLE IF NOT EXISTS count_session_lines (
+    id SERIAL PRIMARY KEY,
+    session_id INT NOT NULL REFERENCES count_sessions(id) ON DELETE CASCADE,
+    material_id INT NOT NULL REFERENCES materials(id) ON DELETE RESTRICT,
+    batch_id INT NOT NULL REFERENCES batches(id) ON DELETE RESTRICT,
+    snapshot_quantity DECIMAL(15, 4) NOT NULL,     -- system quantity when the session started
+    counted_quantity DECIMAL(15, 4),               -- NULL until counted
+    counted_by INT REFERENCES users(id) ON DELETE SET NULL,
+    counted_at TIMESTAMP WITH TIME ZONE,
+    adjustment_movement_id INT REFERENCES stock_movements(id) ON DELETE SET NULL,
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (session_id, batch_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_count_session_lines_session_id ON count_session_lines(session_id);
+
+CREATE TRIGGER trg_update_count_session_lines_updated_at
+BEFORE UPDATE ON count_session_lines
+FOR EACH ROW
+EXECUTE PROCEDURE update_updated_at_column();
+
+COMMENT ON VIEW v_material_abc_class IS 'ABC class per material and warehouse from 12 months of consumption value';
+COMMENT ON TABLE count_sessions IS 'Physical inventory / cycle count sessions';
+COMMENT ON TABLE count_session_lines IS 'Frozen batch quantities and counted quantities of a count session';
@@ -0,0 +1,86 @@
+-- Migration 017: Bin / storage-location level inside warehouses
+-- Bins (shelves, bins, pallet positions, floor areas) sit under a warehouse
+-- and batches are stored per bin. A batch without a bin is stored at the
+-- warehouse level, so existing stock keeps working unchanged.
+
+-- ============================================================================
+-- BINS
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS warehouse_bins (
+    id SERIAL PRIMARY KEY,
+    warehouse_id INT NOT NULL REFERENCES warehouses(id) ON DELETE CASCADE,
+    code VARCHAR(100) NOT NULL,                     -- e.g. A-01-03, unique inside the warehouse
+    name VARCHAR(255),
+    bin_type VARCHAR(20) NOT NULL DEFAULT 'Bin'
+        CHECK (bin_type IN ('Shelf', 'Bin', 'Pallet', 'Floor')),
+    zone VARCHAR(100),                              -- aisle / area used to group bins
+    capacity DECIMAL(15, 4) CHECK (capacity IS NULL OR capacity > 0),  -- NULL = unlimited
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (warehouse_id, code)
+);
+
+CREATE INDEX IF NOT EXISTS idx_warehouse_bins_warehouse_id ON warehouse_bins(warehouse_id);
+
+CREATE TRIGGER trg_update_warehouse_bins_updated_at
+BEFORE UPDATE ON warehouse_bins
+FOR EACH ROW
+EXECUTE PROCEDURE update_updated_at_column();
+
+-- ============================================================================
+-- BATCHES PER BIN
+-- ============================================================================
+
+ALTER TABLE batches
+ADD COLUMN IF NOT EXISTS bin_id INT REFERENCES warehouse_bins(id) ON DELETE RESTRICT;
+
+CREATE INDEX IF NOT EXISTS idx_batches_bin_id ON batches(bin_id);
+
+ALTER TABLE stock_movements
+ADD COLUMN IF NOT EXISTS from_bin_id INT REFERENCES warehouse_bins(id) ON DELETE SET NULL,
+ADD COLUMN IF NOT EXISTS to_bin_id INT REFERENCES warehouse_bins(id) ON DELETE SET NULL;
+
+-- A batch can only be stored in a bin of its own warehouse
+CREATE OR REPLACE FUNCTION check_batch_bin_warehouse()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.bin_id IS NOT NULL AND NOT EXISTS (
+        SELECT 1 FROM warehouse_bins
+        WHERE id = NEW.bin_id AND warehouse_id = NEW.warehouse_id
+    ) THEN
+        RAISE EXCEPTION 'Bin % does not belong to warehouse %', NEW.bin_id, NEW.warehouse_id;
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_check_batch_bin_warehouse
+BEFORE INSERT OR UPDATE OF bin_id, warehouse_id ON batches
+FOR EACH ROW
+EXECUTE FUNCTION check_batch_bin_warehouse();
+
+-- ============================================================================
+-- BIN STOCK
+-- ============================================================================
+
+CREATE OR REPLACE VIEW v_bin_stock AS
+SELECT
+    wb.id AS bin_id,
+    wb.warehouse_id,
+    b.material_id,
+    SUM(b.current_quantity)::DECIMAL(15, 4) AS quantity,
+    COUNT(b.id) AS batch_count
+FROM warehouse_bins wb
+JOIN batches b ON b.bin_id = wb.id
+WHERE b.current_quantity > 0
+GROUP BY wb.id, wb.warehouse_id, b.material_id;
+
+COMMENT ON TABLE warehouse_bins IS 'Storage locations (shelves, bins, pallet positions) inside a warehouse';
+COMMENT ON COLUMN batches.bin_id IS 'Bin the batch is stored in, NULL = warehouse level';
+COMMENT ON COLUMN stock_movements.from_bin_id IS 'Source bin of a transfer';
+COMMENT ON COLUMN stock_movements.to_bin_id IS 'Destination bin of a receipt or transfer';
+COMMENT ON VIEW v_bin_stock IS 'On hand quantity per bin and material';
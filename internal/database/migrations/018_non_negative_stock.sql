@@ -0,0 +1,15 @@
+-- Migration 018: Non-negative stock guarantee
+-- Stock-outs lock the batches they allocate from, this constraint is the
+-- last line of defence: an update that would drive a batch below zero fails
+-- and rolls its whole transaction back instead of overselling.
+
+-- Rows written before this migration are not rechecked, every insert and
+-- update from now on is
+ALTER TABLE batches
+DROP CONSTRAINT IF EXISTS chk_batches_current_quantity_non_negative;
+
+ALTER TABLE batches
+ADD CONSTRAINT chk_batches_current_quantity_non_negative
+CHECK (current_quantity >= 0) NOT VALID;
+
+COMMENT ON CONSTRAINT chk_batches_current_quantity_non_negative ON batches IS 'A batch can never hold a negative quantity';
@@ -0,0 +1,25 @@
+-- Migration 019: Idempotency keys for stock transactions
+-- A client sends an Idempotency-Key header with a stock posting. The first
+-- request with a key claims it and stores its response, a retry with the
+-- same key and payload gets the stored response back instead of posting
+-- the stock a second time. Keys are scoped to the user and expire after
+-- 24 hours.
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    idempotency_key VARCHAR(255) NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    path TEXT NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,             -- SHA-256 of method, path and body
+    status_code INT,                               -- NULL while the first request is running
+    response_body BYTEA,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE (user_id, idempotency_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_created_at ON idempotency_keys(created_at);
+
+COMMENT ON TABLE idempotency_keys IS 'Stored responses of stock postings, replayed for retried requests with the same Idempotency-Key';
+COMMENT ON COLUMN idempotency_keys.status_code IS 'Status of the stored response, NULL while the first request is in progress';
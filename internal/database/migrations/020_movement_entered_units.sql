@@ -0,0 +1,12 @@
+-- Migration 020: Unit of measure on stock movements
+-- Transactions may be entered in any unit that converts to the material's
+-- unit through the measure_units graph (convert_to / convertion_factor).
+-- stock_movements.quantity stays in the material's base unit, the quantity
+-- and unit the user entered are kept next to it.
+
+ALTER TABLE stock_movements
+ADD COLUMN IF NOT EXISTS entered_quantity DECIMAL(15, 4),
+ADD COLUMN IF NOT EXISTS entered_unit_id INT REFERENCES measure_units(id) ON DELETE SET NULL;
+
+COMMENT ON COLUMN stock_movements.entered_quantity IS 'Quantity as entered, in entered_unit_id; NULL = entered in the base unit';
+COMMENT ON COLUMN stock_movements.entered_unit_id IS 'Unit the quantity was entered in, quantity holds the base unit equivalent';
@@ -0,0 +1,69 @@
+-- Migration 021: Reorder points and replenishment suggestions
+-- Planning parameters per material and warehouse drive a job that compares
+-- the stock on hand plus the open purchase order quantities with the reorder
+-- point and suggests what to order. A buyer converts suggestions into
+-- Pending purchase orders, one per supplier and warehouse.
+
+-- ============================================================================
+-- PLANNING PARAMETERS
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS material_planning_parameters (
+    id SERIAL PRIMARY KEY,
+    material_id INT NOT NULL REFERENCES materials(id) ON DELETE CASCADE,
+    warehouse_id INT NOT NULL REFERENCES warehouses(id) ON DELETE CASCADE,
+    min_quantity DECIMAL(15, 4) NOT NULL DEFAULT 0 CHECK (min_quantity >= 0),
+    max_quantity DECIMAL(15, 4) CHECK (max_quantity IS NULL OR max_quantity >= min_quantity),
+    reorder_point DECIMAL(15, 4) NOT NULL DEFAULT 0 CHECK (reorder_point >= 0),
+    reorder_quantity DECIMAL(15, 4) NOT NULL DEFAULT 0 CHECK (reorder_quantity >= 0), -- 0 = order up to max
+    preferred_supplier_id INT REFERENCES suppliers(id) ON DELETE SET NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (material_id, warehouse_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_material_planning_parameters_warehouse_id ON material_planning_parameters(warehouse_id);
+
+CREATE TRIGGER trg_update_material_planning_parameters_updated_at
+BEFORE UPDATE ON material_planning_parameters
+FOR EACH ROW
+EXECUTE PROCEDURE update_updated_at_column();
+
+-- ============================================================================
+-- REPLENISHMENT SUGGESTIONS
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS replenishment_suggestions (
+    id SERIAL PRIMARY KEY,
+    material_id INT NOT NULL REFERENCES materials(id) ON DELETE CASCADE,
+    warehouse_id INT NOT NULL REFERENCES warehouses(id) ON DELETE CASCADE,
+    supplier_id INT REFERENCES suppliers(id) ON DELETE SET NULL,
+    on_hand_quantity DECIMAL(15, 4) NOT NULL,
+    on_order_quantity DECIMAL(15, 4) NOT NULL,
+    reorder_point DECIMAL(15, 4) NOT NULL,
+    suggested_quantity DECIMAL(15, 4) NOT NULL CHECK (suggested_quantity > 0),
+    unit_price DECIMAL(15, 4),                                  -- last purchase price, else the material price
+    status VARCHAR(20) NOT NULL DEFAULT 'Open'
+        CHECK (status IN ('Open', 'Converted', 'Dismissed')),
+    purchase_order_id INT REFERENCES purchase_orders(id) ON DELETE SET NULL,
+    converted_by INT REFERENCES users(id) ON DELETE SET NULL,
+    converted_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The job keeps at most one open suggestion per material and warehouse
+CREATE UNIQUE INDEX IF NOT EXISTS idx_replenishment_suggestions_open
+ON replenishment_suggestions(material_id, warehouse_id)
+WHERE status = 'Open';
+CREATE INDEX IF NOT EXISTS idx_replenishment_suggestions_purchase_order_id ON replenishment_suggestions(purchase_order_id);
+
+CREATE TRIGGER trg_update_replenishment_suggestions_updated_at
+BEFORE UPDATE ON replenishment_suggestions
+FOR EACH ROW
+EXECUTE PROCEDURE update_updated_at_column();
+
+COMMENT ON COLUMN material_planning_parameters.reorder_point IS 'Suggest an order when on hand plus on order falls to this quantity, 0 = use min_quantity';
+COMMENT ON COLUMN material_planning_parameters.reorder_quantity IS 'Quantity to order, 0 = order up to max_quantity';
+COMMENT ON TABLE replenishment_suggestions IS 'Orders suggested by the replenishment job, converted into purchase orders by a buyer';
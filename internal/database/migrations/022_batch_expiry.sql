@@ -0,0 +1,17 @@
+-- Migration 022: Batch expiry monitoring
+-- A scheduled job flags batches with stock that expire within the warning
+-- horizon and places a blocked quality hold on every batch that has
+-- expired, so allocation skips it until quality releases or scraps it.
+
+CREATE TABLE IF NOT EXISTS batch_expiry_flags (
+    batch_id INT PRIMARY KEY REFERENCES batches(id) ON DELETE CASCADE,
+    flagged_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP, -- first seen within the horizon
+    hold_id INT REFERENCES quality_holds(id) ON DELETE SET NULL,          -- hold placed once expired
+    held_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_batches_expiry_date
+ON batches(expiry_date)
+WHERE expiry_date IS NOT NULL AND current_quantity > 0;
+
+COMMENT ON TABLE batch_expiry_flags IS 'Batches the expiry job found nearing expiry, with the quality hold it placed once they expired';
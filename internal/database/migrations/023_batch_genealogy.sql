@@ -0,0 +1,48 @@
+-- Migration 023: Batch genealogy
+-- Transfers and customer returns create new batches, production will create
+-- batches from consumed components. Each parent batch that went into a child
+-- batch is recorded here so batches can be traced upstream to suppliers and
+-- purchase orders and downstream to sales orders and customers.
+
+CREATE TABLE IF NOT EXISTS batch_genealogy (
+    id SERIAL PRIMARY KEY,
+    parent_batch_id INT NOT NULL REFERENCES batches(id) ON DELETE CASCADE,
+    child_batch_id INT NOT NULL REFERENCES batches(id) ON DELETE CASCADE,
+    link_type VARCHAR(20) NOT NULL
+        CHECK (link_type IN ('TRANSFER', 'RETURN', 'PRODUCTION')),
+    movement_id INT REFERENCES stock_movements(id) ON DELETE SET NULL, -- movement that created the child batch
+    quantity DECIMAL(15, 4),                                           -- parent quantity that went into the child, NULL when unknown
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (parent_batch_id <> child_batch_id),
+    UNIQUE (parent_batch_id, child_batch_id, link_type)
+);
+
+CREATE INDEX IF NOT EXISTS idx_batch_genealogy_child_batch_id ON batch_genealogy(child_batch_id);
+
+-- Backfill transfers: the destination batch notes name the source batch and
+-- the TRANSFER_OUT movement that drew from it
+INSERT INTO batch_genealogy (parent_batch_id, child_batch_id, link_type, movement_id, quantity)
+SELECT src.batch_id, child.id, 'TRANSFER', child.movement_id, child.start_quantity
+FROM batches child
+JOIN stock_movements mi ON mi.id = child.movement_id AND mi.movement_type = 'TRANSFER_IN'
+JOIN stock_movement_batches src
+    ON src.movement_id = substring(child.notes FROM '\(movement ([0-9]+)\)$')::int
+JOIN batches parent
+    ON parent.id = src.batch_id
+   AND parent.batch_number = substring(child.notes FROM '^Transferred from batch (.+) \(movement [0-9]+\)$')
+WHERE child.notes ~ '^Transferred from batch .+ \(movement [0-9]+\)$'
+ON CONFLICT DO NOTHING;
+
+-- Backfill customer returns: the returned batch may hold stock of any batch
+-- the original sale drew from
+INSERT INTO batch_genealogy (parent_batch_id, child_batch_id, link_type, movement_id)
+SELECT src.batch_id, child.id, 'RETURN', child.movement_id
+FROM batches child
+JOIN stock_movements mi ON mi.id = child.movement_id AND mi.movement_type = 'CUSTOMER_RETURN'
+JOIN stock_movement_batches src
+    ON src.movement_id = substring(child.notes FROM '\(movement ([0-9]+)\)$')::int
+WHERE child.notes ~ '^Return from sale order [0-9]+ \(movement [0-9]+\)$'
+  AND src.batch_id <> child.id
+ON CONFLICT DO NOTHING;
+
+COMMENT ON TABLE batch_genealogy IS 'Parent batches that went into a batch through a transfer, customer return or production';
@@ -0,0 +1,85 @@
+-- Migration 024: Production work orders
+-- A work order produces a finished material from one BOM version. The BOM
+-- lines are copied onto the order when it is created, issuing consumes the
+-- component batches as PRODUCTION_ISSUE movements and completion receives a
+-- finished goods batch as a PRODUCTION_RECEIPT movement costed from them.
+
+ALTER TYPE stock_movement_type ADD VALUE IF NOT EXISTS 'PRODUCTION_ISSUE';
+ALTER TYPE stock_movement_type ADD VALUE IF NOT EXISTS 'PRODUCTION_RECEIPT';
+
+-- ============================================================================
+-- WORK ORDERS
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS work_orders (
+    id SERIAL PRIMARY KEY,
+    work_order_number VARCHAR(100) NOT NULL UNIQUE,
+    finished_material_id INT NOT NULL REFERENCES materials(id) ON DELETE RESTRICT,
+    bom_version VARCHAR(50) NOT NULL,
+    warehouse_id INT NOT NULL REFERENCES warehouses(id) ON DELETE RESTRICT, -- components are issued from and goods received into it
+    planned_quantity DECIMAL(15, 4) NOT NULL CHECK (planned_quantity > 0),
+    completed_quantity DECIMAL(15, 4) NOT NULL DEFAULT 0 CHECK (completed_quantity >= 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'Planned'
+        CHECK (status IN ('Planned', 'In Progress', 'Completed', 'Cancelled')),
+    issued_cost DECIMAL(15, 4) NOT NULL DEFAULT 0,                          -- cost of the components issued so far
+    batch_id INT REFERENCES batches(id) ON DELETE SET NULL,                 -- finished goods batch
+    due_date DATE,
+    notes TEXT,
+    created_by INT REFERENCES users(id) ON DELETE SET NULL,
+    completed_by INT REFERENCES users(id) ON DELETE SET NULL,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_work_orders_finished_material_id ON work_orders(finished_material_id);
+CREATE INDEX IF NOT EXISTS idx_work_orders_status ON work_orders(status);
+
+CREATE TRIGGER trg_update_work_orders_updated_at
+BEFORE UPDATE ON work_orders
+FOR EACH ROW
+EXECUTE PROCEDURE update_updated_at_column();
+
+-- ============================================================================
+-- WORK ORDER COMPONENTS
+-- ============================================================================
+
+-- Quantities are in the unit of the component material
+CREATE TABLE IF NOT EXISTS work_order_components (
+    id SERIAL PRIMARY KEY,
+    work_order_id INT NOT NULL REFERENCES work_orders(id) ON DELETE CASCADE,
+    bom_id INT REFERENCES bills_of_materials(id) ON DELETE SET NULL,
+    component_material_id INT NOT NULL REFERENCES materials(id) ON DELETE RESTRICT,
+    alternate_component_id INT REFERENCES materials(id) ON DELETE RESTRICT,
+    base_quantity DECIMAL(15, 4) NOT NULL,                -- per finished unit, per order when fixed_quantity
+    scrap_percentage DECIMAL(5, 2) NOT NULL DEFAULT 0,
+    fixed_quantity BOOLEAN NOT NULL DEFAULT FALSE,
+    is_optional BOOLEAN NOT NULL DEFAULT FALSE,
+    required_quantity DECIMAL(15, 4) NOT NULL CHECK (required_quantity >= 0), -- scrap included
+    issued_quantity DECIMAL(15, 4) NOT NULL DEFAULT 0,    -- component and alternate, in the component unit
+    alternate_issued_quantity DECIMAL(15, 4) NOT NULL DEFAULT 0, -- in the alternate unit
+    issued_cost DECIMAL(15, 4) NOT NULL DEFAULT 0,
+    sequence INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_work_order_components_work_order_id ON work_order_components(work_order_id);
+
+CREATE TRIGGER trg_update_work_order_components_updated_at
+BEFORE UPDATE ON work_order_components
+FOR EACH ROW
+EXECUTE PROCEDURE update_updated_at_column();
+
+-- ============================================================================
+-- PRODUCTION MOVEMENTS
+-- ============================================================================
+
+ALTER TABLE stock_movements
+ADD COLUMN IF NOT EXISTS work_order_id INT REFERENCES work_orders(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_work_order_id ON stock_movements(work_order_id);
+
+COMMENT ON TABLE work_orders IS 'Production of a finished material from one BOM version';
+COMMENT ON TABLE work_order_components IS 'BOM lines copied onto a work order with the quantities required and issued';
+COMMENT ON COLUMN stock_movements.work_order_id IS 'Work order a PRODUCTION_ISSUE or PRODUCTION_RECEIPT movement belongs to';
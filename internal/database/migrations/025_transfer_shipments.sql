@@ -0,0 +1,66 @@
+-- Migration 025: Two-step transfers with in-transit stock
+-- A transfer between warehouses can be shipped and received separately.
+-- Shipping consumes the source batches with a TRANSFER_OUT movement, the
+-- stock is then in transit to the destination until it is received there
+-- with a TRANSFER_IN movement. The receipt may record less than was shipped,
+-- the difference is the discrepancy of the shipment.
+
+-- ============================================================================
+-- TRANSFER SHIPMENTS
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS transfer_shipments (
+    id SERIAL PRIMARY KEY,
+    shipment_number VARCHAR(100) NOT NULL UNIQUE,
+    material_id INT NOT NULL REFERENCES materials(id) ON DELETE RESTRICT,
+    from_warehouse_id INT NOT NULL REFERENCES warehouses(id) ON DELETE RESTRICT,
+    to_warehouse_id INT NOT NULL REFERENCES warehouses(id) ON DELETE RESTRICT,
+    from_bin_id INT REFERENCES warehouse_bins(id) ON DELETE SET NULL,
+    to_bin_id INT REFERENCES warehouse_bins(id) ON DELETE SET NULL,       -- planned putaway bin, the receipt may choose another
+    quantity DECIMAL(15, 4) NOT NULL CHECK (quantity > 0),
+    received_quantity DECIMAL(15, 4) CHECK (received_quantity >= 0 AND received_quantity <= quantity),
+    unit_cost DECIMAL(15, 4),
+    status VARCHAR(20) NOT NULL DEFAULT 'In Transit'
+        CHECK (status IN ('In Transit', 'Received')),
+    expected_date DATE,
+    discrepancy_reason TEXT,
+    notes TEXT,
+    shipped_by INT REFERENCES users(id) ON DELETE SET NULL,
+    received_by INT REFERENCES users(id) ON DELETE SET NULL,
+    shipped_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    received_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (from_warehouse_id <> to_warehouse_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transfer_shipments_material_id ON transfer_shipments(material_id);
+CREATE INDEX IF NOT EXISTS idx_transfer_shipments_in_transit ON transfer_shipments(to_warehouse_id, material_id)
+WHERE status = 'In Transit';
+
+CREATE TRIGGER trg_update_transfer_shipments_updated_at
+BEFORE UPDATE ON transfer_shipments
+FOR EACH ROW
+EXECUTE PROCEDURE update_updated_at_column();
+
+ALTER TABLE stock_movements
+ADD COLUMN IF NOT EXISTS transfer_shipment_id INT REFERENCES transfer_shipments(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_transfer_shipment_id ON stock_movements(transfer_shipment_id);
+
+-- ============================================================================
+-- IN-TRANSIT QUANTITIES
+-- ============================================================================
+
+CREATE OR REPLACE VIEW v_in_transit_stock AS
+SELECT
+    material_id,
+    to_warehouse_id AS warehouse_id,
+    SUM(quantity)::DECIMAL(15, 4) AS in_transit_quantity
+FROM transfer_shipments
+WHERE status = 'In Transit'
+GROUP BY material_id, to_warehouse_id;
+
+COMMENT ON TABLE transfer_shipments IS 'Transfers between warehouses shipped and received in two steps';
+COMMENT ON COLUMN stock_movements.transfer_shipment_id IS 'Transfer shipment a TRANSFER_OUT or TRANSFER_IN movement ships or receives';
+COMMENT ON VIEW v_in_transit_stock IS 'Quantity shipped to a warehouse and not received yet, per material and warehouse';
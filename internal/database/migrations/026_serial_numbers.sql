@@ -0,0 +1,55 @@
+-- Migration 026: Serial number tracking
+-- Serialized materials are tracked per unit. Each unit has a serial number
+-- unique for its material, registered when it is received and kept on the
+-- batch and warehouse that hold it. Every movement of a serialized material
+-- records the serial numbers it moved, which is the history of a serial.
+
+ALTER TABLE materials
+ADD COLUMN IF NOT EXISTS is_serialized BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- ============================================================================
+-- SERIAL NUMBERS
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS serial_numbers (
+    id SERIAL PRIMARY KEY,
+    material_id INT NOT NULL REFERENCES materials(id) ON DELETE RESTRICT,
+    serial_number VARCHAR(100) NOT NULL,
+    batch_id INT REFERENCES batches(id) ON DELETE SET NULL,           -- batch holding the unit, the last one once it left
+    warehouse_id INT REFERENCES warehouses(id) ON DELETE RESTRICT,    -- NULL unless the unit is in stock
+    status VARCHAR(20) NOT NULL DEFAULT 'In Stock'
+        CHECK (status IN ('In Stock', 'In Transit', 'Sold', 'Lost')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (material_id, serial_number),
+    CHECK ((status = 'In Stock') = (warehouse_id IS NOT NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_serial_numbers_serial_number ON serial_numbers(serial_number);
+CREATE INDEX IF NOT EXISTS idx_serial_numbers_batch_id ON serial_numbers(batch_id);
+CREATE INDEX IF NOT EXISTS idx_serial_numbers_in_stock ON serial_numbers(material_id, warehouse_id)
+WHERE status = 'In Stock';
+
+CREATE TRIGGER trg_update_serial_numbers_updated_at
+BEFORE UPDATE ON serial_numbers
+FOR EACH ROW
+EXECUTE PROCEDURE update_updated_at_column();
+
+-- ============================================================================
+-- MOVEMENT SERIALS
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS stock_movement_serials (
+    id SERIAL PRIMARY KEY,
+    movement_id INT NOT NULL REFERENCES stock_movements(id) ON DELETE CASCADE,
+    serial_number_id INT NOT NULL REFERENCES serial_numbers(id) ON DELETE CASCADE,
+    batch_id INT REFERENCES batches(id) ON DELETE SET NULL,           -- batch the unit left or entered with the movement
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (movement_id, serial_number_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_movement_serials_serial_number_id ON stock_movement_serials(serial_number_id);
+
+COMMENT ON COLUMN materials.is_serialized IS 'Units of the material are tracked by serial number';
+COMMENT ON TABLE serial_numbers IS 'Serial numbers of serialized materials with the batch and warehouse holding them';
+COMMENT ON TABLE stock_movement_serials IS 'Serial numbers moved by a stock movement';
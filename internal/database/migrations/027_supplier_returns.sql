@@ -0,0 +1,20 @@
+-- Migration 027: Supplier returns
+-- Stock received against a purchase order can be sent back to the supplier
+-- as a SUPPLIER_RETURN movement out of the received batch. The return takes
+-- the quantity off the purchase order line it was received on and, when it
+-- settles a non-conformance report with a return_to_supplier disposition,
+-- references the report so the returns can be totalled against it.
+
+ALTER TYPE stock_movement_type ADD VALUE IF NOT EXISTS 'SUPPLIER_RETURN';
+
+ALTER TABLE stock_movements
+ADD COLUMN IF NOT EXISTS ncr_id INT REFERENCES non_conformance_reports(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_ncr_id ON stock_movements(ncr_id);
+
+-- Serialized units sent back to the supplier leave stock as Returned
+ALTER TABLE serial_numbers DROP CONSTRAINT IF EXISTS serial_numbers_status_check;
+ALTER TABLE serial_numbers ADD CONSTRAINT serial_numbers_status_check
+    CHECK (status IN ('In Stock', 'In Transit', 'Sold', 'Lost', 'Returned'));
+
+COMMENT ON COLUMN stock_movements.ncr_id IS 'Non-conformance report a SUPPLIER_RETURN movement settles';
@@ -0,0 +1,13 @@
+-- Migration 028: Incoming inspection on purchase receipt
+-- A purchase receipt of a material with required quality specs creates a
+-- pending incoming inspection of the new batch and quarantines the batch
+-- with a quality hold placed by the inspection. Deciding the inspection
+-- releases its holds when it passed, or keeps them and raises an NCR when
+-- it failed.
+
+CREATE INDEX IF NOT EXISTS idx_quality_holds_inspection_id ON quality_holds(inspection_id)
+WHERE is_released = FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_ncr_inspection ON non_conformance_reports(inspection_id);
+
+CREATE INDEX IF NOT EXISTS idx_quality_inspections_stock_movement_id ON quality_inspections(stock_movement_id);
@@ -0,0 +1,30 @@
+-- Migration 029: Work order numbers from a sequence
+-- Work order numbers were built from the material and the current second,
+-- so two orders for the same material in the same second collided on the
+-- unique number. The number is now set on insert from a sequence: WO- for
+-- production orders and RW- for the rework of returned stock.
+
+CREATE SEQUENCE IF NOT EXISTS work_order_number_seq;
+
+-- Auto-generate work order number
+CREATE OR REPLACE FUNCTION set_work_order_number()
+RETURNS TRIGGER AS $$
+DECLARE
+    prefix TEXT := 'WO-';
+BEGIN
+    IF NEW.work_order_number IS NULL OR NEW.work_order_number = '' THEN
+        IF NEW.bom_version = 'REWORK' THEN
+            prefix := 'RW-';
+        END IF;
+        NEW.work_order_number := prefix || TO_CHAR(CURRENT_DATE, 'YYYY') || '-'
+            || LPAD(nextval('work_order_number_seq')::TEXT, 6, '0');
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_set_work_order_number ON work_orders;
+CREATE TRIGGER trg_set_work_order_number
+BEFORE INSERT ON work_orders
+FOR EACH ROW
+EXECUTE FUNCTION set_work_order_number();
@@ -0,0 +1,9 @@
+-- Migration 030: Serial numbers on scrap and rework
+-- Scrap and stock adjustments of a serialized material take the serial
+-- numbers of the units they write off. Scrapped units leave stock as
+-- Scrapped, units an adjustment finds missing as Lost. Returned units issued
+-- to a rework order are Consumed until the order receives them again.
+
+ALTER TABLE serial_numbers DROP CONSTRAINT IF EXISTS serial_numbers_status_check;
+ALTER TABLE serial_numbers ADD CONSTRAINT serial_numbers_status_check
+    CHECK (status IN ('In Stock', 'In Transit', 'Sold', 'Lost', 'Returned', 'Scrapped', 'Consumed'));
@@ -0,0 +1,9 @@
+-- Migration 031: Transfer losses
+-- What a transfer shipment shipped and did not deliver was written off
+-- without a movement, the value simply disappeared from the books. The
+-- receipt now posts the missing quantity as a TRANSFER_LOSS movement out of
+-- the shipment, at the cost it was shipped with and with the discrepancy
+-- reason as notes. It has no batch lines: the source batches were already
+-- consumed by the TRANSFER_OUT movement when the shipment was shipped.
+
+ALTER TYPE stock_movement_type ADD VALUE IF NOT EXISTS 'TRANSFER_LOSS';
@@ -0,0 +1,11 @@
+-- Migration 032: Idempotency key leases
+-- A claim of a running first request now lapses after a lease, so a retry
+-- can take over a key whose request died with the process instead of
+-- waiting 24 hours. The lease is cleared once the request has committed
+-- stock: a key whose response could not be stored then stays claimed, a
+-- retry is told the outcome is unknown instead of posting the stock again.
+
+ALTER TABLE idempotency_keys
+ADD COLUMN IF NOT EXISTS lease_expires_at TIMESTAMP WITH TIME ZONE;
+
+COMMENT ON COLUMN idempotency_keys.lease_expires_at IS 'When the claim of a running first request lapses, NULL once the request completed or committed';
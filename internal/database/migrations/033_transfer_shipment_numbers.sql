@@ -0,0 +1,26 @@
+-- Migration 033: Transfer shipment numbers from a sequence
+-- Shipment numbers were built from the warehouses, the material and the
+-- current second, so two shipments of the same material between the same
+-- warehouses in the same second collided on the unique number. The number
+-- is now set on insert from a sequence. The movements of a shipment are
+-- linked to it by transfer_shipment_id, the number is only their reference.
+
+CREATE SEQUENCE IF NOT EXISTS transfer_shipment_number_seq;
+
+-- Auto-generate transfer shipment number
+CREATE OR REPLACE FUNCTION set_transfer_shipment_number()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.shipment_number IS NULL OR NEW.shipment_number = '' THEN
+        NEW.shipment_number := 'TS-' || TO_CHAR(CURRENT_DATE, 'YYYY') || '-'
+            || LPAD(nextval('transfer_shipment_number_seq')::TEXT, 6, '0');
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_set_transfer_shipment_number ON transfer_shipments;
+CREATE TRIGGER trg_set_transfer_shipment_number
+BEFORE INSERT ON transfer_shipments
+FOR EACH ROW
+EXECUTE FUNCTION set_transfer_shipment_number();
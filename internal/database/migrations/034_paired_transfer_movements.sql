@@ -0,0 +1,31 @@
+-- Migration 034: Paired transfer movements
+-- The two legs of a one-step transfer were only tied together by their
+-- reference, which was built from the warehouses, the material and the
+-- current second and so was not unique. A reversal could pick up the leg of
+-- another transfer. Each leg now points at the other one and a reversal
+-- follows that link. Existing transfers are paired where their reference
+-- identifies exactly one leg of each direction, the others stay unpaired
+-- and cannot be reversed.
+
+ALTER TABLE stock_movements
+ADD COLUMN IF NOT EXISTS paired_movement_id INT REFERENCES stock_movements(id) ON DELETE RESTRICT;
+
+WITH legs AS (
+    SELECT reference, material_id,
+        MIN(id) FILTER (WHERE movement_type = 'TRANSFER_OUT') AS out_id,
+        MIN(id) FILTER (WHERE movement_type = 'TRANSFER_IN') AS in_id
+    FROM stock_movements
+    WHERE movement_type IN ('TRANSFER_OUT', 'TRANSFER_IN')
+      AND transfer_shipment_id IS NULL
+      AND reference IS NOT NULL
+    GROUP BY reference, material_id
+    HAVING COUNT(*) FILTER (WHERE movement_type = 'TRANSFER_OUT') = 1
+       AND COUNT(*) FILTER (WHERE movement_type = 'TRANSFER_IN') = 1
+)
+UPDATE stock_movements sm
+SET paired_movement_id = CASE WHEN sm.id = legs.out_id THEN legs.in_id ELSE legs.out_id END
+FROM legs
+WHERE sm.id IN (legs.out_id, legs.in_id)
+  AND sm.paired_movement_id IS NULL;
+
+COMMENT ON COLUMN stock_movements.paired_movement_id IS 'Other leg of a one-step transfer';
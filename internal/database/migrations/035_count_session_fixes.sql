@@ -0,0 +1,38 @@
+-- Migration 035: Count session numbers and expected quantities
+-- Session numbers were built from the warehouse and the current second, so
+-- two sessions for one warehouse in the same second collided on the unique
+-- number. The number is now set on insert from a sequence.
+--
+-- The variance of a line was the counted quantity less the snapshot taken
+-- when the session started, applied to the batch as it is at approval.
+-- Stock that moved between the snapshot and the count was counted twice or
+-- cancelled out. Recording a count now also stores the system quantity of
+-- the batch at that moment, and the variance is taken against it: movements
+-- before the count are in the expected quantity, movements after it are in
+-- the batch the variance is applied to. Postings on batches in scope are
+-- not blocked while a session is open.
+
+CREATE SEQUENCE IF NOT EXISTS count_session_number_seq;
+
+-- Auto-generate count session number
+CREATE OR REPLACE FUNCTION set_count_session_number()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.session_number IS NULL OR NEW.session_number = '' THEN
+        NEW.session_number := 'CC-' || TO_CHAR(CURRENT_DATE, 'YYYY') || '-'
+            || LPAD(nextval('count_session_number_seq')::TEXT, 6, '0');
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_set_count_session_number ON count_sessions;
+CREATE TRIGGER trg_set_count_session_number
+BEFORE INSERT ON count_sessions
+FOR EACH ROW
+EXECUTE FUNCTION set_count_session_number();
+
+ALTER TABLE count_session_lines
+ADD COLUMN IF NOT EXISTS expected_quantity DECIMAL(15, 4);
+
+COMMENT ON COLUMN count_session_lines.expected_quantity IS 'System quantity of the batch when it was counted, the variance is taken against it';
@@ -0,0 +1,7 @@
+-- Migration 036: Replenishment order numbers from a sequence
+-- Purchase orders converted from replenishment suggestions were numbered
+-- from the supplier, the warehouse and the current second, so two
+-- conversions in the same second collided on the unique order number. They
+-- now take the next number of a sequence.
+
+CREATE SEQUENCE IF NOT EXISTS replenishment_order_number_seq;
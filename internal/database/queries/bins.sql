@@ -0,0 +1,110 @@
+-- =====================================================
+-- WAREHOUSE BIN QUERIES
+-- =====================================================
+
+-- name: CreateWarehouseBin :one
+INSERT INTO warehouse_bins (
+    warehouse_id, code, name, bin_type, zone, capacity, notes
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING id, warehouse_id, code, name, bin_type, zone, capacity, is_active, notes, created_at, updated_at;
+
+-- name: GetWarehouseBinByID :one
+SELECT id, warehouse_id, code, name, bin_type, zone, capacity, is_active, notes, created_at, updated_at
+FROM warehouse_bins
+WHERE id = $1;
+
+-- name: GetWarehouseBinByCode :one
+SELECT id, warehouse_id, code, name, bin_type, zone, capacity, is_active, notes, created_at, updated_at
+FROM warehouse_bins
+WHERE warehouse_id = $1 AND code = $2;
+
+-- name: ListWarehouseBins :many
+SELECT
+    wb.id,
+    wb.warehouse_id,
+    wb.code,
+    wb.name,
+    wb.bin_type,
+    wb.zone,
+    wb.capacity,
+    wb.is_active,
+    wb.notes,
+    wb.created_at,
+    wb.updated_at,
+    COALESCE(SUM(bs.quantity), 0)::DECIMAL(15, 4) as occupied_quantity,
+    COUNT(bs.material_id) as material_count
+FROM warehouse_bins wb
+LEFT JOIN v_bin_stock bs ON bs.bin_id = wb.id
+WHERE wb.warehouse_id = $1
+GROUP BY wb.id
+ORDER BY wb.zone NULLS LAST, wb.code;
+
+-- name: UpdateWarehouseBin :one
+UPDATE warehouse_bins
+SET
+    code = COALESCE(sqlc.narg(code), code),
+    name = COALESCE(sqlc.narg(name), name),
+    bin_type = COALESCE(sqlc.narg(bin_type), bin_type),
+    zone = COALESCE(sqlc.narg(zone), zone),
+    capacity = COALESCE(sqlc.narg(capacity), capacity),
+    is_active = COALESCE(sqlc.narg(is_active), is_active),
+    notes = COALESCE(sqlc.narg(notes), notes),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, warehouse_id, code, name, bin_type, zone, capacity, is_active, notes, created_at, updated_at;
+
+-- name: DeleteWarehouseBin :exec
+DELETE FROM warehouse_bins
+WHERE id = $1;
+
+-- name: CountBinBatches :one
+SELECT COUNT(*) AS count
+FROM batches
+WHERE bin_id = $1;
+
+-- =====================================================
+-- BIN STOCK QUERIES
+-- =====================================================
+
+-- Stock per bin and material of a warehouse, batches without a bin are
+-- reported with an empty bin
+-- name: GetBinStockLevels :many
+SELECT
+    b.bin_id,
+    wb.code as bin_code,
+    wb.zone,
+    m.id as material_id,
+    m.code as material_code,
+    m.name as material_name,
+    SUM(b.current_quantity)::DECIMAL(15, 4) as total_quantity,
+    COUNT(b.id) as batch_count
+FROM batches b
+JOIN materials m ON b.material_id = m.id
+LEFT JOIN warehouse_bins wb ON b.bin_id = wb.id
+WHERE b.warehouse_id = $1
+  AND b.current_quantity > 0
+  AND (sqlc.narg(bin_id)::int IS NULL OR b.bin_id = sqlc.narg(bin_id)::int)
+  AND (sqlc.narg(material_id)::int IS NULL OR b.material_id = sqlc.narg(material_id)::int)
+GROUP BY b.bin_id, wb.code, wb.zone, m.id, m.code, m.name
+ORDER BY wb.code NULLS FIRST, m.code;
+
+-- Active bins of a warehouse with what they hold, used for putaway suggestions
+-- name: ListPutawayCandidates :many
+SELECT
+    wb.id,
+    wb.code,
+    wb.name,
+    wb.bin_type,
+    wb.zone,
+    wb.capacity,
+    COALESCE(SUM(bs.quantity), 0)::DECIMAL(15, 4) as occupied_quantity,
+    COALESCE(SUM(bs.quantity) FILTER (WHERE bs.material_id = $2), 0)::DECIMAL(15, 4) as material_quantity,
+    COUNT(bs.material_id) FILTER (WHERE bs.material_id <> $2) as other_material_count
+FROM warehouse_bins wb
+LEFT JOIN v_bin_stock bs ON bs.bin_id = wb.id
+WHERE wb.warehouse_id = $1
+  AND wb.is_active = TRUE
+GROUP BY wb.id
+ORDER BY wb.zone NULLS LAST, wb.code;
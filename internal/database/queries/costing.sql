@@ -0,0 +1,86 @@
+-- =====================================================
+-- RUNNING AVERAGE COST QUERIES
+-- =====================================================
+
+-- name: GetMaterialAverageCost :one
+SELECT material_id, warehouse_id, on_hand_quantity, total_value,
+    average_unit_cost, created_at, updated_at
+FROM material_average_costs
+WHERE material_id = $1 AND warehouse_id = $2;
+
+-- name: CreateMaterialAverageCostIfMissing :exec
+INSERT INTO material_average_costs (material_id, warehouse_id)
+VALUES ($1, $2)
+ON CONFLICT (material_id, warehouse_id) DO NOTHING;
+
+-- name: GetMaterialAverageCostForUpdate :one
+SELECT material_id, warehouse_id, on_hand_quantity, total_value,
+    average_unit_cost, created_at, updated_at
+FROM material_average_costs
+WHERE material_id = $1 AND warehouse_id = $2
+FOR UPDATE;
+
+-- name: UpsertMaterialAverageCost :one
+INSERT INTO material_average_costs (
+    material_id, warehouse_id, on_hand_quantity, total_value, average_unit_cost
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (material_id, warehouse_id) DO UPDATE
+SET on_hand_quantity = EXCLUDED.on_hand_quantity,
+    total_value = EXCLUDED.total_value,
+    average_unit_cost = EXCLUDED.average_unit_cost,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING material_id, warehouse_id, on_hand_quantity, total_value,
+    average_unit_cost, created_at, updated_at;
+
+-- name: ListMaterialAverageCosts :many
+SELECT
+    mac.material_id,
+    mac.warehouse_id,
+    mac.on_hand_quantity,
+    mac.total_value,
+    mac.average_unit_cost,
+    mac.updated_at,
+    m.name as material_name,
+    m.code as material_code,
+    w.name as warehouse_name
+FROM material_average_costs mac
+JOIN materials m ON mac.material_id = m.id
+JOIN warehouses w ON mac.warehouse_id = w.id
+WHERE mac.material_id = $1
+ORDER BY w.name;
+
+-- =====================================================
+-- COST LEDGER QUERIES
+-- =====================================================
+
+-- name: CreateCostLedgerEntry :one
+INSERT INTO material_cost_ledger (
+    material_id, warehouse_id, movement_id,
+    quantity_change, unit_cost, value_change,
+    on_hand_quantity, total_value, average_unit_cost
+) VALUES (
+    $1, $2, $3,
+    $4, $5, $6,
+    $7, $8, $9
+)
+RETURNING id, material_id, warehouse_id, movement_id,
+    quantity_change, unit_cost, value_change,
+    on_hand_quantity, total_value, average_unit_cost, created_at;
+
+-- name: ListCostLedgerEntries :many
+SELECT
+    cl.id, cl.material_id, cl.warehouse_id, cl.movement_id,
+    cl.quantity_change, cl.unit_cost, cl.value_change,
+    cl.on_hand_quantity, cl.total_value, cl.average_unit_cost, cl.created_at,
+    sm.movement_type,
+    sm.stock_direction,
+    sm.reference,
+    sm.movement_date
+FROM material_cost_ledger cl
+LEFT JOIN stock_movements sm ON cl.movement_id = sm.id
+WHERE cl.material_id = $1
+  AND cl.warehouse_id = $2
+ORDER BY cl.id DESC
+LIMIT $3 OFFSET $4;
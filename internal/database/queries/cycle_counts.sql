@@ -0,0 +1,147 @@
+-- =====================================================
+-- COUNT SESSION QUERIES
+-- =====================================================
+
+-- name: CreateCountSession :one
+INSERT INTO count_sessions (
+    session_number, warehouse_id, category_id, abc_class, created_by, notes
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+RETURNING id, session_number, warehouse_id, category_id, abc_class, status,
+    created_by, approved_by, approved_at, notes, created_at, updated_at;
+
+-- name: GetCountSessionByID :one
+SELECT id, session_number, warehouse_id, category_id, abc_class, status,
+    created_by, approved_by, approved_at, notes, created_at, updated_at
+FROM count_sessions
+WHERE id = $1;
+
+-- name: GetCountSessionByIDForUpdate :one
+SELECT id, session_number, warehouse_id, category_id, abc_class, status,
+    created_by, approved_by, approved_at, notes, created_at, updated_at
+FROM count_sessions
+WHERE id = $1
+FOR UPDATE;
+
+-- name: ListCountSessions :many
+SELECT
+    cs.id,
+    cs.session_number,
+    cs.warehouse_id,
+    cs.category_id,
+    cs.abc_class,
+    cs.status,
+    cs.created_by,
+    cs.approved_by,
+    cs.approved_at,
+    cs.notes,
+    cs.created_at,
+    w.name as warehouse_name,
+    mc.name as category_name,
+    COUNT(csl.id) as line_count,
+    COUNT(csl.counted_quantity) as counted_line_count
+FROM count_sessions cs
+JOIN warehouses w ON cs.warehouse_id = w.id
+LEFT JOIN material_categories mc ON cs.category_id = mc.id
+LEFT JOIN count_session_lines csl ON csl.session_id = cs.id
+WHERE cs.warehouse_id = $1
+GROUP BY cs.id, w.name, mc.name
+ORDER BY cs.created_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: UpdateCountSessionStatus :one
+UPDATE count_sessions
+SET status = $2,
+    approved_by = $3,
+    approved_at = CASE WHEN $2 = 'Approved' THEN CURRENT_TIMESTAMP ELSE approved_at END,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, session_number, warehouse_id, category_id, abc_class, status,
+    created_by, approved_by, approved_at, notes, created_at, updated_at;
+
+-- =====================================================
+-- COUNT SESSION LINE QUERIES
+-- =====================================================
+
+-- Freezes the batch quantities of the session scope
+-- Serialized materials are counted by serial number, not in count sessions
+-- name: CreateCountSessionLines :execrows
+INSERT INTO count_session_lines (session_id, material_id, batch_id, snapshot_quantity)
+SELECT $1, b.material_id, b.id, b.current_quantity
+FROM batches b
+JOIN materials m ON b.material_id = m.id
+LEFT JOIN v_material_abc_class abc ON abc.material_id = b.material_id AND abc.warehouse_id = b.warehouse_id
+WHERE b.warehouse_id = $2
+  AND b.current_quantity > 0
+  AND m.is_serialized = FALSE
+  AND (sqlc.narg(category_id)::int IS NULL OR m.category = sqlc.narg(category_id)::int)
+  AND (sqlc.narg(abc_class)::varchar IS NULL OR abc.abc_class = sqlc.narg(abc_class)::varchar)
+ORDER BY m.code, b.batch_number;
+
+-- name: ListCountSessionLines :many
+SELECT
+    csl.id,
+    csl.session_id,
+    csl.material_id,
+    csl.batch_id,
+    csl.snapshot_quantity,
+    csl.counted_quantity,
+    csl.expected_quantity,
+    (csl.counted_quantity - COALESCE(csl.expected_quantity, csl.snapshot_quantity))::DECIMAL(15, 4) as variance_quantity,
+    csl.counted_by,
+    csl.counted_at,
+    csl.adjustment_movement_id,
+    csl.notes,
+    m.code as material_code,
+    m.name as material_name,
+    mu.abbreviation as unit,
+    b.batch_number,
+    b.expiry_date,
+    b.unit_price,
+    wb.code as bin_code
+FROM count_session_lines csl
+JOIN materials m ON csl.material_id = m.id
+JOIN batches b ON csl.batch_id = b.id
+LEFT JOIN warehouse_bins wb ON b.bin_id = wb.id
+LEFT JOIN measure_units mu ON m.measure_unit_id = mu.id
+WHERE csl.session_id = $1
+ORDER BY wb.code NULLS LAST, m.code, b.batch_number;
+
+-- Stores a count with the system quantity of the batch at that moment
+-- name: UpdateCountSessionLineCount :execrows
+UPDATE count_session_lines
+SET counted_quantity = $3,
+    counted_by = $4,
+    counted_at = CURRENT_TIMESTAMP,
+    expected_quantity = (
+        SELECT b.current_quantity FROM batches b
+        WHERE b.id = count_session_lines.batch_id
+        FOR SHARE
+    ),
+    notes = COALESCE(sqlc.narg(notes), notes),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND session_id = $2;
+
+-- name: SetCountSessionLineMovement :exec
+UPDATE count_session_lines
+SET adjustment_movement_id = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- =====================================================
+-- ABC CLASSIFICATION QUERIES
+-- =====================================================
+
+-- name: ListMaterialABCClasses :many
+SELECT
+    abc.material_id,
+    abc.warehouse_id,
+    abc.annual_usage_value,
+    abc.abc_class,
+    m.code as material_code,
+    m.name as material_name
+FROM v_material_abc_class abc
+JOIN materials m ON abc.material_id = m.id
+WHERE abc.warehouse_id = $1
+ORDER BY abc.annual_usage_value DESC, m.code;
@@ -0,0 +1,79 @@
+-- =====================================================
+-- BATCH EXPIRY QUERIES
+-- =====================================================
+
+-- Flags the batches with stock that expire within the horizon and were not
+-- flagged yet
+-- name: FlagExpiringBatches :execrows
+INSERT INTO batch_expiry_flags (batch_id)
+SELECT b.id
+FROM batches b
+WHERE b.current_quantity > 0
+  AND b.expiry_date IS NOT NULL
+  AND b.expiry_date <= CURRENT_DATE + sqlc.arg(horizon_days)::int
+ON CONFLICT (batch_id) DO NOTHING;
+
+-- Batches with stock past their expiry date that no active hold covers yet.
+-- A batch is usable up to and including its expiry date. A batch is held for
+-- expiry once, after quality released or scrapped it the job leaves it be.
+-- name: ListExpiredBatchesWithoutHold :many
+SELECT
+    b.id,
+    b.material_id::int as material_id,
+    b.warehouse_id,
+    b.batch_number,
+    b.current_quantity,
+    b.expiry_date,
+    m.measure_unit_id
+FROM batches b
+JOIN materials m ON b.material_id = m.id
+WHERE b.current_quantity > 0
+  AND b.expiry_date < CURRENT_DATE
+  AND NOT EXISTS (SELECT 1 FROM v_batches_on_hold h WHERE h.batch_id = b.id)
+  AND NOT EXISTS (
+      SELECT 1 FROM batch_expiry_flags ef
+      WHERE ef.batch_id = b.id AND ef.held_at IS NOT NULL
+  )
+ORDER BY b.id
+FOR UPDATE OF b;
+
+-- name: SetBatchExpiryHold :exec
+INSERT INTO batch_expiry_flags (batch_id, hold_id, held_at)
+VALUES ($1, $2, CURRENT_TIMESTAMP)
+ON CONFLICT (batch_id) DO UPDATE SET
+    hold_id = EXCLUDED.hold_id,
+    held_at = EXCLUDED.held_at;
+
+-- Batches with stock that expire within the horizon or have expired, soonest first
+-- name: ListExpiringBatches :many
+SELECT
+    b.id,
+    b.batch_number,
+    b.material_id,
+    m.code as material_code,
+    m.name as material_name,
+    b.warehouse_id,
+    w.name as warehouse_name,
+    wb.code as bin_code,
+    b.current_quantity,
+    mu.abbreviation as unit,
+    b.unit_price,
+    b.expiry_date,
+    (b.expiry_date - CURRENT_DATE)::int as days_to_expiry,
+    (b.expiry_date < CURRENT_DATE)::boolean as expired,
+    ef.flagged_at,
+    h.hold_id,
+    h.hold_number
+FROM batches b
+JOIN materials m ON b.material_id = m.id
+JOIN warehouses w ON b.warehouse_id = w.id
+LEFT JOIN warehouse_bins wb ON b.bin_id = wb.id
+LEFT JOIN measure_units mu ON m.measure_unit_id = mu.id
+LEFT JOIN batch_expiry_flags ef ON ef.batch_id = b.id
+LEFT JOIN v_batches_on_hold h ON h.batch_id = b.id
+WHERE b.current_quantity > 0
+  AND b.expiry_date IS NOT NULL
+  AND b.expiry_date <= CURRENT_DATE + sqlc.arg(horizon_days)::int
+  AND (sqlc.narg(warehouse_id)::int IS NULL OR b.warehouse_id = sqlc.narg(warehouse_id)::int)
+  AND (sqlc.narg(material_id)::int IS NULL OR b.material_id = sqlc.narg(material_id)::int)
+ORDER BY b.expiry_date, w.name, m.code, b.id;
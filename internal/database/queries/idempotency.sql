@@ -0,0 +1,54 @@
+-- =====================================================
+-- IDEMPOTENCY KEY QUERIES
+-- =====================================================
+
+-- Claims a key for a request, a key older than 24 hours or whose running
+-- request let its lease lapse is taken over. Returns no row when the key is
+-- already in use.
+-- name: ClaimIdempotencyKey :one
+INSERT INTO idempotency_keys (
+    user_id, idempotency_key, method, path, request_hash, lease_expires_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+)
+ON CONFLICT (user_id, idempotency_key) DO UPDATE
+SET method = EXCLUDED.method,
+    path = EXCLUDED.path,
+    request_hash = EXCLUDED.request_hash,
+    status_code = NULL,
+    response_body = NULL,
+    created_at = CURRENT_TIMESTAMP,
+    completed_at = NULL,
+    lease_expires_at = EXCLUDED.lease_expires_at
+WHERE idempotency_keys.created_at < CURRENT_TIMESTAMP - INTERVAL '24 hours'
+   OR idempotency_keys.lease_expires_at < CURRENT_TIMESTAMP
+RETURNING id;
+
+-- name: GetIdempotencyKey :one
+SELECT id, user_id, idempotency_key, method, path, request_hash,
+    status_code, response_body, created_at, completed_at, lease_expires_at
+FROM idempotency_keys
+WHERE user_id = $1 AND idempotency_key = $2;
+
+-- name: CompleteIdempotencyKey :exec
+UPDATE idempotency_keys
+SET status_code = $2,
+    response_body = $3,
+    completed_at = CURRENT_TIMESTAMP,
+    lease_expires_at = NULL
+WHERE id = $1;
+
+-- Keeps the claim of a request that committed without storing its response,
+-- the lease no longer lapses
+-- name: HoldIdempotencyKey :exec
+UPDATE idempotency_keys
+SET lease_expires_at = NULL
+WHERE id = $1;
+
+-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE id = $1;
+
+-- name: DeleteExpiredIdempotencyKeys :execrows
+DELETE FROM idempotency_keys
+WHERE created_at < CURRENT_TIMESTAMP - INTERVAL '24 hours';
@@ -7,7 +7,7 @@ INSERT INTO materials (
     is_toxic, is_flammable, is_fragile,
     image_url, document_url,
     tax_rate, discount_rate,
-    is_active, meta
+    is_active, meta, is_serialized, allocation_strategy
 ) VALUES (
     $1, $2, $3, $4, $5,
     $6, $7, $8, $9, $10, $11,
@@ -15,7 +15,7 @@ INSERT INTO materials (
     $16, $17, $18,
     $19, $20,
     $21, $22,
-    $23, $24
+    $23, $24, $25, $26
 )
 RETURNING id, name, description, valuation, type, saleable,
     unit_price, sale_price, category, code, sku, barcode,
@@ -23,7 +23,7 @@ RETURNING id, name, description, valuation, type, saleable,
     is_toxic, is_flammable, is_fragile,
     image_url, document_url,
     tax_rate, discount_rate,
-    is_active, archived, meta, created_at, updated_at;
+    is_active, archived, meta, created_at, updated_at, is_serialized, allocation_strategy;
 
 
 -- name: BatchCreateMaterials :copyfrom
@@ -55,7 +55,7 @@ SELECT
     m.image_url, m.document_url,
     m.tax_rate, m.discount_rate,
     m.is_active, m.archived, m.meta,
-    m.created_at, m.updated_at
+    m.created_at, m.updated_at, m.is_serialized, m.allocation_strategy
 FROM materials m
 LEFT JOIN material_categories mc ON m.category = mc.id
 LEFT JOIN measure_units mu ON m.measure_unit_id = mu.id
@@ -154,6 +154,8 @@ SET
     discount_rate = COALESCE($23, discount_rate),
     is_active = COALESCE($24, is_active),
     meta = COALESCE($25, meta),
+    is_serialized = COALESCE($26, is_serialized),
+    allocation_strategy = CASE WHEN $28::boolean THEN NULL ELSE COALESCE($27, allocation_strategy) END,
     updated_at = CURRENT_TIMESTAMP
 WHERE id = $1 AND archived = FALSE
 RETURNING id, name, description, valuation, type, saleable,
@@ -162,7 +164,7 @@ RETURNING id, name, description, valuation, type, saleable,
     is_toxic, is_flammable, is_fragile,
     image_url, document_url,
     tax_rate, discount_rate,
-    is_active, archived, meta, created_at, updated_at;
+    is_active, archived, meta, created_at, updated_at, is_serialized, allocation_strategy;
 
 
 -- name: ArchiveMaterial :exec
@@ -8,6 +8,12 @@ SELECT id, order_number, supplier_id, order_date, expected_delivery_date, status
 FROM purchase_orders
 WHERE id = $1;
 
+-- name: GetPurchaseOrderByIDForUpdate :one
+SELECT id, order_number, supplier_id, order_date, expected_delivery_date, status, total_amount, created_by, approved_by, meta, created_at, updated_at
+FROM purchase_orders
+WHERE id = $1
+FOR UPDATE;
+
 -- name: GetPurchaseOrderByOrderNumber :one
 SELECT id, order_number, supplier_id, order_date, expected_delivery_date, status, total_amount, created_by, approved_by, meta, created_at, updated_at
 FROM purchase_orders
@@ -113,3 +119,38 @@ SET
     updated_at = CURRENT_TIMESTAMP
 WHERE id = $1
 RETURNING id, purchase_order_id, material_id, quantity, unit_price, total_price, received_quantity, created_at, updated_at;
+
+-- name: UpdatePurchaseOrderStatus :one
+UPDATE purchase_orders
+SET
+    status = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, order_number, supplier_id, order_date, expected_delivery_date, status, total_amount, created_by, approved_by, meta, created_at, updated_at;
+
+-- name: ListPurchaseOrderReceipts :many
+SELECT
+    sm.id as movement_id,
+    sm.purchase_order_item_id,
+    sm.material_id,
+    sm.to_warehouse_id,
+    sm.quantity,
+    sm.unit_cost,
+    sm.total_cost,
+    sm.reference,
+    sm.movement_date,
+    sm.notes,
+    m.name as material_name,
+    w.name as warehouse_name,
+    b.id as batch_id,
+    b.batch_number,
+    u.username as performed_by_username
+FROM stock_movements sm
+JOIN purchase_order_items poi ON sm.purchase_order_item_id = poi.id
+LEFT JOIN materials m ON sm.material_id = m.id
+LEFT JOIN warehouses w ON sm.to_warehouse_id = w.id
+LEFT JOIN batches b ON b.movement_id = sm.id
+LEFT JOIN users u ON sm.performed_by = u.id
+WHERE poi.purchase_order_id = $1
+  AND sm.movement_type = 'PURCHASE_RECEIPT'
+ORDER BY sm.movement_date DESC, sm.id DESC;
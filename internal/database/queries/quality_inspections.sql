@@ -87,6 +87,11 @@ WHERE qi.id = $1;
 SELECT * FROM quality_inspections
 WHERE inspection_number = $1;
 
+-- name: GetQualityInspectionForUpdate :one
+SELECT * FROM quality_inspections
+WHERE id = $1
+FOR UPDATE;
+
 -- name: ListQualityInspections :many
 SELECT 
     qi.id, qi.inspection_number, qi.inspection_type, qi.inspection_status,
@@ -265,6 +270,24 @@ WHERE ncr.id = $1;
 SELECT * FROM non_conformance_reports
 WHERE ncr_number = $1;
 
+-- name: GetNonConformanceReportForUpdate :one
+SELECT * FROM non_conformance_reports
+WHERE id = $1
+FOR UPDATE;
+
+-- Quantity sent back to the supplier to settle a non-conformance report
+-- name: GetNcrReturnedQuantity :one
+SELECT COALESCE(SUM(quantity), 0)::DECIMAL(15, 4) as returned_quantity
+FROM stock_movements
+WHERE ncr_id = $1
+  AND movement_type = 'SUPPLIER_RETURN'
+  AND reversed_by_movement_id IS NULL;
+
+-- name: ListNonConformanceReportsByInspection :many
+SELECT * FROM non_conformance_reports
+WHERE inspection_id = $1
+ORDER BY reported_date;
+
 -- name: ListNonConformanceReports :many
 SELECT 
     ncr.id, ncr.ncr_number, ncr.title, ncr.ncr_type, ncr.severity, ncr.status,
@@ -417,6 +440,11 @@ SELECT * FROM quality_holds
 WHERE batch_number = $1
 ORDER BY placed_date DESC;
 
+-- name: ListActiveQualityHoldsByInspection :many
+SELECT * FROM quality_holds
+WHERE inspection_id = $1 AND is_released = FALSE
+ORDER BY id;
+
 -- name: ListQualityHoldsByStatus :many
 SELECT * FROM quality_holds
 WHERE quality_status = $1 AND is_released = FALSE
@@ -436,6 +464,16 @@ SET
 WHERE id = $1
 RETURNING *;
 
+-- The holds an inspection placed stay in force after it failed, with the
+-- decision and the report raised about it
+-- name: FailInspectionQualityHolds :exec
+UPDATE quality_holds
+SET
+    quality_status = $2,
+    ncr_id = COALESCE(sqlc.narg('ncr_id'), ncr_id),
+    updated_at = CURRENT_TIMESTAMP
+WHERE inspection_id = $1 AND is_released = FALSE;
+
 -- name: ReleaseQualityHold :one
 UPDATE quality_holds
 SET 
@@ -569,6 +607,14 @@ LEFT JOIN materials m ON mqs.material_id = m.id
 LEFT JOIN quality_inspection_criteria qic ON mqs.criteria_id = qic.id
 WHERE mqs.material_id = $1;
 
+-- Materials with required criteria of active templates are inspected on receipt
+-- name: CountRequiredMaterialQualitySpecs :one
+SELECT COUNT(*) FROM material_quality_specs mqs
+JOIN quality_inspection_criteria qic ON mqs.criteria_id = qic.id
+WHERE mqs.material_id = $1
+  AND COALESCE(mqs.is_required, TRUE)
+  AND COALESCE(qic.is_active, TRUE);
+
 -- name: UpdateMaterialQualitySpec :one
 UPDATE material_quality_specs
 SET 
@@ -0,0 +1,203 @@
+-- =====================================================
+-- PLANNING PARAMETERS QUERIES
+-- =====================================================
+
+-- name: UpsertPlanningParameters :one
+INSERT INTO material_planning_parameters (
+    material_id, warehouse_id, min_quantity, max_quantity, reorder_point,
+    reorder_quantity, preferred_supplier_id, is_active
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT (material_id, warehouse_id) DO UPDATE SET
+    min_quantity = EXCLUDED.min_quantity,
+    max_quantity = EXCLUDED.max_quantity,
+    reorder_point = EXCLUDED.reorder_point,
+    reorder_quantity = EXCLUDED.reorder_quantity,
+    preferred_supplier_id = EXCLUDED.preferred_supplier_id,
+    is_active = EXCLUDED.is_active,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING id, material_id, warehouse_id, min_quantity, max_quantity, reorder_point,
+    reorder_quantity, preferred_supplier_id, is_active, created_at, updated_at;
+
+-- name: ListPlanningParameters :many
+SELECT
+    pp.id,
+    pp.material_id,
+    m.code as material_code,
+    m.name as material_name,
+    pp.warehouse_id,
+    w.name as warehouse_name,
+    pp.min_quantity,
+    pp.max_quantity,
+    pp.reorder_point,
+    pp.reorder_quantity,
+    pp.preferred_supplier_id,
+    s.name as preferred_supplier_name,
+    pp.is_active,
+    pp.created_at,
+    pp.updated_at
+FROM material_planning_parameters pp
+JOIN materials m ON pp.material_id = m.id
+JOIN warehouses w ON pp.warehouse_id = w.id
+LEFT JOIN suppliers s ON pp.preferred_supplier_id = s.id
+WHERE (sqlc.narg(material_id)::int IS NULL OR pp.material_id = sqlc.narg(material_id)::int)
+  AND (sqlc.narg(warehouse_id)::int IS NULL OR pp.warehouse_id = sqlc.narg(warehouse_id)::int)
+ORDER BY w.name, m.code;
+
+-- name: DeletePlanningParameters :execrows
+DELETE FROM material_planning_parameters
+WHERE id = $1;
+
+-- Active planning parameters of active materials with the price to order at:
+-- the last price paid to the preferred supplier, else the last price paid to
+-- anyone, else the material price
+-- name: ListActivePlanningParameters :many
+SELECT
+    pp.id,
+    pp.material_id,
+    pp.warehouse_id,
+    pp.min_quantity,
+    pp.max_quantity,
+    pp.reorder_point,
+    pp.reorder_quantity,
+    pp.preferred_supplier_id,
+    COALESCE(supplier_price.unit_price, last_price.unit_price, m.unit_price)::DECIMAL(15, 4) as unit_price
+FROM material_planning_parameters pp
+JOIN materials m ON pp.material_id = m.id
+LEFT JOIN LATERAL (
+    SELECT poi.unit_price
+    FROM purchase_order_items poi
+    JOIN purchase_orders po ON poi.purchase_order_id = po.id
+    WHERE poi.material_id = pp.material_id
+      AND po.supplier_id = pp.preferred_supplier_id
+      AND po.status <> 'Cancelled'
+    ORDER BY po.order_date DESC, poi.id DESC
+    LIMIT 1
+) supplier_price ON TRUE
+LEFT JOIN LATERAL (
+    SELECT poi.unit_price
+    FROM purchase_order_items poi
+    JOIN purchase_orders po ON poi.purchase_order_id = po.id
+    WHERE poi.material_id = pp.material_id
+      AND po.status <> 'Cancelled'
+    ORDER BY po.order_date DESC, poi.id DESC
+    LIMIT 1
+) last_price ON TRUE
+WHERE pp.is_active = TRUE
+  AND m.is_active = TRUE
+ORDER BY pp.material_id, pp.warehouse_id;
+
+-- Quantities still to be received on open purchase orders. warehouse_id is
+-- the destination recorded in the order meta, 0 when the order has none.
+-- name: GetOpenPurchaseOrderQuantities :many
+SELECT
+    poi.material_id::int as material_id,
+    COALESCE(CASE
+        WHEN po.meta->>'warehouse_id' ~ '^[0-9]+$' THEN (po.meta->>'warehouse_id')::int
+    END, 0)::int as warehouse_id,
+    SUM(GREATEST(poi.quantity - COALESCE(poi.received_quantity, 0), 0))::DECIMAL(15, 4) as open_quantity
+FROM purchase_order_items poi
+JOIN purchase_orders po ON poi.purchase_order_id = po.id
+WHERE po.status NOT IN ('Received', 'Cancelled')
+  AND poi.material_id IS NOT NULL
+GROUP BY 1, 2
+HAVING SUM(GREATEST(poi.quantity - COALESCE(poi.received_quantity, 0), 0)) > 0;
+
+-- =====================================================
+-- REPLENISHMENT SUGGESTIONS QUERIES
+-- =====================================================
+
+-- name: UpsertOpenReplenishmentSuggestion :one
+INSERT INTO replenishment_suggestions (
+    material_id, warehouse_id, supplier_id, on_hand_quantity, on_order_quantity,
+    reorder_point, suggested_quantity, unit_price
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT (material_id, warehouse_id) WHERE status = 'Open' DO UPDATE SET
+    supplier_id = EXCLUDED.supplier_id,
+    on_hand_quantity = EXCLUDED.on_hand_quantity,
+    on_order_quantity = EXCLUDED.on_order_quantity,
+    reorder_point = EXCLUDED.reorder_point,
+    suggested_quantity = EXCLUDED.suggested_quantity,
+    unit_price = EXCLUDED.unit_price,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING id, material_id, warehouse_id, supplier_id, on_hand_quantity, on_order_quantity,
+    reorder_point, suggested_quantity, unit_price, status, purchase_order_id,
+    converted_by, converted_at, created_at, updated_at;
+
+-- Drops the open suggestions the last run did not confirm
+-- name: DeleteStaleReplenishmentSuggestions :execrows
+DELETE FROM replenishment_suggestions
+WHERE status = 'Open'
+  AND NOT (id = ANY(sqlc.arg(keep_ids)::int[]));
+
+-- name: ListReplenishmentSuggestions :many
+SELECT
+    rs.id,
+    rs.material_id,
+    m.code as material_code,
+    m.name as material_name,
+    mu.abbreviation as unit,
+    rs.warehouse_id,
+    w.name as warehouse_name,
+    rs.supplier_id,
+    s.name as supplier_name,
+    rs.on_hand_quantity,
+    rs.on_order_quantity,
+    rs.reorder_point,
+    rs.suggested_quantity,
+    rs.unit_price,
+    rs.status,
+    rs.purchase_order_id,
+    po.order_number,
+    rs.converted_by,
+    rs.converted_at,
+    rs.created_at,
+    rs.updated_at
+FROM replenishment_suggestions rs
+JOIN materials m ON rs.material_id = m.id
+JOIN warehouses w ON rs.warehouse_id = w.id
+LEFT JOIN measure_units mu ON m.measure_unit_id = mu.id
+LEFT JOIN suppliers s ON rs.supplier_id = s.id
+LEFT JOIN purchase_orders po ON rs.purchase_order_id = po.id
+WHERE (sqlc.narg(status)::text IS NULL OR rs.status = sqlc.narg(status)::text)
+  AND (sqlc.narg(warehouse_id)::int IS NULL OR rs.warehouse_id = sqlc.narg(warehouse_id)::int)
+  AND (sqlc.narg(supplier_id)::int IS NULL OR rs.supplier_id = sqlc.narg(supplier_id)::int)
+ORDER BY s.name NULLS LAST, w.name, m.code;
+
+-- name: GetReplenishmentSuggestionsForUpdate :many
+SELECT id, material_id, warehouse_id, supplier_id, on_hand_quantity, on_order_quantity,
+    reorder_point, suggested_quantity, unit_price, status, purchase_order_id,
+    converted_by, converted_at, created_at, updated_at
+FROM replenishment_suggestions
+WHERE id = ANY(sqlc.arg(ids)::int[])
+ORDER BY id
+FOR UPDATE;
+
+-- name: MarkReplenishmentSuggestionConverted :exec
+UPDATE replenishment_suggestions
+SET
+    status = 'Converted',
+    purchase_order_id = $2,
+    converted_by = $3,
+    converted_at = CURRENT_TIMESTAMP,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- Number of a purchase order converted from suggestions
+-- name: NextReplenishmentOrderNumber :one
+SELECT ('PO-RPL-' || TO_CHAR(CURRENT_DATE, 'YYYY') || '-'
+    || LPAD(nextval('replenishment_order_number_seq')::TEXT, 6, '0'))::TEXT AS order_number;
+
+-- name: DismissReplenishmentSuggestion :one
+UPDATE replenishment_suggestions
+SET
+    status = 'Dismissed',
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+  AND status = 'Open'
+RETURNING id, material_id, warehouse_id, supplier_id, on_hand_quantity, on_order_quantity,
+    reorder_point, suggested_quantity, unit_price, status, purchase_order_id,
+    converted_by, converted_at, created_at, updated_at;
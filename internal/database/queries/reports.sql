@@ -0,0 +1,100 @@
+-- =====================================================
+-- REPORT QUERIES
+-- =====================================================
+
+-- On-hand stock per material and warehouse with everything needed to value
+-- it by its valuation method: the batch layers at their cost and the
+-- weighted-average cost. With as_of the stock and average cost are rebuilt
+-- from the movement history at that moment.
+-- name: GetInventoryValuation :many
+WITH later_changes AS (
+    SELECT
+        smb.batch_id,
+        SUM(CASE WHEN sm.stock_direction = 'IN' THEN smb.quantity ELSE -smb.quantity END) AS quantity_change
+    FROM stock_movement_batches smb
+    JOIN stock_movements sm ON sm.id = smb.movement_id
+    WHERE sqlc.narg(as_of)::timestamptz IS NOT NULL
+      AND sm.movement_date > sqlc.narg(as_of)::timestamptz
+    GROUP BY smb.batch_id
+),
+batch_stock AS (
+    SELECT
+        b.material_id,
+        b.warehouse_id,
+        b.current_quantity - COALESCE(lc.quantity_change, 0) AS quantity,
+        (b.current_quantity - COALESCE(lc.quantity_change, 0)) * COALESCE(b.unit_price, 0) AS value
+    FROM batches b
+    LEFT JOIN later_changes lc ON lc.batch_id = b.id
+    WHERE b.current_quantity - COALESCE(lc.quantity_change, 0) > 0
+)
+SELECT
+    m.id as material_id,
+    m.code as material_code,
+    m.name as material_name,
+    mc.id as category_id,
+    mc.name as category_name,
+    w.id as warehouse_id,
+    w.code as warehouse_code,
+    w.name as warehouse_name,
+    COALESCE(m.valuation, w.valuation) as valuation_method,
+    mu.abbreviation as unit,
+    SUM(bs.quantity)::DECIMAL(15, 4) as quantity,
+    COUNT(*) as batch_count,
+    SUM(bs.value)::DECIMAL(15, 4) as batch_value,
+    (CASE
+        WHEN sqlc.narg(as_of)::timestamptz IS NULL THEN mac.average_unit_cost
+        ELSE ledger.average_unit_cost
+    END)::DECIMAL(15, 4) as average_unit_cost
+FROM batch_stock bs
+JOIN materials m ON bs.material_id = m.id
+JOIN warehouses w ON bs.warehouse_id = w.id
+LEFT JOIN material_categories mc ON m.category = mc.id
+LEFT JOIN measure_units mu ON m.measure_unit_id = mu.id
+LEFT JOIN material_average_costs mac ON mac.material_id = m.id AND mac.warehouse_id = w.id
+LEFT JOIN LATERAL (
+    SELECT cl.average_unit_cost
+    FROM material_cost_ledger cl
+    JOIN stock_movements sm ON sm.id = cl.movement_id
+    WHERE cl.material_id = m.id
+      AND cl.warehouse_id = w.id
+      AND sm.movement_date <= sqlc.narg(as_of)::timestamptz
+    ORDER BY sm.movement_date DESC, cl.id DESC
+    LIMIT 1
+) ledger ON TRUE
+WHERE (sqlc.narg(warehouse_id)::int IS NULL OR w.id = sqlc.narg(warehouse_id)::int)
+  AND (sqlc.narg(category_id)::int IS NULL OR m.category = sqlc.narg(category_id)::int)
+GROUP BY m.id, m.code, m.name, mc.id, mc.name, w.id, w.code, w.name, mu.abbreviation,
+    mac.average_unit_cost, ledger.average_unit_cost
+ORDER BY mc.name NULLS LAST, m.code, w.name;
+
+-- Stock shipped to a warehouse and not received yet, per material and
+-- destination warehouse, at the cost it left the source with. With as_of
+-- the shipments that were in transit at that moment.
+-- name: GetInTransitValuation :many
+SELECT
+    m.id as material_id,
+    m.code as material_code,
+    m.name as material_name,
+    mc.id as category_id,
+    mc.name as category_name,
+    w.id as warehouse_id,
+    w.code as warehouse_code,
+    w.name as warehouse_name,
+    mu.abbreviation as unit,
+    COUNT(*) as shipment_count,
+    SUM(ts.quantity)::DECIMAL(15, 4) as quantity,
+    SUM(ts.quantity * COALESCE(ts.unit_cost, 0))::DECIMAL(15, 4) as value
+FROM transfer_shipments ts
+JOIN materials m ON ts.material_id = m.id
+JOIN warehouses w ON ts.to_warehouse_id = w.id
+LEFT JOIN material_categories mc ON m.category = mc.id
+LEFT JOIN measure_units mu ON m.measure_unit_id = mu.id
+WHERE (
+        (sqlc.narg(as_of)::timestamptz IS NULL AND ts.status = 'In Transit')
+        OR (ts.shipped_at <= sqlc.narg(as_of)::timestamptz
+            AND (ts.received_at IS NULL OR ts.received_at > sqlc.narg(as_of)::timestamptz))
+    )
+  AND (sqlc.narg(warehouse_id)::int IS NULL OR w.id = sqlc.narg(warehouse_id)::int)
+  AND (sqlc.narg(category_id)::int IS NULL OR m.category = sqlc.narg(category_id)::int)
+GROUP BY m.id, m.code, m.name, mc.id, mc.name, w.id, w.code, w.name, mu.abbreviation
+ORDER BY mc.name NULLS LAST, m.code, w.name;
@@ -0,0 +1,123 @@
+-- =====================================================
+-- STOCK RESERVATION QUERIES
+-- =====================================================
+
+-- name: CreateStockReservation :one
+INSERT INTO stock_reservations (
+    sales_order_item_id, material_id, warehouse_id, batch_id,
+    quantity, reserved_by, notes
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING id, sales_order_item_id, material_id, warehouse_id, batch_id,
+    quantity, fulfilled_quantity, status, reserved_by, released_at, notes,
+    created_at, updated_at;
+
+-- name: GetStockReservationByIDForUpdate :one
+SELECT id, sales_order_item_id, material_id, warehouse_id, batch_id,
+    quantity, fulfilled_quantity, status, reserved_by, released_at, notes,
+    created_at, updated_at
+FROM stock_reservations
+WHERE id = $1
+FOR UPDATE;
+
+-- name: ListStockReservationsBySalesOrder :many
+SELECT
+    sr.id,
+    sr.sales_order_item_id,
+    sr.material_id,
+    sr.warehouse_id,
+    sr.batch_id,
+    sr.quantity,
+    sr.fulfilled_quantity,
+    sr.status,
+    sr.reserved_by,
+    sr.released_at,
+    sr.notes,
+    sr.created_at,
+    m.name as material_name,
+    w.name as warehouse_name,
+    b.batch_number,
+    u.username as reserved_by_username
+FROM stock_reservations sr
+JOIN sales_order_items soi ON sr.sales_order_item_id = soi.id
+JOIN materials m ON sr.material_id = m.id
+JOIN warehouses w ON sr.warehouse_id = w.id
+LEFT JOIN batches b ON sr.batch_id = b.id
+LEFT JOIN users u ON sr.reserved_by = u.id
+WHERE soi.sales_order_id = $1
+ORDER BY sr.created_at DESC;
+
+-- name: ListActiveStockReservations :many
+SELECT id, sales_order_item_id, material_id, warehouse_id, batch_id,
+    quantity, fulfilled_quantity, status, reserved_by, released_at, notes,
+    created_at, updated_at
+FROM stock_reservations
+WHERE material_id = $1
+  AND warehouse_id = $2
+  AND status = 'Active'
+ORDER BY created_at ASC;
+
+-- name: ListActiveLineReservationsForUpdate :many
+SELECT id, sales_order_item_id, material_id, warehouse_id, batch_id,
+    quantity, fulfilled_quantity, status, reserved_by, released_at, notes,
+    created_at, updated_at
+FROM stock_reservations
+WHERE sales_order_item_id = $1
+  AND warehouse_id = $2
+  AND status = 'Active'
+ORDER BY created_at ASC
+FOR UPDATE;
+
+-- name: UpdateStockReservationFulfilment :one
+UPDATE stock_reservations
+SET fulfilled_quantity = $2,
+    status = $3,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, sales_order_item_id, material_id, warehouse_id, batch_id,
+    quantity, fulfilled_quantity, status, reserved_by, released_at, notes,
+    created_at, updated_at;
+
+-- name: ReleaseStockReservation :one
+UPDATE stock_reservations
+SET status = 'Released',
+    released_at = CURRENT_TIMESTAMP,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, sales_order_item_id, material_id, warehouse_id, batch_id,
+    quantity, fulfilled_quantity, status, reserved_by, released_at, notes,
+    created_at, updated_at;
+
+-- name: ReleaseSalesOrderItemReservations :execrows
+UPDATE stock_reservations
+SET status = 'Released',
+    released_at = CURRENT_TIMESTAMP,
+    updated_at = CURRENT_TIMESTAMP
+WHERE sales_order_item_id = $1
+  AND status = 'Active';
+
+-- name: ReleaseSalesOrderReservations :execrows
+UPDATE stock_reservations
+SET status = 'Released',
+    released_at = CURRENT_TIMESTAMP,
+    updated_at = CURRENT_TIMESTAMP
+WHERE status = 'Active'
+  AND sales_order_item_id IN (
+      SELECT id FROM sales_order_items WHERE sales_order_id = $1
+  );
+
+-- name: GetLineReservedQuantity :one
+SELECT COALESCE(SUM(quantity - fulfilled_quantity), 0)::DECIMAL(15, 4) as reserved_quantity
+FROM stock_reservations
+WHERE sales_order_item_id = $1
+  AND status = 'Active';
+
+-- Serialises reservations and stock-outs of a material in a warehouse, rows
+-- are locked in id order so concurrent stock-outs cannot deadlock
+-- name: LockMaterialBatches :exec
+SELECT id FROM batches
+WHERE material_id = $1
+  AND warehouse_id = $2
+ORDER BY id
+FOR UPDATE;
@@ -8,6 +8,12 @@ SELECT id, order_number, customer_id, order_date, expected_delivery_date, status
 FROM sales_orders
 WHERE id = $1;
 
+-- name: GetSalesOrderByIDForUpdate :one
+SELECT id, order_number, customer_id, order_date, expected_delivery_date, status, total_amount, created_by, approved_by, meta, created_at, updated_at
+FROM sales_orders
+WHERE id = $1
+FOR UPDATE;
+
 -- name: GetSalesOrderByOrderNumber :one
 SELECT id, order_number, customer_id, order_date, expected_delivery_date, status, total_amount, created_by, approved_by, meta, created_at, updated_at
 FROM sales_orders
@@ -113,3 +119,11 @@ SET
     updated_at = CURRENT_TIMESTAMP
 WHERE id = $1
 RETURNING id, sales_order_id, material_id, quantity, unit_price, total_price, shipped_quantity, created_at, updated_at;
+
+-- name: UpdateSalesOrderStatus :one
+UPDATE sales_orders
+SET
+    status = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, order_number, customer_id, order_date, expected_delivery_date, status, total_amount, created_by, approved_by, meta, created_at, updated_at;
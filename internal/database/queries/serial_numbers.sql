@@ -0,0 +1,145 @@
+-- =====================================================
+-- SERIAL NUMBER QUERIES
+-- =====================================================
+
+-- The serialization of a material cannot change while it has stock
+-- name: CheckMaterialHasStock :one
+SELECT EXISTS (
+    SELECT 1 FROM batches WHERE material_id = $1 AND current_quantity > 0
+) OR EXISTS (
+    SELECT 1 FROM transfer_shipments WHERE material_id = $1 AND status = 'In Transit'
+) AS has_stock;
+
+-- name: GetSerialNumbersForUpdate :many
+SELECT id, material_id, serial_number, batch_id, warehouse_id, status, created_at, updated_at
+FROM serial_numbers
+WHERE material_id = sqlc.arg(material_id)
+  AND serial_number = ANY(sqlc.arg(serial_numbers)::text[])
+ORDER BY serial_number
+FOR UPDATE;
+
+-- The units in stock in a batch
+-- name: ListBatchSerialNumbersForUpdate :many
+SELECT id, material_id, serial_number, batch_id, warehouse_id, status, created_at, updated_at
+FROM serial_numbers
+WHERE batch_id = $1
+  AND status = 'In Stock'
+ORDER BY serial_number
+FOR UPDATE;
+
+-- Registers a received unit. A serial number seen before is taken back into
+-- stock, unless it is still in stock or in transit, then no row is returned.
+-- name: RegisterSerialNumber :one
+INSERT INTO serial_numbers (material_id, serial_number, batch_id, warehouse_id, status)
+VALUES ($1, $2, $3, $4, 'In Stock')
+ON CONFLICT (material_id, serial_number) DO UPDATE
+SET
+    batch_id = EXCLUDED.batch_id,
+    warehouse_id = EXCLUDED.warehouse_id,
+    status = 'In Stock',
+    updated_at = CURRENT_TIMESTAMP
+WHERE serial_numbers.status NOT IN ('In Stock', 'In Transit')
+RETURNING id, material_id, serial_number, batch_id, warehouse_id, status, created_at, updated_at;
+
+-- name: UpdateSerialNumber :one
+UPDATE serial_numbers
+SET
+    batch_id = $2,
+    warehouse_id = $3,
+    status = $4,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, material_id, serial_number, batch_id, warehouse_id, status, created_at, updated_at;
+
+-- name: CreateStockMovementSerial :exec
+INSERT INTO stock_movement_serials (movement_id, serial_number_id, batch_id)
+VALUES ($1, $2, $3);
+
+-- The serial numbers moved by movements with the batch they left or entered
+-- name: ListMovementSerials :many
+SELECT
+    sms.movement_id,
+    sms.serial_number_id,
+    sn.serial_number,
+    sms.batch_id
+FROM stock_movement_serials sms
+JOIN serial_numbers sn ON sms.serial_number_id = sn.id
+WHERE sms.movement_id = ANY(sqlc.arg(movement_ids)::int[])
+ORDER BY sms.movement_id, sn.serial_number;
+
+-- name: ListSerialNumbers :many
+SELECT
+    sn.id,
+    sn.material_id,
+    m.code as material_code,
+    m.name as material_name,
+    sn.serial_number,
+    sn.batch_id,
+    b.batch_number,
+    sn.warehouse_id,
+    w.name as warehouse_name,
+    sn.status,
+    sn.created_at,
+    sn.updated_at
+FROM serial_numbers sn
+JOIN materials m ON sn.material_id = m.id
+LEFT JOIN batches b ON sn.batch_id = b.id
+LEFT JOIN warehouses w ON sn.warehouse_id = w.id
+WHERE (sqlc.narg(material_id)::int IS NULL OR sn.material_id = sqlc.narg(material_id)::int)
+  AND (sqlc.narg(warehouse_id)::int IS NULL OR sn.warehouse_id = sqlc.narg(warehouse_id)::int)
+  AND (sqlc.narg(batch_id)::int IS NULL OR sn.batch_id = sqlc.narg(batch_id)::int)
+  AND (sqlc.narg(status)::text IS NULL OR sn.status = sqlc.narg(status)::text)
+  AND (sqlc.narg(search)::text IS NULL OR sn.serial_number ILIKE '%' || sqlc.narg(search)::text || '%')
+ORDER BY m.code, sn.serial_number
+LIMIT $1 OFFSET $2;
+
+-- A serial number may be used by more than one material
+-- name: GetSerialNumbersByNumber :many
+SELECT
+    sn.id,
+    sn.material_id,
+    m.code as material_code,
+    m.name as material_name,
+    sn.serial_number,
+    sn.batch_id,
+    b.batch_number,
+    sn.warehouse_id,
+    w.name as warehouse_name,
+    sn.status,
+    sn.created_at,
+    sn.updated_at
+FROM serial_numbers sn
+JOIN materials m ON sn.material_id = m.id
+LEFT JOIN batches b ON sn.batch_id = b.id
+LEFT JOIN warehouses w ON sn.warehouse_id = w.id
+WHERE sn.serial_number = sqlc.arg(serial_number)
+  AND (sqlc.narg(material_id)::int IS NULL OR sn.material_id = sqlc.narg(material_id)::int)
+ORDER BY m.code;
+
+-- The movements of a serial number, oldest first
+-- name: GetSerialNumberHistory :many
+SELECT
+    sm.id as movement_id,
+    sm.movement_type,
+    sm.stock_direction,
+    sm.from_warehouse_id,
+    fw.name as from_warehouse_name,
+    sm.to_warehouse_id,
+    tw.name as to_warehouse_name,
+    sms.batch_id,
+    b.batch_number,
+    sm.reference,
+    sm.transfer_shipment_id,
+    sm.sales_order_item_id,
+    sm.purchase_order_item_id,
+    sm.performed_by,
+    u.username as performed_by_name,
+    sm.movement_date
+FROM stock_movement_serials sms
+JOIN stock_movements sm ON sms.movement_id = sm.id
+LEFT JOIN warehouses fw ON sm.from_warehouse_id = fw.id
+LEFT JOIN warehouses tw ON sm.to_warehouse_id = tw.id
+LEFT JOIN batches b ON sms.batch_id = b.id
+LEFT JOIN users u ON sm.performed_by = u.id
+WHERE sms.serial_number_id = $1
+ORDER BY sm.movement_date, sm.id;
@@ -0,0 +1,132 @@
+-- =====================================================
+-- POINT IN TIME STOCK QUERIES
+-- =====================================================
+-- Stock at a past moment is rebuilt from the current batch quantities by
+-- undoing the batch lines of every movement dated after that moment. A
+-- batch created later comes out at zero and is left out.
+
+-- name: GetBatchStockAsOf :many
+WITH later_changes AS (
+    SELECT
+        smb.batch_id,
+        SUM(CASE WHEN sm.stock_direction = 'IN' THEN smb.quantity ELSE -smb.quantity END) AS quantity_change
+    FROM stock_movement_batches smb
+    JOIN stock_movements sm ON sm.id = smb.movement_id
+    WHERE sm.movement_date > sqlc.arg(as_of)::timestamptz
+    GROUP BY smb.batch_id
+)
+SELECT
+    b.id,
+    b.batch_number,
+    b.material_id,
+    m.code as material_code,
+    m.name as material_name,
+    b.warehouse_id,
+    w.name as warehouse_name,
+    wb.code as bin_code,
+    b.unit_price,
+    b.manufacture_date,
+    b.expiry_date,
+    (b.current_quantity - COALESCE(lc.quantity_change, 0))::DECIMAL(15, 4) as quantity,
+    ((b.current_quantity - COALESCE(lc.quantity_change, 0)) * COALESCE(b.unit_price, 0))::DECIMAL(15, 4) as value
+FROM batches b
+JOIN materials m ON b.material_id = m.id
+JOIN warehouses w ON b.warehouse_id = w.id
+LEFT JOIN warehouse_bins wb ON b.bin_id = wb.id
+LEFT JOIN later_changes lc ON lc.batch_id = b.id
+WHERE b.current_quantity - COALESCE(lc.quantity_change, 0) <> 0
+  AND (sqlc.narg(material_id)::int IS NULL OR b.material_id = sqlc.narg(material_id)::int)
+  AND (sqlc.narg(warehouse_id)::int IS NULL OR b.warehouse_id = sqlc.narg(warehouse_id)::int)
+ORDER BY w.name, m.code, b.created_at, b.id;
+
+-- Stock shipped to a warehouse and not received yet at a past moment, per
+-- material and destination warehouse, at the cost it left the source with.
+-- The TRANSFER_OUT movement took it out of the source batches and it joins a
+-- destination batch only on receipt, so the batch rebuild has it nowhere.
+-- name: GetInTransitAsOf :many
+SELECT
+    m.id as material_id,
+    m.code as material_code,
+    m.name as material_name,
+    w.id as warehouse_id,
+    w.name as warehouse_name,
+    COUNT(*) as shipment_count,
+    SUM(ts.quantity)::DECIMAL(15, 4) as quantity,
+    SUM(ts.quantity * COALESCE(ts.unit_cost, 0))::DECIMAL(15, 4) as value
+FROM transfer_shipments ts
+JOIN materials m ON ts.material_id = m.id
+JOIN warehouses w ON ts.to_warehouse_id = w.id
+WHERE ts.shipped_at <= sqlc.arg(as_of)::timestamptz
+  AND (ts.received_at IS NULL OR ts.received_at > sqlc.arg(as_of)::timestamptz)
+  AND (sqlc.narg(material_id)::int IS NULL OR ts.material_id = sqlc.narg(material_id)::int)
+  AND (sqlc.narg(warehouse_id)::int IS NULL OR ts.to_warehouse_id = sqlc.narg(warehouse_id)::int)
+GROUP BY m.id, m.code, m.name, w.id, w.name
+ORDER BY w.name, m.code;
+
+-- The moment batch level stock history starts. Migration 010 backfilled
+-- batch lines only for movements that created a batch, so stock before the
+-- first batch line cannot be rebuilt. No row when no movement predates it.
+-- name: GetStockHistoryStart :one
+WITH first_line AS (
+    SELECT MIN(created_at) AS created_at
+    FROM stock_movement_batches
+)
+SELECT fl.created_at::timestamptz AS history_start
+FROM first_line fl
+WHERE EXISTS (
+    SELECT 1 FROM stock_movements sm
+    WHERE sm.movement_date < fl.created_at
+);
+
+-- Stock per material and warehouse at a past moment. batch_value is the
+-- quantity at batch cost, ledger_value the weighted-average value of the
+-- cost ledger entry of the last movement up to that moment (NULL when no
+-- movement was costed yet).
+-- name: GetStockLevelsAsOf :many
+WITH later_changes AS (
+    SELECT
+        smb.batch_id,
+        SUM(CASE WHEN sm.stock_direction = 'IN' THEN smb.quantity ELSE -smb.quantity END) AS quantity_change
+    FROM stock_movement_batches smb
+    JOIN stock_movements sm ON sm.id = smb.movement_id
+    WHERE sm.movement_date > sqlc.arg(as_of)::timestamptz
+    GROUP BY smb.batch_id
+),
+batch_stock AS (
+    SELECT
+        b.material_id,
+        b.warehouse_id,
+        b.current_quantity - COALESCE(lc.quantity_change, 0) AS quantity,
+        (b.current_quantity - COALESCE(lc.quantity_change, 0)) * COALESCE(b.unit_price, 0) AS value
+    FROM batches b
+    LEFT JOIN later_changes lc ON lc.batch_id = b.id
+    WHERE b.current_quantity - COALESCE(lc.quantity_change, 0) <> 0
+      AND (sqlc.narg(material_id)::int IS NULL OR b.material_id = sqlc.narg(material_id)::int)
+      AND (sqlc.narg(warehouse_id)::int IS NULL OR b.warehouse_id = sqlc.narg(warehouse_id)::int)
+)
+SELECT
+    m.id as material_id,
+    m.code as material_code,
+    m.name as material_name,
+    w.id as warehouse_id,
+    w.name as warehouse_name,
+    SUM(bs.quantity)::DECIMAL(15, 4) as total_quantity,
+    COUNT(*) as batch_count,
+    SUM(bs.value)::DECIMAL(15, 4) as batch_value,
+    ledger.total_value as ledger_value,
+    ledger.average_unit_cost
+FROM batch_stock bs
+JOIN materials m ON bs.material_id = m.id
+JOIN warehouses w ON bs.warehouse_id = w.id
+LEFT JOIN LATERAL (
+    SELECT cl.total_value, cl.average_unit_cost
+    FROM material_cost_ledger cl
+    JOIN stock_movements sm ON sm.id = cl.movement_id
+    WHERE cl.material_id = m.id
+      AND cl.warehouse_id = w.id
+      AND sm.movement_date <= sqlc.arg(as_of)::timestamptz
+    ORDER BY sm.movement_date DESC, cl.id DESC
+    LIMIT 1
+) ledger ON TRUE
+GROUP BY m.id, m.code, m.name, w.id, w.name, ledger.total_value, ledger.average_unit_cost
+ORDER BY w.name, m.code;
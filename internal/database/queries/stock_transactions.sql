@@ -22,15 +22,15 @@ SELECT EXISTS(
 INSERT INTO batches (
     material_id, supplier_id, warehouse_id, movement_id,
     unit_price, batch_number, manufacture_date, expiry_date,
-    start_quantity, current_quantity, notes, meta
+    start_quantity, current_quantity, notes, meta, bin_id
 ) VALUES (
     $1, $2, $3, $4,
     $5, $6, $7, $8,
-    $9, $10, $11, $12
+    $9, $10, $11, $12, $13
 )
 RETURNING id, material_id, supplier_id, warehouse_id, movement_id,
     unit_price, batch_number, manufacture_date, expiry_date,
-    start_quantity, current_quantity, notes, meta, created_at, updated_at;
+    start_quantity, current_quantity, notes, meta, created_at, updated_at, bin_id;
 
 -- name: UpdateBatchQuantity :one
 UPDATE batches
@@ -39,41 +39,71 @@ SET current_quantity = current_quantity + $2,
 WHERE id = $1
 RETURNING id, material_id, supplier_id, warehouse_id, movement_id,
     unit_price, batch_number, manufacture_date, expiry_date,
-    start_quantity, current_quantity, notes, meta, created_at, updated_at;
+    start_quantity, current_quantity, notes, meta, created_at, updated_at, bin_id;
 
 -- name: GetBatchesByWarehouseAndMaterial :many
 SELECT id, material_id, supplier_id, warehouse_id, movement_id,
     unit_price, batch_number, manufacture_date, expiry_date,
-    start_quantity, current_quantity, notes, meta, created_at, updated_at
+    start_quantity, current_quantity, notes, meta, created_at, updated_at, bin_id
 FROM batches
 WHERE warehouse_id = $1
   AND material_id = $2
   AND current_quantity > 0
-ORDER BY created_at ASC;
+  AND NOT EXISTS (
+      SELECT 1 FROM v_batches_on_hold h WHERE h.batch_id = batches.id
+  )
+ORDER BY created_at ASC
+FOR UPDATE OF batches;
 
 -- name: GetBatchesByWarehouseAndMaterialLIFO :many
 SELECT id, material_id, supplier_id, warehouse_id, movement_id,
     unit_price, batch_number, manufacture_date, expiry_date,
-    start_quantity, current_quantity, notes, meta, created_at, updated_at
+    start_quantity, current_quantity, notes, meta, created_at, updated_at, bin_id
 FROM batches
 WHERE warehouse_id = $1
   AND material_id = $2
   AND current_quantity > 0
-ORDER BY created_at DESC;
+  AND NOT EXISTS (
+      SELECT 1 FROM v_batches_on_hold h WHERE h.batch_id = batches.id
+  )
+ORDER BY created_at DESC
+FOR UPDATE OF batches;
+
+-- name: GetBatchesByWarehouseAndMaterialFEFO :many
+SELECT id, material_id, supplier_id, warehouse_id, movement_id,
+    unit_price, batch_number, manufacture_date, expiry_date,
+    start_quantity, current_quantity, notes, meta, created_at, updated_at, bin_id
+FROM batches
+WHERE warehouse_id = $1
+  AND material_id = $2
+  AND current_quantity > 0
+  AND (expiry_date IS NULL OR expiry_date >= CURRENT_DATE)
+  AND NOT EXISTS (
+      SELECT 1 FROM v_batches_on_hold h WHERE h.batch_id = batches.id
+  )
+ORDER BY expiry_date ASC NULLS LAST, created_at ASC
+FOR UPDATE OF batches;
 
 -- name: GetBatchByID :one
 SELECT id, material_id, supplier_id, warehouse_id, movement_id,
     unit_price, batch_number, manufacture_date, expiry_date,
-    start_quantity, current_quantity, notes, meta, created_at, updated_at
+    start_quantity, current_quantity, notes, meta, created_at, updated_at, bin_id
 FROM batches
 WHERE id = $1;
 
 -- name: GetBatchesByIDs :many
 SELECT id, material_id, supplier_id, warehouse_id, movement_id,
     unit_price, batch_number, manufacture_date, expiry_date,
-    start_quantity, current_quantity, notes, meta, created_at, updated_at
+    start_quantity, current_quantity, notes, meta, created_at, updated_at, bin_id
 FROM batches
-WHERE id = ANY($1::int[]);
+WHERE id = ANY($1::int[])
+ORDER BY id
+FOR UPDATE;
+
+-- name: GetBatchHoldsByIDs :many
+SELECT batch_id, batch_number, hold_number, quality_status, hold_reason
+FROM v_batches_on_hold
+WHERE batch_id = ANY($1::int[]);
 
 -- =====================================================
 -- STOCK MOVEMENT QUERIES
@@ -83,27 +113,54 @@ WHERE id = ANY($1::int[]);
 INSERT INTO stock_movements (
     material_id, from_warehouse_id, to_warehouse_id,
     quantity, stock_direction, movement_type,
-    reference, performed_by, movement_date, notes
+    reference, performed_by, movement_date, notes,
+    unit_cost, total_cost, sales_order_item_id, purchase_order_item_id, reverses_movement_id, reversed_by_movement_id, from_bin_id, to_bin_id, entered_quantity, entered_unit_id, work_order_id, transfer_shipment_id, ncr_id, paired_movement_id
 ) VALUES (
     $1, $2, $3,
     $4, $5, $6,
-    $7, $8, $9, $10
+    $7, $8, $9, $10,
+    $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24
 )
 RETURNING id, material_id, from_warehouse_id, to_warehouse_id,
     quantity, stock_direction, movement_type,
-    reference, performed_by, movement_date, notes, created_at, updated_at;
+    reference, performed_by, movement_date, notes, created_at, updated_at,
+    unit_cost, total_cost, sales_order_item_id, purchase_order_item_id, reverses_movement_id, reversed_by_movement_id, from_bin_id, to_bin_id, entered_quantity, entered_unit_id, work_order_id, transfer_shipment_id, ncr_id, paired_movement_id;
 
 -- name: GetStockMovementByID :one
 SELECT id, material_id, from_warehouse_id, to_warehouse_id,
     quantity, stock_direction, movement_type,
-    reference, performed_by, movement_date, notes, created_at, updated_at
+    reference, performed_by, movement_date, notes, created_at, updated_at,
+    unit_cost, total_cost, sales_order_item_id, purchase_order_item_id, reverses_movement_id, reversed_by_movement_id, from_bin_id, to_bin_id, entered_quantity, entered_unit_id, work_order_id, transfer_shipment_id, ncr_id, paired_movement_id
 FROM stock_movements
 WHERE id = $1;
 
+-- name: GetStockMovementByIDForUpdate :one
+SELECT id, material_id, from_warehouse_id, to_warehouse_id,
+    quantity, stock_direction, movement_type,
+    reference, performed_by, movement_date, notes, created_at, updated_at,
+    unit_cost, total_cost, sales_order_item_id, purchase_order_item_id, reverses_movement_id, reversed_by_movement_id, from_bin_id, to_bin_id, entered_quantity, entered_unit_id, work_order_id, transfer_shipment_id, ncr_id, paired_movement_id
+FROM stock_movements
+WHERE id = $1
+FOR UPDATE;
+
+-- name: MarkStockMovementReversed :exec
+UPDATE stock_movements
+SET reversed_by_movement_id = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- Links the two legs of a one-step transfer
+-- name: PairStockMovement :exec
+UPDATE stock_movements
+SET paired_movement_id = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
 -- name: GetStockMovementsByReference :many
 SELECT id, material_id, from_warehouse_id, to_warehouse_id,
     quantity, stock_direction, movement_type,
-    reference, performed_by, movement_date, notes, created_at, updated_at
+    reference, performed_by, movement_date, notes, created_at, updated_at,
+    unit_cost, total_cost, sales_order_item_id, purchase_order_item_id, reverses_movement_id, reversed_by_movement_id, from_bin_id, to_bin_id, entered_quantity, entered_unit_id, work_order_id, transfer_shipment_id, ncr_id, paired_movement_id
 FROM stock_movements
 WHERE reference = $1
 ORDER BY movement_date DESC;
@@ -112,7 +169,7 @@ ORDER BY movement_date DESC;
 SELECT sm.id, sm.material_id, sm.from_warehouse_id, sm.to_warehouse_id,
     sm.quantity, sm.stock_direction, sm.movement_type,
     sm.reference, sm.performed_by, sm.movement_date, sm.notes,
-    sm.created_at, sm.updated_at,
+    sm.created_at, sm.updated_at, sm.unit_cost, sm.total_cost, sm.sales_order_item_id, sm.purchase_order_item_id, sm.reverses_movement_id, sm.reversed_by_movement_id, sm.from_bin_id, sm.to_bin_id, sm.entered_quantity, sm.entered_unit_id, sm.work_order_id, sm.transfer_shipment_id, sm.ncr_id, sm.paired_movement_id,
     m.name as material_name,
     u.username as performed_by_username
 FROM stock_movements sm
@@ -126,7 +183,7 @@ LIMIT $2 OFFSET $3;
 SELECT sm.id, sm.material_id, sm.from_warehouse_id, sm.to_warehouse_id,
     sm.quantity, sm.stock_direction, sm.movement_type,
     sm.reference, sm.performed_by, sm.movement_date, sm.notes,
-    sm.created_at, sm.updated_at,
+    sm.created_at, sm.updated_at, sm.unit_cost, sm.total_cost, sm.sales_order_item_id, sm.purchase_order_item_id, sm.reverses_movement_id, sm.reversed_by_movement_id, sm.from_bin_id, sm.to_bin_id, sm.entered_quantity, sm.entered_unit_id, sm.work_order_id, sm.transfer_shipment_id, sm.ncr_id, sm.paired_movement_id,
     m.name as material_name,
     u.username as performed_by_username
 FROM stock_movements sm
@@ -136,6 +193,34 @@ WHERE (sm.from_warehouse_id = $1 OR sm.to_warehouse_id = $1)
 ORDER BY sm.movement_date DESC
 LIMIT $2 OFFSET $3;
 
+-- =====================================================
+-- MOVEMENT BATCH LINE QUERIES
+-- =====================================================
+
+-- name: CreateStockMovementBatch :one
+INSERT INTO stock_movement_batches (
+    movement_id, batch_id, quantity, unit_cost
+) VALUES (
+    $1, $2, $3, $4
+)
+RETURNING id, movement_id, batch_id, quantity, unit_cost, created_at;
+
+-- name: GetStockMovementBatches :many
+SELECT
+    smb.id,
+    smb.movement_id,
+    smb.batch_id,
+    smb.quantity,
+    smb.unit_cost,
+    smb.created_at,
+    b.batch_number,
+    b.warehouse_id,
+    b.expiry_date
+FROM stock_movement_batches smb
+JOIN batches b ON smb.batch_id = b.id
+WHERE smb.movement_id = $1
+ORDER BY smb.id;
+
 -- =====================================================
 -- VALUATION METHOD QUERIES
 -- =====================================================
@@ -146,6 +231,14 @@ FROM materials m
 CROSS JOIN warehouses w
 WHERE m.id = $1 AND w.id = $2;
 
+-- The order batches of a material are drawn from in a warehouse, the
+-- material's own strategy or else the warehouse's
+-- name: GetMaterialAllocationStrategy :one
+SELECT COALESCE(m.allocation_strategy, w.allocation_strategy) as allocation_strategy
+FROM materials m
+CROSS JOIN warehouses w
+WHERE m.id = $1 AND w.id = $2;
+
 -- =====================================================
 -- TRANSACTION-SPECIFIC QUERIES
 -- =====================================================
@@ -172,7 +265,7 @@ WHERE soi.sales_order_id = $1;
 SELECT sm.id, sm.material_id, sm.from_warehouse_id, sm.to_warehouse_id,
     sm.quantity, sm.stock_direction, sm.movement_type,
     sm.reference, sm.performed_by, sm.movement_date, sm.notes,
-    sm.created_at, sm.updated_at
+    sm.created_at, sm.updated_at, sm.unit_cost, sm.total_cost, sm.sales_order_item_id, sm.purchase_order_item_id, sm.reverses_movement_id, sm.reversed_by_movement_id, sm.from_bin_id, sm.to_bin_id, sm.entered_quantity, sm.entered_unit_id, sm.work_order_id, sm.transfer_shipment_id, sm.ncr_id, sm.paired_movement_id
 FROM stock_movements sm
 WHERE sm.id = $1
   AND sm.movement_type = 'TRANSFER_OUT';
@@ -184,8 +277,23 @@ WHERE sm.id = $1
 -- name: GetCurrentStockLevel :one
 SELECT 
     COALESCE(SUM(b.current_quantity), 0) as total_quantity,
-    COUNT(DISTINCT b.id) as batch_count
+    COUNT(DISTINCT b.id) as batch_count,
+    COALESCE(SUM(h.held_quantity), 0)::DECIMAL(15, 4) as held_quantity,
+    COALESCE(MAX(r.reserved_quantity), 0)::DECIMAL(15, 4) as reserved_quantity,
+    GREATEST(
+        COALESCE(SUM(b.current_quantity), 0)
+        - COALESCE(SUM(h.held_quantity), 0)
+        - COALESCE(MAX(r.reserved_quantity), 0),
+        0
+    )::DECIMAL(15, 4) as available_quantity,
+    (
+        SELECT COALESCE(SUM(t.in_transit_quantity), 0)
+        FROM v_in_transit_stock t
+        WHERE t.material_id = $1 AND t.warehouse_id = $2
+    )::DECIMAL(15, 4) as in_transit_quantity
 FROM batches b
+LEFT JOIN v_batches_on_hold h ON h.batch_id = b.id
+LEFT JOIN v_reserved_stock r ON r.material_id = b.material_id AND r.warehouse_id = b.warehouse_id
 WHERE b.material_id = $1
   AND b.warehouse_id = $2
   AND b.current_quantity > 0;
@@ -198,13 +306,16 @@ SELECT
     m.name as material_name,
     m.code as material_code,
     COALESCE(SUM(b.current_quantity), 0) as total_quantity,
-    COUNT(DISTINCT b.id) as batch_count
+    COUNT(DISTINCT b.id) as batch_count,
+    COALESCE(MAX(t.in_transit_quantity), 0)::DECIMAL(15, 4) as in_transit_quantity
 FROM warehouses w
 CROSS JOIN materials m
 LEFT JOIN batches b ON b.warehouse_id = w.id AND b.material_id = m.id AND b.current_quantity > 0
+LEFT JOIN v_in_transit_stock t ON t.material_id = m.id AND t.warehouse_id = w.id
 WHERE m.is_active = TRUE
 GROUP BY w.id, w.name, m.id, m.name, m.code
 HAVING COALESCE(SUM(b.current_quantity), 0) > 0
+    OR COALESCE(MAX(t.in_transit_quantity), 0) > 0
 ORDER BY w.name, m.name;
 
 -- name: GetStockLevelsByMaterial :many
@@ -218,10 +329,18 @@ SELECT
     COUNT(DISTINCT b.id) as batch_count,
     MIN(b.unit_price) as min_unit_price,
     MAX(b.unit_price) as max_unit_price,
-    AVG(b.unit_price) as avg_unit_price
+    AVG(b.unit_price) as avg_unit_price,
+    COALESCE(MAX(t.in_transit_quantity), 0)::DECIMAL(15, 4) as in_transit_quantity
 FROM materials m
-LEFT JOIN batches b ON b.material_id = m.id AND b.current_quantity > 0
-LEFT JOIN warehouses w ON b.warehouse_id = w.id
+LEFT JOIN warehouses w ON EXISTS (
+        SELECT 1 FROM batches sb
+        WHERE sb.material_id = m.id AND sb.warehouse_id = w.id AND sb.current_quantity > 0
+    ) OR EXISTS (
+        SELECT 1 FROM v_in_transit_stock st
+        WHERE st.material_id = m.id AND st.warehouse_id = w.id
+    )
+LEFT JOIN batches b ON b.material_id = m.id AND b.warehouse_id = w.id AND b.current_quantity > 0
+LEFT JOIN v_in_transit_stock t ON t.material_id = m.id AND t.warehouse_id = w.id
 WHERE m.id = $1
 GROUP BY m.id, m.name, m.code, w.id, w.name
 ORDER BY w.name;
@@ -239,10 +358,19 @@ SELECT
     b.manufacture_date,
     b.expiry_date,
     w.name as warehouse_name,
-    s.name as supplier_name
+    s.name as supplier_name,
+    COALESCE(h.held_quantity, 0)::DECIMAL(15, 4) as held_quantity,
+    COALESCE(rb.reserved_quantity, 0)::DECIMAL(15, 4) as reserved_quantity,
+    GREATEST(b.current_quantity - COALESCE(h.held_quantity, 0) - COALESCE(rb.reserved_quantity, 0), 0)::DECIMAL(15, 4) as available_quantity,
+    h.hold_number,
+    b.bin_id,
+    wb.code as bin_code
 FROM batches b
 LEFT JOIN warehouses w ON b.warehouse_id = w.id
+LEFT JOIN warehouse_bins wb ON b.bin_id = wb.id
 LEFT JOIN suppliers s ON b.supplier_id = s.id
+LEFT JOIN v_batches_on_hold h ON h.batch_id = b.id
+LEFT JOIN v_reserved_batches rb ON rb.batch_id = b.id
 WHERE b.material_id = $1
   AND b.warehouse_id = $2
   AND b.current_quantity > 0
@@ -0,0 +1,138 @@
+-- =====================================================
+-- BATCH GENEALOGY QUERIES
+-- =====================================================
+
+-- name: CreateBatchGenealogyLink :exec
+INSERT INTO batch_genealogy (
+    parent_batch_id, child_batch_id, link_type, movement_id, quantity
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (parent_batch_id, child_batch_id, link_type) DO NOTHING;
+
+-- Links a new batch to every batch the source movement drew from
+-- name: LinkBatchToMovementBatches :exec
+INSERT INTO batch_genealogy (parent_batch_id, child_batch_id, link_type, movement_id)
+SELECT smb.batch_id, sqlc.arg(child_batch_id)::int, sqlc.arg(link_type)::text, sqlc.narg(movement_id)::int
+FROM stock_movement_batches smb
+WHERE smb.movement_id = sqlc.arg(source_movement_id)::int
+  AND smb.batch_id <> sqlc.arg(child_batch_id)::int
+ON CONFLICT (parent_batch_id, child_batch_id, link_type) DO NOTHING;
+
+-- =====================================================
+-- TRACEABILITY QUERIES
+-- =====================================================
+
+-- name: FindBatchesByNumber :many
+SELECT id
+FROM batches
+WHERE batch_number = sqlc.arg(batch_number)::text
+  AND (sqlc.narg(material_id)::int IS NULL OR material_id = sqlc.narg(material_id)::int)
+ORDER BY id;
+
+-- Genealogy links above the batches, up to the batches nothing went into.
+-- depth is 1 for the links into the given batches.
+-- name: TraceBatchesUpstream :many
+WITH RECURSIVE upstream AS (
+    SELECT g.id, g.parent_batch_id, g.child_batch_id, g.link_type, g.movement_id, g.quantity, 1 AS depth
+    FROM batch_genealogy g
+    WHERE g.child_batch_id = ANY(sqlc.arg(batch_ids)::int[])
+    UNION
+    SELECT g.id, g.parent_batch_id, g.child_batch_id, g.link_type, g.movement_id, g.quantity, u.depth + 1
+    FROM batch_genealogy g
+    JOIN upstream u ON g.child_batch_id = u.parent_batch_id
+    WHERE u.depth < 50
+)
+SELECT DISTINCT ON (id)
+    id, parent_batch_id, child_batch_id, link_type, movement_id, quantity, depth::int as depth
+FROM upstream
+ORDER BY id, depth;
+
+-- Genealogy links below the batches, down to the batches nothing came from.
+-- depth is 1 for the links out of the given batches.
+-- name: TraceBatchesDownstream :many
+WITH RECURSIVE downstream AS (
+    SELECT g.id, g.parent_batch_id, g.child_batch_id, g.link_type, g.movement_id, g.quantity, 1 AS depth
+    FROM batch_genealogy g
+    WHERE g.parent_batch_id = ANY(sqlc.arg(batch_ids)::int[])
+    UNION
+    SELECT g.id, g.parent_batch_id, g.child_batch_id, g.link_type, g.movement_id, g.quantity, d.depth + 1
+    FROM batch_genealogy g
+    JOIN downstream d ON g.parent_batch_id = d.child_batch_id
+    WHERE d.depth < 50
+)
+SELECT DISTINCT ON (id)
+    id, parent_batch_id, child_batch_id, link_type, movement_id, quantity, depth::int as depth
+FROM downstream
+ORDER BY id, depth;
+
+-- name: GetTraceBatches :many
+SELECT
+    b.id,
+    b.batch_number,
+    b.material_id,
+    m.code as material_code,
+    m.name as material_name,
+    mu.abbreviation as unit,
+    b.warehouse_id,
+    w.name as warehouse_name,
+    b.supplier_id,
+    s.name as supplier_name,
+    b.start_quantity,
+    b.current_quantity,
+    b.manufacture_date,
+    b.expiry_date,
+    h.hold_number,
+    b.created_at
+FROM batches b
+LEFT JOIN materials m ON b.material_id = m.id
+LEFT JOIN measure_units mu ON m.measure_unit_id = mu.id
+LEFT JOIN warehouses w ON b.warehouse_id = w.id
+LEFT JOIN suppliers s ON b.supplier_id = s.id
+LEFT JOIN v_batches_on_hold h ON h.batch_id = b.id
+WHERE b.id = ANY(sqlc.arg(batch_ids)::int[])
+ORDER BY b.id;
+
+-- Movements of the batches with the purchase order and supplier they were
+-- received from and the sales order and customer they were shipped to.
+-- Movements posted before order lines were recorded on movements fall back
+-- to the PO-<id> and SO-<id> references.
+-- name: GetTraceBatchMovements :many
+SELECT
+    smb.batch_id,
+    sm.id as movement_id,
+    sm.movement_type,
+    sm.stock_direction,
+    smb.quantity,
+    sm.movement_date,
+    sm.reference,
+    sm.from_warehouse_id,
+    fw.name as from_warehouse_name,
+    sm.to_warehouse_id,
+    tw.name as to_warehouse_name,
+    po.id as purchase_order_id,
+    po.order_number as purchase_order_number,
+    ps.id as po_supplier_id,
+    ps.name as po_supplier_name,
+    so.id as sales_order_id,
+    so.order_number as sales_order_number,
+    c.id as customer_id,
+    c.name as customer_name,
+    sm.reverses_movement_id,
+    sm.reversed_by_movement_id
+FROM stock_movement_batches smb
+JOIN stock_movements sm ON smb.movement_id = sm.id
+LEFT JOIN warehouses fw ON sm.from_warehouse_id = fw.id
+LEFT JOIN warehouses tw ON sm.to_warehouse_id = tw.id
+LEFT JOIN purchase_order_items poi ON sm.purchase_order_item_id = poi.id
+LEFT JOIN purchase_orders po ON po.id = COALESCE(poi.purchase_order_id, CASE
+    WHEN sm.movement_type = 'PURCHASE_RECEIPT' AND sm.reference ~ '^PO-[0-9]+$' THEN substring(sm.reference FROM 4)::int
+END)
+LEFT JOIN suppliers ps ON po.supplier_id = ps.id
+LEFT JOIN sales_order_items soi ON sm.sales_order_item_id = soi.id
+LEFT JOIN sales_orders so ON so.id = COALESCE(soi.sales_order_id, CASE
+    WHEN sm.movement_type = 'SALE' AND sm.reference ~ '^SO-[0-9]+$' THEN substring(sm.reference FROM 4)::int
+END)
+LEFT JOIN customers c ON so.customer_id = c.id
+WHERE smb.batch_id = ANY(sqlc.arg(batch_ids)::int[])
+ORDER BY sm.movement_date, sm.id, smb.batch_id;
@@ -0,0 +1,119 @@
+-- =====================================================
+-- TRANSFER SHIPMENT QUERIES
+-- =====================================================
+
+-- name: CreateTransferShipment :one
+INSERT INTO transfer_shipments (
+    shipment_number, material_id, from_warehouse_id, to_warehouse_id,
+    from_bin_id, to_bin_id, quantity, unit_cost, expected_date, notes, shipped_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+)
+RETURNING id, shipment_number, material_id, from_warehouse_id, to_warehouse_id,
+    from_bin_id, to_bin_id, quantity, received_quantity, unit_cost, status,
+    expected_date, discrepancy_reason, notes, shipped_by, received_by, shipped_at,
+    received_at, created_at, updated_at;
+
+-- name: GetTransferShipmentByID :one
+SELECT id, shipment_number, material_id, from_warehouse_id, to_warehouse_id,
+    from_bin_id, to_bin_id, quantity, received_quantity, unit_cost, status,
+    expected_date, discrepancy_reason, notes, shipped_by, received_by, shipped_at,
+    received_at, created_at, updated_at
+FROM transfer_shipments
+WHERE id = $1;
+
+-- name: GetTransferShipmentByIDForUpdate :one
+SELECT id, shipment_number, material_id, from_warehouse_id, to_warehouse_id,
+    from_bin_id, to_bin_id, quantity, received_quantity, unit_cost, status,
+    expected_date, discrepancy_reason, notes, shipped_by, received_by, shipped_at,
+    received_at, created_at, updated_at
+FROM transfer_shipments
+WHERE id = $1
+FOR UPDATE;
+
+-- Shipments with material and warehouses. shipped_before keeps the ones
+-- shipped before a point in time, the report of overdue transfers.
+-- name: ListTransferShipments :many
+SELECT
+    ts.id,
+    ts.shipment_number,
+    ts.material_id,
+    m.code as material_code,
+    m.name as material_name,
+    mu.abbreviation as unit,
+    ts.from_warehouse_id,
+    fw.name as from_warehouse_name,
+    ts.to_warehouse_id,
+    tw.name as to_warehouse_name,
+    ts.quantity,
+    ts.received_quantity,
+    ts.unit_cost,
+    ts.status,
+    ts.expected_date,
+    ts.discrepancy_reason,
+    ts.notes,
+    ts.shipped_by,
+    ts.shipped_at,
+    ts.received_at,
+    FLOOR(EXTRACT(EPOCH FROM (COALESCE(ts.received_at, CURRENT_TIMESTAMP) - ts.shipped_at)) / 86400)::int as days_in_transit
+FROM transfer_shipments ts
+JOIN materials m ON ts.material_id = m.id
+JOIN warehouses fw ON ts.from_warehouse_id = fw.id
+JOIN warehouses tw ON ts.to_warehouse_id = tw.id
+LEFT JOIN measure_units mu ON m.measure_unit_id = mu.id
+WHERE (sqlc.narg(status)::text IS NULL OR ts.status = sqlc.narg(status)::text)
+  AND (sqlc.narg(from_warehouse_id)::int IS NULL OR ts.from_warehouse_id = sqlc.narg(from_warehouse_id)::int)
+  AND (sqlc.narg(to_warehouse_id)::int IS NULL OR ts.to_warehouse_id = sqlc.narg(to_warehouse_id)::int)
+  AND (sqlc.narg(material_id)::int IS NULL OR ts.material_id = sqlc.narg(material_id)::int)
+  AND (sqlc.narg(shipped_before)::timestamptz IS NULL OR ts.shipped_at < sqlc.narg(shipped_before)::timestamptz)
+ORDER BY ts.shipped_at, ts.id;
+
+-- name: ReceiveTransferShipment :one
+UPDATE transfer_shipments
+SET
+    status = 'Received',
+    received_quantity = $2,
+    discrepancy_reason = $3,
+    received_by = $4,
+    received_at = CURRENT_TIMESTAMP,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, shipment_number, material_id, from_warehouse_id, to_warehouse_id,
+    from_bin_id, to_bin_id, quantity, received_quantity, unit_cost, status,
+    expected_date, discrepancy_reason, notes, shipped_by, received_by, shipped_at,
+    received_at, created_at, updated_at;
+
+-- The source batches a shipment consumed, at the cost they were shipped with
+-- name: GetTransferShipmentBatches :many
+SELECT
+    smb.batch_id,
+    b.batch_number,
+    b.supplier_id,
+    b.unit_price,
+    b.manufacture_date,
+    b.expiry_date,
+    smb.quantity,
+    smb.unit_cost,
+    smb.movement_id
+FROM stock_movement_batches smb
+JOIN stock_movements sm ON smb.movement_id = sm.id
+JOIN batches b ON smb.batch_id = b.id
+WHERE sm.transfer_shipment_id = $1
+  AND sm.stock_direction = 'OUT'
+ORDER BY smb.id;
+
+-- name: ListTransferShipmentMovements :many
+SELECT
+    sm.id,
+    sm.movement_type,
+    sm.stock_direction,
+    sm.from_warehouse_id,
+    sm.to_warehouse_id,
+    sm.quantity,
+    sm.unit_cost,
+    sm.total_cost,
+    sm.movement_date,
+    sm.performed_by
+FROM stock_movements sm
+WHERE sm.transfer_shipment_id = $1
+ORDER BY sm.movement_date, sm.id;
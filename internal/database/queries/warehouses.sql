@@ -12,22 +12,22 @@ CREATE TABLE IF NOT EXISTS warehouses (
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
 -- name: CreateWarehouse :one
-INSERT INTO warehouses (name, code, location, description, valuation, parent_warehouse, capacity, meta)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-RETURNING id, name, code, location, description, valuation, parent_warehouse, capacity, meta, created_at, updated_at;
+INSERT INTO warehouses (name, code, location, description, valuation, parent_warehouse, capacity, meta, allocation_strategy)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, name, code, location, description, valuation, parent_warehouse, capacity, meta, created_at, updated_at, allocation_strategy;
 
 -- name: GetWarehouseByID :one
-SELECT id, name, code, location, description, valuation, parent_warehouse, capacity, meta, created_at, updated_at
+SELECT id, name, code, location, description, valuation, parent_warehouse, capacity, meta, created_at, updated_at, allocation_strategy
 FROM warehouses
 WHERE id = $1;
 
 -- name: GetWarehouseByCode :one
-SELECT id, name, code, location, description, valuation, parent_warehouse, capacity, meta, created_at, updated_at
+SELECT id, name, code, location, description, valuation, parent_warehouse, capacity, meta, created_at, updated_at, allocation_strategy
 FROM warehouses
 WHERE code = $1;
 
 -- name: GetWarehouseByName :one
-SELECT id, name, code, location, description, valuation, parent_warehouse, capacity, meta, created_at, updated_at
+SELECT id, name, code, location, description, valuation, parent_warehouse, capacity, meta, created_at, updated_at, allocation_strategy
 FROM warehouses
 WHERE name = $1;
 
@@ -42,16 +42,17 @@ SET
     parent_warehouse = COALESCE($7, parent_warehouse),
     capacity = COALESCE($8, capacity),
     meta = COALESCE($9, meta),
+    allocation_strategy = COALESCE($10, allocation_strategy),
     updated_at = CURRENT_TIMESTAMP
 WHERE id = $1
-RETURNING id, name, code, location, description, valuation, parent_warehouse, capacity, meta, created_at, updated_at;
+RETURNING id, name, code, location, description, valuation, parent_warehouse, capacity, meta, created_at, updated_at, allocation_strategy;
 
 -- name: DeleteWarehouse :exec
 DELETE FROM warehouses
 WHERE id = $1;
 
 -- name: ListWarehouses :many
-SELECT id, name, code, location, description, valuation, parent_warehouse, capacity, meta, created_at, updated_at
+SELECT id, name, code, location, description, valuation, parent_warehouse, capacity, meta, created_at, updated_at, allocation_strategy
 FROM warehouses
 ORDER BY created_at DESC
 LIMIT $1 OFFSET $2;
\ No newline at end of file
@@ -0,0 +1,221 @@
+-- =====================================================
+-- WORK ORDER QUERIES
+-- =====================================================
+
+-- An empty work_order_number is set by trg_set_work_order_number
+-- name: CreateWorkOrder :one
+INSERT INTO work_orders (
+    work_order_number, finished_material_id, bom_version, warehouse_id,
+    planned_quantity, due_date, notes, created_by
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING id, work_order_number, finished_material_id, bom_version, warehouse_id,
+    planned_quantity, completed_quantity, status, issued_cost, batch_id, due_date,
+    notes, created_by, completed_by, completed_at, created_at, updated_at;
+
+-- name: GetWorkOrderByID :one
+SELECT id, work_order_number, finished_material_id, bom_version, warehouse_id,
+    planned_quantity, completed_quantity, status, issued_cost, batch_id, due_date,
+    notes, created_by, completed_by, completed_at, created_at, updated_at
+FROM work_orders
+WHERE id = $1;
+
+-- name: GetWorkOrderByIDForUpdate :one
+SELECT id, work_order_number, finished_material_id, bom_version, warehouse_id,
+    planned_quantity, completed_quantity, status, issued_cost, batch_id, due_date,
+    notes, created_by, completed_by, completed_at, created_at, updated_at
+FROM work_orders
+WHERE id = $1
+FOR UPDATE;
+
+-- name: ListWorkOrders :many
+SELECT
+    wo.id,
+    wo.work_order_number,
+    wo.finished_material_id,
+    m.code as material_code,
+    m.name as material_name,
+    mu.abbreviation as unit,
+    wo.bom_version,
+    wo.warehouse_id,
+    w.name as warehouse_name,
+    wo.planned_quantity,
+    wo.completed_quantity,
+    wo.status,
+    wo.issued_cost,
+    wo.batch_id,
+    b.batch_number,
+    wo.due_date,
+    wo.notes,
+    wo.created_by,
+    wo.completed_at,
+    wo.created_at
+FROM work_orders wo
+JOIN materials m ON wo.finished_material_id = m.id
+JOIN warehouses w ON wo.warehouse_id = w.id
+LEFT JOIN measure_units mu ON m.measure_unit_id = mu.id
+LEFT JOIN batches b ON wo.batch_id = b.id
+WHERE (sqlc.narg(status)::text IS NULL OR wo.status = sqlc.narg(status)::text)
+  AND (sqlc.narg(warehouse_id)::int IS NULL OR wo.warehouse_id = sqlc.narg(warehouse_id)::int)
+  AND (sqlc.narg(material_id)::int IS NULL OR wo.finished_material_id = sqlc.narg(material_id)::int)
+ORDER BY wo.created_at DESC, wo.id DESC;
+
+-- name: UpdateWorkOrderStatus :one
+UPDATE work_orders
+SET
+    status = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, work_order_number, finished_material_id, bom_version, warehouse_id,
+    planned_quantity, completed_quantity, status, issued_cost, batch_id, due_date,
+    notes, created_by, completed_by, completed_at, created_at, updated_at;
+
+-- Adds the cost of an issue and puts the order in progress
+-- name: AddWorkOrderIssuedCost :one
+UPDATE work_orders
+SET
+    status = 'In Progress',
+    issued_cost = issued_cost + $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, work_order_number, finished_material_id, bom_version, warehouse_id,
+    planned_quantity, completed_quantity, status, issued_cost, batch_id, due_date,
+    notes, created_by, completed_by, completed_at, created_at, updated_at;
+
+-- name: CompleteWorkOrder :one
+UPDATE work_orders
+SET
+    status = 'Completed',
+    completed_quantity = $2,
+    batch_id = $3,
+    completed_by = $4,
+    completed_at = CURRENT_TIMESTAMP,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1
+RETURNING id, work_order_number, finished_material_id, bom_version, warehouse_id,
+    planned_quantity, completed_quantity, status, issued_cost, batch_id, due_date,
+    notes, created_by, completed_by, completed_at, created_at, updated_at;
+
+-- =====================================================
+-- WORK ORDER COMPONENT QUERIES
+-- =====================================================
+
+-- The version of a finished material's BOM that is in effect today
+-- name: GetEffectiveBOMVersion :one
+SELECT version::text
+FROM bills_of_materials
+WHERE finished_material_id = $1
+  AND is_active = TRUE
+  AND archived = FALSE
+  AND (effective_date IS NULL OR effective_date <= CURRENT_DATE)
+  AND (expiry_date IS NULL OR expiry_date > CURRENT_DATE)
+ORDER BY version DESC
+LIMIT 1;
+
+-- The BOM lines of one version in effect today, in production order
+-- name: GetBOMLinesForWorkOrder :many
+SELECT
+    b.id,
+    b.component_material_id::int as component_material_id,
+    b.alternate_component_id,
+    b.quantity,
+    b.unit_measure_id,
+    COALESCE(b.scrap_percentage, 0)::DECIMAL(5, 2) as scrap_percentage,
+    COALESCE(b.fixed_quantity, FALSE)::boolean as fixed_quantity,
+    COALESCE(b.is_optional, FALSE)::boolean as is_optional
+FROM bills_of_materials b
+WHERE b.finished_material_id = $1
+  AND b.version = $2
+  AND b.component_material_id IS NOT NULL
+  AND b.is_active = TRUE
+  AND b.archived = FALSE
+  AND (b.effective_date IS NULL OR b.effective_date <= CURRENT_DATE)
+  AND (b.expiry_date IS NULL OR b.expiry_date > CURRENT_DATE)
+ORDER BY b.priority, b.operation_sequence NULLS LAST, b.id;
+
+-- name: CreateWorkOrderComponent :one
+INSERT INTO work_order_components (
+    work_order_id, bom_id, component_material_id, alternate_component_id,
+    base_quantity, scrap_percentage, fixed_quantity, is_optional,
+    required_quantity, sequence
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+)
+RETURNING id, work_order_id, bom_id, component_material_id, alternate_component_id,
+    base_quantity, scrap_percentage, fixed_quantity, is_optional, required_quantity,
+    issued_quantity, alternate_issued_quantity, issued_cost, sequence, created_at, updated_at;
+
+-- name: ListWorkOrderComponents :many
+SELECT
+    c.id,
+    c.work_order_id,
+    c.bom_id,
+    c.component_material_id,
+    m.code as component_code,
+    m.name as component_name,
+    m.measure_unit_id as component_unit_id,
+    mu.abbreviation as component_unit,
+    c.alternate_component_id,
+    alt.code as alternate_code,
+    alt.name as alternate_name,
+    alt_mu.abbreviation as alternate_unit,
+    c.base_quantity,
+    c.scrap_percentage,
+    c.fixed_quantity,
+    c.is_optional,
+    c.required_quantity,
+    c.issued_quantity,
+    c.alternate_issued_quantity,
+    c.issued_cost,
+    c.sequence
+FROM work_order_components c
+JOIN materials m ON c.component_material_id = m.id
+LEFT JOIN measure_units mu ON m.measure_unit_id = mu.id
+LEFT JOIN materials alt ON c.alternate_component_id = alt.id
+LEFT JOIN measure_units alt_mu ON alt.measure_unit_id = alt_mu.id
+WHERE c.work_order_id = $1
+ORDER BY c.sequence, c.id;
+
+-- name: AddWorkOrderComponentIssue :exec
+UPDATE work_order_components
+SET
+    issued_quantity = issued_quantity + $2,
+    alternate_issued_quantity = alternate_issued_quantity + $3,
+    issued_cost = issued_cost + $4,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- =====================================================
+-- PRODUCTION MOVEMENT QUERIES
+-- =====================================================
+
+-- name: ListWorkOrderMovements :many
+SELECT
+    sm.id,
+    sm.material_id,
+    m.code as material_code,
+    m.name as material_name,
+    sm.movement_type,
+    sm.stock_direction,
+    sm.quantity,
+    sm.unit_cost,
+    sm.total_cost,
+    sm.movement_date,
+    sm.reversed_by_movement_id
+FROM stock_movements sm
+LEFT JOIN materials m ON sm.material_id = m.id
+WHERE sm.work_order_id = $1
+ORDER BY sm.movement_date, sm.id;
+
+-- Links the finished goods batch to every batch the work order consumed
+-- name: LinkBatchToWorkOrderIssues :exec
+INSERT INTO batch_genealogy (parent_batch_id, child_batch_id, link_type, movement_id, quantity)
+SELECT smb.batch_id, sqlc.arg(child_batch_id)::int, 'PRODUCTION', sqlc.narg(movement_id)::int, SUM(smb.quantity)
+FROM stock_movement_batches smb
+JOIN stock_movements sm ON smb.movement_id = sm.id
+WHERE sm.work_order_id = sqlc.arg(work_order_id)::int
+  AND sm.movement_type = 'PRODUCTION_ISSUE'
+  AND sm.reversed_by_movement_id IS NULL
+GROUP BY smb.batch_id
+ON CONFLICT (parent_batch_id, child_batch_id, link_type) DO NOTHING;
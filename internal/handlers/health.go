@@ -13,7 +13,7 @@ type HealthResponse struct {
 func HealthCheck(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	
+
 	json.NewEncoder(w).Encode(HealthResponse{
 		Status:  "ok",
 		Message: "Server is running",
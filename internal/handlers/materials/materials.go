@@ -48,10 +48,23 @@ func isValidValuationMethod(v string) bool {
 	return false
 }
 
+// isValidAllocationStrategy checks the order batches are drawn from, FEFO is
+// an allocation strategy and not a valuation method
+func isValidAllocationStrategy(v string) bool {
+	validStrategies := []string{"FIFO", "LIFO", "FEFO"}
+	for _, valid := range validStrategies {
+		if v == valid {
+			return true
+		}
+	}
+	return false
+}
+
 type CreateMaterialRequest struct {
 	Name          string          `json:"name"`
 	Description   string          `json:"description"`
 	Valuation     string          `json:"valuation"`
+	Allocation    string          `json:"allocation_strategy"`
 	Type          string          `json:"type"`
 	Code          string          `json:"code"`
 	SKU           string          `json:"sku"`
@@ -70,6 +83,7 @@ type CreateMaterialRequest struct {
 	IsFragile     bool            `json:"is_fragile"`
 	IsFlammable   bool            `json:"is_flammable"`
 	IsToxic       bool            `json:"is_toxic"`
+	IsSerialized  bool            `json:"is_serialized"`
 	ImageURL      string          `json:"image_url"`
 	DocumentURL   string          `json:"document_url"`
 	Meta          json.RawMessage `json:"meta"`
@@ -79,6 +93,7 @@ type UpdateMaterialRequest struct {
 	Name          *string         `json:"name,omitempty"`
 	Description   *string         `json:"description,omitempty"`
 	Valuation     *string         `json:"valuation,omitempty"`
+	Allocation    *string         `json:"allocation_strategy,omitempty"`
 	Type          *string         `json:"type,omitempty"`
 	Code          *string         `json:"code,omitempty"`
 	SKU           *string         `json:"sku,omitempty"`
@@ -97,6 +112,7 @@ type UpdateMaterialRequest struct {
 	IsFragile     *bool           `json:"is_fragile,omitempty"`
 	IsFlammable   *bool           `json:"is_flammable,omitempty"`
 	IsToxic       *bool           `json:"is_toxic,omitempty"`
+	IsSerialized  *bool           `json:"is_serialized,omitempty"`
 	ImageURL      *string         `json:"image_url,omitempty"`
 	DocumentURL   *string         `json:"document_url,omitempty"`
 	Meta          json.RawMessage `json:"meta,omitempty"`
@@ -127,6 +143,11 @@ func (m *MaterialHandler) CreateMaterial(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if req.Allocation != "" && !isValidAllocationStrategy(req.Allocation) {
+		config.RespondBadRequest(w, "Invalid allocation strategy", "Allocation strategy must be one of: FIFO, LIFO, FEFO")
+		return
+	}
+
 	codeExists, err := m.h.Queries.CheckDuplicateCode(context.Background(), db.CheckDuplicateCodeParams{
 		Code: req.Code,
 		ID:   0,
@@ -170,6 +191,9 @@ func (m *MaterialHandler) CreateMaterial(w http.ResponseWriter, r *http.Request)
 	if req.Valuation != "" {
 		params.Valuation = db.NullValuationMethod{ValuationMethod: db.ValuationMethod(req.Valuation), Valid: true}
 	}
+	if req.Allocation != "" {
+		params.AllocationStrategy = db.NullAllocationStrategy{AllocationStrategy: db.AllocationStrategy(req.Allocation), Valid: true}
+	}
 	if req.UnitPrice > 0 {
 		params.UnitPrice = pgtype.Numeric{Int: big.NewInt(int64(req.UnitPrice * 100)), Exp: -2, Valid: true}
 	}
@@ -215,6 +239,7 @@ func (m *MaterialHandler) CreateMaterial(w http.ResponseWriter, r *http.Request)
 	if req.Meta != nil {
 		params.Meta = req.Meta
 	}
+	params.IsSerialized = req.IsSerialized
 
 	material, err := m.h.Queries.CreateMaterial(context.Background(), params)
 	if err != nil {
@@ -634,6 +659,12 @@ func (m *MaterialHandler) UpdateMaterial(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Validate allocation strategy if provided
+	if req.Allocation != nil && *req.Allocation != "" && !isValidAllocationStrategy(*req.Allocation) {
+		config.RespondBadRequest(w, "Invalid allocation strategy", "Allocation strategy must be one of: FIFO, LIFO, FEFO")
+		return
+	}
+
 	// Validate material type if provided
 	if req.Type != nil && *req.Type != "" {
 		// Normalize type to lowercase
@@ -645,6 +676,20 @@ func (m *MaterialHandler) UpdateMaterial(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
+	// Units in stock were received with or without serial numbers, so the
+	// serialization only changes while the material has no stock
+	if req.IsSerialized != nil && *req.IsSerialized != current.IsSerialized {
+		hasStock, err := m.h.Queries.CheckMaterialHasStock(context.Background(), pgtype.Int4{Int32: id, Valid: true})
+		if err != nil {
+			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to check material stock"})
+			return
+		}
+		if hasStock {
+			config.RespondJSON(w, http.StatusConflict, map[string]string{"error": "Serialization cannot change while the material has stock"})
+			return
+		}
+	}
+
 	params := db.UpdateMaterialParams{
 		ID: id,
 	}
@@ -688,6 +733,14 @@ func (m *MaterialHandler) UpdateMaterial(w http.ResponseWriter, r *http.Request)
 	if req.Valuation != nil {
 		params.Valuation = db.NullValuationMethod{ValuationMethod: db.ValuationMethod(*req.Valuation), Valid: true}
 	}
+	// An empty allocation strategy drops the override, the material follows
+	// its warehouse again
+	if req.Allocation != nil && *req.Allocation == "" {
+		params.Column28 = true
+	}
+	if req.Allocation != nil && *req.Allocation != "" {
+		params.AllocationStrategy = db.NullAllocationStrategy{AllocationStrategy: db.AllocationStrategy(*req.Allocation), Valid: true}
+	}
 	if req.Type != nil {
 		params.Type = db.MaterialType(*req.Type)
 	}
@@ -714,6 +767,9 @@ func (m *MaterialHandler) UpdateMaterial(w http.ResponseWriter, r *http.Request)
 	if req.IsFragile != nil {
 		params.IsFragile = pgtype.Bool{Bool: *req.IsFragile, Valid: true}
 	}
+	if req.IsSerialized != nil {
+		params.IsSerialized = pgtype.Bool{Bool: *req.IsSerialized, Valid: true}
+	}
 
 	// Numeric price fields
 	if req.UnitPrice != nil {
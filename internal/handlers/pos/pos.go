@@ -70,7 +70,7 @@ func parseFlexibleDate(dateStr string) (time.Time, error) {
 }
 
 func isValidPOStatus(s string) bool {
-	validStatuses := []string{"Pending", "Approved", "Received", "Cancelled", "Partial"}
+	validStatuses := []string{"Pending", "Approved", "PartiallyReceived", "Received", "Cancelled", "Partial"}
 	for _, valid := range validStatuses {
 		if s == valid {
 			return true
@@ -79,6 +79,29 @@ func isValidPOStatus(s string) bool {
 	return false
 }
 
+// purchaseOrderError is a rejected purchase order with the response to send
+type purchaseOrderError struct {
+	status  int
+	message string
+	details string
+}
+
+func (e *purchaseOrderError) Error() string {
+	if e.details == "" {
+		return e.message
+	}
+	return e.message + ": " + e.details
+}
+
+// respond writes the error the way the purchase order handlers answer
+func (e *purchaseOrderError) respond(w http.ResponseWriter) {
+	if e.status == http.StatusBadRequest {
+		config.RespondBadRequest(w, e.message, e.details)
+		return
+	}
+	config.RespondJSON(w, e.status, map[string]string{"error": e.message})
+}
+
 // CreatePurchaseOrder creates a new purchase order with items.
 func (po *POSHandler) CreatePurchaseOrder(w http.ResponseWriter, r *http.Request) {
 	var req CreatePurchaseOrderRequest
@@ -87,11 +110,34 @@ func (po *POSHandler) CreatePurchaseOrder(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	if req.OrderNumber == "" || len(req.Items) == 0 {
-		config.RespondBadRequest(w, "Missing required fields", "Order number and at least one item are required")
+	// Get current user ID from context
+	var userID int32
+	if session, ok := middlewares.GetSessionFromContext(r); ok {
+		if uid, err := fmt.Sscanf(session.UserID, "%d", &userID); err == nil && uid == 1 {
+			// User ID successfully parsed
+		}
+	}
+
+	purchaseOrder, items, err := po.createPurchaseOrder(context.Background(), po.h.Queries, req, userID)
+	if err != nil {
+		err.respond(w)
 		return
 	}
 
+	config.RespondJSON(w, http.StatusCreated, map[string]any{
+		"purchase_order": purchaseOrder,
+		"items":          items,
+	})
+}
+
+// createPurchaseOrder validates and creates a purchase order with its items.
+// It is shared by CreatePurchaseOrder and the conversion of replenishment
+// suggestions, which passes the queries of its own transaction.
+func (po *POSHandler) createPurchaseOrder(ctx context.Context, queries *db.Queries, req CreatePurchaseOrderRequest, userID int32) (db.PurchaseOrder, []db.PurchaseOrderItem, *purchaseOrderError) {
+	if req.OrderNumber == "" || len(req.Items) == 0 {
+		return db.PurchaseOrder{}, nil, &purchaseOrderError{http.StatusBadRequest, "Missing required fields", "Order number and at least one item are required"}
+	}
+
 	// Set default status if not provided
 	if req.Status == "" {
 		req.Status = "Pending"
@@ -99,27 +145,23 @@ func (po *POSHandler) CreatePurchaseOrder(w http.ResponseWriter, r *http.Request
 
 	// Validate status
 	if !isValidPOStatus(req.Status) {
-		config.RespondBadRequest(w, "Invalid status", "Status must be one of: Pending, Approved, Received, Cancelled, Partial")
-		return
+		return db.PurchaseOrder{}, nil, &purchaseOrderError{http.StatusBadRequest, "Invalid status", "Status must be one of: Pending, Approved, PartiallyReceived, Received, Cancelled, Partial"}
 	}
 
 	// Check for duplicate order number
-	_, err := po.h.Queries.GetPurchaseOrderByOrderNumber(context.Background(), req.OrderNumber)
+	_, err := queries.GetPurchaseOrderByOrderNumber(ctx, req.OrderNumber)
 	if err == nil {
-		config.RespondJSON(w, http.StatusConflict, map[string]string{"error": "Purchase order number already exists"})
-		return
+		return db.PurchaseOrder{}, nil, &purchaseOrderError{status: http.StatusConflict, message: "Purchase order number already exists"}
 	}
 
 	// Calculate total amount from items
 	var totalAmount float64
 	for _, item := range req.Items {
 		if item.Quantity <= 0 || item.UnitPrice <= 0 {
-			config.RespondBadRequest(w, "Invalid item data", "Quantity and unit price must be greater than 0")
-			return
+			return db.PurchaseOrder{}, nil, &purchaseOrderError{http.StatusBadRequest, "Invalid item data", "Quantity and unit price must be greater than 0"}
 		}
 		if item.ReceivedQuantity < 0 {
-			config.RespondBadRequest(w, "Invalid item data", "Received quantity cannot be negative")
-			return
+			return db.PurchaseOrder{}, nil, &purchaseOrderError{http.StatusBadRequest, "Invalid item data", "Received quantity cannot be negative"}
 		}
 		totalAmount += item.Quantity * item.UnitPrice
 	}
@@ -129,8 +171,7 @@ func (po *POSHandler) CreatePurchaseOrder(w http.ResponseWriter, r *http.Request
 	if req.OrderDate != nil && *req.OrderDate != "" {
 		parsedDate, err := parseFlexibleDate(*req.OrderDate)
 		if err != nil {
-			config.RespondBadRequest(w, "Invalid order date format", err.Error())
-			return
+			return db.PurchaseOrder{}, nil, &purchaseOrderError{http.StatusBadRequest, "Invalid order date format", err.Error()}
 		}
 		orderDate = parsedDate
 	} else {
@@ -140,50 +181,26 @@ func (po *POSHandler) CreatePurchaseOrder(w http.ResponseWriter, r *http.Request
 	if req.ExpectedDeliveryDate != nil && *req.ExpectedDeliveryDate != "" {
 		parsedDate, err := parseFlexibleDate(*req.ExpectedDeliveryDate)
 		if err != nil {
-			config.RespondBadRequest(w, "Invalid expected delivery date format", err.Error())
-			return
+			return db.PurchaseOrder{}, nil, &purchaseOrderError{http.StatusBadRequest, "Invalid expected delivery date format", err.Error()}
 		}
 		expectedDate = parsedDate
 
 		// Check if order date is before expected delivery date
 		if !orderDate.Before(expectedDate) {
-			config.RespondBadRequest(w, "Invalid dates", "Order date must be before expected delivery date")
-			return
-		}
-	}
-
-	// Get current user ID from context
-	var userID int32
-	if session, ok := middlewares.GetSessionFromContext(r); ok {
-		if uid, err := fmt.Sscanf(session.UserID, "%d", &userID); err == nil && uid == 1 {
-			// User ID successfully parsed
+			return db.PurchaseOrder{}, nil, &purchaseOrderError{http.StatusBadRequest, "Invalid dates", "Order date must be before expected delivery date"}
 		}
 	}
 
 	params := db.CreatePurchaseOrderParams{
 		OrderNumber: req.OrderNumber,
 		Status:      req.Status,
+		OrderDate:   pgtype.Timestamptz{Time: orderDate, Valid: true},
 	}
 
 	if req.SupplierID > 0 {
 		params.SupplierID = pgtype.Int4{Int32: req.SupplierID, Valid: true}
 	}
-	if req.OrderDate != nil && *req.OrderDate != "" {
-		orderDate, err := parseFlexibleDate(*req.OrderDate)
-		if err != nil {
-			config.RespondBadRequest(w, "Invalid order date format", err.Error())
-			return
-		}
-		params.OrderDate = pgtype.Timestamptz{Time: orderDate, Valid: true}
-	} else {
-		params.OrderDate = pgtype.Timestamptz{Time: time.Now(), Valid: true}
-	}
-	if req.ExpectedDeliveryDate != nil && *req.ExpectedDeliveryDate != "" {
-		expectedDate, err := parseFlexibleDate(*req.ExpectedDeliveryDate)
-		if err != nil {
-			config.RespondBadRequest(w, "Invalid expected delivery date format", err.Error())
-			return
-		}
+	if !expectedDate.IsZero() {
 		params.ExpectedDeliveryDate = pgtype.Timestamptz{Time: expectedDate, Valid: true}
 	}
 	if userID > 0 {
@@ -198,11 +215,10 @@ func (po *POSHandler) CreatePurchaseOrder(w http.ResponseWriter, r *http.Request
 	params.TotalAmount.Scan(fmt.Sprintf("%.4f", totalAmount))
 
 	// Create purchase order
-	purchaseOrder, err := po.h.Queries.CreatePurchaseOrder(context.Background(), params)
+	purchaseOrder, err := queries.CreatePurchaseOrder(ctx, params)
 	if err != nil {
 		po.h.Logger.Error("Failed to create purchase order", "error", err)
-		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
-		return
+		return db.PurchaseOrder{}, nil, &purchaseOrderError{status: http.StatusInternalServerError, message: err.Error()}
 	}
 
 	// Create purchase order items
@@ -227,21 +243,17 @@ func (po *POSHandler) CreatePurchaseOrder(w http.ResponseWriter, r *http.Request
 		itemParams.ReceivedQuantity = pgtype.Numeric{Valid: true}
 		itemParams.ReceivedQuantity.Scan(fmt.Sprintf("%.4f", item.ReceivedQuantity))
 
-		createdItem, err := po.h.Queries.CreatePurchaseOrderItem(context.Background(), itemParams)
+		createdItem, err := queries.CreatePurchaseOrderItem(ctx, itemParams)
 		if err != nil {
 			po.h.Logger.Error("Failed to create purchase order item", "error", err)
 			// Rollback: delete the purchase order
-			po.h.Queries.DeletePurchaseOrder(context.Background(), purchaseOrder.ID)
-			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
-			return
+			queries.DeletePurchaseOrder(ctx, purchaseOrder.ID)
+			return db.PurchaseOrder{}, nil, &purchaseOrderError{status: http.StatusInternalServerError, message: err.Error()}
 		}
 		items = append(items, createdItem)
 	}
 
-	config.RespondJSON(w, http.StatusCreated, map[string]any{
-		"purchase_order": purchaseOrder,
-		"items":          items,
-	})
+	return purchaseOrder, items, nil
 }
 
 // GetPurchaseOrder retrieves a purchase order by ID with its items.
@@ -300,7 +312,7 @@ func (po *POSHandler) UpdatePurchaseOrder(w http.ResponseWriter, r *http.Request
 
 	// Validate status if provided
 	if req.Status != nil && *req.Status != "" && !isValidPOStatus(*req.Status) {
-		config.RespondBadRequest(w, "Invalid status", "Status must be one of: Pending, Approved, Received, Cancelled, Partial")
+		config.RespondBadRequest(w, "Invalid status", "Status must be one of: Pending, Approved, PartiallyReceived, Received, Cancelled, Partial")
 		return
 	}
 
@@ -509,6 +521,63 @@ func (po *POSHandler) GetPurchaseOrderItems(w http.ResponseWriter, r *http.Reque
 	})
 }
 
+// PurchaseOrderLineReceipt summarises how much of an order line has arrived.
+type PurchaseOrderLineReceipt struct {
+	db.PurchaseOrderItem
+	RemainingQuantity float64 `json:"remaining_quantity"`
+}
+
+// GetPurchaseOrderReceipts retrieves the receipt history of a purchase order.
+func (po *POSHandler) GetPurchaseOrderReceipts(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	if idStr == "" {
+		config.RespondBadRequest(w, "Missing purchase order ID", "")
+		return
+	}
+
+	var id int32
+	if _, err := fmt.Sscanf(idStr, "%d", &id); err != nil {
+		config.RespondBadRequest(w, "Invalid purchase order ID format", err.Error())
+		return
+	}
+
+	purchaseOrder, err := po.h.Queries.GetPurchaseOrderByID(context.Background(), id)
+	if err != nil {
+		config.RespondJSON(w, http.StatusNotFound, map[string]string{"error": "Purchase order not found"})
+		return
+	}
+
+	items, err := po.h.Queries.ListPurchaseOrderItems(context.Background(), pgtype.Int4{Int32: id, Valid: true})
+	if err != nil {
+		po.h.Logger.Error("Failed to get purchase order items", "error", err)
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	lines := make([]PurchaseOrderLineReceipt, len(items))
+	for i, item := range items {
+		ordered, _ := item.Quantity.Float64Value()
+		received, _ := item.ReceivedQuantity.Float64Value()
+		lines[i] = PurchaseOrderLineReceipt{
+			PurchaseOrderItem: item,
+			RemainingQuantity: max(ordered.Float64-received.Float64, 0),
+		}
+	}
+
+	receipts, err := po.h.Queries.ListPurchaseOrderReceipts(context.Background(), pgtype.Int4{Int32: id, Valid: true})
+	if err != nil {
+		po.h.Logger.Error("Failed to get purchase order receipts", "error", err)
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusOK, map[string]any{
+		"purchase_order": purchaseOrder,
+		"lines":          lines,
+		"receipts":       receipts,
+	})
+}
+
 // UpdatePurchaseOrderItem updates a single purchase order item.
 func (po *POSHandler) UpdatePurchaseOrderItem(w http.ResponseWriter, r *http.Request) {
 	poIDStr := r.PathValue("id")
@@ -0,0 +1,525 @@
+package pos
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"warehouse_system/internal/config"
+	db "warehouse_system/internal/database/db"
+	"warehouse_system/internal/middlewares"
+)
+
+// =====================================================
+// REORDER POINTS AND REPLENISHMENT
+// =====================================================
+
+// ReplenishmentJobType is the job type of the replenishment run
+const ReplenishmentJobType = "replenishment.generate_suggestions"
+
+// PlanningParametersRequest sets the planning parameters of a material in a warehouse
+type PlanningParametersRequest struct {
+	MaterialID          int32    `json:"material_id"`
+	WarehouseID         int32    `json:"warehouse_id"`
+	MinQuantity         float64  `json:"min_quantity"`
+	MaxQuantity         *float64 `json:"max_quantity"`
+	ReorderPoint        float64  `json:"reorder_point"`
+	ReorderQuantity     float64  `json:"reorder_quantity"`
+	PreferredSupplierID *int32   `json:"preferred_supplier_id"`
+	IsActive            *bool    `json:"is_active"`
+}
+
+// ConvertSuggestionsRequest converts replenishment suggestions to purchase orders
+type ConvertSuggestionsRequest struct {
+	SuggestionIDs        []int32 `json:"suggestion_ids"`
+	ExpectedDeliveryDate *string `json:"expected_delivery_date"`
+}
+
+// ReplenishmentRun is the outcome of one replenishment run
+type ReplenishmentRun struct {
+	Evaluated int   `json:"evaluated"`
+	Suggested int   `json:"suggested"`
+	Removed   int64 `json:"removed"`
+}
+
+// replenishmentMeta is stored in the meta of a purchase order created from
+// suggestions. The warehouse tells the next run where the order goes.
+type replenishmentMeta struct {
+	Source        string  `json:"source"`
+	WarehouseID   int32   `json:"warehouse_id"`
+	SuggestionIDs []int32 `json:"suggestion_ids"`
+}
+
+// stockKey identifies the stock of a material in a warehouse
+type stockKey struct {
+	materialID  int32
+	warehouseID int32
+}
+
+// numericFromFloat converts float64 to pgtype.Numeric
+func numericFromFloat(f float64) pgtype.Numeric {
+	var n pgtype.Numeric
+	n.Scan(fmt.Sprintf("%.4f", f))
+	return n
+}
+
+// floatFromNumeric converts pgtype.Numeric to float64, NULL is 0
+func floatFromNumeric(n pgtype.Numeric) float64 {
+	f, _ := n.Float64Value()
+	return f.Float64
+}
+
+// orderQuantity is the quantity to order for planning parameters p when
+// projected is on hand plus on order, 0 when no order is needed. An order is
+// due once projected falls to the reorder point (the minimum when no reorder
+// point is set). It orders the reorder quantity, or up to the maximum without
+// one, and never less than what it takes to reach the minimum.
+func orderQuantity(p db.ListActivePlanningParametersRow, projected float64) float64 {
+	trigger := floatFromNumeric(p.ReorderPoint)
+	if trigger <= 0 {
+		trigger = floatFromNumeric(p.MinQuantity)
+	}
+	if projected > trigger {
+		return 0
+	}
+
+	quantity := floatFromNumeric(p.ReorderQuantity)
+	if quantity <= 0 && p.MaxQuantity.Valid {
+		quantity = floatFromNumeric(p.MaxQuantity) - projected
+	}
+	if minimum := floatFromNumeric(p.MinQuantity); projected+quantity < minimum {
+		quantity = minimum - projected
+	}
+	return max(quantity, 0)
+}
+
+// GenerateReplenishmentSuggestions compares the planning parameters with the
+// stock levels and the open purchase orders and refreshes the open
+// suggestions: one per material and warehouse that needs an order, the
+// others are removed. Open order quantities without a destination warehouse
+// cover the warehouses of the material one after the other.
+func (po *POSHandler) GenerateReplenishmentSuggestions(ctx context.Context) (ReplenishmentRun, error) {
+	var run ReplenishmentRun
+
+	parameters, err := po.h.Queries.ListActivePlanningParameters(ctx)
+	if err != nil {
+		return run, fmt.Errorf("failed to get planning parameters: %w", err)
+	}
+
+	levels, err := po.h.Queries.GetStockLevelsByWarehouse(ctx)
+	if err != nil {
+		return run, fmt.Errorf("failed to get stock levels: %w", err)
+	}
+	// Stock shipped from another warehouse counts as on hand, it is on its way
+	onHand := make(map[stockKey]float64, len(levels))
+	for _, l := range levels {
+		key := stockKey{l.MaterialID, l.WarehouseID}
+		if quantity, ok := l.TotalQuantity.(pgtype.Numeric); ok {
+			onHand[key] = floatFromNumeric(quantity)
+		}
+		onHand[key] += floatFromNumeric(l.InTransitQuantity)
+	}
+
+	openOrders, err := po.h.Queries.GetOpenPurchaseOrderQuantities(ctx)
+	if err != nil {
+		return run, fmt.Errorf("failed to get open purchase orders: %w", err)
+	}
+	onOrder := make(map[stockKey]float64, len(openOrders))
+	for _, o := range openOrders {
+		onOrder[stockKey{o.MaterialID, o.WarehouseID}] += floatFromNumeric(o.OpenQuantity)
+	}
+
+	tx, err := po.h.DB.Begin(ctx)
+	if err != nil {
+		return run, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	queries := po.h.Queries.WithTx(tx)
+
+	keepIDs := []int32{}
+	for _, p := range parameters {
+		run.Evaluated++
+		key := stockKey{p.MaterialID, p.WarehouseID}
+		unassigned := stockKey{p.MaterialID, 0}
+
+		ordered := onOrder[key]
+		if open := onOrder[unassigned]; open > 0 {
+			take := min(open, orderQuantity(p, onHand[key]+ordered))
+			ordered += take
+			onOrder[unassigned] -= take
+		}
+
+		quantity := orderQuantity(p, onHand[key]+ordered)
+		if quantity <= 0 {
+			continue
+		}
+
+		suggestion, err := queries.UpsertOpenReplenishmentSuggestion(ctx, db.UpsertOpenReplenishmentSuggestionParams{
+			MaterialID:        p.MaterialID,
+			WarehouseID:       p.WarehouseID,
+			SupplierID:        p.PreferredSupplierID,
+			OnHandQuantity:    numericFromFloat(onHand[key]),
+			OnOrderQuantity:   numericFromFloat(ordered),
+			ReorderPoint:      p.ReorderPoint,
+			SuggestedQuantity: numericFromFloat(quantity),
+			UnitPrice:         p.UnitPrice,
+		})
+		if err != nil {
+			return run, fmt.Errorf("failed to save suggestion for material %d: %w", p.MaterialID, err)
+		}
+		keepIDs = append(keepIDs, suggestion.ID)
+		run.Suggested++
+	}
+
+	run.Removed, err = queries.DeleteStaleReplenishmentSuggestions(ctx, keepIDs)
+	if err != nil {
+		return run, fmt.Errorf("failed to remove stale suggestions: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return run, fmt.Errorf("failed to commit suggestions: %w", err)
+	}
+	return run, nil
+}
+
+// ReplenishmentJob runs GenerateReplenishmentSuggestions as a background job
+func (po *POSHandler) ReplenishmentJob(ctx context.Context, _ json.RawMessage) (interface{}, error) {
+	run, err := po.GenerateReplenishmentSuggestions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	po.h.Logger.Info("replenishment suggestions generated",
+		"evaluated", run.Evaluated,
+		"suggested", run.Suggested,
+		"removed", run.Removed,
+	)
+	return run, nil
+}
+
+// SetPlanningParameters creates or replaces the planning parameters of a material in a warehouse.
+func (po *POSHandler) SetPlanningParameters(w http.ResponseWriter, r *http.Request) {
+	var req PlanningParametersRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		config.RespondBadRequest(w, "Invalid request payload", err.Error())
+		return
+	}
+
+	if req.MaterialID <= 0 || req.WarehouseID <= 0 {
+		config.RespondBadRequest(w, "Missing required fields", "material_id and warehouse_id are required")
+		return
+	}
+	if req.MinQuantity < 0 || req.ReorderPoint < 0 || req.ReorderQuantity < 0 {
+		config.RespondBadRequest(w, "Invalid planning parameters", "Quantities cannot be negative")
+		return
+	}
+	if req.MaxQuantity != nil && *req.MaxQuantity < req.MinQuantity {
+		config.RespondBadRequest(w, "Invalid planning parameters", "max_quantity cannot be below min_quantity")
+		return
+	}
+	if req.ReorderQuantity <= 0 && req.MaxQuantity == nil {
+		config.RespondBadRequest(w, "Invalid planning parameters", "Either reorder_quantity or max_quantity is required")
+		return
+	}
+
+	params := db.UpsertPlanningParametersParams{
+		MaterialID:      req.MaterialID,
+		WarehouseID:     req.WarehouseID,
+		MinQuantity:     numericFromFloat(req.MinQuantity),
+		ReorderPoint:    numericFromFloat(req.ReorderPoint),
+		ReorderQuantity: numericFromFloat(req.ReorderQuantity),
+		IsActive:        true,
+	}
+	if req.MaxQuantity != nil {
+		params.MaxQuantity = numericFromFloat(*req.MaxQuantity)
+	}
+	if req.PreferredSupplierID != nil && *req.PreferredSupplierID > 0 {
+		params.PreferredSupplierID = pgtype.Int4{Int32: *req.PreferredSupplierID, Valid: true}
+	}
+	if req.IsActive != nil {
+		params.IsActive = *req.IsActive
+	}
+
+	parameters, err := po.h.Queries.UpsertPlanningParameters(r.Context(), params)
+	if err != nil {
+		po.h.Logger.Error("Failed to save planning parameters", "error", err)
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusOK, parameters)
+}
+
+// ListPlanningParameters lists planning parameters, optionally of one material or warehouse.
+func (po *POSHandler) ListPlanningParameters(w http.ResponseWriter, r *http.Request) {
+	var materialID, warehouseID int32
+	if v := r.URL.Query().Get("material_id"); v != "" {
+		if _, err := fmt.Sscanf(v, "%d", &materialID); err != nil {
+			config.RespondBadRequest(w, "Invalid material_id", err.Error())
+			return
+		}
+	}
+	if v := r.URL.Query().Get("warehouse_id"); v != "" {
+		if _, err := fmt.Sscanf(v, "%d", &warehouseID); err != nil {
+			config.RespondBadRequest(w, "Invalid warehouse_id", err.Error())
+			return
+		}
+	}
+
+	parameters, err := po.h.Queries.ListPlanningParameters(r.Context(), db.ListPlanningParametersParams{
+		MaterialID:  pgtype.Int4{Int32: materialID, Valid: materialID > 0},
+		WarehouseID: pgtype.Int4{Int32: warehouseID, Valid: warehouseID > 0},
+	})
+	if err != nil {
+		po.h.Logger.Error("Failed to list planning parameters", "error", err)
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusOK, map[string]any{
+		"planning_parameters": parameters,
+	})
+}
+
+// DeletePlanningParameters deletes planning parameters by ID.
+func (po *POSHandler) DeletePlanningParameters(w http.ResponseWriter, r *http.Request) {
+	var id int32
+	if _, err := fmt.Sscanf(r.PathValue("id"), "%d", &id); err != nil {
+		config.RespondBadRequest(w, "Invalid planning parameters ID format", err.Error())
+		return
+	}
+
+	deleted, err := po.h.Queries.DeletePlanningParameters(r.Context(), id)
+	if err != nil {
+		po.h.Logger.Error("Failed to delete planning parameters", "error", err)
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if deleted == 0 {
+		config.RespondJSON(w, http.StatusNotFound, map[string]string{"error": "Planning parameters not found"})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusOK, map[string]string{
+		"message": "Planning parameters deleted successfully",
+	})
+}
+
+// RunReplenishment runs the replenishment job right away.
+func (po *POSHandler) RunReplenishment(w http.ResponseWriter, r *http.Request) {
+	run, err := po.GenerateReplenishmentSuggestions(r.Context())
+	if err != nil {
+		po.h.Logger.Error("Failed to generate replenishment suggestions", "error", err)
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to generate replenishment suggestions"})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusOK, run)
+}
+
+// ListReplenishmentSuggestions lists suggestions, the open ones unless a status is given.
+func (po *POSHandler) ListReplenishmentSuggestions(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = "Open"
+	}
+	if status != "Open" && status != "Converted" && status != "Dismissed" && status != "all" {
+		config.RespondBadRequest(w, "Invalid status", "Status must be one of: Open, Converted, Dismissed, all")
+		return
+	}
+
+	var warehouseID, supplierID int32
+	if v := r.URL.Query().Get("warehouse_id"); v != "" {
+		if _, err := fmt.Sscanf(v, "%d", &warehouseID); err != nil {
+			config.RespondBadRequest(w, "Invalid warehouse_id", err.Error())
+			return
+		}
+	}
+	if v := r.URL.Query().Get("supplier_id"); v != "" {
+		if _, err := fmt.Sscanf(v, "%d", &supplierID); err != nil {
+			config.RespondBadRequest(w, "Invalid supplier_id", err.Error())
+			return
+		}
+	}
+
+	suggestions, err := po.h.Queries.ListReplenishmentSuggestions(r.Context(), db.ListReplenishmentSuggestionsParams{
+		Status:      pgtype.Text{String: status, Valid: status != "all"},
+		WarehouseID: pgtype.Int4{Int32: warehouseID, Valid: warehouseID > 0},
+		SupplierID:  pgtype.Int4{Int32: supplierID, Valid: supplierID > 0},
+	})
+	if err != nil {
+		po.h.Logger.Error("Failed to list replenishment suggestions", "error", err)
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusOK, map[string]any{
+		"suggestions": suggestions,
+	})
+}
+
+// DismissReplenishmentSuggestion dismisses an open suggestion. The next run
+// suggests the order again if it is still needed.
+func (po *POSHandler) DismissReplenishmentSuggestion(w http.ResponseWriter, r *http.Request) {
+	var id int32
+	if _, err := fmt.Sscanf(r.PathValue("id"), "%d", &id); err != nil {
+		config.RespondBadRequest(w, "Invalid suggestion ID format", err.Error())
+		return
+	}
+
+	suggestion, err := po.h.Queries.DismissReplenishmentSuggestion(r.Context(), id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		config.RespondJSON(w, http.StatusNotFound, map[string]string{"error": "Open suggestion not found"})
+		return
+	}
+	if err != nil {
+		po.h.Logger.Error("Failed to dismiss replenishment suggestion", "error", err)
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusOK, suggestion)
+}
+
+// ConvertReplenishmentSuggestions turns open suggestions into Pending
+// purchase orders, one per supplier and warehouse, through the same logic as
+// CreatePurchaseOrder. Either all suggestions are converted or none.
+func (po *POSHandler) ConvertReplenishmentSuggestions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req ConvertSuggestionsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		config.RespondBadRequest(w, "Invalid request payload", err.Error())
+		return
+	}
+	if len(req.SuggestionIDs) == 0 {
+		config.RespondBadRequest(w, "Missing required fields", "At least one suggestion ID is required")
+		return
+	}
+
+	var userID int32
+	if session, ok := middlewares.GetSessionFromContext(r); ok {
+		fmt.Sscanf(session.UserID, "%d", &userID)
+	}
+
+	tx, err := po.h.DB.Begin(ctx)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to start transaction"})
+		return
+	}
+	defer tx.Rollback(ctx)
+	queries := po.h.Queries.WithTx(tx)
+
+	suggestions, err := queries.GetReplenishmentSuggestionsForUpdate(ctx, req.SuggestionIDs)
+	if err != nil {
+		po.h.Logger.Error("Failed to get replenishment suggestions", "error", err)
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	found := make(map[int32]bool, len(suggestions))
+	for _, s := range suggestions {
+		found[s.ID] = true
+	}
+	for _, id := range req.SuggestionIDs {
+		if !found[id] {
+			config.RespondJSON(w, http.StatusNotFound, map[string]string{"error": fmt.Sprintf("Suggestion %d not found", id)})
+			return
+		}
+	}
+
+	// One order per supplier and warehouse, in the order they first appear
+	type orderGroup struct {
+		supplierID  int32
+		warehouseID int32
+		suggestions []db.ReplenishmentSuggestion
+	}
+	var groups []*orderGroup
+	byKey := map[[2]int32]*orderGroup{}
+	for _, s := range suggestions {
+		if s.Status != "Open" {
+			config.RespondJSON(w, http.StatusConflict, map[string]string{"error": fmt.Sprintf("Suggestion %d is %s", s.ID, s.Status)})
+			return
+		}
+		if !s.SupplierID.Valid {
+			config.RespondBadRequest(w, "Missing supplier", fmt.Sprintf("Suggestion %d has no supplier, set a preferred supplier in the planning parameters", s.ID))
+			return
+		}
+		if floatFromNumeric(s.UnitPrice) <= 0 {
+			config.RespondBadRequest(w, "Missing price", fmt.Sprintf("Suggestion %d has no unit price, the material has no price and was never purchased", s.ID))
+			return
+		}
+
+		key := [2]int32{s.SupplierID.Int32, s.WarehouseID}
+		group, ok := byKey[key]
+		if !ok {
+			group = &orderGroup{supplierID: s.SupplierID.Int32, warehouseID: s.WarehouseID}
+			byKey[key] = group
+			groups = append(groups, group)
+		}
+		group.suggestions = append(group.suggestions, s)
+	}
+
+	orders := make([]map[string]any, 0, len(groups))
+	for _, group := range groups {
+		// Numbers come from a sequence, concurrent conversions never share one
+		orderNumber, err := queries.NextReplenishmentOrderNumber(ctx)
+		if err != nil {
+			po.h.Logger.Error("Failed to get purchase order number", "error", err)
+			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+
+		meta := replenishmentMeta{Source: "replenishment", WarehouseID: group.warehouseID}
+		orderReq := CreatePurchaseOrderRequest{
+			OrderNumber:          orderNumber,
+			SupplierID:           group.supplierID,
+			ExpectedDeliveryDate: req.ExpectedDeliveryDate,
+			Status:               "Pending",
+		}
+		for _, s := range group.suggestions {
+			meta.SuggestionIDs = append(meta.SuggestionIDs, s.ID)
+			orderReq.Items = append(orderReq.Items, PurchaseOrderItemRequest{
+				MaterialID: s.MaterialID,
+				Quantity:   floatFromNumeric(s.SuggestedQuantity),
+				UnitPrice:  floatFromNumeric(s.UnitPrice),
+			})
+		}
+		orderReq.Meta, _ = json.Marshal(meta)
+
+		purchaseOrder, items, poErr := po.createPurchaseOrder(ctx, queries, orderReq, userID)
+		if poErr != nil {
+			poErr.respond(w)
+			return
+		}
+
+		for _, s := range group.suggestions {
+			if err := queries.MarkReplenishmentSuggestionConverted(ctx, db.MarkReplenishmentSuggestionConvertedParams{
+				ID:              s.ID,
+				PurchaseOrderID: pgtype.Int4{Int32: purchaseOrder.ID, Valid: true},
+				ConvertedBy:     pgtype.Int4{Int32: userID, Valid: userID > 0},
+			}); err != nil {
+				po.h.Logger.Error("Failed to mark suggestion converted", "suggestion_id", s.ID, "error", err)
+				config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+		}
+
+		orders = append(orders, map[string]any{
+			"purchase_order": purchaseOrder,
+			"items":          items,
+		})
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to commit transaction"})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusCreated, map[string]any{
+		"purchase_orders": orders,
+	})
+}
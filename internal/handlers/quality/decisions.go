@@ -0,0 +1,143 @@
+package quality
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	db "warehouse_system/internal/database/db"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// INSPECTION DECISIONS
+// ============================================================================
+
+// Outcome of a decided inspection
+const (
+	inspectionUndecided = ""
+	inspectionPassed    = "passed"
+	inspectionFailed    = "failed"
+)
+
+// inspectionOutcome reads the decision of an inspection. A final decision
+// other than quarantine wins over the inspection status.
+func inspectionOutcome(inspection db.QualityInspection) string {
+	if inspection.FinalDecision.Valid {
+		switch inspection.FinalDecision.QualityStatus {
+		case db.QualityStatusUnrestricted:
+			return inspectionPassed
+		case db.QualityStatusBlocked, db.QualityStatusRejected:
+			return inspectionFailed
+		}
+	}
+
+	switch inspection.InspectionStatus.QualityInspectionStatus {
+	case db.QualityInspectionStatusPassed:
+		return inspectionPassed
+	case db.QualityInspectionStatusFailed:
+		return inspectionFailed
+	}
+	return inspectionUndecided
+}
+
+// applyInspectionDecision acts on the stock an inspection holds once it is
+// decided. A pass releases the holds the inspection placed. A fail keeps
+// them, blocked or rejected, and raises an NCR about the held stock unless
+// the inspection already has one. Nothing happens until the decision
+// changes, so saving a decided inspection again is harmless.
+func applyInspectionDecision(ctx context.Context, queries *db.Queries, before, after db.QualityInspection, userID pgtype.Int4) error {
+	outcome := inspectionOutcome(after)
+	if outcome == inspectionUndecided || outcome == inspectionOutcome(before) {
+		return nil
+	}
+
+	holds, err := queries.ListActiveQualityHoldsByInspection(ctx, pgtype.Int4{Int32: after.ID, Valid: true})
+	if err != nil {
+		return fmt.Errorf("failed to get inspection holds: %w", err)
+	}
+	if len(holds) == 0 {
+		return nil
+	}
+
+	if outcome == inspectionPassed {
+		for _, hold := range holds {
+			if _, err := queries.ReleaseQualityHold(ctx, db.ReleaseQualityHoldParams{
+				ID:           hold.ID,
+				ReleasedBy:   userID,
+				ReleaseNotes: pgtype.Text{String: fmt.Sprintf("Inspection %s passed", after.InspectionNumber), Valid: true},
+			}); err != nil {
+				return fmt.Errorf("failed to release hold %s: %w", hold.HoldNumber, err)
+			}
+		}
+		return nil
+	}
+
+	status := db.QualityStatusRejected
+	if after.FinalDecision.Valid && after.FinalDecision.QualityStatus == db.QualityStatusBlocked {
+		status = db.QualityStatusBlocked
+	}
+
+	reports, err := queries.ListNonConformanceReportsByInspection(ctx, pgtype.Int4{Int32: after.ID, Valid: true})
+	if err != nil {
+		return fmt.Errorf("failed to get inspection NCRs: %w", err)
+	}
+	var ncrID pgtype.Int4
+	if len(reports) == 0 {
+		ncr, err := createInspectionNCR(ctx, queries, after, userID)
+		if err != nil {
+			return err
+		}
+		ncrID = pgtype.Int4{Int32: ncr.ID, Valid: true}
+	}
+
+	if err := queries.FailInspectionQualityHolds(ctx, db.FailInspectionQualityHoldsParams{
+		InspectionID:  pgtype.Int4{Int32: after.ID, Valid: true},
+		QualityStatus: status,
+		NcrID:         ncrID,
+	}); err != nil {
+		return fmt.Errorf("failed to update inspection holds: %w", err)
+	}
+	return nil
+}
+
+// createInspectionNCR opens the NCR of a failed inspection. The failed
+// quantity is affected when it was recorded, otherwise the whole quantity.
+func createInspectionNCR(ctx context.Context, queries *db.Queries, inspection db.QualityInspection, userID pgtype.Int4) (db.NonConformanceReport, error) {
+	ncrType := db.NcrTypeProcess
+	switch inspection.InspectionType {
+	case db.QualityInspectionTypeIncoming:
+		ncrType = db.NcrTypeSupplier
+	case db.QualityInspectionTypeCustomerReturn:
+		ncrType = db.NcrTypeCustomer
+	}
+
+	quantity := inspection.Quantity
+	if f, err := inspection.QuantityFailed.Float64Value(); err == nil && f.Valid && f.Float64 > 0 {
+		quantity = inspection.QuantityFailed
+	}
+
+	ncr, err := queries.CreateNonConformanceReport(ctx, db.CreateNonConformanceReportParams{
+		NcrNumber:        "", // Trigger will generate
+		Title:            fmt.Sprintf("Inspection %s failed", inspection.InspectionNumber),
+		Description:      fmt.Sprintf("Batch %s failed %s inspection %s and is held", inspection.BatchNumber.String, inspection.InspectionType, inspection.InspectionNumber),
+		NcrType:          ncrType,
+		Severity:         db.NcrSeverityMajor,
+		Status:           db.NullNcrStatus{NcrStatus: db.NcrStatusOpen, Valid: true},
+		MaterialID:       inspection.MaterialID,
+		BatchNumber:      inspection.BatchNumber,
+		QuantityAffected: quantity,
+		UnitID:           inspection.UnitID,
+		InspectionID:     pgtype.Int4{Int32: inspection.ID, Valid: true},
+		SupplierID:       inspection.SupplierID,
+		PurchaseOrderID:  inspection.PurchaseOrderID,
+		SalesOrderID:     inspection.SalesOrderID,
+		ReportedBy:       userID,
+		ReportedDate:     pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		return ncr, fmt.Errorf("failed to create NCR: %w", err)
+	}
+	return ncr, nil
+}
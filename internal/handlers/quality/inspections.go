@@ -3,12 +3,14 @@ package quality
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
 	"warehouse_system/internal/config"
 	db "warehouse_system/internal/database/db"
+	"warehouse_system/internal/middlewares"
 
 	"github.com/jackc/pgx/v5/pgtype"
 )
@@ -343,13 +345,46 @@ func (qh *QualityHandler) UpdateQualityInspection(w http.ResponseWriter, r *http
 		params.Attachments = req.Attachments
 	}
 
-	inspection, err := qh.h.Queries.UpdateQualityInspection(context.Background(), params)
+	ctx := r.Context()
+	tx, err := qh.h.DB.Begin(ctx)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback(ctx)
+	queries := qh.h.Queries.WithTx(tx)
+
+	before, err := queries.GetQualityInspectionForUpdate(ctx, int32(id))
+	if err != nil {
+		config.RespondJSON(w, http.StatusNotFound, "Quality inspection not found")
+		return
+	}
+
+	inspection, err := queries.UpdateQualityInspection(ctx, params)
 	if err != nil {
 		qh.h.Logger.Error("Failed to update quality inspection", "error", err)
 		config.RespondJSON(w, http.StatusInternalServerError, "Failed to update quality inspection")
 		return
 	}
 
+	// A decision releases or keeps the stock the inspection holds
+	var userID pgtype.Int4
+	if session, ok := middlewares.GetSessionFromContext(r); ok {
+		if _, err := fmt.Sscanf(session.UserID, "%d", &userID.Int32); err == nil {
+			userID.Valid = true
+		}
+	}
+	if err := applyInspectionDecision(ctx, queries, before, inspection, userID); err != nil {
+		qh.h.Logger.Error("Failed to apply inspection decision", "error", err)
+		config.RespondJSON(w, http.StatusInternalServerError, "Failed to apply inspection decision")
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, "Failed to commit transaction")
+		return
+	}
+
 	config.RespondJSON(w, http.StatusOK, inspection)
 }
 
@@ -0,0 +1,413 @@
+package reports
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"warehouse_system/internal/config"
+	db "warehouse_system/internal/database/db"
+	"warehouse_system/internal/handlers"
+)
+
+// =====================================================
+// INVENTORY VALUATION
+// =====================================================
+
+// InventoryValuationLine is the on-hand stock of one material in one
+// warehouse valued by its valuation method
+type InventoryValuationLine struct {
+	MaterialID      int32              `json:"material_id"`
+	MaterialCode    string             `json:"material_code"`
+	MaterialName    string             `json:"material_name"`
+	CategoryID      int32              `json:"category_id"`
+	CategoryName    string             `json:"category_name"`
+	WarehouseID     int32              `json:"warehouse_id"`
+	WarehouseCode   string             `json:"warehouse_code"`
+	WarehouseName   string             `json:"warehouse_name"`
+	ValuationMethod db.ValuationMethod `json:"valuation_method"`
+	Unit            string             `json:"unit"`
+	Quantity        float64            `json:"quantity"`
+	BatchCount      int64              `json:"batch_count"`
+	BatchValue      float64            `json:"batch_value"`
+	AverageUnitCost float64            `json:"average_unit_cost"`
+	UnitCost        float64            `json:"unit_cost"`
+	Value           float64            `json:"value"`
+}
+
+// InventoryValuationInTransitLine is the stock of one material shipped to one
+// warehouse and not received yet, valued at the cost it was shipped with
+type InventoryValuationInTransitLine struct {
+	MaterialID    int32   `json:"material_id"`
+	MaterialCode  string  `json:"material_code"`
+	MaterialName  string  `json:"material_name"`
+	CategoryID    int32   `json:"category_id"`
+	CategoryName  string  `json:"category_name"`
+	WarehouseID   int32   `json:"warehouse_id"`
+	WarehouseCode string  `json:"warehouse_code"`
+	WarehouseName string  `json:"warehouse_name"`
+	Unit          string  `json:"unit"`
+	Shipments     int64   `json:"shipments"`
+	Quantity      float64 `json:"quantity"`
+	Value         float64 `json:"value"`
+}
+
+// InventoryValuationTotal is the value of the stock of one category or one
+// warehouse. Quantities are left out, they are in different units.
+type InventoryValuationTotal struct {
+	ID    int32   `json:"id"`
+	Code  string  `json:"code,omitempty"`
+	Name  string  `json:"name"`
+	Lines int     `json:"lines"`
+	Value float64 `json:"value"`
+}
+
+// InventoryValuationResponse is the inventory valuation report. The total
+// is the on-hand value plus the value in transit, which is counted at the
+// destination warehouse.
+type InventoryValuationResponse struct {
+	AsOf           time.Time                         `json:"as_of"`
+	OnHandValue    float64                           `json:"on_hand_value"`
+	InTransitValue float64                           `json:"in_transit_value"`
+	TotalValue     float64                           `json:"total_value"`
+	ByCategory     []InventoryValuationTotal         `json:"by_category"`
+	ByWarehouse    []InventoryValuationTotal         `json:"by_warehouse"`
+	Lines          []InventoryValuationLine          `json:"lines"`
+	InTransit      []InventoryValuationInTransitLine `json:"in_transit"`
+}
+
+// uncategorized is the name of the total of materials without a category
+const uncategorized = "Uncategorized"
+
+// valuationLine values one row of GetInventoryValuation. FIFO and LIFO stock
+// is valued at the cost of the batches that are left; weighted average
+// stock at the running average cost, or at batch cost while the material
+// has not been costed yet.
+func valuationLine(row db.GetInventoryValuationRow) InventoryValuationLine {
+	line := InventoryValuationLine{
+		MaterialID:      row.MaterialID,
+		MaterialCode:    row.MaterialCode,
+		MaterialName:    row.MaterialName,
+		CategoryID:      row.CategoryID.Int32,
+		CategoryName:    row.CategoryName.String,
+		WarehouseID:     row.WarehouseID,
+		WarehouseCode:   row.WarehouseCode,
+		WarehouseName:   row.WarehouseName,
+		ValuationMethod: row.ValuationMethod,
+		Unit:            row.Unit.String,
+		Quantity:        numericToFloat(row.Quantity),
+		BatchCount:      row.BatchCount,
+		BatchValue:      numericToFloat(row.BatchValue),
+		AverageUnitCost: numericToFloat(row.AverageUnitCost),
+	}
+	if !row.CategoryID.Valid {
+		line.CategoryName = uncategorized
+	}
+
+	line.Value = line.BatchValue
+	if line.ValuationMethod == db.ValuationMethodWeightedAverage && line.AverageUnitCost > 0 {
+		line.Value = line.Quantity * line.AverageUnitCost
+	}
+	if line.Quantity != 0 {
+		line.UnitCost = line.Value / line.Quantity
+	}
+	return line
+}
+
+// inTransitLine converts one row of GetInTransitValuation
+func inTransitLine(row db.GetInTransitValuationRow) InventoryValuationInTransitLine {
+	line := InventoryValuationInTransitLine{
+		MaterialID:    row.MaterialID,
+		MaterialCode:  row.MaterialCode,
+		MaterialName:  row.MaterialName,
+		CategoryID:    row.CategoryID.Int32,
+		CategoryName:  row.CategoryName.String,
+		WarehouseID:   row.WarehouseID,
+		WarehouseCode: row.WarehouseCode,
+		WarehouseName: row.WarehouseName,
+		Unit:          row.Unit.String,
+		Shipments:     row.ShipmentCount,
+		Quantity:      numericToFloat(row.Quantity),
+		Value:         numericToFloat(row.Value),
+	}
+	if !row.CategoryID.Valid {
+		line.CategoryName = uncategorized
+	}
+	return line
+}
+
+// buildInventoryValuation values the stock on hand and in transit, optionally
+// at a past moment and limited to a warehouse and/or a category
+func (rh *ReportHandler) buildInventoryValuation(r *http.Request) (InventoryValuationResponse, int, error) {
+	asOf, err := handlers.ParseAsOf(r)
+	if err != nil {
+		return InventoryValuationResponse{}, http.StatusBadRequest, err
+	}
+	if status, err := rh.h.CheckStockHistory(r.Context(), asOf); err != nil {
+		return InventoryValuationResponse{}, status, err
+	}
+	warehouseID, err := parseOptionalID(r, "warehouse_id")
+	if err != nil {
+		return InventoryValuationResponse{}, http.StatusBadRequest, err
+	}
+	categoryID, err := parseOptionalID(r, "category_id")
+	if err != nil {
+		return InventoryValuationResponse{}, http.StatusBadRequest, err
+	}
+
+	rows, err := rh.h.Queries.GetInventoryValuation(r.Context(), db.GetInventoryValuationParams{
+		AsOf:        asOf,
+		WarehouseID: warehouseID,
+		CategoryID:  categoryID,
+	})
+	if err != nil {
+		rh.h.Logger.Error("Failed to get inventory valuation", "error", err)
+		return InventoryValuationResponse{}, http.StatusInternalServerError, fmt.Errorf("failed to get inventory valuation")
+	}
+
+	inTransitRows, err := rh.h.Queries.GetInTransitValuation(r.Context(), db.GetInTransitValuationParams{
+		AsOf:        asOf,
+		WarehouseID: warehouseID,
+		CategoryID:  categoryID,
+	})
+	if err != nil {
+		rh.h.Logger.Error("Failed to get in-transit valuation", "error", err)
+		return InventoryValuationResponse{}, http.StatusInternalServerError, fmt.Errorf("failed to get in-transit valuation")
+	}
+
+	response := InventoryValuationResponse{
+		AsOf:      time.Now(),
+		Lines:     make([]InventoryValuationLine, 0, len(rows)),
+		InTransit: make([]InventoryValuationInTransitLine, 0, len(inTransitRows)),
+	}
+	if asOf.Valid {
+		response.AsOf = asOf.Time
+	}
+
+	categories := map[int32]*InventoryValuationTotal{}
+	warehouses := map[int32]*InventoryValuationTotal{}
+	addToTotals := func(categoryID int32, categoryName string, warehouseID int32, warehouseCode, warehouseName string, value float64) {
+		category, ok := categories[categoryID]
+		if !ok {
+			category = &InventoryValuationTotal{ID: categoryID, Name: categoryName}
+			categories[categoryID] = category
+		}
+		category.Lines++
+		category.Value += value
+
+		warehouse, ok := warehouses[warehouseID]
+		if !ok {
+			warehouse = &InventoryValuationTotal{ID: warehouseID, Code: warehouseCode, Name: warehouseName}
+			warehouses[warehouseID] = warehouse
+		}
+		warehouse.Lines++
+		warehouse.Value += value
+	}
+
+	for _, row := range rows {
+		line := valuationLine(row)
+		response.Lines = append(response.Lines, line)
+		response.OnHandValue += line.Value
+		addToTotals(line.CategoryID, line.CategoryName, line.WarehouseID, line.WarehouseCode, line.WarehouseName, line.Value)
+	}
+
+	for _, row := range inTransitRows {
+		line := inTransitLine(row)
+		response.InTransit = append(response.InTransit, line)
+		response.InTransitValue += line.Value
+		addToTotals(line.CategoryID, line.CategoryName, line.WarehouseID, line.WarehouseCode, line.WarehouseName, line.Value)
+	}
+	response.TotalValue = response.OnHandValue + response.InTransitValue
+
+	response.ByCategory = sortedTotals(categories)
+	response.ByWarehouse = sortedTotals(warehouses)
+	return response, http.StatusOK, nil
+}
+
+// sortedTotals lists totals by name, uncategorized last
+func sortedTotals(totals map[int32]*InventoryValuationTotal) []InventoryValuationTotal {
+	list := make([]InventoryValuationTotal, 0, len(totals))
+	for _, t := range totals {
+		list = append(list, *t)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if (list[i].ID == 0) != (list[j].ID == 0) {
+			return list[j].ID == 0
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// GetInventoryValuation - Value the on-hand and in-transit stock per material
+// and warehouse with totals by category and warehouse
+func (rh *ReportHandler) GetInventoryValuation(w http.ResponseWriter, r *http.Request) {
+	response, status, err := rh.buildInventoryValuation(r)
+	if err != nil {
+		config.RespondJSON(w, status, map[string]string{"error": err.Error()})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusOK, response)
+}
+
+// ExportInventoryValuation - Export the inventory valuation as Excel, with
+// the lines, the stock in transit and the totals by category and warehouse
+// on separate sheets
+func (rh *ReportHandler) ExportInventoryValuation(w http.ResponseWriter, r *http.Request) {
+	response, status, err := rh.buildInventoryValuation(r)
+	if err != nil {
+		config.RespondJSON(w, status, map[string]string{"error": err.Error()})
+		return
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	style, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"#E0E0E0"}, Pattern: 1},
+	})
+
+	sheet := "Valuation"
+	index, _ := f.NewSheet(sheet)
+	f.SetActiveSheet(index)
+	f.DeleteSheet("Sheet1")
+
+	// Define headers
+	headers := []string{
+		"Category",
+		"Warehouse",
+		"Material Code",
+		"Material Name",
+		"Valuation Method",
+		"Unit",
+		"Quantity",
+		"Batches",
+		"Batch Value",
+		"Average Unit Cost",
+		"Unit Cost",
+		"Value",
+	}
+
+	// Set headers
+	for i, h := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheet, cell, h)
+	}
+
+	for i, l := range response.Lines {
+		row := i + 2
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), l.CategoryName)
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), l.WarehouseName)
+		f.SetCellValue(sheet, fmt.Sprintf("C%d", row), l.MaterialCode)
+		f.SetCellValue(sheet, fmt.Sprintf("D%d", row), l.MaterialName)
+		f.SetCellValue(sheet, fmt.Sprintf("E%d", row), string(l.ValuationMethod))
+		f.SetCellValue(sheet, fmt.Sprintf("F%d", row), l.Unit)
+		f.SetCellValue(sheet, fmt.Sprintf("G%d", row), l.Quantity)
+		f.SetCellValue(sheet, fmt.Sprintf("H%d", row), l.BatchCount)
+		f.SetCellValue(sheet, fmt.Sprintf("I%d", row), l.BatchValue)
+		f.SetCellValue(sheet, fmt.Sprintf("J%d", row), l.AverageUnitCost)
+		f.SetCellValue(sheet, fmt.Sprintf("K%d", row), l.UnitCost)
+		f.SetCellValue(sheet, fmt.Sprintf("L%d", row), l.Value)
+	}
+
+	totalRow := len(response.Lines) + 2
+	f.SetCellValue(sheet, fmt.Sprintf("A%d", totalRow), "Total")
+	f.SetCellValue(sheet, fmt.Sprintf("L%d", totalRow), response.OnHandValue)
+	f.SetCellStyle(sheet, fmt.Sprintf("A%d", totalRow), fmt.Sprintf("L%d", totalRow), style)
+
+	// Style headers
+	f.SetCellStyle(sheet, "A1", "L1", style)
+
+	// Set column widths
+	f.SetColWidth(sheet, "A", "B", 20)
+	f.SetColWidth(sheet, "C", "C", 15)
+	f.SetColWidth(sheet, "D", "D", 30)
+	f.SetColWidth(sheet, "E", "E", 18)
+	f.SetColWidth(sheet, "F", "H", 10)
+	f.SetColWidth(sheet, "I", "L", 16)
+
+	writeInTransitValuation(f, response.InTransit, response.InTransitValue, style)
+	writeValuationTotals(f, "By Category", "Category", response.ByCategory, response.TotalValue, style)
+	writeValuationTotals(f, "By Warehouse", "Warehouse", response.ByWarehouse, response.TotalValue, style)
+
+	// Write to response
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=inventory_valuation_%s.xlsx", response.AsOf.Format("2006-01-02")))
+
+	if err := f.Write(w); err != nil {
+		rh.h.Logger.Error("Failed to write inventory valuation", "error", err)
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to generate inventory valuation"})
+		return
+	}
+}
+
+// writeInTransitValuation adds a sheet with the stock in transit per material
+// and destination warehouse and its total below
+func writeInTransitValuation(f *excelize.File, lines []InventoryValuationInTransitLine, totalValue float64, style int) {
+	sheet := "In Transit"
+	f.NewSheet(sheet)
+
+	headers := []string{"Category", "Warehouse", "Material Code", "Material Name", "Unit", "Shipments", "Quantity", "Value"}
+	for i, h := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheet, cell, h)
+	}
+
+	for i, l := range lines {
+		row := i + 2
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), l.CategoryName)
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), l.WarehouseName)
+		f.SetCellValue(sheet, fmt.Sprintf("C%d", row), l.MaterialCode)
+		f.SetCellValue(sheet, fmt.Sprintf("D%d", row), l.MaterialName)
+		f.SetCellValue(sheet, fmt.Sprintf("E%d", row), l.Unit)
+		f.SetCellValue(sheet, fmt.Sprintf("F%d", row), l.Shipments)
+		f.SetCellValue(sheet, fmt.Sprintf("G%d", row), l.Quantity)
+		f.SetCellValue(sheet, fmt.Sprintf("H%d", row), l.Value)
+	}
+
+	totalRow := len(lines) + 2
+	f.SetCellValue(sheet, fmt.Sprintf("A%d", totalRow), "Total")
+	f.SetCellValue(sheet, fmt.Sprintf("H%d", totalRow), totalValue)
+	f.SetCellStyle(sheet, fmt.Sprintf("A%d", totalRow), fmt.Sprintf("H%d", totalRow), style)
+
+	f.SetCellStyle(sheet, "A1", "H1", style)
+	f.SetColWidth(sheet, "A", "B", 20)
+	f.SetColWidth(sheet, "C", "C", 15)
+	f.SetColWidth(sheet, "D", "D", 30)
+	f.SetColWidth(sheet, "E", "G", 10)
+	f.SetColWidth(sheet, "H", "H", 16)
+}
+
+// writeValuationTotals adds a sheet with one valuation total per row and the
+// grand total below
+func writeValuationTotals(f *excelize.File, sheet, label string, totals []InventoryValuationTotal, totalValue float64, style int) {
+	f.NewSheet(sheet)
+
+	headers := []string{label, "Lines", "Value"}
+	for i, h := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheet, cell, h)
+	}
+
+	for i, t := range totals {
+		row := i + 2
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), t.Name)
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), t.Lines)
+		f.SetCellValue(sheet, fmt.Sprintf("C%d", row), t.Value)
+	}
+
+	totalRow := len(totals) + 2
+	f.SetCellValue(sheet, fmt.Sprintf("A%d", totalRow), "Total")
+	f.SetCellValue(sheet, fmt.Sprintf("C%d", totalRow), totalValue)
+	f.SetCellStyle(sheet, fmt.Sprintf("A%d", totalRow), fmt.Sprintf("C%d", totalRow), style)
+
+	f.SetCellStyle(sheet, "A1", "C1", style)
+	f.SetColWidth(sheet, "A", "A", 30)
+	f.SetColWidth(sheet, "B", "B", 10)
+	f.SetColWidth(sheet, "C", "C", 16)
+}
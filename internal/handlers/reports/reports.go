@@ -0,0 +1,41 @@
+package reports
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"warehouse_system/internal/handlers"
+)
+
+type ReportHandler struct {
+	h *handlers.Handler
+}
+
+func NewReportHandler(h *handlers.Handler) *ReportHandler {
+	return &ReportHandler{h: h}
+}
+
+// numericToFloat converts pgtype.Numeric to float64, NULL is 0
+func numericToFloat(n pgtype.Numeric) float64 {
+	f, err := n.Float64Value()
+	if err != nil || !f.Valid {
+		return 0
+	}
+	return f.Float64
+}
+
+// parseOptionalID reads an optional ID query parameter, empty is NULL
+func parseOptionalID(r *http.Request, name string) (pgtype.Int4, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return pgtype.Int4{}, nil
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil || id <= 0 {
+		return pgtype.Int4{}, fmt.Errorf("invalid %s", name)
+	}
+	return pgtype.Int4{Int32: int32(id), Valid: true}, nil
+}
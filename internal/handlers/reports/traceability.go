@@ -0,0 +1,664 @@
+package reports
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/xuri/excelize/v2"
+
+	"warehouse_system/internal/config"
+	db "warehouse_system/internal/database/db"
+)
+
+// =====================================================
+// BATCH TRACEABILITY
+// =====================================================
+
+// Trace directions
+const (
+	traceBoth       = "both"
+	traceUpstream   = "upstream"
+	traceDownstream = "downstream"
+)
+
+// Relations of a batch to the traced batch
+const (
+	relationOrigin     = "origin"
+	relationUpstream   = "upstream"
+	relationDownstream = "downstream"
+)
+
+// TraceBatch is a batch in the trace with its relation to the traced batch.
+// Depth counts the genealogy links between them.
+type TraceBatch struct {
+	ID              int32      `json:"id"`
+	BatchNumber     string     `json:"batch_number"`
+	Relation        string     `json:"relation"`
+	Depth           int32      `json:"depth"`
+	MaterialID      int32      `json:"material_id"`
+	MaterialCode    string     `json:"material_code"`
+	MaterialName    string     `json:"material_name"`
+	Unit            string     `json:"unit"`
+	WarehouseID     int32      `json:"warehouse_id"`
+	WarehouseName   string     `json:"warehouse_name"`
+	SupplierID      int32      `json:"supplier_id,omitempty"`
+	SupplierName    string     `json:"supplier_name,omitempty"`
+	StartQuantity   float64    `json:"start_quantity"`
+	CurrentQuantity float64    `json:"current_quantity"`
+	ManufactureDate *time.Time `json:"manufacture_date,omitempty"`
+	ExpiryDate      *time.Time `json:"expiry_date,omitempty"`
+	HoldNumber      string     `json:"hold_number,omitempty"`
+}
+
+// TraceMovement is a movement of a traced batch. Reversed movements and
+// their reversals are listed but add no edges to the graph.
+type TraceMovement struct {
+	MovementID          int32                `json:"movement_id"`
+	BatchID             int32                `json:"batch_id"`
+	BatchNumber         string               `json:"batch_number"`
+	Relation            string               `json:"relation"`
+	MovementType        db.StockMovementType `json:"movement_type"`
+	Direction           db.StockDirection    `json:"direction"`
+	Quantity            float64              `json:"quantity"`
+	Date                time.Time            `json:"date"`
+	Reference           string               `json:"reference,omitempty"`
+	FromWarehouse       string               `json:"from_warehouse,omitempty"`
+	ToWarehouse         string               `json:"to_warehouse,omitempty"`
+	PurchaseOrderID     int32                `json:"purchase_order_id,omitempty"`
+	PurchaseOrderNumber string               `json:"purchase_order_number,omitempty"`
+	SupplierID          int32                `json:"supplier_id,omitempty"`
+	SupplierName        string               `json:"supplier_name,omitempty"`
+	SalesOrderID        int32                `json:"sales_order_id,omitempty"`
+	SalesOrderNumber    string               `json:"sales_order_number,omitempty"`
+	CustomerID          int32                `json:"customer_id,omitempty"`
+	CustomerName        string               `json:"customer_name,omitempty"`
+	Reversed            bool                 `json:"reversed"`
+}
+
+// TraceOrderLine is a receipt from a supplier or a shipment to a customer of
+// a traced batch
+type TraceOrderLine struct {
+	PartyID      int32     `json:"party_id"`
+	PartyName    string    `json:"party_name"`
+	OrderID      int32     `json:"order_id,omitempty"`
+	OrderNumber  string    `json:"order_number,omitempty"`
+	BatchID      int32     `json:"batch_id"`
+	BatchNumber  string    `json:"batch_number"`
+	MaterialCode string    `json:"material_code"`
+	MaterialName string    `json:"material_name"`
+	Quantity     float64   `json:"quantity"`
+	Unit         string    `json:"unit"`
+	Date         time.Time `json:"date"`
+	MovementID   int32     `json:"movement_id"`
+}
+
+// TraceNode is a batch, supplier, purchase order, warehouse, sales order or
+// customer in the traceability graph
+type TraceNode struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	RefID    int32  `json:"ref_id"`
+	Label    string `json:"label"`
+	Relation string `json:"relation,omitempty"`
+}
+
+// TraceEdge is a flow of goods between two nodes: supplied, received,
+// stored_in, the genealogy link types TRANSFER, RETURN and PRODUCTION,
+// shipped and sold_to
+type TraceEdge struct {
+	From       string   `json:"from"`
+	To         string   `json:"to"`
+	Type       string   `json:"type"`
+	MovementID int32    `json:"movement_id,omitempty"`
+	Quantity   *float64 `json:"quantity,omitempty"`
+}
+
+// BatchTraceResponse is the upstream and/or downstream trace of all batches
+// with a batch number
+type BatchTraceResponse struct {
+	BatchNumber string           `json:"batch_number"`
+	Direction   string           `json:"direction"`
+	Batches     []TraceBatch     `json:"batches"`
+	Movements   []TraceMovement  `json:"movements"`
+	Receipts    []TraceOrderLine `json:"receipts"`
+	Shipments   []TraceOrderLine `json:"shipments"`
+	Nodes       []TraceNode      `json:"nodes"`
+	Edges       []TraceEdge      `json:"edges"`
+}
+
+// traceGraph collects the nodes and edges of a trace once each
+type traceGraph struct {
+	nodes     []TraceNode
+	edges     []TraceEdge
+	seenNodes map[string]bool
+	seenEdges map[string]bool
+}
+
+func newTraceGraph() *traceGraph {
+	return &traceGraph{
+		nodes:     []TraceNode{},
+		edges:     []TraceEdge{},
+		seenNodes: map[string]bool{},
+		seenEdges: map[string]bool{},
+	}
+}
+
+// node adds a node and returns its ID
+func (g *traceGraph) node(nodeType string, refID int32, label, relation string) string {
+	id := fmt.Sprintf("%s:%d", nodeType, refID)
+	if !g.seenNodes[id] {
+		g.seenNodes[id] = true
+		g.nodes = append(g.nodes, TraceNode{ID: id, Type: nodeType, RefID: refID, Label: label, Relation: relation})
+	}
+	return id
+}
+
+func (g *traceGraph) edge(from, to, edgeType string, movementID int32, quantity *float64) {
+	key := fmt.Sprintf("%s>%s>%s>%d", from, to, edgeType, movementID)
+	if g.seenEdges[key] {
+		return
+	}
+	g.seenEdges[key] = true
+	g.edges = append(g.edges, TraceEdge{From: from, To: to, Type: edgeType, MovementID: movementID, Quantity: quantity})
+}
+
+// dateValue converts a pgtype.Date, NULL is nil
+func dateValue(d pgtype.Date) *time.Time {
+	if !d.Valid {
+		return nil
+	}
+	t := d.Time
+	return &t
+}
+
+// optionalQuantity converts a pgtype.Numeric, NULL is nil
+func optionalQuantity(n pgtype.Numeric) *float64 {
+	if !n.Valid {
+		return nil
+	}
+	q := numericToFloat(n)
+	return &q
+}
+
+// relationOrder sorts batches from the furthest upstream to the furthest
+// downstream
+func relationOrder(b TraceBatch) int32 {
+	switch b.Relation {
+	case relationUpstream:
+		return -b.Depth
+	case relationDownstream:
+		return b.Depth
+	}
+	return 0
+}
+
+// traceMovementIncluded keeps the movements that belong to the trace: how
+// upstream batches came in, everything that happened to downstream batches
+// and, depending on the direction, both for the traced batches
+func traceMovementIncluded(relation, direction string, stockDirection db.StockDirection) bool {
+	switch relation {
+	case relationUpstream:
+		return stockDirection == db.StockDirectionIN
+	case relationOrigin:
+		if stockDirection == db.StockDirectionIN {
+			return direction != traceDownstream
+		}
+		return direction != traceUpstream
+	}
+	return true
+}
+
+// buildBatchTrace traces the batches with the batch_number query parameter,
+// optionally limited to material_id, in the requested direction
+func (rh *ReportHandler) buildBatchTrace(r *http.Request) (BatchTraceResponse, int, error) {
+	ctx := r.Context()
+
+	batchNumber := strings.TrimSpace(r.URL.Query().Get("batch_number"))
+	if batchNumber == "" {
+		return BatchTraceResponse{}, http.StatusBadRequest, fmt.Errorf("batch_number is required")
+	}
+	materialID, err := parseOptionalID(r, "material_id")
+	if err != nil {
+		return BatchTraceResponse{}, http.StatusBadRequest, err
+	}
+	direction := r.URL.Query().Get("direction")
+	if direction == "" {
+		direction = traceBoth
+	}
+	if direction != traceBoth && direction != traceUpstream && direction != traceDownstream {
+		return BatchTraceResponse{}, http.StatusBadRequest, fmt.Errorf("direction must be both, upstream or downstream")
+	}
+
+	roots, err := rh.h.Queries.FindBatchesByNumber(ctx, db.FindBatchesByNumberParams{
+		BatchNumber: batchNumber,
+		MaterialID:  materialID,
+	})
+	if err != nil {
+		rh.h.Logger.Error("Failed to find batches", "error", err)
+		return BatchTraceResponse{}, http.StatusInternalServerError, fmt.Errorf("failed to find batches")
+	}
+	if len(roots) == 0 {
+		return BatchTraceResponse{}, http.StatusNotFound, fmt.Errorf("batch not found")
+	}
+
+	type placement struct {
+		relation string
+		depth    int32
+	}
+	placed := map[int32]placement{}
+	for _, id := range roots {
+		placed[id] = placement{relation: relationOrigin}
+	}
+
+	// Genealogy links, the batch a link places is the one further away
+	type traceLink struct {
+		parent, child int32
+		linkType      string
+		movementID    int32
+		quantity      *float64
+	}
+	var links []traceLink
+	hasParent := map[int32]bool{}
+
+	if direction != traceDownstream {
+		upstream, err := rh.h.Queries.TraceBatchesUpstream(ctx, roots)
+		if err != nil {
+			rh.h.Logger.Error("Failed to trace batches upstream", "error", err)
+			return BatchTraceResponse{}, http.StatusInternalServerError, fmt.Errorf("failed to trace batches")
+		}
+		for _, l := range upstream {
+			links = append(links, traceLink{l.ParentBatchID, l.ChildBatchID, l.LinkType, l.MovementID.Int32, optionalQuantity(l.Quantity)})
+			hasParent[l.ChildBatchID] = true
+			if _, ok := placed[l.ParentBatchID]; !ok {
+				placed[l.ParentBatchID] = placement{relation: relationUpstream, depth: l.Depth}
+			}
+		}
+	}
+	if direction != traceUpstream {
+		downstream, err := rh.h.Queries.TraceBatchesDownstream(ctx, roots)
+		if err != nil {
+			rh.h.Logger.Error("Failed to trace batches downstream", "error", err)
+			return BatchTraceResponse{}, http.StatusInternalServerError, fmt.Errorf("failed to trace batches")
+		}
+		for _, l := range downstream {
+			links = append(links, traceLink{l.ParentBatchID, l.ChildBatchID, l.LinkType, l.MovementID.Int32, optionalQuantity(l.Quantity)})
+			hasParent[l.ChildBatchID] = true
+			if _, ok := placed[l.ChildBatchID]; !ok {
+				placed[l.ChildBatchID] = placement{relation: relationDownstream, depth: l.Depth}
+			}
+		}
+	}
+
+	ids := make([]int32, 0, len(placed))
+	for id := range placed {
+		ids = append(ids, id)
+	}
+
+	batchRows, err := rh.h.Queries.GetTraceBatches(ctx, ids)
+	if err != nil {
+		rh.h.Logger.Error("Failed to get traced batches", "error", err)
+		return BatchTraceResponse{}, http.StatusInternalServerError, fmt.Errorf("failed to get traced batches")
+	}
+	movementRows, err := rh.h.Queries.GetTraceBatchMovements(ctx, ids)
+	if err != nil {
+		rh.h.Logger.Error("Failed to get traced movements", "error", err)
+		return BatchTraceResponse{}, http.StatusInternalServerError, fmt.Errorf("failed to get traced movements")
+	}
+
+	response := BatchTraceResponse{
+		BatchNumber: batchNumber,
+		Direction:   direction,
+		Batches:     make([]TraceBatch, 0, len(batchRows)),
+		Movements:   []TraceMovement{},
+		Receipts:    []TraceOrderLine{},
+		Shipments:   []TraceOrderLine{},
+	}
+	for _, row := range batchRows {
+		p := placed[row.ID]
+		response.Batches = append(response.Batches, TraceBatch{
+			ID:              row.ID,
+			BatchNumber:     row.BatchNumber,
+			Relation:        p.relation,
+			Depth:           p.depth,
+			MaterialID:      row.MaterialID.Int32,
+			MaterialCode:    row.MaterialCode.String,
+			MaterialName:    row.MaterialName.String,
+			Unit:            row.Unit.String,
+			WarehouseID:     row.WarehouseID.Int32,
+			WarehouseName:   row.WarehouseName.String,
+			SupplierID:      row.SupplierID.Int32,
+			SupplierName:    row.SupplierName.String,
+			StartQuantity:   numericToFloat(row.StartQuantity),
+			CurrentQuantity: numericToFloat(row.CurrentQuantity),
+			ManufactureDate: dateValue(row.ManufactureDate),
+			ExpiryDate:      dateValue(row.ExpiryDate),
+			HoldNumber:      row.HoldNumber.String,
+		})
+	}
+	sort.Slice(response.Batches, func(i, j int) bool {
+		oi, oj := relationOrder(response.Batches[i]), relationOrder(response.Batches[j])
+		if oi != oj {
+			return oi < oj
+		}
+		return response.Batches[i].ID < response.Batches[j].ID
+	})
+
+	batches := map[int32]TraceBatch{}
+	graph := newTraceGraph()
+	for _, b := range response.Batches {
+		batches[b.ID] = b
+		batchNode := graph.node("batch", b.ID, b.BatchNumber, b.Relation)
+		if b.WarehouseID != 0 {
+			graph.edge(batchNode, graph.node("warehouse", b.WarehouseID, b.WarehouseName, ""), "stored_in", 0, nil)
+		}
+	}
+
+	for _, l := range links {
+		graph.edge(fmt.Sprintf("batch:%d", l.parent), fmt.Sprintf("batch:%d", l.child), l.linkType, l.movementID, l.quantity)
+	}
+
+	receivedFrom := map[int32]bool{}
+	for _, row := range movementRows {
+		b := batches[row.BatchID]
+		if !traceMovementIncluded(b.Relation, direction, row.StockDirection) {
+			continue
+		}
+
+		m := TraceMovement{
+			MovementID:          row.MovementID,
+			BatchID:             row.BatchID,
+			BatchNumber:         b.BatchNumber,
+			Relation:            b.Relation,
+			MovementType:        row.MovementType,
+			Direction:           row.StockDirection,
+			Quantity:            numericToFloat(row.Quantity),
+			Date:                row.MovementDate.Time,
+			Reference:           row.Reference.String,
+			FromWarehouse:       row.FromWarehouseName.String,
+			ToWarehouse:         row.ToWarehouseName.String,
+			PurchaseOrderID:     row.PurchaseOrderID.Int32,
+			PurchaseOrderNumber: row.PurchaseOrderNumber.String,
+			SupplierID:          row.PoSupplierID.Int32,
+			SupplierName:        row.PoSupplierName.String,
+			SalesOrderID:        row.SalesOrderID.Int32,
+			SalesOrderNumber:    row.SalesOrderNumber.String,
+			CustomerID:          row.CustomerID.Int32,
+			CustomerName:        row.CustomerName.String,
+			Reversed:            row.ReversedByMovementID.Valid,
+		}
+		response.Movements = append(response.Movements, m)
+
+		if m.Reversed || row.ReversesMovementID.Valid {
+			continue
+		}
+
+		batchNode := fmt.Sprintf("batch:%d", m.BatchID)
+		quantity := m.Quantity
+		line := TraceOrderLine{
+			BatchID:      m.BatchID,
+			BatchNumber:  m.BatchNumber,
+			MaterialCode: b.MaterialCode,
+			MaterialName: b.MaterialName,
+			Quantity:     m.Quantity,
+			Unit:         b.Unit,
+			Date:         m.Date,
+			MovementID:   m.MovementID,
+		}
+
+		switch m.MovementType {
+		case db.StockMovementTypePURCHASERECEIPT:
+			receivedFrom[m.BatchID] = true
+			line.PartyID, line.PartyName = m.SupplierID, m.SupplierName
+			if line.PartyID == 0 {
+				line.PartyID, line.PartyName = b.SupplierID, b.SupplierName
+			}
+			line.OrderID, line.OrderNumber = m.PurchaseOrderID, m.PurchaseOrderNumber
+			response.Receipts = append(response.Receipts, line)
+
+			source := batchNode
+			if m.PurchaseOrderID != 0 {
+				poNode := graph.node("purchase_order", m.PurchaseOrderID, m.PurchaseOrderNumber, "")
+				graph.edge(poNode, batchNode, "received", m.MovementID, &quantity)
+				source = poNode
+			}
+			if line.PartyID != 0 {
+				graph.edge(graph.node("supplier", line.PartyID, line.PartyName, ""), source, "supplied", 0, nil)
+			}
+		case db.StockMovementTypeSALE:
+			line.PartyID, line.PartyName = m.CustomerID, m.CustomerName
+			line.OrderID, line.OrderNumber = m.SalesOrderID, m.SalesOrderNumber
+			response.Shipments = append(response.Shipments, line)
+
+			if m.SalesOrderID != 0 {
+				soNode := graph.node("sales_order", m.SalesOrderID, m.SalesOrderNumber, "")
+				graph.edge(batchNode, soNode, "shipped", m.MovementID, &quantity)
+				if m.CustomerID != 0 {
+					graph.edge(soNode, graph.node("customer", m.CustomerID, m.CustomerName, ""), "sold_to", 0, nil)
+				}
+			}
+		}
+	}
+
+	// Batches that were neither received on an order nor descend from other
+	// batches, such as opening stock, still name their supplier
+	for _, b := range response.Batches {
+		if b.Relation == relationDownstream || b.SupplierID == 0 || receivedFrom[b.ID] || hasParent[b.ID] {
+			continue
+		}
+		graph.edge(graph.node("supplier", b.SupplierID, b.SupplierName, ""), fmt.Sprintf("batch:%d", b.ID), "supplied", 0, nil)
+	}
+
+	response.Nodes = graph.nodes
+	response.Edges = graph.edges
+	return response, http.StatusOK, nil
+}
+
+// GetBatchTrace - Trace a batch number upstream to its suppliers and purchase
+// orders and downstream to the sales orders and customers it reached
+func (rh *ReportHandler) GetBatchTrace(w http.ResponseWriter, r *http.Request) {
+	response, status, err := rh.buildBatchTrace(r)
+	if err != nil {
+		config.RespondJSON(w, status, map[string]string{"error": err.Error()})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusOK, response)
+}
+
+// ExportBatchTrace - Export the batch trace as Excel with the batches,
+// genealogy links, movements, supplier receipts and customer shipments on
+// separate sheets
+func (rh *ReportHandler) ExportBatchTrace(w http.ResponseWriter, r *http.Request) {
+	response, status, err := rh.buildBatchTrace(r)
+	if err != nil {
+		config.RespondJSON(w, status, map[string]string{"error": err.Error()})
+		return
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	style, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"#E0E0E0"}, Pattern: 1},
+	})
+
+	sheet := "Batches"
+	index, _ := f.NewSheet(sheet)
+	f.SetActiveSheet(index)
+	f.DeleteSheet("Sheet1")
+
+	writeTraceHeaders(f, sheet, []string{
+		"Relation",
+		"Depth",
+		"Batch Number",
+		"Material Code",
+		"Material Name",
+		"Warehouse",
+		"Supplier",
+		"Unit",
+		"Start Quantity",
+		"Current Quantity",
+		"Manufacture Date",
+		"Expiry Date",
+		"Hold",
+	}, style)
+	for i, b := range response.Batches {
+		row := i + 2
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), b.Relation)
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), b.Depth)
+		f.SetCellValue(sheet, fmt.Sprintf("C%d", row), b.BatchNumber)
+		f.SetCellValue(sheet, fmt.Sprintf("D%d", row), b.MaterialCode)
+		f.SetCellValue(sheet, fmt.Sprintf("E%d", row), b.MaterialName)
+		f.SetCellValue(sheet, fmt.Sprintf("F%d", row), b.WarehouseName)
+		f.SetCellValue(sheet, fmt.Sprintf("G%d", row), b.SupplierName)
+		f.SetCellValue(sheet, fmt.Sprintf("H%d", row), b.Unit)
+		f.SetCellValue(sheet, fmt.Sprintf("I%d", row), b.StartQuantity)
+		f.SetCellValue(sheet, fmt.Sprintf("J%d", row), b.CurrentQuantity)
+		if b.ManufactureDate != nil {
+			f.SetCellValue(sheet, fmt.Sprintf("K%d", row), b.ManufactureDate.Format("2006-01-02"))
+		}
+		if b.ExpiryDate != nil {
+			f.SetCellValue(sheet, fmt.Sprintf("L%d", row), b.ExpiryDate.Format("2006-01-02"))
+		}
+		f.SetCellValue(sheet, fmt.Sprintf("M%d", row), b.HoldNumber)
+	}
+	f.SetColWidth(sheet, "A", "B", 12)
+	f.SetColWidth(sheet, "C", "D", 18)
+	f.SetColWidth(sheet, "E", "G", 25)
+	f.SetColWidth(sheet, "H", "M", 16)
+
+	// Genealogy links between the traced batches
+	batchNumbers := map[string]string{}
+	for _, b := range response.Batches {
+		batchNumbers[fmt.Sprintf("batch:%d", b.ID)] = b.BatchNumber
+	}
+	sheet = "Genealogy"
+	f.NewSheet(sheet)
+	writeTraceHeaders(f, sheet, []string{"Parent Batch", "Child Batch", "Link", "Movement ID", "Quantity"}, style)
+	row := 2
+	for _, e := range response.Edges {
+		parent, isParent := batchNumbers[e.From]
+		child, isChild := batchNumbers[e.To]
+		if !isParent || !isChild {
+			continue
+		}
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), parent)
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), child)
+		f.SetCellValue(sheet, fmt.Sprintf("C%d", row), e.Type)
+		if e.MovementID != 0 {
+			f.SetCellValue(sheet, fmt.Sprintf("D%d", row), e.MovementID)
+		}
+		if e.Quantity != nil {
+			f.SetCellValue(sheet, fmt.Sprintf("E%d", row), *e.Quantity)
+		}
+		row++
+	}
+	f.SetColWidth(sheet, "A", "B", 18)
+	f.SetColWidth(sheet, "C", "E", 14)
+
+	sheet = "Movements"
+	f.NewSheet(sheet)
+	writeTraceHeaders(f, sheet, []string{
+		"Date",
+		"Relation",
+		"Batch Number",
+		"Movement ID",
+		"Type",
+		"Direction",
+		"Quantity",
+		"From Warehouse",
+		"To Warehouse",
+		"Reference",
+		"Purchase Order",
+		"Supplier",
+		"Sales Order",
+		"Customer",
+		"Reversed",
+	}, style)
+	for i, m := range response.Movements {
+		row := i + 2
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), m.Date.Format("2006-01-02 15:04"))
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), m.Relation)
+		f.SetCellValue(sheet, fmt.Sprintf("C%d", row), m.BatchNumber)
+		f.SetCellValue(sheet, fmt.Sprintf("D%d", row), m.MovementID)
+		f.SetCellValue(sheet, fmt.Sprintf("E%d", row), string(m.MovementType))
+		f.SetCellValue(sheet, fmt.Sprintf("F%d", row), string(m.Direction))
+		f.SetCellValue(sheet, fmt.Sprintf("G%d", row), m.Quantity)
+		f.SetCellValue(sheet, fmt.Sprintf("H%d", row), m.FromWarehouse)
+		f.SetCellValue(sheet, fmt.Sprintf("I%d", row), m.ToWarehouse)
+		f.SetCellValue(sheet, fmt.Sprintf("J%d", row), m.Reference)
+		f.SetCellValue(sheet, fmt.Sprintf("K%d", row), m.PurchaseOrderNumber)
+		f.SetCellValue(sheet, fmt.Sprintf("L%d", row), m.SupplierName)
+		f.SetCellValue(sheet, fmt.Sprintf("M%d", row), m.SalesOrderNumber)
+		f.SetCellValue(sheet, fmt.Sprintf("N%d", row), m.CustomerName)
+		if m.Reversed {
+			f.SetCellValue(sheet, fmt.Sprintf("O%d", row), "Yes")
+		}
+	}
+	f.SetColWidth(sheet, "A", "A", 18)
+	f.SetColWidth(sheet, "B", "G", 14)
+	f.SetColWidth(sheet, "H", "N", 22)
+	f.SetColWidth(sheet, "O", "O", 10)
+
+	writeTraceOrderLines(f, "Suppliers", "Supplier", "Purchase Order", response.Receipts, style)
+	writeTraceOrderLines(f, "Customers", "Customer", "Sales Order", response.Shipments, style)
+
+	// Write to response
+	filename := strings.NewReplacer("/", "-", "\\", "-", " ", "_", "\"", "").Replace(response.BatchNumber)
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=batch_trace_%s.xlsx", filename))
+
+	if err := f.Write(w); err != nil {
+		rh.h.Logger.Error("Failed to write batch trace", "error", err)
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to generate batch trace"})
+		return
+	}
+}
+
+// writeTraceHeaders writes and styles the header row of a sheet
+func writeTraceHeaders(f *excelize.File, sheet string, headers []string, style int) {
+	for i, h := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheet, cell, h)
+	}
+	last, _ := excelize.CoordinatesToCellName(len(headers), 1)
+	f.SetCellStyle(sheet, "A1", last, style)
+}
+
+// writeTraceOrderLines adds a sheet with the receipts or shipments of the
+// traced batches
+func writeTraceOrderLines(f *excelize.File, sheet, party, order string, lines []TraceOrderLine, style int) {
+	f.NewSheet(sheet)
+
+	writeTraceHeaders(f, sheet, []string{
+		party,
+		order,
+		"Batch Number",
+		"Material Code",
+		"Material Name",
+		"Quantity",
+		"Unit",
+		"Date",
+	}, style)
+	for i, l := range lines {
+		row := i + 2
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), l.PartyName)
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), l.OrderNumber)
+		f.SetCellValue(sheet, fmt.Sprintf("C%d", row), l.BatchNumber)
+		f.SetCellValue(sheet, fmt.Sprintf("D%d", row), l.MaterialCode)
+		f.SetCellValue(sheet, fmt.Sprintf("E%d", row), l.MaterialName)
+		f.SetCellValue(sheet, fmt.Sprintf("F%d", row), l.Quantity)
+		f.SetCellValue(sheet, fmt.Sprintf("G%d", row), l.Unit)
+		f.SetCellValue(sheet, fmt.Sprintf("H%d", row), l.Date.Format("2006-01-02"))
+	}
+
+	f.SetColWidth(sheet, "A", "B", 25)
+	f.SetColWidth(sheet, "C", "D", 18)
+	f.SetColWidth(sheet, "E", "E", 30)
+	f.SetColWidth(sheet, "F", "H", 14)
+}
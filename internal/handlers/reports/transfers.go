@@ -0,0 +1,117 @@
+package reports
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"warehouse_system/internal/config"
+	db "warehouse_system/internal/database/db"
+)
+
+// =====================================================
+// OVERDUE TRANSFERS
+// =====================================================
+
+// OverdueTransferLine is a transfer shipment still in transit after the
+// overdue threshold
+type OverdueTransferLine struct {
+	ID                int32      `json:"id"`
+	ShipmentNumber    string     `json:"shipment_number"`
+	MaterialID        int32      `json:"material_id"`
+	MaterialCode      string     `json:"material_code"`
+	MaterialName      string     `json:"material_name"`
+	Unit              string     `json:"unit"`
+	FromWarehouseID   int32      `json:"from_warehouse_id"`
+	FromWarehouseName string     `json:"from_warehouse_name"`
+	ToWarehouseID     int32      `json:"to_warehouse_id"`
+	ToWarehouseName   string     `json:"to_warehouse_name"`
+	Quantity          float64    `json:"quantity"`
+	UnitCost          float64    `json:"unit_cost"`
+	Value             float64    `json:"value"`
+	ShippedAt         time.Time  `json:"shipped_at"`
+	ExpectedDate      *time.Time `json:"expected_date,omitempty"`
+	DaysInTransit     int32      `json:"days_in_transit"`
+}
+
+// OverdueTransfersResponse is the report of overdue transfers
+type OverdueTransfersResponse struct {
+	Days          int                   `json:"days"`
+	ShippedBefore time.Time             `json:"shipped_before"`
+	TotalValue    float64               `json:"total_value"`
+	Transfers     []OverdueTransferLine `json:"transfers"`
+}
+
+// GetOverdueTransfers - Get the transfers still in transit more than days
+// after they were shipped, longest in transit first
+func (rh *ReportHandler) GetOverdueTransfers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	days := rh.h.CFG.Inventory.TransferOverdueDays
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		parsed, err := strconv.Atoi(daysStr)
+		if err != nil || parsed < 0 {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid days"})
+			return
+		}
+		days = parsed
+	}
+
+	params := db.ListTransferShipmentsParams{
+		Status:        pgtype.Text{String: "In Transit", Valid: true},
+		ShippedBefore: pgtype.Timestamptz{Time: time.Now().AddDate(0, 0, -days), Valid: true},
+	}
+
+	var err error
+	if params.FromWarehouseID, err = parseOptionalID(r, "from_warehouse_id"); err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if params.ToWarehouseID, err = parseOptionalID(r, "to_warehouse_id"); err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if params.MaterialID, err = parseOptionalID(r, "material_id"); err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	rows, err := rh.h.Queries.ListTransferShipments(ctx, params)
+	if err != nil {
+		rh.h.Logger.Error("Failed to get overdue transfers", "error", err)
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get overdue transfers"})
+		return
+	}
+
+	response := OverdueTransfersResponse{
+		Days:          days,
+		ShippedBefore: params.ShippedBefore.Time,
+		Transfers:     make([]OverdueTransferLine, 0, len(rows)),
+	}
+	for _, row := range rows {
+		line := OverdueTransferLine{
+			ID:                row.ID,
+			ShipmentNumber:    row.ShipmentNumber,
+			MaterialID:        row.MaterialID,
+			MaterialCode:      row.MaterialCode,
+			MaterialName:      row.MaterialName,
+			Unit:              row.Unit.String,
+			FromWarehouseID:   row.FromWarehouseID,
+			FromWarehouseName: row.FromWarehouseName,
+			ToWarehouseID:     row.ToWarehouseID,
+			ToWarehouseName:   row.ToWarehouseName,
+			Quantity:          numericToFloat(row.Quantity),
+			UnitCost:          numericToFloat(row.UnitCost),
+			ShippedAt:         row.ShippedAt.Time,
+			ExpectedDate:      dateValue(row.ExpectedDate),
+			DaysInTransit:     row.DaysInTransit,
+		}
+		line.Value = line.Quantity * line.UnitCost
+		response.TotalValue += line.Value
+		response.Transfers = append(response.Transfers, line)
+	}
+
+	config.RespondJSON(w, http.StatusOK, response)
+}
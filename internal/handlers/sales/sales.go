@@ -70,7 +70,7 @@ func parseFlexibleDate(dateStr string) (time.Time, error) {
 }
 
 func isValidSOStatus(s string) bool {
-	validStatuses := []string{"Pending", "Approved", "Shipped", "Cancelled", "Partial"}
+	validStatuses := []string{"Pending", "Approved", "PartiallyShipped", "Shipped", "Cancelled", "Partial"}
 	for _, valid := range validStatuses {
 		if s == valid {
 			return true
@@ -99,7 +99,7 @@ func (so *SalesHandler) CreateSalesOrder(w http.ResponseWriter, r *http.Request)
 
 	// Validate status
 	if !isValidSOStatus(req.Status) {
-		config.RespondBadRequest(w, "Invalid status", "Status must be one of: Pending, Approved, Shipped, Cancelled, Partial")
+		config.RespondBadRequest(w, "Invalid status", "Status must be one of: Pending, Approved, PartiallyShipped, Shipped, Cancelled, Partial")
 		return
 	}
 
@@ -292,7 +292,7 @@ func (so *SalesHandler) UpdateSalesOrder(w http.ResponseWriter, r *http.Request)
 
 	// Validate status if provided
 	if req.Status != nil && *req.Status != "" && !isValidSOStatus(*req.Status) {
-		config.RespondBadRequest(w, "Invalid status", "Status must be one of: Pending, Approved, Shipped, Cancelled, Partial")
+		config.RespondBadRequest(w, "Invalid status", "Status must be one of: Pending, Approved, PartiallyShipped, Shipped, Cancelled, Partial")
 		return
 	}
 
@@ -367,13 +367,39 @@ func (so *SalesHandler) UpdateSalesOrder(w http.ResponseWriter, r *http.Request)
 		params.Meta = req.Meta
 	}
 
-	salesOrder, err := so.h.Queries.UpdateSalesOrder(context.Background(), params)
+	// The status change and the release of the reservations it ends commit
+	// together
+	ctx := r.Context()
+	tx, err := so.h.DB.Begin(ctx)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to start transaction"})
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	queries := so.h.Queries.WithTx(tx)
+
+	salesOrder, err := queries.UpdateSalesOrder(ctx, params)
 	if err != nil {
 		so.h.Logger.Error("Failed to update sales order", "error", err)
 		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 		return
 	}
 
+	// Cancelled and shipped orders no longer hold stock
+	if salesOrder.Status == "Cancelled" || salesOrder.Status == "Shipped" {
+		if _, err := queries.ReleaseSalesOrderReservations(ctx, pgtype.Int4{Int32: id, Valid: true}); err != nil {
+			so.h.Logger.Error("Failed to release sales order reservations", "error", err)
+			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to commit transaction"})
+		return
+	}
+
 	config.RespondJSON(w, http.StatusOK, salesOrder)
 }
 
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ParseAsOf reads the optional as_of query parameter. A plain date means the
+// end of that day in UTC, so as_of=2024-01-31 is the January close. Clients
+// closing in another timezone pass an RFC3339 moment with their offset.
+func ParseAsOf(r *http.Request) (pgtype.Timestamptz, error) {
+	asOfStr := r.URL.Query().Get("as_of")
+	if asOfStr == "" {
+		return pgtype.Timestamptz{}, nil
+	}
+
+	if t, err := time.Parse("2006-01-02", asOfStr); err == nil {
+		return pgtype.Timestamptz{Time: t.Add(24*time.Hour - time.Microsecond), Valid: true}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, asOfStr); err == nil {
+		return pgtype.Timestamptz{Time: t, Valid: true}, nil
+	}
+	return pgtype.Timestamptz{}, fmt.Errorf("invalid as_of, use YYYY-MM-DD or RFC3339")
+}
+
+// CheckStockHistory rejects an as_of moment before stock history by batch
+// starts. Stock is rebuilt from batch movement lines, which migration 010
+// did not backfill for older stock-outs, so earlier stock would come out
+// wrong. Returns the status to answer with when the moment is rejected.
+func (h *Handler) CheckStockHistory(ctx context.Context, asOf pgtype.Timestamptz) (int, error) {
+	if !asOf.Valid {
+		return http.StatusOK, nil
+	}
+
+	start, err := h.Queries.GetStockHistoryStart(ctx)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return http.StatusOK, nil
+		}
+		return http.StatusInternalServerError, errors.New("Failed to get stock history start")
+	}
+	if start.Valid && asOf.Time.Before(start.Time) {
+		return http.StatusBadRequest, fmt.Errorf("Stock history starts at %s, as_of must not be earlier", start.Time.Format(time.RFC3339))
+	}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,143 @@
+package transactions
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"warehouse_system/internal/config"
+	db "warehouse_system/internal/database/db"
+)
+
+// =====================================================
+// BIN HELPERS
+// =====================================================
+
+// binParam converts an optional bin ID of a request to a nullable column value
+func binParam(binID *int32) pgtype.Int4 {
+	return pgtype.Int4{Int32: int32Value(binID), Valid: binID != nil && *binID != 0}
+}
+
+// checkBin makes sure a bin exists and belongs to the warehouse
+func checkBin(ctx context.Context, queries *db.Queries, binID, warehouseID int32) (db.WarehouseBin, error) {
+	bin, err := queries.GetWarehouseBinByID(ctx, binID)
+	if err != nil {
+		return db.WarehouseBin{}, fmt.Errorf("bin %d not found", binID)
+	}
+	if bin.WarehouseID != warehouseID {
+		return db.WarehouseBin{}, fmt.Errorf("bin %s does not belong to warehouse %d", bin.Code, warehouseID)
+	}
+	return bin, nil
+}
+
+// checkBinPutaway validates the destination bin of incoming stock. Only
+// active bins take stock and a bin with a capacity cannot be filled above it.
+func checkBinPutaway(ctx context.Context, queries *db.Queries, binID *int32, warehouseID int32, quantity float64) error {
+	if binID == nil || *binID == 0 {
+		return nil
+	}
+
+	bin, err := checkBin(ctx, queries, *binID, warehouseID)
+	if err != nil {
+		return err
+	}
+	if !bin.IsActive {
+		return fmt.Errorf("bin %s is not active", bin.Code)
+	}
+	if !bin.Capacity.Valid {
+		return nil
+	}
+
+	levels, err := queries.GetBinStockLevels(ctx, db.GetBinStockLevelsParams{
+		WarehouseID: pgtype.Int4{Int32: warehouseID, Valid: true},
+		BinID:       pgtype.Int4{Int32: bin.ID, Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get bin stock: %w", err)
+	}
+
+	occupied := 0.0
+	for _, l := range levels {
+		occupied += numericToFloat(l.TotalQuantity)
+	}
+
+	free := numericToFloat(bin.Capacity) - occupied
+	if quantity > free+0.0001 {
+		return fmt.Errorf("bin %s has only %.2f free capacity, cannot put away %.2f", bin.Code, max(free, 0), quantity)
+	}
+	return nil
+}
+
+// checkBatchesInBin makes sure manually selected batches are taken from the source bin
+func checkBatchesInBin(ctx context.Context, queries *db.Queries, allocations []BatchAllocation, binID int32) error {
+	batchIDs := make([]int32, len(allocations))
+	for i, a := range allocations {
+		batchIDs[i] = a.BatchID
+	}
+
+	batches, err := queries.GetBatchesByIDs(ctx, batchIDs)
+	if err != nil {
+		return fmt.Errorf("failed to fetch batches: %w", err)
+	}
+
+	for _, b := range batches {
+		if b.BinID.Int32 != binID {
+			return fmt.Errorf("batch %d (%s) is not stored in the source bin", b.ID, b.BatchNumber)
+		}
+	}
+	return nil
+}
+
+// =====================================================
+// BIN STOCK
+// =====================================================
+
+// GetBinStockLevels - Get stock per bin and material in a warehouse
+func (th *TransactionHandler) GetBinStockLevels(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	warehouseIDStr := r.URL.Query().Get("warehouse_id")
+	if warehouseIDStr == "" {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "warehouse_id is required"})
+		return
+	}
+
+	warehouseID, err := strconv.Atoi(warehouseIDStr)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid warehouse_id"})
+		return
+	}
+
+	params := db.GetBinStockLevelsParams{
+		WarehouseID: pgtype.Int4{Int32: int32(warehouseID), Valid: true},
+	}
+
+	if binIDStr := r.URL.Query().Get("bin_id"); binIDStr != "" {
+		binID, err := strconv.Atoi(binIDStr)
+		if err != nil {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid bin_id"})
+			return
+		}
+		params.BinID = pgtype.Int4{Int32: int32(binID), Valid: true}
+	}
+
+	if materialIDStr := r.URL.Query().Get("material_id"); materialIDStr != "" {
+		materialID, err := strconv.Atoi(materialIDStr)
+		if err != nil {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid material_id"})
+			return
+		}
+		params.MaterialID = pgtype.Int4{Int32: int32(materialID), Valid: true}
+	}
+
+	levels, err := th.h.Queries.GetBinStockLevels(ctx, params)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get bin stock levels"})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusOK, levels)
+}
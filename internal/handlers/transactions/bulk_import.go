@@ -0,0 +1,676 @@
+package transactions
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/xuri/excelize/v2"
+
+	"warehouse_system/internal/config"
+	db "warehouse_system/internal/database/db"
+	"warehouse_system/internal/middlewares"
+)
+
+// =====================================================
+// BULK IMPORT TYPES
+// =====================================================
+
+// BulkImportFailedEntry represents a row of a bulk import that was not posted
+type BulkImportFailedEntry struct {
+	Row           int    `json:"row"`
+	MaterialCode  string `json:"material_code"`
+	WarehouseCode string `json:"warehouse_code"`
+	Reason        string `json:"reason"`
+}
+
+// BulkImportResponse represents the response for a bulk transaction import
+type BulkImportResponse struct {
+	Type         string                  `json:"type"`
+	Mode         string                  `json:"mode"`
+	SuccessCount int                     `json:"success_count"`
+	FailedCount  int                     `json:"failed_count"`
+	TotalRows    int                     `json:"total_rows"`
+	MovementIDs  []int32                 `json:"movement_ids,omitempty"`
+	Failed       []BulkImportFailedEntry `json:"failed,omitempty"`
+}
+
+// How the rows of an import are posted
+const (
+	bulkImportPerFile = "file" // all rows in one transaction, or none
+	bulkImportPerRow  = "row"  // every valid row, the others are reported
+)
+
+// importColumn is one column of an import template
+type importColumn struct {
+	header   string
+	example  string
+	width    float64
+	required bool
+}
+
+// bulkImporter posts the rows of one transaction type. post reads and
+// validates a row and posts it in the transaction of queries.
+type bulkImporter struct {
+	sheet   string
+	columns []importColumn
+	post    func(th *TransactionHandler, ctx context.Context, queries *db.Queries, row importRow, userID int32) (TransactionResponse, int, error)
+}
+
+// bulkImporters are the transaction types that can be imported, by the
+// name used in the import paths
+var bulkImporters = map[string]bulkImporter{
+	"purchase-receipt": {
+		sheet: "Purchase Receipts",
+		columns: []importColumn{
+			{header: "Material Code", example: "10.2401", width: 15, required: true},
+			{header: "Warehouse Code", example: "WH-MAIN", width: 15, required: true},
+			{header: "Quantity", example: "500", width: 12, required: true},
+			{header: "Unit", example: "", width: 10},
+			{header: "Unit Price", example: "1.50", width: 12},
+			{header: "Supplier", example: "", width: 25},
+			{header: "Purchase Order ID", example: "12", width: 18},
+			{header: "Purchase Order Line ID", example: "", width: 22},
+			{header: "Bin Code", example: "", width: 12},
+			{header: "Manufacture Date (YYYY-MM-DD)", example: "2026-01-15", width: 25},
+			{header: "Expiry Date (YYYY-MM-DD)", example: "2027-01-15", width: 25},
+			{header: "Serial Numbers", example: "", width: 30},
+			{header: "Notes", example: "Delivery note 4711", width: 30},
+		},
+		post: importPurchaseReceipt,
+	},
+	"transfer": {
+		sheet: "Transfers",
+		columns: []importColumn{
+			{header: "Material Code", example: "10.2401", width: 15, required: true},
+			{header: "From Warehouse Code", example: "WH-MAIN", width: 20, required: true},
+			{header: "To Warehouse Code", example: "WH-2", width: 20, required: true},
+			{header: "Quantity", example: "100", width: 12, required: true},
+			{header: "Unit", example: "", width: 10},
+			{header: "From Bin Code", example: "", width: 15},
+			{header: "To Bin Code", example: "", width: 15},
+			{header: "Serial Numbers", example: "", width: 30},
+			{header: "Notes", example: "Replenish branch", width: 30},
+		},
+		post: importTransfer,
+	},
+	"scrap": {
+		sheet: "Scrap",
+		columns: []importColumn{
+			{header: "Material Code", example: "10.2401", width: 15, required: true},
+			{header: "Warehouse Code", example: "WH-MAIN", width: 15, required: true},
+			{header: "Quantity", example: "5", width: 12, required: true},
+			{header: "Unit", example: "", width: 10},
+			{header: "Reason", example: "Damaged in storage", width: 30, required: true},
+			{header: "Serial Numbers", example: "", width: 30},
+		},
+		post: importScrap,
+	},
+	"adjustment": {
+		sheet: "Adjustments",
+		columns: []importColumn{
+			{header: "Material Code", example: "10.2401", width: 15, required: true},
+			{header: "Warehouse Code", example: "WH-MAIN", width: 15, required: true},
+			{header: "Direction (IN/OUT)", example: "IN", width: 18, required: true},
+			{header: "Quantity", example: "12", width: 12, required: true},
+			{header: "Unit", example: "", width: 10},
+			{header: "Unit Price", example: "", width: 12},
+			{header: "Reason", example: "Found during stock take", width: 30, required: true},
+			{header: "Serial Numbers", example: "", width: 30},
+		},
+		post: importAdjustment,
+	},
+}
+
+// =====================================================
+// BULK IMPORT ROWS
+// =====================================================
+
+// importRow is one data row of an import file, its cells are read by the
+// lower cased column header
+type importRow struct {
+	num    int
+	cells  []string
+	colMap map[string]int
+}
+
+func (r importRow) get(header string) string {
+	if idx, exists := r.colMap[strings.ToLower(header)]; exists && idx < len(r.cells) {
+		return strings.TrimSpace(r.cells[idx])
+	}
+	return ""
+}
+
+// text returns an optional text cell
+func (r importRow) text(header string) *string {
+	if v := r.get(header); v != "" {
+		return &v
+	}
+	return nil
+}
+
+// quantity returns a required positive number
+func (r importRow) quantity(header string) (float64, error) {
+	v := r.get(header)
+	quantity, err := strconv.ParseFloat(v, 64)
+	if err != nil || quantity <= 0 {
+		return 0, fmt.Errorf("Invalid %s: '%s' (must be a positive number)", strings.ToLower(header), v)
+	}
+	return quantity, nil
+}
+
+// price returns an optional non-negative number
+func (r importRow) price(header string) (*float64, error) {
+	v := r.get(header)
+	if v == "" {
+		return nil, nil
+	}
+	price, err := strconv.ParseFloat(v, 64)
+	if err != nil || price < 0 {
+		return nil, fmt.Errorf("Invalid %s: '%s' (must be a non-negative number)", strings.ToLower(header), v)
+	}
+	return &price, nil
+}
+
+// id returns an optional record ID
+func (r importRow) id(header string) (*int32, error) {
+	v := r.get(header)
+	if v == "" {
+		return nil, nil
+	}
+	id, err := strconv.ParseInt(v, 10, 32)
+	if err != nil || id <= 0 {
+		return nil, fmt.Errorf("Invalid %s: '%s'", strings.ToLower(header), v)
+	}
+	id32 := int32(id)
+	return &id32, nil
+}
+
+// date returns an optional YYYY-MM-DD date
+func (r importRow) date(header string) (*string, error) {
+	v := r.get(header)
+	if v == "" {
+		return nil, nil
+	}
+	if _, err := time.Parse("2006-01-02", v); err != nil {
+		return nil, fmt.Errorf("Invalid %s: '%s' (use YYYY-MM-DD)", strings.ToLower(strings.TrimSuffix(header, " (YYYY-MM-DD)")), v)
+	}
+	return &v, nil
+}
+
+// serialNumbers splits the serial numbers cell on commas, semicolons and
+// white space
+func (r importRow) serialNumbers() []string {
+	return strings.FieldsFunc(r.get("Serial Numbers"), func(c rune) bool {
+		return c == ',' || c == ';' || c == ' ' || c == '\n' || c == '\t'
+	})
+}
+
+func (r importRow) material(ctx context.Context, queries *db.Queries) (int32, error) {
+	code := r.get("Material Code")
+	material, err := queries.GetMaterialByCode(ctx, code)
+	if err != nil {
+		return 0, fmt.Errorf("Material with code '%s' not found", code)
+	}
+	return material.ID, nil
+}
+
+func (r importRow) warehouse(ctx context.Context, queries *db.Queries, header string) (int32, error) {
+	code := r.get(header)
+	warehouse, err := queries.GetWarehouseByCode(ctx, code)
+	if err != nil {
+		return 0, fmt.Errorf("Warehouse with code '%s' not found", code)
+	}
+	return warehouse.ID, nil
+}
+
+// bin returns the optional bin of a warehouse
+func (r importRow) bin(ctx context.Context, queries *db.Queries, header string, warehouseID int32) (*int32, error) {
+	code := r.get(header)
+	if code == "" {
+		return nil, nil
+	}
+	bin, err := queries.GetWarehouseBinByCode(ctx, db.GetWarehouseBinByCodeParams{WarehouseID: warehouseID, Code: code})
+	if err != nil {
+		return nil, fmt.Errorf("Bin with code '%s' not found in the warehouse", code)
+	}
+	return &bin.ID, nil
+}
+
+// unit returns the optional unit the quantities are entered in, by its
+// abbreviation
+func (r importRow) unit(ctx context.Context, queries *db.Queries) (*int32, error) {
+	abbreviation := r.get("Unit")
+	if abbreviation == "" {
+		return nil, nil
+	}
+	unit, err := queries.GetUnitByAbbreviation(ctx, abbreviation)
+	if err != nil {
+		return nil, fmt.Errorf("Unit '%s' not found", abbreviation)
+	}
+	return &unit.ID, nil
+}
+
+// =====================================================
+// BULK IMPORT POSTING
+// =====================================================
+
+func importPurchaseReceipt(th *TransactionHandler, ctx context.Context, queries *db.Queries, row importRow, userID int32) (TransactionResponse, int, error) {
+	var req PurchaseReceiptRequest
+	var err error
+	if req.MaterialID, err = row.material(ctx, queries); err != nil {
+		return TransactionResponse{}, http.StatusBadRequest, err
+	}
+	if req.WarehouseID, err = row.warehouse(ctx, queries, "Warehouse Code"); err != nil {
+		return TransactionResponse{}, http.StatusBadRequest, err
+	}
+	if req.Quantity, err = row.quantity("Quantity"); err != nil {
+		return TransactionResponse{}, http.StatusBadRequest, err
+	}
+	if req.UnitID, err = row.unit(ctx, queries); err != nil {
+		return TransactionResponse{}, http.StatusBadRequest, err
+	}
+	// Receipts against an order default the price from the order line
+	unitPrice, err := row.price("Unit Price")
+	if err != nil {
+		return TransactionResponse{}, http.StatusBadRequest, err
+	}
+	if unitPrice != nil {
+		req.UnitPrice = *unitPrice
+	}
+	if name := row.get("Supplier"); name != "" {
+		supplier, err := queries.GetSupplierByName(ctx, name)
+		if err != nil {
+			return TransactionResponse{}, http.StatusBadRequest, fmt.Errorf("Supplier '%s' not found", name)
+		}
+		req.SupplierID = &supplier.ID
+	}
+	if req.PurchaseOrderID, err = row.id("Purchase Order ID"); err != nil {
+		return TransactionResponse{}, http.StatusBadRequest, err
+	}
+	if req.PurchaseOrderItemID, err = row.id("Purchase Order Line ID"); err != nil {
+		return TransactionResponse{}, http.StatusBadRequest, err
+	}
+	if req.BinID, err = row.bin(ctx, queries, "Bin Code", req.WarehouseID); err != nil {
+		return TransactionResponse{}, http.StatusBadRequest, err
+	}
+	if req.ManufactureDate, err = row.date("Manufacture Date (YYYY-MM-DD)"); err != nil {
+		return TransactionResponse{}, http.StatusBadRequest, err
+	}
+	if req.ExpiryDate, err = row.date("Expiry Date (YYYY-MM-DD)"); err != nil {
+		return TransactionResponse{}, http.StatusBadRequest, err
+	}
+	req.SerialNumbers = row.serialNumbers()
+	req.Notes = row.text("Notes")
+
+	return th.postPurchaseReceipt(ctx, queries, req, userID)
+}
+
+func importTransfer(th *TransactionHandler, ctx context.Context, queries *db.Queries, row importRow, userID int32) (TransactionResponse, int, error) {
+	var req TransferRequest
+	var err error
+	if req.MaterialID, err = row.material(ctx, queries); err != nil {
+		return TransactionResponse{}, http.StatusBadRequest, err
+	}
+	if req.FromWarehouseID, err = row.warehouse(ctx, queries, "From Warehouse Code"); err != nil {
+		return TransactionResponse{}, http.StatusBadRequest, err
+	}
+	if req.ToWarehouseID, err = row.warehouse(ctx, queries, "To Warehouse Code"); err != nil {
+		return TransactionResponse{}, http.StatusBadRequest, err
+	}
+	if req.Quantity, err = row.quantity("Quantity"); err != nil {
+		return TransactionResponse{}, http.StatusBadRequest, err
+	}
+	if req.UnitID, err = row.unit(ctx, queries); err != nil {
+		return TransactionResponse{}, http.StatusBadRequest, err
+	}
+	if req.FromBinID, err = row.bin(ctx, queries, "From Bin Code", req.FromWarehouseID); err != nil {
+		return TransactionResponse{}, http.StatusBadRequest, err
+	}
+	if req.ToBinID, err = row.bin(ctx, queries, "To Bin Code", req.ToWarehouseID); err != nil {
+		return TransactionResponse{}, http.StatusBadRequest, err
+	}
+	req.SerialNumbers = row.serialNumbers()
+	req.Notes = row.text("Notes")
+
+	return th.postTransfer(ctx, queries, req, userID)
+}
+
+func importScrap(th *TransactionHandler, ctx context.Context, queries *db.Queries, row importRow, userID int32) (TransactionResponse, int, error) {
+	var req ScrapRequest
+	var err error
+	if req.MaterialID, err = row.material(ctx, queries); err != nil {
+		return TransactionResponse{}, http.StatusBadRequest, err
+	}
+	if req.WarehouseID, err = row.warehouse(ctx, queries, "Warehouse Code"); err != nil {
+		return TransactionResponse{}, http.StatusBadRequest, err
+	}
+	if req.Quantity, err = row.quantity("Quantity"); err != nil {
+		return TransactionResponse{}, http.StatusBadRequest, err
+	}
+	if req.UnitID, err = row.unit(ctx, queries); err != nil {
+		return TransactionResponse{}, http.StatusBadRequest, err
+	}
+	req.Reason = row.get("Reason")
+	req.SerialNumbers = row.serialNumbers()
+
+	return th.postScrap(ctx, queries, req, userID)
+}
+
+func importAdjustment(th *TransactionHandler, ctx context.Context, queries *db.Queries, row importRow, userID int32) (TransactionResponse, int, error) {
+	var req AdjustmentRequest
+	var err error
+	if req.MaterialID, err = row.material(ctx, queries); err != nil {
+		return TransactionResponse{}, http.StatusBadRequest, err
+	}
+	if req.WarehouseID, err = row.warehouse(ctx, queries, "Warehouse Code"); err != nil {
+		return TransactionResponse{}, http.StatusBadRequest, err
+	}
+	req.Direction = strings.ToUpper(row.get("Direction (IN/OUT)"))
+	if req.Quantity, err = row.quantity("Quantity"); err != nil {
+		return TransactionResponse{}, http.StatusBadRequest, err
+	}
+	if req.UnitID, err = row.unit(ctx, queries); err != nil {
+		return TransactionResponse{}, http.StatusBadRequest, err
+	}
+	if req.UnitPrice, err = row.price("Unit Price"); err != nil {
+		return TransactionResponse{}, http.StatusBadRequest, err
+	}
+	req.Reason = row.get("Reason")
+	req.SerialNumbers = row.serialNumbers()
+
+	return th.postAdjustment(ctx, queries, req, userID)
+}
+
+// =====================================================
+// BULK IMPORT FILES
+// =====================================================
+
+// readImportFile reads the rows of an uploaded .xlsx or .csv file, the
+// first row holds the column headers
+func readImportFile(r *http.Request) ([][]string, error) {
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		return nil, fmt.Errorf("No file uploaded")
+	}
+	defer file.Close()
+
+	if strings.EqualFold(filepath.Ext(header.Filename), ".csv") {
+		reader := csv.NewReader(file)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		rows, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("Invalid CSV file: %v", err)
+		}
+		// Spreadsheet programs start UTF-8 CSV files with a byte order mark
+		if len(rows) > 0 && len(rows[0]) > 0 {
+			rows[0][0] = strings.TrimPrefix(rows[0][0], "\ufeff")
+		}
+		return rows, nil
+	}
+
+	f, err := excelize.OpenReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid Excel file: %v", err)
+	}
+	defer f.Close()
+
+	rows, err := f.GetRows(f.GetSheetName(0))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read Excel rows: %v", err)
+	}
+	return rows, nil
+}
+
+// DownloadImportTemplate generates the import template of a transaction
+// type, as Excel unless format=csv
+func (th *TransactionHandler) DownloadImportTemplate(w http.ResponseWriter, r *http.Request) {
+	importType := r.PathValue("type")
+	importer, ok := bulkImporters[importType]
+	if !ok {
+		config.RespondJSON(w, http.StatusNotFound, map[string]string{"error": fmt.Sprintf("Unknown import type '%s'", importType)})
+		return
+	}
+
+	filename := strings.ReplaceAll(importType, "-", "_") + "_template"
+
+	if r.URL.Query().Get("format") == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.csv", filename))
+
+		writer := csv.NewWriter(w)
+		headers := make([]string, len(importer.columns))
+		examples := make([]string, len(importer.columns))
+		for i, c := range importer.columns {
+			headers[i] = c.header
+			examples[i] = c.example
+		}
+		writer.Write(headers)
+		writer.Write(examples)
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			th.h.Logger.Error("Failed to write CSV template", "type", importType, "error", err)
+		}
+		return
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := importer.sheet
+	index, _ := f.NewSheet(sheet)
+	f.SetActiveSheet(index)
+	f.DeleteSheet("Sheet1")
+
+	// Set headers, an example row and column widths
+	for i, c := range importer.columns {
+		col, _ := excelize.ColumnNumberToName(i + 1)
+		f.SetCellValue(sheet, col+"1", c.header)
+		f.SetCellValue(sheet, col+"2", c.example)
+		f.SetColWidth(sheet, col, col, c.width)
+	}
+
+	// Style headers
+	style, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"#E0E0E0"}, Pattern: 1},
+	})
+	lastCell, _ := excelize.CoordinatesToCellName(len(importer.columns), 1)
+	f.SetCellStyle(sheet, "A1", lastCell, style)
+
+	// Write to response
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.xlsx", filename))
+
+	if err := f.Write(w); err != nil {
+		th.h.Logger.Error("Failed to write Excel template", "type", importType, "error", err)
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to generate template"})
+		return
+	}
+}
+
+// ImportTransactions - Post the rows of an Excel or CSV file as stock
+// transactions of one type. Every row is validated and posted the same way
+// as the single transaction endpoint. With mode=file (the default) the file
+// posts in one database transaction and any failed row rolls it back, with
+// mode=row every valid row is posted and the failed rows are reported. A row
+// that hits a deadlock or serialization failure aborts the file in both
+// modes, so a retry starts from a rolled back transaction.
+func (th *TransactionHandler) ImportTransactions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user session from context
+	session, ok := middlewares.GetSessionFromContext(r)
+	if !ok {
+		config.RespondJSON(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized - Authentication required"})
+		return
+	}
+
+	// Parse user ID from session
+	var userID int32
+	_, err := fmt.Sscanf(session.UserID, "%d", &userID)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+		return
+	}
+
+	importType := r.PathValue("type")
+	importer, ok := bulkImporters[importType]
+	if !ok {
+		config.RespondJSON(w, http.StatusNotFound, map[string]string{"error": fmt.Sprintf("Unknown import type '%s'", importType)})
+		return
+	}
+
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		config.RespondBadRequest(w, "Failed to parse form", err.Error())
+		return
+	}
+
+	mode := r.FormValue("mode")
+	if mode == "" {
+		mode = bulkImportPerFile
+	}
+	if mode != bulkImportPerFile && mode != bulkImportPerRow {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Mode must be 'file' or 'row'"})
+		return
+	}
+
+	rows, err := readImportFile(r)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	if len(rows) < 2 {
+		config.RespondBadRequest(w, "File is empty", "File must have at least one data row")
+		return
+	}
+
+	// Build column header map
+	colMap := make(map[string]int)
+	for i, header := range rows[0] {
+		colMap[strings.ToLower(strings.TrimSpace(header))] = i
+	}
+
+	// Validate required columns exist
+	for _, c := range importer.columns {
+		if _, exists := colMap[strings.ToLower(c.header)]; c.required && !exists {
+			config.RespondBadRequest(w, "Missing required column", fmt.Sprintf("File must have '%s' column", c.header))
+			return
+		}
+	}
+
+	warehouseHeader := "warehouse code"
+	if importType == "transfer" {
+		warehouseHeader = "from warehouse code"
+	}
+
+	tx, err := th.h.DB.Begin(ctx)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to start transaction"})
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var failed []BulkImportFailedEntry
+	movementIDs := []int32{}
+	totalRows := 0
+
+	// Each row posts under its own savepoint, so a failed row is undone
+	// without aborting the rows around it
+	for i, cells := range rows[1:] {
+		row := importRow{num: i + 2, cells: cells, colMap: colMap}
+		if strings.TrimSpace(strings.Join(cells, "")) == "" {
+			continue
+		}
+		totalRows++
+
+		response, err := th.postImportRow(ctx, tx, importer, row, userID)
+		if err != nil && middlewares.TxConflicted(ctx) {
+			// A deadlock or serialization failure gives up on the whole
+			// file, nothing is committed and the request may run again. The
+			// status is a 5xx so an Idempotency-Key is not stored with it.
+			th.h.Logger.Warn("Transaction conflict during import", "type", importType, "row", row.num)
+			config.RespondJSON(w, http.StatusServiceUnavailable, map[string]string{"error": fmt.Sprintf("Row %d conflicted with a concurrent transaction, no rows were posted, please retry", row.num)})
+			return
+		}
+		if err != nil {
+			failed = append(failed, BulkImportFailedEntry{
+				Row:           row.num,
+				MaterialCode:  row.get("material code"),
+				WarehouseCode: row.get(warehouseHeader),
+				Reason:        err.Error(),
+			})
+			continue
+		}
+		movementIDs = append(movementIDs, response.MovementID)
+	}
+
+	// A file posted as a whole is rolled back when any row failed
+	if len(failed) > 0 && (mode == bulkImportPerFile || len(movementIDs) == 0) {
+		config.RespondJSON(w, http.StatusBadRequest, BulkImportResponse{
+			Type:         importType,
+			Mode:         mode,
+			SuccessCount: 0,
+			FailedCount:  len(failed),
+			TotalRows:    totalRows,
+			Failed:       failed,
+		})
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to commit transaction"})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusCreated, BulkImportResponse{
+		Type:         importType,
+		Mode:         mode,
+		SuccessCount: len(movementIDs),
+		FailedCount:  len(failed),
+		TotalRows:    totalRows,
+		MovementIDs:  movementIDs,
+		Failed:       failed,
+	})
+}
+
+// postImportRow checks the required cells of an import row and posts it
+// under a savepoint of tx
+func (th *TransactionHandler) postImportRow(ctx context.Context, tx pgx.Tx, importer bulkImporter, row importRow, userID int32) (TransactionResponse, error) {
+	var missing []string
+	for _, c := range importer.columns {
+		if c.required && row.get(c.header) == "" {
+			missing = append(missing, strings.ToLower(c.header))
+		}
+	}
+	if len(missing) > 0 {
+		return TransactionResponse{}, fmt.Errorf("Missing required fields (%s)", strings.Join(missing, ", "))
+	}
+
+	savepoint, err := tx.Begin(ctx)
+	if err != nil {
+		return TransactionResponse{}, fmt.Errorf("Failed to start row: %v", err)
+	}
+	defer savepoint.Rollback(ctx)
+
+	response, status, err := importer.post(th, ctx, th.h.Queries.WithTx(savepoint), row, userID)
+	if err != nil {
+		if status >= http.StatusInternalServerError {
+			th.h.Logger.Error("Failed to import row", "row", row.num, "error", err)
+		}
+		return response, err
+	}
+
+	if err := savepoint.Commit(ctx); err != nil {
+		return response, fmt.Errorf("Failed to post row: %v", err)
+	}
+	return response, nil
+}
@@ -13,6 +13,7 @@ import (
 
 	"warehouse_system/internal/config"
 	db "warehouse_system/internal/database/db"
+	"warehouse_system/internal/middlewares"
 )
 
 // BulkOpeningStockEntry represents a single opening stock entry from Excel
@@ -181,6 +182,15 @@ func (t *TransactionHandler) ImportOpeningStockFromExcel(w http.ResponseWriter,
 
 	qtx := t.h.Queries.WithTx(tx)
 
+	// Record the importing user when a session is present
+	var performedBy pgtype.Int4
+	if session, ok := middlewares.GetSessionFromContext(r); ok {
+		var userID int32
+		if _, err := fmt.Sscanf(session.UserID, "%d", &userID); err == nil {
+			performedBy = pgtype.Int4{Int32: userID, Valid: true}
+		}
+	}
+
 	var failed []BulkOpeningStockFailedEntry
 	successCount := 0
 
@@ -309,23 +319,65 @@ func (t *TransactionHandler) ImportOpeningStockFromExcel(w http.ResponseWriter,
 			continue
 		}
 
-		params := db.CreateTransactionParams{
-			MaterialID:      pgtype.Int4{Int32: material.ID, Valid: true},
-			WarehouseID:     pgtype.Int4{Int32: warehouse.ID, Valid: true},
-			TransactionType: "opening_stock",
-			Quantity:        pgQuantity,
-			UnitPrice:       pgUnitPrice,
-			ManufactureDate: pgManufactureDate,
-			ExpiryDate:      pgExpiryDate,
+		exists, err := qtx.CheckOpeningStockExists(ctx, pgtype.Int4{Int32: material.ID, Valid: true})
+		if err != nil || exists {
+			failed = append(failed, BulkOpeningStockFailedEntry{
+				Row:           rowNum,
+				MaterialCode:  materialCode,
+				WarehouseCode: warehouseCode,
+				Reason:        "Opening stock already exists for this material",
+			})
+			continue
 		}
 
-		// Set notes if provided
-		if notes != "" {
-			params.Notes = pgtype.Text{String: notes, Valid: true}
+		batchNumber, err := generateBatchNumber(ctx, qtx, material.ID, "open")
+		if err != nil {
+			failed = append(failed, BulkOpeningStockFailedEntry{
+				Row:           rowNum,
+				MaterialCode:  materialCode,
+				WarehouseCode: warehouseCode,
+				Reason:        fmt.Sprintf("Failed to generate batch number: %v", err),
+			})
+			continue
 		}
 
+		pgNotes := pgtype.Text{String: notes, Valid: notes != ""}
+		unitCost, totalCost := movementCost(quantity, unitPrice)
+
 		// Execute opening stock creation
-		_, err = qtx.CreateTransaction(ctx, params)
+		movement, err := qtx.CreateStockMovement(ctx, db.CreateStockMovementParams{
+			MaterialID:     pgtype.Int4{Int32: material.ID, Valid: true},
+			ToWarehouseID:  pgtype.Int4{Int32: warehouse.ID, Valid: true},
+			Quantity:       pgQuantity,
+			StockDirection: db.StockDirectionIN,
+			MovementType:   db.StockMovementTypeOPENING,
+			PerformedBy:    performedBy,
+			MovementDate:   pgtype.Timestamptz{Time: time.Now(), Valid: true},
+			Notes:          pgNotes,
+			UnitCost:       unitCost,
+			TotalCost:      totalCost,
+		})
+		var batch db.Batch
+		if err == nil {
+			batch, err = qtx.CreateBatch(ctx, db.CreateBatchParams{
+				MaterialID:      pgtype.Int4{Int32: material.ID, Valid: true},
+				WarehouseID:     pgtype.Int4{Int32: warehouse.ID, Valid: true},
+				MovementID:      pgtype.Int4{Int32: movement.ID, Valid: true},
+				UnitPrice:       pgUnitPrice,
+				BatchNumber:     batchNumber,
+				ManufactureDate: pgManufactureDate,
+				ExpiryDate:      pgExpiryDate,
+				StartQuantity:   pgQuantity,
+				CurrentQuantity: pgQuantity,
+				Notes:           pgNotes,
+			})
+		}
+		if err == nil {
+			err = recordMovementBatch(ctx, qtx, movement.ID, batch.ID, quantity, unitPrice)
+		}
+		if err == nil {
+			err = postCostLedger(ctx, qtx, material.ID, warehouse.ID, movement.ID, quantity, unitPrice)
+		}
 		if err != nil {
 			t.h.Logger.Error("Failed to create opening stock", "error", err, "material", materialCode, "warehouse", warehouseCode)
 			failed = append(failed, BulkOpeningStockFailedEntry{
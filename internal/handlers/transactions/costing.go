@@ -0,0 +1,260 @@
+package transactions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"warehouse_system/internal/config"
+	db "warehouse_system/internal/database/db"
+)
+
+// =====================================================
+// COSTING HELPERS
+// =====================================================
+
+// costFromFloat converts a cost to pgtype.Numeric keeping 4 decimals,
+// matching the precision of the cost columns
+func costFromFloat(f float64) pgtype.Numeric {
+	return pgtype.Numeric{
+		Int:   big.NewInt(int64(math.Round(f * 10000))),
+		Exp:   -4,
+		Valid: true,
+	}
+}
+
+// movementCost returns the unit and total cost to stamp on a stock movement
+func movementCost(quantity, unitCost float64) (pgtype.Numeric, pgtype.Numeric) {
+	return costFromFloat(unitCost), costFromFloat(quantity * unitCost)
+}
+
+// averageUnitCost returns the running average unit cost of a material in a
+// warehouse, or 0 when nothing has been costed there yet
+func averageUnitCost(ctx context.Context, queries *db.Queries, materialID, warehouseID int32) (float64, error) {
+	avg, err := queries.GetMaterialAverageCost(ctx, db.GetMaterialAverageCostParams{
+		MaterialID:  materialID,
+		WarehouseID: warehouseID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get average cost: %w", err)
+	}
+	return numericToFloat(avg.AverageUnitCost), nil
+}
+
+// issueUnitCost returns the unit cost of stock leaving a warehouse together
+// with the cost of every consumed batch. Weighted Average materials are issued
+// at the running average, batch based methods (FIFO, LIFO) at the
+// receipt price of the consumed batches.
+func issueUnitCost(ctx context.Context, queries *db.Queries, materialID, warehouseID int32, allocations []BatchAllocation) (float64, map[int32]float64, error) {
+	valuationMethod, err := getValuationMethod(ctx, queries, materialID, warehouseID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	batchIDs := make([]int32, len(allocations))
+	for i, a := range allocations {
+		batchIDs[i] = a.BatchID
+	}
+
+	batches, err := queries.GetBatchesByIDs(ctx, batchIDs)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to fetch batches: %w", err)
+	}
+
+	batchCosts := make(map[int32]float64, len(batches))
+	for _, b := range batches {
+		batchCosts[b.ID] = numericToFloat(b.UnitPrice)
+	}
+
+	if valuationMethod == string(db.ValuationMethodWeightedAverage) {
+		avg, err := queries.GetMaterialAverageCost(ctx, db.GetMaterialAverageCostParams{
+			MaterialID:  materialID,
+			WarehouseID: warehouseID,
+		})
+		if err == nil {
+			unitCost := numericToFloat(avg.AverageUnitCost)
+			for id := range batchCosts {
+				batchCosts[id] = unitCost
+			}
+			return unitCost, batchCosts, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil, fmt.Errorf("failed to get average cost: %w", err)
+		}
+		// No running average yet, fall back to the batch prices
+	}
+
+	totalQty, totalValue := 0.0, 0.0
+	for _, a := range allocations {
+		totalQty += a.Quantity
+		totalValue += a.Quantity * batchCosts[a.BatchID]
+	}
+
+	if totalQty <= 0 {
+		return 0, batchCosts, nil
+	}
+	return totalValue / totalQty, batchCosts, nil
+}
+
+// postCostLedger applies a movement to the running average of a material in
+// a warehouse and records it in the cost ledger. quantityChange is positive
+// for stock coming in and negative for stock going out.
+func postCostLedger(ctx context.Context, queries *db.Queries, materialID, warehouseID, movementID int32, quantityChange, unitCost float64) error {
+	// The first movement of a material in a warehouse creates the row, so
+	// there is always a row to lock and concurrent first receipts queue up
+	// behind each other instead of overwriting each other's totals
+	err := queries.CreateMaterialAverageCostIfMissing(ctx, db.CreateMaterialAverageCostIfMissingParams{
+		MaterialID:  materialID,
+		WarehouseID: warehouseID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create average cost: %w", err)
+	}
+
+	current, err := queries.GetMaterialAverageCostForUpdate(ctx, db.GetMaterialAverageCostForUpdateParams{
+		MaterialID:  materialID,
+		WarehouseID: warehouseID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to lock average cost: %w", err)
+	}
+	onHand := numericToFloat(current.OnHandQuantity)
+	totalValue := numericToFloat(current.TotalValue)
+	average := numericToFloat(current.AverageUnitCost)
+
+	valueChange := quantityChange * unitCost
+	onHand += quantityChange
+	totalValue += valueChange
+
+	if onHand > 0.0001 {
+		average = totalValue / onHand
+	} else {
+		// Nothing left on hand, keep the last average for the next issue
+		onHand = 0
+		totalValue = 0
+		if quantityChange > 0 {
+			average = unitCost
+		}
+	}
+
+	_, err = queries.UpsertMaterialAverageCost(ctx, db.UpsertMaterialAverageCostParams{
+		MaterialID:      materialID,
+		WarehouseID:     warehouseID,
+		OnHandQuantity:  costFromFloat(onHand),
+		TotalValue:      costFromFloat(totalValue),
+		AverageUnitCost: costFromFloat(average),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update average cost: %w", err)
+	}
+
+	_, err = queries.CreateCostLedgerEntry(ctx, db.CreateCostLedgerEntryParams{
+		MaterialID:      materialID,
+		WarehouseID:     warehouseID,
+		MovementID:      pgtype.Int4{Int32: movementID, Valid: true},
+		QuantityChange:  costFromFloat(quantityChange),
+		UnitCost:        costFromFloat(unitCost),
+		ValueChange:     costFromFloat(valueChange),
+		OnHandQuantity:  costFromFloat(onHand),
+		TotalValue:      costFromFloat(totalValue),
+		AverageUnitCost: costFromFloat(average),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create cost ledger entry: %w", err)
+	}
+
+	return nil
+}
+
+// =====================================================
+// COSTING QUERIES
+// =====================================================
+
+// GetMaterialAverageCosts - Get the running average cost of a material in every warehouse
+func (th *TransactionHandler) GetMaterialAverageCosts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	materialIDStr := r.URL.Query().Get("material_id")
+	if materialIDStr == "" {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "material_id is required"})
+		return
+	}
+
+	materialID, err := strconv.Atoi(materialIDStr)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid material_id"})
+		return
+	}
+
+	costs, err := th.h.Queries.ListMaterialAverageCosts(ctx, int32(materialID))
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get average costs"})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusOK, costs)
+}
+
+// GetCostLedger - Get the cost ledger of a material in a warehouse, newest first
+func (th *TransactionHandler) GetCostLedger(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	materialIDStr := r.URL.Query().Get("material_id")
+	warehouseIDStr := r.URL.Query().Get("warehouse_id")
+
+	if materialIDStr == "" || warehouseIDStr == "" {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "material_id and warehouse_id are required"})
+		return
+	}
+
+	materialID, err := strconv.Atoi(materialIDStr)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid material_id"})
+		return
+	}
+
+	warehouseID, err := strconv.Atoi(warehouseIDStr)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid warehouse_id"})
+		return
+	}
+
+	// Get pagination params
+	limit := 50
+	offset := 0
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+
+	entries, err := th.h.Queries.ListCostLedgerEntries(ctx, db.ListCostLedgerEntriesParams{
+		MaterialID:  int32(materialID),
+		WarehouseID: int32(warehouseID),
+		Limit:       int32(limit),
+		Offset:      int32(offset),
+	})
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get cost ledger"})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusOK, entries)
+}
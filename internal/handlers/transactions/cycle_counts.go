@@ -0,0 +1,844 @@
+package transactions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/xuri/excelize/v2"
+
+	"warehouse_system/internal/config"
+	db "warehouse_system/internal/database/db"
+	"warehouse_system/internal/middlewares"
+)
+
+// =====================================================
+// COUNT SESSION REQUEST TYPES
+// =====================================================
+
+type CreateCountSessionRequest struct {
+	WarehouseID int32   `json:"warehouse_id"`
+	CategoryID  *int32  `json:"category_id,omitempty"`
+	ABCClass    *string `json:"abc_class,omitempty"`
+	Notes       *string `json:"notes,omitempty"`
+}
+
+// CountEntry is the counted quantity of one session line
+type CountEntry struct {
+	LineID          int32   `json:"line_id"`
+	CountedQuantity float64 `json:"counted_quantity"`
+	Notes           *string `json:"notes,omitempty"`
+}
+
+type RecordCountsRequest struct {
+	Lines []CountEntry `json:"lines"`
+}
+
+// CountSheetFailedEntry represents a count sheet row that was not recorded
+type CountSheetFailedEntry struct {
+	Row    int    `json:"row"`
+	LineID string `json:"line_id"`
+	Reason string `json:"reason"`
+}
+
+type CountSessionResponse struct {
+	db.CountSession
+	LineCount         int                           `json:"line_count"`
+	CountedLineCount  int                           `json:"counted_line_count"`
+	VarianceLineCount int                           `json:"variance_line_count"`
+	VarianceValue     float64                       `json:"variance_value"`
+	Lines             []db.ListCountSessionLinesRow `json:"lines"`
+}
+
+// =====================================================
+// COUNT SESSION HELPERS
+// =====================================================
+
+// lockOpenCountSession locks a session for changes, only open sessions can
+// be counted, approved or cancelled
+func lockOpenCountSession(ctx context.Context, queries *db.Queries, id int32) (db.CountSession, int, error) {
+	session, err := queries.GetCountSessionByIDForUpdate(ctx, id)
+	if err != nil {
+		return db.CountSession{}, http.StatusNotFound, fmt.Errorf("count session not found")
+	}
+	if session.Status != "Open" {
+		return db.CountSession{}, http.StatusBadRequest, fmt.Errorf("count session is %s", session.Status)
+	}
+	return session, http.StatusOK, nil
+}
+
+// recordCount stores the counted quantity of a session line
+func recordCount(ctx context.Context, queries *db.Queries, sessionID, userID int32, entry CountEntry) error {
+	if entry.CountedQuantity < 0 {
+		return fmt.Errorf("line %d: counted quantity cannot be negative", entry.LineID)
+	}
+
+	rows, err := queries.UpdateCountSessionLineCount(ctx, db.UpdateCountSessionLineCountParams{
+		ID:              entry.LineID,
+		SessionID:       sessionID,
+		CountedQuantity: decimalFromFloat(entry.CountedQuantity),
+		CountedBy:       pgtype.Int4{Int32: userID, Valid: true},
+		Notes:           pgtype.Text{String: stringValue(entry.Notes), Valid: entry.Notes != nil && *entry.Notes != ""},
+	})
+	if err != nil {
+		return fmt.Errorf("line %d: failed to record count: %w", entry.LineID, err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("line %d is not part of this count session", entry.LineID)
+	}
+	return nil
+}
+
+// postCountVariance books the variance of a counted line against its batch as
+// an ADJUSTMENT_IN or ADJUSTMENT_OUT movement and returns the movement ID.
+// The variance is taken against the batch quantity when the line was
+// counted, so stock moved before the count is not counted twice and stock
+// moved after it stays in the batch the variance is applied to.
+func postCountVariance(ctx context.Context, queries *db.Queries, session db.CountSession, line db.ListCountSessionLinesRow, userID int32) (int32, error) {
+	expected := numericToFloat(line.SnapshotQuantity)
+	if line.ExpectedQuantity.Valid {
+		expected = numericToFloat(line.ExpectedQuantity)
+	}
+	variance := numericToFloat(line.CountedQuantity) - expected
+	quantity := math.Abs(variance)
+
+	unitCost, _, err := issueUnitCost(ctx, queries, line.MaterialID, session.WarehouseID, []BatchAllocation{{BatchID: line.BatchID, Quantity: quantity}})
+	if err != nil {
+		return 0, err
+	}
+	costPerUnit, totalCost := movementCost(quantity, unitCost)
+
+	params := db.CreateStockMovementParams{
+		MaterialID:   pgtype.Int4{Int32: line.MaterialID, Valid: true},
+		Quantity:     decimalFromFloat(quantity),
+		Reference:    pgtype.Text{String: session.SessionNumber, Valid: true},
+		PerformedBy:  pgtype.Int4{Int32: userID, Valid: true},
+		MovementDate: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		Notes:        pgtype.Text{String: fmt.Sprintf("Count %s, batch %s: counted %.2f, expected %.2f", session.SessionNumber, line.BatchNumber, numericToFloat(line.CountedQuantity), expected), Valid: true},
+		UnitCost:     costPerUnit,
+		TotalCost:    totalCost,
+	}
+
+	sign := 1.0
+	if variance > 0 {
+		params.StockDirection = db.StockDirectionIN
+		params.MovementType = db.StockMovementTypeADJUSTMENTIN
+		params.ToWarehouseID = pgtype.Int4{Int32: session.WarehouseID, Valid: true}
+	} else {
+		sign = -1
+		params.StockDirection = db.StockDirectionOUT
+		params.MovementType = db.StockMovementTypeADJUSTMENTOUT
+		params.FromWarehouseID = pgtype.Int4{Int32: session.WarehouseID, Valid: true}
+	}
+
+	movement, err := queries.CreateStockMovement(ctx, params)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create adjustment movement: %w", err)
+	}
+
+	batch, err := queries.UpdateBatchQuantity(ctx, db.UpdateBatchQuantityParams{
+		ID:              line.BatchID,
+		CurrentQuantity: decimalFromFloat(sign * quantity),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to update batch %s: %w", line.BatchNumber, err)
+	}
+	if numericToFloat(batch.CurrentQuantity) < 0 {
+		return 0, fmt.Errorf("batch %s has moved since it was counted, its quantity would become negative", line.BatchNumber)
+	}
+
+	if err := recordMovementBatch(ctx, queries, movement.ID, line.BatchID, quantity, unitCost); err != nil {
+		return 0, err
+	}
+
+	if err := postCostLedger(ctx, queries, line.MaterialID, session.WarehouseID, movement.ID, sign*quantity, unitCost); err != nil {
+		return 0, err
+	}
+
+	if err := queries.SetCountSessionLineMovement(ctx, db.SetCountSessionLineMovementParams{
+		ID:                   line.ID,
+		AdjustmentMovementID: pgtype.Int4{Int32: movement.ID, Valid: true},
+	}); err != nil {
+		return 0, fmt.Errorf("failed to link adjustment movement: %w", err)
+	}
+
+	return movement.ID, nil
+}
+
+// parseCountSessionID reads the session ID from the path
+func parseCountSessionID(r *http.Request) (int32, error) {
+	var id int32
+	if _, err := fmt.Sscanf(r.PathValue("id"), "%d", &id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// =====================================================
+// COUNT SESSION HANDLERS
+// =====================================================
+
+// CreateCountSession - Start a count session and freeze the batch quantities in scope
+func (th *TransactionHandler) CreateCountSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user session from context
+	session, ok := middlewares.GetSessionFromContext(r)
+	if !ok {
+		config.RespondJSON(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized - Authentication required"})
+		return
+	}
+
+	// Parse user ID from session
+	var userID int32
+	_, err := fmt.Sscanf(session.UserID, "%d", &userID)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+		return
+	}
+
+	var req CreateCountSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	if req.WarehouseID == 0 {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "warehouse_id is required"})
+		return
+	}
+
+	var abcClass pgtype.Text
+	if req.ABCClass != nil && *req.ABCClass != "" {
+		class := strings.ToUpper(*req.ABCClass)
+		if class != "A" && class != "B" && class != "C" {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "abc_class must be 'A', 'B' or 'C'"})
+			return
+		}
+		abcClass = pgtype.Text{String: class, Valid: true}
+	}
+
+	tx, err := th.h.DB.Begin(ctx)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to start transaction"})
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	queries := th.h.Queries.WithTx(tx)
+
+	categoryID := pgtype.Int4{Int32: int32Value(req.CategoryID), Valid: req.CategoryID != nil && *req.CategoryID != 0}
+
+	// The session number is set from a sequence on insert
+	countSession, err := queries.CreateCountSession(ctx, db.CreateCountSessionParams{
+		WarehouseID: req.WarehouseID,
+		CategoryID:  categoryID,
+		AbcClass:    abcClass,
+		CreatedBy:   pgtype.Int4{Int32: userID, Valid: true},
+		Notes:       pgtype.Text{String: stringValue(req.Notes), Valid: req.Notes != nil && *req.Notes != ""},
+	})
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create count session"})
+		return
+	}
+
+	lineCount, err := queries.CreateCountSessionLines(ctx, db.CreateCountSessionLinesParams{
+		SessionID:   countSession.ID,
+		WarehouseID: pgtype.Int4{Int32: req.WarehouseID, Valid: true},
+		CategoryID:  categoryID,
+		AbcClass:    abcClass,
+	})
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to snapshot batch quantities"})
+		return
+	}
+
+	if lineCount == 0 {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "No stock to count in this warehouse and scope"})
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to commit transaction"})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusCreated, map[string]any{
+		"session":    countSession,
+		"line_count": lineCount,
+	})
+}
+
+// ListCountSessions - Get the count sessions of a warehouse, newest first
+func (th *TransactionHandler) ListCountSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	warehouseIDStr := r.URL.Query().Get("warehouse_id")
+	if warehouseIDStr == "" {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "warehouse_id is required"})
+		return
+	}
+
+	warehouseID, err := strconv.Atoi(warehouseIDStr)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid warehouse_id"})
+		return
+	}
+
+	// Get pagination params
+	limit := 50
+	offset := 0
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			limit = l
+		}
+	}
+
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+
+	sessions, err := th.h.Queries.ListCountSessions(ctx, db.ListCountSessionsParams{
+		WarehouseID: int32(warehouseID),
+		Limit:       int32(limit),
+		Offset:      int32(offset),
+	})
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get count sessions"})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusOK, sessions)
+}
+
+// GetCountSession - Get a count session with its lines and variances
+func (th *TransactionHandler) GetCountSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := parseCountSessionID(r)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid count session ID"})
+		return
+	}
+
+	countSession, err := th.h.Queries.GetCountSessionByID(ctx, id)
+	if err != nil {
+		config.RespondJSON(w, http.StatusNotFound, map[string]string{"error": "Count session not found"})
+		return
+	}
+
+	lines, err := th.h.Queries.ListCountSessionLines(ctx, id)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get count session lines"})
+		return
+	}
+
+	response := CountSessionResponse{
+		CountSession: countSession,
+		LineCount:    len(lines),
+		Lines:        lines,
+	}
+
+	for _, l := range lines {
+		if !l.CountedQuantity.Valid {
+			continue
+		}
+		response.CountedLineCount++
+
+		variance := numericToFloat(l.VarianceQuantity)
+		if math.Abs(variance) > 0.0001 {
+			response.VarianceLineCount++
+			response.VarianceValue += variance * numericToFloat(l.UnitPrice)
+		}
+	}
+
+	config.RespondJSON(w, http.StatusOK, response)
+}
+
+// DownloadCountSheet - Export the count sheet of a session as Excel. The sheet
+// is blind: it lists the batches to count without their system quantity.
+func (th *TransactionHandler) DownloadCountSheet(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := parseCountSessionID(r)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid count session ID"})
+		return
+	}
+
+	countSession, err := th.h.Queries.GetCountSessionByID(ctx, id)
+	if err != nil {
+		config.RespondJSON(w, http.StatusNotFound, map[string]string{"error": "Count session not found"})
+		return
+	}
+
+	lines, err := th.h.Queries.ListCountSessionLines(ctx, id)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get count session lines"})
+		return
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := "Count Sheet"
+	index, _ := f.NewSheet(sheet)
+	f.SetActiveSheet(index)
+	f.DeleteSheet("Sheet1")
+
+	// Define headers
+	headers := []string{
+		"Line ID",
+		"Bin",
+		"Material Code",
+		"Material Name",
+		"Batch Number",
+		"Expiry Date",
+		"Unit",
+		"Counted Quantity",
+		"Notes",
+	}
+
+	// Set headers
+	for i, h := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheet, cell, h)
+	}
+
+	for i, l := range lines {
+		row := i + 2
+		f.SetCellValue(sheet, fmt.Sprintf("A%d", row), l.ID)
+		f.SetCellValue(sheet, fmt.Sprintf("B%d", row), l.BinCode.String)
+		f.SetCellValue(sheet, fmt.Sprintf("C%d", row), l.MaterialCode)
+		f.SetCellValue(sheet, fmt.Sprintf("D%d", row), l.MaterialName)
+		f.SetCellValue(sheet, fmt.Sprintf("E%d", row), l.BatchNumber)
+		if l.ExpiryDate.Valid {
+			f.SetCellValue(sheet, fmt.Sprintf("F%d", row), l.ExpiryDate.Time.Format("2006-01-02"))
+		}
+		f.SetCellValue(sheet, fmt.Sprintf("G%d", row), l.Unit.String)
+		if l.CountedQuantity.Valid {
+			f.SetCellValue(sheet, fmt.Sprintf("H%d", row), numericToFloat(l.CountedQuantity))
+		}
+		f.SetCellValue(sheet, fmt.Sprintf("I%d", row), l.Notes.String)
+	}
+
+	// Style headers
+	style, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"#E0E0E0"}, Pattern: 1},
+	})
+	f.SetCellStyle(sheet, "A1", "I1", style)
+
+	// Set column widths
+	f.SetColWidth(sheet, "A", "A", 10)
+	f.SetColWidth(sheet, "B", "B", 12)
+	f.SetColWidth(sheet, "C", "C", 15)
+	f.SetColWidth(sheet, "D", "D", 30)
+	f.SetColWidth(sheet, "E", "E", 20)
+	f.SetColWidth(sheet, "F", "F", 15)
+	f.SetColWidth(sheet, "G", "G", 10)
+	f.SetColWidth(sheet, "H", "H", 18)
+	f.SetColWidth(sheet, "I", "I", 30)
+
+	// Write to response
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=count_sheet_%s.xlsx", countSession.SessionNumber))
+
+	if err := f.Write(w); err != nil {
+		th.h.Logger.Error("Failed to write count sheet", "error", err)
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to generate count sheet"})
+		return
+	}
+}
+
+// RecordCounts - Record counted quantities for session lines
+func (th *TransactionHandler) RecordCounts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user session from context
+	session, ok := middlewares.GetSessionFromContext(r)
+	if !ok {
+		config.RespondJSON(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized - Authentication required"})
+		return
+	}
+
+	// Parse user ID from session
+	var userID int32
+	_, err := fmt.Sscanf(session.UserID, "%d", &userID)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+		return
+	}
+
+	id, err := parseCountSessionID(r)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid count session ID"})
+		return
+	}
+
+	var req RecordCountsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	if len(req.Lines) == 0 {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "No counts provided"})
+		return
+	}
+
+	tx, err := th.h.DB.Begin(ctx)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to start transaction"})
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	queries := th.h.Queries.WithTx(tx)
+
+	if _, status, err := lockOpenCountSession(ctx, queries, id); err != nil {
+		config.RespondJSON(w, status, map[string]string{"error": err.Error()})
+		return
+	}
+
+	for _, entry := range req.Lines {
+		if err := recordCount(ctx, queries, id, userID, entry); err != nil {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to commit transaction"})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusOK, map[string]any{
+		"success":        true,
+		"recorded_count": len(req.Lines),
+	})
+}
+
+// ImportCountSheet - Record counted quantities from a filled in count sheet.
+// Rows without a counted quantity are skipped.
+func (th *TransactionHandler) ImportCountSheet(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user session from context
+	session, ok := middlewares.GetSessionFromContext(r)
+	if !ok {
+		config.RespondJSON(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized - Authentication required"})
+		return
+	}
+
+	// Parse user ID from session
+	var userID int32
+	_, err := fmt.Sscanf(session.UserID, "%d", &userID)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+		return
+	}
+
+	id, err := parseCountSessionID(r)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid count session ID"})
+		return
+	}
+
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		config.RespondBadRequest(w, "Failed to parse form", err.Error())
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		config.RespondBadRequest(w, "No file uploaded", err.Error())
+		return
+	}
+	defer file.Close()
+
+	f, err := excelize.OpenReader(file)
+	if err != nil {
+		config.RespondBadRequest(w, "Invalid Excel file", err.Error())
+		return
+	}
+	defer f.Close()
+
+	rows, err := f.GetRows(f.GetSheetName(0))
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to read Excel rows"})
+		return
+	}
+
+	if len(rows) < 2 {
+		config.RespondBadRequest(w, "Excel file is empty", "File must have at least one data row")
+		return
+	}
+
+	// Build column header map
+	colMap := make(map[string]int)
+	for i, header := range rows[0] {
+		colMap[strings.ToLower(strings.TrimSpace(header))] = i
+	}
+
+	getCol := func(row []string, colName string) string {
+		if idx, exists := colMap[colName]; exists && idx < len(row) {
+			return strings.TrimSpace(row[idx])
+		}
+		return ""
+	}
+
+	for _, col := range []string{"line id", "counted quantity"} {
+		if _, exists := colMap[col]; !exists {
+			config.RespondBadRequest(w, "Missing required column", fmt.Sprintf("Excel file must have '%s' column", col))
+			return
+		}
+	}
+
+	tx, err := th.h.DB.Begin(ctx)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to start transaction"})
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	queries := th.h.Queries.WithTx(tx)
+
+	if _, status, err := lockOpenCountSession(ctx, queries, id); err != nil {
+		config.RespondJSON(w, status, map[string]string{"error": err.Error()})
+		return
+	}
+
+	var failed []CountSheetFailedEntry
+	recorded := 0
+
+	for i, row := range rows[1:] {
+		rowNum := i + 2
+
+		lineIDStr := getCol(row, "line id")
+		countedStr := getCol(row, "counted quantity")
+		if countedStr == "" {
+			continue
+		}
+
+		lineID, err := strconv.Atoi(lineIDStr)
+		if err != nil {
+			failed = append(failed, CountSheetFailedEntry{Row: rowNum, LineID: lineIDStr, Reason: "Invalid line ID"})
+			continue
+		}
+
+		counted, err := strconv.ParseFloat(countedStr, 64)
+		if err != nil {
+			failed = append(failed, CountSheetFailedEntry{Row: rowNum, LineID: lineIDStr, Reason: fmt.Sprintf("Invalid counted quantity: %s", countedStr)})
+			continue
+		}
+
+		entry := CountEntry{LineID: int32(lineID), CountedQuantity: counted}
+		if notes := getCol(row, "notes"); notes != "" {
+			entry.Notes = &notes
+		}
+
+		if err := recordCount(ctx, queries, id, userID, entry); err != nil {
+			failed = append(failed, CountSheetFailedEntry{Row: rowNum, LineID: lineIDStr, Reason: err.Error()})
+			continue
+		}
+		recorded++
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to commit transaction"})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusOK, map[string]any{
+		"recorded_count": recorded,
+		"failed_count":   len(failed),
+		"failed":         failed,
+	})
+}
+
+// ApproveCountSession - Post the variances of a fully counted session as adjustments
+func (th *TransactionHandler) ApproveCountSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user session from context
+	session, ok := middlewares.GetSessionFromContext(r)
+	if !ok {
+		config.RespondJSON(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized - Authentication required"})
+		return
+	}
+
+	// Parse user ID from session
+	var userID int32
+	_, err := fmt.Sscanf(session.UserID, "%d", &userID)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+		return
+	}
+
+	id, err := parseCountSessionID(r)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid count session ID"})
+		return
+	}
+
+	tx, err := th.h.DB.Begin(ctx)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to start transaction"})
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	queries := th.h.Queries.WithTx(tx)
+
+	countSession, status, err := lockOpenCountSession(ctx, queries, id)
+	if err != nil {
+		config.RespondJSON(w, status, map[string]string{"error": err.Error()})
+		return
+	}
+
+	lines, err := queries.ListCountSessionLines(ctx, id)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get count session lines"})
+		return
+	}
+
+	uncounted := 0
+	for _, l := range lines {
+		if !l.CountedQuantity.Valid {
+			uncounted++
+		}
+	}
+	if uncounted > 0 {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("%d lines have not been counted yet", uncounted)})
+		return
+	}
+
+	// Lock the counted stock before any batch is adjusted
+	materialIDs := make([]int32, len(lines))
+	for i, l := range lines {
+		materialIDs[i] = l.MaterialID
+	}
+	if err := lockStocks(ctx, queries, materialIDs, countSession.WarehouseID); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to lock stock"})
+		return
+	}
+
+	// All adjustments are posted in this transaction or none
+	movementIDs := []int32{}
+	for _, l := range lines {
+		if math.Abs(numericToFloat(l.VarianceQuantity)) <= 0.0001 {
+			continue
+		}
+
+		// A count cannot tell which units were found or are missing, sessions
+		// opened before serialized materials were left out may still hold them
+		if err := rejectSerialized(ctx, queries, l.MaterialID, "post the variance as an adjustment with serial numbers, then count the line at its snapshot quantity"); err != nil {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("Line %d: %s", l.ID, err.Error())})
+			return
+		}
+
+		movementID, err := postCountVariance(ctx, queries, countSession, l, userID)
+		if err != nil {
+			th.h.Logger.Error("Failed to post count variance", "session_id", id, "line_id", l.ID, "error", err)
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		movementIDs = append(movementIDs, movementID)
+	}
+
+	approved, err := queries.UpdateCountSessionStatus(ctx, db.UpdateCountSessionStatusParams{
+		ID:         id,
+		Status:     "Approved",
+		ApprovedBy: pgtype.Int4{Int32: userID, Valid: true},
+	})
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to approve count session"})
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to commit transaction"})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusOK, map[string]any{
+		"session":      approved,
+		"movement_ids": movementIDs,
+	})
+}
+
+// CancelCountSession - Cancel an open count session without posting anything
+func (th *TransactionHandler) CancelCountSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := parseCountSessionID(r)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid count session ID"})
+		return
+	}
+
+	tx, err := th.h.DB.Begin(ctx)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to start transaction"})
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	queries := th.h.Queries.WithTx(tx)
+
+	if _, status, err := lockOpenCountSession(ctx, queries, id); err != nil {
+		config.RespondJSON(w, status, map[string]string{"error": err.Error()})
+		return
+	}
+
+	cancelled, err := queries.UpdateCountSessionStatus(ctx, db.UpdateCountSessionStatusParams{
+		ID:     id,
+		Status: "Cancelled",
+	})
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to cancel count session"})
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to commit transaction"})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusOK, cancelled)
+}
+
+// GetMaterialABCClasses - Get the ABC class of every stocked material in a warehouse
+func (th *TransactionHandler) GetMaterialABCClasses(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	warehouseIDStr := r.URL.Query().Get("warehouse_id")
+	if warehouseIDStr == "" {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "warehouse_id is required"})
+		return
+	}
+
+	warehouseID, err := strconv.Atoi(warehouseIDStr)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid warehouse_id"})
+		return
+	}
+
+	classes, err := th.h.Queries.ListMaterialABCClasses(ctx, pgtype.Int4{Int32: int32(warehouseID), Valid: true})
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get ABC classes"})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusOK, classes)
+}
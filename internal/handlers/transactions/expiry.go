@@ -0,0 +1,172 @@
+package transactions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"warehouse_system/internal/config"
+	db "warehouse_system/internal/database/db"
+)
+
+// =====================================================
+// BATCH EXPIRY MONITORING
+// =====================================================
+
+// ExpiryJobType is the job type of the batch expiry check
+const ExpiryJobType = "batches.check_expiry"
+
+// ExpiryCheckRun is the outcome of one expiry check
+type ExpiryCheckRun struct {
+	HorizonDays int      `json:"horizon_days"`
+	Flagged     int64    `json:"flagged"`
+	Held        int      `json:"held"`
+	HoldNumbers []string `json:"hold_numbers"`
+}
+
+// ExpiringBatchesResponse lists the batches nearing or past expiry
+type ExpiringBatchesResponse struct {
+	HorizonDays int                         `json:"horizon_days"`
+	Expired     int                         `json:"expired"`
+	Expiring    int                         `json:"expiring"`
+	Batches     []db.ListExpiringBatchesRow `json:"batches"`
+}
+
+// CheckBatchExpiry flags the batches expiring within the configured horizon
+// and places a blocked quality hold on every expired batch that is not on
+// hold yet, so stock-outs can no longer allocate it. Each batch is held once,
+// a hold quality released is not placed again.
+func (th *TransactionHandler) CheckBatchExpiry(ctx context.Context) (ExpiryCheckRun, error) {
+	run := ExpiryCheckRun{HorizonDays: th.h.CFG.Inventory.ExpiryWarningDays, HoldNumbers: []string{}}
+
+	tx, err := th.h.DB.Begin(ctx)
+	if err != nil {
+		return run, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	queries := th.h.Queries.WithTx(tx)
+
+	run.Flagged, err = queries.FlagExpiringBatches(ctx, int32(run.HorizonDays))
+	if err != nil {
+		return run, fmt.Errorf("failed to flag expiring batches: %w", err)
+	}
+
+	expired, err := queries.ListExpiredBatchesWithoutHold(ctx)
+	if err != nil {
+		return run, fmt.Errorf("failed to get expired batches: %w", err)
+	}
+
+	for _, b := range expired {
+		hold, err := queries.CreateQualityHold(ctx, db.CreateQualityHoldParams{
+			HoldNumber:    "", // Trigger will generate
+			MaterialID:    b.MaterialID,
+			WarehouseID:   b.WarehouseID,
+			BatchNumber:   pgtype.Text{String: b.BatchNumber, Valid: true},
+			Quantity:      b.CurrentQuantity,
+			UnitID:        b.MeasureUnitID,
+			QualityStatus: db.QualityStatusBlocked,
+			HoldReason:    fmt.Sprintf("Batch %s expired on %s", b.BatchNumber, b.ExpiryDate.Time.Format("2006-01-02")),
+			PlacedDate:    pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		})
+		if err != nil {
+			return run, fmt.Errorf("failed to place hold on batch %d: %w", b.ID, err)
+		}
+
+		if err := queries.SetBatchExpiryHold(ctx, db.SetBatchExpiryHoldParams{
+			BatchID: b.ID,
+			HoldID:  pgtype.Int4{Int32: hold.ID, Valid: true},
+		}); err != nil {
+			return run, fmt.Errorf("failed to record hold of batch %d: %w", b.ID, err)
+		}
+
+		run.Held++
+		run.HoldNumbers = append(run.HoldNumbers, hold.HoldNumber)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return run, fmt.Errorf("failed to commit expiry check: %w", err)
+	}
+	return run, nil
+}
+
+// ExpiryJob runs CheckBatchExpiry as a background job
+func (th *TransactionHandler) ExpiryJob(ctx context.Context, _ json.RawMessage) (interface{}, error) {
+	run, err := th.CheckBatchExpiry(ctx)
+	if err != nil {
+		return nil, err
+	}
+	th.h.Logger.Info("batch expiry checked",
+		"horizon_days", run.HorizonDays,
+		"flagged", run.Flagged,
+		"held", run.Held,
+	)
+	return run, nil
+}
+
+// RunExpiryCheck - Run the batch expiry check right away
+func (th *TransactionHandler) RunExpiryCheck(w http.ResponseWriter, r *http.Request) {
+	run, err := th.CheckBatchExpiry(r.Context())
+	if err != nil {
+		th.h.Logger.Error("Failed to check batch expiry", "error", err)
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to check batch expiry"})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusOK, run)
+}
+
+// ListExpiringBatches - List batches with stock that expire within the
+// horizon (days, default the configured warning horizon) or have expired
+func (th *TransactionHandler) ListExpiringBatches(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	horizon := th.h.CFG.Inventory.ExpiryWarningDays
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		days, err := strconv.Atoi(daysStr)
+		if err != nil || days < 0 {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid days"})
+			return
+		}
+		horizon = days
+	}
+
+	params := db.ListExpiringBatchesParams{HorizonDays: int32(horizon)}
+	if warehouseIDStr := r.URL.Query().Get("warehouse_id"); warehouseIDStr != "" {
+		warehouseID, err := strconv.Atoi(warehouseIDStr)
+		if err != nil {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid warehouse_id"})
+			return
+		}
+		params.WarehouseID = pgtype.Int4{Int32: int32(warehouseID), Valid: true}
+	}
+	if materialIDStr := r.URL.Query().Get("material_id"); materialIDStr != "" {
+		materialID, err := strconv.Atoi(materialIDStr)
+		if err != nil {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid material_id"})
+			return
+		}
+		params.MaterialID = pgtype.Int4{Int32: int32(materialID), Valid: true}
+	}
+
+	batches, err := th.h.Queries.ListExpiringBatches(ctx, params)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get expiring batches"})
+		return
+	}
+
+	response := ExpiringBatchesResponse{HorizonDays: horizon, Batches: batches}
+	for _, b := range batches {
+		if b.Expired {
+			response.Expired++
+		} else {
+			response.Expiring++
+		}
+	}
+
+	config.RespondJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,215 @@
+package transactions
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"warehouse_system/internal/config"
+	db "warehouse_system/internal/database/db"
+	"warehouse_system/internal/middlewares"
+)
+
+// =====================================================
+// IDEMPOTENCY KEYS
+// =====================================================
+
+// IdempotencyKeyHeader is the request header carrying the client chosen key
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength matches idempotency_keys.idempotency_key
+const maxIdempotencyKeyLength = 255
+
+// IdempotencyCleanupJobType is the job type of the expired idempotency key cleanup
+const IdempotencyCleanupJobType = "idempotency.cleanup"
+
+// capturedResponse passes a response through to the client and keeps a copy
+// so it can be stored for replays
+type capturedResponse struct {
+	http.ResponseWriter
+	body       bytes.Buffer
+	statusCode int
+}
+
+func (c *capturedResponse) WriteHeader(code int) {
+	if c.statusCode == 0 {
+		c.statusCode = code
+	}
+	c.ResponseWriter.WriteHeader(code)
+}
+
+func (c *capturedResponse) Write(data []byte) (int, error) {
+	if c.statusCode == 0 {
+		c.statusCode = http.StatusOK
+	}
+	c.body.Write(data)
+	return c.ResponseWriter.Write(data)
+}
+
+// requestHash fingerprints a request so a reused key with a different
+// payload can be told apart from a retry
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n", r.Method, r.URL.Path)
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// replayIdempotentResponse answers a request whose key is already claimed:
+// the stored response for a retry, an error for a different payload, for a
+// first request that is still running or for one that committed without
+// storing its response
+func replayIdempotentResponse(w http.ResponseWriter, stored db.IdempotencyKey, hash string) {
+	if stored.RequestHash != hash {
+		config.RespondJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": "Idempotency-Key was already used with a different request"})
+		return
+	}
+	if !stored.StatusCode.Valid && stored.LeaseExpiresAt.Valid {
+		config.RespondJSON(w, http.StatusConflict, map[string]string{"error": "A request with this Idempotency-Key is still in progress"})
+		return
+	}
+	if !stored.StatusCode.Valid {
+		config.RespondJSON(w, http.StatusConflict, map[string]string{"error": "The request with this Idempotency-Key was posted but its response was lost, check the stock before posting it again"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(int(stored.StatusCode.Int32))
+	w.Write(stored.ResponseBody)
+}
+
+// Idempotency is a route middleware for stock postings. A request with an
+// Idempotency-Key header runs once per user and key; a retry with the same
+// payload gets the stored response back without posting anything. Server
+// errors are not stored, so a request that failed that way can be retried
+// with the same key. A request that committed is never run again for the
+// key, even when its response could not be stored. A running request holds
+// the key for the configured lease, after that a retry takes it over.
+// Requests without the header are not affected.
+func (th *TransactionHandler) Idempotency(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("Idempotency-Key cannot be longer than %d characters", maxIdempotencyKeyLength)})
+			return
+		}
+
+		// Keys are scoped to the user, without a session the handler rejects the request
+		session, ok := middlewares.GetSessionFromContext(r)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		var userID int32
+		if _, err := fmt.Sscanf(session.UserID, "%d", &userID); err != nil {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Failed to read request body"})
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		hash := requestHash(r, body)
+
+		ctx := r.Context()
+		claimID, err := th.h.Queries.ClaimIdempotencyKey(ctx, db.ClaimIdempotencyKeyParams{
+			UserID:         userID,
+			IdempotencyKey: key,
+			Method:         r.Method,
+			Path:           r.URL.Path,
+			RequestHash:    hash,
+			LeaseExpiresAt: pgtype.Timestamptz{Time: time.Now().Add(th.h.CFG.Inventory.IdempotencyLease), Valid: true},
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			stored, err := th.h.Queries.GetIdempotencyKey(ctx, db.GetIdempotencyKeyParams{
+				UserID:         userID,
+				IdempotencyKey: key,
+			})
+			if err != nil {
+				config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get idempotency key"})
+				return
+			}
+			replayIdempotentResponse(w, stored, hash)
+			return
+		}
+		if err != nil {
+			th.h.Logger.Error("Failed to claim idempotency key", "error", err)
+			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to claim idempotency key"})
+			return
+		}
+
+		// The outcome is stored even if the client has gone away meanwhile,
+		// that is exactly the case a retry will come back for
+		storeCtx := context.WithoutCancel(ctx)
+		resp := &capturedResponse{ResponseWriter: w}
+		completed := false
+		trackedCtx, committed := middlewares.TrackTxCommits(ctx)
+		defer func() {
+			if completed {
+				return
+			}
+			// Stock the handler committed is posted, a retry must not post
+			// it again, so the key stays claimed with an unknown outcome
+			if committed() {
+				th.h.Logger.Warn("Idempotent request committed without a stored response", "key_id", claimID)
+				if err := th.h.Queries.HoldIdempotencyKey(storeCtx, claimID); err != nil {
+					th.h.Logger.Error("Failed to hold idempotency key", "key_id", claimID, "error", err)
+				}
+				return
+			}
+			// A panicking handler must not leave the key blocked
+			if err := th.h.Queries.DeleteIdempotencyKey(storeCtx, claimID); err != nil {
+				th.h.Logger.Error("Failed to release idempotency key", "key_id", claimID, "error", err)
+			}
+		}()
+
+		next.ServeHTTP(resp, r.WithContext(trackedCtx))
+
+		if resp.statusCode == 0 {
+			resp.statusCode = http.StatusOK
+		}
+		if resp.statusCode >= http.StatusInternalServerError {
+			return
+		}
+
+		if err := th.h.Queries.CompleteIdempotencyKey(storeCtx, db.CompleteIdempotencyKeyParams{
+			ID:           claimID,
+			StatusCode:   pgtype.Int4{Int32: int32(resp.statusCode), Valid: true},
+			ResponseBody: resp.body.Bytes(),
+		}); err != nil {
+			th.h.Logger.Error("Failed to store idempotent response", "key_id", claimID, "error", err)
+			return
+		}
+		completed = true
+	})
+}
+
+// IdempotencyCleanupJob deletes the idempotency keys older than 24 hours,
+// a retry after that is posted as a new request
+func (th *TransactionHandler) IdempotencyCleanupJob(ctx context.Context, _ json.RawMessage) (interface{}, error) {
+	deleted, err := th.h.Queries.DeleteExpiredIdempotencyKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+	th.h.Logger.Info("expired idempotency keys deleted", "deleted", deleted)
+	return map[string]int64{"deleted": deleted}, nil
+}
@@ -0,0 +1,57 @@
+package transactions
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "warehouse_system/internal/database/db"
+)
+
+// =====================================================
+// RECEIPT INSPECTION
+// =====================================================
+
+// quarantineBatch opens a pending inspection of a batch that was just
+// received and places the batch on a quarantine hold owned by the
+// inspection, so stock-outs cannot allocate it until the inspection is
+// decided. The caller fills in the inspection type and the documents it
+// refers to, the batch fills in the rest.
+func quarantineBatch(ctx context.Context, queries *db.Queries, batch db.Batch, inspection db.CreateQualityInspectionParams, userID int32) (db.QualityInspection, error) {
+	material, err := queries.GetMaterialByID(ctx, batch.MaterialID.Int32)
+	if err != nil {
+		return db.QualityInspection{}, fmt.Errorf("material %d not found", batch.MaterialID.Int32)
+	}
+
+	inspection.InspectionNumber = "" // Trigger will generate
+	inspection.InspectionStatus = db.NullQualityInspectionStatus{QualityInspectionStatus: db.QualityInspectionStatusPending, Valid: true}
+	inspection.MaterialID = batch.MaterialID
+	inspection.BatchNumber = pgtype.Text{String: batch.BatchNumber, Valid: true}
+	inspection.Quantity = batch.StartQuantity
+	inspection.UnitID = material.MeasureUnitID
+
+	created, err := queries.CreateQualityInspection(ctx, inspection)
+	if err != nil {
+		return created, fmt.Errorf("failed to create inspection: %w", err)
+	}
+
+	if _, err := queries.CreateQualityHold(ctx, db.CreateQualityHoldParams{
+		HoldNumber:    "", // Trigger will generate
+		MaterialID:    batch.MaterialID.Int32,
+		WarehouseID:   batch.WarehouseID,
+		BatchNumber:   pgtype.Text{String: batch.BatchNumber, Valid: true},
+		Quantity:      batch.StartQuantity,
+		UnitID:        material.MeasureUnitID,
+		QualityStatus: db.QualityStatusQuarantine,
+		HoldReason:    fmt.Sprintf("Awaiting %s inspection %s", created.InspectionType, created.InspectionNumber),
+		InspectionID:  pgtype.Int4{Int32: created.ID, Valid: true},
+		PlacedBy:      pgtype.Int4{Int32: userID, Valid: true},
+		PlacedDate:    pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	}); err != nil {
+		return created, fmt.Errorf("failed to place quarantine hold: %w", err)
+	}
+
+	return created, nil
+}
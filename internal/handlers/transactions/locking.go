@@ -0,0 +1,52 @@
+package transactions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "warehouse_system/internal/database/db"
+)
+
+// =====================================================
+// STOCK LOCKING
+// =====================================================
+
+// lockStock locks every batch of a material in a warehouse for the rest of
+// the transaction. Every stock-out takes this lock before it reads batch
+// quantities, so two concurrent stock-outs of the same stock run one after
+// the other and the second one sees what the first one consumed.
+func lockStock(ctx context.Context, queries *db.Queries, materialID, warehouseID int32) error {
+	if err := queries.LockMaterialBatches(ctx, db.LockMaterialBatchesParams{
+		MaterialID:  pgtype.Int4{Int32: materialID, Valid: true},
+		WarehouseID: pgtype.Int4{Int32: warehouseID, Valid: true},
+	}); err != nil {
+		return fmt.Errorf("failed to lock stock: %w", err)
+	}
+	return nil
+}
+
+// lockStocks locks the stock of several materials in a warehouse. The
+// materials are locked in ID order so two transactions touching the same
+// materials cannot deadlock.
+func lockStocks(ctx context.Context, queries *db.Queries, materialIDs []int32, warehouseID int32) error {
+	ids := slices.Clone(materialIDs)
+	slices.Sort(ids)
+	for _, id := range slices.Compact(ids) {
+		if err := lockStock(ctx, queries, id, warehouseID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isNegativeStock reports whether err is the database refusing to drive a
+// batch below zero, see migration 018
+func isNegativeStock(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.ConstraintName == "chk_batches_current_quantity_non_negative"
+}
@@ -3,8 +3,10 @@ package transactions
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
@@ -19,10 +21,12 @@ import (
 // =====================================================
 
 type CustomerReturnRequest struct {
-	SalesOrderID int32   `json:"sales_order_id"`
-	MaterialID   int32   `json:"material_id"`
-	Quantity     float64 `json:"quantity"`
-	Notes        *string `json:"notes,omitempty"`
+	SalesOrderID  int32    `json:"sales_order_id"`
+	MaterialID    int32    `json:"material_id"`
+	Quantity      float64  `json:"quantity"`
+	UnitID        *int32   `json:"unit_id,omitempty"`
+	Notes         *string  `json:"notes,omitempty"`
+	SerialNumbers []string `json:"serial_numbers,omitempty"` // one per unit of a serialized material
 }
 
 // =====================================================
@@ -44,17 +48,37 @@ func getValuationMethod(ctx context.Context, queries *db.Queries, materialID, wa
 	return "FIFO", nil // default
 }
 
-func allocateBatchesAuto(ctx context.Context, queries *db.Queries, materialID, warehouseID int32, quantity float64, valuationMethod string) ([]BatchAllocation, error) {
+// getAllocationStrategy returns the order batches of a material are drawn
+// from in a warehouse
+func getAllocationStrategy(ctx context.Context, queries *db.Queries, materialID, warehouseID int32) (db.AllocationStrategy, error) {
+	strategy, err := queries.GetMaterialAllocationStrategy(ctx, db.GetMaterialAllocationStrategyParams{
+		ID:   materialID,
+		ID_2: warehouseID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get allocation strategy: %w", err)
+	}
+	return strategy, nil
+}
+
+func allocateBatchesAuto(ctx context.Context, queries *db.Queries, materialID, warehouseID, binID int32, quantity float64, strategy db.AllocationStrategy, reservations stockReservations) ([]BatchAllocation, error) {
 	var batches []db.Batch
 	var err error
 
-	if valuationMethod == "LIFO" {
+	switch strategy {
+	case db.AllocationStrategyLIFO:
 		batches, err = queries.GetBatchesByWarehouseAndMaterialLIFO(ctx, db.GetBatchesByWarehouseAndMaterialLIFOParams{
 			WarehouseID: pgtype.Int4{Int32: warehouseID, Valid: true},
 			MaterialID:  pgtype.Int4{Int32: materialID, Valid: true},
 		})
-	} else {
-		// FIFO or Weighted Average (use FIFO for allocation)
+	case db.AllocationStrategyFEFO:
+		// Earliest expiry first, expired batches are never allocated
+		batches, err = queries.GetBatchesByWarehouseAndMaterialFEFO(ctx, db.GetBatchesByWarehouseAndMaterialFEFOParams{
+			WarehouseID: pgtype.Int4{Int32: warehouseID, Valid: true},
+			MaterialID:  pgtype.Int4{Int32: materialID, Valid: true},
+		})
+	default:
+		// FIFO, oldest batch first
 		batches, err = queries.GetBatchesByWarehouseAndMaterial(ctx, db.GetBatchesByWarehouseAndMaterialParams{
 			WarehouseID: pgtype.Int4{Int32: warehouseID, Valid: true},
 			MaterialID:  pgtype.Int4{Int32: materialID, Valid: true},
@@ -65,6 +89,13 @@ func allocateBatchesAuto(ctx context.Context, queries *db.Queries, materialID, w
 		return nil, fmt.Errorf("failed to get batches: %w", err)
 	}
 
+	// Batches pinned to the line being shipped go first
+	if len(reservations.ownBatches) > 0 {
+		sort.SliceStable(batches, func(i, j int) bool {
+			return reservations.ownBatches[batches[i].ID] && !reservations.ownBatches[batches[j].ID]
+		})
+	}
+
 	allocations := []BatchAllocation{}
 	remaining := quantity
 
@@ -73,11 +104,21 @@ func allocateBatchesAuto(ctx context.Context, queries *db.Queries, materialID, w
 			break
 		}
 
+		// A bin ID of 0 allocates from every bin of the warehouse
+		if binID != 0 && batch.BinID.Int32 != binID {
+			continue
+		}
+
 		// Convert pgtype.Numeric to float64
 		batchQty, _ := batch.CurrentQuantity.Float64Value()
+		freeQty := batchQty.Float64 - reservations.pinned[batch.ID]
+		if freeQty <= 0 {
+			continue
+		}
+
 		allocQty := remaining
-		if allocQty > batchQty.Float64 {
-			allocQty = batchQty.Float64
+		if allocQty > freeQty {
+			allocQty = freeQty
 		}
 
 		allocations = append(allocations, BatchAllocation{
@@ -89,13 +130,13 @@ func allocateBatchesAuto(ctx context.Context, queries *db.Queries, materialID, w
 	}
 
 	if remaining > 0 {
-		return nil, fmt.Errorf("insufficient stock: need %.2f more units", remaining)
+		return nil, fmt.Errorf("insufficient stock: need %.2f more units (batches on quality hold and reserved stock are excluded)", remaining)
 	}
 
 	return allocations, nil
 }
 
-func validateBatchAllocations(ctx context.Context, queries *db.Queries, batches []BatchAllocation, totalQuantity float64) error {
+func validateBatchAllocations(ctx context.Context, queries *db.Queries, batches []BatchAllocation, totalQuantity float64, reservations stockReservations) error {
 	if len(batches) == 0 {
 		return fmt.Errorf("no batches provided")
 	}
@@ -122,8 +163,20 @@ func validateBatchAllocations(ctx context.Context, queries *db.Queries, batches
 		batchMap[b.ID] = b
 	}
 
+	// Batches under an active quality hold cannot leave stock
+	holds, err := queries.GetBatchHoldsByIDs(ctx, batchIDs)
+	if err != nil {
+		return fmt.Errorf("failed to check quality holds: %w", err)
+	}
+	if len(holds) > 0 {
+		h := holds[0]
+		return fmt.Errorf("batch %d (%s) is on quality hold %s (%s): %s",
+			h.BatchID, h.BatchNumber, h.HoldNumber, h.QualityStatus, h.HoldReason)
+	}
+
 	// Validate each allocation
 	allocatedTotal := 0.0
+	today := time.Now().Format("2006-01-02")
 	for _, alloc := range batches {
 		batch, exists := batchMap[alloc.BatchID]
 		if !exists {
@@ -134,6 +187,18 @@ func validateBatchAllocations(ctx context.Context, queries *db.Queries, batches
 			return fmt.Errorf("batch %d: quantity must be positive", alloc.BatchID)
 		}
 
+		// FEFO never draws from expired batches, picking one by hand does not either
+		if batch.ExpiryDate.Valid && batch.ExpiryDate.Time.Format("2006-01-02") < today {
+			strategy, err := getAllocationStrategy(ctx, queries, batch.MaterialID.Int32, batch.WarehouseID.Int32)
+			if err != nil {
+				return err
+			}
+			if strategy == db.AllocationStrategyFEFO {
+				return fmt.Errorf("batch %d (%s) expired on %s and cannot be allocated under FEFO",
+					alloc.BatchID, batch.BatchNumber, batch.ExpiryDate.Time.Format("2006-01-02"))
+			}
+		}
+
 		// Convert pgtype.Numeric to float64
 		currentQty, _ := batch.CurrentQuantity.Float64Value()
 		if alloc.Quantity > currentQty.Float64 {
@@ -141,6 +206,12 @@ func validateBatchAllocations(ctx context.Context, queries *db.Queries, batches
 				alloc.BatchID, currentQty.Float64, alloc.Quantity)
 		}
 
+		// Quantity pinned to other sales order lines stays in the batch
+		if pinned := reservations.pinned[alloc.BatchID]; alloc.Quantity > currentQty.Float64-pinned+0.0001 {
+			return fmt.Errorf("batch %d: %.2f is reserved for other sales orders (available: %.2f, requested: %.2f)",
+				alloc.BatchID, pinned, max(currentQty.Float64-pinned, 0), alloc.Quantity)
+		}
+
 		allocatedTotal += alloc.Quantity
 	}
 
@@ -153,6 +224,107 @@ func validateBatchAllocations(ctx context.Context, queries *db.Queries, batches
 	return nil
 }
 
+// recordMovementBatch stores a batch line of a movement: a batch consumed by
+// an OUT movement or created by an IN movement
+func recordMovementBatch(ctx context.Context, queries *db.Queries, movementID, batchID int32, quantity, unitCost float64) error {
+	_, err := queries.CreateStockMovementBatch(ctx, db.CreateStockMovementBatchParams{
+		MovementID: movementID,
+		BatchID:    batchID,
+		Quantity:   decimalFromFloat(quantity),
+		UnitCost:   costFromFloat(unitCost),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record movement batch: %w", err)
+	}
+	return nil
+}
+
+// Link types of batch_genealogy: how a child batch came from its parents
+const (
+	batchLinkTransfer = "TRANSFER"
+	batchLinkReturn   = "RETURN"
+)
+
+// =====================================================
+// SALES ORDER FULFILMENT
+// =====================================================
+
+// findSalesOrderLine picks the order line a sale ships: the requested line, or
+// the first line of the material that still has an open quantity
+func findSalesOrderLine(items []db.SalesOrderItem, materialID int32, itemID *int32) (db.SalesOrderItem, error) {
+	if itemID != nil {
+		for _, item := range items {
+			if item.ID != *itemID {
+				continue
+			}
+			if !item.MaterialID.Valid || item.MaterialID.Int32 != materialID {
+				return db.SalesOrderItem{}, fmt.Errorf("sales order line %d is not for material %d", item.ID, materialID)
+			}
+			return item, nil
+		}
+		return db.SalesOrderItem{}, fmt.Errorf("sales order line %d not found on this order", *itemID)
+	}
+
+	onOrder := false
+	for _, item := range items {
+		if !item.MaterialID.Valid || item.MaterialID.Int32 != materialID {
+			continue
+		}
+		onOrder = true
+		if numericToFloat(item.Quantity)-numericToFloat(item.ShippedQuantity) > 0.0001 {
+			return item, nil
+		}
+	}
+
+	if onOrder {
+		return db.SalesOrderItem{}, fmt.Errorf("material %d is already fully shipped on this order", materialID)
+	}
+	return db.SalesOrderItem{}, fmt.Errorf("material %d is not on this sales order", materialID)
+}
+
+// updateSalesOrderFulfilment moves the order to PartiallyShipped or Shipped
+// based on the shipped quantities of its lines, and back to Approved when a
+// reversal leaves nothing shipped
+func updateSalesOrderFulfilment(ctx context.Context, queries *db.Queries, salesOrder db.SalesOrder) (string, error) {
+	items, err := queries.ListSalesOrderItems(ctx, pgtype.Int4{Int32: salesOrder.ID, Valid: true})
+	if err != nil {
+		return "", fmt.Errorf("failed to get sales order items: %w", err)
+	}
+
+	allShipped, anyShipped := true, false
+	for _, item := range items {
+		shipped := numericToFloat(item.ShippedQuantity)
+		if shipped > 0 {
+			anyShipped = true
+		}
+		if shipped < numericToFloat(item.Quantity)-0.0001 {
+			allShipped = false
+		}
+	}
+
+	status := salesOrder.Status
+	switch {
+	case anyShipped && allShipped:
+		status = "Shipped"
+	case anyShipped:
+		status = "PartiallyShipped"
+	case status == "PartiallyShipped" || status == "Shipped":
+		status = "Approved"
+	}
+
+	if status == salesOrder.Status {
+		return status, nil
+	}
+
+	if _, err := queries.UpdateSalesOrderStatus(ctx, db.UpdateSalesOrderStatusParams{
+		ID:     salesOrder.ID,
+		Status: status,
+	}); err != nil {
+		return "", fmt.Errorf("failed to update sales order status: %w", err)
+	}
+	return status, nil
+}
+
 // =====================================================
 // SALE
 // =====================================================
@@ -186,6 +358,15 @@ func (th *TransactionHandler) Sale(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Quantities may be entered in any unit that converts to the material's unit
+	conv, err := convertToBaseUnit(ctx, th.h.Queries, req.MaterialID, req.UnitID, req.Quantity)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	req.Quantity = conv.baseQuantity(req.Quantity)
+	req.Batches = conv.baseAllocations(req.Batches)
+
 	tx, err := th.h.DB.Begin(ctx)
 	if err != nil {
 		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to start transaction"})
@@ -195,39 +376,108 @@ func (th *TransactionHandler) Sale(w http.ResponseWriter, r *http.Request) {
 
 	queries := th.h.Queries.WithTx(tx)
 
-	// Get batch allocations
+	// Lock the order so concurrent shipments cannot over-ship a line
+	salesOrder, err := queries.GetSalesOrderByIDForUpdate(ctx, req.SalesOrderID)
+	if err != nil {
+		config.RespondJSON(w, http.StatusNotFound, map[string]string{"error": "Sales order not found"})
+		return
+	}
+
+	if salesOrder.Status == "Cancelled" {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Cannot ship a cancelled sales order"})
+		return
+	}
+
+	orderItems, err := queries.ListSalesOrderItems(ctx, pgtype.Int4{Int32: salesOrder.ID, Valid: true})
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get sales order items"})
+		return
+	}
+
+	orderLine, err := findSalesOrderLine(orderItems, req.MaterialID, req.SalesOrderItemID)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	shipped := numericToFloat(orderLine.ShippedQuantity)
+	remaining := numericToFloat(orderLine.Quantity) - shipped
+	if req.Quantity > remaining+0.0001 {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("Quantity exceeds the open quantity of order line %d (remaining: %.2f)", orderLine.ID, remaining)})
+		return
+	}
+
+	// The line may ship its own reservations but not those of other orders
+	reservations, err := checkStockReservations(ctx, queries, req.MaterialID, req.WarehouseID, req.Quantity, orderLine.ID)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	serialized, err := checkSerialNumbers(ctx, queries, req.MaterialID, req.Quantity, req.SerialNumbers)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	// Get batch allocations, the units of a serialized material are shipped
+	// from the batches holding them
 	var allocations []BatchAllocation
-	if req.UseManual {
-		if err := validateBatchAllocations(ctx, queries, req.Batches, req.Quantity); err != nil {
+	var units []db.SerialNumber
+	if serialized {
+		units, allocations, err = issueSerialNumbers(ctx, queries, req.MaterialID, req.WarehouseID, req.SerialNumbers)
+		if err != nil {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		if err := validateBatchAllocations(ctx, queries, allocations, req.Quantity, reservations); err != nil {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	} else if req.UseManual {
+		if err := validateBatchAllocations(ctx, queries, req.Batches, req.Quantity, reservations); err != nil {
 			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 			return
 		}
 		allocations = req.Batches
 	} else {
-		valuationMethod, err := getValuationMethod(ctx, queries, req.MaterialID, req.WarehouseID)
+		strategy, err := getAllocationStrategy(ctx, queries, req.MaterialID, req.WarehouseID)
 		if err != nil {
-			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get valuation method"})
+			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get allocation strategy"})
 			return
 		}
 
-		allocations, err = allocateBatchesAuto(ctx, queries, req.MaterialID, req.WarehouseID, req.Quantity, valuationMethod)
+		allocations, err = allocateBatchesAuto(ctx, queries, req.MaterialID, req.WarehouseID, 0, req.Quantity, strategy, reservations)
 		if err != nil {
 			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 			return
 		}
 	}
 
+	// Cost the issue before the batches are consumed
+	unitCost, batchCosts, err := issueUnitCost(ctx, queries, req.MaterialID, req.WarehouseID, allocations)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to calculate issue cost"})
+		return
+	}
+	costPerUnit, totalCost := movementCost(req.Quantity, unitCost)
+
 	// Create stock movement
 	reference := fmt.Sprintf("SO-%d", req.SalesOrderID)
 	movement, err := queries.CreateStockMovement(ctx, db.CreateStockMovementParams{
-		MaterialID:      pgtype.Int4{Int32: req.MaterialID, Valid: true},
-		FromWarehouseID: pgtype.Int4{Int32: req.WarehouseID, Valid: true},
-		Quantity:        decimalFromFloat(req.Quantity),
-		StockDirection:  db.StockDirectionOUT,
-		MovementType:    db.StockMovementTypeSALE,
-		Reference:       pgtype.Text{String: reference, Valid: true},
-		PerformedBy:     pgtype.Int4{Int32: userID, Valid: true},
-		MovementDate:    pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		MaterialID:       pgtype.Int4{Int32: req.MaterialID, Valid: true},
+		FromWarehouseID:  pgtype.Int4{Int32: req.WarehouseID, Valid: true},
+		Quantity:         decimalFromFloat(req.Quantity),
+		StockDirection:   db.StockDirectionOUT,
+		MovementType:     db.StockMovementTypeSALE,
+		Reference:        pgtype.Text{String: reference, Valid: true},
+		PerformedBy:      pgtype.Int4{Int32: userID, Valid: true},
+		MovementDate:     pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		UnitCost:         costPerUnit,
+		TotalCost:        totalCost,
+		SalesOrderItemID: pgtype.Int4{Int32: orderLine.ID, Valid: true},
+		EnteredQuantity:  conv.enteredQuantity(),
+		EnteredUnitID:    conv.enteredUnit(),
 	})
 	if err != nil {
 		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create stock movement"})
@@ -241,9 +491,57 @@ func (th *TransactionHandler) Sale(w http.ResponseWriter, r *http.Request) {
 			CurrentQuantity: decimalFromFloat(-alloc.Quantity),
 		})
 		if err != nil {
+			if isNegativeStock(err) {
+				config.RespondJSON(w, http.StatusConflict, map[string]string{"error": fmt.Sprintf("Batch %d does not hold %.2f anymore", alloc.BatchID, alloc.Quantity)})
+				return
+			}
 			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to update batch quantity"})
 			return
 		}
+		if err := recordMovementBatch(ctx, queries, movement.ID, alloc.BatchID, alloc.Quantity, batchCosts[alloc.BatchID]); err != nil {
+			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to record movement batch"})
+			return
+		}
+	}
+
+	for _, u := range units {
+		if err := moveSerialNumber(ctx, queries, movement.ID, u.ID, u.BatchID, pgtype.Int4{}, serialSold); err != nil {
+			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to record serial numbers"})
+			return
+		}
+	}
+
+	if err := postCostLedger(ctx, queries, req.MaterialID, req.WarehouseID, movement.ID, -req.Quantity, unitCost); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to update cost ledger"})
+		return
+	}
+
+	// Record the shipment on the order line and update the order status
+	if _, err := queries.UpdateSalesOrderItemShippedQuantity(ctx, db.UpdateSalesOrderItemShippedQuantityParams{
+		ID:              orderLine.ID,
+		ShippedQuantity: decimalFromFloat(shipped + req.Quantity),
+	}); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to update shipped quantity"})
+		return
+	}
+
+	if err := fulfilReservations(ctx, queries, orderLine.ID, req.WarehouseID, req.Quantity); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to fulfil reservations"})
+		return
+	}
+
+	// A fully shipped line no longer needs stock held for it
+	if shipped+req.Quantity >= numericToFloat(orderLine.Quantity)-0.0001 {
+		if _, err := queries.ReleaseSalesOrderItemReservations(ctx, orderLine.ID); err != nil {
+			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to release reservations"})
+			return
+		}
+	}
+
+	orderStatus, err := updateSalesOrderFulfilment(ctx, queries, salesOrder)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to update sales order status"})
+		return
 	}
 
 	if err := tx.Commit(ctx); err != nil {
@@ -258,7 +556,7 @@ func (th *TransactionHandler) Sale(w http.ResponseWriter, r *http.Request) {
 
 	config.RespondJSON(w, http.StatusCreated, TransactionResponse{
 		Success:    true,
-		Message:    "Sale recorded successfully",
+		Message:    fmt.Sprintf("Sale recorded successfully, sales order is %s", orderStatus),
 		MovementID: movement.ID,
 		BatchIDs:   batchIDs,
 	})
@@ -297,6 +595,14 @@ func (th *TransactionHandler) CustomerReturn(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	// Quantities may be entered in any unit that converts to the material's unit
+	conv, err := convertToBaseUnit(ctx, th.h.Queries, req.MaterialID, req.UnitID, req.Quantity)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	req.Quantity = conv.baseQuantity(req.Quantity)
+
 	tx, err := th.h.DB.Begin(ctx)
 	if err != nil {
 		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to start transaction"})
@@ -306,6 +612,47 @@ func (th *TransactionHandler) CustomerReturn(w http.ResponseWriter, r *http.Requ
 
 	queries := th.h.Queries.WithTx(tx)
 
+	// Lock the order before the stock so concurrent returns against it are
+	// checked one after the other
+	salesOrder, err := queries.GetSalesOrderByIDForUpdate(ctx, req.SalesOrderID)
+	if err != nil {
+		config.RespondJSON(w, http.StatusNotFound, map[string]string{"error": "Sales order not found"})
+		return
+	}
+
+	orderItems, err := queries.ListSalesOrderItems(ctx, pgtype.Int4{Int32: salesOrder.ID, Valid: true})
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get sales order items"})
+		return
+	}
+
+	// Only what was shipped and has not come back yet can be returned
+	var shipped float64
+	for _, item := range orderItems {
+		if item.MaterialID.Valid && item.MaterialID.Int32 == req.MaterialID {
+			shipped += numericToFloat(item.ShippedQuantity)
+		}
+	}
+
+	returnReference := fmt.Sprintf("RETURN-SO-%d-M%d", req.SalesOrderID, req.MaterialID)
+	earlierReturns, err := queries.GetStockMovementsByReference(ctx, pgtype.Text{String: returnReference, Valid: true})
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get earlier returns"})
+		return
+	}
+	var returned float64
+	for _, sm := range earlierReturns {
+		if sm.MovementType == db.StockMovementTypeCUSTOMERRETURN && !sm.ReversedByMovementID.Valid {
+			returned += numericToFloat(sm.Quantity)
+		}
+	}
+
+	returnable := shipped - returned
+	if req.Quantity > returnable+0.0001 {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("Quantity exceeds the returnable quantity of sales order %d (returnable: %.2f)", req.SalesOrderID, max(returnable, 0))})
+		return
+	}
+
 	// Get original sale movements and batches
 	saleReference := fmt.Sprintf("SO-%d", req.SalesOrderID)
 	saleMovements, err := queries.GetStockMovementsByReference(ctx, pgtype.Text{String: saleReference, Valid: true})
@@ -317,6 +664,7 @@ func (th *TransactionHandler) CustomerReturn(w http.ResponseWriter, r *http.Requ
 	// Find the sale movement for this material
 	var originalMovementID int32
 	var originalWarehouseID int32
+	var originalUnitCost pgtype.Numeric
 	found := false
 	for _, sm := range saleMovements {
 		if sm.MaterialID.Valid && sm.MaterialID.Int32 == req.MaterialID {
@@ -324,6 +672,7 @@ func (th *TransactionHandler) CustomerReturn(w http.ResponseWriter, r *http.Requ
 			if sm.FromWarehouseID.Valid {
 				originalWarehouseID = sm.FromWarehouseID.Int32
 			}
+			originalUnitCost = sm.UnitCost
 			found = true
 			break
 		}
@@ -334,23 +683,62 @@ func (th *TransactionHandler) CustomerReturn(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	serialized, err := checkSerialNumbers(ctx, queries, req.MaterialID, req.Quantity, req.SerialNumbers)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	// Serial numbers come back only from the sale that shipped them
+	var units []db.SerialNumber
+	if serialized {
+		var saleMovementIDs []int32
+		for _, sm := range saleMovements {
+			if sm.MaterialID.Valid && sm.MaterialID.Int32 == req.MaterialID {
+				saleMovementIDs = append(saleMovementIDs, sm.ID)
+			}
+		}
+		units, err = returnSerialNumbers(ctx, queries, req.MaterialID, saleMovementIDs, req.SerialNumbers)
+		if err != nil {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	// Returned goods come back at the cost they were issued at; sales recorded
+	// before costing was introduced fall back to the current running average
+	var unitCost float64
+	if originalUnitCost.Valid {
+		unitCost = numericToFloat(originalUnitCost)
+	} else {
+		unitCost, err = averageUnitCost(ctx, queries, req.MaterialID, originalWarehouseID)
+		if err != nil {
+			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get average cost"})
+			return
+		}
+	}
+	costPerUnit, totalCost := movementCost(req.Quantity, unitCost)
+
 	// Create return movement
 	var notes pgtype.Text
 	if req.Notes != nil {
 		notes = pgtype.Text{String: *req.Notes, Valid: true}
 	}
 
-	returnReference := fmt.Sprintf("RETURN-SO-%d-M%d", req.SalesOrderID, req.MaterialID)
 	movement, err := queries.CreateStockMovement(ctx, db.CreateStockMovementParams{
-		MaterialID:     pgtype.Int4{Int32: req.MaterialID, Valid: true},
-		ToWarehouseID:  pgtype.Int4{Int32: originalWarehouseID, Valid: true},
-		Quantity:       decimalFromFloat(req.Quantity),
-		StockDirection: db.StockDirectionIN,
-		MovementType:   db.StockMovementTypeCUSTOMERRETURN,
-		Reference:      pgtype.Text{String: returnReference, Valid: true},
-		PerformedBy:    pgtype.Int4{Int32: userID, Valid: true},
-		MovementDate:   pgtype.Timestamptz{Time: time.Now(), Valid: true},
-		Notes:          notes,
+		MaterialID:      pgtype.Int4{Int32: req.MaterialID, Valid: true},
+		ToWarehouseID:   pgtype.Int4{Int32: originalWarehouseID, Valid: true},
+		Quantity:        decimalFromFloat(req.Quantity),
+		StockDirection:  db.StockDirectionIN,
+		MovementType:    db.StockMovementTypeCUSTOMERRETURN,
+		Reference:       pgtype.Text{String: returnReference, Valid: true},
+		PerformedBy:     pgtype.Int4{Int32: userID, Valid: true},
+		MovementDate:    pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		Notes:           notes,
+		UnitCost:        costPerUnit,
+		TotalCost:       totalCost,
+		EnteredQuantity: conv.enteredQuantity(),
+		EnteredUnitID:   conv.enteredUnit(),
 	})
 	if err != nil {
 		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create return movement"})
@@ -371,7 +759,7 @@ func (th *TransactionHandler) CustomerReturn(w http.ResponseWriter, r *http.Requ
 		MaterialID:      pgtype.Int4{Int32: req.MaterialID, Valid: true},
 		WarehouseID:     pgtype.Int4{Int32: originalWarehouseID, Valid: true},
 		MovementID:      pgtype.Int4{Int32: movement.ID, Valid: true},
-		UnitPrice:       decimalFromFloat(0), // Return pricing could be different
+		UnitPrice:       decimalFromFloat(unitCost),
 		BatchNumber:     batchNumber,
 		StartQuantity:   decimalFromFloat(req.Quantity),
 		CurrentQuantity: decimalFromFloat(req.Quantity),
@@ -382,6 +770,47 @@ func (th *TransactionHandler) CustomerReturn(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
+	if err := recordMovementBatch(ctx, queries, movement.ID, batch.ID, req.Quantity, unitCost); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to record movement batch"})
+		return
+	}
+
+	for _, u := range units {
+		if err := moveSerialNumber(ctx, queries, movement.ID, u.ID, pgtype.Int4{Int32: batch.ID, Valid: true}, pgtype.Int4{Int32: originalWarehouseID, Valid: true}, serialInStock); err != nil {
+			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to record serial numbers"})
+			return
+		}
+	}
+
+	// The returned stock may come from any batch the sale drew from
+	if err := queries.LinkBatchToMovementBatches(ctx, db.LinkBatchToMovementBatchesParams{
+		ChildBatchID:     batch.ID,
+		LinkType:         batchLinkReturn,
+		MovementID:       pgtype.Int4{Int32: movement.ID, Valid: true},
+		SourceMovementID: originalMovementID,
+	}); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to record batch genealogy"})
+		return
+	}
+
+	if err := postCostLedger(ctx, queries, req.MaterialID, originalWarehouseID, movement.ID, req.Quantity, unitCost); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to update cost ledger"})
+		return
+	}
+
+	// Returned goods are quarantined until their inspection decides whether
+	// they are restocked, scrapped or reworked
+	inspection, err := quarantineBatch(ctx, queries, batch, db.CreateQualityInspectionParams{
+		InspectionType:  db.QualityInspectionTypeCustomerReturn,
+		SalesOrderID:    pgtype.Int4{Int32: req.SalesOrderID, Valid: true},
+		StockMovementID: pgtype.Int4{Int32: movement.ID, Valid: true},
+		Notes:           notes,
+	}, userID)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to open return inspection"})
+		return
+	}
+
 	if err := tx.Commit(ctx); err != nil {
 		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to commit transaction"})
 		return
@@ -389,7 +818,7 @@ func (th *TransactionHandler) CustomerReturn(w http.ResponseWriter, r *http.Requ
 
 	config.RespondJSON(w, http.StatusCreated, TransactionResponse{
 		Success:    true,
-		Message:    "Customer return recorded successfully",
+		Message:    fmt.Sprintf("Customer return recorded successfully, batch %s is quarantined for return inspection %s", batch.BatchNumber, inspection.InspectionNumber),
 		MovementID: movement.ID,
 		BatchIDs:   []int32{batch.ID},
 	})
@@ -399,71 +828,94 @@ func (th *TransactionHandler) CustomerReturn(w http.ResponseWriter, r *http.Requ
 // TRANSFER
 // =====================================================
 
-func (th *TransactionHandler) Transfer(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
-
-	// Get user session from context
-	session, ok := middlewares.GetSessionFromContext(r)
-	if !ok {
-		config.RespondJSON(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized - Authentication required"})
-		return
+// postTransfer moves stock between warehouses or bins as a TRANSFER_OUT and
+// TRANSFER_IN movement, in the transaction of queries. Stock moved to another
+// warehouse gets a new batch number, a bin move keeps the batch number.
+func (th *TransactionHandler) postTransfer(ctx context.Context, queries *db.Queries, req TransferRequest, userID int32) (TransactionResponse, int, error) {
+	if req.Quantity <= 0 {
+		return TransactionResponse{}, http.StatusBadRequest, errors.New("Quantity must be positive")
 	}
 
-	// Parse user ID from session
-	var userID int32
-	_, err := fmt.Sscanf(session.UserID, "%d", &userID)
+	// Quantities may be entered in any unit that converts to the material's unit
+	conv, err := convertToBaseUnit(ctx, queries, req.MaterialID, req.UnitID, req.Quantity)
 	if err != nil {
-		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
-		return
+		return TransactionResponse{}, http.StatusBadRequest, err
 	}
+	req.Quantity = conv.baseQuantity(req.Quantity)
+	req.Batches = conv.baseAllocations(req.Batches)
 
-	var req TransferRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
-		return
+	// Inside one warehouse stock moves between bins
+	if req.FromWarehouseID == req.ToWarehouseID && int32Value(req.FromBinID) == int32Value(req.ToBinID) {
+		return TransactionResponse{}, http.StatusBadRequest, errors.New("Source and destination must be different warehouses or bins")
 	}
 
-	if req.Quantity <= 0 {
-		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Quantity must be positive"})
-		return
+	if req.FromBinID != nil && *req.FromBinID != 0 {
+		if _, err := checkBin(ctx, queries, *req.FromBinID, req.FromWarehouseID); err != nil {
+			return TransactionResponse{}, http.StatusBadRequest, err
+		}
 	}
 
-	if req.FromWarehouseID == req.ToWarehouseID {
-		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Source and destination warehouses must be different"})
-		return
+	if err := checkBinPutaway(ctx, queries, req.ToBinID, req.ToWarehouseID, req.Quantity); err != nil {
+		return TransactionResponse{}, http.StatusBadRequest, err
 	}
 
-	tx, err := th.h.DB.Begin(ctx)
+	// Stock reserved for sales orders stays where it is
+	reservations, err := checkStockReservations(ctx, queries, req.MaterialID, req.FromWarehouseID, req.Quantity, 0)
 	if err != nil {
-		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to start transaction"})
-		return
+		return TransactionResponse{}, http.StatusBadRequest, err
 	}
-	defer tx.Rollback(ctx)
 
-	queries := th.h.Queries.WithTx(tx)
+	serialized, err := checkSerialNumbers(ctx, queries, req.MaterialID, req.Quantity, req.SerialNumbers)
+	if err != nil {
+		return TransactionResponse{}, http.StatusBadRequest, err
+	}
 
-	// Get batch allocations for transfer out
+	// Get batch allocations for transfer out, the units of a serialized
+	// material leave the batches holding them
 	var allocations []BatchAllocation
-	if req.UseManual {
-		if err := validateBatchAllocations(ctx, queries, req.Batches, req.Quantity); err != nil {
-			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
-			return
+	var units []db.SerialNumber
+	if serialized {
+		units, allocations, err = issueSerialNumbers(ctx, queries, req.MaterialID, req.FromWarehouseID, req.SerialNumbers)
+		if err != nil {
+			return TransactionResponse{}, http.StatusBadRequest, err
+		}
+		if err := validateBatchAllocations(ctx, queries, allocations, req.Quantity, reservations); err != nil {
+			return TransactionResponse{}, http.StatusBadRequest, err
+		}
+		if req.FromBinID != nil && *req.FromBinID != 0 {
+			if err := checkBatchesInBin(ctx, queries, allocations, *req.FromBinID); err != nil {
+				return TransactionResponse{}, http.StatusBadRequest, err
+			}
+		}
+	} else if req.UseManual {
+		if err := validateBatchAllocations(ctx, queries, req.Batches, req.Quantity, reservations); err != nil {
+			return TransactionResponse{}, http.StatusBadRequest, err
+		}
+		if req.FromBinID != nil && *req.FromBinID != 0 {
+			if err := checkBatchesInBin(ctx, queries, req.Batches, *req.FromBinID); err != nil {
+				return TransactionResponse{}, http.StatusBadRequest, err
+			}
 		}
 		allocations = req.Batches
 	} else {
-		valuationMethod, err := getValuationMethod(ctx, queries, req.MaterialID, req.FromWarehouseID)
+		strategy, err := getAllocationStrategy(ctx, queries, req.MaterialID, req.FromWarehouseID)
 		if err != nil {
-			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get valuation method"})
-			return
+			return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to get allocation strategy")
 		}
 
-		allocations, err = allocateBatchesAuto(ctx, queries, req.MaterialID, req.FromWarehouseID, req.Quantity, valuationMethod)
+		allocations, err = allocateBatchesAuto(ctx, queries, req.MaterialID, req.FromWarehouseID, int32Value(req.FromBinID), req.Quantity, strategy, reservations)
 		if err != nil {
-			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
-			return
+			return TransactionResponse{}, http.StatusBadRequest, err
 		}
 	}
 
+	// Cost the issue before the batches are consumed
+	unitCost, batchCosts, err := issueUnitCost(ctx, queries, req.MaterialID, req.FromWarehouseID, allocations)
+	if err != nil {
+		return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to calculate issue cost")
+	}
+	costPerUnit, totalCost := movementCost(req.Quantity, unitCost)
+
 	// Create transfer out movement
 	var notes pgtype.Text
 	if req.Notes != nil {
@@ -483,10 +935,15 @@ func (th *TransactionHandler) Transfer(w http.ResponseWriter, r *http.Request) {
 		PerformedBy:     pgtype.Int4{Int32: userID, Valid: true},
 		MovementDate:    pgtype.Timestamptz{Time: time.Now(), Valid: true},
 		Notes:           notes,
+		UnitCost:        costPerUnit,
+		TotalCost:       totalCost,
+		FromBinID:       binParam(req.FromBinID),
+		ToBinID:         binParam(req.ToBinID),
+		EnteredQuantity: conv.enteredQuantity(),
+		EnteredUnitID:   conv.enteredUnit(),
 	})
 	if err != nil {
-		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create transfer out movement"})
-		return
+		return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to create transfer out movement")
 	}
 
 	// Update source batches
@@ -496,27 +953,61 @@ func (th *TransactionHandler) Transfer(w http.ResponseWriter, r *http.Request) {
 			CurrentQuantity: decimalFromFloat(-alloc.Quantity),
 		})
 		if err != nil {
-			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to update source batch"})
-			return
+			if isNegativeStock(err) {
+				return TransactionResponse{}, http.StatusConflict, fmt.Errorf("Batch %d does not hold %.2f anymore", alloc.BatchID, alloc.Quantity)
+			}
+			return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to update source batch")
+		}
+		if err := recordMovementBatch(ctx, queries, movementOut.ID, alloc.BatchID, alloc.Quantity, batchCosts[alloc.BatchID]); err != nil {
+			return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to record movement batch")
 		}
 	}
 
+	for _, u := range units {
+		if err := moveSerialNumber(ctx, queries, movementOut.ID, u.ID, u.BatchID, pgtype.Int4{}, serialInTransit); err != nil {
+			return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to record serial numbers")
+		}
+	}
+
+	if err := postCostLedger(ctx, queries, req.MaterialID, req.FromWarehouseID, movementOut.ID, -req.Quantity, unitCost); err != nil {
+		return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to update cost ledger")
+	}
+
 	// Create transfer in movement
 	movementIn, err := queries.CreateStockMovement(ctx, db.CreateStockMovementParams{
-		MaterialID:      pgtype.Int4{Int32: req.MaterialID, Valid: true},
-		FromWarehouseID: pgtype.Int4{Int32: req.FromWarehouseID, Valid: true},
-		ToWarehouseID:   pgtype.Int4{Int32: req.ToWarehouseID, Valid: true},
-		Quantity:        decimalFromFloat(req.Quantity),
-		StockDirection:  db.StockDirectionIN,
-		MovementType:    db.StockMovementTypeTRANSFERIN,
-		Reference:       pgtype.Text{String: transferRef, Valid: true},
-		PerformedBy:     pgtype.Int4{Int32: userID, Valid: true},
-		MovementDate:    pgtype.Timestamptz{Time: time.Now(), Valid: true},
-		Notes:           notes,
+		MaterialID:       pgtype.Int4{Int32: req.MaterialID, Valid: true},
+		FromWarehouseID:  pgtype.Int4{Int32: req.FromWarehouseID, Valid: true},
+		ToWarehouseID:    pgtype.Int4{Int32: req.ToWarehouseID, Valid: true},
+		Quantity:         decimalFromFloat(req.Quantity),
+		StockDirection:   db.StockDirectionIN,
+		MovementType:     db.StockMovementTypeTRANSFERIN,
+		Reference:        pgtype.Text{String: transferRef, Valid: true},
+		PerformedBy:      pgtype.Int4{Int32: userID, Valid: true},
+		MovementDate:     pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		Notes:            notes,
+		UnitCost:         costPerUnit,
+		TotalCost:        totalCost,
+		FromBinID:        binParam(req.FromBinID),
+		ToBinID:          binParam(req.ToBinID),
+		EnteredQuantity:  conv.enteredQuantity(),
+		EnteredUnitID:    conv.enteredUnit(),
+		PairedMovementID: pgtype.Int4{Int32: movementOut.ID, Valid: true},
 	})
 	if err != nil {
-		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create transfer in movement"})
-		return
+		return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to create transfer in movement")
+	}
+
+	// The legs point at each other, a reversal finds the other leg by it
+	if err := queries.PairStockMovement(ctx, db.PairStockMovementParams{
+		ID:               movementOut.ID,
+		PairedMovementID: pgtype.Int4{Int32: movementIn.ID, Valid: true},
+	}); err != nil {
+		return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to pair transfer movements")
+	}
+
+	// The destination receives the stock at the cost it left the source with
+	if err := postCostLedger(ctx, queries, req.MaterialID, req.ToWarehouseID, movementIn.ID, req.Quantity, unitCost); err != nil {
+		return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to update cost ledger")
 	}
 
 	// Create new batches in destination warehouse
@@ -524,14 +1015,20 @@ func (th *TransactionHandler) Transfer(w http.ResponseWriter, r *http.Request) {
 	for _, alloc := range allocations {
 		sourceBatch, err := queries.GetBatchByID(ctx, alloc.BatchID)
 		if err != nil {
-			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get source batch"})
-			return
+			return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to get source batch")
 		}
 
-		batchNumber, err := generateBatchNumber(ctx, queries, req.MaterialID, "transfer")
-		if err != nil {
-			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to generate batch number"})
-			return
+		// A bin move inside one warehouse splits the batch under its own
+		// number, so holds, recalls and certificates by batch number still
+		// find the stock
+		batchNumber := sourceBatch.BatchNumber
+		batchNotes := fmt.Sprintf("Moved from batch %d (movement %d)", sourceBatch.ID, movementOut.ID)
+		if req.FromWarehouseID != req.ToWarehouseID {
+			batchNumber, err = generateBatchNumber(ctx, queries, req.MaterialID, "transfer")
+			if err != nil {
+				return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to generate batch number")
+			}
+			batchNotes = fmt.Sprintf("Transferred from batch %s (movement %d)", sourceBatch.BatchNumber, movementOut.ID)
 		}
 
 		newBatch, err := queries.CreateBatch(ctx, db.CreateBatchParams{
@@ -545,34 +1042,50 @@ func (th *TransactionHandler) Transfer(w http.ResponseWriter, r *http.Request) {
 			ExpiryDate:      sourceBatch.ExpiryDate,
 			StartQuantity:   decimalFromFloat(alloc.Quantity),
 			CurrentQuantity: decimalFromFloat(alloc.Quantity),
-			Notes:           pgtype.Text{String: fmt.Sprintf("Transferred from batch %s (movement %d)", sourceBatch.BatchNumber, movementOut.ID), Valid: true},
+			Notes:           pgtype.Text{String: batchNotes, Valid: true},
+			BinID:           binParam(req.ToBinID),
 		})
 		if err != nil {
-			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create destination batch"})
-			return
+			return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to create destination batch")
 		}
 
-		newBatchIDs = append(newBatchIDs, newBatch.ID)
-	}
+		if err := recordMovementBatch(ctx, queries, movementIn.ID, newBatch.ID, alloc.Quantity, batchCosts[alloc.BatchID]); err != nil {
+			return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to record movement batch")
+		}
 
-	if err := tx.Commit(ctx); err != nil {
-		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to commit transaction"})
-		return
+		// The destination batch descends from the source batch
+		if err := queries.CreateBatchGenealogyLink(ctx, db.CreateBatchGenealogyLinkParams{
+			ParentBatchID: alloc.BatchID,
+			ChildBatchID:  newBatch.ID,
+			LinkType:      batchLinkTransfer,
+			MovementID:    pgtype.Int4{Int32: movementIn.ID, Valid: true},
+			Quantity:      decimalFromFloat(alloc.Quantity),
+		}); err != nil {
+			return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to record batch genealogy")
+		}
+
+		// The units of the source batch are now held by the destination batch
+		for _, u := range units {
+			if u.BatchID.Int32 != alloc.BatchID {
+				continue
+			}
+			if err := moveSerialNumber(ctx, queries, movementIn.ID, u.ID, pgtype.Int4{Int32: newBatch.ID, Valid: true}, pgtype.Int4{Int32: req.ToWarehouseID, Valid: true}, serialInStock); err != nil {
+				return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to record serial numbers")
+			}
+		}
+
+		newBatchIDs = append(newBatchIDs, newBatch.ID)
 	}
 
-	config.RespondJSON(w, http.StatusCreated, TransactionResponse{
+	return TransactionResponse{
 		Success:    true,
 		Message:    "Transfer completed successfully",
 		MovementID: movementOut.ID,
 		BatchIDs:   newBatchIDs,
-	})
+	}, http.StatusCreated, nil
 }
 
-// =====================================================
-// SCRAP
-// =====================================================
-
-func (th *TransactionHandler) Scrap(w http.ResponseWriter, r *http.Request) {
+func (th *TransactionHandler) Transfer(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	// Get user session from context
@@ -590,53 +1103,104 @@ func (th *TransactionHandler) Scrap(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var req ScrapRequest
+	var req TransferRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
 		return
 	}
 
-	if req.Quantity <= 0 {
-		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Quantity must be positive"})
+	tx, err := th.h.DB.Begin(ctx)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to start transaction"})
 		return
 	}
+	defer tx.Rollback(ctx)
 
-	if req.Reason == "" {
-		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Reason is required for scrap"})
+	queries := th.h.Queries.WithTx(tx)
+
+	response, status, err := th.postTransfer(ctx, queries, req, userID)
+	if err != nil {
+		config.RespondJSON(w, status, map[string]string{"error": err.Error()})
 		return
 	}
 
-	tx, err := th.h.DB.Begin(ctx)
-	if err != nil {
-		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to start transaction"})
+	if err := tx.Commit(ctx); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to commit transaction"})
 		return
 	}
-	defer tx.Rollback(ctx)
 
-	queries := th.h.Queries.WithTx(tx)
+	config.RespondJSON(w, status, response)
+}
+
+// =====================================================
+// SCRAP
+// =====================================================
+
+// postScrap writes stock off as a SCRAP movement
+func (th *TransactionHandler) postScrap(ctx context.Context, queries *db.Queries, req ScrapRequest, userID int32) (TransactionResponse, int, error) {
+	if req.Quantity <= 0 {
+		return TransactionResponse{}, http.StatusBadRequest, errors.New("Quantity must be positive")
+	}
+
+	// Quantities may be entered in any unit that converts to the material's unit
+	conv, err := convertToBaseUnit(ctx, queries, req.MaterialID, req.UnitID, req.Quantity)
+	if err != nil {
+		return TransactionResponse{}, http.StatusBadRequest, err
+	}
+	req.Quantity = conv.baseQuantity(req.Quantity)
+	req.Batches = conv.baseAllocations(req.Batches)
+
+	if req.Reason == "" {
+		return TransactionResponse{}, http.StatusBadRequest, errors.New("Reason is required for scrap")
+	}
+
+	// Lock the stock so a concurrent stock-out cannot consume the same batches
+	if err := lockStock(ctx, queries, req.MaterialID, req.WarehouseID); err != nil {
+		return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to lock stock")
+	}
+
+	serialized, err := checkSerialNumbers(ctx, queries, req.MaterialID, req.Quantity, req.SerialNumbers)
+	if err != nil {
+		return TransactionResponse{}, http.StatusBadRequest, err
+	}
 
-	// Get batch allocations
+	// Get batch allocations, scrapped stock is gone whether reserved or not.
+	// The units of a serialized material are scrapped from the batches
+	// holding them.
 	var allocations []BatchAllocation
-	if req.UseManual {
-		if err := validateBatchAllocations(ctx, queries, req.Batches, req.Quantity); err != nil {
-			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
-			return
+	var units []db.SerialNumber
+	if serialized {
+		units, allocations, err = issueSerialNumbers(ctx, queries, req.MaterialID, req.WarehouseID, req.SerialNumbers)
+		if err != nil {
+			return TransactionResponse{}, http.StatusBadRequest, err
+		}
+		if err := validateBatchAllocations(ctx, queries, allocations, req.Quantity, stockReservations{}); err != nil {
+			return TransactionResponse{}, http.StatusBadRequest, err
+		}
+	} else if req.UseManual {
+		if err := validateBatchAllocations(ctx, queries, req.Batches, req.Quantity, stockReservations{}); err != nil {
+			return TransactionResponse{}, http.StatusBadRequest, err
 		}
 		allocations = req.Batches
 	} else {
-		valuationMethod, err := getValuationMethod(ctx, queries, req.MaterialID, req.WarehouseID)
+		strategy, err := getAllocationStrategy(ctx, queries, req.MaterialID, req.WarehouseID)
 		if err != nil {
-			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get valuation method"})
-			return
+			return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to get allocation strategy")
 		}
 
-		allocations, err = allocateBatchesAuto(ctx, queries, req.MaterialID, req.WarehouseID, req.Quantity, valuationMethod)
+		allocations, err = allocateBatchesAuto(ctx, queries, req.MaterialID, req.WarehouseID, 0, req.Quantity, strategy, stockReservations{})
 		if err != nil {
-			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
-			return
+			return TransactionResponse{}, http.StatusBadRequest, err
 		}
 	}
 
+	// Cost the issue before the batches are consumed
+	unitCost, batchCosts, err := issueUnitCost(ctx, queries, req.MaterialID, req.WarehouseID, allocations)
+	if err != nil {
+		return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to calculate issue cost")
+	}
+	costPerUnit, totalCost := movementCost(req.Quantity, unitCost)
+
 	// Create scrap movement
 	movement, err := queries.CreateStockMovement(ctx, db.CreateStockMovementParams{
 		MaterialID:      pgtype.Int4{Int32: req.MaterialID, Valid: true},
@@ -648,10 +1212,13 @@ func (th *TransactionHandler) Scrap(w http.ResponseWriter, r *http.Request) {
 		PerformedBy:     pgtype.Int4{Int32: userID, Valid: true},
 		MovementDate:    pgtype.Timestamptz{Time: time.Now(), Valid: true},
 		Notes:           pgtype.Text{String: req.Reason, Valid: true},
+		UnitCost:        costPerUnit,
+		TotalCost:       totalCost,
+		EnteredQuantity: conv.enteredQuantity(),
+		EnteredUnitID:   conv.enteredUnit(),
 	})
 	if err != nil {
-		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create scrap movement"})
-		return
+		return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to create scrap movement")
 	}
 
 	// Update batches
@@ -662,30 +1229,36 @@ func (th *TransactionHandler) Scrap(w http.ResponseWriter, r *http.Request) {
 			CurrentQuantity: decimalFromFloat(-alloc.Quantity),
 		})
 		if err != nil {
-			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to update batch quantity"})
-			return
+			if isNegativeStock(err) {
+				return TransactionResponse{}, http.StatusConflict, fmt.Errorf("Batch %d does not hold %.2f anymore", alloc.BatchID, alloc.Quantity)
+			}
+			return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to update batch quantity")
+		}
+		if err := recordMovementBatch(ctx, queries, movement.ID, alloc.BatchID, alloc.Quantity, batchCosts[alloc.BatchID]); err != nil {
+			return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to record movement batch")
 		}
 		batchIDs = append(batchIDs, alloc.BatchID)
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to commit transaction"})
-		return
+	for _, u := range units {
+		if err := moveSerialNumber(ctx, queries, movement.ID, u.ID, u.BatchID, pgtype.Int4{}, serialScrapped); err != nil {
+			return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to record serial numbers")
+		}
 	}
 
-	config.RespondJSON(w, http.StatusCreated, TransactionResponse{
+	if err := postCostLedger(ctx, queries, req.MaterialID, req.WarehouseID, movement.ID, -req.Quantity, unitCost); err != nil {
+		return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to update cost ledger")
+	}
+
+	return TransactionResponse{
 		Success:    true,
 		Message:    "Scrap recorded successfully",
 		MovementID: movement.ID,
 		BatchIDs:   batchIDs,
-	})
+	}, http.StatusCreated, nil
 }
 
-// =====================================================
-// ADJUSTMENT
-// =====================================================
-
-func (th *TransactionHandler) Adjustment(w http.ResponseWriter, r *http.Request) {
+func (th *TransactionHandler) Scrap(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	// Get user session from context
@@ -703,35 +1276,71 @@ func (th *TransactionHandler) Adjustment(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	var req AdjustmentRequest
+	var req ScrapRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
 		return
 	}
 
-	if req.Quantity <= 0 {
-		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Quantity must be positive"})
+	tx, err := th.h.DB.Begin(ctx)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to start transaction"})
 		return
 	}
+	defer tx.Rollback(ctx)
 
-	if req.Reason == "" {
-		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Reason is required for adjustment"})
+	queries := th.h.Queries.WithTx(tx)
+
+	response, status, err := th.postScrap(ctx, queries, req, userID)
+	if err != nil {
+		config.RespondJSON(w, status, map[string]string{"error": err.Error()})
 		return
 	}
 
-	if req.Direction != "IN" && req.Direction != "OUT" {
-		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Direction must be 'IN' or 'OUT'"})
+	if err := tx.Commit(ctx); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to commit transaction"})
 		return
 	}
 
-	tx, err := th.h.DB.Begin(ctx)
+	config.RespondJSON(w, status, response)
+}
+
+// =====================================================
+// ADJUSTMENT
+// =====================================================
+
+// postAdjustment corrects stock up or down as an ADJUSTMENT_IN or
+// ADJUSTMENT_OUT movement
+func (th *TransactionHandler) postAdjustment(ctx context.Context, queries *db.Queries, req AdjustmentRequest, userID int32) (TransactionResponse, int, error) {
+	if req.Quantity <= 0 {
+		return TransactionResponse{}, http.StatusBadRequest, errors.New("Quantity must be positive")
+	}
+
+	// Quantities may be entered in any unit that converts to the material's unit
+	conv, err := convertToBaseUnit(ctx, queries, req.MaterialID, req.UnitID, req.Quantity)
 	if err != nil {
-		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to start transaction"})
-		return
+		return TransactionResponse{}, http.StatusBadRequest, err
+	}
+	req.Quantity = conv.baseQuantity(req.Quantity)
+	req.Batches = conv.baseAllocations(req.Batches)
+	if req.UnitPrice != nil {
+		unitPrice := conv.basePrice(*req.UnitPrice)
+		req.UnitPrice = &unitPrice
 	}
-	defer tx.Rollback(ctx)
 
-	queries := th.h.Queries.WithTx(tx)
+	if req.Reason == "" {
+		return TransactionResponse{}, http.StatusBadRequest, errors.New("Reason is required for adjustment")
+	}
+
+	if req.Direction != "IN" && req.Direction != "OUT" {
+		return TransactionResponse{}, http.StatusBadRequest, errors.New("Direction must be 'IN' or 'OUT'")
+	}
+
+	// Units of a serialized material are found or lost by serial number
+	serialized, err := checkSerialNumbers(ctx, queries, req.MaterialID, req.Quantity, req.SerialNumbers)
+	if err != nil {
+		return TransactionResponse{}, http.StatusBadRequest, err
+	}
 
 	var movement db.StockMovement
 	var batchIDs []int32
@@ -740,32 +1349,41 @@ func (th *TransactionHandler) Adjustment(w http.ResponseWriter, r *http.Request)
 		// Adjustment IN - add stock
 		movementType := db.StockMovementTypeADJUSTMENTIN
 
+		// Found stock is valued at the given price, otherwise at the running average
+		var unitPrice float64
+		if req.UnitPrice != nil {
+			unitPrice = *req.UnitPrice
+		} else {
+			unitPrice, err = averageUnitCost(ctx, queries, req.MaterialID, req.WarehouseID)
+			if err != nil {
+				return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to get average cost")
+			}
+		}
+		costPerUnit, totalCost := movementCost(req.Quantity, unitPrice)
+
 		movement, err = queries.CreateStockMovement(ctx, db.CreateStockMovementParams{
-			MaterialID:     pgtype.Int4{Int32: req.MaterialID, Valid: true},
-			ToWarehouseID:  pgtype.Int4{Int32: req.WarehouseID, Valid: true},
-			Quantity:       decimalFromFloat(req.Quantity),
-			StockDirection: db.StockDirectionIN,
-			MovementType:   movementType,
-			Reference:      pgtype.Text{String: fmt.Sprintf("ADJ-IN-M%d-%d", req.MaterialID, time.Now().Unix()), Valid: true},
-			PerformedBy:    pgtype.Int4{Int32: userID, Valid: true},
-			MovementDate:   pgtype.Timestamptz{Time: time.Now(), Valid: true},
-			Notes:          pgtype.Text{String: req.Reason, Valid: true},
+			MaterialID:      pgtype.Int4{Int32: req.MaterialID, Valid: true},
+			ToWarehouseID:   pgtype.Int4{Int32: req.WarehouseID, Valid: true},
+			Quantity:        decimalFromFloat(req.Quantity),
+			StockDirection:  db.StockDirectionIN,
+			MovementType:    movementType,
+			Reference:       pgtype.Text{String: fmt.Sprintf("ADJ-IN-M%d-%d", req.MaterialID, time.Now().Unix()), Valid: true},
+			PerformedBy:     pgtype.Int4{Int32: userID, Valid: true},
+			MovementDate:    pgtype.Timestamptz{Time: time.Now(), Valid: true},
+			Notes:           pgtype.Text{String: req.Reason, Valid: true},
+			UnitCost:        costPerUnit,
+			TotalCost:       totalCost,
+			EnteredQuantity: conv.enteredQuantity(),
+			EnteredUnitID:   conv.enteredUnit(),
 		})
 		if err != nil {
-			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create adjustment movement"})
-			return
+			return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to create adjustment movement")
 		}
 
 		// Create new batch
 		batchNumber, err := generateBatchNumber(ctx, queries, req.MaterialID, "adjustment")
 		if err != nil {
-			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to generate batch number"})
-			return
-		}
-
-		unitPrice := 0.0
-		if req.UnitPrice != nil {
-			unitPrice = *req.UnitPrice
+			return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to generate batch number")
 		}
 
 		batch, err := queries.CreateBatch(ctx, db.CreateBatchParams{
@@ -779,36 +1397,67 @@ func (th *TransactionHandler) Adjustment(w http.ResponseWriter, r *http.Request)
 			Notes:           pgtype.Text{String: req.Reason, Valid: true},
 		})
 		if err != nil {
-			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create adjustment batch"})
-			return
+			return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to create adjustment batch")
+		}
+
+		if err := recordMovementBatch(ctx, queries, movement.ID, batch.ID, req.Quantity, unitPrice); err != nil {
+			return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to record movement batch")
+		}
+
+		if serialized {
+			if err := receiveSerialNumbers(ctx, queries, req.MaterialID, req.WarehouseID, batch.ID, movement.ID, req.SerialNumbers); err != nil {
+				return TransactionResponse{}, http.StatusConflict, err
+			}
+		}
+
+		if err := postCostLedger(ctx, queries, req.MaterialID, req.WarehouseID, movement.ID, req.Quantity, unitPrice); err != nil {
+			return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to update cost ledger")
 		}
 
 		batchIDs = []int32{batch.ID}
 
 	} else {
 		// Adjustment OUT - remove stock
-		// Get batch allocations
+		if err := lockStock(ctx, queries, req.MaterialID, req.WarehouseID); err != nil {
+			return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to lock stock")
+		}
+
+		// Get batch allocations, a count correction is not blocked by reservations.
+		// Missing units of a serialized material come off the batches holding them.
 		var allocations []BatchAllocation
-		if req.UseManual {
-			if err := validateBatchAllocations(ctx, queries, req.Batches, req.Quantity); err != nil {
-				config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
-				return
+		var units []db.SerialNumber
+		if serialized {
+			units, allocations, err = issueSerialNumbers(ctx, queries, req.MaterialID, req.WarehouseID, req.SerialNumbers)
+			if err != nil {
+				return TransactionResponse{}, http.StatusBadRequest, err
+			}
+			if err := validateBatchAllocations(ctx, queries, allocations, req.Quantity, stockReservations{}); err != nil {
+				return TransactionResponse{}, http.StatusBadRequest, err
+			}
+		} else if req.UseManual {
+			if err := validateBatchAllocations(ctx, queries, req.Batches, req.Quantity, stockReservations{}); err != nil {
+				return TransactionResponse{}, http.StatusBadRequest, err
 			}
 			allocations = req.Batches
 		} else {
-			valuationMethod, err := getValuationMethod(ctx, queries, req.MaterialID, req.WarehouseID)
+			strategy, err := getAllocationStrategy(ctx, queries, req.MaterialID, req.WarehouseID)
 			if err != nil {
-				config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get valuation method"})
-				return
+				return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to get allocation strategy")
 			}
 
-			allocations, err = allocateBatchesAuto(ctx, queries, req.MaterialID, req.WarehouseID, req.Quantity, valuationMethod)
+			allocations, err = allocateBatchesAuto(ctx, queries, req.MaterialID, req.WarehouseID, 0, req.Quantity, strategy, stockReservations{})
 			if err != nil {
-				config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
-				return
+				return TransactionResponse{}, http.StatusBadRequest, err
 			}
 		}
 
+		// Cost the issue before the batches are consumed
+		unitCost, batchCosts, err := issueUnitCost(ctx, queries, req.MaterialID, req.WarehouseID, allocations)
+		if err != nil {
+			return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to calculate issue cost")
+		}
+		costPerUnit, totalCost := movementCost(req.Quantity, unitCost)
+
 		movementType := db.StockMovementTypeADJUSTMENTOUT
 
 		movement, err = queries.CreateStockMovement(ctx, db.CreateStockMovementParams{
@@ -821,10 +1470,13 @@ func (th *TransactionHandler) Adjustment(w http.ResponseWriter, r *http.Request)
 			PerformedBy:     pgtype.Int4{Int32: userID, Valid: true},
 			MovementDate:    pgtype.Timestamptz{Time: time.Now(), Valid: true},
 			Notes:           pgtype.Text{String: req.Reason, Valid: true},
+			UnitCost:        costPerUnit,
+			TotalCost:       totalCost,
+			EnteredQuantity: conv.enteredQuantity(),
+			EnteredUnitID:   conv.enteredUnit(),
 		})
 		if err != nil {
-			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create adjustment movement"})
-			return
+			return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to create adjustment movement")
 		}
 
 		// Update batches
@@ -834,22 +1486,79 @@ func (th *TransactionHandler) Adjustment(w http.ResponseWriter, r *http.Request)
 				CurrentQuantity: decimalFromFloat(-alloc.Quantity),
 			})
 			if err != nil {
-				config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to update batch quantity"})
-				return
+				if isNegativeStock(err) {
+					return TransactionResponse{}, http.StatusConflict, fmt.Errorf("Batch %d does not hold %.2f anymore", alloc.BatchID, alloc.Quantity)
+				}
+				return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to update batch quantity")
+			}
+			if err := recordMovementBatch(ctx, queries, movement.ID, alloc.BatchID, alloc.Quantity, batchCosts[alloc.BatchID]); err != nil {
+				return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to record movement batch")
 			}
 			batchIDs = append(batchIDs, alloc.BatchID)
 		}
-	}
 
-	if err := tx.Commit(ctx); err != nil {
-		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to commit transaction"})
-		return
+		for _, u := range units {
+			if err := moveSerialNumber(ctx, queries, movement.ID, u.ID, u.BatchID, pgtype.Int4{}, serialLost); err != nil {
+				return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to record serial numbers")
+			}
+		}
+
+		if err := postCostLedger(ctx, queries, req.MaterialID, req.WarehouseID, movement.ID, -req.Quantity, unitCost); err != nil {
+			return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to update cost ledger")
+		}
 	}
 
-	config.RespondJSON(w, http.StatusCreated, TransactionResponse{
+	return TransactionResponse{
 		Success:    true,
 		Message:    fmt.Sprintf("Adjustment %s recorded successfully", req.Direction),
 		MovementID: movement.ID,
 		BatchIDs:   batchIDs,
-	})
+	}, http.StatusCreated, nil
+}
+
+func (th *TransactionHandler) Adjustment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user session from context
+	session, ok := middlewares.GetSessionFromContext(r)
+	if !ok {
+		config.RespondJSON(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized - Authentication required"})
+		return
+	}
+
+	// Parse user ID from session
+	var userID int32
+	_, err := fmt.Sscanf(session.UserID, "%d", &userID)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+		return
+	}
+
+	var req AdjustmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	tx, err := th.h.DB.Begin(ctx)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to start transaction"})
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	queries := th.h.Queries.WithTx(tx)
+
+	response, status, err := th.postAdjustment(ctx, queries, req, userID)
+	if err != nil {
+		config.RespondJSON(w, status, map[string]string{"error": err.Error()})
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to commit transaction"})
+		return
+	}
+
+	config.RespondJSON(w, status, response)
 }
@@ -6,6 +6,7 @@ import (
 
 	"warehouse_system/internal/config"
 	db "warehouse_system/internal/database/db"
+	"warehouse_system/internal/handlers"
 
 	"github.com/jackc/pgx/v5/pgtype"
 )
@@ -14,7 +15,8 @@ import (
 // QUERY ENDPOINTS
 // =====================================================
 
-// GetStockLevel - Get current stock level for a material in a warehouse
+// GetStockLevel - Get current stock level for a material in a warehouse, or
+// the level rebuilt from movement history when as_of is given
 func (th *TransactionHandler) GetStockLevel(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -38,6 +40,16 @@ func (th *TransactionHandler) GetStockLevel(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	asOf, err := handlers.ParseAsOf(r)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if asOf.Valid {
+		th.respondStockAsOf(w, r, asOf, int32(materialID), int32(warehouseID))
+		return
+	}
+
 	stockLevel, err := th.h.Queries.GetCurrentStockLevel(ctx, db.GetCurrentStockLevelParams{
 		MaterialID:  pgtype.Int4{Int32: int32(materialID), Valid: true},
 		WarehouseID: pgtype.Int4{Int32: int32(warehouseID), Valid: true},
@@ -50,10 +62,29 @@ func (th *TransactionHandler) GetStockLevel(w http.ResponseWriter, r *http.Reque
 	config.RespondJSON(w, http.StatusOK, stockLevel)
 }
 
-// GetStockLevelsByWarehouse - Get all stock levels in a warehouse
+// GetStockLevelsByWarehouse - Get all stock levels in a warehouse, or the
+// levels rebuilt from movement history when as_of is given
 func (th *TransactionHandler) GetStockLevelsByWarehouse(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
+	asOf, err := handlers.ParseAsOf(r)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if asOf.Valid {
+		var warehouseID int
+		if warehouseIDStr := r.URL.Query().Get("warehouse_id"); warehouseIDStr != "" {
+			warehouseID, err = strconv.Atoi(warehouseIDStr)
+			if err != nil {
+				config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid warehouse_id"})
+				return
+			}
+		}
+		th.respondStockAsOf(w, r, asOf, 0, int32(warehouseID))
+		return
+	}
+
 	stockLevels, err := th.h.Queries.GetStockLevelsByWarehouse(ctx)
 	if err != nil {
 		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get stock levels"})
@@ -214,6 +245,12 @@ func (th *TransactionHandler) GetWarehouseMovements(w http.ResponseWriter, r *ht
 	config.RespondJSON(w, http.StatusOK, movements)
 }
 
+// MovementDetailResponse - Stock movement with the batches it consumed or created
+type MovementDetailResponse struct {
+	db.StockMovement
+	Batches []db.GetStockMovementBatchesRow `json:"batches"`
+}
+
 // GetMovementByID - Get details of a specific stock movement
 func (th *TransactionHandler) GetMovementByID(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -236,7 +273,16 @@ func (th *TransactionHandler) GetMovementByID(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	config.RespondJSON(w, http.StatusOK, movement)
+	batches, err := th.h.Queries.GetStockMovementBatches(ctx, movement.ID)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get movement batches"})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusOK, MovementDetailResponse{
+		StockMovement: movement,
+		Batches:       batches,
+	})
 }
 
 // =====================================================
@@ -244,31 +290,41 @@ func (th *TransactionHandler) GetMovementByID(w http.ResponseWriter, r *http.Req
 // =====================================================
 
 type BatchDetail struct {
-	ID              int32   `json:"id"`
-	BatchNumber     string  `json:"batch_number"`
-	CurrentQuantity float64 `json:"current_quantity"`
-	UnitPrice       float64 `json:"unit_price"`
-	ManufactureDate *string `json:"manufacture_date,omitempty"`
-	ExpiryDate      *string `json:"expiry_date,omitempty"`
-	WarehouseName   string  `json:"warehouse_name,omitempty"`
-	SupplierName    string  `json:"supplier_name,omitempty"`
+	ID               int32   `json:"id"`
+	BatchNumber      string  `json:"batch_number"`
+	CurrentQuantity  float64 `json:"current_quantity"`
+	HeldQuantity     float64 `json:"held_quantity"`
+	ReservedQuantity float64 `json:"reserved_quantity"`
+	UnitPrice        float64 `json:"unit_price"`
+	ManufactureDate  *string `json:"manufacture_date,omitempty"`
+	ExpiryDate       *string `json:"expiry_date,omitempty"`
+	WarehouseName    string  `json:"warehouse_name,omitempty"`
+	SupplierName     string  `json:"supplier_name,omitempty"`
+	BinCode          string  `json:"bin_code,omitempty"`
 }
 
 type WarehouseStock struct {
-	WarehouseID   int32         `json:"warehouse_id"`
-	WarehouseName string        `json:"warehouse_name"`
-	TotalQuantity float64       `json:"total_quantity"`
-	BatchCount    int           `json:"batch_count"`
-	Batches       []BatchDetail `json:"batches"`
+	WarehouseID       int32         `json:"warehouse_id"`
+	WarehouseName     string        `json:"warehouse_name"`
+	TotalQuantity     float64       `json:"total_quantity"`
+	HeldQuantity      float64       `json:"held_quantity"`
+	ReservedQuantity  float64       `json:"reserved_quantity"`
+	AvailableQuantity float64       `json:"available_quantity"`
+	InTransitQuantity float64       `json:"in_transit_quantity"` // shipped here, not received yet
+	BatchCount        int           `json:"batch_count"`
+	Batches           []BatchDetail `json:"batches"`
 }
 
 type MaterialStockResponse struct {
-	MaterialID   int32            `json:"material_id"`
-	MaterialName string           `json:"material_name"`
-	MaterialCode string           `json:"material_code"`
-	TotalStock   float64          `json:"total_stock"`
-	TotalBatches int              `json:"total_batches"`
-	Warehouses   []WarehouseStock `json:"warehouses"`
+	MaterialID     int32            `json:"material_id"`
+	MaterialName   string           `json:"material_name"`
+	MaterialCode   string           `json:"material_code"`
+	TotalStock     float64          `json:"total_stock"`
+	TotalReserved  float64          `json:"total_reserved"`
+	TotalAvailable float64          `json:"total_available"`
+	TotalInTransit float64          `json:"total_in_transit"`
+	TotalBatches   int              `json:"total_batches"`
+	Warehouses     []WarehouseStock `json:"warehouses"`
 }
 
 // Helper function to convert pgtype.Numeric to float64
@@ -303,7 +359,8 @@ func numericToFloat(n interface{}) float64 {
 	}
 }
 
-// GetMaterialStock - Get comprehensive stock info for a material with all batches
+// GetMaterialStock - Get comprehensive stock info for a material with all batches,
+// or the stock rebuilt from movement history when as_of is given
 func (th *TransactionHandler) GetMaterialStock(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -319,6 +376,16 @@ func (th *TransactionHandler) GetMaterialStock(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	asOf, err := handlers.ParseAsOf(r)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if asOf.Valid {
+		th.respondStockAsOf(w, r, asOf, int32(materialID), 0)
+		return
+	}
+
 	// Get stock levels by material (aggregated by warehouse)
 	stockLevels, err := th.h.Queries.GetStockLevelsByMaterial(ctx, int32(materialID))
 	if err != nil {
@@ -365,14 +432,25 @@ func (th *TransactionHandler) GetMaterialStock(w http.ResponseWriter, r *http.Re
 			continue
 		}
 
+		// On-hand, held, reserved and available to promise
+		level, err := th.h.Queries.GetCurrentStockLevel(ctx, db.GetCurrentStockLevelParams{
+			MaterialID:  pgtype.Int4{Int32: int32(materialID), Valid: true},
+			WarehouseID: pgtype.Int4{Int32: warehouseID, Valid: true},
+		})
+		if err != nil {
+			continue
+		}
+
 		// Convert batches to response format
 		batchDetails := []BatchDetail{}
 		for _, b := range batches {
 			detail := BatchDetail{
-				ID:              b.ID,
-				BatchNumber:     b.BatchNumber,
-				CurrentQuantity: numericToFloat(b.CurrentQuantity),
-				UnitPrice:       numericToFloat(b.UnitPrice),
+				ID:               b.ID,
+				BatchNumber:      b.BatchNumber,
+				CurrentQuantity:  numericToFloat(b.CurrentQuantity),
+				HeldQuantity:     numericToFloat(b.HeldQuantity),
+				ReservedQuantity: numericToFloat(b.ReservedQuantity),
+				UnitPrice:        numericToFloat(b.UnitPrice),
 			}
 
 			if b.ManufactureDate.Valid {
@@ -393,19 +471,30 @@ func (th *TransactionHandler) GetMaterialStock(w http.ResponseWriter, r *http.Re
 				detail.SupplierName = b.SupplierName.String
 			}
 
+			if b.BinCode.Valid {
+				detail.BinCode = b.BinCode.String
+			}
+
 			batchDetails = append(batchDetails, detail)
 		}
 
 		warehouseStock := WarehouseStock{
-			WarehouseID:   warehouseID,
-			WarehouseName: warehouseName,
-			TotalQuantity: quantity,
-			BatchCount:    len(batchDetails),
-			Batches:       batchDetails,
+			WarehouseID:       warehouseID,
+			WarehouseName:     warehouseName,
+			TotalQuantity:     quantity,
+			HeldQuantity:      numericToFloat(level.HeldQuantity),
+			ReservedQuantity:  numericToFloat(level.ReservedQuantity),
+			AvailableQuantity: numericToFloat(level.AvailableQuantity),
+			InTransitQuantity: numericToFloat(level.InTransitQuantity),
+			BatchCount:        len(batchDetails),
+			Batches:           batchDetails,
 		}
 
 		response.Warehouses = append(response.Warehouses, warehouseStock)
 		response.TotalStock += quantity
+		response.TotalReserved += warehouseStock.ReservedQuantity
+		response.TotalAvailable += warehouseStock.AvailableQuantity
+		response.TotalInTransit += warehouseStock.InTransitQuantity
 		response.TotalBatches += len(batchDetails)
 	}
 
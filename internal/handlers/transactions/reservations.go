@@ -0,0 +1,372 @@
+package transactions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"warehouse_system/internal/config"
+	db "warehouse_system/internal/database/db"
+	"warehouse_system/internal/middlewares"
+)
+
+// =====================================================
+// RESERVATION REQUEST TYPES
+// =====================================================
+
+type ReservationRequest struct {
+	SalesOrderID     int32   `json:"sales_order_id"`
+	SalesOrderItemID int32   `json:"sales_order_item_id"`
+	WarehouseID      int32   `json:"warehouse_id"`
+	BatchID          *int32  `json:"batch_id,omitempty"`
+	Quantity         float64 `json:"quantity"`
+	Notes            *string `json:"notes,omitempty"`
+}
+
+// =====================================================
+// RESERVATION HELPERS
+// =====================================================
+
+// stockReservations is the reserved stock of a material in a warehouse as
+// seen by one stock-out: stock reserved for other order lines is off limits,
+// batches pinned to the line being shipped are used first
+type stockReservations struct {
+	reserved   float64           // open quantity reserved by other lines
+	pinned     map[int32]float64 // batch quantity pinned by other lines
+	ownBatches map[int32]bool    // batches pinned to the line being shipped
+	available  float64           // on hand less held and reserved stock
+}
+
+// checkStockReservations locks the stock of a material in a warehouse and
+// rejects a stock-out that would eat into stock promised to other sales
+// order lines. salesOrderItemID is the line being shipped, 0 for stock-outs
+// that do not ship a sales order.
+func checkStockReservations(ctx context.Context, queries *db.Queries, materialID, warehouseID int32, quantity float64, salesOrderItemID int32) (stockReservations, error) {
+	res := stockReservations{
+		pinned:     map[int32]float64{},
+		ownBatches: map[int32]bool{},
+	}
+
+	if err := lockStock(ctx, queries, materialID, warehouseID); err != nil {
+		return res, err
+	}
+
+	reservations, err := queries.ListActiveStockReservations(ctx, db.ListActiveStockReservationsParams{
+		MaterialID:  materialID,
+		WarehouseID: warehouseID,
+	})
+	if err != nil {
+		return res, fmt.Errorf("failed to get reservations: %w", err)
+	}
+
+	for _, r := range reservations {
+		open := numericToFloat(r.Quantity) - numericToFloat(r.FulfilledQuantity)
+		if salesOrderItemID != 0 && r.SalesOrderItemID == salesOrderItemID {
+			if r.BatchID.Valid {
+				res.ownBatches[r.BatchID.Int32] = true
+			}
+			continue
+		}
+		res.reserved += open
+		if r.BatchID.Valid {
+			res.pinned[r.BatchID.Int32] += open
+		}
+	}
+
+	level, err := queries.GetCurrentStockLevel(ctx, db.GetCurrentStockLevelParams{
+		MaterialID:  pgtype.Int4{Int32: materialID, Valid: true},
+		WarehouseID: pgtype.Int4{Int32: warehouseID, Valid: true},
+	})
+	if err != nil {
+		return res, fmt.Errorf("failed to get stock level: %w", err)
+	}
+
+	free := numericToFloat(level.TotalQuantity) - numericToFloat(level.HeldQuantity) - res.reserved
+	res.available = max(free, 0)
+	if quantity > free+0.0001 {
+		return res, fmt.Errorf("insufficient available stock: %.2f available, %.2f reserved for other sales orders",
+			max(free, 0), res.reserved)
+	}
+
+	return res, nil
+}
+
+// fulfilReservations consumes the reservations of a sales order line in a
+// warehouse by a shipped quantity, oldest first
+func fulfilReservations(ctx context.Context, queries *db.Queries, salesOrderItemID, warehouseID int32, quantity float64) error {
+	reservations, err := queries.ListActiveLineReservationsForUpdate(ctx, db.ListActiveLineReservationsForUpdateParams{
+		SalesOrderItemID: salesOrderItemID,
+		WarehouseID:      warehouseID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get reservations: %w", err)
+	}
+
+	remaining := quantity
+	for _, r := range reservations {
+		if remaining <= 0.0001 {
+			break
+		}
+
+		reserved := numericToFloat(r.Quantity)
+		fulfilled := numericToFloat(r.FulfilledQuantity)
+		take := min(reserved-fulfilled, remaining)
+
+		status := "Active"
+		if fulfilled+take >= reserved-0.0001 {
+			status = "Fulfilled"
+		}
+
+		if _, err := queries.UpdateStockReservationFulfilment(ctx, db.UpdateStockReservationFulfilmentParams{
+			ID:                r.ID,
+			FulfilledQuantity: decimalFromFloat(fulfilled + take),
+			Status:            status,
+		}); err != nil {
+			return fmt.Errorf("failed to fulfil reservation %d: %w", r.ID, err)
+		}
+		remaining -= take
+	}
+
+	return nil
+}
+
+// =====================================================
+// RESERVATION HANDLERS
+// =====================================================
+
+// CreateReservation - Reserve stock of a warehouse for a sales order line
+func (th *TransactionHandler) CreateReservation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user session from context
+	session, ok := middlewares.GetSessionFromContext(r)
+	if !ok {
+		config.RespondJSON(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized - Authentication required"})
+		return
+	}
+
+	// Parse user ID from session
+	var userID int32
+	_, err := fmt.Sscanf(session.UserID, "%d", &userID)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+		return
+	}
+
+	var req ReservationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	if req.SalesOrderID == 0 || req.SalesOrderItemID == 0 || req.WarehouseID == 0 {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "sales_order_id, sales_order_item_id and warehouse_id are required"})
+		return
+	}
+
+	if req.Quantity <= 0 {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Quantity must be positive"})
+		return
+	}
+
+	tx, err := th.h.DB.Begin(ctx)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to start transaction"})
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	queries := th.h.Queries.WithTx(tx)
+
+	// Lock the order so concurrent reservations cannot over-reserve a line
+	salesOrder, err := queries.GetSalesOrderByIDForUpdate(ctx, req.SalesOrderID)
+	if err != nil {
+		config.RespondJSON(w, http.StatusNotFound, map[string]string{"error": "Sales order not found"})
+		return
+	}
+
+	if salesOrder.Status == "Cancelled" || salesOrder.Status == "Shipped" {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("Cannot reserve stock for a %s sales order", salesOrder.Status)})
+		return
+	}
+
+	orderLine, err := queries.GetSalesOrderItemByID(ctx, req.SalesOrderItemID)
+	if err != nil || orderLine.SalesOrderID.Int32 != salesOrder.ID {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("sales order line %d not found on this order", req.SalesOrderItemID)})
+		return
+	}
+
+	if !orderLine.MaterialID.Valid {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Sales order line has no material"})
+		return
+	}
+	materialID := orderLine.MaterialID.Int32
+
+	// A line cannot be reserved beyond what is still to be shipped
+	lineReserved, err := queries.GetLineReservedQuantity(ctx, orderLine.ID)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get reserved quantity"})
+		return
+	}
+
+	unreserved := numericToFloat(orderLine.Quantity) - numericToFloat(orderLine.ShippedQuantity) - numericToFloat(lineReserved)
+	if req.Quantity > unreserved+0.0001 {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("Quantity exceeds the unreserved open quantity of order line %d (remaining: %.2f)", orderLine.ID, max(unreserved, 0))})
+		return
+	}
+
+	// Lock the stock so two orders cannot be promised the same quantity
+	if err := queries.LockMaterialBatches(ctx, db.LockMaterialBatchesParams{
+		MaterialID:  pgtype.Int4{Int32: materialID, Valid: true},
+		WarehouseID: pgtype.Int4{Int32: req.WarehouseID, Valid: true},
+	}); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to lock stock"})
+		return
+	}
+
+	level, err := queries.GetCurrentStockLevel(ctx, db.GetCurrentStockLevelParams{
+		MaterialID:  pgtype.Int4{Int32: materialID, Valid: true},
+		WarehouseID: pgtype.Int4{Int32: req.WarehouseID, Valid: true},
+	})
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get stock level"})
+		return
+	}
+
+	available := numericToFloat(level.AvailableQuantity)
+	if req.Quantity > available+0.0001 {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("Insufficient available stock (available: %.2f)", available)})
+		return
+	}
+
+	// A pinned batch must be in the warehouse, free of holds and unreserved
+	if req.BatchID != nil {
+		batches, err := queries.GetAvailableBatchesForMaterial(ctx, db.GetAvailableBatchesForMaterialParams{
+			MaterialID:  pgtype.Int4{Int32: materialID, Valid: true},
+			WarehouseID: pgtype.Int4{Int32: req.WarehouseID, Valid: true},
+		})
+		if err != nil {
+			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get batches"})
+			return
+		}
+
+		var batch *db.GetAvailableBatchesForMaterialRow
+		for i := range batches {
+			if batches[i].ID == *req.BatchID {
+				batch = &batches[i]
+				break
+			}
+		}
+
+		if batch == nil {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("Batch %d has no stock of this material in the warehouse", *req.BatchID)})
+			return
+		}
+
+		if batch.HoldNumber.Valid {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("Batch %s is on quality hold %s", batch.BatchNumber, batch.HoldNumber.String)})
+			return
+		}
+
+		batchAvailable := numericToFloat(batch.AvailableQuantity)
+		if req.Quantity > batchAvailable+0.0001 {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("Insufficient available quantity in batch %s (available: %.2f)", batch.BatchNumber, batchAvailable)})
+			return
+		}
+	}
+
+	reservation, err := queries.CreateStockReservation(ctx, db.CreateStockReservationParams{
+		SalesOrderItemID: orderLine.ID,
+		MaterialID:       materialID,
+		WarehouseID:      req.WarehouseID,
+		BatchID:          pgtype.Int4{Int32: int32Value(req.BatchID), Valid: req.BatchID != nil},
+		Quantity:         decimalFromFloat(req.Quantity),
+		ReservedBy:       pgtype.Int4{Int32: userID, Valid: true},
+		Notes:            pgtype.Text{String: stringValue(req.Notes), Valid: req.Notes != nil && *req.Notes != ""},
+	})
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create reservation"})
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to commit transaction"})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusCreated, reservation)
+}
+
+// ReleaseReservation - Release an active reservation back to available stock
+func (th *TransactionHandler) ReleaseReservation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	idStr := r.PathValue("id")
+	var id int32
+	if _, err := fmt.Sscanf(idStr, "%d", &id); err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid reservation ID"})
+		return
+	}
+
+	tx, err := th.h.DB.Begin(ctx)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to start transaction"})
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	queries := th.h.Queries.WithTx(tx)
+
+	reservation, err := queries.GetStockReservationByIDForUpdate(ctx, id)
+	if err != nil {
+		config.RespondJSON(w, http.StatusNotFound, map[string]string{"error": "Reservation not found"})
+		return
+	}
+
+	if reservation.Status != "Active" {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("Reservation is already %s", reservation.Status)})
+		return
+	}
+
+	released, err := queries.ReleaseStockReservation(ctx, id)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to release reservation"})
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to commit transaction"})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusOK, released)
+}
+
+// GetReservations - Get the stock reservations of a sales order, newest first
+func (th *TransactionHandler) GetReservations(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	salesOrderIDStr := r.URL.Query().Get("sales_order_id")
+	if salesOrderIDStr == "" {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "sales_order_id is required"})
+		return
+	}
+
+	salesOrderID, err := strconv.Atoi(salesOrderIDStr)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid sales_order_id"})
+		return
+	}
+
+	reservations, err := th.h.Queries.ListStockReservationsBySalesOrder(ctx, pgtype.Int4{Int32: int32(salesOrderID), Valid: true})
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get reservations"})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusOK, reservations)
+}
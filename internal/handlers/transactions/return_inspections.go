@@ -0,0 +1,348 @@
+package transactions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"warehouse_system/internal/config"
+	db "warehouse_system/internal/database/db"
+	"warehouse_system/internal/middlewares"
+)
+
+// =====================================================
+// RETURN INSPECTION REQUEST TYPES
+// =====================================================
+
+// ReturnDecisionRequest decides what happens to the stock of a customer
+// return once its inspection is done
+type ReturnDecisionRequest struct {
+	Decision string  `json:"decision"` // restock, scrap or rework
+	Notes    *string `json:"notes,omitempty"`
+}
+
+// Decisions on returned stock
+const (
+	returnRestock = "restock"
+	returnScrap   = "scrap"
+	returnRework  = "rework"
+)
+
+// BOM version recorded on the work orders that rework returned stock, it
+// also gives them an RW- number instead of WO-
+const reworkBOMVersion = "REWORK"
+
+// =====================================================
+// RETURN INSPECTION HELPERS
+// =====================================================
+
+// issueReturnedBatch posts one movement taking quantity out of a returned
+// batch and returns the movement and its unit cost. The batch is on hold so
+// it is issued directly, the stock must have been locked. The units in stock
+// in the batch leave with the movement as serialStatus. An error from the
+// non-negative batch check is wrapped so isNegativeStock sees it.
+func issueReturnedBatch(ctx context.Context, queries *db.Queries, batch db.Batch, quantity float64, params db.CreateStockMovementParams, serialStatus string) (db.StockMovement, float64, error) {
+	allocations := []BatchAllocation{{BatchID: batch.ID, Quantity: quantity}}
+	unitCost, batchCosts, err := issueUnitCost(ctx, queries, batch.MaterialID.Int32, batch.WarehouseID.Int32, allocations)
+	if err != nil {
+		return db.StockMovement{}, 0, fmt.Errorf("failed to calculate issue cost: %w", err)
+	}
+
+	params.MaterialID = batch.MaterialID
+	params.FromWarehouseID = batch.WarehouseID
+	params.FromBinID = batch.BinID
+	params.Quantity = decimalFromFloat(quantity)
+	params.StockDirection = db.StockDirectionOUT
+	params.MovementDate = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+	params.UnitCost, params.TotalCost = movementCost(quantity, unitCost)
+
+	movement, err := queries.CreateStockMovement(ctx, params)
+	if err != nil {
+		return movement, 0, fmt.Errorf("failed to create %s movement: %w", params.MovementType, err)
+	}
+
+	if _, err := queries.UpdateBatchQuantity(ctx, db.UpdateBatchQuantityParams{
+		ID:              batch.ID,
+		CurrentQuantity: decimalFromFloat(-quantity),
+	}); err != nil {
+		return movement, 0, fmt.Errorf("failed to update batch quantity: %w", err)
+	}
+	if err := recordMovementBatch(ctx, queries, movement.ID, batch.ID, quantity, batchCosts[batch.ID]); err != nil {
+		return movement, 0, err
+	}
+
+	units, err := queries.ListBatchSerialNumbersForUpdate(ctx, pgtype.Int4{Int32: batch.ID, Valid: true})
+	if err != nil {
+		return movement, 0, fmt.Errorf("failed to get serial numbers: %w", err)
+	}
+	for _, u := range units {
+		if err := moveSerialNumber(ctx, queries, movement.ID, u.ID, u.BatchID, pgtype.Int4{}, serialStatus); err != nil {
+			return movement, 0, fmt.Errorf("failed to update serial number %s: %w", u.SerialNumber, err)
+		}
+	}
+
+	if err := postCostLedger(ctx, queries, batch.MaterialID.Int32, batch.WarehouseID.Int32, movement.ID, -quantity, unitCost); err != nil {
+		return movement, 0, err
+	}
+	return movement, unitCost, nil
+}
+
+// reworkReturnedBatch opens a work order that reworks a returned batch into
+// the same material and issues the batch to it. Recording the issue puts the
+// order In Progress in the same transaction, so it cannot be cancelled as
+// if nothing was issued and completing it receives the reworked stock as a
+// new batch.
+func reworkReturnedBatch(ctx context.Context, queries *db.Queries, batch db.Batch, quantity float64, inspection db.QualityInspection, userID int32) (db.WorkOrder, db.StockMovement, error) {
+	materialID := batch.MaterialID.Int32
+
+	workOrder, err := queries.CreateWorkOrder(ctx, db.CreateWorkOrderParams{
+		FinishedMaterialID: materialID,
+		BomVersion:         reworkBOMVersion,
+		WarehouseID:        batch.WarehouseID.Int32,
+		PlannedQuantity:    decimalFromFloat(quantity),
+		Notes:              pgtype.Text{String: fmt.Sprintf("Rework of returned batch %s after inspection %s", batch.BatchNumber, inspection.InspectionNumber), Valid: true},
+		CreatedBy:          pgtype.Int4{Int32: userID, Valid: true},
+	})
+	if err != nil {
+		return workOrder, db.StockMovement{}, fmt.Errorf("failed to create rework order: %w", err)
+	}
+
+	// The returned material is the only component, one for one
+	component, err := queries.CreateWorkOrderComponent(ctx, db.CreateWorkOrderComponentParams{
+		WorkOrderID:         workOrder.ID,
+		ComponentMaterialID: materialID,
+		BaseQuantity:        decimalFromFloat(1),
+		ScrapPercentage:     decimalFromFloat(0),
+		RequiredQuantity:    decimalFromFloat(quantity),
+		Sequence:            1,
+	})
+	if err != nil {
+		return workOrder, db.StockMovement{}, fmt.Errorf("failed to create rework component: %w", err)
+	}
+
+	movement, unitCost, err := issueReturnedBatch(ctx, queries, batch, quantity, db.CreateStockMovementParams{
+		MovementType: db.StockMovementTypePRODUCTIONISSUE,
+		Reference:    pgtype.Text{String: workOrder.WorkOrderNumber, Valid: true},
+		PerformedBy:  pgtype.Int4{Int32: userID, Valid: true},
+		WorkOrderID:  pgtype.Int4{Int32: workOrder.ID, Valid: true},
+	}, serialConsumed)
+	if err != nil {
+		return workOrder, movement, err
+	}
+
+	issuedCost := costFromFloat(quantity * unitCost)
+	if err := queries.AddWorkOrderComponentIssue(ctx, db.AddWorkOrderComponentIssueParams{
+		ID:                      component.ID,
+		IssuedQuantity:          decimalFromFloat(quantity),
+		AlternateIssuedQuantity: decimalFromFloat(0),
+		IssuedCost:              issuedCost,
+	}); err != nil {
+		return workOrder, movement, fmt.Errorf("failed to record rework issue: %w", err)
+	}
+
+	workOrder, err = queries.AddWorkOrderIssuedCost(ctx, db.AddWorkOrderIssuedCostParams{
+		ID:         workOrder.ID,
+		IssuedCost: issuedCost,
+	})
+	if err != nil {
+		return workOrder, movement, fmt.Errorf("failed to update rework order cost: %w", err)
+	}
+	return workOrder, movement, nil
+}
+
+// respondReturnIssueError answers a failed scrap or rework of a returned
+// batch, a batch that no longer holds the quantity is a conflict
+func respondReturnIssueError(w http.ResponseWriter, err error, batch db.Batch, quantity float64) {
+	if isNegativeStock(err) {
+		config.RespondJSON(w, http.StatusConflict, map[string]string{"error": fmt.Sprintf("Batch %s does not hold %.2f anymore", batch.BatchNumber, quantity)})
+		return
+	}
+	config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+}
+
+// =====================================================
+// RETURN INSPECTION DECISION
+// =====================================================
+
+// DecideCustomerReturn - Decide the inspection of a customer return and post
+// what it means for the quarantined stock. Restock releases the batch into
+// available stock, scrap posts a SCRAP movement of what the batch holds and
+// rework issues it to a new rework work order. The inspection is passed on
+// restock and failed otherwise, its holds are released in every case.
+func (th *TransactionHandler) DecideCustomerReturn(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user session from context
+	session, ok := middlewares.GetSessionFromContext(r)
+	if !ok {
+		config.RespondJSON(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized - Authentication required"})
+		return
+	}
+
+	// Parse user ID from session
+	var userID int32
+	_, err := fmt.Sscanf(session.UserID, "%d", &userID)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid inspection ID"})
+		return
+	}
+
+	var req ReturnDecisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	switch req.Decision {
+	case returnRestock, returnScrap, returnRework:
+	default:
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Decision must be restock, scrap or rework"})
+		return
+	}
+
+	tx, err := th.h.DB.Begin(ctx)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to start transaction"})
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	queries := th.h.Queries.WithTx(tx)
+
+	inspection, err := queries.GetQualityInspectionForUpdate(ctx, int32(id))
+	if err != nil {
+		config.RespondJSON(w, http.StatusNotFound, map[string]string{"error": "Inspection not found"})
+		return
+	}
+	if inspection.InspectionType != db.QualityInspectionTypeCustomerReturn || !inspection.StockMovementID.Valid {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("Inspection %s is not the inspection of a customer return", inspection.InspectionNumber)})
+		return
+	}
+
+	// Only stock still held by the inspection is waiting for a decision
+	holds, err := queries.ListActiveQualityHoldsByInspection(ctx, pgtype.Int4{Int32: inspection.ID, Valid: true})
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get inspection holds"})
+		return
+	}
+	if len(holds) == 0 {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("The stock of inspection %s is not held anymore", inspection.InspectionNumber)})
+		return
+	}
+
+	// The return movement put the stock into one batch
+	lines, err := queries.GetStockMovementBatches(ctx, inspection.StockMovementID.Int32)
+	if err != nil || len(lines) == 0 {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get the returned batch"})
+		return
+	}
+	if err := lockStock(ctx, queries, inspection.MaterialID.Int32, lines[0].WarehouseID.Int32); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to lock stock"})
+		return
+	}
+	batch, err := queries.GetBatchByID(ctx, lines[0].BatchID)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get the returned batch"})
+		return
+	}
+	quantity := numericToFloat(batch.CurrentQuantity)
+	if req.Decision != returnRestock && quantity <= 0.0001 {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("Batch %s has no stock left", batch.BatchNumber)})
+		return
+	}
+
+	notes := pgtype.Text{String: stringValue(req.Notes), Valid: req.Notes != nil && *req.Notes != ""}
+	var movementID int32
+	message := fmt.Sprintf("Batch %s restocked", batch.BatchNumber)
+
+	switch req.Decision {
+	case returnScrap:
+		if !notes.Valid {
+			notes = pgtype.Text{String: fmt.Sprintf("Scrapped after inspection %s", inspection.InspectionNumber), Valid: true}
+		}
+		movement, _, err := issueReturnedBatch(ctx, queries, batch, quantity, db.CreateStockMovementParams{
+			MovementType: db.StockMovementTypeSCRAP,
+			Reference:    pgtype.Text{String: inspection.InspectionNumber, Valid: true},
+			PerformedBy:  pgtype.Int4{Int32: userID, Valid: true},
+			Notes:        notes,
+		}, serialScrapped)
+		if err != nil {
+			respondReturnIssueError(w, err, batch, quantity)
+			return
+		}
+		movementID = movement.ID
+		message = fmt.Sprintf("Batch %s scrapped", batch.BatchNumber)
+	case returnRework:
+		workOrder, movement, err := reworkReturnedBatch(ctx, queries, batch, quantity, inspection, userID)
+		if err != nil {
+			respondReturnIssueError(w, err, batch, quantity)
+			return
+		}
+		movementID = movement.ID
+		message = fmt.Sprintf("Batch %s issued to rework order %s", batch.BatchNumber, workOrder.WorkOrderNumber)
+	}
+
+	for _, hold := range holds {
+		if _, err := queries.ReleaseQualityHold(ctx, db.ReleaseQualityHoldParams{
+			ID:           hold.ID,
+			ReleasedBy:   pgtype.Int4{Int32: userID, Valid: true},
+			ReleaseNotes: pgtype.Text{String: fmt.Sprintf("Inspection %s decided %s", inspection.InspectionNumber, req.Decision), Valid: true},
+		}); err != nil {
+			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to release hold " + hold.HoldNumber})
+			return
+		}
+	}
+
+	// Restocked stock passed the inspection, scrapped and reworked stock failed
+	params := db.UpdateQualityInspectionParams{
+		ID:               inspection.ID,
+		InspectionStatus: db.NullQualityInspectionStatus{QualityInspectionStatus: db.QualityInspectionStatusPassed, Valid: true},
+		ApprovedByID:     pgtype.Int4{Int32: userID, Valid: true},
+		QuantityPassed:   decimalFromFloat(quantity),
+		QuantityFailed:   decimalFromFloat(0),
+		FinalDecision:    db.NullQualityStatus{QualityStatus: db.QualityStatusUnrestricted, Valid: true},
+		DecisionDate:     pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		Notes:            pgtype.Text{String: fmt.Sprintf("Decision: %s", req.Decision), Valid: true},
+	}
+	if !inspection.InspectionDate.Valid {
+		params.InspectionDate = params.DecisionDate
+	}
+	if req.Decision != returnRestock {
+		params.InspectionStatus.QualityInspectionStatus = db.QualityInspectionStatusFailed
+		params.QuantityPassed, params.QuantityFailed = params.QuantityFailed, params.QuantityPassed
+		params.FinalDecision.QualityStatus = db.QualityStatusRejected
+	}
+	if inspection.Notes.Valid && inspection.Notes.String != "" {
+		params.Notes.String = inspection.Notes.String + "\n" + params.Notes.String
+	}
+	if req.Notes != nil && *req.Notes != "" {
+		params.Notes.String += ": " + *req.Notes
+	}
+	if _, err := queries.UpdateQualityInspection(ctx, params); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to update inspection"})
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to commit transaction"})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusOK, TransactionResponse{
+		Success:    true,
+		Message:    fmt.Sprintf("%s, inspection %s is %s", message, inspection.InspectionNumber, params.InspectionStatus.QualityInspectionStatus),
+		MovementID: movementID,
+		BatchIDs:   []int32{batch.ID},
+	})
+}
@@ -0,0 +1,388 @@
+package transactions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"warehouse_system/internal/config"
+	db "warehouse_system/internal/database/db"
+	"warehouse_system/internal/middlewares"
+)
+
+// =====================================================
+// REVERSAL REQUEST TYPES
+// =====================================================
+
+type ReverseMovementRequest struct {
+	Reason *string `json:"reason,omitempty"`
+}
+
+// =====================================================
+// REVERSAL HELPERS
+// =====================================================
+
+// movementWarehouse returns the warehouse whose stock a movement changed
+func movementWarehouse(movement db.StockMovement) int32 {
+	if movement.StockDirection == db.StockDirectionIN {
+		return movement.ToWarehouseID.Int32
+	}
+	return movement.FromWarehouseID.Int32
+}
+
+// checkReversible makes sure a movement can be reversed with the batch lines
+// it posted. Stock that came in must still be untouched in its batches,
+// otherwise it has been consumed downstream and only an adjustment can fix it.
+func checkReversible(ctx context.Context, queries *db.Queries, movement db.StockMovement, lines []db.GetStockMovementBatchesRow) error {
+	if movement.MovementType == db.StockMovementTypeREVERSAL {
+		return fmt.Errorf("movement %d is a reversal and cannot be reversed", movement.ID)
+	}
+	if movement.WorkOrderID.Valid {
+		return fmt.Errorf("movement %d belongs to work order %d, production movements cannot be reversed", movement.ID, movement.WorkOrderID.Int32)
+	}
+	if movement.TransferShipmentID.Valid {
+		return fmt.Errorf("movement %d belongs to transfer shipment %d, shipped transfers cannot be reversed", movement.ID, movement.TransferShipmentID.Int32)
+	}
+	if (movement.MovementType == db.StockMovementTypeTRANSFEROUT || movement.MovementType == db.StockMovementTypeTRANSFERIN) && !movement.PairedMovementID.Valid {
+		return fmt.Errorf("movement %d is not linked to the other leg of its transfer and cannot be reversed", movement.ID)
+	}
+	if movement.NcrID.Valid {
+		return fmt.Errorf("movement %d settles non-conformance report %d, supplier returns of a report cannot be reversed", movement.ID, movement.NcrID.Int32)
+	}
+	serials, err := queries.ListMovementSerials(ctx, []int32{movement.ID})
+	if err != nil {
+		return fmt.Errorf("failed to get movement serial numbers: %w", err)
+	}
+	if len(serials) > 0 {
+		return fmt.Errorf("movement %d moved serial numbers, movements of serialized materials cannot be reversed", movement.ID)
+	}
+	if movement.ReversedByMovementID.Valid {
+		return fmt.Errorf("movement %d is already reversed by movement %d", movement.ID, movement.ReversedByMovementID.Int32)
+	}
+	if len(lines) == 0 {
+		return fmt.Errorf("movement %d has no batch lines to restore", movement.ID)
+	}
+
+	if movement.StockDirection != db.StockDirectionIN {
+		return nil
+	}
+
+	if err := queries.LockMaterialBatches(ctx, db.LockMaterialBatchesParams{
+		MaterialID:  movement.MaterialID,
+		WarehouseID: movement.ToWarehouseID,
+	}); err != nil {
+		return fmt.Errorf("failed to lock stock: %w", err)
+	}
+
+	batchIDs := make([]int32, len(lines))
+	for i, l := range lines {
+		batchIDs[i] = l.BatchID
+	}
+
+	batches, err := queries.GetBatchesByIDs(ctx, batchIDs)
+	if err != nil {
+		return fmt.Errorf("failed to fetch batches: %w", err)
+	}
+
+	current := make(map[int32]float64, len(batches))
+	for _, b := range batches {
+		current[b.ID] = numericToFloat(b.CurrentQuantity)
+	}
+
+	for _, l := range lines {
+		if current[l.BatchID] < numericToFloat(l.Quantity)-0.0001 {
+			return fmt.Errorf("batch %s has since been consumed downstream (remaining: %.2f, received: %.2f)",
+				l.BatchNumber, current[l.BatchID], numericToFloat(l.Quantity))
+		}
+	}
+
+	return nil
+}
+
+// lockMovementOrder locks the sales or purchase order a movement was posted
+// against. Sales, receipts and supplier returns lock the order before the
+// batches, a reversal takes the locks in the same order so it cannot
+// deadlock with them.
+func lockMovementOrder(ctx context.Context, queries *db.Queries, movement db.StockMovement) error {
+	switch {
+	case movement.MovementType == db.StockMovementTypeSALE && movement.SalesOrderItemID.Valid:
+		line, err := queries.GetSalesOrderItemByID(ctx, movement.SalesOrderItemID.Int32)
+		if err != nil {
+			return fmt.Errorf("failed to get sales order line: %w", err)
+		}
+		if _, err := queries.GetSalesOrderByIDForUpdate(ctx, line.SalesOrderID.Int32); err != nil {
+			return fmt.Errorf("failed to lock sales order: %w", err)
+		}
+
+	case (movement.MovementType == db.StockMovementTypePURCHASERECEIPT || movement.MovementType == db.StockMovementTypeSUPPLIERRETURN) && movement.PurchaseOrderItemID.Valid:
+		line, err := queries.GetPurchaseOrderItemByID(ctx, movement.PurchaseOrderItemID.Int32)
+		if err != nil {
+			return fmt.Errorf("failed to get purchase order line: %w", err)
+		}
+		if _, err := queries.GetPurchaseOrderByIDForUpdate(ctx, line.PurchaseOrderID.Int32); err != nil {
+			return fmt.Errorf("failed to lock purchase order: %w", err)
+		}
+	}
+	return nil
+}
+
+// reverseMovement posts the compensating REVERSAL movement of a movement:
+// the opposite direction on the same warehouse, at the same cost, restoring
+// exactly the batch quantities of the original lines. The order of the
+// movement must have been locked with lockMovementOrder.
+func reverseMovement(ctx context.Context, queries *db.Queries, movement db.StockMovement, lines []db.GetStockMovementBatchesRow, userID int32, notes pgtype.Text) (db.StockMovement, error) {
+	warehouseID := movementWarehouse(movement)
+	quantity := numericToFloat(movement.Quantity)
+	unitCost := numericToFloat(movement.UnitCost)
+
+	params := db.CreateStockMovementParams{
+		MaterialID:          movement.MaterialID,
+		Quantity:            movement.Quantity,
+		MovementType:        db.StockMovementTypeREVERSAL,
+		Reference:           pgtype.Text{String: fmt.Sprintf("REVERSAL-%d", movement.ID), Valid: true},
+		PerformedBy:         pgtype.Int4{Int32: userID, Valid: true},
+		MovementDate:        pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		Notes:               notes,
+		UnitCost:            movement.UnitCost,
+		TotalCost:           movement.TotalCost,
+		SalesOrderItemID:    movement.SalesOrderItemID,
+		PurchaseOrderItemID: movement.PurchaseOrderItemID,
+		ReversesMovementID:  pgtype.Int4{Int32: movement.ID, Valid: true},
+	}
+
+	// Stock that came in goes back out and vice versa
+	sign := 1.0
+	if movement.StockDirection == db.StockDirectionIN {
+		sign = -1
+		params.StockDirection = db.StockDirectionOUT
+		params.FromWarehouseID = movement.ToWarehouseID
+	} else {
+		params.StockDirection = db.StockDirectionIN
+		params.ToWarehouseID = movement.FromWarehouseID
+	}
+
+	reversal, err := queries.CreateStockMovement(ctx, params)
+	if err != nil {
+		return db.StockMovement{}, fmt.Errorf("failed to create reversal movement: %w", err)
+	}
+
+	for _, l := range lines {
+		lineQty := numericToFloat(l.Quantity)
+		if _, err := queries.UpdateBatchQuantity(ctx, db.UpdateBatchQuantityParams{
+			ID:              l.BatchID,
+			CurrentQuantity: decimalFromFloat(sign * lineQty),
+		}); err != nil {
+			return db.StockMovement{}, fmt.Errorf("failed to restore batch %d: %w", l.BatchID, err)
+		}
+		if err := recordMovementBatch(ctx, queries, reversal.ID, l.BatchID, lineQty, numericToFloat(l.UnitCost)); err != nil {
+			return db.StockMovement{}, err
+		}
+	}
+
+	if err := postCostLedger(ctx, queries, movement.MaterialID.Int32, warehouseID, reversal.ID, sign*quantity, unitCost); err != nil {
+		return db.StockMovement{}, err
+	}
+
+	if err := queries.MarkStockMovementReversed(ctx, db.MarkStockMovementReversedParams{
+		ID:                   movement.ID,
+		ReversedByMovementID: pgtype.Int4{Int32: reversal.ID, Valid: true},
+	}); err != nil {
+		return db.StockMovement{}, fmt.Errorf("failed to link reversed movement: %w", err)
+	}
+
+	// Undo what the movement did to its order line
+	switch {
+	case movement.MovementType == db.StockMovementTypeSALE && movement.SalesOrderItemID.Valid:
+		line, err := queries.GetSalesOrderItemByID(ctx, movement.SalesOrderItemID.Int32)
+		if err != nil {
+			return db.StockMovement{}, fmt.Errorf("failed to get sales order line: %w", err)
+		}
+		salesOrder, err := queries.GetSalesOrderByIDForUpdate(ctx, line.SalesOrderID.Int32)
+		if err != nil {
+			return db.StockMovement{}, fmt.Errorf("failed to lock sales order: %w", err)
+		}
+		// Read the line again under the lock, a shipment may have committed since
+		line, err = queries.GetSalesOrderItemByID(ctx, line.ID)
+		if err != nil {
+			return db.StockMovement{}, fmt.Errorf("failed to get sales order line: %w", err)
+		}
+		if _, err := queries.UpdateSalesOrderItemShippedQuantity(ctx, db.UpdateSalesOrderItemShippedQuantityParams{
+			ID:              line.ID,
+			ShippedQuantity: decimalFromFloat(max(numericToFloat(line.ShippedQuantity)-quantity, 0)),
+		}); err != nil {
+			return db.StockMovement{}, fmt.Errorf("failed to update shipped quantity: %w", err)
+		}
+		if _, err := updateSalesOrderFulfilment(ctx, queries, salesOrder); err != nil {
+			return db.StockMovement{}, err
+		}
+
+	case (movement.MovementType == db.StockMovementTypePURCHASERECEIPT || movement.MovementType == db.StockMovementTypeSUPPLIERRETURN) && movement.PurchaseOrderItemID.Valid:
+		// A receipt comes off the line again, a return goes back on it
+		received := -quantity
+		if movement.MovementType == db.StockMovementTypeSUPPLIERRETURN {
+			received = quantity
+		}
+		line, err := queries.GetPurchaseOrderItemByID(ctx, movement.PurchaseOrderItemID.Int32)
+		if err != nil {
+			return db.StockMovement{}, fmt.Errorf("failed to get purchase order line: %w", err)
+		}
+		purchaseOrder, err := queries.GetPurchaseOrderByIDForUpdate(ctx, line.PurchaseOrderID.Int32)
+		if err != nil {
+			return db.StockMovement{}, fmt.Errorf("failed to lock purchase order: %w", err)
+		}
+		// Read the line again under the lock, a receipt may have committed since
+		line, err = queries.GetPurchaseOrderItemByID(ctx, line.ID)
+		if err != nil {
+			return db.StockMovement{}, fmt.Errorf("failed to get purchase order line: %w", err)
+		}
+		if _, err := queries.UpdatePurchaseOrderItemReceivedQuantity(ctx, db.UpdatePurchaseOrderItemReceivedQuantityParams{
+			ID:               line.ID,
+			ReceivedQuantity: decimalFromFloat(max(numericToFloat(line.ReceivedQuantity)+received, 0)),
+		}); err != nil {
+			return db.StockMovement{}, fmt.Errorf("failed to update received quantity: %w", err)
+		}
+		if _, err := updatePurchaseOrderReceiving(ctx, queries, purchaseOrder); err != nil {
+			return db.StockMovement{}, err
+		}
+	}
+
+	return reversal, nil
+}
+
+// =====================================================
+// REVERSAL HANDLER
+// =====================================================
+
+// ReverseMovement - Void a posted movement with a compensating movement.
+// Both legs of a transfer are reversed together.
+func (th *TransactionHandler) ReverseMovement(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user session from context
+	session, ok := middlewares.GetSessionFromContext(r)
+	if !ok {
+		config.RespondJSON(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized - Authentication required"})
+		return
+	}
+
+	// Parse user ID from session
+	var userID int32
+	_, err := fmt.Sscanf(session.UserID, "%d", &userID)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+		return
+	}
+
+	idStr := r.PathValue("id")
+	var id int32
+	if _, err := fmt.Sscanf(idStr, "%d", &id); err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid movement ID"})
+		return
+	}
+
+	// The body is optional
+	var req ReverseMovementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	tx, err := th.h.DB.Begin(ctx)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to start transaction"})
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	queries := th.h.Queries.WithTx(tx)
+
+	movement, err := queries.GetStockMovementByIDForUpdate(ctx, id)
+	if err != nil {
+		config.RespondJSON(w, http.StatusNotFound, map[string]string{"error": "Movement not found"})
+		return
+	}
+
+	legs := []db.StockMovement{movement}
+
+	// A one-step transfer is one movement per warehouse, each pointing at
+	// the other
+	if movement.PairedMovementID.Valid {
+		counterpart, err := queries.GetStockMovementByIDForUpdate(ctx, movement.PairedMovementID.Int32)
+		if err != nil {
+			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to lock transfer movement"})
+			return
+		}
+		legs = append(legs, counterpart)
+
+		// Take the stock out of the destination before returning it to the source
+		if legs[0].StockDirection == db.StockDirectionOUT {
+			legs[0], legs[1] = legs[1], legs[0]
+		}
+	}
+
+	// Orders are locked before any batch, the same order a sale or a
+	// receipt takes its locks in
+	for _, leg := range legs {
+		if err := lockMovementOrder(ctx, queries, leg); err != nil {
+			th.h.Logger.Error("Failed to lock movement order", "movement_id", leg.ID, "error", err)
+			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to lock order"})
+			return
+		}
+	}
+
+	legLines := make([][]db.GetStockMovementBatchesRow, len(legs))
+	for i, leg := range legs {
+		lines, err := queries.GetStockMovementBatches(ctx, leg.ID)
+		if err != nil {
+			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get movement batches"})
+			return
+		}
+
+		if err := checkReversible(ctx, queries, leg, lines); err != nil {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		legLines[i] = lines
+	}
+
+	notes := pgtype.Text{String: fmt.Sprintf("Reversal of movement %d", movement.ID), Valid: true}
+	if req.Reason != nil && *req.Reason != "" {
+		notes.String = fmt.Sprintf("Reversal of movement %d: %s", movement.ID, *req.Reason)
+	}
+
+	var reversalID int32
+	batchIDs := []int32{}
+	for i, leg := range legs {
+		reversal, err := reverseMovement(ctx, queries, leg, legLines[i], userID, notes)
+		if err != nil {
+			th.h.Logger.Error("Failed to reverse movement", "movement_id", leg.ID, "error", err)
+			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to reverse movement"})
+			return
+		}
+
+		if leg.ID == movement.ID {
+			reversalID = reversal.ID
+		}
+		for _, l := range legLines[i] {
+			batchIDs = append(batchIDs, l.BatchID)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to commit transaction"})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusCreated, TransactionResponse{
+		Success:    true,
+		Message:    fmt.Sprintf("Movement %d reversed successfully", movement.ID),
+		MovementID: reversalID,
+		BatchIDs:   batchIDs,
+	})
+}
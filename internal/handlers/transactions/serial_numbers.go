@@ -0,0 +1,322 @@
+package transactions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"warehouse_system/internal/config"
+	db "warehouse_system/internal/database/db"
+)
+
+// =====================================================
+// SERIAL NUMBER HELPERS
+// =====================================================
+
+// Statuses of a serial number
+const (
+	serialInStock   = "In Stock"
+	serialInTransit = "In Transit"
+	serialSold      = "Sold"
+	serialLost      = "Lost"
+	serialReturned  = "Returned"
+	serialScrapped  = "Scrapped"
+	serialConsumed  = "Consumed"
+)
+
+type SerialNumberLookupResponse struct {
+	db.GetSerialNumbersByNumberRow
+	History []db.GetSerialNumberHistoryRow `json:"history"`
+}
+
+// checkSerialNumbers validates the serial numbers given for quantity units of
+// a material and reports whether the material is serialized. A serialized
+// material moves in whole units with one distinct serial number each, other
+// materials take no serial numbers.
+func checkSerialNumbers(ctx context.Context, queries *db.Queries, materialID int32, quantity float64, serials []string) (bool, error) {
+	material, err := queries.GetMaterialByID(ctx, materialID)
+	if err != nil {
+		return false, fmt.Errorf("material %d not found", materialID)
+	}
+	if !material.IsSerialized {
+		if len(serials) > 0 {
+			return false, fmt.Errorf("material %s is not serialized", material.Code)
+		}
+		return false, nil
+	}
+
+	units := math.Round(quantity)
+	if math.Abs(quantity-units) > 0.0001 {
+		return false, fmt.Errorf("material %s is serialized, the quantity must be a whole number", material.Code)
+	}
+	if len(serials) != int(units) {
+		return false, fmt.Errorf("material %s is serialized, %d serial numbers are required (got %d)", material.Code, int(units), len(serials))
+	}
+
+	seen := make(map[string]bool, len(serials))
+	for i, s := range serials {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return false, fmt.Errorf("serial numbers cannot be empty")
+		}
+		if seen[s] {
+			return false, fmt.Errorf("serial number %s is given more than once", s)
+		}
+		seen[s] = true
+		serials[i] = s
+	}
+	return true, nil
+}
+
+// rejectSerialized fails when the material is serialized, for stock changes
+// that cannot say which units they move. reason tells how to post them.
+func rejectSerialized(ctx context.Context, queries *db.Queries, materialID int32, reason string) error {
+	material, err := queries.GetMaterialByID(ctx, materialID)
+	if err != nil {
+		return fmt.Errorf("material %d not found", materialID)
+	}
+	if material.IsSerialized {
+		return fmt.Errorf("material %s is serialized, %s", material.Code, reason)
+	}
+	return nil
+}
+
+// issueSerialNumbers locks the serial numbers leaving a warehouse and checks
+// they are in stock there. The batches holding them are the allocations of
+// the issue, one unit per serial number.
+func issueSerialNumbers(ctx context.Context, queries *db.Queries, materialID, warehouseID int32, serials []string) ([]db.SerialNumber, []BatchAllocation, error) {
+	units, err := queries.GetSerialNumbersForUpdate(ctx, db.GetSerialNumbersForUpdateParams{
+		MaterialID:    materialID,
+		SerialNumbers: serials,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get serial numbers: %w", err)
+	}
+
+	registered := make(map[string]bool, len(units))
+	for _, u := range units {
+		registered[u.SerialNumber] = true
+	}
+	for _, s := range serials {
+		if !registered[s] {
+			return nil, nil, fmt.Errorf("serial number %s is not registered for this material", s)
+		}
+	}
+
+	var allocations []BatchAllocation
+	index := make(map[int32]int)
+	for _, u := range units {
+		if u.Status != serialInStock || u.WarehouseID.Int32 != warehouseID {
+			return nil, nil, fmt.Errorf("serial number %s is not in stock in warehouse %d (%s)", u.SerialNumber, warehouseID, u.Status)
+		}
+		if !u.BatchID.Valid {
+			return nil, nil, fmt.Errorf("serial number %s is not in a batch", u.SerialNumber)
+		}
+		if i, ok := index[u.BatchID.Int32]; ok {
+			allocations[i].Quantity++
+			continue
+		}
+		index[u.BatchID.Int32] = len(allocations)
+		allocations = append(allocations, BatchAllocation{BatchID: u.BatchID.Int32, Quantity: 1})
+	}
+	return units, allocations, nil
+}
+
+// returnSerialNumbers locks the serial numbers returned by a customer and
+// checks they were sold with one of the sale movements
+func returnSerialNumbers(ctx context.Context, queries *db.Queries, materialID int32, saleMovementIDs []int32, serials []string) ([]db.SerialNumber, error) {
+	sold, err := queries.ListMovementSerials(ctx, saleMovementIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sold serial numbers: %w", err)
+	}
+	soldIDs := make(map[int32]bool, len(sold))
+	for _, s := range sold {
+		soldIDs[s.SerialNumberID] = true
+	}
+
+	units, err := queries.GetSerialNumbersForUpdate(ctx, db.GetSerialNumbersForUpdateParams{
+		MaterialID:    materialID,
+		SerialNumbers: serials,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get serial numbers: %w", err)
+	}
+
+	found := make(map[string]db.SerialNumber, len(units))
+	for _, u := range units {
+		found[u.SerialNumber] = u
+	}
+	for _, s := range serials {
+		u, ok := found[s]
+		if !ok || !soldIDs[u.ID] {
+			return nil, fmt.Errorf("serial number %s was not sold with this sales order", s)
+		}
+		if u.Status != serialSold {
+			return nil, fmt.Errorf("serial number %s is %s, only sold units can be returned", s, u.Status)
+		}
+	}
+	return units, nil
+}
+
+// receiveSerialNumbers registers the serial numbers entering a warehouse
+// with the batch created for them and records them on the movement
+func receiveSerialNumbers(ctx context.Context, queries *db.Queries, materialID, warehouseID, batchID, movementID int32, serials []string) error {
+	for _, s := range serials {
+		unit, err := queries.RegisterSerialNumber(ctx, db.RegisterSerialNumberParams{
+			MaterialID:   materialID,
+			SerialNumber: s,
+			BatchID:      pgtype.Int4{Int32: batchID, Valid: true},
+			WarehouseID:  pgtype.Int4{Int32: warehouseID, Valid: true},
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("serial number %s is already in stock or in transit", s)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to register serial number %s: %w", s, err)
+		}
+
+		if err := queries.CreateStockMovementSerial(ctx, db.CreateStockMovementSerialParams{
+			MovementID:     movementID,
+			SerialNumberID: unit.ID,
+			BatchID:        unit.BatchID,
+		}); err != nil {
+			return fmt.Errorf("failed to record serial number %s: %w", s, err)
+		}
+	}
+	return nil
+}
+
+// moveSerialNumber records a registered serial number on a movement and
+// where the movement left it. Units out of stock have no warehouse.
+func moveSerialNumber(ctx context.Context, queries *db.Queries, movementID, serialNumberID int32, batchID, warehouseID pgtype.Int4, status string) error {
+	if _, err := queries.UpdateSerialNumber(ctx, db.UpdateSerialNumberParams{
+		ID:          serialNumberID,
+		BatchID:     batchID,
+		WarehouseID: warehouseID,
+		Status:      status,
+	}); err != nil {
+		return err
+	}
+
+	return queries.CreateStockMovementSerial(ctx, db.CreateStockMovementSerialParams{
+		MovementID:     movementID,
+		SerialNumberID: serialNumberID,
+		BatchID:        batchID,
+	})
+}
+
+// =====================================================
+// SERIAL NUMBER QUERIES
+// =====================================================
+
+// ListSerialNumbers - Get serial numbers, filtered by material, warehouse,
+// batch, status or part of the serial number
+func (th *TransactionHandler) ListSerialNumbers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	params := db.ListSerialNumbersParams{Limit: 100}
+	query := r.URL.Query()
+
+	if status := query.Get("status"); status != "" {
+		params.Status = pgtype.Text{String: status, Valid: true}
+	}
+	if search := query.Get("search"); search != "" {
+		params.Search = pgtype.Text{String: search, Valid: true}
+	}
+
+	for name, target := range map[string]*pgtype.Int4{
+		"material_id":  &params.MaterialID,
+		"warehouse_id": &params.WarehouseID,
+		"batch_id":     &params.BatchID,
+	} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid " + name})
+			return
+		}
+		*target = pgtype.Int4{Int32: int32(id), Valid: true}
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid limit"})
+			return
+		}
+		params.Limit = int32(limit)
+	}
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		offset, err := strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid offset"})
+			return
+		}
+		params.Offset = int32(offset)
+	}
+
+	serials, err := th.h.Queries.ListSerialNumbers(ctx, params)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get serial numbers"})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusOK, serials)
+}
+
+// LookupSerialNumber - Get a serial number with where it is now and every
+// movement it went through, oldest first
+func (th *TransactionHandler) LookupSerialNumber(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	serialNumber := strings.TrimSpace(r.URL.Query().Get("serial_number"))
+	if serialNumber == "" {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "serial_number is required"})
+		return
+	}
+
+	var materialID pgtype.Int4
+	if materialStr := r.URL.Query().Get("material_id"); materialStr != "" {
+		id, err := strconv.Atoi(materialStr)
+		if err != nil {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid material_id"})
+			return
+		}
+		materialID = pgtype.Int4{Int32: int32(id), Valid: true}
+	}
+
+	units, err := th.h.Queries.GetSerialNumbersByNumber(ctx, db.GetSerialNumbersByNumberParams{
+		SerialNumber: serialNumber,
+		MaterialID:   materialID,
+	})
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get serial number"})
+		return
+	}
+	if len(units) == 0 {
+		config.RespondJSON(w, http.StatusNotFound, map[string]string{"error": "Serial number not found"})
+		return
+	}
+
+	response := make([]SerialNumberLookupResponse, 0, len(units))
+	for _, u := range units {
+		history, err := th.h.Queries.GetSerialNumberHistory(ctx, u.ID)
+		if err != nil {
+			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get serial number history"})
+			return
+		}
+		response = append(response, SerialNumberLookupResponse{GetSerialNumbersByNumberRow: u, History: history})
+	}
+
+	config.RespondJSON(w, http.StatusOK, response)
+}
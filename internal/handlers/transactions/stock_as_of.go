@@ -0,0 +1,91 @@
+package transactions
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"warehouse_system/internal/config"
+	db "warehouse_system/internal/database/db"
+)
+
+// =====================================================
+// POINT IN TIME STOCK
+// =====================================================
+
+// StockAsOfResponse is the stock rebuilt from movement history at a past
+// moment, per material and warehouse and per batch. Stock shipped between
+// warehouses and not received yet at that moment is in no batch, it is
+// listed under InTransit by destination warehouse like in the valuation.
+type StockAsOfResponse struct {
+	AsOf              time.Time                  `json:"as_of"`
+	TotalQuantity     float64                    `json:"total_quantity"`
+	TotalBatchValue   float64                    `json:"total_batch_value"`
+	TotalLedgerValue  float64                    `json:"total_ledger_value"`
+	InTransitQuantity float64                    `json:"in_transit_quantity"`
+	InTransitValue    float64                    `json:"in_transit_value"`
+	Levels            []db.GetStockLevelsAsOfRow `json:"levels"`
+	Batches           []db.GetBatchStockAsOfRow  `json:"batches"`
+	InTransit         []db.GetInTransitAsOfRow   `json:"in_transit"`
+}
+
+// respondStockAsOf rebuilds the stock at asOf, optionally limited to a
+// material and/or a warehouse (0 = all). asOf before stock history starts
+// is rejected.
+func (th *TransactionHandler) respondStockAsOf(w http.ResponseWriter, r *http.Request, asOf pgtype.Timestamptz, materialID, warehouseID int32) {
+	ctx := r.Context()
+
+	if status, err := th.h.CheckStockHistory(ctx, asOf); err != nil {
+		config.RespondJSON(w, status, map[string]string{"error": err.Error()})
+		return
+	}
+
+	levels, err := th.h.Queries.GetStockLevelsAsOf(ctx, db.GetStockLevelsAsOfParams{
+		AsOf:        asOf,
+		MaterialID:  pgtype.Int4{Int32: materialID, Valid: materialID != 0},
+		WarehouseID: pgtype.Int4{Int32: warehouseID, Valid: warehouseID != 0},
+	})
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get stock levels"})
+		return
+	}
+
+	batches, err := th.h.Queries.GetBatchStockAsOf(ctx, db.GetBatchStockAsOfParams{
+		AsOf:        asOf,
+		MaterialID:  pgtype.Int4{Int32: materialID, Valid: materialID != 0},
+		WarehouseID: pgtype.Int4{Int32: warehouseID, Valid: warehouseID != 0},
+	})
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get batches"})
+		return
+	}
+
+	inTransit, err := th.h.Queries.GetInTransitAsOf(ctx, db.GetInTransitAsOfParams{
+		AsOf:        asOf,
+		MaterialID:  pgtype.Int4{Int32: materialID, Valid: materialID != 0},
+		WarehouseID: pgtype.Int4{Int32: warehouseID, Valid: warehouseID != 0},
+	})
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get stock in transit"})
+		return
+	}
+
+	response := StockAsOfResponse{
+		AsOf:      asOf.Time,
+		Levels:    levels,
+		Batches:   batches,
+		InTransit: inTransit,
+	}
+	for _, l := range levels {
+		response.TotalQuantity += numericToFloat(l.TotalQuantity)
+		response.TotalBatchValue += numericToFloat(l.BatchValue)
+		response.TotalLedgerValue += numericToFloat(l.LedgerValue)
+	}
+	for _, t := range inTransit {
+		response.InTransitQuantity += numericToFloat(t.Quantity)
+		response.InTransitValue += numericToFloat(t.Value)
+	}
+
+	config.RespondJSON(w, http.StatusOK, response)
+}
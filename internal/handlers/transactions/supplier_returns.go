@@ -0,0 +1,367 @@
+package transactions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"warehouse_system/internal/config"
+	db "warehouse_system/internal/database/db"
+	"warehouse_system/internal/middlewares"
+)
+
+// =====================================================
+// SUPPLIER RETURN REQUEST TYPES
+// =====================================================
+
+// SupplierReturnRequest sends stock of a purchased batch back to its
+// supplier. The purchase order line is the one the batch was received on.
+// NcrID is the non-conformance report the return settles, if any.
+type SupplierReturnRequest struct {
+	BatchID       int32    `json:"batch_id"`
+	Quantity      float64  `json:"quantity"`
+	UnitID        *int32   `json:"unit_id,omitempty"`
+	NcrID         *int32   `json:"ncr_id,omitempty"`
+	Notes         *string  `json:"notes,omitempty"`
+	SerialNumbers []string `json:"serial_numbers,omitempty"` // the units sent back, all from the batch
+}
+
+// Disposition of a non-conformance report settled by a supplier return
+const ncrReturnToSupplier = "return_to_supplier"
+
+// =====================================================
+// SUPPLIER RETURN HELPERS
+// =====================================================
+
+// checkReturnNCR locks the non-conformance report a supplier return settles
+// and checks it asks for the return of the batch
+func checkReturnNCR(ctx context.Context, queries *db.Queries, ncrID int32, batch db.Batch, purchaseOrder db.PurchaseOrder) (db.NonConformanceReport, error) {
+	ncr, err := queries.GetNonConformanceReportForUpdate(ctx, ncrID)
+	if err != nil {
+		return ncr, fmt.Errorf("non-conformance report %d not found", ncrID)
+	}
+
+	switch ncr.Status.NcrStatus {
+	case db.NcrStatusResolved, db.NcrStatusClosed, db.NcrStatusCancelled:
+		return ncr, fmt.Errorf("non-conformance report %s is %s", ncr.NcrNumber, ncr.Status.NcrStatus)
+	}
+	if ncr.Disposition.String != ncrReturnToSupplier {
+		return ncr, fmt.Errorf("non-conformance report %s does not have the %s disposition", ncr.NcrNumber, ncrReturnToSupplier)
+	}
+	if ncr.MaterialID.Valid && ncr.MaterialID.Int32 != batch.MaterialID.Int32 {
+		return ncr, fmt.Errorf("non-conformance report %s is about material %d, not the material of batch %s", ncr.NcrNumber, ncr.MaterialID.Int32, batch.BatchNumber)
+	}
+	if ncr.BatchNumber.Valid && ncr.BatchNumber.String != "" && ncr.BatchNumber.String != batch.BatchNumber {
+		return ncr, fmt.Errorf("non-conformance report %s is about batch %s, not batch %s", ncr.NcrNumber, ncr.BatchNumber.String, batch.BatchNumber)
+	}
+	if ncr.PurchaseOrderID.Valid && ncr.PurchaseOrderID.Int32 != purchaseOrder.ID {
+		return ncr, fmt.Errorf("non-conformance report %s is about purchase order %d, batch %s was received on purchase order %d",
+			ncr.NcrNumber, ncr.PurchaseOrderID.Int32, batch.BatchNumber, purchaseOrder.ID)
+	}
+	return ncr, nil
+}
+
+// settleReturnNCR moves a non-conformance report on after a supplier return:
+// it is closed once the returns add up to the quantity it affects, before
+// that the action is in progress
+func settleReturnNCR(ctx context.Context, queries *db.Queries, ncr db.NonConformanceReport, userID int32) (db.NcrStatus, error) {
+	returned, err := queries.GetNcrReturnedQuantity(ctx, pgtype.Int4{Int32: ncr.ID, Valid: true})
+	if err != nil {
+		return "", fmt.Errorf("failed to get returned quantity: %w", err)
+	}
+
+	// The affected quantity is in the report's unit, returns in the material's
+	affected := 0.0
+	if ncr.QuantityAffected.Valid {
+		var unitID *int32
+		if ncr.UnitID.Valid {
+			unitID = &ncr.UnitID.Int32
+		}
+		conv, err := convertToBaseUnit(ctx, queries, ncr.MaterialID.Int32, unitID, numericToFloat(ncr.QuantityAffected))
+		if err != nil {
+			return "", err
+		}
+		affected = conv.baseQuantity(numericToFloat(ncr.QuantityAffected))
+	}
+
+	now := time.Now()
+	params := db.UpdateNonConformanceReportParams{
+		ID:     ncr.ID,
+		Status: db.NullNcrStatus{NcrStatus: db.NcrStatusInProgress, Valid: true},
+	}
+	if numericToFloat(returned) >= affected-0.0001 {
+		params.Status.NcrStatus = db.NcrStatusClosed
+		params.ActionCompletedDate = pgtype.Date{Time: now, Valid: true}
+		params.ClosedBy = pgtype.Int4{Int32: userID, Valid: true}
+		params.ClosedDate = pgtype.Timestamptz{Time: now, Valid: true}
+	}
+
+	if _, err := queries.UpdateNonConformanceReport(ctx, params); err != nil {
+		return "", fmt.Errorf("failed to update non-conformance report: %w", err)
+	}
+	return params.Status.NcrStatus, nil
+}
+
+// =====================================================
+// SUPPLIER RETURN
+// =====================================================
+
+// SupplierReturn - Send stock of a batch received on a purchase order back
+// to the supplier. The returned quantity comes off the order line's received
+// quantity and, when the return settles a non-conformance report, the report
+// is closed once everything it affects has gone back.
+func (th *TransactionHandler) SupplierReturn(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user session from context
+	session, ok := middlewares.GetSessionFromContext(r)
+	if !ok {
+		config.RespondJSON(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized - Authentication required"})
+		return
+	}
+
+	// Parse user ID from session
+	var userID int32
+	_, err := fmt.Sscanf(session.UserID, "%d", &userID)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+		return
+	}
+
+	var req SupplierReturnRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	if req.Quantity <= 0 {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Quantity must be positive"})
+		return
+	}
+
+	batch, err := th.h.Queries.GetBatchByID(ctx, req.BatchID)
+	if err != nil {
+		config.RespondJSON(w, http.StatusNotFound, map[string]string{"error": "Batch not found"})
+		return
+	}
+	materialID, warehouseID := batch.MaterialID.Int32, batch.WarehouseID.Int32
+
+	// Quantities may be entered in any unit that converts to the material's unit
+	conv, err := convertToBaseUnit(ctx, th.h.Queries, materialID, req.UnitID, req.Quantity)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	req.Quantity = conv.baseQuantity(req.Quantity)
+
+	// The batch goes back on the order line it was received on
+	receipt, err := th.h.Queries.GetStockMovementByID(ctx, batch.MovementID.Int32)
+	if err != nil || receipt.MovementType != db.StockMovementTypePURCHASERECEIPT || !receipt.PurchaseOrderItemID.Valid {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("Batch %s was not received against a purchase order", batch.BatchNumber)})
+		return
+	}
+
+	tx, err := th.h.DB.Begin(ctx)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to start transaction"})
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	queries := th.h.Queries.WithTx(tx)
+
+	orderLine, err := queries.GetPurchaseOrderItemByID(ctx, receipt.PurchaseOrderItemID.Int32)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get purchase order line"})
+		return
+	}
+
+	// Lock the order so concurrent receipts and returns see each other
+	purchaseOrder, err := queries.GetPurchaseOrderByIDForUpdate(ctx, orderLine.PurchaseOrderID.Int32)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to lock purchase order"})
+		return
+	}
+
+	// Read the line again under the lock, a receipt may have committed since
+	orderLine, err = queries.GetPurchaseOrderItemByID(ctx, orderLine.ID)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get purchase order line"})
+		return
+	}
+	if purchaseOrder.Status == "Cancelled" {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Cannot return against a cancelled purchase order"})
+		return
+	}
+	if req.Quantity > numericToFloat(orderLine.ReceivedQuantity)+0.0001 {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("Quantity exceeds what was received on order line %d (received: %.2f)", orderLine.ID, numericToFloat(orderLine.ReceivedQuantity))})
+		return
+	}
+
+	var ncr db.NonConformanceReport
+	if req.NcrID != nil {
+		ncr, err = checkReturnNCR(ctx, queries, *req.NcrID, batch, purchaseOrder)
+		if err != nil {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	// Stock on quality hold is what usually goes back, it is not available
+	// stock so only unheld stock has to leave reservations alone
+	holds, err := queries.GetBatchHoldsByIDs(ctx, []int32{batch.ID})
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to check quality holds"})
+		return
+	}
+	reservations := stockReservations{}
+	if len(holds) == 0 {
+		reservations, err = checkStockReservations(ctx, queries, materialID, warehouseID, req.Quantity, 0)
+		if err != nil {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	} else if err := lockStock(ctx, queries, materialID, warehouseID); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to lock stock"})
+		return
+	}
+
+	// Read the batch again now the stock is locked
+	batch, err = queries.GetBatchByID(ctx, batch.ID)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get batch"})
+		return
+	}
+	current := numericToFloat(batch.CurrentQuantity)
+	if req.Quantity > current+0.0001 {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("Batch %s: insufficient quantity (available: %.2f, requested: %.2f)", batch.BatchNumber, current, req.Quantity)})
+		return
+	}
+	if pinned := reservations.pinned[batch.ID]; req.Quantity > current-pinned+0.0001 {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("Batch %s: %.2f is reserved for sales orders (available: %.2f, requested: %.2f)", batch.BatchNumber, pinned, max(current-pinned, 0), req.Quantity)})
+		return
+	}
+
+	serialized, err := checkSerialNumbers(ctx, queries, materialID, req.Quantity, req.SerialNumbers)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	var units []db.SerialNumber
+	if serialized {
+		var allocations []BatchAllocation
+		units, allocations, err = issueSerialNumbers(ctx, queries, materialID, warehouseID, req.SerialNumbers)
+		if err != nil {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		if len(allocations) != 1 || allocations[0].BatchID != batch.ID {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("All serial numbers must be in batch %s", batch.BatchNumber)})
+			return
+		}
+	}
+
+	// Cost the return before the batch is consumed
+	allocations := []BatchAllocation{{BatchID: batch.ID, Quantity: req.Quantity}}
+	unitCost, batchCosts, err := issueUnitCost(ctx, queries, materialID, warehouseID, allocations)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to calculate issue cost"})
+		return
+	}
+	costPerUnit, totalCost := movementCost(req.Quantity, unitCost)
+
+	reference := fmt.Sprintf("RETURN-PO-%d", purchaseOrder.ID)
+	if req.NcrID != nil {
+		reference = ncr.NcrNumber
+	}
+	movement, err := queries.CreateStockMovement(ctx, db.CreateStockMovementParams{
+		MaterialID:          batch.MaterialID,
+		FromWarehouseID:     batch.WarehouseID,
+		Quantity:            decimalFromFloat(req.Quantity),
+		StockDirection:      db.StockDirectionOUT,
+		MovementType:        db.StockMovementTypeSUPPLIERRETURN,
+		Reference:           pgtype.Text{String: reference, Valid: true},
+		PerformedBy:         pgtype.Int4{Int32: userID, Valid: true},
+		MovementDate:        pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		Notes:               pgtype.Text{String: stringValue(req.Notes), Valid: req.Notes != nil && *req.Notes != ""},
+		UnitCost:            costPerUnit,
+		TotalCost:           totalCost,
+		PurchaseOrderItemID: pgtype.Int4{Int32: orderLine.ID, Valid: true},
+		FromBinID:           batch.BinID,
+		EnteredQuantity:     conv.enteredQuantity(),
+		EnteredUnitID:       conv.enteredUnit(),
+		NcrID:               pgtype.Int4{Int32: int32Value(req.NcrID), Valid: req.NcrID != nil},
+	})
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create supplier return movement"})
+		return
+	}
+
+	if _, err := queries.UpdateBatchQuantity(ctx, db.UpdateBatchQuantityParams{
+		ID:              batch.ID,
+		CurrentQuantity: decimalFromFloat(-req.Quantity),
+	}); err != nil {
+		if isNegativeStock(err) {
+			config.RespondJSON(w, http.StatusConflict, map[string]string{"error": fmt.Sprintf("Batch %d does not hold %.2f anymore", batch.ID, req.Quantity)})
+			return
+		}
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to update batch quantity"})
+		return
+	}
+	if err := recordMovementBatch(ctx, queries, movement.ID, batch.ID, req.Quantity, batchCosts[batch.ID]); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to record movement batch"})
+		return
+	}
+
+	for _, u := range units {
+		if err := moveSerialNumber(ctx, queries, movement.ID, u.ID, u.BatchID, pgtype.Int4{}, serialReturned); err != nil {
+			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to update serial number " + u.SerialNumber})
+			return
+		}
+	}
+
+	if err := postCostLedger(ctx, queries, materialID, warehouseID, movement.ID, -req.Quantity, unitCost); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to update cost ledger"})
+		return
+	}
+
+	// Take the return off the order line and update the order status
+	if _, err := queries.UpdatePurchaseOrderItemReceivedQuantity(ctx, db.UpdatePurchaseOrderItemReceivedQuantityParams{
+		ID:               orderLine.ID,
+		ReceivedQuantity: decimalFromFloat(max(numericToFloat(orderLine.ReceivedQuantity)-req.Quantity, 0)),
+	}); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to update received quantity"})
+		return
+	}
+	orderStatus, err := updatePurchaseOrderReceiving(ctx, queries, purchaseOrder)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to update purchase order status"})
+		return
+	}
+	message := fmt.Sprintf("Supplier return recorded successfully, purchase order is %s", orderStatus)
+
+	if req.NcrID != nil {
+		ncrStatus, err := settleReturnNCR(ctx, queries, ncr, userID)
+		if err != nil {
+			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		message = fmt.Sprintf("%s, non-conformance report %s is %s", message, ncr.NcrNumber, ncrStatus)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to commit transaction"})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusCreated, TransactionResponse{
+		Success:    true,
+		Message:    message,
+		MovementID: movement.ID,
+		BatchIDs:   []int32{batch.ID},
+	})
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"math/big"
 	"net/http"
 	"strconv"
@@ -35,64 +37,82 @@ type BatchAllocation struct {
 type OpeningStockRequest struct {
 	MaterialID      int32                  `json:"material_id"`
 	WarehouseID     int32                  `json:"warehouse_id"`
+	BinID           *int32                 `json:"bin_id,omitempty"`
 	Quantity        float64                `json:"quantity"`
+	UnitID          *int32                 `json:"unit_id,omitempty"`
 	UnitPrice       float64                `json:"unit_price"`
 	ManufactureDate *string                `json:"manufacture_date,omitempty"`
 	ExpiryDate      *string                `json:"expiry_date,omitempty"`
 	Notes           *string                `json:"notes,omitempty"`
 	Meta            map[string]interface{} `json:"meta,omitempty"`
+	SerialNumbers   []string               `json:"serial_numbers,omitempty"` // one per unit of a serialized material
 }
 
 type PurchaseReceiptRequest struct {
-	MaterialID      int32                  `json:"material_id"`
-	WarehouseID     int32                  `json:"warehouse_id"`
-	SupplierID      *int32                 `json:"supplier_id,omitempty"`
-	PurchaseOrderID *int32                 `json:"purchase_order_id,omitempty"`
-	Quantity        float64                `json:"quantity"`
-	UnitPrice       float64                `json:"unit_price"`
-	ManufactureDate *string                `json:"manufacture_date,omitempty"`
-	ExpiryDate      *string                `json:"expiry_date,omitempty"`
-	Notes           *string                `json:"notes,omitempty"`
-	Meta            map[string]interface{} `json:"meta,omitempty"`
+	MaterialID          int32                  `json:"material_id"`
+	WarehouseID         int32                  `json:"warehouse_id"`
+	SupplierID          *int32                 `json:"supplier_id,omitempty"`
+	PurchaseOrderID     *int32                 `json:"purchase_order_id,omitempty"`
+	PurchaseOrderItemID *int32                 `json:"purchase_order_item_id,omitempty"`
+	BinID               *int32                 `json:"bin_id,omitempty"`
+	Quantity            float64                `json:"quantity"`
+	UnitID              *int32                 `json:"unit_id,omitempty"`
+	UnitPrice           float64                `json:"unit_price"`
+	ManufactureDate     *string                `json:"manufacture_date,omitempty"`
+	ExpiryDate          *string                `json:"expiry_date,omitempty"`
+	Notes               *string                `json:"notes,omitempty"`
+	Meta                map[string]interface{} `json:"meta,omitempty"`
+	SerialNumbers       []string               `json:"serial_numbers,omitempty"` // one per unit of a serialized material
 }
 
 type SaleRequest struct {
-	SalesOrderID int32             `json:"sales_order_id"`
-	WarehouseID  int32             `json:"warehouse_id"`
-	MaterialID   int32             `json:"material_id"`
-	Quantity     float64           `json:"quantity"`
-	UseManual    bool              `json:"use_manual"`
-	Batches      []BatchAllocation `json:"batches,omitempty"`
+	SalesOrderID     int32             `json:"sales_order_id"`
+	SalesOrderItemID *int32            `json:"sales_order_item_id,omitempty"`
+	WarehouseID      int32             `json:"warehouse_id"`
+	MaterialID       int32             `json:"material_id"`
+	Quantity         float64           `json:"quantity"`
+	UnitID           *int32            `json:"unit_id,omitempty"`
+	UseManual        bool              `json:"use_manual"`
+	Batches          []BatchAllocation `json:"batches,omitempty"`
+	SerialNumbers    []string          `json:"serial_numbers,omitempty"` // select the units shipped, in place of batches
 }
 
 type TransferRequest struct {
 	MaterialID      int32             `json:"material_id"`
 	FromWarehouseID int32             `json:"from_warehouse_id"`
 	ToWarehouseID   int32             `json:"to_warehouse_id"`
+	FromBinID       *int32            `json:"from_bin_id,omitempty"`
+	ToBinID         *int32            `json:"to_bin_id,omitempty"`
 	Quantity        float64           `json:"quantity"`
+	UnitID          *int32            `json:"unit_id,omitempty"`
 	UseManual       bool              `json:"use_manual"`
 	Batches         []BatchAllocation `json:"batches,omitempty"`
 	Notes           *string           `json:"notes,omitempty"`
+	SerialNumbers   []string          `json:"serial_numbers,omitempty"` // select the units transferred, in place of batches
 }
 
 type ScrapRequest struct {
-	MaterialID  int32             `json:"material_id"`
-	WarehouseID int32             `json:"warehouse_id"`
-	Quantity    float64           `json:"quantity"`
-	Reason      string            `json:"reason"`
-	UseManual   bool              `json:"use_manual"`
-	Batches     []BatchAllocation `json:"batches,omitempty"`
+	MaterialID    int32             `json:"material_id"`
+	WarehouseID   int32             `json:"warehouse_id"`
+	Quantity      float64           `json:"quantity"`
+	UnitID        *int32            `json:"unit_id,omitempty"`
+	Reason        string            `json:"reason"`
+	UseManual     bool              `json:"use_manual"`
+	Batches       []BatchAllocation `json:"batches,omitempty"`
+	SerialNumbers []string          `json:"serial_numbers,omitempty"` // select the units scrapped, in place of batches
 }
 
 type AdjustmentRequest struct {
-	MaterialID  int32             `json:"material_id"`
-	WarehouseID int32             `json:"warehouse_id"`
-	Quantity    float64           `json:"quantity"`
-	Direction   string            `json:"direction"`
-	Reason      string            `json:"reason"`
-	UnitPrice   *float64          `json:"unit_price,omitempty"`
-	UseManual   bool              `json:"use_manual"`
-	Batches     []BatchAllocation `json:"batches,omitempty"`
+	MaterialID    int32             `json:"material_id"`
+	WarehouseID   int32             `json:"warehouse_id"`
+	Quantity      float64           `json:"quantity"`
+	UnitID        *int32            `json:"unit_id,omitempty"`
+	Direction     string            `json:"direction"`
+	Reason        string            `json:"reason"`
+	UnitPrice     *float64          `json:"unit_price,omitempty"`
+	UseManual     bool              `json:"use_manual"`
+	Batches       []BatchAllocation `json:"batches,omitempty"`
+	SerialNumbers []string          `json:"serial_numbers,omitempty"` // units found (IN) or missing (OUT) of a serialized material
 }
 
 type TransactionResponse struct {
@@ -134,12 +154,13 @@ func parseDate(dateStr *string) pgtype.Date {
 	return pgtype.Date{Time: t, Valid: true}
 }
 
-// decimalFromFloat converts float64 to pgtype.Numeric without manual scaling
-// PostgreSQL NUMERIC type handles decimals natively
+// decimalFromFloat converts float64 to pgtype.Numeric rounded to 4 decimals,
+// the scale of the DECIMAL(15,4) quantity and price columns. Quantities and
+// prices converted from another unit need all 4 of them.
 func decimalFromFloat(f float64) pgtype.Numeric {
 	return pgtype.Numeric{
-		Int:   new(big.Int).SetInt64(int64(f * 100)),
-		Exp:   -2,
+		Int:   new(big.Int).SetInt64(int64(math.Round(f * 10000))),
+		Exp:   -4,
 		Valid: true,
 	}
 }
@@ -208,6 +229,15 @@ func (th *TransactionHandler) OpeningStock(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	// Quantities may be entered in any unit that converts to the material's unit
+	conv, err := convertToBaseUnit(ctx, th.h.Queries, req.MaterialID, req.UnitID, req.Quantity)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	req.Quantity = conv.baseQuantity(req.Quantity)
+	req.UnitPrice = conv.basePrice(req.UnitPrice)
+
 	tx, err := th.h.DB.Begin(ctx)
 	if err != nil {
 		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to start transaction"})
@@ -217,6 +247,11 @@ func (th *TransactionHandler) OpeningStock(w http.ResponseWriter, r *http.Reques
 
 	queries := th.h.Queries.WithTx(tx)
 
+	if err := checkBinPutaway(ctx, queries, req.BinID, req.WarehouseID, req.Quantity); err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
 	exists, err := queries.CheckOpeningStockExists(ctx, pgtype.Int4{Int32: req.MaterialID, Valid: true})
 	if err != nil {
 		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to check existing opening stock"})
@@ -228,13 +263,20 @@ func (th *TransactionHandler) OpeningStock(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	serialized, err := checkSerialNumbers(ctx, queries, req.MaterialID, req.Quantity, req.SerialNumbers)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
 	batchNumber, err := generateBatchNumber(ctx, queries, req.MaterialID, "open")
 	if err != nil {
 		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to generate batch number"})
 		return
 	}
 
-	// Create stock movement
+	// Create stock movement, received at the opening unit price
+	unitCost, totalCost := movementCost(req.Quantity, req.UnitPrice)
 	movement, err := queries.CreateStockMovement(ctx, db.CreateStockMovementParams{
 		MaterialID:      pgtype.Int4{Int32: req.MaterialID, Valid: true},
 		FromWarehouseID: pgtype.Int4{Valid: false},
@@ -246,6 +288,11 @@ func (th *TransactionHandler) OpeningStock(w http.ResponseWriter, r *http.Reques
 		PerformedBy:     pgtype.Int4{Int32: userID, Valid: true},
 		MovementDate:    pgtype.Timestamptz{Time: time.Now(), Valid: true},
 		Notes:           pgtype.Text{String: stringValue(req.Notes), Valid: req.Notes != nil && *req.Notes != ""},
+		UnitCost:        unitCost,
+		TotalCost:       totalCost,
+		ToBinID:         binParam(req.BinID),
+		EnteredQuantity: conv.enteredQuantity(),
+		EnteredUnitID:   conv.enteredUnit(),
 	})
 	if err != nil {
 		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create stock movement"})
@@ -272,12 +319,30 @@ func (th *TransactionHandler) OpeningStock(w http.ResponseWriter, r *http.Reques
 		CurrentQuantity: decimalFromFloat(req.Quantity),
 		Notes:           pgtype.Text{String: stringValue(req.Notes), Valid: req.Notes != nil && *req.Notes != ""},
 		Meta:            metaJSON,
+		BinID:           binParam(req.BinID),
 	})
 	if err != nil {
 		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create batch"})
 		return
 	}
 
+	if err := recordMovementBatch(ctx, queries, movement.ID, batch.ID, req.Quantity, req.UnitPrice); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to record movement batch"})
+		return
+	}
+
+	if serialized {
+		if err := receiveSerialNumbers(ctx, queries, req.MaterialID, req.WarehouseID, batch.ID, movement.ID, req.SerialNumbers); err != nil {
+			config.RespondJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	if err := postCostLedger(ctx, queries, req.MaterialID, req.WarehouseID, movement.ID, req.Quantity, req.UnitPrice); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to update cost ledger"})
+		return
+	}
+
 	if err := tx.Commit(ctx); err != nil {
 		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to commit transaction"})
 		return
@@ -292,73 +357,189 @@ func (th *TransactionHandler) OpeningStock(w http.ResponseWriter, r *http.Reques
 }
 
 //////////////////////////////////////////////////////
-// PURCHASE RECEIPT HANDLER
+// PURCHASE ORDER RECEIVING
 //////////////////////////////////////////////////////
 
-func (th *TransactionHandler) PurchaseReceipt(w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
+// findPurchaseOrderLine picks the order line a receipt posts against: the
+// requested line, or the first line of the material still awaiting delivery
+func findPurchaseOrderLine(items []db.PurchaseOrderItem, materialID int32, itemID *int32) (db.PurchaseOrderItem, error) {
+	if itemID != nil {
+		for _, item := range items {
+			if item.ID != *itemID {
+				continue
+			}
+			if !item.MaterialID.Valid || item.MaterialID.Int32 != materialID {
+				return db.PurchaseOrderItem{}, fmt.Errorf("purchase order line %d is not for material %d", item.ID, materialID)
+			}
+			return item, nil
+		}
+		return db.PurchaseOrderItem{}, fmt.Errorf("purchase order line %d not found on this order", *itemID)
+	}
 
-	// Get user session from context
-	session, ok := middlewares.GetSessionFromContext(r)
-	if !ok {
-		config.RespondJSON(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized - Authentication required"})
-		return
+	var lastLine *db.PurchaseOrderItem
+	for i, item := range items {
+		if !item.MaterialID.Valid || item.MaterialID.Int32 != materialID {
+			continue
+		}
+		if numericToFloat(item.Quantity)-numericToFloat(item.ReceivedQuantity) > 0.0001 {
+			return item, nil
+		}
+		lastLine = &items[i]
 	}
 
-	// Parse user ID from session
-	var userID int32
-	_, err := fmt.Sscanf(session.UserID, "%d", &userID)
+	// Every line is fully received, over-receipt tolerance may still apply
+	if lastLine != nil {
+		return *lastLine, nil
+	}
+	return db.PurchaseOrderItem{}, fmt.Errorf("material %d is not on this purchase order", materialID)
+}
+
+// updatePurchaseOrderReceiving moves the order to PartiallyReceived or
+// Received based on the received quantities of its lines, and back to
+// Approved when a reversal leaves nothing received
+func updatePurchaseOrderReceiving(ctx context.Context, queries *db.Queries, purchaseOrder db.PurchaseOrder) (string, error) {
+	items, err := queries.ListPurchaseOrderItems(ctx, pgtype.Int4{Int32: purchaseOrder.ID, Valid: true})
 	if err != nil {
-		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
-		return
+		return "", fmt.Errorf("failed to get purchase order items: %w", err)
 	}
 
-	var req PurchaseReceiptRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
-		return
+	allReceived, anyReceived := true, false
+	for _, item := range items {
+		received := numericToFloat(item.ReceivedQuantity)
+		if received > 0 {
+			anyReceived = true
+		}
+		if received < numericToFloat(item.Quantity)-0.0001 {
+			allReceived = false
+		}
+	}
+
+	status := purchaseOrder.Status
+	switch {
+	case anyReceived && allReceived:
+		status = "Received"
+	case anyReceived:
+		status = "PartiallyReceived"
+	case status == "PartiallyReceived" || status == "Received":
+		status = "Approved"
 	}
 
+	if status == purchaseOrder.Status {
+		return status, nil
+	}
+
+	if _, err := queries.UpdatePurchaseOrderStatus(ctx, db.UpdatePurchaseOrderStatusParams{
+		ID:     purchaseOrder.ID,
+		Status: status,
+	}); err != nil {
+		return "", fmt.Errorf("failed to update purchase order status: %w", err)
+	}
+	return status, nil
+}
+
+//////////////////////////////////////////////////////
+// PURCHASE RECEIPT HANDLER
+//////////////////////////////////////////////////////
+
+// postPurchaseReceipt receives stock into a new batch, against a purchase
+// order line when the request names an order. The posting functions work in
+// the caller's transaction and return the HTTP status to respond with, so
+// the handlers and the bulk importer post the same way.
+func (th *TransactionHandler) postPurchaseReceipt(ctx context.Context, queries *db.Queries, req PurchaseReceiptRequest, userID int32) (TransactionResponse, int, error) {
 	if req.Quantity <= 0 {
-		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Quantity must be positive"})
-		return
+		return TransactionResponse{}, http.StatusBadRequest, errors.New("Quantity must be positive")
 	}
 
-	tx, err := th.h.DB.Begin(ctx)
+	// Quantities may be entered in any unit that converts to the material's unit
+	conv, err := convertToBaseUnit(ctx, queries, req.MaterialID, req.UnitID, req.Quantity)
 	if err != nil {
-		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to start transaction"})
-		return
+		return TransactionResponse{}, http.StatusBadRequest, err
 	}
-	defer tx.Rollback(ctx)
+	req.Quantity = conv.baseQuantity(req.Quantity)
+	req.UnitPrice = conv.basePrice(req.UnitPrice)
 
-	queries := th.h.Queries.WithTx(tx)
+	// Receipts against a purchase order post to one of its lines
+	var purchaseOrder *db.PurchaseOrder
+	var orderLine db.PurchaseOrderItem
+	if req.PurchaseOrderID != nil && *req.PurchaseOrderID != 0 {
+		// Lock the order so concurrent receipts cannot over-receive a line
+		po, err := queries.GetPurchaseOrderByIDForUpdate(ctx, *req.PurchaseOrderID)
+		if err != nil {
+			return TransactionResponse{}, http.StatusNotFound, errors.New("Purchase order not found")
+		}
+		purchaseOrder = &po
+
+		if po.Status == "Cancelled" {
+			return TransactionResponse{}, http.StatusBadRequest, errors.New("Cannot receive against a cancelled purchase order")
+		}
+
+		orderItems, err := queries.ListPurchaseOrderItems(ctx, pgtype.Int4{Int32: po.ID, Valid: true})
+		if err != nil {
+			return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to get purchase order items")
+		}
+
+		orderLine, err = findPurchaseOrderLine(orderItems, req.MaterialID, req.PurchaseOrderItemID)
+		if err != nil {
+			return TransactionResponse{}, http.StatusBadRequest, err
+		}
+
+		tolerance := th.h.CFG.Inventory.OverReceiptTolerancePercent
+		allowed := numericToFloat(orderLine.Quantity)*(1+tolerance/100) - numericToFloat(orderLine.ReceivedQuantity)
+		if req.Quantity > allowed+0.0001 {
+			return TransactionResponse{}, http.StatusBadRequest, fmt.Errorf("Quantity exceeds what may still be received on order line %d (remaining: %.2f, over-receipt tolerance: %.2f%%)", orderLine.ID, math.Max(allowed, 0), tolerance)
+		}
+
+		// Default the price and supplier from the order
+		if req.UnitPrice == 0 {
+			req.UnitPrice = numericToFloat(orderLine.UnitPrice)
+		}
+		if (req.SupplierID == nil || *req.SupplierID == 0) && po.SupplierID.Valid {
+			req.SupplierID = &po.SupplierID.Int32
+		}
+	} else if req.PurchaseOrderItemID != nil {
+		return TransactionResponse{}, http.StatusBadRequest, errors.New("purchase_order_id is required with purchase_order_item_id")
+	}
+
+	if err := checkBinPutaway(ctx, queries, req.BinID, req.WarehouseID, req.Quantity); err != nil {
+		return TransactionResponse{}, http.StatusBadRequest, err
+	}
+
+	serialized, err := checkSerialNumbers(ctx, queries, req.MaterialID, req.Quantity, req.SerialNumbers)
+	if err != nil {
+		return TransactionResponse{}, http.StatusBadRequest, err
+	}
 
 	batchNumber, err := generateBatchNumber(ctx, queries, req.MaterialID, "purchase")
 	if err != nil {
-		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to generate batch number"})
-		return
+		return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to generate batch number")
 	}
 
 	// Create stock movement
 	var poRef string
-	if req.PurchaseOrderID != nil && *req.PurchaseOrderID != 0 {
-		poRef = fmt.Sprintf("PO-%d", *req.PurchaseOrderID)
+	if purchaseOrder != nil {
+		poRef = fmt.Sprintf("PO-%d", purchaseOrder.ID)
 	}
+	unitCost, totalCost := movementCost(req.Quantity, req.UnitPrice)
 	movement, err := queries.CreateStockMovement(ctx, db.CreateStockMovementParams{
-		MaterialID:      pgtype.Int4{Int32: req.MaterialID, Valid: true},
-		FromWarehouseID: pgtype.Int4{Valid: false},
-		ToWarehouseID:   pgtype.Int4{Int32: req.WarehouseID, Valid: true},
-		Quantity:        decimalFromFloat(req.Quantity),
-		StockDirection:  db.StockDirectionIN,
-		MovementType:    db.StockMovementTypePURCHASERECEIPT,
-		Reference:       pgtype.Text{String: poRef, Valid: poRef != ""},
-		PerformedBy:     pgtype.Int4{Int32: userID, Valid: true},
-		MovementDate:    pgtype.Timestamptz{Time: time.Now(), Valid: true},
-		Notes:           pgtype.Text{String: stringValue(req.Notes), Valid: req.Notes != nil && *req.Notes != ""},
+		MaterialID:          pgtype.Int4{Int32: req.MaterialID, Valid: true},
+		FromWarehouseID:     pgtype.Int4{Valid: false},
+		ToWarehouseID:       pgtype.Int4{Int32: req.WarehouseID, Valid: true},
+		Quantity:            decimalFromFloat(req.Quantity),
+		StockDirection:      db.StockDirectionIN,
+		MovementType:        db.StockMovementTypePURCHASERECEIPT,
+		Reference:           pgtype.Text{String: poRef, Valid: poRef != ""},
+		PerformedBy:         pgtype.Int4{Int32: userID, Valid: true},
+		MovementDate:        pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		Notes:               pgtype.Text{String: stringValue(req.Notes), Valid: req.Notes != nil && *req.Notes != ""},
+		UnitCost:            unitCost,
+		TotalCost:           totalCost,
+		PurchaseOrderItemID: pgtype.Int4{Int32: orderLine.ID, Valid: purchaseOrder != nil},
+		ToBinID:             binParam(req.BinID),
+		EnteredQuantity:     conv.enteredQuantity(),
+		EnteredUnitID:       conv.enteredUnit(),
 	})
 	if err != nil {
-		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create stock movement"})
-		return
+		return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to create stock movement")
 	}
 
 	// Prepare metadata
@@ -381,9 +562,114 @@ func (th *TransactionHandler) PurchaseReceipt(w http.ResponseWriter, r *http.Req
 		CurrentQuantity: decimalFromFloat(req.Quantity),
 		Notes:           pgtype.Text{String: stringValue(req.Notes), Valid: req.Notes != nil && *req.Notes != ""},
 		Meta:            metaJSON,
+		BinID:           binParam(req.BinID),
 	})
 	if err != nil {
-		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create batch"})
+		return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to create batch")
+	}
+
+	if err := recordMovementBatch(ctx, queries, movement.ID, batch.ID, req.Quantity, req.UnitPrice); err != nil {
+		return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to record movement batch")
+	}
+
+	if serialized {
+		if err := receiveSerialNumbers(ctx, queries, req.MaterialID, req.WarehouseID, batch.ID, movement.ID, req.SerialNumbers); err != nil {
+			return TransactionResponse{}, http.StatusConflict, err
+		}
+	}
+
+	if err := postCostLedger(ctx, queries, req.MaterialID, req.WarehouseID, movement.ID, req.Quantity, req.UnitPrice); err != nil {
+		return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to update cost ledger")
+	}
+
+	// Materials with required quality specs wait in quarantine for their
+	// incoming inspection
+	specs, err := queries.CountRequiredMaterialQualitySpecs(ctx, req.MaterialID)
+	if err != nil {
+		return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to get material quality specs")
+	}
+	var inspection *db.QualityInspection
+	if specs > 0 {
+		params := db.CreateQualityInspectionParams{
+			InspectionType:  db.QualityInspectionTypeIncoming,
+			StockMovementID: pgtype.Int4{Int32: movement.ID, Valid: true},
+			SupplierID:      batch.SupplierID,
+			Notes:           pgtype.Text{String: fmt.Sprintf("Incoming inspection of batch %s", batch.BatchNumber), Valid: true},
+		}
+		if purchaseOrder != nil {
+			params.PurchaseOrderID = pgtype.Int4{Int32: purchaseOrder.ID, Valid: true}
+		}
+		created, err := quarantineBatch(ctx, queries, batch, params, userID)
+		if err != nil {
+			return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to open incoming inspection")
+		}
+		inspection = &created
+	}
+
+	message := "Purchase receipt recorded successfully"
+	if purchaseOrder != nil {
+		// Record the receipt on the order line and update the order status
+		if _, err := queries.UpdatePurchaseOrderItemReceivedQuantity(ctx, db.UpdatePurchaseOrderItemReceivedQuantityParams{
+			ID:               orderLine.ID,
+			ReceivedQuantity: decimalFromFloat(numericToFloat(orderLine.ReceivedQuantity) + req.Quantity),
+		}); err != nil {
+			return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to update received quantity")
+		}
+
+		orderStatus, err := updatePurchaseOrderReceiving(ctx, queries, *purchaseOrder)
+		if err != nil {
+			return TransactionResponse{}, http.StatusInternalServerError, errors.New("Failed to update purchase order status")
+		}
+		message = fmt.Sprintf("Purchase receipt recorded successfully, purchase order is %s", orderStatus)
+	}
+	if inspection != nil {
+		message = fmt.Sprintf("%s, batch %s is quarantined for incoming inspection %s", message, batch.BatchNumber, inspection.InspectionNumber)
+	}
+
+	return TransactionResponse{
+		Success:    true,
+		Message:    message,
+		MovementID: movement.ID,
+		BatchIDs:   []int32{batch.ID},
+	}, http.StatusCreated, nil
+}
+
+func (th *TransactionHandler) PurchaseReceipt(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user session from context
+	session, ok := middlewares.GetSessionFromContext(r)
+	if !ok {
+		config.RespondJSON(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized - Authentication required"})
+		return
+	}
+
+	// Parse user ID from session
+	var userID int32
+	_, err := fmt.Sscanf(session.UserID, "%d", &userID)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+		return
+	}
+
+	var req PurchaseReceiptRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	tx, err := th.h.DB.Begin(ctx)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to start transaction"})
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	queries := th.h.Queries.WithTx(tx)
+
+	response, status, err := th.postPurchaseReceipt(ctx, queries, req, userID)
+	if err != nil {
+		config.RespondJSON(w, status, map[string]string{"error": err.Error()})
 		return
 	}
 
@@ -392,10 +678,5 @@ func (th *TransactionHandler) PurchaseReceipt(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	config.RespondJSON(w, http.StatusCreated, TransactionResponse{
-		Success:    true,
-		Message:    "Purchase receipt recorded successfully",
-		MovementID: movement.ID,
-		BatchIDs:   []int32{batch.ID},
-	})
+	config.RespondJSON(w, status, response)
 }
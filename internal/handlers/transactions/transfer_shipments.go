@@ -0,0 +1,759 @@
+package transactions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"warehouse_system/internal/config"
+	db "warehouse_system/internal/database/db"
+	"warehouse_system/internal/middlewares"
+)
+
+// =====================================================
+// TRANSFER SHIPMENT REQUEST TYPES
+// =====================================================
+
+type ShipTransferRequest struct {
+	MaterialID      int32             `json:"material_id"`
+	FromWarehouseID int32             `json:"from_warehouse_id"`
+	ToWarehouseID   int32             `json:"to_warehouse_id"`
+	FromBinID       *int32            `json:"from_bin_id,omitempty"`
+	ToBinID         *int32            `json:"to_bin_id,omitempty"`
+	Quantity        float64           `json:"quantity"`
+	UnitID          *int32            `json:"unit_id,omitempty"`
+	UseManual       bool              `json:"use_manual"`
+	Batches         []BatchAllocation `json:"batches,omitempty"`
+	ExpectedDate    *string           `json:"expected_date,omitempty"`
+	Notes           *string           `json:"notes,omitempty"`
+	SerialNumbers   []string          `json:"serial_numbers,omitempty"` // select the units shipped, in place of batches
+}
+
+// ReceiveTransferRequest records what arrived of a shipment. Batches lists
+// the quantity received of each shipped source batch, batches left out did
+// not arrive. Without batches, ReceivedQuantity is the total received and a
+// shortage is taken from the last shipped batches. Without either the whole
+// shipment arrived. A serialized material is received by SerialNumbers, the
+// units that arrived, instead.
+type ReceiveTransferRequest struct {
+	ReceivedQuantity  *float64          `json:"received_quantity,omitempty"`
+	UnitID            *int32            `json:"unit_id,omitempty"`
+	Batches           []BatchAllocation `json:"batches,omitempty"`
+	ToBinID           *int32            `json:"to_bin_id,omitempty"` // default the bin chosen when shipping
+	DiscrepancyReason *string           `json:"discrepancy_reason,omitempty"`
+	Notes             *string           `json:"notes,omitempty"`
+	SerialNumbers     []string          `json:"serial_numbers,omitempty"`
+}
+
+type TransferShipmentResponse struct {
+	db.TransferShipment
+	Batches   []db.GetTransferShipmentBatchesRow    `json:"batches"`
+	Movements []db.ListTransferShipmentMovementsRow `json:"movements"`
+}
+
+// =====================================================
+// TRANSFER SHIPMENT HELPERS
+// =====================================================
+
+func parseTransferShipmentID(r *http.Request) (int32, error) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return 0, err
+	}
+	return int32(id), nil
+}
+
+// receivedPerBatch spreads what arrived of a shipment over its source
+// batches, see ReceiveTransferRequest
+func receivedPerBatch(lines []db.GetTransferShipmentBatchesRow, batches []BatchAllocation, received *float64) ([]float64, error) {
+	quantities := make([]float64, len(lines))
+
+	if len(batches) > 0 {
+		index := make(map[int32]int, len(lines))
+		for i, l := range lines {
+			index[l.BatchID] = i
+		}
+		for _, b := range batches {
+			i, ok := index[b.BatchID]
+			if !ok {
+				return nil, fmt.Errorf("batch %d was not shipped with this transfer", b.BatchID)
+			}
+			if b.Quantity < 0 {
+				return nil, fmt.Errorf("received quantity of batch %d cannot be negative", b.BatchID)
+			}
+			quantities[i] += b.Quantity
+			if shipped := numericToFloat(lines[i].Quantity); quantities[i] > shipped+0.0001 {
+				return nil, fmt.Errorf("batch %s: received %.2f, only %.2f was shipped", lines[i].BatchNumber, quantities[i], shipped)
+			}
+		}
+		return quantities, nil
+	}
+
+	shipped := 0.0
+	for i, l := range lines {
+		quantities[i] = numericToFloat(l.Quantity)
+		shipped += quantities[i]
+	}
+	if received == nil {
+		return quantities, nil
+	}
+	if *received < 0 {
+		return nil, fmt.Errorf("received quantity cannot be negative")
+	}
+	if *received > shipped+0.0001 {
+		return nil, fmt.Errorf("received %.2f, only %.2f was shipped", *received, shipped)
+	}
+
+	// The shortage comes off the last shipped batches first
+	shortage := shipped - *received
+	for i := len(quantities) - 1; i >= 0 && shortage > 0.0001; i-- {
+		take := min(quantities[i], shortage)
+		quantities[i] -= take
+		shortage -= take
+	}
+	return quantities, nil
+}
+
+// respondTransferShipment responds with a shipment, its batches and movements
+func (th *TransactionHandler) respondTransferShipment(w http.ResponseWriter, ctx context.Context, status int, shipment db.TransferShipment) {
+	shipmentID := pgtype.Int4{Int32: shipment.ID, Valid: true}
+
+	batches, err := th.h.Queries.GetTransferShipmentBatches(ctx, shipmentID)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get shipment batches"})
+		return
+	}
+	movements, err := th.h.Queries.ListTransferShipmentMovements(ctx, shipmentID)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get shipment movements"})
+		return
+	}
+
+	config.RespondJSON(w, status, TransferShipmentResponse{TransferShipment: shipment, Batches: batches, Movements: movements})
+}
+
+// =====================================================
+// TRANSFER SHIPMENT HANDLERS
+// =====================================================
+
+// ShipTransfer - Ship stock to another warehouse. The source batches are
+// consumed now, the stock is in transit to the destination until it is
+// received there.
+func (th *TransactionHandler) ShipTransfer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user session from context
+	session, ok := middlewares.GetSessionFromContext(r)
+	if !ok {
+		config.RespondJSON(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized - Authentication required"})
+		return
+	}
+
+	// Parse user ID from session
+	var userID int32
+	_, err := fmt.Sscanf(session.UserID, "%d", &userID)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+		return
+	}
+
+	var req ShipTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	if req.Quantity <= 0 {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Quantity must be positive"})
+		return
+	}
+
+	// Stock moving inside one warehouse is never in transit
+	if req.FromWarehouseID == req.ToWarehouseID {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Source and destination must be different warehouses"})
+		return
+	}
+
+	// Quantities may be entered in any unit that converts to the material's unit
+	conv, err := convertToBaseUnit(ctx, th.h.Queries, req.MaterialID, req.UnitID, req.Quantity)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	req.Quantity = conv.baseQuantity(req.Quantity)
+	req.Batches = conv.baseAllocations(req.Batches)
+
+	tx, err := th.h.DB.Begin(ctx)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to start transaction"})
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	queries := th.h.Queries.WithTx(tx)
+
+	if req.FromBinID != nil && *req.FromBinID != 0 {
+		if _, err := checkBin(ctx, queries, *req.FromBinID, req.FromWarehouseID); err != nil {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	// The capacity of the destination bin is checked when the stock arrives
+	if req.ToBinID != nil && *req.ToBinID != 0 {
+		if _, err := checkBin(ctx, queries, *req.ToBinID, req.ToWarehouseID); err != nil {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	// Stock reserved for sales orders stays where it is
+	reservations, err := checkStockReservations(ctx, queries, req.MaterialID, req.FromWarehouseID, req.Quantity, 0)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	serialized, err := checkSerialNumbers(ctx, queries, req.MaterialID, req.Quantity, req.SerialNumbers)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	// Get batch allocations for the shipment, the units of a serialized
+	// material leave the batches holding them
+	var allocations []BatchAllocation
+	var units []db.SerialNumber
+	if serialized {
+		units, allocations, err = issueSerialNumbers(ctx, queries, req.MaterialID, req.FromWarehouseID, req.SerialNumbers)
+		if err != nil {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		if err := validateBatchAllocations(ctx, queries, allocations, req.Quantity, reservations); err != nil {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		if req.FromBinID != nil && *req.FromBinID != 0 {
+			if err := checkBatchesInBin(ctx, queries, allocations, *req.FromBinID); err != nil {
+				config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+		}
+	} else if req.UseManual {
+		if err := validateBatchAllocations(ctx, queries, req.Batches, req.Quantity, reservations); err != nil {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		if req.FromBinID != nil && *req.FromBinID != 0 {
+			if err := checkBatchesInBin(ctx, queries, req.Batches, *req.FromBinID); err != nil {
+				config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+		}
+		allocations = req.Batches
+	} else {
+		strategy, err := getAllocationStrategy(ctx, queries, req.MaterialID, req.FromWarehouseID)
+		if err != nil {
+			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get allocation strategy"})
+			return
+		}
+
+		allocations, err = allocateBatchesAuto(ctx, queries, req.MaterialID, req.FromWarehouseID, int32Value(req.FromBinID), req.Quantity, strategy, reservations)
+		if err != nil {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	// Cost the issue before the batches are consumed
+	unitCost, batchCosts, err := issueUnitCost(ctx, queries, req.MaterialID, req.FromWarehouseID, allocations)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to calculate issue cost"})
+		return
+	}
+	costPerUnit, totalCost := movementCost(req.Quantity, unitCost)
+
+	var notes pgtype.Text
+	if req.Notes != nil {
+		notes = pgtype.Text{String: *req.Notes, Valid: true}
+	}
+
+	// The shipment number is set from a sequence on insert
+	shipment, err := queries.CreateTransferShipment(ctx, db.CreateTransferShipmentParams{
+		MaterialID:      req.MaterialID,
+		FromWarehouseID: req.FromWarehouseID,
+		ToWarehouseID:   req.ToWarehouseID,
+		FromBinID:       binParam(req.FromBinID),
+		ToBinID:         binParam(req.ToBinID),
+		Quantity:        decimalFromFloat(req.Quantity),
+		UnitCost:        costPerUnit,
+		ExpectedDate:    parseDate(req.ExpectedDate),
+		Notes:           notes,
+		ShippedBy:       pgtype.Int4{Int32: userID, Valid: true},
+	})
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create transfer shipment"})
+		return
+	}
+
+	movement, err := queries.CreateStockMovement(ctx, db.CreateStockMovementParams{
+		MaterialID:         pgtype.Int4{Int32: req.MaterialID, Valid: true},
+		FromWarehouseID:    pgtype.Int4{Int32: req.FromWarehouseID, Valid: true},
+		ToWarehouseID:      pgtype.Int4{Int32: req.ToWarehouseID, Valid: true},
+		Quantity:           decimalFromFloat(req.Quantity),
+		StockDirection:     db.StockDirectionOUT,
+		MovementType:       db.StockMovementTypeTRANSFEROUT,
+		Reference:          pgtype.Text{String: shipment.ShipmentNumber, Valid: true},
+		PerformedBy:        pgtype.Int4{Int32: userID, Valid: true},
+		MovementDate:       pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		Notes:              notes,
+		UnitCost:           costPerUnit,
+		TotalCost:          totalCost,
+		FromBinID:          binParam(req.FromBinID),
+		ToBinID:            binParam(req.ToBinID),
+		EnteredQuantity:    conv.enteredQuantity(),
+		EnteredUnitID:      conv.enteredUnit(),
+		TransferShipmentID: pgtype.Int4{Int32: shipment.ID, Valid: true},
+	})
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create transfer out movement"})
+		return
+	}
+
+	// Update source batches
+	for _, alloc := range allocations {
+		_, err := queries.UpdateBatchQuantity(ctx, db.UpdateBatchQuantityParams{
+			ID:              alloc.BatchID,
+			CurrentQuantity: decimalFromFloat(-alloc.Quantity),
+		})
+		if err != nil {
+			if isNegativeStock(err) {
+				config.RespondJSON(w, http.StatusConflict, map[string]string{"error": fmt.Sprintf("Batch %d does not hold %.2f anymore", alloc.BatchID, alloc.Quantity)})
+				return
+			}
+			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to update source batch"})
+			return
+		}
+		if err := recordMovementBatch(ctx, queries, movement.ID, alloc.BatchID, alloc.Quantity, batchCosts[alloc.BatchID]); err != nil {
+			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to record movement batch"})
+			return
+		}
+	}
+
+	// The units are in transit until the shipment is received
+	for _, u := range units {
+		if err := moveSerialNumber(ctx, queries, movement.ID, u.ID, u.BatchID, pgtype.Int4{}, serialInTransit); err != nil {
+			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to record serial numbers"})
+			return
+		}
+	}
+
+	// The value leaves the source now and reaches the destination on receipt
+	if err := postCostLedger(ctx, queries, req.MaterialID, req.FromWarehouseID, movement.ID, -req.Quantity, unitCost); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to update cost ledger"})
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to commit transaction"})
+		return
+	}
+
+	th.respondTransferShipment(w, ctx, http.StatusCreated, shipment)
+}
+
+// ReceiveTransfer - Receive a shipment at its destination. Each shipped
+// batch that arrived becomes a new batch there, what did not arrive is the
+// discrepancy of the shipment, needs a reason and is posted as a
+// TRANSFER_LOSS movement.
+func (th *TransactionHandler) ReceiveTransfer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user session from context
+	session, ok := middlewares.GetSessionFromContext(r)
+	if !ok {
+		config.RespondJSON(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized - Authentication required"})
+		return
+	}
+
+	// Parse user ID from session
+	var userID int32
+	_, err := fmt.Sscanf(session.UserID, "%d", &userID)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+		return
+	}
+
+	id, err := parseTransferShipmentID(r)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid transfer shipment ID"})
+		return
+	}
+
+	var req ReceiveTransferRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+			return
+		}
+	}
+
+	if len(req.SerialNumbers) > 0 && (req.ReceivedQuantity != nil || len(req.Batches) > 0) {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "serial_numbers cannot be combined with received_quantity or batches"})
+		return
+	}
+
+	tx, err := th.h.DB.Begin(ctx)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to start transaction"})
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	queries := th.h.Queries.WithTx(tx)
+
+	shipment, err := queries.GetTransferShipmentByIDForUpdate(ctx, id)
+	if err != nil {
+		config.RespondJSON(w, http.StatusNotFound, map[string]string{"error": "Transfer shipment not found"})
+		return
+	}
+	if shipment.Status != "In Transit" {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("transfer shipment %s is %s", shipment.ShipmentNumber, shipment.Status)})
+		return
+	}
+
+	// Quantities may be entered in any unit that converts to the material's unit
+	conv := unitConversion{factor: 1}
+	if req.ReceivedQuantity != nil || len(req.Batches) > 0 {
+		quantity := 0.0
+		if len(req.Batches) > 0 {
+			for _, b := range req.Batches {
+				quantity += b.Quantity
+			}
+		} else {
+			quantity = *req.ReceivedQuantity
+		}
+		conv, err = convertToBaseUnit(ctx, queries, shipment.MaterialID, req.UnitID, quantity)
+		if err != nil {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		if req.ReceivedQuantity != nil {
+			received := conv.baseQuantity(*req.ReceivedQuantity)
+			req.ReceivedQuantity = &received
+		}
+		req.Batches = conv.baseAllocations(req.Batches)
+	}
+
+	lines, err := queries.GetTransferShipmentBatches(ctx, pgtype.Int4{Int32: shipment.ID, Valid: true})
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get shipment batches"})
+		return
+	}
+
+	// The serial numbers that arrived decide what arrived of each batch
+	var serials []db.ListMovementSerialsRow
+	if len(lines) > 0 {
+		serials, err = queries.ListMovementSerials(ctx, []int32{lines[0].MovementID})
+		if err != nil {
+			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get shipment serial numbers"})
+			return
+		}
+	}
+	arrived := make(map[int32]bool, len(serials))
+	if len(req.SerialNumbers) > 0 {
+		if _, err := checkSerialNumbers(ctx, queries, shipment.MaterialID, float64(len(req.SerialNumbers)), req.SerialNumbers); err != nil {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+
+		shippedSerials := make(map[string]db.ListMovementSerialsRow, len(serials))
+		for _, sn := range serials {
+			shippedSerials[sn.SerialNumber] = sn
+		}
+		perBatch := make(map[int32]float64)
+		for _, number := range req.SerialNumbers {
+			sn, ok := shippedSerials[number]
+			if !ok {
+				config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("serial number %s was not shipped with this transfer", number)})
+				return
+			}
+			arrived[sn.SerialNumberID] = true
+			perBatch[sn.BatchID.Int32]++
+		}
+		for _, l := range lines {
+			if q := perBatch[l.BatchID]; q > 0 {
+				req.Batches = append(req.Batches, BatchAllocation{BatchID: l.BatchID, Quantity: q})
+			}
+		}
+	} else if len(serials) > 0 {
+		// Without serial numbers the whole shipment arrived, or none of it
+		partial := len(req.Batches) > 0 || (req.ReceivedQuantity != nil && *req.ReceivedQuantity > 0.0001 && *req.ReceivedQuantity < numericToFloat(shipment.Quantity)-0.0001)
+		if partial {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "serial_numbers are required to receive part of a serialized shipment"})
+			return
+		}
+		if req.ReceivedQuantity == nil || *req.ReceivedQuantity > 0.0001 {
+			for _, sn := range serials {
+				arrived[sn.SerialNumberID] = true
+			}
+		}
+	}
+
+	quantities, err := receivedPerBatch(lines, req.Batches, req.ReceivedQuantity)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	received, receivedValue := 0.0, 0.0
+	for i, l := range lines {
+		received += quantities[i]
+		receivedValue += quantities[i] * numericToFloat(l.UnitCost)
+	}
+
+	shipped := numericToFloat(shipment.Quantity)
+	discrepancy := shipped - received
+	if discrepancy > 0.0001 && stringValue(req.DiscrepancyReason) == "" {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("%.2f of %.2f shipped did not arrive, discrepancy_reason is required", discrepancy, shipped)})
+		return
+	}
+
+	toBinID := req.ToBinID
+	if toBinID == nil && shipment.ToBinID.Valid {
+		toBinID = &shipment.ToBinID.Int32
+	}
+	if err := checkBinPutaway(ctx, queries, toBinID, shipment.ToWarehouseID, received); err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	var notes pgtype.Text
+	if req.Notes != nil {
+		notes = pgtype.Text{String: *req.Notes, Valid: true}
+	}
+
+	// Nothing is received of a shipment that was lost entirely
+	if received > 0.0001 {
+		unitCost := receivedValue / received
+		costPerUnit, totalCost := movementCost(received, unitCost)
+
+		movement, err := queries.CreateStockMovement(ctx, db.CreateStockMovementParams{
+			MaterialID:         pgtype.Int4{Int32: shipment.MaterialID, Valid: true},
+			FromWarehouseID:    pgtype.Int4{Int32: shipment.FromWarehouseID, Valid: true},
+			ToWarehouseID:      pgtype.Int4{Int32: shipment.ToWarehouseID, Valid: true},
+			Quantity:           decimalFromFloat(received),
+			StockDirection:     db.StockDirectionIN,
+			MovementType:       db.StockMovementTypeTRANSFERIN,
+			Reference:          pgtype.Text{String: shipment.ShipmentNumber, Valid: true},
+			PerformedBy:        pgtype.Int4{Int32: userID, Valid: true},
+			MovementDate:       pgtype.Timestamptz{Time: time.Now(), Valid: true},
+			Notes:              notes,
+			UnitCost:           costPerUnit,
+			TotalCost:          totalCost,
+			FromBinID:          shipment.FromBinID,
+			ToBinID:            binParam(toBinID),
+			EnteredQuantity:    conv.enteredQuantity(),
+			EnteredUnitID:      conv.enteredUnit(),
+			TransferShipmentID: pgtype.Int4{Int32: shipment.ID, Valid: true},
+		})
+		if err != nil {
+			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create transfer in movement"})
+			return
+		}
+
+		// The destination receives the stock at the cost it left the source with
+		if err := postCostLedger(ctx, queries, shipment.MaterialID, shipment.ToWarehouseID, movement.ID, received, unitCost); err != nil {
+			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to update cost ledger"})
+			return
+		}
+
+		// Create new batches in destination warehouse
+		for i, l := range lines {
+			if quantities[i] <= 0.0001 {
+				continue
+			}
+
+			batchNumber, err := generateBatchNumber(ctx, queries, shipment.MaterialID, "transfer")
+			if err != nil {
+				config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to generate batch number"})
+				return
+			}
+
+			newBatch, err := queries.CreateBatch(ctx, db.CreateBatchParams{
+				MaterialID:      pgtype.Int4{Int32: shipment.MaterialID, Valid: true},
+				SupplierID:      l.SupplierID,
+				WarehouseID:     pgtype.Int4{Int32: shipment.ToWarehouseID, Valid: true},
+				MovementID:      pgtype.Int4{Int32: movement.ID, Valid: true},
+				UnitPrice:       l.UnitPrice,
+				BatchNumber:     batchNumber,
+				ManufactureDate: l.ManufactureDate,
+				ExpiryDate:      l.ExpiryDate,
+				StartQuantity:   decimalFromFloat(quantities[i]),
+				CurrentQuantity: decimalFromFloat(quantities[i]),
+				Notes:           pgtype.Text{String: fmt.Sprintf("Transferred from batch %s (movement %d)", l.BatchNumber, l.MovementID), Valid: true},
+				BinID:           binParam(toBinID),
+			})
+			if err != nil {
+				config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create destination batch"})
+				return
+			}
+
+			if err := recordMovementBatch(ctx, queries, movement.ID, newBatch.ID, quantities[i], numericToFloat(l.UnitCost)); err != nil {
+				config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to record movement batch"})
+				return
+			}
+
+			// The destination batch descends from the source batch
+			if err := queries.CreateBatchGenealogyLink(ctx, db.CreateBatchGenealogyLinkParams{
+				ParentBatchID: l.BatchID,
+				ChildBatchID:  newBatch.ID,
+				LinkType:      batchLinkTransfer,
+				MovementID:    pgtype.Int4{Int32: movement.ID, Valid: true},
+				Quantity:      decimalFromFloat(quantities[i]),
+			}); err != nil {
+				config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to record batch genealogy"})
+				return
+			}
+
+			// The units that arrived of the source batch are held by the new batch
+			for _, sn := range serials {
+				if sn.BatchID.Int32 != l.BatchID || !arrived[sn.SerialNumberID] {
+					continue
+				}
+				if err := moveSerialNumber(ctx, queries, movement.ID, sn.SerialNumberID, pgtype.Int4{Int32: newBatch.ID, Valid: true}, pgtype.Int4{Int32: shipment.ToWarehouseID, Valid: true}, serialInStock); err != nil {
+					config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to record serial numbers"})
+					return
+				}
+			}
+		}
+	}
+
+	// What did not arrive is written off as a loss at the cost it was
+	// shipped with. The source batches were consumed when shipping, so the
+	// loss has no batch lines.
+	var reason pgtype.Text
+	if discrepancy > 0.0001 {
+		reason = pgtype.Text{String: *req.DiscrepancyReason, Valid: true}
+
+		lostValue := -receivedValue
+		for _, l := range lines {
+			lostValue += numericToFloat(l.Quantity) * numericToFloat(l.UnitCost)
+		}
+		costPerUnit, totalCost := movementCost(discrepancy, lostValue/discrepancy)
+
+		loss, err := queries.CreateStockMovement(ctx, db.CreateStockMovementParams{
+			MaterialID:         pgtype.Int4{Int32: shipment.MaterialID, Valid: true},
+			FromWarehouseID:    pgtype.Int4{Int32: shipment.FromWarehouseID, Valid: true},
+			ToWarehouseID:      pgtype.Int4{Int32: shipment.ToWarehouseID, Valid: true},
+			Quantity:           decimalFromFloat(discrepancy),
+			StockDirection:     db.StockDirectionOUT,
+			MovementType:       db.StockMovementTypeTRANSFERLOSS,
+			Reference:          pgtype.Text{String: shipment.ShipmentNumber, Valid: true},
+			PerformedBy:        pgtype.Int4{Int32: userID, Valid: true},
+			MovementDate:       pgtype.Timestamptz{Time: time.Now(), Valid: true},
+			Notes:              reason,
+			UnitCost:           costPerUnit,
+			TotalCost:          totalCost,
+			FromBinID:          shipment.FromBinID,
+			TransferShipmentID: pgtype.Int4{Int32: shipment.ID, Valid: true},
+		})
+		if err != nil {
+			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create transfer loss movement"})
+			return
+		}
+
+		// Units that did not arrive are lost
+		for _, sn := range serials {
+			if arrived[sn.SerialNumberID] {
+				continue
+			}
+			if err := moveSerialNumber(ctx, queries, loss.ID, sn.SerialNumberID, sn.BatchID, pgtype.Int4{}, serialLost); err != nil {
+				config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to record serial numbers"})
+				return
+			}
+		}
+	}
+
+	shipment, err = queries.ReceiveTransferShipment(ctx, db.ReceiveTransferShipmentParams{
+		ID:                shipment.ID,
+		ReceivedQuantity:  decimalFromFloat(received),
+		DiscrepancyReason: reason,
+		ReceivedBy:        pgtype.Int4{Int32: userID, Valid: true},
+	})
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to update transfer shipment"})
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to commit transaction"})
+		return
+	}
+
+	if discrepancy > 0.0001 {
+		th.h.Logger.Warn("Transfer shipment received with discrepancy", "shipment", shipment.ShipmentNumber, "shipped", shipped, "received", received)
+	}
+
+	th.respondTransferShipment(w, ctx, http.StatusOK, shipment)
+}
+
+// ListTransferShipments - Get transfer shipments, oldest first
+func (th *TransactionHandler) ListTransferShipments(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	params := db.ListTransferShipmentsParams{}
+	if status := r.URL.Query().Get("status"); status != "" {
+		params.Status = pgtype.Text{String: status, Valid: true}
+	}
+
+	for name, target := range map[string]*pgtype.Int4{
+		"from_warehouse_id": &params.FromWarehouseID,
+		"to_warehouse_id":   &params.ToWarehouseID,
+		"material_id":       &params.MaterialID,
+	} {
+		value := r.URL.Query().Get(name)
+		if value == "" {
+			continue
+		}
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid " + name})
+			return
+		}
+		*target = pgtype.Int4{Int32: int32(id), Valid: true}
+	}
+
+	shipments, err := th.h.Queries.ListTransferShipments(ctx, params)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get transfer shipments"})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusOK, shipments)
+}
+
+// GetTransferShipment - Get a transfer shipment with its batches and movements
+func (th *TransactionHandler) GetTransferShipment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := parseTransferShipmentID(r)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid transfer shipment ID"})
+		return
+	}
+
+	shipment, err := th.h.Queries.GetTransferShipmentByID(ctx, id)
+	if err != nil {
+		config.RespondJSON(w, http.StatusNotFound, map[string]string{"error": "Transfer shipment not found"})
+		return
+	}
+
+	th.respondTransferShipment(w, ctx, http.StatusOK, shipment)
+}
@@ -0,0 +1,129 @@
+package transactions
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	db "warehouse_system/internal/database/db"
+)
+
+// =====================================================
+// UNIT OF MEASURE CONVERSION
+// =====================================================
+
+// unitConversion converts the quantities of one transaction request from the
+// unit the user entered them in to the material's base unit
+type unitConversion struct {
+	unitID  int32   // entered unit, 0 = entered in the base unit
+	entered float64 // quantity as entered
+	factor  float64 // base units per entered unit
+}
+
+// baseQuantity converts an entered quantity to the base unit, rounded to the
+// 4 decimals a quantity is stored with
+func (c unitConversion) baseQuantity(quantity float64) float64 {
+	return math.Round(quantity*c.factor*10000) / 10000
+}
+
+// basePrice converts a price per entered unit to a price per base unit
+func (c unitConversion) basePrice(price float64) float64 {
+	return price / c.factor
+}
+
+// baseAllocations converts manually selected batch quantities to the base unit
+func (c unitConversion) baseAllocations(allocations []BatchAllocation) []BatchAllocation {
+	if c.unitID == 0 {
+		return allocations
+	}
+	converted := make([]BatchAllocation, len(allocations))
+	for i, a := range allocations {
+		converted[i] = BatchAllocation{BatchID: a.BatchID, Quantity: c.baseQuantity(a.Quantity)}
+	}
+	return converted
+}
+
+// enteredQuantity is the movement column of the quantity as entered
+func (c unitConversion) enteredQuantity() pgtype.Numeric {
+	if c.unitID == 0 {
+		return pgtype.Numeric{}
+	}
+	return decimalFromFloat(c.entered)
+}
+
+// enteredUnit is the movement column of the unit the quantity was entered in
+func (c unitConversion) enteredUnit() pgtype.Int4 {
+	return pgtype.Int4{Int32: c.unitID, Valid: c.unitID != 0}
+}
+
+// unitToRoot follows the convert_to chain of a unit to the unit it finally
+// converts to and returns that unit with the number of its units per unit
+func unitToRoot(ctx context.Context, queries *db.Queries, unitID int32) (int32, float64, error) {
+	factor := 1.0
+	visited := map[int32]bool{}
+	for {
+		if visited[unitID] {
+			return 0, 0, fmt.Errorf("unit %d has a conversion loop", unitID)
+		}
+		visited[unitID] = true
+
+		unit, err := queries.GetUnitByID(ctx, unitID)
+		if err != nil {
+			return 0, 0, fmt.Errorf("unit %d not found", unitID)
+		}
+		if !unit.ConvertTo.Valid || unit.ConvertTo.Int32 == unit.ID {
+			return unit.ID, factor, nil
+		}
+
+		step := numericToFloat(unit.ConvertionFactor)
+		if step <= 0 {
+			return 0, 0, fmt.Errorf("unit %s has no valid conversion factor", unit.Abbreviation)
+		}
+		factor *= step
+		unitID = unit.ConvertTo.Int32
+	}
+}
+
+// convertToBaseUnit prepares the conversion of a request entered in unitID to
+// the material's unit. Both units must convert to the same unit in the end,
+// otherwise they measure different things and the request is rejected.
+func convertToBaseUnit(ctx context.Context, queries *db.Queries, materialID int32, unitID *int32, quantity float64) (unitConversion, error) {
+	conv := unitConversion{entered: quantity, factor: 1}
+	if unitID == nil || *unitID == 0 {
+		return conv, nil
+	}
+
+	material, err := queries.GetMaterialByID(ctx, materialID)
+	if err != nil {
+		return conv, fmt.Errorf("material %d not found", materialID)
+	}
+	if material.MeasureUnitID.Int32 == *unitID {
+		return conv, nil
+	}
+	if !material.MeasureUnitID.Valid {
+		return conv, fmt.Errorf("material %s has no unit of measure to convert to", material.Code)
+	}
+
+	enteredRoot, enteredFactor, err := unitToRoot(ctx, queries, *unitID)
+	if err != nil {
+		return conv, err
+	}
+	baseRoot, baseFactor, err := unitToRoot(ctx, queries, material.MeasureUnitID.Int32)
+	if err != nil {
+		return conv, err
+	}
+	if enteredRoot != baseRoot {
+		return conv, fmt.Errorf("unit %d cannot be converted to %s, the unit of material %s", *unitID, material.UnitAbbreviation.String, material.Code)
+	}
+
+	conv.unitID = *unitID
+	conv.factor = enteredFactor / baseFactor
+
+	// A positive quantity must not round away to nothing in the base unit
+	if quantity > 0 && conv.baseQuantity(quantity) <= 0 {
+		return conv, fmt.Errorf("quantity %g is less than 0.0001 %s, the smallest quantity of material %s", quantity, material.UnitAbbreviation.String, material.Code)
+	}
+	return conv, nil
+}
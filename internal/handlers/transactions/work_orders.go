@@ -0,0 +1,775 @@
+package transactions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"warehouse_system/internal/config"
+	db "warehouse_system/internal/database/db"
+	"warehouse_system/internal/middlewares"
+)
+
+// =====================================================
+// WORK ORDER REQUEST TYPES
+// =====================================================
+
+type CreateWorkOrderRequest struct {
+	FinishedMaterialID int32   `json:"finished_material_id"`
+	WarehouseID        int32   `json:"warehouse_id"`
+	Quantity           float64 `json:"quantity"`
+	UnitID             *int32  `json:"unit_id,omitempty"`
+	BOMVersion         *string `json:"bom_version,omitempty"` // default the version in effect
+	DueDate            *string `json:"due_date,omitempty"`
+	Notes              *string `json:"notes,omitempty"`
+}
+
+type CompleteWorkOrderRequest struct {
+	Quantity        *float64 `json:"quantity,omitempty"` // default the planned quantity
+	UnitID          *int32   `json:"unit_id,omitempty"`
+	BinID           *int32   `json:"bin_id,omitempty"`
+	ManufactureDate *string  `json:"manufacture_date,omitempty"` // default today
+	ExpiryDate      *string  `json:"expiry_date,omitempty"`
+	Notes           *string  `json:"notes,omitempty"`
+	SerialNumbers   []string `json:"serial_numbers,omitempty"` // one per unit of a serialized finished material
+}
+
+type WorkOrderResponse struct {
+	db.WorkOrder
+	Components []db.ListWorkOrderComponentsRow `json:"components"`
+	Movements  []db.ListWorkOrderMovementsRow  `json:"movements"`
+}
+
+// SkippedComponent is an optional component an issue left out for lack of stock
+type SkippedComponent struct {
+	ComponentID int32   `json:"component_id"`
+	MaterialID  int32   `json:"material_id"`
+	Code        string  `json:"code"`
+	Quantity    float64 `json:"quantity"`
+	Reason      string  `json:"reason"`
+}
+
+// =====================================================
+// WORK ORDER HELPERS
+// =====================================================
+
+// requiredComponentQuantity is the quantity of a BOM line needed for an
+// order, the same as calculate_material_requirements: scrap is added on top
+// and fixed quantities do not scale with the order quantity
+func requiredComponentQuantity(baseQuantity, scrapPercentage float64, fixedQuantity bool, orderQuantity float64) float64 {
+	quantity := baseQuantity * (1 + scrapPercentage/100)
+	if fixedQuantity {
+		return quantity
+	}
+	return quantity * orderQuantity
+}
+
+func parseWorkOrderID(r *http.Request) (int32, error) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return 0, err
+	}
+	return int32(id), nil
+}
+
+// lockWorkOrder locks a work order for changes and checks its status
+func lockWorkOrder(ctx context.Context, queries *db.Queries, id int32, statuses ...string) (db.WorkOrder, int, error) {
+	workOrder, err := queries.GetWorkOrderByIDForUpdate(ctx, id)
+	if err != nil {
+		return workOrder, http.StatusNotFound, fmt.Errorf("work order not found")
+	}
+	for _, s := range statuses {
+		if workOrder.Status == s {
+			return workOrder, http.StatusOK, nil
+		}
+	}
+	return workOrder, http.StatusBadRequest, fmt.Errorf("work order %s is %s", workOrder.WorkOrderNumber, workOrder.Status)
+}
+
+// issueToWorkOrder posts one PRODUCTION_ISSUE movement of a material and
+// returns its total cost. The stock must have been checked and locked.
+// Serialized components are refused, the issue picks batches and cannot
+// tell which units it consumed.
+func issueToWorkOrder(ctx context.Context, queries *db.Queries, workOrder db.WorkOrder, materialID int32, quantity float64, reservations stockReservations, userID int32) (float64, error) {
+	if err := rejectSerialized(ctx, queries, materialID, "it cannot be issued to work orders"); err != nil {
+		return 0, err
+	}
+
+	strategy, err := getAllocationStrategy(ctx, queries, materialID, workOrder.WarehouseID)
+	if err != nil {
+		return 0, err
+	}
+
+	allocations, err := allocateBatchesAuto(ctx, queries, materialID, workOrder.WarehouseID, 0, quantity, strategy, reservations)
+	if err != nil {
+		return 0, err
+	}
+
+	// Cost the issue before the batches are consumed
+	unitCost, batchCosts, err := issueUnitCost(ctx, queries, materialID, workOrder.WarehouseID, allocations)
+	if err != nil {
+		return 0, err
+	}
+	costPerUnit, totalCost := movementCost(quantity, unitCost)
+
+	movement, err := queries.CreateStockMovement(ctx, db.CreateStockMovementParams{
+		MaterialID:      pgtype.Int4{Int32: materialID, Valid: true},
+		FromWarehouseID: pgtype.Int4{Int32: workOrder.WarehouseID, Valid: true},
+		Quantity:        decimalFromFloat(quantity),
+		StockDirection:  db.StockDirectionOUT,
+		MovementType:    db.StockMovementTypePRODUCTIONISSUE,
+		Reference:       pgtype.Text{String: workOrder.WorkOrderNumber, Valid: true},
+		PerformedBy:     pgtype.Int4{Int32: userID, Valid: true},
+		MovementDate:    pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		UnitCost:        costPerUnit,
+		TotalCost:       totalCost,
+		WorkOrderID:     pgtype.Int4{Int32: workOrder.ID, Valid: true},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to create issue movement: %w", err)
+	}
+
+	for _, alloc := range allocations {
+		if _, err := queries.UpdateBatchQuantity(ctx, db.UpdateBatchQuantityParams{
+			ID:              alloc.BatchID,
+			CurrentQuantity: decimalFromFloat(-alloc.Quantity),
+		}); err != nil {
+			if isNegativeStock(err) {
+				return 0, fmt.Errorf("batch %d does not hold %.2f anymore", alloc.BatchID, alloc.Quantity)
+			}
+			return 0, fmt.Errorf("failed to update batch quantity: %w", err)
+		}
+		if err := recordMovementBatch(ctx, queries, movement.ID, alloc.BatchID, alloc.Quantity, batchCosts[alloc.BatchID]); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := postCostLedger(ctx, queries, materialID, workOrder.WarehouseID, movement.ID, -quantity, unitCost); err != nil {
+		return 0, err
+	}
+
+	return quantity * unitCost, nil
+}
+
+// issueWorkOrderComponent issues the open quantity of one component line.
+// What the component's stock cannot cover comes from the alternate, if the
+// line has one. Optional lines that cannot be covered are skipped.
+func issueWorkOrderComponent(ctx context.Context, queries *db.Queries, workOrder db.WorkOrder, c db.ListWorkOrderComponentsRow, userID int32) (*SkippedComponent, error) {
+	open := numericToFloat(c.RequiredQuantity) - numericToFloat(c.IssuedQuantity)
+	if open <= 0.0001 {
+		return nil, nil
+	}
+
+	reservations, err := checkStockReservations(ctx, queries, c.ComponentMaterialID, workOrder.WarehouseID, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	fromComponent := min(open, reservations.available)
+	shortfall := open - fromComponent
+
+	// The alternate covers the whole shortfall or nothing
+	var fromAlternate float64
+	var alternateReservations stockReservations
+	if shortfall > 0.0001 && c.AlternateComponentID.Valid {
+		var unitID *int32
+		if c.ComponentUnitID.Valid {
+			unitID = &c.ComponentUnitID.Int32
+		}
+		conv, err := convertToBaseUnit(ctx, queries, c.AlternateComponentID.Int32, unitID, shortfall)
+		if err != nil {
+			return nil, fmt.Errorf("alternate %s of %s: %w", c.AlternateCode.String, c.ComponentCode, err)
+		}
+		alternateReservations, err = checkStockReservations(ctx, queries, c.AlternateComponentID.Int32, workOrder.WarehouseID, 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		if need := conv.baseQuantity(shortfall); alternateReservations.available >= need-0.0001 {
+			fromAlternate = need
+			shortfall = 0
+		}
+	}
+
+	if shortfall > 0.0001 {
+		reason := fmt.Sprintf("insufficient stock of %s: %.2f required, %.2f available", c.ComponentCode, open, fromComponent)
+		if c.AlternateComponentID.Valid {
+			reason += fmt.Sprintf(", alternate %s cannot cover the rest", c.AlternateCode.String)
+		}
+		if c.IsOptional {
+			return &SkippedComponent{ComponentID: c.ID, MaterialID: c.ComponentMaterialID, Code: c.ComponentCode, Quantity: open, Reason: reason}, nil
+		}
+		return nil, errors.New(reason)
+	}
+
+	issuedCost := 0.0
+	if fromComponent > 0.0001 {
+		cost, err := issueToWorkOrder(ctx, queries, workOrder, c.ComponentMaterialID, fromComponent, reservations, userID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", c.ComponentCode, err)
+		}
+		issuedCost += cost
+	}
+	if fromAlternate > 0.0001 {
+		cost, err := issueToWorkOrder(ctx, queries, workOrder, c.AlternateComponentID.Int32, fromAlternate, alternateReservations, userID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", c.AlternateCode.String, err)
+		}
+		issuedCost += cost
+	}
+
+	if err := queries.AddWorkOrderComponentIssue(ctx, db.AddWorkOrderComponentIssueParams{
+		ID:                      c.ID,
+		IssuedQuantity:          decimalFromFloat(open),
+		AlternateIssuedQuantity: decimalFromFloat(fromAlternate),
+		IssuedCost:              costFromFloat(issuedCost),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to record issue of %s: %w", c.ComponentCode, err)
+	}
+
+	if _, err := queries.AddWorkOrderIssuedCost(ctx, db.AddWorkOrderIssuedCostParams{
+		ID:         workOrder.ID,
+		IssuedCost: costFromFloat(issuedCost),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to update work order cost: %w", err)
+	}
+
+	return nil, nil
+}
+
+// respondWorkOrder responds with a work order, its components and movements
+func (th *TransactionHandler) respondWorkOrder(w http.ResponseWriter, ctx context.Context, status int, workOrder db.WorkOrder, extra map[string]any) {
+	components, err := th.h.Queries.ListWorkOrderComponents(ctx, workOrder.ID)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get work order components"})
+		return
+	}
+	movements, err := th.h.Queries.ListWorkOrderMovements(ctx, pgtype.Int4{Int32: workOrder.ID, Valid: true})
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get work order movements"})
+		return
+	}
+
+	response := WorkOrderResponse{WorkOrder: workOrder, Components: components, Movements: movements}
+	if len(extra) == 0 {
+		config.RespondJSON(w, status, response)
+		return
+	}
+	extra["work_order"] = response
+	config.RespondJSON(w, status, extra)
+}
+
+// =====================================================
+// WORK ORDER HANDLERS
+// =====================================================
+
+// CreateWorkOrder - Plan the production of a finished material from a BOM
+// version. The BOM lines are copied onto the order with the required
+// quantities, later BOM changes do not affect it.
+func (th *TransactionHandler) CreateWorkOrder(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user session from context
+	session, ok := middlewares.GetSessionFromContext(r)
+	if !ok {
+		config.RespondJSON(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized - Authentication required"})
+		return
+	}
+
+	// Parse user ID from session
+	var userID int32
+	_, err := fmt.Sscanf(session.UserID, "%d", &userID)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+		return
+	}
+
+	var req CreateWorkOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	if req.FinishedMaterialID == 0 || req.WarehouseID == 0 {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "finished_material_id and warehouse_id are required"})
+		return
+	}
+	if req.Quantity <= 0 {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Quantity must be positive"})
+		return
+	}
+
+	// Quantities may be entered in any unit that converts to the material's unit
+	conv, err := convertToBaseUnit(ctx, th.h.Queries, req.FinishedMaterialID, req.UnitID, req.Quantity)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	quantity := conv.baseQuantity(req.Quantity)
+
+	if _, err := th.h.Queries.GetWarehouseByID(ctx, req.WarehouseID); err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Warehouse not found"})
+		return
+	}
+
+	finishedMaterialID := pgtype.Int4{Int32: req.FinishedMaterialID, Valid: true}
+	version := stringValue(req.BOMVersion)
+	if version == "" {
+		version, err = th.h.Queries.GetEffectiveBOMVersion(ctx, finishedMaterialID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Material has no BOM in effect"})
+			return
+		}
+		if err != nil {
+			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get BOM version"})
+			return
+		}
+	}
+
+	lines, err := th.h.Queries.GetBOMLinesForWorkOrder(ctx, db.GetBOMLinesForWorkOrderParams{
+		FinishedMaterialID: finishedMaterialID,
+		Version:            pgtype.Text{String: version, Valid: true},
+	})
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get BOM"})
+		return
+	}
+	if len(lines) == 0 {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("BOM version %s has no components in effect", version)})
+		return
+	}
+
+	tx, err := th.h.DB.Begin(ctx)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to start transaction"})
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	queries := th.h.Queries.WithTx(tx)
+
+	workOrder, err := queries.CreateWorkOrder(ctx, db.CreateWorkOrderParams{
+		FinishedMaterialID: req.FinishedMaterialID,
+		BomVersion:         version,
+		WarehouseID:        req.WarehouseID,
+		PlannedQuantity:    decimalFromFloat(quantity),
+		DueDate:            parseDate(req.DueDate),
+		Notes:              pgtype.Text{String: stringValue(req.Notes), Valid: req.Notes != nil && *req.Notes != ""},
+		CreatedBy:          pgtype.Int4{Int32: userID, Valid: true},
+	})
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create work order"})
+		return
+	}
+
+	for i, l := range lines {
+		// BOM quantities may be in any unit that converts to the component's unit
+		var unitID *int32
+		if l.UnitMeasureID.Valid {
+			unitID = &l.UnitMeasureID.Int32
+		}
+		lineConv, err := convertToBaseUnit(ctx, queries, l.ComponentMaterialID, unitID, numericToFloat(l.Quantity))
+		if err != nil {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("BOM line %d: %s", l.ID, err.Error())})
+			return
+		}
+		baseQuantity := lineConv.baseQuantity(numericToFloat(l.Quantity))
+
+		if _, err := queries.CreateWorkOrderComponent(ctx, db.CreateWorkOrderComponentParams{
+			WorkOrderID:          workOrder.ID,
+			BomID:                pgtype.Int4{Int32: l.ID, Valid: true},
+			ComponentMaterialID:  l.ComponentMaterialID,
+			AlternateComponentID: l.AlternateComponentID,
+			BaseQuantity:         decimalFromFloat(baseQuantity),
+			ScrapPercentage:      l.ScrapPercentage,
+			FixedQuantity:        l.FixedQuantity,
+			IsOptional:           l.IsOptional,
+			RequiredQuantity:     decimalFromFloat(requiredComponentQuantity(baseQuantity, numericToFloat(l.ScrapPercentage), l.FixedQuantity, quantity)),
+			Sequence:             int32(i + 1),
+		}); err != nil {
+			config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create work order component"})
+			return
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to commit transaction"})
+		return
+	}
+
+	th.respondWorkOrder(w, ctx, http.StatusCreated, workOrder, nil)
+}
+
+// ListWorkOrders - Get work orders, newest first
+func (th *TransactionHandler) ListWorkOrders(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	params := db.ListWorkOrdersParams{}
+	if status := r.URL.Query().Get("status"); status != "" {
+		params.Status = pgtype.Text{String: status, Valid: true}
+	}
+	if warehouseIDStr := r.URL.Query().Get("warehouse_id"); warehouseIDStr != "" {
+		warehouseID, err := strconv.Atoi(warehouseIDStr)
+		if err != nil {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid warehouse_id"})
+			return
+		}
+		params.WarehouseID = pgtype.Int4{Int32: int32(warehouseID), Valid: true}
+	}
+	if materialIDStr := r.URL.Query().Get("material_id"); materialIDStr != "" {
+		materialID, err := strconv.Atoi(materialIDStr)
+		if err != nil {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid material_id"})
+			return
+		}
+		params.MaterialID = pgtype.Int4{Int32: int32(materialID), Valid: true}
+	}
+
+	workOrders, err := th.h.Queries.ListWorkOrders(ctx, params)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get work orders"})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusOK, workOrders)
+}
+
+// GetWorkOrder - Get a work order with its components and movements
+func (th *TransactionHandler) GetWorkOrder(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := parseWorkOrderID(r)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid work order ID"})
+		return
+	}
+
+	workOrder, err := th.h.Queries.GetWorkOrderByID(ctx, id)
+	if err != nil {
+		config.RespondJSON(w, http.StatusNotFound, map[string]string{"error": "Work order not found"})
+		return
+	}
+
+	th.respondWorkOrder(w, ctx, http.StatusOK, workOrder, nil)
+}
+
+// IssueWorkOrder - Consume the open component quantities of a work order from
+// its warehouse as PRODUCTION_ISSUE movements. All components are issued or
+// none, optional components without stock are skipped.
+func (th *TransactionHandler) IssueWorkOrder(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user session from context
+	session, ok := middlewares.GetSessionFromContext(r)
+	if !ok {
+		config.RespondJSON(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized - Authentication required"})
+		return
+	}
+
+	// Parse user ID from session
+	var userID int32
+	_, err := fmt.Sscanf(session.UserID, "%d", &userID)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+		return
+	}
+
+	id, err := parseWorkOrderID(r)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid work order ID"})
+		return
+	}
+
+	tx, err := th.h.DB.Begin(ctx)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to start transaction"})
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	queries := th.h.Queries.WithTx(tx)
+
+	workOrder, status, err := lockWorkOrder(ctx, queries, id, "Planned", "In Progress")
+	if err != nil {
+		config.RespondJSON(w, status, map[string]string{"error": err.Error()})
+		return
+	}
+
+	components, err := queries.ListWorkOrderComponents(ctx, id)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get work order components"})
+		return
+	}
+
+	// Lock the stock of every component and alternate before any is consumed
+	materialIDs := []int32{}
+	for _, c := range components {
+		materialIDs = append(materialIDs, c.ComponentMaterialID)
+		if c.AlternateComponentID.Valid {
+			materialIDs = append(materialIDs, c.AlternateComponentID.Int32)
+		}
+	}
+	if err := lockStocks(ctx, queries, materialIDs, workOrder.WarehouseID); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to lock stock"})
+		return
+	}
+
+	skipped := []SkippedComponent{}
+	for _, c := range components {
+		skip, err := issueWorkOrderComponent(ctx, queries, workOrder, c, userID)
+		if err != nil {
+			th.h.Logger.Error("Failed to issue work order component", "work_order_id", id, "component_id", c.ID, "error", err)
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		if skip != nil {
+			skipped = append(skipped, *skip)
+		}
+	}
+
+	workOrder, err = queries.UpdateWorkOrderStatus(ctx, db.UpdateWorkOrderStatusParams{
+		ID:     id,
+		Status: "In Progress",
+	})
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to update work order"})
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to commit transaction"})
+		return
+	}
+
+	th.respondWorkOrder(w, ctx, http.StatusOK, workOrder, map[string]any{"skipped": skipped})
+}
+
+// CompleteWorkOrder - Receive the produced quantity as a new finished goods
+// batch. Its unit cost is the cost of the issued components divided by the
+// quantity produced, and the batches consumed become its genealogy.
+func (th *TransactionHandler) CompleteWorkOrder(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// Get user session from context
+	session, ok := middlewares.GetSessionFromContext(r)
+	if !ok {
+		config.RespondJSON(w, http.StatusUnauthorized, map[string]string{"error": "Unauthorized - Authentication required"})
+		return
+	}
+
+	// Parse user ID from session
+	var userID int32
+	_, err := fmt.Sscanf(session.UserID, "%d", &userID)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid user ID"})
+		return
+	}
+
+	id, err := parseWorkOrderID(r)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid work order ID"})
+		return
+	}
+
+	var req CompleteWorkOrderRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+			return
+		}
+	}
+
+	tx, err := th.h.DB.Begin(ctx)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to start transaction"})
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	queries := th.h.Queries.WithTx(tx)
+
+	workOrder, status, err := lockWorkOrder(ctx, queries, id, "In Progress")
+	if err != nil {
+		if workOrder.Status == "Planned" {
+			err = fmt.Errorf("issue the components of work order %s first", workOrder.WorkOrderNumber)
+		}
+		config.RespondJSON(w, status, map[string]string{"error": err.Error()})
+		return
+	}
+
+	quantity := numericToFloat(workOrder.PlannedQuantity)
+	conv := unitConversion{factor: 1}
+	if req.Quantity != nil {
+		if *req.Quantity <= 0 {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Quantity must be positive"})
+			return
+		}
+		conv, err = convertToBaseUnit(ctx, queries, workOrder.FinishedMaterialID, req.UnitID, *req.Quantity)
+		if err != nil {
+			config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		quantity = conv.baseQuantity(*req.Quantity)
+	}
+
+	if err := checkBinPutaway(ctx, queries, req.BinID, workOrder.WarehouseID, quantity); err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	serialized, err := checkSerialNumbers(ctx, queries, workOrder.FinishedMaterialID, quantity, req.SerialNumbers)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	// The components roll up into the cost of the finished goods
+	unitCost := numericToFloat(workOrder.IssuedCost) / quantity
+	costPerUnit, totalCost := movementCost(quantity, unitCost)
+
+	movement, err := queries.CreateStockMovement(ctx, db.CreateStockMovementParams{
+		MaterialID:      pgtype.Int4{Int32: workOrder.FinishedMaterialID, Valid: true},
+		ToWarehouseID:   pgtype.Int4{Int32: workOrder.WarehouseID, Valid: true},
+		Quantity:        decimalFromFloat(quantity),
+		StockDirection:  db.StockDirectionIN,
+		MovementType:    db.StockMovementTypePRODUCTIONRECEIPT,
+		Reference:       pgtype.Text{String: workOrder.WorkOrderNumber, Valid: true},
+		PerformedBy:     pgtype.Int4{Int32: userID, Valid: true},
+		MovementDate:    pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		Notes:           pgtype.Text{String: stringValue(req.Notes), Valid: req.Notes != nil && *req.Notes != ""},
+		UnitCost:        costPerUnit,
+		TotalCost:       totalCost,
+		ToBinID:         binParam(req.BinID),
+		EnteredQuantity: conv.enteredQuantity(),
+		EnteredUnitID:   conv.enteredUnit(),
+		WorkOrderID:     pgtype.Int4{Int32: workOrder.ID, Valid: true},
+	})
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create production receipt movement"})
+		return
+	}
+
+	batchNumber, err := generateBatchNumber(ctx, queries, workOrder.FinishedMaterialID, "production")
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to generate batch number"})
+		return
+	}
+
+	manufactureDate := parseDate(req.ManufactureDate)
+	if !manufactureDate.Valid {
+		manufactureDate = pgtype.Date{Time: time.Now(), Valid: true}
+	}
+
+	batch, err := queries.CreateBatch(ctx, db.CreateBatchParams{
+		MaterialID:      pgtype.Int4{Int32: workOrder.FinishedMaterialID, Valid: true},
+		WarehouseID:     pgtype.Int4{Int32: workOrder.WarehouseID, Valid: true},
+		MovementID:      pgtype.Int4{Int32: movement.ID, Valid: true},
+		UnitPrice:       costFromFloat(unitCost),
+		BatchNumber:     batchNumber,
+		ManufactureDate: manufactureDate,
+		ExpiryDate:      parseDate(req.ExpiryDate),
+		StartQuantity:   decimalFromFloat(quantity),
+		CurrentQuantity: decimalFromFloat(quantity),
+		Notes:           pgtype.Text{String: fmt.Sprintf("Produced by work order %s", workOrder.WorkOrderNumber), Valid: true},
+		BinID:           binParam(req.BinID),
+	})
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to create finished goods batch"})
+		return
+	}
+
+	if err := recordMovementBatch(ctx, queries, movement.ID, batch.ID, quantity, unitCost); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to record movement batch"})
+		return
+	}
+
+	if serialized {
+		if err := receiveSerialNumbers(ctx, queries, workOrder.FinishedMaterialID, workOrder.WarehouseID, batch.ID, movement.ID, req.SerialNumbers); err != nil {
+			config.RespondJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
+	if err := postCostLedger(ctx, queries, workOrder.FinishedMaterialID, workOrder.WarehouseID, movement.ID, quantity, unitCost); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to update cost ledger"})
+		return
+	}
+
+	// The finished goods batch descends from every batch consumed
+	if err := queries.LinkBatchToWorkOrderIssues(ctx, db.LinkBatchToWorkOrderIssuesParams{
+		ChildBatchID: batch.ID,
+		MovementID:   pgtype.Int4{Int32: movement.ID, Valid: true},
+		WorkOrderID:  workOrder.ID,
+	}); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to record batch genealogy"})
+		return
+	}
+
+	workOrder, err = queries.CompleteWorkOrder(ctx, db.CompleteWorkOrderParams{
+		ID:                id,
+		CompletedQuantity: decimalFromFloat(quantity),
+		BatchID:           pgtype.Int4{Int32: batch.ID, Valid: true},
+		CompletedBy:       pgtype.Int4{Int32: userID, Valid: true},
+	})
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to complete work order"})
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to commit transaction"})
+		return
+	}
+
+	th.respondWorkOrder(w, ctx, http.StatusOK, workOrder, map[string]any{
+		"batch_id":     batch.ID,
+		"batch_number": batch.BatchNumber,
+		"unit_cost":    unitCost,
+	})
+}
+
+// CancelWorkOrder - Cancel a work order nothing has been issued to yet
+func (th *TransactionHandler) CancelWorkOrder(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := parseWorkOrderID(r)
+	if err != nil {
+		config.RespondJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid work order ID"})
+		return
+	}
+
+	tx, err := th.h.DB.Begin(ctx)
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to start transaction"})
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	queries := th.h.Queries.WithTx(tx)
+
+	if _, status, err := lockWorkOrder(ctx, queries, id, "Planned"); err != nil {
+		config.RespondJSON(w, status, map[string]string{"error": err.Error()})
+		return
+	}
+
+	workOrder, err := queries.UpdateWorkOrderStatus(ctx, db.UpdateWorkOrderStatusParams{
+		ID:     id,
+		Status: "Cancelled",
+	})
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to cancel work order"})
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to commit transaction"})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusOK, workOrder)
+}
@@ -0,0 +1,415 @@
+package warehouses
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"warehouse_system/internal/config"
+	db "warehouse_system/internal/database/db"
+)
+
+func isValidBinType(t string) bool {
+	validTypes := []string{"Shelf", "Bin", "Pallet", "Floor"}
+	for _, valid := range validTypes {
+		if t == valid {
+			return true
+		}
+	}
+	return false
+}
+
+type CreateBinRequest struct {
+	Code     string  `json:"code"`
+	Name     string  `json:"name"`
+	BinType  string  `json:"bin_type"`
+	Zone     string  `json:"zone"`
+	Capacity float64 `json:"capacity"`
+	Notes    string  `json:"notes"`
+}
+
+type UpdateBinRequest struct {
+	Code     *string  `json:"code,omitempty"`
+	Name     *string  `json:"name,omitempty"`
+	BinType  *string  `json:"bin_type,omitempty"`
+	Zone     *string  `json:"zone,omitempty"`
+	Capacity *float64 `json:"capacity,omitempty"`
+	IsActive *bool    `json:"is_active,omitempty"`
+	Notes    *string  `json:"notes,omitempty"`
+}
+
+// PutawaySuggestion is a bin proposed for incoming stock
+type PutawaySuggestion struct {
+	BinID             int32    `json:"bin_id"`
+	Code              string   `json:"code"`
+	Zone              string   `json:"zone,omitempty"`
+	FreeCapacity      *float64 `json:"free_capacity"`
+	SuggestedQuantity float64  `json:"suggested_quantity"`
+	Reason            string   `json:"reason"`
+}
+
+type PutawayResponse struct {
+	WarehouseID      int32               `json:"warehouse_id"`
+	MaterialID       int32               `json:"material_id"`
+	Quantity         float64             `json:"quantity"`
+	Suggestions      []PutawaySuggestion `json:"suggestions"`
+	UnplacedQuantity float64             `json:"unplaced_quantity"`
+}
+
+func parsePathID(r *http.Request, name string) (int32, error) {
+	var id int32
+	_, err := fmt.Sscanf(r.PathValue(name), "%d", &id)
+	return id, err
+}
+
+func numericValue(n pgtype.Numeric) float64 {
+	f, _ := n.Float64Value()
+	return f.Float64
+}
+
+// getWarehouseBin loads a bin through its warehouse path
+func (wh *WarehouseHandler) getWarehouseBin(w http.ResponseWriter, r *http.Request) (db.WarehouseBin, bool) {
+	warehouseID, err := parsePathID(r, "id")
+	if err != nil {
+		config.RespondBadRequest(w, "Invalid warehouse ID format", err.Error())
+		return db.WarehouseBin{}, false
+	}
+
+	binID, err := parsePathID(r, "bin_id")
+	if err != nil {
+		config.RespondBadRequest(w, "Invalid bin ID format", err.Error())
+		return db.WarehouseBin{}, false
+	}
+
+	bin, err := wh.h.Queries.GetWarehouseBinByID(r.Context(), binID)
+	if err != nil || bin.WarehouseID != warehouseID {
+		config.RespondJSON(w, http.StatusNotFound, map[string]string{"error": "Bin not found"})
+		return db.WarehouseBin{}, false
+	}
+
+	return bin, true
+}
+
+// CreateBin creates a storage bin inside a warehouse.
+func (wh *WarehouseHandler) CreateBin(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	warehouseID, err := parsePathID(r, "id")
+	if err != nil {
+		config.RespondBadRequest(w, "Invalid warehouse ID format", err.Error())
+		return
+	}
+
+	var req CreateBinRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		config.RespondBadRequest(w, "Invalid request payload", err.Error())
+		return
+	}
+
+	if req.Code == "" {
+		config.RespondBadRequest(w, "Missing required fields", "Code is required")
+		return
+	}
+
+	if req.BinType == "" {
+		req.BinType = "Bin"
+	}
+	if !isValidBinType(req.BinType) {
+		config.RespondBadRequest(w, "Invalid bin type", "Bin type must be one of: Shelf, Bin, Pallet, Floor")
+		return
+	}
+
+	if req.Capacity < 0 {
+		config.RespondBadRequest(w, "Invalid capacity", "Capacity cannot be negative")
+		return
+	}
+
+	if _, err := wh.h.Queries.GetWarehouseByID(ctx, warehouseID); err != nil {
+		config.RespondJSON(w, http.StatusNotFound, map[string]string{"error": "Warehouse not found"})
+		return
+	}
+
+	// Check for duplicate code inside the warehouse
+	if _, err := wh.h.Queries.GetWarehouseBinByCode(ctx, db.GetWarehouseBinByCodeParams{WarehouseID: warehouseID, Code: req.Code}); err == nil {
+		config.RespondJSON(w, http.StatusConflict, map[string]string{"error": "Bin code already exists in this warehouse"})
+		return
+	}
+
+	params := db.CreateWarehouseBinParams{
+		WarehouseID: warehouseID,
+		Code:        req.Code,
+		BinType:     req.BinType,
+	}
+
+	if req.Name != "" {
+		params.Name = pgtype.Text{String: req.Name, Valid: true}
+	}
+	if req.Zone != "" {
+		params.Zone = pgtype.Text{String: req.Zone, Valid: true}
+	}
+	if req.Capacity > 0 {
+		params.Capacity.Scan(fmt.Sprintf("%.4f", req.Capacity))
+	}
+	if req.Notes != "" {
+		params.Notes = pgtype.Text{String: req.Notes, Valid: true}
+	}
+
+	bin, err := wh.h.Queries.CreateWarehouseBin(ctx, params)
+	if err != nil {
+		wh.h.Logger.Error("Failed to create bin", "error", err)
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusCreated, bin)
+}
+
+// ListBins lists the bins of a warehouse with their occupied quantity.
+func (wh *WarehouseHandler) ListBins(w http.ResponseWriter, r *http.Request) {
+	warehouseID, err := parsePathID(r, "id")
+	if err != nil {
+		config.RespondBadRequest(w, "Invalid warehouse ID format", err.Error())
+		return
+	}
+
+	bins, err := wh.h.Queries.ListWarehouseBins(r.Context(), warehouseID)
+	if err != nil {
+		wh.h.Logger.Error("Failed to list bins", "error", err)
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusOK, bins)
+}
+
+// GetBin retrieves a bin of a warehouse.
+func (wh *WarehouseHandler) GetBin(w http.ResponseWriter, r *http.Request) {
+	bin, ok := wh.getWarehouseBin(w, r)
+	if !ok {
+		return
+	}
+
+	config.RespondJSON(w, http.StatusOK, bin)
+}
+
+// UpdateBin updates a bin of a warehouse. Deactivated bins keep their stock
+// but no longer take new stock.
+func (wh *WarehouseHandler) UpdateBin(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	bin, ok := wh.getWarehouseBin(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateBinRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		config.RespondBadRequest(w, "Invalid request payload", err.Error())
+		return
+	}
+
+	if req.BinType != nil && !isValidBinType(*req.BinType) {
+		config.RespondBadRequest(w, "Invalid bin type", "Bin type must be one of: Shelf, Bin, Pallet, Floor")
+		return
+	}
+
+	if req.Capacity != nil && *req.Capacity <= 0 {
+		config.RespondBadRequest(w, "Invalid capacity", "Capacity must be positive")
+		return
+	}
+
+	// Check for duplicate code if being updated
+	if req.Code != nil && *req.Code != bin.Code {
+		if *req.Code == "" {
+			config.RespondBadRequest(w, "Invalid code", "Code cannot be empty")
+			return
+		}
+		if _, err := wh.h.Queries.GetWarehouseBinByCode(ctx, db.GetWarehouseBinByCodeParams{WarehouseID: bin.WarehouseID, Code: *req.Code}); err == nil {
+			config.RespondJSON(w, http.StatusConflict, map[string]string{"error": "Bin code already exists in this warehouse"})
+			return
+		}
+	}
+
+	params := db.UpdateWarehouseBinParams{
+		ID: bin.ID,
+	}
+
+	if req.Code != nil {
+		params.Code = pgtype.Text{String: *req.Code, Valid: true}
+	}
+	if req.Name != nil {
+		params.Name = pgtype.Text{String: *req.Name, Valid: true}
+	}
+	if req.BinType != nil {
+		params.BinType = pgtype.Text{String: *req.BinType, Valid: true}
+	}
+	if req.Zone != nil {
+		params.Zone = pgtype.Text{String: *req.Zone, Valid: true}
+	}
+	if req.Capacity != nil {
+		params.Capacity.Scan(fmt.Sprintf("%.4f", *req.Capacity))
+	}
+	if req.IsActive != nil {
+		params.IsActive = pgtype.Bool{Bool: *req.IsActive, Valid: true}
+	}
+	if req.Notes != nil {
+		params.Notes = pgtype.Text{String: *req.Notes, Valid: true}
+	}
+
+	updated, err := wh.h.Queries.UpdateWarehouseBin(ctx, params)
+	if err != nil {
+		wh.h.Logger.Error("Failed to update bin", "error", err)
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusOK, updated)
+}
+
+// DeleteBin deletes a bin that never stored a batch. Bins with stock history
+// are deactivated instead.
+func (wh *WarehouseHandler) DeleteBin(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	bin, ok := wh.getWarehouseBin(w, r)
+	if !ok {
+		return
+	}
+
+	count, err := wh.h.Queries.CountBinBatches(ctx, pgtype.Int4{Int32: bin.ID, Valid: true})
+	if err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	if count > 0 {
+		config.RespondJSON(w, http.StatusConflict, map[string]string{"error": "Bin has stored batches, deactivate it instead"})
+		return
+	}
+
+	if err := wh.h.Queries.DeleteWarehouseBin(ctx, bin.ID); err != nil {
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	config.RespondJSON(w, http.StatusOK, map[string]string{"message": "Bin deleted successfully"})
+}
+
+// SuggestPutaway proposes bins for incoming stock of a material. Bins already
+// holding the material come first so stock stays together, then empty bins
+// with the smallest sufficient capacity, then bins holding other materials.
+// The quantity is split over several bins when one bin cannot take it all.
+func (wh *WarehouseHandler) SuggestPutaway(w http.ResponseWriter, r *http.Request) {
+	warehouseID, err := parsePathID(r, "id")
+	if err != nil {
+		config.RespondBadRequest(w, "Invalid warehouse ID format", err.Error())
+		return
+	}
+
+	materialID, err := strconv.Atoi(r.URL.Query().Get("material_id"))
+	if err != nil {
+		config.RespondBadRequest(w, "Invalid material_id", "material_id is required")
+		return
+	}
+
+	quantity, err := strconv.ParseFloat(r.URL.Query().Get("quantity"), 64)
+	if err != nil || quantity <= 0 {
+		config.RespondBadRequest(w, "Invalid quantity", "quantity must be a positive number")
+		return
+	}
+
+	candidates, err := wh.h.Queries.ListPutawayCandidates(r.Context(), db.ListPutawayCandidatesParams{
+		WarehouseID: warehouseID,
+		MaterialID:  pgtype.Int4{Int32: int32(materialID), Valid: true},
+	})
+	if err != nil {
+		wh.h.Logger.Error("Failed to list putaway candidates", "error", err)
+		config.RespondJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	type rankedBin struct {
+		row  db.ListPutawayCandidatesRow
+		rank int
+		free *float64
+	}
+
+	ranked := []rankedBin{}
+	for _, c := range candidates {
+		rb := rankedBin{row: c}
+		if c.Capacity.Valid {
+			free := numericValue(c.Capacity) - numericValue(c.OccupiedQuantity)
+			if free <= 0 {
+				continue
+			}
+			rb.free = &free
+		}
+
+		switch {
+		case numericValue(c.MaterialQuantity) > 0:
+			rb.rank = 0
+		case numericValue(c.OccupiedQuantity) == 0:
+			rb.rank = 1
+		default:
+			rb.rank = 2
+		}
+		ranked = append(ranked, rb)
+	}
+
+	// Inside a rank the tightest fit goes first, bins without a capacity last
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].rank != ranked[j].rank {
+			return ranked[i].rank < ranked[j].rank
+		}
+		if ranked[i].free == nil || ranked[j].free == nil {
+			return ranked[j].free == nil && ranked[i].free != nil
+		}
+		iFits, jFits := *ranked[i].free >= quantity, *ranked[j].free >= quantity
+		if iFits != jFits {
+			return iFits
+		}
+		if iFits {
+			return *ranked[i].free < *ranked[j].free
+		}
+		return *ranked[i].free > *ranked[j].free
+	})
+
+	reasons := []string{"Already holds this material", "Empty bin", "Has free capacity"}
+
+	response := PutawayResponse{
+		WarehouseID: warehouseID,
+		MaterialID:  int32(materialID),
+		Quantity:    quantity,
+		Suggestions: []PutawaySuggestion{},
+	}
+
+	remaining := quantity
+	for _, rb := range ranked {
+		if remaining <= 0 {
+			break
+		}
+
+		suggested := remaining
+		if rb.free != nil && *rb.free < suggested {
+			suggested = *rb.free
+		}
+
+		response.Suggestions = append(response.Suggestions, PutawaySuggestion{
+			BinID:             rb.row.ID,
+			Code:              rb.row.Code,
+			Zone:              rb.row.Zone.String,
+			FreeCapacity:      rb.free,
+			SuggestedQuantity: suggested,
+			Reason:            reasons[rb.rank],
+		})
+		remaining -= suggested
+	}
+
+	response.UnplacedQuantity = max(remaining, 0)
+
+	config.RespondJSON(w, http.StatusOK, response)
+}
@@ -32,12 +32,25 @@ func isValidValuationMethod(v string) bool {
 	return false
 }
 
+// isValidAllocationStrategy checks the order batches are drawn from, FEFO is
+// an allocation strategy and not a valuation method
+func isValidAllocationStrategy(v string) bool {
+	validStrategies := []string{"FIFO", "LIFO", "FEFO"}
+	for _, valid := range validStrategies {
+		if v == valid {
+			return true
+		}
+	}
+	return false
+}
+
 type CreateWarehouseRequest struct {
 	Name            string          `json:"name"`
 	Code            string          `json:"code"`
 	Location        string          `json:"location"`
 	Description     string          `json:"description"`
 	Valuation       string          `json:"valuation"`
+	Allocation      string          `json:"allocation_strategy"`
 	ParentWarehouse *int32          `json:"parent_warehouse"`
 	Capacity        float64         `json:"capacity"`
 	Meta            json.RawMessage `json:"meta"`
@@ -49,6 +62,7 @@ type UpdateWarehouseRequest struct {
 	Location        *string         `json:"location,omitempty"`
 	Description     *string         `json:"description,omitempty"`
 	Valuation       *string         `json:"valuation,omitempty"`
+	Allocation      *string         `json:"allocation_strategy,omitempty"`
 	ParentWarehouse *int32          `json:"parent_warehouse,omitempty"`
 	Capacity        *float64        `json:"capacity,omitempty"`
 	Meta            json.RawMessage `json:"meta,omitempty"`
@@ -72,6 +86,11 @@ func (wh *WarehouseHandler) CreateWarehouse(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if req.Allocation != "" && !isValidAllocationStrategy(req.Allocation) {
+		config.RespondBadRequest(w, "Invalid allocation strategy", "Allocation strategy must be one of: FIFO, LIFO, FEFO")
+		return
+	}
+
 	// Check for duplicate code
 	_, err := wh.h.Queries.GetWarehouseByCode(context.Background(), req.Code)
 	if err == nil {
@@ -87,8 +106,9 @@ func (wh *WarehouseHandler) CreateWarehouse(w http.ResponseWriter, r *http.Reque
 	}
 
 	params := db.CreateWarehouseParams{
-		Name: req.Name,
-		Code: req.Code,
+		Name:               req.Name,
+		Code:               req.Code,
+		AllocationStrategy: db.AllocationStrategyFIFO,
 	}
 
 	if req.Location != "" {
@@ -100,6 +120,9 @@ func (wh *WarehouseHandler) CreateWarehouse(w http.ResponseWriter, r *http.Reque
 	if req.Valuation != "" {
 		params.Valuation = db.ValuationMethod(req.Valuation)
 	}
+	if req.Allocation != "" {
+		params.AllocationStrategy = db.AllocationStrategy(req.Allocation)
+	}
 	if req.ParentWarehouse != nil {
 		params.ParentWarehouse = pgtype.Int4{Int32: *req.ParentWarehouse, Valid: true}
 	}
@@ -170,6 +193,12 @@ func (wh *WarehouseHandler) UpdateWarehouse(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	// Validate allocation strategy if provided
+	if req.Allocation != nil && *req.Allocation != "" && !isValidAllocationStrategy(*req.Allocation) {
+		config.RespondBadRequest(w, "Invalid allocation strategy", "Allocation strategy must be one of: FIFO, LIFO, FEFO")
+		return
+	}
+
 	// Get current warehouse
 	current, err := wh.h.Queries.GetWarehouseByID(context.Background(), id)
 	if err != nil {
@@ -196,7 +225,8 @@ func (wh *WarehouseHandler) UpdateWarehouse(w http.ResponseWriter, r *http.Reque
 	}
 
 	params := db.UpdateWarehouseParams{
-		ID: id,
+		ID:                 id,
+		AllocationStrategy: current.AllocationStrategy,
 	}
 
 	// Handle name and code (interface{} due to NULLIF)
@@ -222,6 +252,9 @@ func (wh *WarehouseHandler) UpdateWarehouse(w http.ResponseWriter, r *http.Reque
 	if req.Valuation != nil {
 		params.Valuation = db.ValuationMethod(*req.Valuation)
 	}
+	if req.Allocation != nil && *req.Allocation != "" {
+		params.AllocationStrategy = db.AllocationStrategy(*req.Allocation)
+	}
 	if req.ParentWarehouse != nil {
 		params.ParentWarehouse = pgtype.Int4{Int32: *req.ParentWarehouse, Valid: true}
 	}
@@ -0,0 +1,9 @@
+package middlewares
+
+import "context"
+
+// ContextWithSession returns ctx carrying session the way SessionAuth stores
+// it, so tests can call handlers without a session store
+func ContextWithSession(ctx context.Context, session *UserSession) context.Context {
+	return context.WithValue(ctx, sessionUserKey, session)
+}
@@ -0,0 +1,192 @@
+package middlewares_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"warehouse_system/internal/config"
+	db "warehouse_system/internal/database/db"
+	"warehouse_system/internal/handlers"
+	"warehouse_system/internal/handlers/transactions"
+	"warehouse_system/internal/middlewares"
+)
+
+// setupTestDB connects to the database of TEST_DATABASE_URL, which must have
+// every migration applied. The pool traces conflicts for TxRetry like the
+// server's pool does. Without it the tests skip, except under CI where they
+// fail so the oversell guarantee is never left unchecked.
+func setupTestDB(t *testing.T) (*pgxpool.Pool, *db.Queries) {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		if os.Getenv("CI") != "" {
+			t.Fatal("TEST_DATABASE_URL must be set in CI")
+		}
+		return nil, nil
+	}
+
+	poolConfig, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatalf("Failed to parse TEST_DATABASE_URL: %v", err)
+	}
+	poolConfig.ConnConfig.Tracer = middlewares.TxConflictTracer{}
+	poolConfig.MaxConns = 20
+
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	return pool, db.New(pool)
+}
+
+// saleFixture is one batch of stock and the sales orders competing for it
+type saleFixture struct {
+	userID      int32
+	materialID  int32
+	warehouseID int32
+	batchID     int32
+	orderIDs    []int32
+}
+
+// createSaleFixture creates a material with one batch of stock units in a
+// new warehouse and one open sales order per order, each for one unit
+func createSaleFixture(t *testing.T, pool *pgxpool.Pool, stock float64, orders int) saleFixture {
+	t.Helper()
+	ctx := context.Background()
+	suffix := strconv.FormatInt(time.Now().UnixNano(), 36)
+	var f saleFixture
+
+	create := func(what, sql string, dest *int32, args ...any) {
+		if err := pool.QueryRow(ctx, sql, args...).Scan(dest); err != nil {
+			t.Fatalf("Failed to create %s: %v", what, err)
+		}
+	}
+	name := "SALE-TEST-" + suffix
+
+	create("user", `INSERT INTO users (username, email, password_hash) VALUES ($1, $2, 'x') RETURNING id`,
+		&f.userID, name, name+"@example.com")
+	create("material", `INSERT INTO materials (name, type, code, sku) VALUES ($1, 'finished', $1, $1) RETURNING id`,
+		&f.materialID, name)
+	create("warehouse", `INSERT INTO warehouses (name, code) VALUES ($1, $1) RETURNING id`,
+		&f.warehouseID, name)
+	create("batch", `INSERT INTO batches (material_id, warehouse_id, batch_number, unit_price, start_quantity, current_quantity)
+		VALUES ($1, $2, $3, 2.5, $4, $4) RETURNING id`,
+		&f.batchID, f.materialID, f.warehouseID, name, stock)
+
+	var customerID int32
+	create("customer", `INSERT INTO customers (name) VALUES ($1) RETURNING id`, &customerID, name)
+
+	for i := 0; i < orders; i++ {
+		var orderID int32
+		create("sales order", `INSERT INTO sales_orders (order_number, customer_id, total_amount, created_by) VALUES ($1, $2, 2.5, $3) RETURNING id`,
+			&orderID, fmt.Sprintf("%s-%d", name, i), customerID, f.userID)
+		if _, err := pool.Exec(ctx,
+			`INSERT INTO sales_order_items (sales_order_id, material_id, quantity, unit_price, total_price) VALUES ($1, $2, 1, 2.5, 2.5)`,
+			orderID, f.materialID,
+		); err != nil {
+			t.Fatalf("Failed to create sales order item: %v", err)
+		}
+		f.orderIDs = append(f.orderIDs, orderID)
+	}
+	return f
+}
+
+// TestSale_ParallelSalesDoNotOversell ships more orders than one batch can
+// cover at the same time. Exactly the stock of the batch is shipped, every
+// other sale is refused and the batch never goes negative.
+func TestSale_ParallelSalesDoNotOversell(t *testing.T) {
+	pool, queries := setupTestDB(t)
+	if pool == nil || queries == nil {
+		t.Skip("Test database not configured")
+	}
+	defer pool.Close()
+
+	const stock = 10
+	const orders = 25
+	f := createSaleFixture(t, pool, stock, orders)
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	h := handlers.NewHandler(queries, nil, logger, pool, &config.Config{})
+	th := transactions.NewTransactionHandler(h)
+	sale := middlewares.TxRetry(&middlewares.TxRetryConfig{Logger: logger})(http.HandlerFunc(th.Sale))
+	session := &middlewares.UserSession{UserID: strconv.Itoa(int(f.userID))}
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	codes := make([]int, orders)
+	bodies := make([]string, orders)
+	for i, orderID := range f.orderIDs {
+		wg.Add(1)
+		go func(i int, orderID int32) {
+			defer wg.Done()
+			body, _ := json.Marshal(transactions.SaleRequest{
+				SalesOrderID: orderID,
+				WarehouseID:  f.warehouseID,
+				MaterialID:   f.materialID,
+				Quantity:     1,
+			})
+			req := httptest.NewRequest(http.MethodPost, "/transactions/sale", bytes.NewReader(body))
+			req = req.WithContext(middlewares.ContextWithSession(req.Context(), session))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			<-start
+			sale.ServeHTTP(w, req)
+			codes[i] = w.Code
+			bodies[i] = w.Body.String()
+		}(i, orderID)
+	}
+	close(start)
+	wg.Wait()
+
+	shipped := 0
+	for i, code := range codes {
+		switch code {
+		case http.StatusCreated:
+			shipped++
+		case http.StatusBadRequest, http.StatusConflict:
+			// Refused for lack of stock
+		default:
+			t.Errorf("Sale %d: unexpected status %d: %s", i, code, bodies[i])
+		}
+	}
+	if shipped != stock {
+		t.Errorf("Expected %d sales to ship, got %d", stock, shipped)
+	}
+
+	ctx := context.Background()
+	var current float64
+	if err := pool.QueryRow(ctx, `SELECT current_quantity::float8 FROM batches WHERE id = $1`, f.batchID).Scan(&current); err != nil {
+		t.Fatalf("Failed to read batch: %v", err)
+	}
+	if current < 0 {
+		t.Errorf("Batch went negative: %.4f", current)
+	}
+	if current != stock-float64(shipped) {
+		t.Errorf("Expected %.0f left in the batch, got %.4f", stock-float64(shipped), current)
+	}
+
+	var moved float64
+	if err := pool.QueryRow(ctx,
+		`SELECT COALESCE(SUM(quantity), 0)::float8 FROM stock_movements WHERE material_id = $1 AND movement_type = 'SALE'`,
+		f.materialID,
+	).Scan(&moved); err != nil {
+		t.Fatalf("Failed to read sale movements: %v", err)
+	}
+	if moved != float64(shipped) {
+		t.Errorf("Expected %d units in SALE movements, got %.4f", shipped, moved)
+	}
+}
@@ -0,0 +1,240 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// TxRetryConfig holds configuration for the transaction retry middleware
+type TxRetryConfig struct {
+	// Logger for structured logging (optional, uses slog.Default if nil)
+	Logger *slog.Logger
+
+	// MaxAttempts is the number of times a request is run before the
+	// last response is returned as is
+	// Default: 3
+	MaxAttempts int
+
+	// Backoff is the delay before the first retry, doubled for each
+	// following retry
+	// Default: 20 milliseconds
+	Backoff time.Duration
+}
+
+// DefaultTxRetryConfig returns a default transaction retry configuration
+func DefaultTxRetryConfig() *TxRetryConfig {
+	return &TxRetryConfig{
+		Logger:      nil, // Will use slog.Default()
+		MaxAttempts: 3,
+		Backoff:     20 * time.Millisecond,
+	}
+}
+
+// txAttemptKey is the context key of the txAttempt of a request
+type txAttemptKey struct{}
+
+// txAttempt records what the database did during one run of a request
+type txAttempt struct {
+	conflict  atomic.Bool // a statement failed with a conflict
+	committed atomic.Bool // a transaction was committed
+	parent    *txAttempt  // enclosing tracker, told about commits as well
+}
+
+// currentTxAttempt returns the txAttempt of the request of ctx, if any
+func currentTxAttempt(ctx context.Context) *txAttempt {
+	attempt, _ := ctx.Value(txAttemptKey{}).(*txAttempt)
+	return attempt
+}
+
+// IsTxConflict reports whether err is a serialization failure (40001) or a
+// deadlock (40P01). PostgreSQL rolls such a transaction back and running it
+// again is expected to succeed.
+func IsTxConflict(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == "40001" || pgErr.Code == "40P01"
+}
+
+// markTxConflict flags the request of ctx for a retry
+func markTxConflict(ctx context.Context) {
+	if attempt, ok := ctx.Value(txAttemptKey{}).(*txAttempt); ok {
+		attempt.conflict.Store(true)
+	}
+}
+
+// markTxCommitted records that the request of ctx committed a transaction,
+// running it again would post its changes twice
+func markTxCommitted(ctx context.Context) {
+	for attempt := currentTxAttempt(ctx); attempt != nil; attempt = attempt.parent {
+		attempt.committed.Store(true)
+	}
+}
+
+// TrackTxCommits returns a context that records the commits of the
+// requests run with it and a function reporting whether one committed.
+// Attempts of a TxRetry run inside it report their commits to it too. The
+// pool must use TxConflictTracer.
+func TrackTxCommits(ctx context.Context) (context.Context, func() bool) {
+	state := &txAttempt{parent: currentTxAttempt(ctx)}
+	return context.WithValue(ctx, txAttemptKey{}, state), state.committed.Load
+}
+
+// TxConflicted reports whether a statement of the request of ctx has failed
+// with a serialization failure or a deadlock. Handlers that catch such an
+// error, e.g. by rolling back to a savepoint, use it to give up on the whole
+// transaction so TxRetry can run the request again.
+func TxConflicted(ctx context.Context) bool {
+	attempt, ok := ctx.Value(txAttemptKey{}).(*txAttempt)
+	return ok && attempt.conflict.Load()
+}
+
+// TxConflictTracer is a pgx query tracer that flags the running request when
+// a statement fails with a serialization failure or a deadlock, and when it
+// commits a transaction. Set it as the tracer of the connection pool so
+// TxRetry can see these without the handlers having to report them.
+type TxConflictTracer struct{}
+
+// TraceQueryStart implements pgx.QueryTracer
+func (TxConflictTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryStartData) context.Context {
+	return ctx
+}
+
+// TraceQueryEnd implements pgx.QueryTracer
+func (TxConflictTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	if IsTxConflict(data.Err) {
+		markTxConflict(ctx)
+	}
+	if data.Err == nil && data.CommandTag.String() == "COMMIT" {
+		markTxCommitted(ctx)
+	}
+}
+
+// bufferedResponse holds the response of one attempt until it is known
+// whether the attempt is kept or retried
+type bufferedResponse struct {
+	header     http.Header
+	body       bytes.Buffer
+	statusCode int
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) WriteHeader(code int) {
+	if b.statusCode == 0 {
+		b.statusCode = code
+	}
+}
+
+func (b *bufferedResponse) Write(data []byte) (int, error) {
+	if b.statusCode == 0 {
+		b.statusCode = http.StatusOK
+	}
+	return b.body.Write(data)
+}
+
+// flush copies the buffered response to the client
+func (b *bufferedResponse) flush(w http.ResponseWriter) {
+	for k, v := range b.header {
+		w.Header()[k] = v
+	}
+	if b.statusCode == 0 {
+		b.statusCode = http.StatusOK
+	}
+	w.WriteHeader(b.statusCode)
+	w.Write(b.body.Bytes())
+}
+
+// TxRetry returns a middleware that runs a request again when its database
+// transaction was aborted by a serialization failure or a deadlock. The
+// request body and the response are buffered so an aborted attempt never
+// reaches the client. An attempt that committed a transaction is never run
+// again, even when another of its statements conflicted. The pool must use
+// TxConflictTracer.
+func TxRetry(config *TxRetryConfig) func(next http.Handler) http.Handler {
+	if config == nil {
+		config = DefaultTxRetryConfig()
+	}
+
+	// Set defaults
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 3
+	}
+	if config.Backoff <= 0 {
+		config.Backoff = 20 * time.Millisecond
+	}
+
+	// Use provided logger or default
+	logger := config.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body []byte
+			if r.Body != nil {
+				var err error
+				body, err = io.ReadAll(r.Body)
+				r.Body.Close()
+				if err != nil {
+					http.Error(w, "Failed to read request body", http.StatusBadRequest)
+					return
+				}
+			}
+
+			backoff := config.Backoff
+			for attempt := 1; ; attempt++ {
+				state := &txAttempt{parent: currentTxAttempt(r.Context())}
+				ctx := context.WithValue(r.Context(), txAttemptKey{}, state)
+
+				req := r.Clone(ctx)
+				req.Body = io.NopCloser(bytes.NewReader(body))
+
+				resp := &bufferedResponse{header: http.Header{}}
+				next.ServeHTTP(resp, req)
+
+				conflict := state.conflict.Load()
+				if !conflict || state.committed.Load() || attempt >= config.MaxAttempts || r.Context().Err() != nil {
+					if conflict {
+						logger.Warn("transaction conflict, giving up",
+							"method", r.Method,
+							"path", r.URL.Path,
+							"attempts", attempt,
+							"committed", state.committed.Load(),
+						)
+					}
+					resp.flush(w)
+					return
+				}
+
+				logger.Info("transaction conflict, retrying request",
+					"method", r.Method,
+					"path", r.URL.Path,
+					"attempt", attempt,
+					"delay", backoff.String(),
+				)
+
+				select {
+				case <-time.After(backoff):
+				case <-r.Context().Done():
+					resp.flush(w)
+					return
+				}
+				backoff *= 2
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// conflict reports a deadlock on the request the way the pool tracer does
+func conflict(r *http.Request) {
+	TxConflictTracer{}.TraceQueryEnd(r.Context(), nil, pgx.TraceQueryEndData{
+		Err: &pgconn.PgError{Code: "40P01"},
+	})
+}
+
+// commit reports a committed transaction on the request
+func commit(r *http.Request) {
+	TxConflictTracer{}.TraceQueryEnd(r.Context(), nil, pgx.TraceQueryEndData{
+		CommandTag: pgconn.NewCommandTag("COMMIT"),
+	})
+}
+
+func TestTxRetry_RetriesConflict(t *testing.T) {
+	attempts := 0
+	handler := TxRetry(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			conflict(r)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		commit(r)
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/transactions/sale", nil))
+
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", attempts)
+	}
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+}
+
+func TestTxRetry_GivesUpAfterMaxAttempts(t *testing.T) {
+	attempts := 0
+	handler := TxRetry(&TxRetryConfig{MaxAttempts: 3})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		conflict(r)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/transactions/sale", nil))
+
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+}
+
+func TestTxRetry_NeverRetriesCommittedRequest(t *testing.T) {
+	attempts := 0
+	handler := TxRetry(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		commit(r)
+		conflict(r)
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/transactions/import/transfer", nil))
+
+	if attempts != 1 {
+		t.Errorf("Expected 1 attempt, got %d", attempts)
+	}
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+}